@@ -0,0 +1,282 @@
+package zxinggo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+)
+
+// FrameResult is a barcode decoded from one frame of an image.
+type FrameResult struct {
+	// Frame is the index of the frame, from 0.
+	Frame  int
+	Result *Result
+}
+
+// DecodeFrames decodes a barcode from every frame of the image read from r,
+// binarized with a binarizer from factory, and returns the result of each
+// frame that holds one, in frame order. Test equipment exports bursts of
+// captures as animated GIF or PNG (APNG) files; each of their frames is
+// composed onto those before it as a viewer shows it. Other images are one
+// frame. It returns ErrNotFound if no frame holds a barcode.
+func DecodeFrames(r io.Reader, factory BinarizerFactory, opts *DecodeOptions) ([]FrameResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	sources, err := readFrames(data)
+	if err != nil {
+		return nil, err
+	}
+	var results []FrameResult
+	for i, source := range sources {
+		result, err := Decode(NewBinaryBitmap(factory.CreateBinarizer(source)), opts)
+		if err == nil {
+			results = append(results, FrameResult{Frame: i, Result: result})
+		}
+	}
+	if len(results) == 0 {
+		return nil, ErrNotFound
+	}
+	return results, nil
+}
+
+// DecodeFileFrames is DecodeFrames for the image file at path.
+func DecodeFileFrames(path string, factory BinarizerFactory, opts *DecodeOptions) ([]FrameResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return DecodeFrames(f, factory, opts)
+}
+
+// readFrames returns the luminance of each frame of an animated GIF or PNG,
+// or of the one frame of any other image.
+func readFrames(data []byte) ([]LuminanceSource, error) {
+	var width, height int
+	var frames []animationFrame
+	switch {
+	case bytes.HasPrefix(data, []byte("GIF8")):
+		g, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		width, height = g.Config.Width, g.Config.Height
+		for i, img := range g.Image {
+			frame := animationFrame{image: img, at: img.Bounds().Min, over: true}
+			if i < len(g.Disposal) {
+				switch g.Disposal[i] {
+				case gif.DisposalBackground:
+					frame.dispose = disposeBackground
+				case gif.DisposalPrevious:
+					frame.dispose = disposePrevious
+				}
+			}
+			frames = append(frames, frame)
+		}
+	case bytes.HasPrefix(data, pngSignature):
+		var err error
+		width, height, frames, err = readAPNG(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if frames == nil {
+		source, err := ReadImageLuminanceSource(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return []LuminanceSource{source}, nil
+	}
+	return composeFrames(width, height, frames), nil
+}
+
+// How a frame is cleared from the canvas before the next is drawn.
+const (
+	disposeNone       = iota // left as it is
+	disposeBackground        // cleared to transparent, which reads as white
+	disposePrevious          // restored to what was there before
+)
+
+// animationFrame is one frame of an animation, to be drawn at at on the
+// canvas.
+type animationFrame struct {
+	image   image.Image
+	at      image.Point
+	over    bool // blend onto the canvas rather than replace it
+	dispose int
+}
+
+// composeFrames draws frames in turn onto a transparent canvas of the given
+// size and returns the luminance of the canvas after each.
+func composeFrames(width, height int, frames []animationFrame) []LuminanceSource {
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	sources := make([]LuminanceSource, 0, len(frames))
+	for _, frame := range frames {
+		rect := frame.image.Bounds().Sub(frame.image.Bounds().Min).Add(frame.at).Intersect(canvas.Bounds())
+		var saved *image.RGBA
+		if frame.dispose == disposePrevious {
+			saved = image.NewRGBA(rect)
+			draw.Draw(saved, rect, canvas, rect.Min, draw.Src)
+		}
+		op := draw.Src
+		if frame.over {
+			op = draw.Over
+		}
+		draw.Draw(canvas, rect, frame.image, frame.image.Bounds().Min, op)
+		sources = append(sources, NewImageLuminanceSource(canvas))
+
+		switch frame.dispose {
+		case disposeBackground:
+			draw.Draw(canvas, rect, image.Transparent, image.Point{}, draw.Src)
+		case disposePrevious:
+			draw.Draw(canvas, rect, saved, rect.Min, draw.Src)
+		}
+	}
+	return sources
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// readAPNG returns the canvas size and frames of an animated PNG, or no
+// frames if the PNG is not animated. Each frame is decoded by image/png as
+// a PNG of its own, made of the frame's data and the chunks, such as the
+// palette, that all frames share.
+func readAPNG(data []byte) (width, height int, frames []animationFrame, err error) {
+	type chunk struct {
+		typ  string
+		data []byte
+	}
+	var chunks []chunk
+	for rest := data[len(pngSignature):]; len(rest) >= 12; {
+		n := binary.BigEndian.Uint32(rest)
+		if uint64(n) > uint64(len(rest)-12) {
+			return 0, 0, nil, fmt.Errorf("%w: truncated PNG chunk", ErrFormat)
+		}
+		chunks = append(chunks, chunk{string(rest[4:8]), rest[8 : 8+n]})
+		rest = rest[12+n:]
+	}
+	if len(chunks) == 0 || chunks[0].typ != "IHDR" || len(chunks[0].data) != 13 {
+		return 0, 0, nil, fmt.Errorf("%w: PNG has no header", ErrFormat)
+	}
+	header := chunks[0].data
+	width, height = int(binary.BigEndian.Uint32(header)), int(binary.BigEndian.Uint32(header[4:]))
+
+	// The chunks before the first frame's data, other than the animation's
+	// own, apply to every frame.
+	var shared []chunk
+	for _, c := range chunks[1:] {
+		if c.typ == "IDAT" || c.typ == "fcTL" {
+			break
+		}
+		if c.typ != "acTL" {
+			shared = append(shared, c)
+		}
+	}
+	animated := false
+	for _, c := range chunks {
+		animated = animated || c.typ == "acTL"
+	}
+	if !animated {
+		return width, height, nil, nil
+	}
+
+	type frameControl struct {
+		width, height, x, y int
+		dispose, blend      byte
+	}
+	var control *frameControl
+	var frameData [][]byte
+	finish := func() error {
+		if control == nil || frameData == nil {
+			return nil
+		}
+		var buf bytes.Buffer
+		buf.Write(pngSignature)
+		frameHeader := bytes.Clone(header)
+		binary.BigEndian.PutUint32(frameHeader, uint32(control.width))
+		binary.BigEndian.PutUint32(frameHeader[4:], uint32(control.height))
+		writePNGChunk(&buf, "IHDR", frameHeader)
+		for _, c := range shared {
+			writePNGChunk(&buf, c.typ, c.data)
+		}
+		for _, d := range frameData {
+			writePNGChunk(&buf, "IDAT", d)
+		}
+		writePNGChunk(&buf, "IEND", nil)
+		img, err := png.Decode(&buf)
+		if err != nil {
+			return err
+		}
+		frame := animationFrame{image: img, at: image.Pt(control.x, control.y), over: control.blend == 1}
+		switch control.dispose {
+		case 1:
+			frame.dispose = disposeBackground
+		case 2:
+			frame.dispose = disposePrevious
+		}
+		// The first frame's previous state is the cleared canvas.
+		if len(frames) == 0 && frame.dispose == disposePrevious {
+			frame.dispose = disposeBackground
+		}
+		frames = append(frames, frame)
+		frameData = nil
+		return nil
+	}
+	for _, c := range chunks {
+		switch c.typ {
+		case "fcTL":
+			if err := finish(); err != nil {
+				return 0, 0, nil, err
+			}
+			if len(c.data) != 26 {
+				return 0, 0, nil, fmt.Errorf("%w: bad APNG frame control", ErrFormat)
+			}
+			control = &frameControl{
+				width:   int(binary.BigEndian.Uint32(c.data[4:])),
+				height:  int(binary.BigEndian.Uint32(c.data[8:])),
+				x:       int(binary.BigEndian.Uint32(c.data[12:])),
+				y:       int(binary.BigEndian.Uint32(c.data[16:])),
+				dispose: c.data[24],
+				blend:   c.data[25],
+			}
+		case "IDAT":
+			// The default image is only a frame if a frame control
+			// precedes it.
+			if control != nil {
+				frameData = append(frameData, c.data)
+			}
+		case "fdAT":
+			if len(c.data) < 4 {
+				return 0, 0, nil, fmt.Errorf("%w: bad APNG frame data", ErrFormat)
+			}
+			frameData = append(frameData, c.data[4:])
+		}
+	}
+	if err := finish(); err != nil {
+		return 0, 0, nil, err
+	}
+	if len(frames) == 0 {
+		return 0, 0, nil, fmt.Errorf("%w: APNG has no frames", ErrFormat)
+	}
+	return width, height, frames, nil
+}
+
+// writePNGChunk writes a PNG chunk of the given type and data to buf.
+func writePNGChunk(buf *bytes.Buffer, typ string, data []byte) {
+	buf.Write(binary.BigEndian.AppendUint32(nil, uint32(len(data))))
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(typ))
+	crc.Write(data)
+	buf.WriteString(typ)
+	buf.Write(data)
+	buf.Write(binary.BigEndian.AppendUint32(nil, crc.Sum32()))
+}
@@ -0,0 +1,155 @@
+package zxinggo_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+)
+
+// animationFrames returns a blank frame, a frame with a QR code of text,
+// and a frame that only marks the corner of the canvas, leaving the code
+// beneath it.
+func animationFrames(t *testing.T, text string) (blank, code, corner *image.Paletted) {
+	t.Helper()
+	matrix, err := zxinggo.Encode(text, zxinggo.FormatQRCode, 200, 200, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds := image.Rect(0, 0, 200, 200)
+	blank = image.NewPaletted(bounds, palette.Plan9)
+	draw.Draw(blank, bounds, image.White, image.Point{}, draw.Src)
+	code = image.NewPaletted(bounds, palette.Plan9)
+	draw.Draw(code, bounds, zxinggo.BitMatrixToImage(matrix), image.Point{}, draw.Src)
+	corner = image.NewPaletted(image.Rect(0, 0, 4, 4), palette.Plan9)
+	draw.Draw(corner, corner.Bounds(), image.White, image.Point{}, draw.Src)
+	return blank, code, corner
+}
+
+func checkFrameResults(t *testing.T, results []zxinggo.FrameResult, text string, frames ...int) {
+	t.Helper()
+	if len(results) != len(frames) {
+		t.Fatalf("got %d frame results, want frames %v", len(results), frames)
+	}
+	for i, r := range results {
+		if r.Frame != frames[i] || r.Result.Text != text {
+			t.Errorf("result %d: frame %d %q, want frame %d %q", i, r.Frame, r.Result.Text, frames[i], text)
+		}
+	}
+}
+
+func TestDecodeFramesGIF(t *testing.T) {
+	blank, code, corner := animationFrames(t, "GIF FRAME")
+	var buf bytes.Buffer
+	err := gif.EncodeAll(&buf, &gif.GIF{
+		Image:    []*image.Paletted{blank, code, corner},
+		Delay:    []int{10, 10, 10},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalNone, gif.DisposalNone},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	results, err := zxinggo.DecodeFrames(bytes.NewReader(data), binarizer.NewHybrid(nil), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFrameResults(t, results, "GIF FRAME", 1, 2)
+
+	// DecodeReader only reads the first frame.
+	if _, err := zxinggo.DecodeReader(bytes.NewReader(data), binarizer.NewHybrid(nil), nil); err == nil {
+		t.Error("DecodeReader read the blank first frame")
+	}
+}
+
+// encodeAPNG encodes frames as an animated PNG, each drawn at the top left
+// of the canvas with no disposal and blended over what is already there.
+// The first frame doubles as the default image.
+func encodeAPNG(t *testing.T, frames ...image.Image) []byte {
+	t.Helper()
+	// idat returns the IHDR and the concatenated IDAT data of img encoded as
+	// a truecolour PNG, so that no frame needs a palette of its own.
+	idat := func(img image.Image) (ihdr, data []byte) {
+		rgba := image.NewNRGBA(img.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, rgba); err != nil {
+			t.Fatal(err)
+		}
+		for rest := buf.Bytes()[8:]; len(rest) >= 12; {
+			n := binary.BigEndian.Uint32(rest)
+			switch string(rest[4:8]) {
+			case "IHDR":
+				ihdr = rest[8 : 8+n]
+			case "IDAT":
+				data = append(data, rest[8:8+n]...)
+			}
+			rest = rest[12+n:]
+		}
+		return ihdr, data
+	}
+
+	var out bytes.Buffer
+	out.WriteString("\x89PNG\r\n\x1a\n")
+	write := func(typ string, data []byte) {
+		out.Write(binary.BigEndian.AppendUint32(nil, uint32(len(data))))
+		out.WriteString(typ)
+		out.Write(data)
+		out.Write(binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(append([]byte(typ), data...))))
+	}
+
+	var sequence uint32
+	for i, frame := range frames {
+		ihdr, data := idat(frame)
+		if i == 0 {
+			write("IHDR", ihdr)
+			write("acTL", binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, uint32(len(frames))), 0))
+		}
+		fc := binary.BigEndian.AppendUint32(nil, sequence)
+		fc = binary.BigEndian.AppendUint32(fc, uint32(frame.Bounds().Dx()))
+		fc = binary.BigEndian.AppendUint32(fc, uint32(frame.Bounds().Dy()))
+		fc = append(fc, make([]byte, 8)...) // x and y offsets
+		fc = append(fc, 0, 1, 0, 10, 0, 1)  // 1/10s delay, no disposal, blend over
+		write("fcTL", fc)
+		sequence++
+		if i == 0 {
+			write("IDAT", data)
+			continue
+		}
+		write("fdAT", append(binary.BigEndian.AppendUint32(nil, sequence), data...))
+		sequence++
+	}
+	write("IEND", nil)
+	return out.Bytes()
+}
+
+func TestDecodeFramesAPNG(t *testing.T) {
+	blank, code, corner := animationFrames(t, "APNG FRAME")
+	results, err := zxinggo.DecodeFrames(bytes.NewReader(encodeAPNG(t, blank, code, corner)), binarizer.NewHybrid(nil), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFrameResults(t, results, "APNG FRAME", 1, 2)
+}
+
+func TestDecodeFramesStill(t *testing.T) {
+	_, code, _ := animationFrames(t, "STILL")
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, code); err != nil {
+		t.Fatal(err)
+	}
+	results, err := zxinggo.DecodeFrames(&buf, binarizer.NewHybrid(nil), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFrameResults(t, results, "STILL", 0)
+}
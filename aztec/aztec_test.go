@@ -1,11 +1,17 @@
 package aztec
 
 import (
+	"errors"
+	"image"
+	"strings"
 	"testing"
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/aztec/decoder"
 	"github.com/ericlevine/zxinggo/aztec/encoder"
+	"github.com/ericlevine/zxinggo/binarizer"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/internal/symbolgen"
 )
 
 func TestAztecEncoderDecoder(t *testing.T) {
@@ -36,7 +42,7 @@ func TestAztecEncoderDecoder(t *testing.T) {
 				NbLayers:     code.Layers,
 			}
 
-			dr, err := decoder.Decode(ddata)
+			dr, err := decoder.Decode(ddata, "")
 			if err != nil {
 				t.Fatalf("decode error for %q: %v", tc.data, err)
 			}
@@ -53,3 +59,237 @@ func TestAztecWriterFormatValidation(t *testing.T) {
 		t.Error("expected error for wrong format on AztecWriter")
 	}
 }
+
+func TestValidateParameters(t *testing.T) {
+	tests := []struct {
+		compact                         bool
+		nbLayers, nbDataBlocks, maxLyrs int
+		ok                              bool
+	}{
+		{true, 4, 64, 0, true},
+		{true, 5, 10, 0, false},
+		{true, 1, 20, 0, false}, // a compact 1-layer symbol holds 17 codewords
+		{false, 32, 1000, 0, true},
+		{false, 33, 10, 0, false},
+		{false, 12, 10, 8, false},
+		{false, 8, 10, 8, true},
+		{false, 0, 1, 0, false},
+	}
+	for _, tt := range tests {
+		err := decoder.ValidateParameters(tt.compact, tt.nbLayers, tt.nbDataBlocks, tt.maxLyrs)
+		if (err == nil) != tt.ok {
+			t.Errorf("ValidateParameters(%v, %d, %d, %d) = %v, want ok=%v",
+				tt.compact, tt.nbLayers, tt.nbDataBlocks, tt.maxLyrs, err, tt.ok)
+		}
+	}
+}
+
+func TestDecodeRejectsUndersizedMatrix(t *testing.T) {
+	code, err := encoder.Encode([]byte("Hello"), 25, 0)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	ddata := &decoder.AztecDetectorResult{
+		Bits:         code.Matrix,
+		Compact:      code.Compact,
+		NbDataBlocks: code.CodeWords,
+		NbLayers:     code.Layers + 1,
+	}
+	if _, err := decoder.Decode(ddata, ""); !errors.Is(err, zxinggo.ErrFormat) {
+		t.Errorf("Decode with too many layers: err = %v, want ErrFormat", err)
+	}
+}
+
+func TestDecodeMatrix(t *testing.T) {
+	for _, data := range []string{"Hello", strings.Repeat("Aztec full range ", 8)} {
+		code, err := encoder.Encode([]byte(data), 25, 0)
+		if err != nil {
+			t.Fatalf("encode error: %v", err)
+		}
+		for rotation := 0; rotation < 4; rotation++ {
+			bits := code.Matrix.Clone()
+			bits.Rotate(90 * rotation)
+			result, err := DecodeMatrix(bits, nil)
+			if err != nil {
+				t.Errorf("DecodeMatrix(%d layers, compact=%v, rotated %d°): %v", code.Layers, code.Compact, 90*rotation, err)
+				continue
+			}
+			if result.Text != data {
+				t.Errorf("got %q, want %q", result.Text, data)
+			}
+		}
+	}
+}
+
+func TestDecodeMatrixCharacterSet(t *testing.T) {
+	code, err := encoder.Encode([]byte("Grüße"), 25, 0)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	for _, tc := range []struct {
+		characterSet string
+		want         string
+	}{
+		{"", "GrÃ¼Ã\u009fe"},
+		{"UTF-8", "Grüße"},
+	} {
+		result, err := DecodeMatrix(code.Matrix, &zxinggo.DecodeOptions{CharacterSet: tc.characterSet})
+		if err != nil {
+			t.Fatalf("CharacterSet %q: DecodeMatrix: %v", tc.characterSet, err)
+		}
+		if result.Text != tc.want {
+			t.Errorf("CharacterSet %q: text = %q, want %q", tc.characterSet, result.Text, tc.want)
+		}
+	}
+}
+
+func TestGS1AndStructuredAppend(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		opts     *encoder.Options
+		want     string
+		modifier string
+		sa       *zxinggo.StructuredAppend
+	}{
+		{"plain", "Hello\x1dWorld", nil, "Hello\x1dWorld", "]z0", nil},
+		{"gs1", "0101234567890128\x1d10ABC", &encoder.Options{GS1: true}, "0101234567890128\x1d10ABC", "]z1", nil},
+		{"structured append", "part two",
+			&encoder.Options{StructuredAppend: &zxinggo.StructuredAppend{Index: 1, Count: 3}},
+			"part two", "]z6", &zxinggo.StructuredAppend{Index: 1, Count: 3}},
+		{"structured append with id", " leading space",
+			&encoder.Options{StructuredAppend: &zxinggo.StructuredAppend{Index: 0, Count: 2, ID: "TICKET42"}},
+			" leading space", "]z6", &zxinggo.StructuredAppend{Index: 0, Count: 2, ID: "TICKET42"}},
+		{"structured append gs1", "0101234567890128",
+			&encoder.Options{GS1: true, StructuredAppend: &zxinggo.StructuredAppend{Index: 25, Count: 26}},
+			"0101234567890128", "]z7", &zxinggo.StructuredAppend{Index: 25, Count: 26}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			code, err := encoder.EncodeWithOptions([]byte(tc.data), 25, 0, tc.opts)
+			if err != nil {
+				t.Fatalf("encode error: %v", err)
+			}
+			result, err := DecodeMatrix(code.Matrix, nil)
+			if err != nil {
+				t.Fatalf("DecodeMatrix: %v", err)
+			}
+			if result.Text != tc.want {
+				t.Errorf("text = %q, want %q", result.Text, tc.want)
+			}
+			if got := result.Metadata[zxinggo.MetadataSymbologyIdentifier]; got != tc.modifier {
+				t.Errorf("symbology identifier = %v, want %s", got, tc.modifier)
+			}
+			sa, _ := result.Metadata[zxinggo.MetadataStructuredAppend].(*zxinggo.StructuredAppend)
+			if (sa == nil) != (tc.sa == nil) || sa != nil && *sa != *tc.sa {
+				t.Errorf("structured append = %+v, want %+v", sa, tc.sa)
+			}
+		})
+	}
+}
+
+func TestStructuredAppendValidation(t *testing.T) {
+	for _, sa := range []*zxinggo.StructuredAppend{
+		{Index: 0, Count: 1},
+		{Index: 2, Count: 2},
+		{Index: 0, Count: 27},
+		{Index: 0, Count: 2, ID: "has space"},
+	} {
+		if _, err := encoder.EncodeWithOptions([]byte("x"), 25, 0, &encoder.Options{StructuredAppend: sa}); err == nil {
+			t.Errorf("EncodeWithOptions with %+v: expected error", sa)
+		}
+	}
+}
+
+// TestDecodeGeneratedSymbols decodes symbols built module by module, without
+// the encoder, with every layer count.
+func TestDecodeGeneratedSymbols(t *testing.T) {
+	for _, compact := range []bool{true, false} {
+		maxLayers := 32
+		if compact {
+			maxLayers = 4
+		}
+		for layers := 1; layers <= maxLayers; layers++ {
+			seed := int64(layers)
+			symbol := symbolgen.Aztec(seed, compact, layers)
+			result, err := decoder.Decode(&decoder.AztecDetectorResult{
+				Bits:         symbol.Bits,
+				Compact:      symbol.Compact,
+				NbLayers:     symbol.Layers,
+				NbDataBlocks: symbol.DataBlocks,
+			}, "")
+			if err != nil {
+				t.Errorf("seed %d, compact %v, %d layers: %v", seed, compact, layers, err)
+				continue
+			}
+			if result.Text != symbol.Text {
+				t.Errorf("seed %d, compact %v, %d layers: decoded %q, want %q", seed, compact, layers, result.Text, symbol.Text)
+			}
+		}
+	}
+}
+
+// renderAt draws bits, mirrored left to right if mirror is set, at x0, y0 in
+// a white image size pixels square, each module 4 pixels wide.
+func renderAt(bits *bitutil.BitMatrix, size, x0, y0 int, mirror bool) *zxinggo.BinaryBitmap {
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	n := bits.Width()
+	for y := 0; y < 4*n; y++ {
+		for x := 0; x < 4*n; x++ {
+			mx := x / 4
+			if mirror {
+				mx = n - 1 - mx
+			}
+			if bits.Get(mx, y/4) {
+				img.Pix[(y0+y)*size+x0+x] = 0
+			}
+		}
+	}
+	return zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(zxinggo.NewGrayImageLuminanceSource(img)))
+}
+
+func TestReaderMirror(t *testing.T) {
+	symbol := symbolgen.Aztec(1, false, 3)
+	size := 4*symbol.Bits.Width() + 40
+	image := renderAt(symbol.Bits, size, 20, 20, true)
+	matrix, err := image.BlackMatrix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decode(matrix, false, [2]int{size / 2, size / 2}, nil); err == nil {
+		t.Error("mirrored symbol decoded as it is")
+	}
+	result, err := NewReader().Decode(image, nil)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if result.Text != symbol.Text {
+		t.Errorf("text = %q, want %q", result.Text, symbol.Text)
+	}
+}
+
+func TestReaderQuarters(t *testing.T) {
+	// A symbol in the corner of a large image is too far from the centre to
+	// be found from there.
+	symbol := symbolgen.Aztec(1, false, 3)
+	tests := []struct {
+		opts *zxinggo.DecodeOptions
+		ok   bool
+	}{
+		{nil, false},
+		{&zxinggo.DecodeOptions{TryHarder: true}, true},
+		{&zxinggo.DecodeOptions{TryHarder: true, DisableDetectors: zxinggo.DetectorAztecQuarters}, false},
+	}
+	for _, tc := range tests {
+		result, err := NewReader().Decode(renderAt(symbol.Bits, 400, 10, 10, false), tc.opts)
+		if tc.ok && (err != nil || result.Text != symbol.Text) {
+			t.Errorf("%+v: result %v, error %v", tc.opts, result, err)
+		}
+		if !tc.ok && err == nil {
+			t.Errorf("%+v: decoded", tc.opts)
+		}
+	}
+}
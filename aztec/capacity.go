@@ -0,0 +1,44 @@
+package aztec
+
+import (
+	"fmt"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/aztec/encoder"
+)
+
+// capacityPlanner sizes Aztec symbols as Writer encodes them, with at least
+// a third of each symbol given to error correction. The error correction
+// level must be empty.
+type capacityPlanner struct{}
+
+// Fits reports whether payload encodes in a symbol with the given number of
+// layers, 1 to 32, or -1 to -4 for a compact symbol.
+func (capacityPlanner) Fits(payload string, version int, ecLevel string) (bool, error) {
+	if ecLevel != "" {
+		return false, fmt.Errorf("%w: Aztec has no error correction level %q", zxinggo.ErrWriter, ecLevel)
+	}
+	if version == 0 || version < -4 || version > 32 {
+		return false, fmt.Errorf("%w: no Aztec symbol with %d layers", zxinggo.ErrWriter, version)
+	}
+	_, err := encoder.Encode([]byte(payload), writerECCPercent, version)
+	return err == nil, nil
+}
+
+// SmallestSymbolFor returns the size Writer would encode payload in.
+func (capacityPlanner) SmallestSymbolFor(payload string, opts *zxinggo.EncodeOptions) (zxinggo.SymbolSize, error) {
+	code, err := encoder.EncodeWithOptions([]byte(payload), writerECCPercent, 0, encoderOptions(opts))
+	if err != nil {
+		return zxinggo.SymbolSize{}, fmt.Errorf("%w: %v", zxinggo.ErrWriter, err)
+	}
+	version := code.Layers
+	if code.Compact {
+		version = -version
+	}
+	return zxinggo.SymbolSize{
+		Format:  zxinggo.FormatAztec,
+		Version: version,
+		Width:   code.Size,
+		Height:  code.Size,
+	}, nil
+}
@@ -12,11 +12,13 @@
 package decoder
 
 import (
+	"fmt"
 	"strings"
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
 	"github.com/ericlevine/zxinggo/charset"
+	"github.com/ericlevine/zxinggo/internal"
 	"github.com/ericlevine/zxinggo/reedsolomon"
 )
 
@@ -35,12 +37,10 @@ type AztecDetectorResult struct {
 	NbLayers     int
 }
 
-// DecoderResult holds the final decoded text and raw bytes.
-type DecoderResult struct {
-	Text            string
-	RawBytes        []byte
-	ErrorsCorrected int
-}
+// DecoderResult holds the final decoded text and raw bytes. StructuredAppend
+// is set if the symbol starts with a structured append header, which is
+// removed from Text.
+type DecoderResult = internal.DecodedPayload
 
 // ---------------------------------------------------------------------------
 // Encoding-mode constants (matching Java ZXing's Table enum)
@@ -89,7 +89,16 @@ var digitTable = [16]string{
 // ---------------------------------------------------------------------------
 
 // Decode decodes an Aztec symbol described by the given detector result.
-func Decode(detectorResult *AztecDetectorResult) (*DecoderResult, error) {
+// Binary data before any ECI is read in characterSet, or ISO-8859-1 if it is
+// empty.
+func Decode(detectorResult *AztecDetectorResult, characterSet string) (*DecoderResult, error) {
+	if err := ValidateParameters(detectorResult.Compact, detectorResult.NbLayers, detectorResult.NbDataBlocks, 0); err != nil {
+		return nil, err
+	}
+	if dim := Dimension(detectorResult.Compact, detectorResult.NbLayers); detectorResult.Bits == nil ||
+		detectorResult.Bits.Width() < dim || detectorResult.Bits.Height() < dim {
+		return nil, zxinggo.ErrFormat
+	}
 	rawbits := extractBits(detectorResult)
 
 	correctedBits, errorsCorrected, err := correctBits(detectorResult, rawbits)
@@ -97,18 +106,88 @@ func Decode(detectorResult *AztecDetectorResult) (*DecoderResult, error) {
 		return nil, err
 	}
 
-	text, rawBytes, err := getEncodedData(correctedBits)
+	data, err := getEncodedData(correctedBits, characterSet)
 	if err != nil {
 		return nil, err
 	}
 
-	return &DecoderResult{
-		Text:            text,
-		RawBytes:        rawBytes,
-		ErrorsCorrected: errorsCorrected,
-	}, nil
+	result := internal.NewDecodedPayload([]byte(data.text), "", nil, "")
+	result.ErrorsCorrected = errorsCorrected
+	text := data.text
+	start := 0
+	if hasStructuredAppendHeader(correctedBits) {
+		result.StructuredAppend, start = parseStructuredAppend(text)
+	}
+	// FLG(0) first, or after a single letter or two digits, flags GS1 or
+	// AIM application data rather than separating fields, so is dropped.
+	for _, pos := range data.fnc1 {
+		if pos < start {
+			continue
+		}
+		if lead := text[start:pos]; lead == "" {
+			result.SymbologyModifier = 1
+		} else if isApplicationIndicator(lead) {
+			result.SymbologyModifier = 2
+		} else {
+			break
+		}
+		text = text[:pos] + text[pos+1:]
+		break
+	}
+	if data.eci {
+		result.SymbologyModifier += 3
+	}
+	if result.StructuredAppend != nil {
+		result.SymbologyModifier += 6
+	}
+	result.Text = text[start:]
+	return result, nil
+}
+
+// hasStructuredAppendHeader reports whether the bit stream starts with the
+// otherwise pointless M/L U/L latch pair that introduces a structured append
+// header.
+func hasStructuredAppendHeader(bits []bool) bool {
+	return len(bits) > 20 && readCodeJava(bits, 0, 5) == 29 && readCodeJava(bits, 5, 5) == 29
 }
 
+// parseStructuredAppend reads the structured append header at the start of
+// text: an optional message ID between spaces, then the symbol's position
+// and the symbol count as letters A-Z. It returns the header and its length,
+// or nil and 0 if text does not start with a valid header.
+func parseStructuredAppend(text string) (*zxinggo.StructuredAppend, int) {
+	id, i := "", 0
+	if strings.HasPrefix(text, " ") {
+		end := strings.IndexByte(text[1:], ' ')
+		if end < 0 {
+			return nil, 0
+		}
+		id, i = text[1:end+1], end+2
+	}
+	if i+1 >= len(text) || !isUpper(text[i]) || !isUpper(text[i+1]) {
+		return nil, 0
+	}
+	sa := &zxinggo.StructuredAppend{Index: int(text[i] - 'A'), Count: int(text[i+1]-'A') + 1, ID: id}
+	if sa.Count < 2 || sa.Index >= sa.Count {
+		return nil, 0
+	}
+	return sa, i + 2
+}
+
+// isApplicationIndicator reports whether s is an AIM application indicator,
+// a single letter or two digits, which FLG(0) may follow as in Code 128.
+func isApplicationIndicator(s string) bool {
+	switch len(s) {
+	case 1:
+		return isUpper(s[0]) || ('a' <= s[0] && s[0] <= 'z')
+	case 2:
+		return '0' <= s[0] && s[0] <= '9' && '0' <= s[1] && s[1] <= '9'
+	}
+	return false
+}
+
+func isUpper(c byte) bool { return 'A' <= c && c <= 'Z' }
+
 // ---------------------------------------------------------------------------
 // Reed-Solomon error correction
 // ---------------------------------------------------------------------------
@@ -127,6 +206,43 @@ func codewordSize(nbLayers int) int {
 	return 12
 }
 
+// Physical layer limits for compact and full-range symbols.
+const (
+	MaxCompactLayers = 4
+	MaxFullLayers    = 32
+)
+
+// ValidateParameters checks the layer and data block counts read from a mode
+// message against the physical limits of the symbol, so that a misread mode
+// message is rejected before the grid is sampled. maxLayers, if positive,
+// further caps the accepted number of layers.
+func ValidateParameters(compact bool, nbLayers, nbDataBlocks, maxLayers int) error {
+	limit := MaxFullLayers
+	if compact {
+		limit = MaxCompactLayers
+	}
+	if maxLayers > 0 && maxLayers < limit {
+		limit = maxLayers
+	}
+	if nbLayers < 1 || nbLayers > limit || nbDataBlocks < 1 {
+		return zxinggo.ErrFormat
+	}
+	numCodewords := totalBitsInLayer(nbLayers, compact) / codewordSize(nbLayers)
+	if nbDataBlocks > numCodewords {
+		return zxinggo.ErrFormat
+	}
+	return nil
+}
+
+// Dimension returns the width, in modules, of a symbol with the given number
+// of layers.
+func Dimension(compact bool, nbLayers int) int {
+	if compact {
+		return 4*nbLayers + 11
+	}
+	return 4*nbLayers + 2*((2*nbLayers+6)/15) + 15
+}
+
 func totalBitsInLayer(layers int, compact bool) int {
 	base := 112
 	if compact {
@@ -269,11 +385,24 @@ func getCharacter(table, code int) string {
 	}
 }
 
+// encodedData is the text of a symbol's bit stream.
+type encodedData struct {
+	text string
+	fnc1 []int // byte offsets in text of the GS written for each FLG(0)
+	eci  bool  // whether the stream contains an ECI
+}
+
 // getEncodedData decodes the corrected data-bit stream into text using the
-// Aztec five-mode encoding scheme. This is a faithful port of Java ZXing
+// Aztec five-mode encoding scheme. This follows Java ZXing
 // Decoder.getEncodedData, including the shiftTable/latchTable architecture,
 // byte accumulation buffer, and ISO-8859-1 default encoding.
-func getEncodedData(correctedBits []bool) (string, []byte, error) {
+//
+// A stream that ends partway through a character code, or through the
+// header of a binary shift or FLG(n), ends there, as the padding filling
+// the last codeword does, and the text read so far is returned. One that
+// ends partway through the bytes of a binary shift or the digits of an ECI
+// promised data it does not hold, and is rejected.
+func getEncodedData(correctedBits []bool, characterSet string) (*encodedData, error) {
 	endIndex := len(correctedBits)
 	latchTable := tableUpper // table most recently latched to
 	shiftTable := tableUpper // table to use for the next read
@@ -283,106 +412,113 @@ func getEncodedData(correctedBits []bool) (string, []byte, error) {
 	// Intermediary buffer of decoded bytes, decoded into a string and flushed
 	// when character encoding changes (ECI) or input ends.
 	var decodedBytes []byte
-	var encoding string // empty means ISO-8859-1 (default)
+	encoding := characterSet // empty means ISO-8859-1 (default)
+	data := &encodedData{}
 
 	index := 0
-	for index < endIndex {
+	// read returns the next n bits as a code, or false if fewer remain.
+	read := func(n int) (int, bool) {
+		if endIndex-index < n {
+			return 0, false
+		}
+		code := readCodeJava(correctedBits, index, n)
+		index += n
+		return code, true
+	}
+
+decode:
+	for {
 		if shiftTable == tableBinary {
-			if endIndex-index < 5 {
+			length, ok := read(5)
+			if !ok {
 				break
 			}
-			length := readCodeJava(correctedBits, index, 5)
-			index += 5
 			if length == 0 {
-				if endIndex-index < 11 {
+				if length, ok = read(11); !ok {
 					break
 				}
-				length = readCodeJava(correctedBits, index, 11) + 31
-				index += 11
+				length += 31
 			}
-			for charCount := 0; charCount < length; charCount++ {
-				if endIndex-index < 8 {
-					index = endIndex // Force outer loop to exit
-					break
+			for range length {
+				code, ok := read(8)
+				if !ok {
+					return nil, fmt.Errorf("%w: binary shift of %d bytes cut short", zxinggo.ErrFormat, length)
 				}
-				code := readCodeJava(correctedBits, index, 8)
 				decodedBytes = append(decodedBytes, byte(code))
-				index += 8
 			}
 			// Go back to whatever mode we had been in
 			shiftTable = latchTable
-		} else {
-			size := 5
-			if shiftTable == tableDigit {
-				size = 4
-			}
-			if endIndex-index < size {
-				break
+			continue
+		}
+
+		size := 5
+		if shiftTable == tableDigit {
+			size = 4
+		}
+		code, ok := read(size)
+		if !ok {
+			break
+		}
+		str := getCharacter(shiftTable, code)
+		switch {
+		case str == "FLG(n)":
+			n, ok := read(3)
+			if !ok {
+				break decode
 			}
-			code := readCodeJava(correctedBits, index, size)
-			index += size
-			str := getCharacter(shiftTable, code)
-			if str == "FLG(n)" {
-				if endIndex-index < 3 {
-					break
-				}
-				n := readCodeJava(correctedBits, index, 3)
-				index += 3
-				// Flush bytes, FLG changes state
-				result.WriteString(encodeBytes(decodedBytes, encoding))
-				decodedBytes = decodedBytes[:0]
-				switch n {
-				case 0:
-					result.WriteByte(29) // FNC1 as ASCII 29
-				case 7:
-					return "", nil, zxinggo.ErrFormat // FLG(7) is reserved and illegal
-				default:
-					// ECI is decimal integer encoded as 1-6 codes in DIGIT mode
-					eci := 0
-					if endIndex-index < 4*n {
-						break
-					}
-					for n > 0 {
-						nextDigit := readCodeJava(correctedBits, index, 4)
-						index += 4
-						if nextDigit < 2 || nextDigit > 11 {
-							return "", nil, zxinggo.ErrFormat // Not a decimal digit
-						}
-						eci = eci*10 + (nextDigit - 2)
-						n--
+			// Flush bytes, FLG changes state
+			result.WriteString(encodeBytes(decodedBytes, encoding))
+			decodedBytes = decodedBytes[:0]
+			switch n {
+			case 0:
+				data.fnc1 = append(data.fnc1, result.Len())
+				result.WriteByte(29) // FNC1 as ASCII 29
+			case 7:
+				return nil, zxinggo.ErrFormat // FLG(7) is reserved and illegal
+			default:
+				// ECI is decimal integer encoded as 1-6 codes in DIGIT mode
+				eci := 0
+				for range n {
+					digit, ok := read(4)
+					if !ok {
+						return nil, fmt.Errorf("%w: ECI of %d digits cut short", zxinggo.ErrFormat, n)
 					}
-					eciObj, err := charset.GetECIByValue(eci)
-					if err != nil || eciObj == nil {
-						return "", nil, zxinggo.ErrFormat
+					if digit < 2 || digit > 11 {
+						return nil, zxinggo.ErrFormat // Not a decimal digit
 					}
-					encoding = eciObj.GoName
+					eci = eci*10 + (digit - 2)
 				}
-				// Go back to whatever mode we had been in
-				shiftTable = latchTable
-			} else if len(str) > 5 && str[:5] == "CTRL_" {
-				// Table changes
-				// ISO/IEC 24778:2008 prescribes ending a shift sequence in the
-				// mode from which it was invoked. That's including when that mode
-				// is a shift.
-				latchTable = shiftTable
-				shiftTable = getTable(str[5])
-				if str[6] == 'L' {
-					latchTable = shiftTable
+				eciObj, err := charset.GetECIByValue(eci)
+				if err != nil || eciObj == nil {
+					return nil, zxinggo.ErrFormat
 				}
-			} else {
-				// Though stored as a table of strings for convenience, codes
-				// actually represent 1 or 2 *bytes*.
-				decodedBytes = append(decodedBytes, str...)
-				// Go back to whatever mode we had been in
-				shiftTable = latchTable
+				encoding = eciObj.GoName
+				data.eci = true
 			}
+			// Go back to whatever mode we had been in
+			shiftTable = latchTable
+		case strings.HasPrefix(str, "CTRL_"):
+			// Table changes
+			// ISO/IEC 24778:2008 prescribes ending a shift sequence in the
+			// mode from which it was invoked. That's including when that mode
+			// is a shift.
+			latchTable = shiftTable
+			shiftTable = getTable(str[5])
+			if str[6] == 'L' {
+				latchTable = shiftTable
+			}
+		default:
+			// Though stored as a table of strings for convenience, codes
+			// actually represent 1 or 2 *bytes*.
+			decodedBytes = append(decodedBytes, str...)
+			// Go back to whatever mode we had been in
+			shiftTable = latchTable
 		}
 	}
 	result.WriteString(encodeBytes(decodedBytes, encoding))
 
-	text := result.String()
-	rawBytes := []byte(text)
-	return text, rawBytes, nil
+	data.text = result.String()
+	return data, nil
 }
 
 // encodeBytes converts a byte buffer to a string using the given encoding.
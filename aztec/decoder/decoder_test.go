@@ -0,0 +1,70 @@
+package decoder
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+)
+
+// codeBits returns the bits of a stream given as pairs of code value and
+// width.
+func codeBits(codes ...int) []bool {
+	var bits []bool
+	for i := 0; i < len(codes); i += 2 {
+		value, width := codes[i], codes[i+1]
+		for b := width - 1; b >= 0; b-- {
+			bits = append(bits, value>>b&1 == 1)
+		}
+	}
+	return bits
+}
+
+func TestGetEncodedDataTruncated(t *testing.T) {
+	bits := codeBits(
+		2, 5, // A
+		0, 5, 0, 5, 2, 3, 4, 4, 8, 4, // P/S FLG(2) ECI 26 (UTF-8)
+		31, 5, 2, 5, 0xC3, 8, 0xA9, 8, // B/S 2 bytes: é
+		3, 5, // B
+		0, 5, 0, 5, 0, 3, // P/S FLG(0)
+		30, 5, 3, 4, 4, 4, // D/L 1 2
+	)
+	// Where the ECI digits and the binary shift bytes lie.
+	const eciStart, eciEnd = 18, 26
+	const bytesStart, bytesEnd = 36, 52
+	const want = "Aé" + "B\x1d12"
+
+	full, err := getEncodedData(bits, "")
+	if err != nil {
+		t.Fatalf("full stream: %v", err)
+	}
+	if full.text != want || !full.eci || len(full.fnc1) != 1 {
+		t.Fatalf("full stream = %+v, want %q with ECI and one FNC1", full, want)
+	}
+
+	for n := range bits {
+		data, err := getEncodedData(bits[:n], "")
+		cutShort := eciStart <= n && n < eciEnd || bytesStart <= n && n < bytesEnd
+		if cutShort {
+			if !errors.Is(err, zxinggo.ErrFormat) {
+				t.Errorf("%d bits: got %v, want ErrFormat", n, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d bits: %v", n, err)
+			continue
+		}
+		if !strings.HasPrefix(want, data.text) {
+			t.Errorf("%d bits: text %q is not a prefix of %q", n, data.text, want)
+		}
+	}
+}
+
+func TestGetEncodedDataRejectsFLG7(t *testing.T) {
+	bits := codeBits(0, 5, 0, 5, 7, 3, 2, 5)
+	if _, err := getEncodedData(bits, ""); !errors.Is(err, zxinggo.ErrFormat) {
+		t.Errorf("FLG(7) = %v, want ErrFormat", err)
+	}
+}
@@ -9,15 +9,16 @@ import (
 	"math/bits"
 
 	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/aztec/decoder"
 	"github.com/ericlevine/zxinggo/bitutil"
 	"github.com/ericlevine/zxinggo/reedsolomon"
 	"github.com/ericlevine/zxinggo/transform"
 )
 
-// DetectorResult encapsulates the result of detecting an Aztec barcode.
+// DetectorResult encapsulates the result of detecting an Aztec barcode: the
+// common detector result and the parameters read from the mode message.
 type DetectorResult struct {
-	Bits            *bitutil.BitMatrix
-	Points          []zxinggo.ResultPoint
+	zxinggo.DetectorResult
 	Compact         bool
 	NbDataBlocks    int
 	NbLayers        int
@@ -52,8 +53,33 @@ type correctedParameter struct {
 // Detect locates an Aztec barcode in the given binary image and returns the
 // detection result.
 func Detect(image *bitutil.BitMatrix, isMirror bool) (*DetectorResult, error) {
+	return DetectWithMaxLayers(image, isMirror, 0)
+}
+
+// DetectWithMaxLayers is like Detect but rejects symbols with more than
+// maxLayers layers before sampling them. A maxLayers of 0 accepts any size.
+func DetectWithMaxLayers(image *bitutil.BitMatrix, isMirror bool, maxLayers int) (*DetectorResult, error) {
+	return DetectWithOptions(image, isMirror, &zxinggo.DecodeOptions{AztecMaxLayers: maxLayers})
+}
+
+// DetectWithOptions is like Detect but applies opts.AztecMaxLayers and skips
+// the stages opts.DisableDetectors names. opts may be nil.
+func DetectWithOptions(image *bitutil.BitMatrix, isMirror bool, opts *zxinggo.DecodeOptions) (*DetectorResult, error) {
+	return DetectNear(image, isMirror, image.Width()/2, image.Height()/2, opts)
+}
+
+// DetectNear is like DetectWithOptions but looks for the bull's eye around
+// x, y rather than the centre of the image.
+func DetectNear(image *bitutil.BitMatrix, isMirror bool, x, y int, opts *zxinggo.DecodeOptions) (*DetectorResult, error) {
+	maxLayers := 0
+	var heatmap *zxinggo.Heatmap
+	if opts != nil {
+		maxLayers = opts.AztecMaxLayers
+		heatmap = opts.Heatmap
+	}
+
 	// 1. Get the center of the aztec matrix
-	pCenter := getMatrixCenter(image)
+	pCenter := getMatrixCenter(image, point{x, y}, !zxinggo.DetectorDisabled(opts, zxinggo.DetectorWhiteRectangle), heatmap)
 
 	// 2. Get the center points of the four diagonal points just outside the bull's eye
 	//  [topRight, bottomRight, bottomLeft, topLeft]
@@ -62,15 +88,37 @@ func Detect(image *bitutil.BitMatrix, isMirror bool) (*DetectorResult, error) {
 		return nil, err
 	}
 
+	// Record the bull's eye in opts.Heatmap, accepted if the symbol around
+	// it is sampled. The mirrored search finds the same bull's eye, so only
+	// the unmirrored one is recorded.
+	accepted := false
+	if !isMirror && heatmap != nil {
+		defer func() { recordBullsEye(heatmap, bullsEyeCorners, accepted) }()
+	}
+
 	if isMirror {
 		bullsEyeCorners[0], bullsEyeCorners[2] = bullsEyeCorners[2], bullsEyeCorners[0]
 	}
 
 	// 3. Get the size of the matrix and other parameters from the bull's eye
-	nbDataBlocks, nbLayers, shift, errorsCorrected, err := extractParameters(image, bullsEyeCorners, compact, nbCenterLayers)
+	resample := !zxinggo.DetectorDisabled(opts, zxinggo.DetectorAztecModeResample)
+	nbDataBlocks, nbLayers, shift, errorsCorrected, err := extractParameters(image, bullsEyeCorners, compact, nbCenterLayers, resample)
 	if err != nil {
 		return nil, err
 	}
+	if err := decoder.ValidateParameters(compact, nbLayers, nbDataBlocks, maxLayers); err != nil {
+		return nil, err
+	}
+
+	// The whole symbol must lie in the image, give or take one module.
+	corners := getMatrixCornerPoints(bullsEyeCorners, nbCenterLayers, compact, nbLayers)
+	moduleSize := distanceRP(bullsEyeCorners[0], bullsEyeCorners[1]) / float64(2*nbCenterLayers)
+	for _, c := range corners {
+		if c.X < -moduleSize || c.Y < -moduleSize ||
+			c.X > float64(image.Width())+moduleSize || c.Y > float64(image.Height())+moduleSize {
+			return nil, zxinggo.ErrFormat
+		}
+	}
 
 	// 4. Sample the grid
 	sampled, err := sampleGrid(image,
@@ -83,12 +131,9 @@ func Detect(image *bitutil.BitMatrix, isMirror bool) (*DetectorResult, error) {
 		return nil, err
 	}
 
-	// 5. Get the corners of the matrix.
-	corners := getMatrixCornerPoints(bullsEyeCorners, nbCenterLayers, compact, nbLayers)
-
+	accepted = true
 	return &DetectorResult{
-		Bits:            sampled,
-		Points:          corners,
+		DetectorResult:  zxinggo.DetectorResult{Bits: sampled, Points: corners},
 		Compact:         compact,
 		NbDataBlocks:    nbDataBlocks,
 		NbLayers:        nbLayers,
@@ -96,8 +141,20 @@ func Detect(image *bitutil.BitMatrix, isMirror bool) (*DetectorResult, error) {
 	}, nil
 }
 
-// extractParameters reads the mode message from the ring around the bull's eye.
-func extractParameters(image *bitutil.BitMatrix, bullsEyeCorners [4]zxinggo.ResultPoint, compact bool, nbCenterLayers int) (nbDataBlocks, nbLayers, shift, errorsCorrected int, err error) {
+// recordBullsEye records the bull's eye with the given corners in heatmap,
+// centred on them and sized by their diagonal.
+func recordBullsEye(heatmap *zxinggo.Heatmap, corners [4]zxinggo.ResultPoint, accepted bool) {
+	x := (corners[0].X + corners[1].X + corners[2].X + corners[3].X) / 4
+	y := (corners[0].Y + corners[1].Y + corners[2].Y + corners[3].Y) / 4
+	heatmap.Candidate(zxinggo.FormatAztec, x, y, distanceRP(corners[0], corners[2]), accepted)
+}
+
+// extractParameters reads the mode message from the ring around the bull's
+// eye, sampling each side along the line between its corners. If the mode
+// message does not correct, as when those lines run between the modules of
+// a tilted symbol, and resample is set, the ring is sampled again through
+// the perspective transform of the bull's eye, as the final grid will be.
+func extractParameters(image *bitutil.BitMatrix, bullsEyeCorners [4]zxinggo.ResultPoint, compact bool, nbCenterLayers int, resample bool) (nbDataBlocks, nbLayers, shift, errorsCorrected int, err error) {
 	if !isValidRP(image, bullsEyeCorners[0]) || !isValidRP(image, bullsEyeCorners[1]) ||
 		!isValidRP(image, bullsEyeCorners[2]) || !isValidRP(image, bullsEyeCorners[3]) {
 		return 0, 0, 0, 0, zxinggo.ErrNotFound
@@ -110,7 +167,35 @@ func extractParameters(image *bitutil.BitMatrix, bullsEyeCorners [4]zxinggo.Resu
 		sampleLine(image, bullsEyeCorners[2], bullsEyeCorners[3], length), // Left side
 		sampleLine(image, bullsEyeCorners[3], bullsEyeCorners[0], length), // Top
 	}
+	nbDataBlocks, nbLayers, shift, errorsCorrected, err = parametersFromSides(sides, compact, length)
+	if err == nil || !resample {
+		return nbDataBlocks, nbLayers, shift, errorsCorrected, err
+	}
+
+	// With no layers, the grid is the bull's eye and the ring around it.
+	core, sampleErr := sampleGrid(image,
+		bullsEyeCorners[0], bullsEyeCorners[1], bullsEyeCorners[2], bullsEyeCorners[3],
+		compact, 0, nbCenterLayers)
+	if sampleErr != nil {
+		return 0, 0, 0, 0, err
+	}
+	// Each side runs clockwise from one corner of the grid up to the next.
+	var gridSides [4]int
+	for i := 0; i < length; i++ {
+		gridSides[0] = gridSides[0]<<1 | bit(core.Get(i, 0))
+		gridSides[1] = gridSides[1]<<1 | bit(core.Get(length, i))
+		gridSides[2] = gridSides[2]<<1 | bit(core.Get(length-i, length))
+		gridSides[3] = gridSides[3]<<1 | bit(core.Get(0, length-i))
+	}
+	if nbDataBlocks, nbLayers, shift, errorsCorrected, gridErr := parametersFromSides(gridSides, compact, length); gridErr == nil {
+		return nbDataBlocks, nbLayers, shift, errorsCorrected, nil
+	}
+	return 0, 0, 0, 0, err
+}
 
+// parametersFromSides orients the four sides of the mode message ring, each
+// length bits starting at a corner, and corrects the mode message they hold.
+func parametersFromSides(sides [4]int, compact bool, length int) (nbDataBlocks, nbLayers, shift, errorsCorrected int, err error) {
 	shift, err = getRotation(sides, length)
 	if err != nil {
 		return 0, 0, 0, 0, err
@@ -151,6 +236,81 @@ func extractParameters(image *bitutil.BitMatrix, bullsEyeCorners [4]zxinggo.Resu
 	return nbDataBlocks, nbLayers, shift, corrected.errorsCorrected, nil
 }
 
+func bit(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// MatrixParameters reads the mode message of an upright, pre-sampled Aztec
+// symbol with no quiet zone and returns its structural parameters. The
+// symbol's width selects between compact and full-range symbols; where both
+// are possible (19, 23 and 27 modules), compact is tried first.
+func MatrixParameters(bits *bitutil.BitMatrix) (compact bool, nbLayers, nbDataBlocks int, err error) {
+	dim := bits.Width()
+	if bits.Height() != dim {
+		return false, 0, 0, zxinggo.ErrFormat
+	}
+	for _, compact := range []bool{true, false} {
+		nbLayers, nbDataBlocks, err := readModeMessage(bits, compact)
+		if err == nil && decoder.Dimension(compact, nbLayers) == dim {
+			return compact, nbLayers, nbDataBlocks, nil
+		}
+	}
+	return false, 0, 0, zxinggo.ErrFormat
+}
+
+// readModeMessage reads the mode message ring around the bull's eye of a
+// sampled symbol, as laid out by the encoder.
+func readModeMessage(bits *bitutil.BitMatrix, compact bool) (nbLayers, nbDataBlocks int, err error) {
+	center := bits.Width() / 2
+	var parameterData int64
+	var message [40]bool
+	if compact {
+		if center < 5 {
+			return 0, 0, zxinggo.ErrFormat
+		}
+		for i := 0; i < 7; i++ {
+			offset := center - 3 + i
+			message[i] = bits.Get(offset, center-5)
+			message[i+7] = bits.Get(center+5, offset)
+			message[20-i] = bits.Get(offset, center+5)
+			message[27-i] = bits.Get(center-5, offset)
+		}
+	} else {
+		if center < 7 {
+			return 0, 0, zxinggo.ErrFormat
+		}
+		for i := 0; i < 10; i++ {
+			offset := center - 5 + i + i/5
+			message[i] = bits.Get(offset, center-7)
+			message[i+10] = bits.Get(center+7, offset)
+			message[29-i] = bits.Get(offset, center+7)
+			message[39-i] = bits.Get(center-7, offset)
+		}
+	}
+	n := 40
+	if compact {
+		n = 28
+	}
+	for _, b := range message[:n] {
+		parameterData <<= 1
+		if b {
+			parameterData |= 1
+		}
+	}
+
+	corrected, err := getCorrectedParameterData(parameterData, compact)
+	if err != nil {
+		return 0, 0, err
+	}
+	if compact {
+		return (corrected.data >> 6) + 1, (corrected.data & 0x3F) + 1, nil
+	}
+	return (corrected.data >> 11) + 1, (corrected.data & 0x7FF) + 1, nil
+}
+
 // getRotation determines the rotation shift from orientation marks.
 func getRotation(sides [4]int, length int) (int, error) {
 	// Grab the 3 bits from each of the sides that form the locator pattern
@@ -264,12 +424,22 @@ func getBullsEyeCorners(image *bitutil.BitMatrix, pCenter point) ([4]zxinggo.Res
 	return corners, compact, nbCenterLayers, nil
 }
 
-// getMatrixCenter locates the approximate center of the Aztec bullseye.
-func getMatrixCenter(image *bitutil.BitMatrix) point {
+// getMatrixCenter locates the approximate center of the Aztec bullseye,
+// starting from start. Unless whiteRectangle is set, it only walks out from
+// start to the first dark pixels, without searching for the white rectangle
+// around the bull's eye. heatmap records the white rectangle search.
+func getMatrixCenter(image *bitutil.BitMatrix, start point, whiteRectangle bool, heatmap *zxinggo.Heatmap) point {
 	var pointA, pointB, pointC, pointD zxinggo.ResultPoint
 
 	// Get a white rectangle that can be the border of the matrix in center bull's eye
-	wrd, err := newWhiteRectangleDetector(image)
+	err := zxinggo.ErrNotFound
+	var wrd *whiteRectangleDetector
+	if whiteRectangle {
+		wrd, err = newWhiteRectangleDetectorWithInit(image, wrdInitSize, start.x, start.y)
+	}
+	if err == nil {
+		wrd.heatmap = heatmap
+	}
 	if err == nil {
 		var cornerPoints []zxinggo.ResultPoint
 		cornerPoints, err = wrd.detect()
@@ -283,8 +453,7 @@ func getMatrixCenter(image *bitutil.BitMatrix) point {
 	if err != nil {
 		// This exception can be in case the initial rectangle is white
 		// In that case, surely in the bull's eye, we try to expand the rectangle.
-		cx := image.Width() / 2
-		cy := image.Height() / 2
+		cx, cy := start.x, start.y
 		pointA = getFirstDifferent(image, point{cx + 7, cy - 7}, false, 1, -1).toResultPoint()
 		pointB = getFirstDifferent(image, point{cx + 7, cy + 7}, false, 1, 1).toResultPoint()
 		pointC = getFirstDifferent(image, point{cx - 7, cy + 7}, false, -1, 1).toResultPoint()
@@ -296,7 +465,13 @@ func getMatrixCenter(image *bitutil.BitMatrix) point {
 	cy := mathRound((pointA.Y + pointD.Y + pointB.Y + pointC.Y) / 4.0)
 
 	// Redetermine the white rectangle starting from previously computed center.
-	wrd2, err := newWhiteRectangleDetectorWithInit(image, 15, cx, cy)
+	var wrd2 *whiteRectangleDetector
+	if whiteRectangle {
+		wrd2, err = newWhiteRectangleDetectorWithInit(image, 15, cx, cy)
+	}
+	if err == nil {
+		wrd2.heatmap = heatmap
+	}
 	if err == nil {
 		var cornerPoints []zxinggo.ResultPoint
 		cornerPoints, err = wrd2.detect()
@@ -324,7 +499,7 @@ func getMatrixCenter(image *bitutil.BitMatrix) point {
 
 // getMatrixCornerPoints gets the Aztec code corners from the bull's eye corners.
 func getMatrixCornerPoints(bullsEyeCorners [4]zxinggo.ResultPoint, nbCenterLayers int, compact bool, nbLayers int) []zxinggo.ResultPoint {
-	expanded := expandSquare(bullsEyeCorners, 2*nbCenterLayers, getDimension(compact, nbLayers))
+	expanded := expandSquare(bullsEyeCorners, 2*nbCenterLayers, decoder.Dimension(compact, nbLayers))
 	return expanded[:]
 }
 
@@ -334,7 +509,7 @@ func sampleGrid(image *bitutil.BitMatrix,
 	compact bool, nbLayers, nbCenterLayers int) (*bitutil.BitMatrix, error) {
 
 	sampler := &transform.DefaultGridSampler{}
-	dimension := getDimension(compact, nbLayers)
+	dimension := decoder.Dimension(compact, nbLayers)
 
 	low := float64(dimension)/2.0 - float64(nbCenterLayers)
 	high := float64(dimension)/2.0 + float64(nbCenterLayers)
@@ -527,14 +702,6 @@ func distanceRP(a, b zxinggo.ResultPoint) float64 {
 	return math.Sqrt(dx*dx + dy*dy)
 }
 
-// getDimension returns the dimension of the Aztec symbol.
-func getDimension(compact bool, nbLayers int) int {
-	if compact {
-		return 4*nbLayers + 11
-	}
-	return 4*nbLayers + 2*((2*nbLayers+6)/15) + 15
-}
-
 func mathRound(f float64) int {
 	return int(math.Round(f))
 }
@@ -553,10 +720,9 @@ type whiteRectangleDetector struct {
 	rightInit int
 	downInit  int
 	upInit    int
-}
 
-func newWhiteRectangleDetector(image *bitutil.BitMatrix) (*whiteRectangleDetector, error) {
-	return newWhiteRectangleDetectorWithInit(image, wrdInitSize, image.Width()/2, image.Height()/2)
+	// heatmap records the pixels containsBlackPoint scans.
+	heatmap *zxinggo.Heatmap
 }
 
 func newWhiteRectangleDetectorWithInit(image *bitutil.BitMatrix, initSz, x, y int) (*whiteRectangleDetector, error) {
@@ -738,15 +904,19 @@ func (d *whiteRectangleDetector) containsBlackPoint(a, b, fixed int, horizontal
 	if horizontal {
 		for x := a; x <= b; x++ {
 			if x >= 0 && x < d.width && fixed >= 0 && fixed < d.height && d.image.Get(x, fixed) {
+				d.heatmap.ScanRow(fixed, a, x+1)
 				return true
 			}
 		}
+		d.heatmap.ScanRow(fixed, a, b+1)
 	} else {
 		for y := a; y <= b; y++ {
 			if fixed >= 0 && fixed < d.width && y >= 0 && y < d.height && d.image.Get(fixed, y) {
+				d.heatmap.ScanColumn(fixed, a, y+1)
 				return true
 			}
 		}
+		d.heatmap.ScanColumn(fixed, a, b+1)
 	}
 	return false
 }
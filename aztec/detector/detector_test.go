@@ -0,0 +1,61 @@
+package detector
+
+import (
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/internal/symbolgen"
+	"github.com/ericlevine/zxinggo/transform"
+)
+
+func TestExtractParametersTilted(t *testing.T) {
+	symbol := symbolgen.Aztec(3, true, 2)
+	n := float64(symbol.Bits.Width())
+	// The symbol turned away about its left edge, whose far side is twice
+	// as tall, at 6 pixels to a module.
+	const scale = 6.0
+	toImage := transform.QuadrilateralToQuadrilateral(
+		0, 0, n, 0, n, n, 0, n,
+		10, 10+n*scale/2, 10+n*scale, 10, 10+n*scale, 10+2*n*scale, 10, 10+1.5*n*scale)
+	toModules := toImage.BuildAdjoint()
+	width, height := int(20+n*scale), int(20+2*n*scale)
+	image := bitutil.NewBitMatrixWithSize(width, height)
+	pt := make([]float64, 2)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pt[0], pt[1] = float64(x)+0.5, float64(y)+0.5
+			toModules.TransformPoints(pt)
+			if pt[0] >= 0 && pt[1] >= 0 && pt[0] < n && pt[1] < n && symbol.Bits.Get(int(pt[0]), int(pt[1])) {
+				image.Set(x, y)
+			}
+		}
+	}
+
+	// The centres of the mode message ring's corner modules.
+	lo, hi := n/2-5, n/2+5
+	var corners [4]zxinggo.ResultPoint
+	for i, c := range [][2]float64{{lo, lo}, {hi, lo}, {hi, hi}, {lo, hi}} {
+		pt[0], pt[1] = c[0], c[1]
+		toImage.TransformPoints(pt)
+		corners[i] = zxinggo.ResultPoint{X: pt[0], Y: pt[1]}
+	}
+
+	var sides [4]int
+	for i := range sides {
+		sides[i] = sampleLine(image, corners[i], corners[(i+1)%4], 10)
+	}
+	if _, _, _, _, err := parametersFromSides(sides, true, 10); err == nil {
+		t.Fatal("the ring sampled along straight lines corrected; the test needs more tilt")
+	}
+	if _, _, _, _, err := extractParameters(image, corners, true, 5, false); err == nil {
+		t.Error("corrected without resampling")
+	}
+	nbDataBlocks, nbLayers, _, _, err := extractParameters(image, corners, true, 5, true)
+	if err != nil {
+		t.Fatalf("extractParameters: %v", err)
+	}
+	if nbLayers != symbol.Layers || nbDataBlocks != symbol.DataBlocks {
+		t.Errorf("got %d layers, %d data blocks; want %d, %d", nbLayers, nbDataBlocks, symbol.Layers, symbol.DataBlocks)
+	}
+}
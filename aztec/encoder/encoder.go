@@ -3,7 +3,9 @@ package encoder
 import (
 	"fmt"
 
+	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/charset"
 	"github.com/ericlevine/zxinggo/reedsolomon"
 )
 
@@ -41,10 +43,32 @@ func gfForWordSize(ws int) *reedsolomon.GenericGF {
 	}
 }
 
+// Options selects optional content of an Aztec symbol.
+type Options struct {
+	// GS1 marks the data as GS1: FLG(0) starts it, and each GS (0x1D) in
+	// the data is encoded as FLG(0).
+	GS1 bool
+
+	// StructuredAppend, if set, starts the symbol with a structured append
+	// header. Count may be 2 to 26, and ID must not contain spaces.
+	StructuredAppend *zxinggo.StructuredAppend
+
+	// CharacterSet, if set, is the character set data is in, announced by
+	// an ECI designator after any structured append header. Without one,
+	// readers take data to be ISO-8859-1.
+	CharacterSet *charset.ECI
+}
+
 // Encode encodes the given data into an Aztec barcode symbol.
 func Encode(data []byte, minECCPercent int, userSpecifiedLayers int) (*AztecCode, error) {
+	return EncodeWithOptions(data, minECCPercent, userSpecifiedLayers, nil)
+}
+
+// EncodeWithOptions encodes the given data into an Aztec barcode symbol
+// with the optional content selected by opts, which may be nil.
+func EncodeWithOptions(data []byte, minECCPercent int, userSpecifiedLayers int, opts *Options) (*AztecCode, error) {
 	// 1. High-level encode the data into a bit stream.
-	bits, err := highLevelEncode(data)
+	bits, err := highLevelEncode(data, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -3,7 +3,10 @@ package encoder
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
+	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
 )
 
@@ -185,7 +188,7 @@ func getLatchSequence(from, to int) []modeSwitch {
 // highLevelEncode encodes data bytes into a BitArray using the Aztec
 // high-level encoding scheme. It uses a greedy strategy starting in UPPER
 // mode.
-func highLevelEncode(data []byte) (*bitutil.BitArray, error) {
+func highLevelEncode(data []byte, opts *Options) (*bitutil.BitArray, error) {
 	if len(data) == 0 {
 		return nil, fmt.Errorf("aztec: empty input")
 	}
@@ -193,8 +196,41 @@ func highLevelEncode(data []byte) (*bitutil.BitArray, error) {
 	result := bitutil.NewBitArray(0)
 	curMode := modeUpper
 
+	var prefix []byte
+	gs1 := opts != nil && opts.GS1
+	eciAt := -1
+	if opts != nil && opts.StructuredAppend != nil {
+		header, err := structuredAppendHeader(opts.StructuredAppend)
+		if err != nil {
+			return nil, err
+		}
+		// M/L U/L announces the header.
+		result.AppendBits(29, modeBits[modeUpper])
+		result.AppendBits(29, modeBits[modeMixed])
+		prefix = append(prefix, header...)
+	}
+	if opts != nil && opts.CharacterSet != nil {
+		eciAt = len(prefix)
+	}
+	if gs1 {
+		prefix = append(prefix, 0x1D)
+	}
+	if prefix != nil {
+		data = append(prefix, data...)
+	}
+
 	i := 0
 	for i < len(data) {
+		if i == eciAt {
+			appendECI(result, curMode, opts.CharacterSet.Value)
+			eciAt = -1
+		}
+		if gs1 && data[i] == 0x1D {
+			appendFNC1(result, curMode)
+			i++
+			continue
+		}
+
 		// Check for two-character PUNCT pairs.
 		if i+1 < len(data) {
 			pair := [2]byte{data[i], data[i+1]}
@@ -261,6 +297,46 @@ func highLevelEncode(data []byte) (*bitutil.BitArray, error) {
 	return result, nil
 }
 
+// structuredAppendHeader returns the text of the structured append header
+// for sa: the message ID between spaces, if any, then the symbol's position
+// and the symbol count as letters A-Z.
+func structuredAppendHeader(sa *zxinggo.StructuredAppend) (string, error) {
+	if sa.Count < 2 || sa.Count > 26 || sa.Index < 0 || sa.Index >= sa.Count {
+		return "", fmt.Errorf("aztec: invalid structured append position %d of %d", sa.Index, sa.Count)
+	}
+	if strings.Contains(sa.ID, " ") {
+		return "", fmt.Errorf("aztec: structured append ID %q contains a space", sa.ID)
+	}
+	header := string(rune('A'+sa.Index)) + string(rune('A'+sa.Count-1))
+	if sa.ID != "" {
+		header = " " + sa.ID + " " + header
+	}
+	return header, nil
+}
+
+// appendFNC1 writes FLG(0), shifting to PUNCT for it unless already there.
+func appendFNC1(bits *bitutil.BitArray, curMode int) {
+	if curMode != modePunct {
+		bits.AppendBits(0, modeBits[curMode]) // P/S
+	}
+	bits.AppendBits(0, modeBits[modePunct]) // FLG(n)
+	bits.AppendBits(0, 3)                   // n = 0
+}
+
+// appendECI writes FLG(n) and the n digits of an ECI designator, shifting
+// to PUNCT for it unless already there, as appendFNC1 does.
+func appendECI(bits *bitutil.BitArray, curMode int, eci int) {
+	if curMode != modePunct {
+		bits.AppendBits(0, modeBits[curMode]) // P/S
+	}
+	digits := strconv.Itoa(eci)
+	bits.AppendBits(0, modeBits[modePunct]) // FLG(n)
+	bits.AppendBits(uint32(len(digits)), 3)
+	for _, d := range digits {
+		bits.AppendBits(uint32(d-'0'+2), 4)
+	}
+}
+
 // findBestMode returns the best mode to encode byte b when currently in
 // curMode, or -1 if no character mode can encode it (binary shift required).
 func findBestMode(b byte, curMode int) int {
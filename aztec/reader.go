@@ -5,24 +5,81 @@ import (
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/aztec/decoder"
 	"github.com/ericlevine/zxinggo/aztec/detector"
+	"github.com/ericlevine/zxinggo/bitutil"
 )
 
 // Reader decodes Aztec barcodes from binary images.
-type Reader struct{}
+type Reader struct {
+	// opts are used by Decode and Detect when they are passed nil.
+	opts *zxinggo.DecodeOptions
+}
 
 // NewReader creates a new Aztec Reader.
 func NewReader() *Reader {
 	return &Reader{}
 }
 
+// NewReaderWithOptions creates an Aztec reader that uses opts whenever it is
+// given nil options, so it can be configured once and used on its own
+// rather than through zxinggo.Decode.
+func NewReaderWithOptions(opts *zxinggo.DecodeOptions) *Reader {
+	return &Reader{opts: opts}
+}
+
 // Decode locates and decodes an Aztec barcode in the given image.
 func (r *Reader) Decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	if opts == nil {
+		opts = r.opts
+	}
 	matrix, err := image.BlackMatrix()
 	if err != nil {
 		return nil, err
 	}
 
-	detResult, err := detector.Detect(matrix, false)
+	// As Java ZXing does, a symbol that does not decode is tried again as
+	// its mirror image, seen from behind a window or printed reversed.
+	var firstErr error
+	for _, start := range searchStarts(matrix, opts) {
+		if zxinggo.DeadlinePassed(opts) {
+			return nil, zxinggo.ErrDeadlineExceeded
+		}
+		for _, mirror := range []bool{false, true} {
+			result, err := decode(matrix, mirror, start, opts)
+			if err == nil {
+				return result, nil
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return nil, firstErr
+}
+
+// searchStarts returns where in matrix to look for a bull's eye: the
+// centre, then with TryHarder the centres of its quarters, for symbols not
+// in the middle of the image.
+func searchStarts(matrix *bitutil.BitMatrix, opts *zxinggo.DecodeOptions) [][2]int {
+	w, h := matrix.Width(), matrix.Height()
+	starts := [][2]int{{w / 2, h / 2}}
+	if opts != nil && opts.TryHarder && !zxinggo.DetectorDisabled(opts, zxinggo.DetectorAztecQuarters) {
+		starts = append(starts, [][2]int{{w / 4, h / 4}, {3 * w / 4, h / 4}, {w / 4, 3 * h / 4}, {3 * w / 4, 3 * h / 4}}...)
+	}
+	return starts
+}
+
+// characterSet returns the character set opts, which may be nil, assume for
+// binary data.
+func characterSet(opts *zxinggo.DecodeOptions) string {
+	if opts == nil {
+		return ""
+	}
+	return opts.CharacterSet
+}
+
+// decode detects and decodes a symbol whose bull's eye is found from start.
+func decode(matrix *bitutil.BitMatrix, mirror bool, start [2]int, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	detResult, err := detector.DetectNear(matrix, mirror, start[0], start[1], opts)
 	if err != nil {
 		return nil, err
 	}
@@ -36,20 +93,87 @@ func (r *Reader) Decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions
 		NbLayers:     detResult.NbLayers,
 	}
 
-	dr, err := decoder.Decode(ddata)
+	dr, err := decoder.Decode(ddata, characterSet(opts))
 	if err != nil {
 		return nil, err
 	}
 
-	errorsCorrected := detResult.ErrorsCorrected + dr.ErrorsCorrected
-	result := zxinggo.NewResult(dr.Text, dr.RawBytes, detResult.Points, zxinggo.FormatAztec)
-	result.PutMetadata(zxinggo.MetadataSymbologyIdentifier, "]z0")
-	result.PutMetadata(zxinggo.MetadataErrorsCorrected, errorsCorrected)
+	// Count the corrections to the mode message with the data's.
+	dr.ErrorsCorrected += detResult.ErrorsCorrected
+	result := dr.Result(detResult.Points, zxinggo.FormatAztec)
+	if err := zxinggo.CheckErrorBudget(result, opts); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
+// DecodeMatrix decodes an Aztec barcode from its module grid, one bit per
+// module with no quiet zone, as sampled by an external detector. The grid may
+// be in any of the four rotations. opts may be nil; only CharacterSet and
+// MaxErrorsCorrected are used. The result has no points.
+func DecodeMatrix(bits *bitutil.BitMatrix, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	bits = bits.Clone()
+	err := error(zxinggo.ErrFormat)
+	for rotation := 0; rotation < 4; rotation++ {
+		if rotation > 0 {
+			bits.Rotate90()
+		}
+		compact, nbLayers, nbDataBlocks, perr := detector.MatrixParameters(bits)
+		if perr != nil {
+			continue
+		}
+		dr, derr := decoder.Decode(&decoder.AztecDetectorResult{
+			Bits:         bits,
+			Compact:      compact,
+			NbDataBlocks: nbDataBlocks,
+			NbLayers:     nbLayers,
+		}, characterSet(opts))
+		if derr != nil {
+			err = derr
+			continue
+		}
+		result := dr.Result(nil, zxinggo.FormatAztec)
+		if err := zxinggo.CheckErrorBudget(result, opts); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	return nil, err
+}
+
+// Detect locates an Aztec barcode in the given image without decoding it.
+// The outline is not oriented: it starts at an arbitrary corner.
+func (r *Reader) Detect(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) ([]zxinggo.Detection, error) {
+	if opts == nil {
+		opts = r.opts
+	}
+	matrix, err := image.BlackMatrix()
+	if err != nil {
+		return nil, err
+	}
+	err = zxinggo.ErrNotFound
+	for i, start := range searchStarts(matrix, opts) {
+		detResult, derr := detector.DetectNear(matrix, false, start[0], start[1], opts)
+		if derr != nil {
+			if i == 0 {
+				err = derr
+			}
+			continue
+		}
+		return []zxinggo.Detection{{
+			Format: zxinggo.FormatAztec,
+			Points: detResult.Points,
+			Bits:   detResult.Bits,
+		}}, nil
+	}
+	return nil, err
+}
+
 // Reset resets internal state.
 func (r *Reader) Reset() {}
 
 // Compile-time check.
-var _ zxinggo.Reader = (*Reader)(nil)
+var (
+	_ zxinggo.Reader   = (*Reader)(nil)
+	_ zxinggo.Detector = (*Reader)(nil)
+)
@@ -9,4 +9,5 @@ func init() {
 	zxinggo.RegisterWriter(zxinggo.FormatAztec, func() zxinggo.Writer {
 		return NewWriter()
 	})
+	zxinggo.RegisterCapacityPlanner(zxinggo.FormatAztec, capacityPlanner{})
 }
@@ -6,8 +6,14 @@ import (
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/aztec/encoder"
 	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/charset"
+	"github.com/ericlevine/zxinggo/gs1"
 )
 
+// writerECCPercent is the least share of a symbol, in percent, that Writer
+// gives to error correction.
+const writerECCPercent = 33
+
 // Writer encodes Aztec barcodes.
 type Writer struct{}
 
@@ -16,7 +22,10 @@ func NewWriter() *Writer {
 	return &Writer{}
 }
 
-// Encode encodes the given contents into an Aztec BitMatrix.
+// Encode encodes the given contents into an Aztec BitMatrix. If
+// opts.CharacterSet is set, contents are converted to it and an ECI for it
+// starts the symbol; otherwise their UTF-8 bytes are encoded as they are.
+// With opts.GS1Format, contents is a GS1 element string, bracketed or raw.
 func (w *Writer) Encode(contents string, format zxinggo.Format, width, height int, opts *zxinggo.EncodeOptions) (*bitutil.BitMatrix, error) {
 	if contents == "" {
 		return nil, fmt.Errorf("found empty contents")
@@ -25,23 +34,49 @@ func (w *Writer) Encode(contents string, format zxinggo.Format, width, height in
 		return nil, fmt.Errorf("can only encode AZTEC, but got %s", format)
 	}
 
-	minECCPercent := 33
-	code, err := encoder.Encode([]byte(contents), minECCPercent, 0)
+	if opts != nil && opts.GS1Format {
+		var err error
+		if contents, err = gs1.RawElementString(contents); err != nil {
+			return nil, err
+		}
+	}
+	data := []byte(contents)
+	encOpts := encoderOptions(opts)
+	if opts != nil && opts.CharacterSet != "" {
+		eci := charset.GetECIByName(opts.CharacterSet)
+		if eci == nil {
+			return nil, fmt.Errorf("%w: unsupported character set %q", zxinggo.ErrWriter, opts.CharacterSet)
+		}
+		var err error
+		if data, err = charset.EncodeString(contents, eci); err != nil {
+			return nil, fmt.Errorf("%w: %v", zxinggo.ErrWriter, err)
+		}
+		encOpts.CharacterSet = eci
+	}
+	code, err := encoder.EncodeWithOptions(data, writerECCPercent, 0, encOpts)
 	if err != nil {
 		return nil, err
 	}
 
-	return renderMatrix(code.Matrix, width, height), nil
+	return renderMatrix(code.Matrix, width, height, zxinggo.EncodeQuietZone(zxinggo.FormatAztec, opts).Left), nil
+}
+
+// encoderOptions returns the encoder options selected by opts, which may be
+// nil.
+func encoderOptions(opts *zxinggo.EncodeOptions) *encoder.Options {
+	if opts == nil {
+		return nil
+	}
+	return &encoder.Options{GS1: opts.GS1Format, StructuredAppend: opts.StructuredAppend}
 }
 
-// renderMatrix scales the encoded Aztec symbol to fit the requested
-// width and height, preserving the module aspect ratio.
-func renderMatrix(code *bitutil.BitMatrix, width, height int) *bitutil.BitMatrix {
+// renderMatrix scales the encoded Aztec symbol, with a quiet zone of qz
+// modules on each side, to fit the requested width and height, preserving
+// the module aspect ratio.
+func renderMatrix(code *bitutil.BitMatrix, width, height, qz int) *bitutil.BitMatrix {
 	inputWidth := code.Width()
 	inputHeight := code.Height()
 
-	// Add a 1-module quiet zone on each side.
-	qz := 1
 	outputWidth := inputWidth + 2*qz
 	outputHeight := inputHeight + 2*qz
 
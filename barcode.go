@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/transform"
 )
 
 // Format represents a barcode format.
@@ -28,6 +29,43 @@ const (
 	FormatRSSExpanded
 	FormatMaxiCode
 	FormatCode93
+	FormatCode11
+	FormatTelepen
+	FormatMatrix2of5
+	FormatIndustrial2of5
+	FormatIATA2of5
+	FormatHanXin
+	FormatDotCode
+	FormatRM4SCC
+	FormatKIX
+	FormatAustraliaPost
+	FormatIntelligentMail
+	FormatCodablockF
+	FormatCode16K
+
+	// formatCount is the number of formats; it must stay last.
+	formatCount
+)
+
+// Format families stand in DecodeOptions.PossibleFormats for every format of
+// a kind; see ExpandFormats. No symbol is of a family format. They are
+// numbered apart from the formats so that new formats do not renumber them.
+const (
+	// FormatAnyOneD is every linear format read row by row: UPC/EAN,
+	// Code 39, Code 93, Code 128, ITF, Codabar, RSS, Code 11, Telepen and
+	// the 2 of 5 variants.
+	FormatAnyOneD Format = 1000 + iota
+	// FormatAnyRetail is the formats scanned at the point of sale: UPC/EAN
+	// and RSS (GS1 DataBar).
+	FormatAnyRetail
+	// FormatAnyGS1 is the formats GS1 specifies for carrying its keys:
+	// UPC/EAN, ITF-14, GS1-128, GS1 DataBar, GS1 DataMatrix, GS1 QR Code and
+	// GS1 DotCode.
+	FormatAnyGS1
+	// FormatAny2D is every matrix and stacked format.
+	FormatAny2D
+	// FormatAnyPostal is the four-state postal formats.
+	FormatAnyPostal
 )
 
 // String returns the name of the barcode format.
@@ -65,6 +103,42 @@ func (f Format) String() string {
 		return "MAXICODE"
 	case FormatCode93:
 		return "CODE_93"
+	case FormatCode11:
+		return "CODE_11"
+	case FormatTelepen:
+		return "TELEPEN"
+	case FormatMatrix2of5:
+		return "MATRIX_2_OF_5"
+	case FormatIndustrial2of5:
+		return "INDUSTRIAL_2_OF_5"
+	case FormatIATA2of5:
+		return "IATA_2_OF_5"
+	case FormatHanXin:
+		return "HAN_XIN"
+	case FormatDotCode:
+		return "DOTCODE"
+	case FormatRM4SCC:
+		return "RM4SCC"
+	case FormatKIX:
+		return "KIX"
+	case FormatAustraliaPost:
+		return "AUSTRALIA_POST"
+	case FormatIntelligentMail:
+		return "USPS_INTELLIGENT_MAIL"
+	case FormatCodablockF:
+		return "CODABLOCK_F"
+	case FormatCode16K:
+		return "CODE_16K"
+	case FormatAnyOneD:
+		return "ANY_1D"
+	case FormatAnyRetail:
+		return "ANY_RETAIL"
+	case FormatAnyGS1:
+		return "ANY_GS1"
+	case FormatAny2D:
+		return "ANY_2D"
+	case FormatAnyPostal:
+		return "ANY_POSTAL"
 	default:
 		return "UNKNOWN"
 	}
@@ -78,7 +152,14 @@ const (
 	MetadataOrientation
 	MetadataByteSegments
 	MetadataErrorCorrectionLevel
+	// MetadataErrorsCorrected is the number of codewords, as an int, that
+	// Reed-Solomon error correction repaired; for Aztec it includes those of
+	// the mode message. Every 2D format sets it; see PutErrorsCorrected.
 	MetadataErrorsCorrected
+	// MetadataErasuresCorrected is the number of the corrected codewords, as
+	// an int, that the decoder knew to be unreadable before correcting them.
+	// It is zero for formats whose decoders do not locate erasures, which is
+	// all but PDF417.
 	MetadataErasuresCorrected
 	MetadataIssueNumber
 	MetadataSuggestedPrice
@@ -88,11 +169,64 @@ const (
 	MetadataStructuredAppendSequence
 	MetadataStructuredAppendParity
 	MetadataSymbologyIdentifier
+	// MetadataSymbolDimension is the size of the sampled symbol in modules, as
+	// a [2]int of columns and rows.
+	MetadataSymbolDimension
+	// MetadataStructuredAppend is a *StructuredAppend placing the symbol in
+	// a message split across several. QR Code, Aztec and Macro PDF417
+	// symbols set it; QR Code also sets MetadataStructuredAppendSequence and
+	// MetadataStructuredAppendParity, its header as encoded.
+	MetadataStructuredAppend
+	// MetadataAlignmentPattern tells, as a string, how a QR code's
+	// bottom-right corner was located: "found" or "retried" if through its
+	// alignment pattern, found by the first search or the wider second one;
+	// "estimated" if the pattern was not found and the corner was estimated
+	// from the finder patterns; or "absent" for version 1, which has none.
+	MetadataAlignmentPattern
+	// MetadataCandidates lists, as a []Candidate, the texts the rows of a 1D
+	// symbol read, most votes first, when DecodeOptions.OneDCandidateRows
+	// asks for them.
+	MetadataCandidates
+	// MetadataUnusedErrorCorrection is the lowest fraction, as a float64
+	// from 0 to 1, of any Reed-Solomon block's correction capacity that
+	// decoding left unused, for QR Code and Data Matrix.
+	MetadataUnusedErrorCorrection
+	// MetadataRawText is the text as decoded, as a string, when
+	// DecodeOptions.Normalize changed it and asked to keep it.
+	MetadataRawText
+	// MetadataSymbolValues lists, as a []int, the value of every symbol
+	// character of a Code 128, Code 39 or Code 93 symbol in the order read,
+	// from the start character to the stop character, including code set
+	// changes, shifts, FNC characters and check characters, for verifiers
+	// judging how the data was encoded. Code 39 and Code 93 start and stop
+	// characters, which have no value, count as 43 and 47, one past the
+	// values of their character sets.
+	MetadataSymbolValues
+
+	// metadataKeyCount is the number of metadata keys; it must stay last.
+	metadataKeyCount
 )
 
+// StructuredAppend identifies one symbol of a message split across several.
+type StructuredAppend struct {
+	Index  int    `json:"index"`            // position of this symbol in the message, from 0
+	Count  int    `json:"count"`            // number of symbols in the message, or 0 if not given
+	ID     string `json:"id,omitempty"`     // identifies the message, if the symbols carry an ID
+	Parity int    `json:"parity,omitempty"` // QR Code's parity byte, the XOR of the message's bytes
+}
+
+// Candidate is a text the rows of a 1D symbol read, and how many of them
+// read it. Rows that disagree are a sign that some misread the symbol.
+type Candidate struct {
+	Format Format `json:"format"`
+	Text   string `json:"text"`
+	Votes  int    `json:"votes"`
+}
+
 // ResultPoint represents a point of interest in an image.
 type ResultPoint struct {
-	X, Y float64
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
 }
 
 // Distance returns the distance between two points.
@@ -106,6 +240,17 @@ func CrossProductZ(a, b, c ResultPoint) float64 {
 	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
 }
 
+// TransformPoints maps points through xform in place, such as from a cropped
+// or rescaled copy of an image back to the original. Points are taken to be
+// at the centres of pixels, so a pixel maps to the centre of the area it
+// covers.
+func TransformPoints(xform *transform.PerspectiveTransform, points []ResultPoint) {
+	for i, p := range points {
+		x, y := xform.Transform(p.X+0.5, p.Y+0.5)
+		points[i] = ResultPoint{X: x - 0.5, Y: y - 0.5}
+	}
+}
+
 // OrderBestPatterns orders three points in an pointA-pointB-pointC order such
 // that AB is less than AC and BC is less than AC.
 func OrderBestPatterns(patterns [3]ResultPoint) [3]ResultPoint {
@@ -169,6 +314,14 @@ func (r *Result) PutMetadata(key ResultMetadataKey, value interface{}) {
 	r.Metadata[key] = value
 }
 
+// PutErrorsCorrected records the codewords error correction repaired, as
+// MetadataErrorsCorrected and MetadataErasuresCorrected, so that results of
+// every format can be compared.
+func (r *Result) PutErrorsCorrected(errors, erasures int) {
+	r.Metadata[MetadataErrorsCorrected] = errors
+	r.Metadata[MetadataErasuresCorrected] = erasures
+}
+
 // AddResultPoints appends additional result points.
 func (r *Result) AddResultPoints(points []ResultPoint) {
 	r.Points = append(r.Points, points...)
@@ -195,6 +348,12 @@ func (b *BinaryBitmap) Height() int {
 	return b.binarizer.Height()
 }
 
+// LuminanceSource returns the luminance source the bitmap is binarized
+// from, for readers that binarize it their own way.
+func (b *BinaryBitmap) LuminanceSource() LuminanceSource {
+	return b.binarizer.LuminanceSource()
+}
+
 // BlackRow returns a row of black/white values.
 func (b *BinaryBitmap) BlackRow(y int, row *bitutil.BitArray) (*bitutil.BitArray, error) {
 	return b.binarizer.BlackRow(y, row)
@@ -254,3 +413,14 @@ func NewBinarizerFromSource(template Binarizer, source LuminanceSource) Binarize
 type BinarizerFactory interface {
 	CreateBinarizer(source LuminanceSource) Binarizer
 }
+
+// FrameBinarizerFactory is a BinarizerFactory whose binarizers can carry
+// state from one frame of a video to the next. Scanner creates each frame's
+// binarizer with CreateFrameBinarizer, and calls Reset to forget the state.
+// Binarizers from CreateBinarizer, including those NewBinarizerFromSource
+// derives for the crops, rotations and retries of a frame, carry none.
+type FrameBinarizerFactory interface {
+	BinarizerFactory
+	CreateFrameBinarizer(source LuminanceSource) Binarizer
+	Reset()
+}
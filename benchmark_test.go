@@ -2,6 +2,7 @@ package zxinggo_test
 
 import (
 	"image"
+	"image/color"
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
@@ -81,6 +82,33 @@ func BenchmarkDecode(b *testing.B) {
 	}
 }
 
+// BenchmarkRetailPreset decodes camera frames of retail symbols, and one
+// holding none, with RetailPreset.
+func BenchmarkRetailPreset(b *testing.B) {
+	frames := []struct {
+		name string
+		path string
+	}{
+		{"EAN13", "testdata/blackbox/ean13-1/1.png"},
+		{"UPCA", "testdata/blackbox/upca-1/2.png"},
+		{"Extension", "testdata/blackbox/upcean-extension-1/1.png"},
+		{"Code128", "testdata/blackbox/code128-1/1.png"},
+		{"NoBarcode", "testdata/blackbox/qrcode-2/1.png"},
+	}
+	for _, tc := range frames {
+		b.Run(tc.name, func(b *testing.B) {
+			img := loadTestImage(tc.path)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				source := zxinggo.NewImageLuminanceSource(img)
+				bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source))
+				zxinggo.Decode(bitmap, zxinggo.RetailPreset())
+			}
+		})
+	}
+}
+
 func BenchmarkEncode(b *testing.B) {
 	for _, tc := range encodeTests {
 		b.Run(tc.name, func(b *testing.B) {
@@ -95,3 +123,61 @@ func BenchmarkEncode(b *testing.B) {
 		})
 	}
 }
+
+// BenchmarkImageLuminanceSource converts a camera-sized frame in the layouts
+// image decoders return.
+func BenchmarkImageLuminanceSource(b *testing.B) {
+	bounds := image.Rect(0, 0, 1280, 720)
+	rgba := image.NewRGBA(bounds)
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			rgba.Set(x, y, color.RGBA{uint8(x), uint8(y), uint8(x + y), 0xFF})
+		}
+	}
+	gray := image.NewGray(bounds)
+	ycbcr := image.NewYCbCr(bounds, image.YCbCrSubsampleRatio420)
+	images := []struct {
+		name string
+		img  image.Image
+	}{
+		{"RGBA", rgba},
+		{"Gray", gray},
+		{"YCbCr", ycbcr},
+	}
+	for _, tc := range images {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				zxinggo.NewImageLuminanceSource(tc.img)
+			}
+		})
+	}
+}
+
+func BenchmarkBinarizer(b *testing.B) {
+	bounds := image.Rect(0, 0, 1280, 720)
+	gray := image.NewGray(bounds)
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			gray.SetGray(x, y, color.Gray{uint8(x ^ y)})
+		}
+	}
+	source := zxinggo.NewImageLuminanceSource(gray)
+	binarizers := []struct {
+		name string
+		new  func(zxinggo.LuminanceSource) zxinggo.Binarizer
+	}{
+		{"Hybrid", func(s zxinggo.LuminanceSource) zxinggo.Binarizer { return binarizer.NewHybrid(s) }},
+		{"GlobalHistogram", func(s zxinggo.LuminanceSource) zxinggo.Binarizer { return binarizer.NewGlobalHistogram(s) }},
+	}
+	for _, tc := range binarizers {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := tc.new(source).BlackMatrix(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -18,6 +18,16 @@ type GlobalHistogram struct {
 	source     zxinggo.LuminanceSource
 	luminances []byte
 	buckets    [luminanceBuckets]int
+	history    *histogramHistory
+}
+
+// histogramHistory is the histogram that the frame binarizers created by a
+// smoothed GlobalHistogram share.
+type histogramHistory struct {
+	weight        float64
+	frames        int
+	width, height int
+	buckets       [luminanceBuckets]float64
 }
 
 // NewGlobalHistogram creates a new GlobalHistogram binarizer.
@@ -25,6 +35,44 @@ func NewGlobalHistogram(source zxinggo.LuminanceSource) *GlobalHistogram {
 	return &GlobalHistogram{source: source}
 }
 
+// NewSmoothedGlobalHistogram returns a GlobalHistogram for use as the
+// BinarizerFactory of a zxinggo.Scanner. The binarizers it creates for
+// frames, with CreateFrameBinarizer, share one histogram: the first frame is sampled as usual, along four rows, and each
+// later frame samples only one of them, in turn, and blends it into the
+// histogram as an exponential moving average in which the new frame has the
+// given weight, between 0 and 1. The black point follows gradual changes in
+// lighting at a quarter of the sampling cost and flickers less between
+// frames. Only BlackMatrix is smoothed; BlackRow is unchanged. Binarizers
+// from CreateBinarizer, as for the crops and rotations of a frame, neither
+// use nor change the histogram.
+//
+// The shared histogram is not safe for concurrent use, and is forgotten
+// when the frame size changes or Reset is called.
+func NewSmoothedGlobalHistogram(weight float64) *GlobalHistogram {
+	return &GlobalHistogram{history: &histogramHistory{weight: weight}}
+}
+
+// CreateBinarizer creates a new GlobalHistogram binarizer with the given
+// source. This implements the BinarizerFactory interface.
+func (g *GlobalHistogram) CreateBinarizer(source zxinggo.LuminanceSource) zxinggo.Binarizer {
+	return &GlobalHistogram{source: source}
+}
+
+// CreateFrameBinarizer creates the binarizer for the next frame, which a
+// smoothed GlobalHistogram binarizes with its shared histogram. This
+// implements the zxinggo.FrameBinarizerFactory interface.
+func (g *GlobalHistogram) CreateFrameBinarizer(source zxinggo.LuminanceSource) zxinggo.Binarizer {
+	return &GlobalHistogram{source: source, history: g.history}
+}
+
+// Reset forgets the histogram shared by a smoothed GlobalHistogram's frame
+// binarizers. Scanner.Reset calls it.
+func (g *GlobalHistogram) Reset() {
+	if g.history != nil {
+		*g.history = histogramHistory{weight: g.history.weight}
+	}
+}
+
 // LuminanceSource returns the underlying source.
 func (g *GlobalHistogram) LuminanceSource() zxinggo.LuminanceSource {
 	return g.source
@@ -83,12 +131,11 @@ func (g *GlobalHistogram) BlackMatrix() (*bitutil.BitMatrix, error) {
 	matrix := bitutil.NewBitMatrixWithSize(width, height)
 
 	g.initArrays(width)
-	for y := 1; y < 5; y++ {
-		row := height * y / 5
-		localLuminances := g.source.Row(row, g.luminances)
-		right := (width * 4) / 5
-		for x := width / 5; x < right; x++ {
-			g.buckets[int(localLuminances[x]&0xff)>>luminanceShift]++
+	if g.history != nil {
+		g.smoothBuckets(width, height)
+	} else {
+		for y := 1; y < 5; y++ {
+			g.sampleRow(height*y/5, width, 1)
 		}
 	}
 	blackPoint, err := estimateBlackPoint(g.buckets[:])
@@ -96,19 +143,56 @@ func (g *GlobalHistogram) BlackMatrix() (*bitutil.BitMatrix, error) {
 		return nil, err
 	}
 
+	if blackPoint == 0 {
+		return matrix, nil
+	}
+	// Pixels darker than the black point are black.
+	thresholds := make([]byte, width)
+	for x := range thresholds {
+		thresholds[x] = byte(blackPoint - 1)
+	}
 	localLuminances := g.source.Matrix()
 	for y := 0; y < height; y++ {
-		offset := y * width
-		for x := 0; x < width; x++ {
-			pixel := int(localLuminances[offset+x] & 0xff)
-			if pixel < blackPoint {
-				matrix.Set(x, y)
-			}
-		}
+		thresholdRow(localLuminances[y*width:(y+1)*width], thresholds, matrix.RowData(y))
 	}
 	return matrix, nil
 }
 
+// sampleRow adds the middle three fifths of row y to the buckets, count
+// times over.
+func (g *GlobalHistogram) sampleRow(y, width, count int) {
+	localLuminances := g.source.Row(y, g.luminances)
+	right := (width * 4) / 5
+	for x := width / 5; x < right; x++ {
+		g.buckets[int(localLuminances[x]&0xff)>>luminanceShift] += count
+	}
+}
+
+// smoothBuckets samples this frame's share of rows, blends them into the
+// shared histogram and sets the buckets to the result.
+func (g *GlobalHistogram) smoothBuckets(width, height int) {
+	h := g.history
+	if h.width != width || h.height != height {
+		*h = histogramHistory{weight: h.weight, width: width, height: height}
+	}
+	if h.frames == 0 {
+		for y := 1; y < 5; y++ {
+			g.sampleRow(height*y/5, width, 1)
+		}
+		for i, count := range g.buckets {
+			h.buckets[i] = float64(count)
+		}
+	} else {
+		// One row counted four times stands in for the four.
+		g.sampleRow(height*(1+h.frames%4)/5, width, 4)
+		for i, count := range g.buckets {
+			h.buckets[i] += h.weight * (float64(count) - h.buckets[i])
+			g.buckets[i] = int(h.buckets[i] + 0.5)
+		}
+	}
+	h.frames++
+}
+
 func (g *GlobalHistogram) initArrays(luminanceSize int) {
 	if len(g.luminances) < luminanceSize {
 		g.luminances = make([]byte, luminanceSize)
@@ -0,0 +1,91 @@
+package binarizer
+
+import (
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+)
+
+// twoToneFrame returns a frame whose left half is dark and right half light.
+func twoToneFrame(width, height int, dark, light byte) zxinggo.LuminanceSource {
+	lum := make([]byte, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := light
+			if x < width/2 {
+				v = dark
+			}
+			lum[y*width+x] = v + byte(x%3)
+		}
+	}
+	return zxinggo.NewLuminanceSourceFromBytes(lum, width, height, width)
+}
+
+func blackMatrix(t *testing.T, b zxinggo.Binarizer) string {
+	t.Helper()
+	matrix, err := b.BlackMatrix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matrix.String()
+}
+
+func TestSmoothedGlobalHistogram(t *testing.T) {
+	dim := twoToneFrame(100, 50, 20, 160)
+	bright := twoToneFrame(100, 50, 130, 250)
+	factory := NewSmoothedGlobalHistogram(0.25)
+
+	// The first frame is binarized as it would be without smoothing.
+	if got, want := blackMatrix(t, factory.CreateFrameBinarizer(dim)), blackMatrix(t, NewGlobalHistogram(dim)); got != want {
+		t.Fatal("first frame differs from GlobalHistogram")
+	}
+	for range 3 {
+		blackMatrix(t, factory.CreateFrameBinarizer(dim))
+	}
+	// A sudden change is damped by the frames before it.
+	want := blackMatrix(t, NewGlobalHistogram(bright))
+	if blackMatrix(t, factory.CreateFrameBinarizer(bright)) == want {
+		t.Fatal("smoothed histogram followed a sudden change in one frame")
+	}
+	// A lasting one is followed.
+	for range 40 {
+		blackMatrix(t, factory.CreateFrameBinarizer(bright))
+	}
+	if blackMatrix(t, factory.CreateFrameBinarizer(bright)) != want {
+		t.Fatal("smoothed histogram did not converge")
+	}
+
+	// Reset and a change of size both forget the history.
+	factory.Reset()
+	if blackMatrix(t, factory.CreateFrameBinarizer(dim)) != blackMatrix(t, NewGlobalHistogram(dim)) {
+		t.Error("frame after Reset differs from GlobalHistogram")
+	}
+	small := twoToneFrame(60, 30, 100, 240)
+	if blackMatrix(t, factory.CreateFrameBinarizer(small)) != blackMatrix(t, NewGlobalHistogram(small)) {
+		t.Error("frame of a new size differs from GlobalHistogram")
+	}
+}
+
+func TestSmoothedGlobalHistogramDerivedBinarizers(t *testing.T) {
+	dim := twoToneFrame(100, 50, 20, 160)
+	bright := twoToneFrame(100, 50, 130, 250)
+	factory := NewSmoothedGlobalHistogram(0.25)
+	frame := factory.CreateFrameBinarizer(dim)
+	blackMatrix(t, frame)
+
+	// Binarizers derived for a rotation or a crop, or for an image outside
+	// the stream, are not smoothed and leave the frames' histogram alone.
+	derived := []zxinggo.Binarizer{
+		zxinggo.NewBinarizerFromSource(frame, bright),
+		zxinggo.NewBinarizerFromSource(frame, twoToneFrame(60, 30, 20, 160)),
+		factory.CreateBinarizer(bright),
+	}
+	for i, b := range derived {
+		if got, want := blackMatrix(t, b), blackMatrix(t, NewGlobalHistogram(b.LuminanceSource())); got != want {
+			t.Errorf("derived binarizer %d is smoothed", i)
+		}
+	}
+	if factory.history.frames != 1 || factory.history.width != 100 {
+		t.Errorf("derived binarizers changed the history: %d frames of width %d", factory.history.frames, factory.history.width)
+	}
+}
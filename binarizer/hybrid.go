@@ -71,12 +71,15 @@ func calculateThresholdForBlock(luminances []byte, subWidth, subHeight, width, h
 	blackPoints [][]int, matrix *bitutil.BitMatrix) {
 	maxYOffset := height - blockSize
 	maxXOffset := width - blockSize
+	// The threshold of each pixel in a row of blocks.
+	thresholds := make([]byte, width)
 	for y := 0; y < subHeight; y++ {
 		yoffset := y << blockSizePower
 		if yoffset > maxYOffset {
 			yoffset = maxYOffset
 		}
 		top := cap3(y, subHeight-3)
+		filled := 0
 		for x := 0; x < subWidth; x++ {
 			xoffset := x << blockSizePower
 			if xoffset > maxXOffset {
@@ -88,8 +91,19 @@ func calculateThresholdForBlock(luminances []byte, subWidth, subHeight, width, h
 				blackRow := blackPoints[top+z]
 				sum += blackRow[left-2] + blackRow[left-1] + blackRow[left] + blackRow[left+1] + blackRow[left+2]
 			}
-			average := sum / 25
-			thresholdBlock(luminances, xoffset, yoffset, average, width, matrix)
+			average := byte(sum / 25)
+			for i := xoffset; i < xoffset+blockSize; i++ {
+				// The last block of a row can overlap the one before it. A
+				// pixel in both is black if either threshold makes it so.
+				if i >= filled || average > thresholds[i] {
+					thresholds[i] = average
+				}
+			}
+			filled = xoffset + blockSize
+		}
+		// Likewise, rows in two rows of blocks keep the pixels set by both.
+		for i := yoffset; i < yoffset+blockSize; i++ {
+			thresholdRow(luminances[i*width:(i+1)*width], thresholds, matrix.RowData(i))
 		}
 	}
 }
@@ -104,16 +118,6 @@ func cap3(value, max int) int {
 	return value
 }
 
-func thresholdBlock(luminances []byte, xoffset, yoffset, threshold, stride int, matrix *bitutil.BitMatrix) {
-	for y, offset := 0, yoffset*stride+xoffset; y < blockSize; y, offset = y+1, offset+stride {
-		for x := 0; x < blockSize; x++ {
-			if int(luminances[offset+x]&0xFF) <= threshold {
-				matrix.Set(xoffset+x, yoffset+y)
-			}
-		}
-	}
-}
-
 func calculateBlackPoints(luminances []byte, subWidth, subHeight, width, height int) [][]int {
 	maxYOffset := height - blockSize
 	maxXOffset := width - blockSize
@@ -0,0 +1,19 @@
+package binarizer
+
+// thresholdRow sets bit x of bits, a row of a bitutil.BitMatrix as returned
+// by RowData, for each pixel x of luminances no brighter than thresholds[x].
+// Bits already set are left set. thresholds must be at least as long as
+// luminances.
+//
+// Builds with the zxinggo_simd tag replace it with a vectorized version; see
+// threshold_simd.go.
+var thresholdRow = thresholdRowGeneric
+
+func thresholdRowGeneric(luminances, thresholds []byte, bits []uint32) {
+	thresholds = thresholds[:len(luminances)]
+	for x, l := range luminances {
+		if l <= thresholds[x] {
+			bits[x>>5] |= 1 << uint(x&0x1f)
+		}
+	}
+}
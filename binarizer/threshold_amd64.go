@@ -0,0 +1,56 @@
+//go:build zxinggo_simd
+
+package binarizer
+
+// thresholdRowAccelerated is thresholdRowAVX2 on processors and operating
+// systems that support AVX2, and nil elsewhere.
+var thresholdRowAccelerated = acceleratedThresholdRow()
+
+func acceleratedThresholdRow() func(luminances, thresholds []byte, bits []uint32) {
+	if hasAVX2() {
+		return thresholdRowAVX2
+	}
+	return nil
+}
+
+// hasAVX2 reports whether the processor has AVX2 and the operating system
+// saves the YMM registers it uses.
+func hasAVX2() bool {
+	maxID, _, _, _ := cpuid(0, 0)
+	if maxID < 7 {
+		return false
+	}
+	_, _, ecx, _ := cpuid(1, 0)
+	const osxsave, avx = 1 << 27, 1 << 28
+	if ecx&osxsave == 0 || ecx&avx == 0 {
+		return false
+	}
+	// XCR0 bits 1 and 2: the XMM and YMM state.
+	if xcr0, _ := xgetbv(); xcr0&6 != 6 {
+		return false
+	}
+	_, ebx, _, _ := cpuid(7, 0)
+	return ebx&(1<<5) != 0
+}
+
+// thresholdRowAVX2 is thresholdRow for 32 pixels at a time, with the pixels
+// at the tail of the row done one at a time.
+func thresholdRowAVX2(luminances, thresholds []byte, bits []uint32) {
+	thresholds = thresholds[:len(luminances)]
+	n := len(luminances) &^ 31
+	if n > 0 {
+		_ = bits[n/32-1]
+		thresholdAVX2(&luminances[0], &thresholds[0], &bits[0], n)
+	}
+	thresholdRowTail(luminances, thresholds, bits, n)
+}
+
+// thresholdAVX2 ORs into the n/32 words at bits the comparisons of the n
+// luminances with their thresholds; n is a multiple of 32.
+//
+//go:noescape
+func thresholdAVX2(luminances, thresholds *byte, bits *uint32, n int)
+
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+func xgetbv() (eax, edx uint32)
@@ -0,0 +1,49 @@
+//go:build zxinggo_simd
+
+#include "textflag.h"
+
+// func thresholdAVX2(luminances, thresholds *byte, bits *uint32, n int)
+TEXT ·thresholdAVX2(SB), NOSPLIT, $0-32
+	MOVQ luminances+0(FP), SI
+	MOVQ thresholds+8(FP), DX
+	MOVQ bits+16(FP), DI
+	MOVQ n+24(FP), CX
+	SHRQ $5, CX
+	JZ   done
+
+loop:
+	// A pixel is dark when it equals the lesser of itself and its threshold.
+	VMOVDQU   (SI), Y0
+	VMOVDQU   (DX), Y1
+	VPMINUB   Y0, Y1, Y2
+	VPCMPEQB  Y0, Y2, Y2
+	VPMOVMSKB Y2, AX
+	ORL       AX, (DI)
+	ADDQ      $32, SI
+	ADDQ      $32, DX
+	ADDQ      $4, DI
+	DECQ      CX
+	JNZ       loop
+	VZEROUPPER
+
+done:
+	RET
+
+// func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+TEXT ·cpuid(SB), NOSPLIT, $0-24
+	MOVL eaxArg+0(FP), AX
+	MOVL ecxArg+4(FP), CX
+	CPUID
+	MOVL AX, eax+8(FP)
+	MOVL BX, ebx+12(FP)
+	MOVL CX, ecx+16(FP)
+	MOVL DX, edx+20(FP)
+	RET
+
+// func xgetbv() (eax, edx uint32)
+TEXT ·xgetbv(SB), NOSPLIT, $0-8
+	MOVL $0, CX
+	XGETBV
+	MOVL AX, eax+0(FP)
+	MOVL DX, edx+4(FP)
+	RET
@@ -0,0 +1,7 @@
+//go:build zxinggo_simd && !amd64
+
+package binarizer
+
+// thresholdRowAccelerated is nil where there is no assembly version, so
+// thresholdRow uses thresholdRowSWAR.
+var thresholdRowAccelerated func(luminances, thresholds []byte, bits []uint32)
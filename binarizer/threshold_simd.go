@@ -0,0 +1,64 @@
+//go:build zxinggo_simd
+
+package binarizer
+
+import "encoding/binary"
+
+// With the zxinggo_simd tag, thresholdRow compares eight pixels at a time
+// in a 64-bit word, or, on amd64 processors with AVX2, 32 at a time in
+// assembly; see threshold_amd64.go. The results are identical to
+// thresholdRowGeneric's.
+
+const (
+	// swarLanes holds 0x01 in the low byte of each 16-bit lane.
+	swarLanes = 0x0001000100010001
+	// swarLowBytes masks the low byte of each 16-bit lane.
+	swarLowBytes = 0x00FF00FF00FF00FF
+	// swarCarries masks the bit above the low byte of each 16-bit lane.
+	swarCarries = 0x0100010001000100
+)
+
+func init() {
+	if thresholdRowAccelerated != nil {
+		thresholdRow = thresholdRowAccelerated
+	} else {
+		thresholdRow = thresholdRowSWAR
+	}
+}
+
+// thresholdRowSWAR is thresholdRow for eight pixels in each 64-bit word,
+// with the pixels at the tail of the row done one at a time.
+func thresholdRowSWAR(luminances, thresholds []byte, bits []uint32) {
+	thresholds = thresholds[:len(luminances)]
+	x := 0
+	for ; x+8 <= len(luminances); x += 8 {
+		mask := lessOrEqual8(binary.LittleEndian.Uint64(luminances[x:]), binary.LittleEndian.Uint64(thresholds[x:]))
+		// x is a multiple of 8, so the eight bits lie in one word.
+		bits[x>>5] |= uint32(mask) << uint(x&0x1f)
+	}
+	thresholdRowTail(luminances, thresholds, bits, x)
+}
+
+// thresholdRowTail does pixels from x to the end of the row one at a time.
+func thresholdRowTail(luminances, thresholds []byte, bits []uint32, x int) {
+	for ; x < len(luminances); x++ {
+		if luminances[x] <= thresholds[x] {
+			bits[x>>5] |= 1 << uint(x&0x1f)
+		}
+	}
+}
+
+// lessOrEqual8 returns a mask with bit i set if byte i of l, counting from
+// the least significant, is no greater than byte i of t. Each byte is
+// compared in a 16-bit lane, where t+256-l keeps its carry bit, 256, only
+// if l <= t, and no borrow can cross into the next lane.
+func lessOrEqual8(l, t uint64) uint8 {
+	even := (t&swarLowBytes | swarCarries) - l&swarLowBytes
+	odd := (t>>8&swarLowBytes | swarCarries) - l>>8&swarLowBytes
+	// Carries of even bytes to bits 0, 16, 32 and 48, of odd ones to 1, 17,
+	// 33 and 49, then each pair of lanes folded down next to the last.
+	m := (even&swarCarries)>>8 | (odd&swarCarries)>>7
+	m |= m >> 14
+	m |= m >> 28
+	return uint8(m)
+}
@@ -0,0 +1,33 @@
+//go:build zxinggo_simd
+
+package binarizer
+
+import "testing"
+
+func TestLessOrEqual8(t *testing.T) {
+	for l := 0; l < 256; l++ {
+		for th := 0; th < 256; th++ {
+			for lane := 0; lane < 8; lane++ {
+				// Fill the other lanes so their comparisons differ.
+				lum := uint64(0xFF00FF00FF00FF00)&^(0xFF<<(8*lane)) | uint64(l)<<(8*lane)
+				thr := uint64(0x00FF00FF00FF00FF)&^(0xFF<<(8*lane)) | uint64(th)<<(8*lane)
+				want := uint8(0x55) &^ (1 << lane)
+				if l <= th {
+					want |= 1 << lane
+				}
+				if got := lessOrEqual8(lum, thr); got != want {
+					t.Fatalf("l %d, t %d, lane %d: mask %08b, want %08b", l, th, lane, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestThresholdRowVariants(t *testing.T) {
+	checkThresholdRow(t, "thresholdRowSWAR", thresholdRowSWAR)
+	if thresholdRowAccelerated == nil {
+		t.Log("no accelerated thresholdRow on this processor")
+		return
+	}
+	checkThresholdRow(t, "thresholdRowAccelerated", thresholdRowAccelerated)
+}
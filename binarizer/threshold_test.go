@@ -0,0 +1,38 @@
+package binarizer
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// checkThresholdRow compares threshold with thresholdRowGeneric on random
+// rows of every length up to 200, with some bits already set.
+func checkThresholdRow(t *testing.T, name string, threshold func(luminances, thresholds []byte, bits []uint32)) {
+	t.Helper()
+	rng := rand.New(rand.NewSource(1))
+	for width := 0; width <= 200; width++ {
+		luminances := make([]byte, width)
+		thresholds := make([]byte, width)
+		for i := range luminances {
+			luminances[i] = byte(rng.Intn(256))
+			// Equal values are black, so make them common.
+			thresholds[i] = luminances[i] + byte(rng.Intn(5)) - 2
+		}
+		want := make([]uint32, (width+31)/32)
+		for i := range want {
+			want[i] = rng.Uint32()
+		}
+		got := append([]uint32(nil), want...)
+		thresholdRowGeneric(luminances, thresholds, want)
+		threshold(luminances, thresholds, got)
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s: width %d: word %d = %08x, want %08x", name, width, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestThresholdRow(t *testing.T) {
+	checkThresholdRow(t, "thresholdRow", thresholdRow)
+}
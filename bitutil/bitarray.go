@@ -51,16 +51,39 @@ func (ba *BitArray) ensureCapacity(newSize int) {
 
 // Get returns true if bit i is set.
 func (ba *BitArray) Get(i int) bool {
+	if boundsChecking {
+		if err := ba.checkBounds("Get", i); err != nil {
+			panic(err)
+		}
+	}
+	return ba.get(i)
+}
+
+func (ba *BitArray) get(i int) bool {
 	return (ba.bits[i/32] & (1 << uint(i&0x1F))) != 0
 }
 
 // Set sets bit i.
 func (ba *BitArray) Set(i int) {
+	if boundsChecking {
+		if err := ba.checkBounds("Set", i); err != nil {
+			panic(err)
+		}
+	}
+	ba.set(i)
+}
+
+func (ba *BitArray) set(i int) {
 	ba.bits[i/32] |= 1 << uint(i&0x1F)
 }
 
 // Flip flips bit i.
 func (ba *BitArray) Flip(i int) {
+	if boundsChecking {
+		if err := ba.checkBounds("Flip", i); err != nil {
+			panic(err)
+		}
+	}
 	ba.bits[i/32] ^= 1 << uint(i&0x1F)
 }
 
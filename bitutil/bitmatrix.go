@@ -1,6 +1,7 @@
 package bitutil
 
 import (
+	"fmt"
 	"math/bits"
 	"strings"
 )
@@ -103,26 +104,89 @@ func ParseStringMatrix(repr, setStr, unsetStr string) *BitMatrix {
 	return matrix
 }
 
+// ParseBitMatrix parses a matrix dumped with String, or with
+// StringWithChars("X ", ". "): one line per row and two characters per bit,
+// "X " for set and "  " or ". " for unset. Dumps pasted into bug reports
+// often lose trailing spaces, so each line may end early, and the matrix is
+// as wide as its longest line. A dump marking unset bits with "." keeps its
+// width however its lines are trimmed; one marking them with spaces loses
+// any columns at the right that are unset in every row. Every line, even an
+// empty one, is a row, except an empty last line after the final newline.
+// Carriage returns are ignored.
+func ParseBitMatrix(dump string) (*BitMatrix, error) {
+	dump = strings.ReplaceAll(dump, "\r", "")
+	lines := strings.Split(strings.TrimSuffix(dump, "\n"), "\n")
+	width := 0
+	for y, line := range lines {
+		for i := 0; i < len(line); i++ {
+			if line[i] != ' ' && (i%2 != 0 || (line[i] != 'X' && line[i] != '.')) {
+				return nil, fmt.Errorf("bitutil: matrix dump has %q at line %d column %d", line[i], y+1, i+1)
+			}
+		}
+		width = max(width, (len(line)+1)/2)
+	}
+	if width == 0 {
+		return nil, fmt.Errorf("bitutil: matrix dump is empty")
+	}
+	bm := NewBitMatrixWithSize(width, len(lines))
+	for y, line := range lines {
+		for x := 0; 2*x < len(line); x++ {
+			if line[2*x] == 'X' {
+				bm.Set(x, y)
+			}
+		}
+	}
+	return bm, nil
+}
+
 // Get returns true if the bit at (x, y) is set.
 func (bm *BitMatrix) Get(x, y int) bool {
+	if boundsChecking {
+		if err := bm.checkBounds("Get", x, y); err != nil {
+			panic(err)
+		}
+	}
+	return bm.get(x, y)
+}
+
+func (bm *BitMatrix) get(x, y int) bool {
 	offset := y*bm.rowSize + x/32
 	return (bm.data[offset]>>uint(x&0x1f))&1 != 0
 }
 
 // Set sets the bit at (x, y).
 func (bm *BitMatrix) Set(x, y int) {
+	if boundsChecking {
+		if err := bm.checkBounds("Set", x, y); err != nil {
+			panic(err)
+		}
+	}
+	bm.set(x, y)
+}
+
+func (bm *BitMatrix) set(x, y int) {
 	offset := y*bm.rowSize + x/32
 	bm.data[offset] |= 1 << uint(x&0x1f)
 }
 
 // Unset clears the bit at (x, y).
 func (bm *BitMatrix) Unset(x, y int) {
+	if boundsChecking {
+		if err := bm.checkBounds("Unset", x, y); err != nil {
+			panic(err)
+		}
+	}
 	offset := y*bm.rowSize + x/32
 	bm.data[offset] &^= 1 << uint(x&0x1f)
 }
 
 // Flip flips the bit at (x, y).
 func (bm *BitMatrix) Flip(x, y int) {
+	if boundsChecking {
+		if err := bm.checkBounds("Flip", x, y); err != nil {
+			panic(err)
+		}
+	}
 	offset := y*bm.rowSize + x/32
 	bm.data[offset] ^= 1 << uint(x&0x1f)
 }
@@ -191,6 +255,18 @@ func (bm *BitMatrix) Row(y int, row *BitArray) *BitArray {
 	return row
 }
 
+// RowData returns the words of row y, 32 pixels to a word with the leftmost
+// in the lowest bit. It shares the matrix's storage, for loops that set
+// many pixels of a row at once.
+func (bm *BitMatrix) RowData(y int) []uint32 {
+	if boundsChecking {
+		if err := bm.checkBounds("RowData", 0, y); err != nil {
+			panic(err)
+		}
+	}
+	return bm.data[y*bm.rowSize : (y+1)*bm.rowSize]
+}
+
 // SetRow sets the row at y from the given BitArray.
 func (bm *BitMatrix) SetRow(y int, row *BitArray) {
 	copy(bm.data[y*bm.rowSize:], row.BitData()[:bm.rowSize])
@@ -1,6 +1,9 @@
 package bitutil
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestBitMatrixGetSet(t *testing.T) {
 	bm := NewBitMatrixWithSize(10, 10)
@@ -130,3 +133,45 @@ func TestBitMatrixEquals(t *testing.T) {
 		t.Error("different matrices should not be equal")
 	}
 }
+
+func TestParseBitMatrix(t *testing.T) {
+	bm := NewBitMatrixWithSize(5, 4)
+	bm.Set(0, 0)
+	bm.Set(4, 0)
+	bm.Set(2, 2)
+	bm.Set(4, 3)
+	dump := bm.String()
+	// Row 1 is blank; editors strip its spaces and those ending other rows.
+	var stripped []string
+	for _, line := range strings.Split(dump, "\n") {
+		stripped = append(stripped, strings.TrimRight(line, " "))
+	}
+	for _, d := range []string{dump, strings.Join(stripped, "\r\n")} {
+		parsed, err := ParseBitMatrix(d)
+		if err != nil {
+			t.Fatalf("ParseBitMatrix(%q): %v", d, err)
+		}
+		if !parsed.Equals(bm) {
+			t.Errorf("ParseBitMatrix(%q) =\n%s\nwant\n%s", d, parsed, bm)
+		}
+	}
+	// Dots keep the width of a matrix whose last column is unset.
+	bm.Unset(4, 0)
+	bm.Unset(4, 3)
+	var dotted []string
+	for _, line := range strings.Split(bm.StringWithChars("X ", ". "), "\n") {
+		dotted = append(dotted, strings.TrimRight(line, " "))
+	}
+	parsed, err := ParseBitMatrix(strings.Join(dotted, "\n"))
+	if err != nil {
+		t.Fatalf("ParseBitMatrix: %v", err)
+	}
+	if !parsed.Equals(bm) {
+		t.Errorf("ParseBitMatrix(dotted) =\n%s\nwant\n%s", parsed, bm)
+	}
+	for _, bad := range []string{"", "X X\n O\n", " X\n", " .\n"} {
+		if _, err := ParseBitMatrix(bad); err == nil {
+			t.Errorf("ParseBitMatrix(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build zxinggo_checked
+
+package bitutil
+
+import "testing"
+
+func TestBitMatrixGetPanicsWhenChecked(t *testing.T) {
+	defer func() {
+		if _, ok := recover().(*IndexError); !ok {
+			t.Error("expected *IndexError panic")
+		}
+	}()
+	NewBitMatrixWithSize(10, 10).Get(-1, 5)
+}
@@ -0,0 +1,6 @@
+//go:build !zxinggo_checked
+
+package bitutil
+
+// boundsChecking enables panicking with *IndexError on out-of-range access.
+const boundsChecking = false
@@ -0,0 +1,83 @@
+package bitutil
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrIndexOutOfRange is wrapped by every *IndexError.
+var ErrIndexOutOfRange = errors.New("bitutil: index out of range")
+
+// IndexError reports an access outside the bounds of a BitMatrix or BitArray.
+// Builds with the zxinggo_checked tag panic with an *IndexError from Get, Set,
+// Unset and Flip instead of silently reading or writing a neighbouring bit;
+// the Checked accessors return it in every build.
+type IndexError struct {
+	Op            string
+	X, Y          int
+	Width, Height int
+}
+
+func (e *IndexError) Error() string {
+	if e.Height == 0 {
+		return fmt.Sprintf("bitutil: BitArray.%s(%d) out of range [0,%d)", e.Op, e.X, e.Width)
+	}
+	return fmt.Sprintf("bitutil: BitMatrix.%s(%d, %d) out of range %dx%d", e.Op, e.X, e.Y, e.Width, e.Height)
+}
+
+// Unwrap returns ErrIndexOutOfRange.
+func (e *IndexError) Unwrap() error {
+	return ErrIndexOutOfRange
+}
+
+func (bm *BitMatrix) checkBounds(op string, x, y int) *IndexError {
+	if x < 0 || y < 0 || x >= bm.width || y >= bm.height {
+		return &IndexError{Op: op, X: x, Y: y, Width: bm.width, Height: bm.height}
+	}
+	return nil
+}
+
+func (ba *BitArray) checkBounds(op string, i int) *IndexError {
+	if i < 0 || i >= ba.size {
+		return &IndexError{Op: op, X: i, Width: ba.size}
+	}
+	return nil
+}
+
+// GetChecked is like Get but returns an *IndexError if (x, y) is outside the
+// matrix.
+func (bm *BitMatrix) GetChecked(x, y int) (bool, error) {
+	if err := bm.checkBounds("Get", x, y); err != nil {
+		return false, err
+	}
+	return bm.get(x, y), nil
+}
+
+// SetChecked is like Set but returns an *IndexError if (x, y) is outside the
+// matrix.
+func (bm *BitMatrix) SetChecked(x, y int) error {
+	if err := bm.checkBounds("Set", x, y); err != nil {
+		return err
+	}
+	bm.set(x, y)
+	return nil
+}
+
+// GetChecked is like Get but returns an *IndexError if i is outside the
+// array.
+func (ba *BitArray) GetChecked(i int) (bool, error) {
+	if err := ba.checkBounds("Get", i); err != nil {
+		return false, err
+	}
+	return ba.get(i), nil
+}
+
+// SetChecked is like Set but returns an *IndexError if i is outside the
+// array.
+func (ba *BitArray) SetChecked(i int) error {
+	if err := ba.checkBounds("Set", i); err != nil {
+		return err
+	}
+	ba.set(i)
+	return nil
+}
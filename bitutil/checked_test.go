@@ -0,0 +1,40 @@
+package bitutil
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBitMatrixChecked(t *testing.T) {
+	bm := NewBitMatrixWithSize(40, 3)
+	if err := bm.SetChecked(39, 2); err != nil {
+		t.Fatalf("SetChecked in range: %v", err)
+	}
+	if v, err := bm.GetChecked(39, 2); err != nil || !v {
+		t.Errorf("GetChecked(39, 2) = %v, %v", v, err)
+	}
+	for _, p := range [][2]int{{-1, 0}, {40, 0}, {0, 3}, {0, -1}} {
+		_, err := bm.GetChecked(p[0], p[1])
+		var indexErr *IndexError
+		if !errors.As(err, &indexErr) || !errors.Is(err, ErrIndexOutOfRange) {
+			t.Errorf("GetChecked(%d, %d) err = %v, want *IndexError", p[0], p[1], err)
+		}
+		if err := bm.SetChecked(p[0], p[1]); err == nil {
+			t.Errorf("SetChecked(%d, %d) succeeded", p[0], p[1])
+		}
+	}
+}
+
+func TestBitArrayChecked(t *testing.T) {
+	ba := NewBitArray(10)
+	if err := ba.SetChecked(9); err != nil {
+		t.Fatalf("SetChecked in range: %v", err)
+	}
+	if v, err := ba.GetChecked(9); err != nil || !v {
+		t.Errorf("GetChecked(9) = %v, %v", v, err)
+	}
+	// Index 10 is within the backing word but past the array's size.
+	if _, err := ba.GetChecked(10); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("GetChecked(10) err = %v", err)
+	}
+}
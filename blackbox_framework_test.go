@@ -2,6 +2,7 @@ package zxinggo_test
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"image"
 	_ "image/gif"
@@ -13,15 +14,25 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/binarizer"
+	"github.com/ericlevine/zxinggo/imaging"
 	"github.com/ericlevine/zxinggo/pdf417"
 )
 
-// blackboxTestDir is the path to the blackbox test resources (copied from Java ZXing).
+// blackboxTestDir is the path to the blackbox test resources (copied from
+// Java ZXing, except the synthetic images in code93-2).
 const blackboxTestDir = "testdata/blackbox"
 
+// blackboxImageBudget is the time decoding one image, at one rotation, with
+// or without TryHarder, may take. Decoding is given it as a deadline, and
+// an image that overruns it fails the test, catching detectors that have
+// become pathologically slow, which the pass counts alone would not.
+var blackboxImageBudget = flag.Duration("blackbox.budget", 2*time.Second,
+	"time decoding one blackbox image may take")
+
 // blackboxTestRotation defines expected pass/fail thresholds for one rotation angle.
 type blackboxTestRotation struct {
 	rotation             float64
@@ -39,58 +50,6 @@ type blackboxTestCase struct {
 	opts   *zxinggo.DecodeOptions // optional extra decode options
 }
 
-// rotateImage rotates an image by the given degrees (must be a multiple of 90).
-func rotateImage(img image.Image, degrees float64) image.Image {
-	switch int(degrees) % 360 {
-	case 0:
-		return img
-	case 90:
-		return rotate90(img)
-	case 180:
-		return rotate180(img)
-	case 270:
-		return rotate270(img)
-	default:
-		panic(fmt.Sprintf("unsupported rotation: %v degrees", degrees))
-	}
-}
-
-func rotate90(img image.Image) image.Image {
-	b := img.Bounds()
-	w, h := b.Dx(), b.Dy()
-	dst := image.NewRGBA(image.Rect(0, 0, h, w))
-	for y := 0; y < h; y++ {
-		for x := 0; x < w; x++ {
-			dst.Set(h-1-y, x, img.At(b.Min.X+x, b.Min.Y+y))
-		}
-	}
-	return dst
-}
-
-func rotate180(img image.Image) image.Image {
-	b := img.Bounds()
-	w, h := b.Dx(), b.Dy()
-	dst := image.NewRGBA(image.Rect(0, 0, w, h))
-	for y := 0; y < h; y++ {
-		for x := 0; x < w; x++ {
-			dst.Set(w-1-x, h-1-y, img.At(b.Min.X+x, b.Min.Y+y))
-		}
-	}
-	return dst
-}
-
-func rotate270(img image.Image) image.Image {
-	b := img.Bounds()
-	w, h := b.Dx(), b.Dy()
-	dst := image.NewRGBA(image.Rect(0, 0, h, w))
-	for y := 0; y < h; y++ {
-		for x := 0; x < w; x++ {
-			dst.Set(y, w-1-x, img.At(b.Min.X+x, b.Min.Y+y))
-		}
-	}
-	return dst
-}
-
 // loadExpectedText loads expected barcode text from a .txt or .bin file.
 func loadExpectedText(basePath string) (string, error) {
 	// Try .txt first (UTF-8)
@@ -273,6 +232,7 @@ func runBlackBoxTest(t *testing.T, tc blackboxTestCase) {
 	misreadCounts := make([]int, testCount)
 	tryHarderCounts := make([]int, testCount)
 	tryHarderMisreadCounts := make([]int, testCount)
+	var slowest imageTiming
 
 	for _, td := range testData {
 		// Load image
@@ -288,12 +248,12 @@ func runBlackBoxTest(t *testing.T, tc blackboxTestCase) {
 		}
 
 		for i, rot := range tc.tests {
-			rotated := rotateImage(img, rot.rotation)
+			rotated := imaging.Rotate(img, rot.rotation)
 
 			// Normal decode (no TryHarder)
 			source := zxinggo.NewImageLuminanceSource(rotated)
-			bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source))
-			result := tryDecode(bitmap, tc.format, false, tc.opts)
+			result, elapsed := timeDecode(source, tc.format, false, tc.opts)
+			slowest.check(t, imageTiming{td.path, rot.rotation, false, elapsed})
 			outcome := classifyResult(result, tc.format, td.expectedText, td.metadata)
 			switch outcome {
 			case resultPassed:
@@ -308,9 +268,8 @@ func runBlackBoxTest(t *testing.T, tc blackboxTestCase) {
 			}
 
 			// TryHarder decode
-			source2 := zxinggo.NewImageLuminanceSource(rotated)
-			bitmap2 := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source2))
-			result2 := tryDecode(bitmap2, tc.format, true, tc.opts)
+			result2, elapsed2 := timeDecode(source, tc.format, true, tc.opts)
+			slowest.check(t, imageTiming{td.path, rot.rotation, true, elapsed2})
 			outcome2 := classifyResult(result2, tc.format, td.expectedText, td.metadata)
 			switch outcome2 {
 			case resultPassed:
@@ -345,6 +304,7 @@ func runBlackBoxTest(t *testing.T, tc blackboxTestCase) {
 
 	t.Logf("Total: %d found of %d needed, %d misread of %d max",
 		totalFound, totalMustPass, totalMisread, totalMaxMisread)
+	t.Logf("Slowest: %v (budget %v)", slowest, *blackboxImageBudget)
 
 	if totalFound > totalMustPass {
 		t.Logf("+++ Test too lax by %d images", totalFound-totalMustPass)
@@ -403,9 +363,50 @@ func classifyResult(result *zxinggo.Result, format zxinggo.Format, expectedText
 	return resultPassed
 }
 
-// tryDecode attempts to decode a barcode, trying PureBarcode first then normal.
-// Recovers from panics in decoders to prevent one bad image from crashing the entire test.
-func tryDecode(bitmap *zxinggo.BinaryBitmap, format zxinggo.Format, tryHarder bool, extraOpts *zxinggo.DecodeOptions) (result *zxinggo.Result) {
+// imageTiming is how long decoding one image took.
+type imageTiming struct {
+	path      string
+	rotation  float64
+	tryHarder bool
+	elapsed   time.Duration
+}
+
+func (it imageTiming) String() string {
+	th := ""
+	if it.tryHarder {
+		th = "(TH)"
+	}
+	return fmt.Sprintf("%v rot=%.0f%s file=%s", it.elapsed.Round(time.Millisecond), it.rotation, th, filepath.Base(it.path))
+}
+
+// check fails t if timing overran blackboxImageBudget, and keeps it in
+// slowest if it is the slowest yet.
+func (slowest *imageTiming) check(t *testing.T, timing imageTiming) {
+	t.Helper()
+	if timing.elapsed > *blackboxImageBudget {
+		t.Errorf("  TIMEOUT %v, budget %v", timing, *blackboxImageBudget)
+	}
+	if timing.elapsed > slowest.elapsed {
+		*slowest = timing
+	}
+}
+
+// timeDecode is tryDecode with blackboxImageBudget as its deadline,
+// returning how long it took.
+func timeDecode(source zxinggo.LuminanceSource, format zxinggo.Format, tryHarder bool, extraOpts *zxinggo.DecodeOptions) (*zxinggo.Result, time.Duration) {
+	start := time.Now()
+	result := tryDecode(source, format, tryHarder, extraOpts, start.Add(*blackboxImageBudget))
+	return result, time.Since(start)
+}
+
+// blackboxLadder binarizes with Hybrid, trying PureBarcode first then
+// normal, as the Java tests do.
+var blackboxLadder = zxinggo.NewLadder(binarizer.NewHybrid(nil)).PureFirst()
+
+// tryDecode attempts to decode a barcode by climbing blackboxLadder, giving
+// up at deadline if it is not zero. Recovers from panics in decoders to
+// prevent one bad image from crashing the entire test.
+func tryDecode(source zxinggo.LuminanceSource, format zxinggo.Format, tryHarder bool, extraOpts *zxinggo.DecodeOptions, deadline time.Time) (result *zxinggo.Result) {
 	defer func() {
 		if r := recover(); r != nil {
 			result = nil
@@ -415,34 +416,18 @@ func tryDecode(bitmap *zxinggo.BinaryBitmap, format zxinggo.Format, tryHarder bo
 	opts := &zxinggo.DecodeOptions{
 		PossibleFormats: []zxinggo.Format{format},
 		TryHarder:       tryHarder,
-		PureBarcode:     true,
+		Deadline:        deadline,
 	}
 	if extraOpts != nil {
 		opts.AlsoInverted = extraOpts.AlsoInverted
 		opts.AllowedEANExtensions = extraOpts.AllowedEANExtensions
+		opts.Profile = extraOpts.Profile
 	}
-
-	// Try PureBarcode first (like Java)
-	result, err := zxinggo.Decode(bitmap, opts)
-	if err == nil {
-		return result
-	}
-
-	// Fall back to normal decode
-	opts2 := &zxinggo.DecodeOptions{
-		PossibleFormats: []zxinggo.Format{format},
-		TryHarder:       tryHarder,
-	}
-	if extraOpts != nil {
-		opts2.AlsoInverted = extraOpts.AlsoInverted
-		opts2.AllowedEANExtensions = extraOpts.AllowedEANExtensions
-	}
-	result, err = zxinggo.Decode(bitmap, opts2)
-	if err == nil {
-		return result
+	result, err := blackboxLadder.Decode(source, opts)
+	if err != nil {
+		return nil
 	}
-
-	return nil
+	return result
 }
 
 // Helper to create test rotation with just pass counts (maxMisreads=0)
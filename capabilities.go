@@ -0,0 +1,92 @@
+package zxinggo
+
+// Capability describes what this build supports for a barcode format.
+type Capability struct {
+	Format Format
+
+	// Read and Write report whether a reader or writer is registered for the
+	// format, that is whether the package implementing it is imported.
+	Read  bool
+	Write bool
+
+	// Multi reports whether several symbols of the format can be read from
+	// one image, as multi.GenericMultipleBarcodeReader does for any format
+	// that can be read.
+	Multi bool
+
+	// ECI reports whether Extended Channel Interpretations in decoded
+	// symbols are honoured.
+	ECI bool
+
+	// GS1 reports whether GS1 data, marked by FNC1, is recognised in decoded
+	// symbols.
+	GS1 bool
+
+	// StructuredAppend reports whether decoded symbols report their place in
+	// a sequence of symbols carrying one message.
+	StructuredAppend bool
+}
+
+// formatFeature flags what the decoder of a format supports beyond reading
+// the symbol's text.
+type formatFeature int
+
+const (
+	featureECI formatFeature = 1 << iota
+	featureGS1
+	featureStructuredAppend
+)
+
+var formatFeatures = map[Format]formatFeature{
+	FormatQRCode:      featureECI | featureGS1 | featureStructuredAppend,
+	FormatDataMatrix:  featureGS1,
+	FormatPDF417:      featureECI | featureStructuredAppend,
+	FormatAztec:       featureECI | featureGS1 | featureStructuredAppend,
+	FormatHanXin:      featureECI,
+	FormatCode128:     featureGS1,
+	FormatRSS14:       featureGS1,
+	FormatRSSExpanded: featureGS1,
+	FormatDotCode:     featureGS1,
+	FormatCode16K:     featureGS1,
+}
+
+// Capabilities returns the support for every format in this build, in
+// Format order. Reading and writing depend on which format packages are
+// imported, so the result is only complete once they are initialized.
+func Capabilities() []Capability {
+	capabilities := make([]Capability, formatCount)
+	for f := range formatCount {
+		capabilities[f] = CapabilityOf(f)
+	}
+	return capabilities
+}
+
+// ReadableFormats returns the formats a reader is registered for, that is
+// whose packages are imported, in Format order. These are what Decode tries
+// when DecodeOptions.PossibleFormats is empty, though some, such as DotCode
+// and the postal formats, only look for symbols when asked for by name.
+func ReadableFormats() []Format {
+	var formats []Format
+	for f := range formatCount {
+		if _, ok := readerFactories[f]; ok {
+			formats = append(formats, f)
+		}
+	}
+	return formats
+}
+
+// CapabilityOf returns the support for format in this build.
+func CapabilityOf(format Format) Capability {
+	_, read := readerFactories[format]
+	_, write := writerFactories[format]
+	features := formatFeatures[format]
+	return Capability{
+		Format:           format,
+		Read:             read,
+		Write:            write,
+		Multi:            read,
+		ECI:              features&featureECI != 0,
+		GS1:              features&featureGS1 != 0,
+		StructuredAppend: features&featureStructuredAppend != 0,
+	}
+}
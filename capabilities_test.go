@@ -0,0 +1,82 @@
+package zxinggo_test
+
+import (
+	"errors"
+	"image"
+	"slices"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+)
+
+func TestCapabilities(t *testing.T) {
+	capabilities := zxinggo.Capabilities()
+	for i, c := range capabilities {
+		if c.Format != zxinggo.Format(i) || c.Format.String() == "UNKNOWN" {
+			t.Fatalf("capability %d is for %v", i, c.Format)
+		}
+	}
+	if len(capabilities) != int(zxinggo.FormatCode16K)+1 {
+		t.Errorf("%d capabilities, want one per format", len(capabilities))
+	}
+
+	tests := []struct {
+		format zxinggo.Format
+		want   zxinggo.Capability
+	}{
+		// The test binary imports the QR code and 1D packages, but not Han Xin.
+		{zxinggo.FormatQRCode, zxinggo.Capability{Read: true, Write: true, Multi: true, ECI: true, GS1: true, StructuredAppend: true}},
+		{zxinggo.FormatRSSExpanded, zxinggo.Capability{Read: true, Multi: true, GS1: true}},
+		{zxinggo.FormatCode39, zxinggo.Capability{Read: true, Write: true, Multi: true}},
+		{zxinggo.FormatHanXin, zxinggo.Capability{ECI: true}},
+	}
+	for _, tt := range tests {
+		tt.want.Format = tt.format
+		if got := capabilities[tt.format]; got != tt.want {
+			t.Errorf("%v: capability = %+v, want %+v", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeWithUnregisteredFormat(t *testing.T) {
+	source := zxinggo.NewImageLuminanceSource(image.NewGray(image.Rect(0, 0, 10, 10)))
+	bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source))
+	_, err := zxinggo.NewMultiFormatReader().DecodeWithFormat(bitmap, zxinggo.FormatHanXin, nil)
+	if !errors.Is(err, zxinggo.ErrNotFound) || err.Error() != "no reader registered for format HAN_XIN: barcode not found" {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestReadableFormats(t *testing.T) {
+	formats := zxinggo.ReadableFormats()
+	for _, f := range []zxinggo.Format{zxinggo.FormatQRCode, zxinggo.FormatAztec, zxinggo.FormatDataMatrix, zxinggo.FormatMaxiCode, zxinggo.FormatRSS14} {
+		if !slices.Contains(formats, f) {
+			t.Errorf("%v not readable", f)
+		}
+	}
+	if slices.Contains(formats, zxinggo.FormatHanXin) {
+		t.Error("Han Xin readable without its package")
+	}
+	if !slices.IsSorted(formats) {
+		t.Errorf("formats %v not in Format order", formats)
+	}
+}
+
+func TestDecodeFormatFamily(t *testing.T) {
+	matrix, err := zxinggo.Encode("ABC-123", zxinggo.FormatCode128, 200, 50, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decode := func(formats ...zxinggo.Format) (*zxinggo.Result, error) {
+		source := zxinggo.NewGrayImageLuminanceSource(zxinggo.BitMatrixToImage(matrix))
+		bitmap := zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(source))
+		return zxinggo.Decode(bitmap, &zxinggo.DecodeOptions{PossibleFormats: formats, PureBarcode: true})
+	}
+	if result, err := decode(zxinggo.FormatAnyOneD); err != nil || result.Text != "ABC-123" {
+		t.Errorf("ANY_1D: %v, %v", result, err)
+	}
+	if result, err := decode(zxinggo.FormatAnyRetail); err == nil {
+		t.Errorf("ANY_RETAIL read %s %q", result.Format, result.Text)
+	}
+}
@@ -0,0 +1,151 @@
+package zxinggo
+
+import "fmt"
+
+// CapacityMode is a kind of data whose capacity CapacityFor measures.
+type CapacityMode int
+
+const (
+	// CapacityNumeric measures capacity in decimal digits.
+	CapacityNumeric CapacityMode = iota
+	// CapacityAlphanumeric measures capacity in upper-case letters, which
+	// every format encodes compactly.
+	CapacityAlphanumeric
+	// CapacityByte measures capacity in binary bytes from 0x80 to 0xFF,
+	// which no format's text modes hold, so that every format stores them
+	// in its byte mode.
+	CapacityByte
+)
+
+// String returns the mode's name.
+func (m CapacityMode) String() string {
+	switch m {
+	case CapacityNumeric:
+		return "NUMERIC"
+	case CapacityAlphanumeric:
+		return "ALPHANUMERIC"
+	case CapacityByte:
+		return "BYTE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// SymbolSize identifies a symbol size of a format.
+type SymbolSize struct {
+	Format Format
+
+	// Version numbers the size within the format: the QR Code version, the
+	// Data Matrix version number (see datamatrix/decoder.Versions), the
+	// Aztec layer count, negative for compact symbols, or the number of
+	// PDF417 data columns. It is 0 for formats with a single size.
+	Version int
+
+	// Width and Height are the size of the symbol in modules, without quiet
+	// zone. A PDF417 symbol's Height counts rows.
+	Width, Height int
+
+	// ECLevel is the error correction level, in the form
+	// EncodeOptions.ErrorCorrection takes for the format.
+	ECLevel string
+}
+
+// CapacityPlanner sizes the symbols of a format. Format packages register
+// one with RegisterCapacityPlanner alongside their writer.
+type CapacityPlanner interface {
+	// Fits reports whether the writer can encode payload in a symbol of the
+	// given version and error correction level. It returns an error wrapping
+	// ErrWriter if the version or level does not exist. CapacityFor passes
+	// payload as a string of bytes, which in byte mode are not valid UTF-8.
+	Fits(payload string, version int, ecLevel string) (bool, error)
+
+	// SmallestSymbolFor returns the size of the symbol the writer would
+	// encode payload in with opts, which may be nil.
+	SmallestSymbolFor(payload string, opts *EncodeOptions) (SymbolSize, error)
+}
+
+var capacityPlanners = map[Format]CapacityPlanner{}
+
+// RegisterCapacityPlanner registers the capacity planner for the given
+// format. This should be called from an init() function in format-specific
+// packages.
+func RegisterCapacityPlanner(format Format, planner CapacityPlanner) {
+	capacityPlanners[format] = planner
+}
+
+// CapacityFor returns how many characters of the given mode the writer can
+// encode in a symbol of format at the given version and error correction
+// level; see SymbolSize for the meaning of version and ecLevel, which may be
+// empty for the writer's default. The capacity is that of this library's
+// encoder, measured by encoding, so it can fall short of the figures in a
+// format's specification where the encoder is less compact.
+// Only the 2D formats register planners; CapacityFor returns an error
+// wrapping ErrWriter for the others.
+func CapacityFor(format Format, version int, ecLevel string, mode CapacityMode) (int, error) {
+	planner, ok := capacityPlanners[format]
+	if !ok {
+		return 0, fmt.Errorf("no capacity planner registered for format %s: %w", format, ErrWriter)
+	}
+	if mode < CapacityNumeric || mode > CapacityByte {
+		return 0, fmt.Errorf("unknown capacity mode %d: %w", mode, ErrWriter)
+	}
+	fits := func(n int) (bool, error) {
+		return planner.Fits(capacityPayload(mode, n), version, ecLevel)
+	}
+	// Double the length until it no longer fits, then bisect.
+	if ok, err := fits(1); err != nil || !ok {
+		return 0, err
+	}
+	low, high := 1, 2
+	for {
+		ok, err := fits(high)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			break
+		}
+		low, high = high, 2*high
+	}
+	// low fits and high does not.
+	for high-low > 1 {
+		mid := (low + high) / 2
+		ok, err := fits(mid)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return low, nil
+}
+
+// SmallestSymbolFor returns the size of the smallest symbol of format that
+// holds payload, encoded as Encode would with opts, which may be nil. It
+// returns an error wrapping ErrWriter if no symbol holds it.
+func SmallestSymbolFor(payload string, format Format, opts *EncodeOptions) (SymbolSize, error) {
+	planner, ok := capacityPlanners[format]
+	if !ok {
+		return SymbolSize{}, fmt.Errorf("no capacity planner registered for format %s: %w", format, ErrWriter)
+	}
+	return planner.SmallestSymbolFor(payload, opts)
+}
+
+// capacityPayload returns n bytes of representative data of mode.
+func capacityPayload(mode CapacityMode, n int) string {
+	payload := make([]byte, n)
+	for i := range payload {
+		switch mode {
+		case CapacityNumeric:
+			payload[i] = '0' + byte(i%10)
+		case CapacityAlphanumeric:
+			payload[i] = 'A' + byte(i%26)
+		default:
+			payload[i] = 0x80 + byte(i%128)
+		}
+	}
+	return string(payload)
+}
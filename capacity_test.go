@@ -0,0 +1,142 @@
+package zxinggo_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	_ "github.com/ericlevine/zxinggo/aztec"
+	_ "github.com/ericlevine/zxinggo/datamatrix"
+	_ "github.com/ericlevine/zxinggo/pdf417"
+	_ "github.com/ericlevine/zxinggo/qrcode"
+)
+
+func TestCapacityForQRCode(t *testing.T) {
+	tests := []struct {
+		version int
+		ecLevel string
+		mode    zxinggo.CapacityMode
+		want    int
+	}{
+		{1, "L", zxinggo.CapacityNumeric, 41},
+		{1, "L", zxinggo.CapacityAlphanumeric, 25},
+		{1, "L", zxinggo.CapacityByte, 17},
+		{1, "H", zxinggo.CapacityNumeric, 17},
+		{10, "M", zxinggo.CapacityAlphanumeric, 311},
+		{40, "L", zxinggo.CapacityNumeric, 7089},
+		{40, "H", zxinggo.CapacityByte, 1273},
+	}
+	for _, tt := range tests {
+		got, err := zxinggo.CapacityFor(zxinggo.FormatQRCode, tt.version, tt.ecLevel, tt.mode)
+		if err != nil {
+			t.Fatalf("version %d-%s %v: %v", tt.version, tt.ecLevel, tt.mode, err)
+		}
+		if got != tt.want {
+			t.Errorf("version %d-%s %v: capacity %d, want %d", tt.version, tt.ecLevel, tt.mode, got, tt.want)
+		}
+	}
+}
+
+// TestSmallestSymbolForMatchesCapacity fills each size to its capacity and
+// checks that one character more moves to a larger symbol.
+func TestSmallestSymbolForMatchesCapacity(t *testing.T) {
+	tests := []struct {
+		format  zxinggo.Format
+		version int
+		ecLevel string
+	}{
+		{zxinggo.FormatQRCode, 1, "L"},
+		{zxinggo.FormatQRCode, 7, "Q"},
+		{zxinggo.FormatDataMatrix, 1, ""},
+		{zxinggo.FormatDataMatrix, 12, ""},
+		{zxinggo.FormatAztec, -2, ""},
+		{zxinggo.FormatAztec, 6, ""},
+	}
+	for _, tt := range tests {
+		n, err := zxinggo.CapacityFor(tt.format, tt.version, tt.ecLevel, zxinggo.CapacityNumeric)
+		if err != nil {
+			t.Fatalf("%v version %d: %v", tt.format, tt.version, err)
+		}
+		opts := &zxinggo.EncodeOptions{ErrorCorrection: tt.ecLevel}
+		payload := strings.Repeat("7", n)
+		size, err := zxinggo.SmallestSymbolFor(payload, tt.format, opts)
+		if err != nil {
+			t.Fatalf("%v: %d digits: %v", tt.format, n, err)
+		}
+		if size.Version != tt.version {
+			t.Errorf("%v: %d digits in version %d, want %d", tt.format, n, size.Version, tt.version)
+		}
+		larger, err := zxinggo.SmallestSymbolFor(payload+"7", tt.format, opts)
+		if err != nil {
+			t.Fatalf("%v: %d digits: %v", tt.format, n+1, err)
+		}
+		if larger.Width*larger.Height <= size.Width*size.Height {
+			t.Errorf("%v: %d digits fit %dx%d, no larger than %d digits", tt.format, n+1, larger.Width, larger.Height, n)
+		}
+	}
+}
+
+func TestCapacityForPDF417(t *testing.T) {
+	narrow, err := zxinggo.CapacityFor(zxinggo.FormatPDF417, 2, "", zxinggo.CapacityByte)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wide, err := zxinggo.CapacityFor(zxinggo.FormatPDF417, 10, "", zxinggo.CapacityByte)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if narrow == 0 || wide <= narrow {
+		t.Errorf("capacity %d bytes in 2 columns, %d in 10", narrow, wide)
+	}
+	size, err := zxinggo.SmallestSymbolFor("PDF417 capacity", zxinggo.FormatPDF417, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size.Width != 17*size.Version+69 || size.Height < 3 || size.ECLevel != "2" {
+		t.Errorf("size %+v", size)
+	}
+}
+
+// TestCapacityByteBelowAlphanumeric checks that byte capacity is measured
+// with data no format can pack more tightly than a byte a character.
+func TestCapacityByteBelowAlphanumeric(t *testing.T) {
+	tests := []struct {
+		format  zxinggo.Format
+		version int
+	}{
+		{zxinggo.FormatQRCode, 10},
+		{zxinggo.FormatDataMatrix, 12},
+		{zxinggo.FormatAztec, 32},
+		{zxinggo.FormatPDF417, 30},
+	}
+	for _, tt := range tests {
+		alphanumeric, err := zxinggo.CapacityFor(tt.format, tt.version, "", zxinggo.CapacityAlphanumeric)
+		if err != nil {
+			t.Fatalf("%v version %d: %v", tt.format, tt.version, err)
+		}
+		bytes, err := zxinggo.CapacityFor(tt.format, tt.version, "", zxinggo.CapacityByte)
+		if err != nil {
+			t.Fatalf("%v version %d: %v", tt.format, tt.version, err)
+		}
+		t.Logf("%v version %d: %d alphanumeric, %d bytes", tt.format, tt.version, alphanumeric, bytes)
+		if bytes >= alphanumeric {
+			t.Errorf("%v version %d: %d bytes, no fewer than %d alphanumeric", tt.format, tt.version, bytes, alphanumeric)
+		}
+	}
+}
+
+func TestCapacityErrors(t *testing.T) {
+	if _, err := zxinggo.CapacityFor(zxinggo.FormatCode128, 1, "", zxinggo.CapacityNumeric); !errors.Is(err, zxinggo.ErrWriter) {
+		t.Errorf("Code 128: error %v, want ErrWriter", err)
+	}
+	if _, err := zxinggo.CapacityFor(zxinggo.FormatQRCode, 41, "L", zxinggo.CapacityNumeric); !errors.Is(err, zxinggo.ErrWriter) {
+		t.Errorf("QR version 41: error %v, want ErrWriter", err)
+	}
+	if _, err := zxinggo.CapacityFor(zxinggo.FormatDataMatrix, 1, "H", zxinggo.CapacityNumeric); !errors.Is(err, zxinggo.ErrWriter) {
+		t.Errorf("Data Matrix level H: error %v, want ErrWriter", err)
+	}
+	if _, err := zxinggo.SmallestSymbolFor(strings.Repeat("9", 8000), zxinggo.FormatQRCode, nil); !errors.Is(err, zxinggo.ErrWriter) {
+		t.Errorf("8000 digits: error %v, want ErrWriter", err)
+	}
+}
@@ -1,7 +1,14 @@
 // Package charset provides character set ECI mappings and encoding detection.
 package charset
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+)
 
 // ErrFormatECI indicates an invalid ECI value.
 var ErrFormatECI = errors.New("charset: invalid ECI value")
@@ -21,8 +28,8 @@ var (
 	ECIISO8859_2  = &ECI{4, "ISO8859_2", "ISO8859_2", []string{"ISO-8859-2"}}
 	ECIISO8859_3  = &ECI{5, "ISO8859_3", "ISO8859_3", []string{"ISO-8859-3"}}
 	ECIISO8859_4  = &ECI{6, "ISO8859_4", "ISO8859_4", []string{"ISO-8859-4"}}
-	ECIISO8859_5  = &ECI{7, "ISO8859_5", "ISO8859_5", []string{"ISO-8859-5"}}
-	ECIISO8859_6  = &ECI{8, "ISO8859_6", "ISO8859_6", []string{"ISO-8859-6"}}
+	ECIISO8859_5  = &ECI{7, "ISO8859_5", "ISO8859_5", []string{"ISO-8859-5", "ISO_8859-5", "cyrillic"}}
+	ECIISO8859_6  = &ECI{8, "ISO8859_6", "ISO8859_6", []string{"ISO-8859-6", "ISO_8859-6", "arabic"}}
 	ECIISO8859_7  = &ECI{9, "ISO8859_7", "ISO8859_7", []string{"ISO-8859-7"}}
 	ECIISO8859_8  = &ECI{10, "ISO8859_8", "ISO8859_8", []string{"ISO-8859-8"}}
 	ECIISO8859_9  = &ECI{11, "ISO8859_9", "ISO8859_9", []string{"ISO-8859-9"}}
@@ -78,10 +85,8 @@ func init() {
 		} else {
 			valueToECI[eci.Value] = eci
 		}
-		nameToECI[eci.Name] = eci
-		nameToECI[eci.GoName] = eci
-		for _, alias := range eci.Aliases {
-			nameToECI[alias] = eci
+		for _, name := range append([]string{eci.Name, eci.GoName}, eci.Aliases...) {
+			nameToECI[strings.ToUpper(name)] = eci
 		}
 	}
 }
@@ -94,7 +99,46 @@ func GetECIByValue(value int) (*ECI, error) {
 	return valueToECI[value], nil
 }
 
-// GetECIByName returns the ECI for the given encoding name.
+// GetECIByName returns the ECI for the given encoding name, or nil if there
+// is none. Names are matched without regard to case, so "windows-1251",
+// "Cp1251" and "CP1251" all name the same character set.
 func GetECIByName(name string) *ECI {
-	return nameToECI[name]
+	return nameToECI[strings.ToUpper(name)]
+}
+
+// EncodeString converts s from UTF-8 to the character set of eci. It fails
+// if the character set cannot represent every character of s, or is not one
+// Go can encode.
+func EncodeString(s string, eci *ECI) ([]byte, error) {
+	switch eci {
+	case ECIUTF8:
+		return []byte(s), nil
+	case ECIASCII:
+		for i := 0; i < len(s); i++ {
+			if s[i] >= 0x80 {
+				return nil, fmt.Errorf("charset: %q is not ASCII", s)
+			}
+		}
+		return []byte(s), nil
+	}
+	enc := eci.encoding()
+	if enc == nil {
+		return nil, fmt.Errorf("charset: no encoder for %s", eci.Name)
+	}
+	encoded, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		return nil, fmt.Errorf("charset: %q cannot be encoded in %s: %w", s, eci.Name, err)
+	}
+	return encoded, nil
+}
+
+// encoding returns the Go implementation of the character set, or nil if
+// there is none.
+func (eci *ECI) encoding() encoding.Encoding {
+	for _, name := range append([]string{eci.GoName, eci.Name}, eci.Aliases...) {
+		if enc, err := ianaindex.IANA.Encoding(name); err == nil && enc != nil {
+			return enc
+		}
+	}
+	return nil
 }
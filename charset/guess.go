@@ -6,9 +6,9 @@ import (
 	"golang.org/x/text/transform"
 )
 
-// DecodeBytes converts bytes from the given encoding to UTF-8.
-// Returns the original bytes if the encoding is already UTF-8/ASCII/ISO-8859-1
-// or if conversion fails.
+// DecodeBytes converts bytes from the given encoding, which may be any name
+// of an ECI's character set, to UTF-8. Returns the original bytes if the
+// encoding is UTF-8 or ASCII, unknown, or if conversion fails.
 func DecodeBytes(data []byte, encoding string) string {
 	switch encoding {
 	case "Shift_JIS", "SJIS":
@@ -23,7 +23,18 @@ func DecodeBytes(data []byte, encoding string) string {
 			return string(decoded)
 		}
 		return string(data)
+	case "", "UTF8", "UTF-8", "ASCII", "US-ASCII":
+		return string(data)
 	default:
+		eci := GetECIByName(encoding)
+		if eci == nil {
+			return string(data)
+		}
+		if enc := eci.encoding(); enc != nil {
+			if decoded, err := enc.NewDecoder().Bytes(data); err == nil {
+				return string(decoded)
+			}
+		}
 		return string(data)
 	}
 }
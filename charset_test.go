@@ -0,0 +1,85 @@
+package zxinggo_test
+
+import (
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+	"github.com/ericlevine/zxinggo/charset"
+)
+
+// TestCharacterSetRoundTrip encodes text in each character set with an ECI
+// and checks that every format's reader converts it back.
+func TestCharacterSetRoundTrip(t *testing.T) {
+	texts := []struct {
+		characterSet, text string
+	}{
+		{"ISO-8859-1", "Grüße, café"},
+		{"ISO-8859-2", "Zażółć gęślą"},
+		{"ISO-8859-5", "Привет, мир"},
+		{"ISO-8859-6", "مرحبا بالعالم"},
+		{"ISO-8859-7", "Καλημέρα"},
+		{"ISO-8859-8", "שלום עולם"},
+		{"windows-1250", "Příliš žluťoučký"},
+		{"windows-1251", "Съешь же ещё"},
+		{"Windows-1252", "Œuvre – €5"},
+		{"WINDOWS-1256", "السلام عليكم"},
+		{"Shift_JIS", "こんにちは"},
+		{"GB18030", "你好世界"},
+		{"EUC-KR", "안녕하세요"},
+		{"UTF-8", "Ünïcödé ✓"},
+	}
+	formats := []zxinggo.Format{zxinggo.FormatQRCode, zxinggo.FormatAztec, zxinggo.FormatPDF417}
+	for _, format := range formats {
+		for _, tc := range texts {
+			matrix, err := zxinggo.Encode(tc.text, format, 200, 200, &zxinggo.EncodeOptions{CharacterSet: tc.characterSet})
+			if err != nil {
+				t.Errorf("%v %s: encode: %v", format, tc.characterSet, err)
+				continue
+			}
+			source := zxinggo.NewGrayImageLuminanceSource(zxinggo.BitMatrixToImage(matrix))
+			bitmap := zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(source))
+			result, err := zxinggo.Decode(bitmap, &zxinggo.DecodeOptions{
+				PossibleFormats: []zxinggo.Format{format},
+				PureBarcode:     true,
+			})
+			if err != nil {
+				t.Errorf("%v %s: decode: %v", format, tc.characterSet, err)
+				continue
+			}
+			if result.Text != tc.text {
+				t.Errorf("%v %s: read %q, want %q", format, tc.characterSet, result.Text, tc.text)
+			}
+		}
+	}
+}
+
+// TestDefaultCharacterSetRoundTrip checks that text with no character set
+// survives the formats whose default is ISO-8859-1.
+func TestDefaultCharacterSetRoundTrip(t *testing.T) {
+	for _, format := range []zxinggo.Format{zxinggo.FormatQRCode, zxinggo.FormatPDF417} {
+		if got := encodeAndDecode(t, "Crème brûlée", format, 0, 0); got != "Crème brûlée" {
+			t.Errorf("%v: read %q, want %q", format, got, "Crème brûlée")
+		}
+	}
+}
+
+func TestCharacterSetNames(t *testing.T) {
+	names := map[string]*charset.ECI{
+		"windows-1251": charset.ECICp1251,
+		"CP1251":       charset.ECICp1251,
+		"Windows1256":  charset.ECICp1256,
+		"iso-8859-5":   charset.ECIISO8859_5,
+		"cyrillic":     charset.ECIISO8859_5,
+		"ARABIC":       charset.ECIISO8859_6,
+		"utf-8":        charset.ECIUTF8,
+	}
+	for name, want := range names {
+		if got := charset.GetECIByName(name); got != want {
+			t.Errorf("GetECIByName(%q) = %v, want %s", name, got, want.Name)
+		}
+	}
+	if got := charset.GetECIByName("KOI8-R"); got != nil {
+		t.Errorf("GetECIByName(KOI8-R) = %s, want nil", got.Name)
+	}
+}
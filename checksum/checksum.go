@@ -0,0 +1,181 @@
+// Package checksum computes and validates the check digits and error
+// correction syndromes of the supported symbologies without an image, for
+// validating codes typed in by users or received from other systems.
+package checksum
+
+import (
+	"fmt"
+	"strings"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/gs1"
+	pdf417decoder "github.com/ericlevine/zxinggo/pdf417/decoder"
+	"github.com/ericlevine/zxinggo/reedsolomon"
+)
+
+// code39Alphabet lists the Code 39 characters in check value order.
+const code39Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ-. $/+%"
+
+// Mod10 computes the GS1 mod-10 check digit over digits, weighting the
+// rightmost digit by 3. This is the check digit of EAN-8, EAN-13, UPC-A,
+// ITF-14 and the GS1 keys such as GTIN and SSCC.
+func Mod10(digits string) (byte, error) {
+	cd, ok := gs1.CheckDigit(digits)
+	if !ok {
+		return 0, fmt.Errorf("%w: %q is not numeric", zxinggo.ErrFormat, digits)
+	}
+	return cd, nil
+}
+
+// ValidateMod10 checks that the last digit of s is the GS1 mod-10 check
+// digit of the digits before it.
+func ValidateMod10(s string) error {
+	if len(s) < 2 {
+		return fmt.Errorf("%w: %q is too short to carry a check digit", zxinggo.ErrFormat, s)
+	}
+	cd, err := Mod10(s[:len(s)-1])
+	if err != nil {
+		return err
+	}
+	if last := s[len(s)-1]; last < '0' || last > '9' {
+		return fmt.Errorf("%w: %q is not numeric", zxinggo.ErrFormat, s)
+	}
+	if cd != s[len(s)-1] {
+		return fmt.Errorf("%w: check digit is %c, want %c", zxinggo.ErrChecksum, s[len(s)-1], cd)
+	}
+	return nil
+}
+
+// ValidateUPCEAN checks an EAN-8, UPC-A or EAN-13 number, including its
+// check digit. UPC-E numbers should be expanded to UPC-A first.
+func ValidateUPCEAN(s string) error {
+	switch len(s) {
+	case 8, 12, 13:
+		return ValidateMod10(s)
+	}
+	return fmt.Errorf("%w: UPC/EAN numbers have 8, 12 or 13 digits, got %d", zxinggo.ErrFormat, len(s))
+}
+
+// ValidateITF14 checks a 14-digit ITF-14 (GTIN-14) number, including its
+// check digit.
+func ValidateITF14(s string) error {
+	if len(s) != 14 {
+		return fmt.Errorf("%w: ITF-14 numbers have 14 digits, got %d", zxinggo.ErrFormat, len(s))
+	}
+	return ValidateMod10(s)
+}
+
+// ValidateGS1 checks the data of a single GS1 Application Identifier against
+// the AI's length, character set and check digit rules.
+func ValidateGS1(ai, value string) error {
+	return gs1.Element{AI: ai, Value: value}.Validate()
+}
+
+// Code39 computes the optional mod-43 check character of a Code 39 message,
+// as appended by readers and writers configured to use a check digit.
+func Code39(s string) (byte, error) {
+	total := 0
+	for i := 0; i < len(s); i++ {
+		v := strings.IndexByte(code39Alphabet, s[i])
+		if v < 0 {
+			return 0, fmt.Errorf("%w: %q is not a Code 39 character", zxinggo.ErrFormat, s[i])
+		}
+		total += v
+	}
+	return code39Alphabet[total%43], nil
+}
+
+// ValidateCode39 checks that the last character of s is the mod-43 check
+// character of the characters before it.
+func ValidateCode39(s string) error {
+	if len(s) < 2 {
+		return fmt.Errorf("%w: %q is too short to carry a check character", zxinggo.ErrFormat, s)
+	}
+	cc, err := Code39(s[:len(s)-1])
+	if err != nil {
+		return err
+	}
+	if cc != s[len(s)-1] {
+		return fmt.Errorf("%w: check character is %c, want %c", zxinggo.ErrChecksum, s[len(s)-1], cc)
+	}
+	return nil
+}
+
+// Code128 computes the mod-103 check symbol of a Code 128 symbol from its
+// code values, starting with the start code (103, 104 or 105) and excluding
+// the check symbol and stop code.
+func Code128(values []int) (int, error) {
+	if len(values) == 0 {
+		return 0, fmt.Errorf("%w: no Code 128 code values", zxinggo.ErrFormat)
+	}
+	total := 0
+	for i, v := range values {
+		if v < 0 || v > 105 {
+			return 0, fmt.Errorf("%w: %d is not a Code 128 code value", zxinggo.ErrFormat, v)
+		}
+		weight := i
+		if i == 0 {
+			weight = 1
+		}
+		total += weight * v
+	}
+	return total % 103, nil
+}
+
+// ValidateCode128 checks that the last of values is the mod-103 check symbol
+// of the code values before it, which start with the start code.
+func ValidateCode128(values []int) error {
+	if len(values) < 2 {
+		return fmt.Errorf("%w: too few Code 128 code values", zxinggo.ErrFormat)
+	}
+	check, err := Code128(values[:len(values)-1])
+	if err != nil {
+		return err
+	}
+	if last := values[len(values)-1]; last != check {
+		return fmt.Errorf("%w: check symbol is %d, want %d", zxinggo.ErrChecksum, last, check)
+	}
+	return nil
+}
+
+// QRSyndromes returns the Reed-Solomon syndromes of one QR Code block: its
+// data codewords followed by numECCodewords error correction codewords. The
+// block is intact exactly when every syndrome is zero.
+func QRSyndromes(codewords []int, numECCodewords int) ([]int, error) {
+	if err := checkCodewords(codewords, numECCodewords, 256); err != nil {
+		return nil, err
+	}
+	return reedsolomon.Syndromes(reedsolomon.QRCodeField256, codewords, numECCodewords), nil
+}
+
+// PDF417Syndromes returns the syndromes of the PDF417 codewords, data
+// followed by numECCodewords error correction codewords, in GF(929). The
+// symbol is intact exactly when every syndrome is zero.
+func PDF417Syndromes(codewords []int, numECCodewords int) ([]int, error) {
+	if err := checkCodewords(codewords, numECCodewords, 929); err != nil {
+		return nil, err
+	}
+	return pdf417decoder.NewErrorCorrection().Syndromes(codewords, numECCodewords), nil
+}
+
+// Valid reports whether all syndromes are zero.
+func Valid(syndromes []int) bool {
+	for _, s := range syndromes {
+		if s != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func checkCodewords(codewords []int, numECCodewords, size int) error {
+	if numECCodewords < 1 || numECCodewords >= len(codewords) {
+		return fmt.Errorf("%w: %d error correction codewords in %d codewords", zxinggo.ErrFormat, numECCodewords, len(codewords))
+	}
+	for _, c := range codewords {
+		if c < 0 || c >= size {
+			return fmt.Errorf("%w: codeword %d out of range", zxinggo.ErrFormat, c)
+		}
+	}
+	return nil
+}
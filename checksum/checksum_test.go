@@ -0,0 +1,84 @@
+package checksum
+
+import (
+	"errors"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	pdf417encoder "github.com/ericlevine/zxinggo/pdf417/encoder"
+	"github.com/ericlevine/zxinggo/reedsolomon"
+)
+
+func TestValidateMod10Formats(t *testing.T) {
+	for _, s := range []string{"96385074", "036000291452", "4006381333931"} {
+		if err := ValidateUPCEAN(s); err != nil {
+			t.Errorf("ValidateUPCEAN(%q): %v", s, err)
+		}
+	}
+	if err := ValidateITF14("15400141288763"); err != nil {
+		t.Errorf("ValidateITF14: %v", err)
+	}
+	if err := ValidateUPCEAN("4006381333932"); !errors.Is(err, zxinggo.ErrChecksum) {
+		t.Errorf("ValidateUPCEAN with wrong check digit = %v, want ErrChecksum", err)
+	}
+	if err := ValidateUPCEAN("40063813339"); !errors.Is(err, zxinggo.ErrFormat) {
+		t.Errorf("ValidateUPCEAN with 11 digits = %v, want ErrFormat", err)
+	}
+	if err := ValidateGS1("01", "09506000134352"); err != nil {
+		t.Errorf("ValidateGS1: %v", err)
+	}
+}
+
+func TestCode39AndCode128(t *testing.T) {
+	if cc, err := Code39("CODE39"); err != nil || cc != 'W' {
+		t.Errorf("Code39(CODE39) = %c, %v, want W", cc, err)
+	}
+	if err := ValidateCode39("CODE39W"); err != nil {
+		t.Errorf("ValidateCode39: %v", err)
+	}
+	if _, err := Code39("code39"); !errors.Is(err, zxinggo.ErrFormat) {
+		t.Errorf("Code39 with lower case = %v, want ErrFormat", err)
+	}
+	// Start B, "PJJ123C": 104 + 48 + 2*42 + 3*42 + 4*17 + 5*18 + 6*19 + 7*35 = 879.
+	values := []int{104, 48, 42, 42, 17, 18, 19, 35}
+	if check, err := Code128(values); err != nil || check != 55 {
+		t.Errorf("Code128 = %d, %v, want 55", check, err)
+	}
+	if err := ValidateCode128(append(values, 54)); !errors.Is(err, zxinggo.ErrChecksum) {
+		t.Errorf("ValidateCode128 with wrong check symbol = %v, want ErrChecksum", err)
+	}
+}
+
+func TestSyndromes(t *testing.T) {
+	qr := []int{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	reedsolomon.NewEncoder(reedsolomon.QRCodeField256).Encode(qr, 10)
+	s, err := QRSyndromes(qr, 10)
+	if err != nil || !Valid(s) {
+		t.Fatalf("QRSyndromes of an encoded block = %v, %v, want all zero", s, err)
+	}
+	qr[3] ^= 1
+	if s, _ := QRSyndromes(qr, 10); Valid(s) {
+		t.Error("QRSyndromes of a damaged block are all zero")
+	}
+
+	data := string([]rune{5, 100, 101, 102, 103})
+	ec, err := pdf417encoder.GenerateErrorCorrection(data, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var codewords []int
+	for _, r := range data + ec {
+		codewords = append(codewords, int(r))
+	}
+	numEC := len(codewords) - len(data)
+	if s, err := PDF417Syndromes(codewords, numEC); err != nil || !Valid(s) {
+		t.Fatalf("PDF417Syndromes of an encoded symbol = %v, %v, want all zero", s, err)
+	}
+	codewords[1] = 99
+	if s, _ := PDF417Syndromes(codewords, numEC); Valid(s) {
+		t.Error("PDF417Syndromes of a damaged symbol are all zero")
+	}
+	if _, err := PDF417Syndromes(codewords, 0); !errors.Is(err, zxinggo.ErrFormat) {
+		t.Errorf("PDF417Syndromes with no EC codewords = %v, want ErrFormat", err)
+	}
+}
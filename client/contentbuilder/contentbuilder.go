@@ -0,0 +1,282 @@
+// Package contentbuilder builds the text payloads that barcode scanning apps
+// recognize — WiFi credentials, contacts, calendar events and URIs — with the
+// escaping each format requires. It is the encoding counterpart of the
+// client/result package.
+package contentbuilder
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WiFi security types.
+const (
+	WiFiWPA    = "WPA"
+	WiFiWEP    = "WEP"
+	WiFiNoPass = "nopass"
+)
+
+// WiFi builds a WIFI: network configuration payload. An empty security type
+// defaults to WPA, or nopass if password is empty.
+func WiFi(ssid, password, security string, hidden bool) string {
+	if security == "" {
+		if password == "" {
+			security = WiFiNoPass
+		} else {
+			security = WiFiWPA
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString("WIFI:T:")
+	sb.WriteString(security)
+	sb.WriteString(";S:")
+	sb.WriteString(escapeMeCard(ssid))
+	sb.WriteByte(';')
+	if security != WiFiNoPass {
+		sb.WriteString("P:")
+		sb.WriteString(escapeMeCard(password))
+		sb.WriteByte(';')
+	}
+	if hidden {
+		sb.WriteString("H:true;")
+	}
+	sb.WriteByte(';')
+	return sb.String()
+}
+
+// Contact holds the fields shared by the vCard and MeCard builders.
+type Contact struct {
+	Name         string
+	Organization string
+	Title        string
+	Phones       []string
+	Emails       []string
+	Addresses    []string
+	URLs         []string
+	Note         string
+}
+
+// VCard builds a vCard 3.0 payload for the contact.
+func VCard(c Contact) string {
+	var sb strings.Builder
+	writeContentLine(&sb, "BEGIN:VCARD")
+	writeContentLine(&sb, "VERSION:3.0")
+	writeContentLine(&sb, "N:"+escapeText(c.Name))
+	writeContentLine(&sb, "FN:"+escapeText(c.Name))
+	if c.Organization != "" {
+		writeContentLine(&sb, "ORG:"+escapeText(c.Organization))
+	}
+	if c.Title != "" {
+		writeContentLine(&sb, "TITLE:"+escapeText(c.Title))
+	}
+	for _, p := range c.Phones {
+		writeContentLine(&sb, "TEL:"+escapeText(p))
+	}
+	for _, e := range c.Emails {
+		writeContentLine(&sb, "EMAIL:"+escapeText(e))
+	}
+	for _, a := range c.Addresses {
+		// Put the whole address in the street component.
+		writeContentLine(&sb, "ADR:;;"+escapeText(a)+";;;;")
+	}
+	for _, u := range c.URLs {
+		writeContentLine(&sb, "URL:"+escapeText(u))
+	}
+	if c.Note != "" {
+		writeContentLine(&sb, "NOTE:"+escapeText(c.Note))
+	}
+	writeContentLine(&sb, "END:VCARD")
+	return sb.String()
+}
+
+// MeCard builds a compact MECARD: payload for the contact.
+func MeCard(c Contact) string {
+	var sb strings.Builder
+	sb.WriteString("MECARD:")
+	writeMeCardField(&sb, "N", c.Name)
+	writeMeCardField(&sb, "ORG", c.Organization)
+	for _, p := range c.Phones {
+		writeMeCardField(&sb, "TEL", p)
+	}
+	for _, e := range c.Emails {
+		writeMeCardField(&sb, "EMAIL", e)
+	}
+	for _, a := range c.Addresses {
+		writeMeCardField(&sb, "ADR", a)
+	}
+	for _, u := range c.URLs {
+		writeMeCardField(&sb, "URL", u)
+	}
+	writeMeCardField(&sb, "NOTE", c.Note)
+	sb.WriteByte(';')
+	return sb.String()
+}
+
+func writeMeCardField(sb *strings.Builder, name, value string) {
+	if value == "" {
+		return
+	}
+	sb.WriteString(name)
+	sb.WriteByte(':')
+	sb.WriteString(escapeMeCard(value))
+	sb.WriteByte(';')
+}
+
+// Event holds the fields of a calendar event.
+type Event struct {
+	Summary string
+	Start   time.Time
+	// End may be zero for events without an end time.
+	End time.Time
+	// AllDay writes the dates without a time of day.
+	AllDay      bool
+	Location    string
+	Description string
+}
+
+// VEvent builds an iCalendar VEVENT payload for the event. Times are written
+// in UTC.
+func VEvent(e Event) string {
+	var sb strings.Builder
+	writeContentLine(&sb, "BEGIN:VEVENT")
+	writeContentLine(&sb, "SUMMARY:"+escapeText(e.Summary))
+	writeContentLine(&sb, "DTSTART"+formatEventTime(e.Start, e.AllDay))
+	if !e.End.IsZero() {
+		writeContentLine(&sb, "DTEND"+formatEventTime(e.End, e.AllDay))
+	}
+	if e.Location != "" {
+		writeContentLine(&sb, "LOCATION:"+escapeText(e.Location))
+	}
+	if e.Description != "" {
+		writeContentLine(&sb, "DESCRIPTION:"+escapeText(e.Description))
+	}
+	writeContentLine(&sb, "END:VEVENT")
+	return sb.String()
+}
+
+func formatEventTime(t time.Time, allDay bool) string {
+	if allDay {
+		return ";VALUE=DATE:" + t.Format("20060102")
+	}
+	return ":" + t.UTC().Format("20060102T150405Z")
+}
+
+// Geo builds a geo: URI (RFC 5870). query, if non-empty, is added as the q
+// parameter understood by most map applications.
+func Geo(latitude, longitude float64, query string) string {
+	s := "geo:" + strconv.FormatFloat(latitude, 'f', -1, 64) + "," +
+		strconv.FormatFloat(longitude, 'f', -1, 64)
+	if query != "" {
+		s += "?q=" + escapeQuery(query)
+	}
+	return s
+}
+
+// Tel builds a tel: URI, dropping visual separators from the number.
+func Tel(number string) string {
+	return "tel:" + cleanPhoneNumber(number)
+}
+
+// SMS builds an sms: URI with an optional message body.
+func SMS(number, body string) string {
+	s := "sms:" + cleanPhoneNumber(number)
+	if body != "" {
+		s += "?body=" + escapeQuery(body)
+	}
+	return s
+}
+
+// Mailto builds a mailto: URI with optional subject and body.
+func Mailto(to, subject, body string) string {
+	var sb strings.Builder
+	sb.WriteString("mailto:")
+	sb.WriteString(to)
+	sep := byte('?')
+	if subject != "" {
+		sb.WriteByte(sep)
+		sb.WriteString("subject=")
+		sb.WriteString(escapeQuery(subject))
+		sep = '&'
+	}
+	if body != "" {
+		sb.WriteByte(sep)
+		sb.WriteString("body=")
+		sb.WriteString(escapeQuery(body))
+	}
+	return sb.String()
+}
+
+// cleanPhoneNumber removes spaces, dashes, dots and parentheses.
+func cleanPhoneNumber(number string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, number)
+}
+
+// escapeQuery percent-encodes a URI query value, using %20 for spaces since
+// not all scanners decode '+'.
+func escapeQuery(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// escapeMeCard backslash-escapes the characters that are special in MECARD
+// and WIFI payloads.
+func escapeMeCard(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\', ';', ',', ':', '"':
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// escapeText escapes a vCard/iCalendar TEXT value.
+func escapeText(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\', ';', ',':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// maxLineOctets is the line length limit for vCard and iCalendar content
+// lines, excluding the CRLF.
+const maxLineOctets = 75
+
+// writeContentLine writes a CRLF-terminated content line, folding it onto
+// continuation lines (starting with a space) if it exceeds maxLineOctets.
+// Folds never split a UTF-8 sequence.
+func writeContentLine(sb *strings.Builder, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		sb.WriteString(line[:cut])
+		sb.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines lose one octet to the leading space.
+		limit = maxLineOctets - 1
+	}
+	sb.WriteString(line)
+	sb.WriteString("\r\n")
+}
@@ -0,0 +1,87 @@
+package contentbuilder
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWiFi(t *testing.T) {
+	tests := []struct {
+		ssid, password, security string
+		hidden                   bool
+		want                     string
+	}{
+		{"Home", "s3cr3t", "", false, "WIFI:T:WPA;S:Home;P:s3cr3t;;"},
+		{"Cafe;Guest", "", "", true, `WIFI:T:nopass;S:Cafe\;Guest;H:true;;`},
+		{"Lab", `a:b"c\d`, WiFiWEP, false, `WIFI:T:WEP;S:Lab;P:a\:b\"c\\d;;`},
+	}
+	for _, tt := range tests {
+		if got := WiFi(tt.ssid, tt.password, tt.security, tt.hidden); got != tt.want {
+			t.Errorf("WiFi(%q) = %q, want %q", tt.ssid, got, tt.want)
+		}
+	}
+}
+
+func TestMeCard(t *testing.T) {
+	c := Contact{Name: "Owen, Sean", Phones: []string{"+12125551212"}, Emails: []string{"srowen@example.org"}}
+	want := `MECARD:N:Owen\, Sean;TEL:+12125551212;EMAIL:srowen@example.org;;`
+	if got := MeCard(c); got != want {
+		t.Errorf("MeCard = %q, want %q", got, want)
+	}
+}
+
+func TestVCard(t *testing.T) {
+	c := Contact{
+		Name:         "Jane Doe",
+		Organization: "Acme; Inc.",
+		Phones:       []string{"+1 555 0100"},
+		Note:         "line one\nline two",
+	}
+	got := VCard(c)
+	for _, line := range []string{
+		"BEGIN:VCARD\r\n", "VERSION:3.0\r\n", "FN:Jane Doe\r\n",
+		"ORG:Acme\\; Inc.\r\n", "TEL:+1 555 0100\r\n", "NOTE:line one\\nline two\r\n", "END:VCARD\r\n",
+	} {
+		if !strings.Contains(got, line) {
+			t.Errorf("VCard missing %q in %q", line, got)
+		}
+	}
+}
+
+func TestVEventFoldsLongLines(t *testing.T) {
+	e := Event{
+		Summary:     "Planning",
+		Start:       time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC),
+		End:         time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+		Description: strings.Repeat("é", 60),
+	}
+	got := VEvent(e)
+	if !strings.Contains(got, "DTSTART:20260301T093000Z\r\n") || !strings.Contains(got, "DTEND:20260301T100000Z\r\n") {
+		t.Errorf("VEvent times wrong: %q", got)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(got, "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line exceeds %d octets: %q", maxLineOctets, line)
+		}
+	}
+	unfolded := strings.ReplaceAll(got, "\r\n ", "")
+	if !strings.Contains(unfolded, "DESCRIPTION:"+strings.Repeat("é", 60)+"\r\n") {
+		t.Errorf("folding corrupted description: %q", got)
+	}
+}
+
+func TestURIs(t *testing.T) {
+	tests := []struct{ got, want string }{
+		{Geo(40.7128, -74.006, "City Hall"), "geo:40.7128,-74.006?q=City%20Hall"},
+		{Tel("+1 (212) 555-1212"), "tel:+12125551212"},
+		{SMS("+12125551212", "Hi & bye"), "sms:+12125551212?body=Hi%20%26%20bye"},
+		{Mailto("a@example.com", "Re: hi", ""), "mailto:a@example.com?subject=Re%3A%20hi"},
+		{Mailto("a@example.com", "", "x"), "mailto:a@example.com?body=x"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("got %q, want %q", tt.got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package result
+
+import (
+	"strconv"
+	"strings"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+)
+
+// BoardingPassParsedResult holds the contents of an IATA Bar Coded Boarding
+// Pass (BCBP, Resolution 792) in the "M" format, as carried by Aztec, PDF417
+// and other 2D symbols.
+type BoardingPassParsedResult struct {
+	PassengerName    string
+	ElectronicTicket bool
+
+	// Version is the BCBP version number, or 0 if the conditional section is
+	// absent.
+	Version int
+
+	// Unique conditional items, present only in versioned passes.
+	PassengerDescription string
+	CheckInSource        string
+	IssuanceSource       string
+	// IssueDate is the date of issue of the boarding pass as a four digit
+	// string: the last digit of the year followed by the day of the year.
+	IssueDate      string
+	DocumentType   string
+	IssuerAirline  string
+	BaggageTagInfo string
+
+	Legs []BoardingPassLeg
+
+	// SecurityDataType and SecurityData hold the optional airline security
+	// section (usually a digital signature).
+	SecurityDataType string
+	SecurityData     string
+}
+
+// BoardingPassLeg holds the per-flight fields of a boarding pass.
+type BoardingPassLeg struct {
+	PNR          string
+	From         string
+	To           string
+	Carrier      string
+	FlightNumber string
+	// FlightDay is the day of the year (1-366) of the flight.
+	FlightDay       int
+	Compartment     string
+	Seat            string
+	CheckInSequence string
+	PassengerStatus string
+
+	// Repeated conditional items.
+	AirlineNumericCode   string
+	DocumentSerialNumber string
+	Selectee             string
+	DocumentVerification string
+	MarketingCarrier     string
+	FrequentFlyerAirline string
+	FrequentFlyerNumber  string
+	IDADIndicator        string
+	FreeBaggageAllowance string
+
+	// AirlineData is the free-form "for individual airline use" field.
+	AirlineData string
+}
+
+// Type returns TypeBoardingPass.
+func (r *BoardingPassParsedResult) Type() ParsedResultType {
+	return TypeBoardingPass
+}
+
+// DisplayResult returns the passenger name followed by one line per leg.
+func (r *BoardingPassParsedResult) DisplayResult() string {
+	var sb strings.Builder
+	maybeAppend(&sb, r.PassengerName)
+	for _, leg := range r.Legs {
+		line := leg.Carrier + leg.FlightNumber + " " + leg.From + "-" + leg.To
+		if leg.Seat != "" {
+			line += " seat " + leg.Seat
+		}
+		maybeAppend(&sb, line)
+	}
+	return sb.String()
+}
+
+const (
+	bcbpHeaderLength       = 23
+	bcbpLegMandatoryLength = 37
+)
+
+// parseBoardingPass recognizes IATA BCBP "M" format payloads.
+func parseBoardingPass(r *zxinggo.Result) ParsedResult {
+	text := r.Text
+	if len(text) < bcbpHeaderLength+bcbpLegMandatoryLength || text[0] != 'M' {
+		return nil
+	}
+	numLegs := int(text[1] - '0')
+	if numLegs < 1 || numLegs > 9 {
+		return nil
+	}
+	result := &BoardingPassParsedResult{
+		PassengerName:    strings.TrimSpace(text[2:22]),
+		ElectronicTicket: text[22] == 'E',
+	}
+
+	pos := bcbpHeaderLength
+	for i := 0; i < numLegs; i++ {
+		if pos+bcbpLegMandatoryLength > len(text) {
+			return nil
+		}
+		f := text[pos : pos+bcbpLegMandatoryLength]
+		leg := BoardingPassLeg{
+			PNR:             strings.TrimSpace(f[0:7]),
+			From:            strings.TrimSpace(f[7:10]),
+			To:              strings.TrimSpace(f[10:13]),
+			Carrier:         strings.TrimSpace(f[13:16]),
+			FlightNumber:    strings.TrimSpace(f[16:21]),
+			Compartment:     strings.TrimSpace(f[24:25]),
+			Seat:            strings.TrimSpace(f[25:29]),
+			CheckInSequence: strings.TrimSpace(f[29:34]),
+			PassengerStatus: strings.TrimSpace(f[34:35]),
+		}
+		leg.FlightDay, _ = strconv.Atoi(strings.TrimSpace(f[21:24]))
+		variableSize, ok := parseHexSize(f[35:37])
+		if !ok {
+			return nil
+		}
+		pos += bcbpLegMandatoryLength
+		if pos+variableSize > len(text) {
+			return nil
+		}
+		variable := text[pos : pos+variableSize]
+		pos += variableSize
+
+		if i == 0 && len(variable) > 0 && variable[0] == '>' {
+			variable = result.parseUniqueConditional(variable)
+		}
+		variable = leg.parseRepeatedConditional(variable)
+		leg.AirlineData = variable
+		result.Legs = append(result.Legs, leg)
+	}
+
+	if pos < len(text) && text[pos] == '^' && pos+4 <= len(text) {
+		result.SecurityDataType = text[pos+1 : pos+2]
+		if size, ok := parseHexSize(text[pos+2 : pos+4]); ok && pos+4+size <= len(text) {
+			result.SecurityData = text[pos+4 : pos+4+size]
+		} else {
+			result.SecurityData = text[pos+4:]
+		}
+	}
+	return result
+}
+
+// parseUniqueConditional reads the version number and the unique conditional
+// items from the first leg's variable field, returning the unread remainder.
+func (r *BoardingPassParsedResult) parseUniqueConditional(s string) string {
+	if len(s) < 4 {
+		return ""
+	}
+	r.Version = int(s[1] - '0')
+	size, ok := parseHexSize(s[2:4])
+	if !ok {
+		return s[2:]
+	}
+	s = s[4:]
+	if size > len(s) {
+		size = len(s)
+	}
+	unique := s[:size]
+	fields := []*string{
+		&r.PassengerDescription, &r.CheckInSource, &r.IssuanceSource,
+		&r.IssueDate, &r.DocumentType, &r.IssuerAirline, &r.BaggageTagInfo,
+	}
+	widths := []int{1, 1, 1, 4, 1, 3, 13}
+	readFixedFields(unique, fields, widths)
+	if len(unique) > 24 {
+		// Version 5 adds two more baggage tag ranges; keep them together.
+		r.BaggageTagInfo = strings.TrimSpace(unique[11:])
+	}
+	return s[size:]
+}
+
+// parseRepeatedConditional reads the repeated conditional items of a leg,
+// returning the unread remainder (the airline's individual-use data).
+func (l *BoardingPassLeg) parseRepeatedConditional(s string) string {
+	if len(s) < 2 {
+		return s
+	}
+	size, ok := parseHexSize(s[0:2])
+	if !ok || size+2 > len(s) {
+		return s
+	}
+	fields := []*string{
+		&l.AirlineNumericCode, &l.DocumentSerialNumber, &l.Selectee,
+		&l.DocumentVerification, &l.MarketingCarrier, &l.FrequentFlyerAirline,
+		&l.FrequentFlyerNumber, &l.IDADIndicator, &l.FreeBaggageAllowance,
+	}
+	widths := []int{3, 10, 1, 1, 3, 3, 16, 1, 3}
+	readFixedFields(s[2:2+size], fields, widths)
+	return s[2+size:]
+}
+
+// readFixedFields fills consecutive fixed-width fields from s, stopping when
+// s runs out. Values are trimmed of padding spaces.
+func readFixedFields(s string, fields []*string, widths []int) {
+	pos := 0
+	for i, w := range widths {
+		if pos >= len(s) {
+			return
+		}
+		end := pos + w
+		if end > len(s) {
+			end = len(s)
+		}
+		*fields[i] = strings.TrimSpace(s[pos:end])
+		pos = end
+	}
+}
+
+// parseHexSize parses a two character hexadecimal field size.
+func parseHexSize(s string) (int, bool) {
+	v, err := strconv.ParseUint(s, 16, 8)
+	if err != nil {
+		return 0, false
+	}
+	return int(v), true
+}
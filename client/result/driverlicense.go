@@ -0,0 +1,339 @@
+package result
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+)
+
+// DriverLicenseParsedResult holds the fields of an AAMVA-compliant driver
+// license or identification card, as found in the PDF417 symbol on the back
+// of most North American licenses.
+type DriverLicenseParsedResult struct {
+	// IssuerID is the six digit Issuer Identification Number (IIN).
+	IssuerID string
+	// Jurisdiction is the two-letter code of the issuing jurisdiction, derived
+	// from the IIN. Empty if the IIN is not recognized.
+	Jurisdiction        string
+	AAMVAVersion        int
+	JurisdictionVersion int
+	// DocumentType is the subfile type, usually "DL" or "ID".
+	DocumentType string
+
+	FirstName  string
+	MiddleName string
+	LastName   string
+	NameSuffix string
+
+	DateOfBirth    time.Time
+	IssueDate      time.Time
+	ExpirationDate time.Time
+
+	// Sex is "M", "F" or "X", or empty if not present.
+	Sex string
+
+	AddressStreet1 string
+	AddressStreet2 string
+	AddressCity    string
+	AddressState   string
+	PostalCode     string
+	Country        string
+
+	DocumentNumber        string
+	DocumentDiscriminator string
+
+	// Elements holds every data element found, keyed by its three letter
+	// element ID, including jurisdiction-specific ones.
+	Elements map[string]string
+}
+
+// Type returns TypeDriverLicense.
+func (r *DriverLicenseParsedResult) Type() ParsedResultType {
+	return TypeDriverLicense
+}
+
+// DisplayResult returns the name, address, document number and dates.
+func (r *DriverLicenseParsedResult) DisplayResult() string {
+	var sb strings.Builder
+	name := strings.TrimSpace(strings.Join([]string{r.FirstName, r.MiddleName, r.LastName, r.NameSuffix}, " "))
+	maybeAppend(&sb, strings.Join(strings.Fields(name), " "))
+	maybeAppend(&sb, r.AddressStreet1)
+	maybeAppend(&sb, r.AddressStreet2)
+	cityLine := strings.TrimSpace(r.AddressCity + " " + r.AddressState + " " + r.PostalCode)
+	maybeAppend(&sb, strings.Join(strings.Fields(cityLine), " "))
+	maybeAppend(&sb, r.DocumentNumber)
+	if !r.DateOfBirth.IsZero() {
+		maybeAppend(&sb, "DOB "+r.DateOfBirth.Format("2006-01-02"))
+	}
+	if !r.ExpirationDate.IsZero() {
+		maybeAppend(&sb, "EXP "+r.ExpirationDate.Format("2006-01-02"))
+	}
+	return sb.String()
+}
+
+// aamvaJurisdictions maps AAMVA Issuer Identification Numbers to
+// jurisdiction codes.
+var aamvaJurisdictions = map[string]string{
+	"636000": "VA", "636001": "NY", "636002": "MA", "636003": "MD",
+	"636004": "NC", "636005": "SC", "636006": "CT", "636007": "LA",
+	"636008": "MT", "636009": "NM", "636010": "FL", "636011": "DE",
+	"636012": "ON", "636013": "NS", "636014": "CA", "636015": "TX",
+	"636016": "NL", "636017": "NB", "636018": "IA", "636019": "GU",
+	"636020": "CO", "636021": "AR", "636022": "KS", "636023": "OH",
+	"636024": "VT", "636025": "PA", "636026": "AZ", "636028": "BC",
+	"636029": "OR", "636030": "MO", "636031": "WI", "636032": "MI",
+	"636033": "AL", "636034": "ND", "636035": "IL", "636036": "NJ",
+	"636037": "IN", "636038": "MN", "636039": "NH", "636040": "UT",
+	"636041": "ME", "636042": "SD", "636043": "DC", "636044": "SK",
+	"636045": "WA", "636046": "KY", "636047": "HI", "636048": "MB",
+	"636049": "NV", "636050": "ID", "636051": "MS", "636052": "RI",
+	"636053": "TN", "636054": "NE", "636055": "GA", "636058": "OK",
+	"636059": "AK", "636060": "WY", "636061": "WV", "636062": "VI",
+	"604426": "PE", "604427": "AS", "604428": "QC", "604429": "YT",
+	"604430": "MP", "604431": "PR", "604432": "AB",
+}
+
+// canadianJurisdictions lists jurisdictions that encode dates as CCYYMMDD
+// regardless of the AAMVA version.
+var canadianJurisdictions = map[string]bool{
+	"AB": true, "BC": true, "MB": true, "NB": true, "NL": true, "NS": true,
+	"ON": true, "PE": true, "QC": true, "SK": true, "YT": true,
+}
+
+// parseDriverLicense recognizes AAMVA DL/ID card design standard payloads.
+// The header is located leniently since several jurisdictions emit damaged
+// or non-standard compliance indicators and separators.
+func parseDriverLicense(r *zxinggo.Result) ParsedResult {
+	text := r.Text
+	if !strings.HasPrefix(text, "@") {
+		return nil
+	}
+	headerSearch := text
+	if len(headerSearch) > 32 {
+		headerSearch = headerSearch[:32]
+	}
+	pos := strings.Index(headerSearch, "ANSI ")
+	if pos < 0 {
+		pos = strings.Index(headerSearch, "AAMVA")
+	}
+	if pos < 0 {
+		return nil
+	}
+	pos += 5
+
+	iin, ok := readDigits(text, pos, 6)
+	if !ok {
+		return nil
+	}
+	pos += 6
+	versionStr, ok := readDigits(text, pos, 2)
+	if !ok {
+		return nil
+	}
+	pos += 2
+	version, _ := strconv.Atoi(versionStr)
+	jurisdictionVersion := 0
+	if version >= 2 {
+		jv, ok := readDigits(text, pos, 2)
+		if !ok {
+			return nil
+		}
+		jurisdictionVersion, _ = strconv.Atoi(jv)
+		pos += 2
+	}
+	entriesStr, ok := readDigits(text, pos, 2)
+	if !ok {
+		return nil
+	}
+	pos += 2
+	numEntries, _ := strconv.Atoi(entriesStr)
+
+	type designator struct {
+		subfileType    string
+		offset, length int
+	}
+	var designators []designator
+	for i := 0; i < numEntries && pos+10 <= len(text); i++ {
+		// The offset and length are four digits each; strconv.Atoi alone
+		// would also accept a sign.
+		offsetStr, ok1 := readDigits(text, pos+2, 4)
+		lengthStr, ok2 := readDigits(text, pos+6, 4)
+		if !ok1 || !ok2 {
+			break
+		}
+		offset, _ := strconv.Atoi(offsetStr)
+		length, _ := strconv.Atoi(lengthStr)
+		designators = append(designators, designator{text[pos : pos+2], offset, length})
+		pos += 10
+	}
+	if len(designators) == 0 {
+		return nil
+	}
+
+	elements := make(map[string]string)
+	documentType := ""
+	for _, d := range designators {
+		start := -1
+		inRange := d.offset >= 0 && d.offset+max(d.length, 2) <= len(text)
+		if inRange && text[d.offset:d.offset+2] == d.subfileType {
+			start = d.offset + 2
+		} else if idx := strings.Index(text[pos:], d.subfileType); idx >= 0 {
+			// Many jurisdictions report wrong offsets or lengths; fall back
+			// to searching for the subfile type after the designator table.
+			start = pos + idx + 2
+		}
+		if start < 0 {
+			continue
+		}
+		end := strings.IndexByte(text[start:], '\r')
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += start
+		}
+		for _, line := range strings.Split(text[start:end], "\n") {
+			line = strings.TrimRight(line, "\r")
+			if len(line) < 3 {
+				continue
+			}
+			key := line[:3]
+			if _, exists := elements[key]; !exists {
+				elements[key] = strings.TrimSpace(line[3:])
+			}
+		}
+		if documentType == "" && (d.subfileType == "DL" || d.subfileType == "ID") {
+			documentType = d.subfileType
+		}
+	}
+	if len(elements) == 0 {
+		return nil
+	}
+
+	result := &DriverLicenseParsedResult{
+		IssuerID:            iin,
+		Jurisdiction:        aamvaJurisdictions[iin],
+		AAMVAVersion:        version,
+		JurisdictionVersion: jurisdictionVersion,
+		DocumentType:        documentType,
+		Elements:            elements,
+	}
+	result.LastName = aamvaValue(elements, "DCS")
+	result.FirstName = aamvaValue(elements, "DAC")
+	result.MiddleName = aamvaValue(elements, "DAD")
+	result.NameSuffix = aamvaValue(elements, "DCU")
+	if result.FirstName == "" {
+		// AAMVA version 2 combines given names in DCT.
+		result.FirstName, result.MiddleName = splitGivenNames(aamvaValue(elements, "DCT"))
+	}
+	if result.LastName == "" {
+		// AAMVA version 1 uses a single full name field: LAST,FIRST,MIDDLE.
+		parts := strings.Split(aamvaValue(elements, "DAA"), ",")
+		result.LastName = strings.TrimSpace(parts[0])
+		if len(parts) > 1 && result.FirstName == "" {
+			result.FirstName = strings.TrimSpace(parts[1])
+		}
+		if len(parts) > 2 && result.MiddleName == "" {
+			result.MiddleName = strings.TrimSpace(parts[2])
+		}
+	}
+
+	result.AddressStreet1 = aamvaValue(elements, "DAG")
+	result.AddressStreet2 = aamvaValue(elements, "DAH")
+	result.AddressCity = aamvaValue(elements, "DAI")
+	result.AddressState = aamvaValue(elements, "DAJ")
+	result.PostalCode = normalizePostalCode(aamvaValue(elements, "DAK"))
+	result.Country = aamvaValue(elements, "DCG")
+	result.DocumentNumber = aamvaValue(elements, "DAQ")
+	result.DocumentDiscriminator = aamvaValue(elements, "DCF")
+	result.Sex = normalizeSex(aamvaValue(elements, "DBC"))
+
+	yearFirst := version <= 1 || result.Country == "CAN" || canadianJurisdictions[result.Jurisdiction]
+	result.DateOfBirth = parseAAMVADate(aamvaValue(elements, "DBB"), yearFirst)
+	result.IssueDate = parseAAMVADate(aamvaValue(elements, "DBD"), yearFirst)
+	result.ExpirationDate = parseAAMVADate(aamvaValue(elements, "DBA"), yearFirst)
+	return result
+}
+
+// readDigits returns the n characters at pos if they are all ASCII digits.
+func readDigits(text string, pos, n int) (string, bool) {
+	if pos+n > len(text) {
+		return "", false
+	}
+	s := text[pos : pos+n]
+	for i := 0; i < n; i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return "", false
+		}
+	}
+	return s, true
+}
+
+// aamvaValue returns the value of an element, treating the placeholder values
+// some jurisdictions use for missing data as empty.
+func aamvaValue(elements map[string]string, key string) string {
+	v := elements[key]
+	switch strings.ToUpper(v) {
+	case "NONE", "UNAVL", "UNAVAIL", "UNAVAILABLE":
+		return ""
+	}
+	return v
+}
+
+// splitGivenNames splits a combined given name field into first and middle
+// names. Both comma and space separators are found in the wild.
+func splitGivenNames(s string) (first, middle string) {
+	if i := strings.IndexByte(s, ','); i >= 0 {
+		return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+	}
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i+1:])
+	}
+	return s, ""
+}
+
+// normalizePostalCode trims padding from US ZIP codes, which are encoded as
+// nine digits with "0000" when the +4 extension is unknown.
+func normalizePostalCode(s string) string {
+	s = strings.TrimSpace(s)
+	if _, ok := readDigits(s, 0, len(s)); ok && len(s) == 9 {
+		if s[5:] == "0000" {
+			return s[:5]
+		}
+		return s[:5] + "-" + s[5:]
+	}
+	return s
+}
+
+// normalizeSex maps the numeric ANSI D-20 codes to letters.
+func normalizeSex(s string) string {
+	switch strings.ToUpper(s) {
+	case "1", "M":
+		return "M"
+	case "2", "F":
+		return "F"
+	case "9", "X":
+		return "X"
+	}
+	return ""
+}
+
+// parseAAMVADate parses an eight digit date. US licenses from version 2 on use
+// MMDDCCYY while Canada and version 1 use CCYYMMDD; the other layout is tried
+// if the preferred one does not yield a valid date.
+func parseAAMVADate(s string, yearFirst bool) time.Time {
+	if _, ok := readDigits(s, 0, 8); !ok || len(s) != 8 {
+		return time.Time{}
+	}
+	layouts := []string{"01022006", "20060102"}
+	if yearFirst {
+		layouts[0], layouts[1] = layouts[1], layouts[0]
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,90 @@
+// Package result parses the raw text of decoded barcodes into structured,
+// application-level results such as driver licenses and boarding passes.
+package result
+
+import (
+	"strings"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+)
+
+// ParsedResultType identifies the kind of structured data a ParsedResult holds.
+type ParsedResultType int
+
+const (
+	TypeText ParsedResultType = iota
+	TypeDriverLicense
+	TypeBoardingPass
+)
+
+// String returns the name of the parsed result type.
+func (t ParsedResultType) String() string {
+	switch t {
+	case TypeText:
+		return "TEXT"
+	case TypeDriverLicense:
+		return "DRIVER_LICENSE"
+	case TypeBoardingPass:
+		return "BOARDING_PASS"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// ParsedResult is the structured interpretation of a barcode's contents.
+type ParsedResult interface {
+	// Type returns the kind of parsed result.
+	Type() ParsedResultType
+
+	// DisplayResult returns a human-readable summary of the result.
+	DisplayResult() string
+}
+
+// TextParsedResult is the fallback result for contents that no other parser
+// recognizes.
+type TextParsedResult struct {
+	Text string
+}
+
+// Type returns TypeText.
+func (r *TextParsedResult) Type() ParsedResultType {
+	return TypeText
+}
+
+// DisplayResult returns the raw text.
+func (r *TextParsedResult) DisplayResult() string {
+	return r.Text
+}
+
+// resultParser attempts to interpret a decoded Result, returning nil if the
+// contents are not in the format it understands.
+type resultParser func(r *zxinggo.Result) ParsedResult
+
+// parsers lists the parsers to try, in order. The first parser to return a
+// non-nil result wins.
+var parsers = []resultParser{
+	parseDriverLicense,
+	parseBoardingPass,
+}
+
+// ParseResult interprets the contents of a decoded barcode. It never returns
+// nil; contents that no parser recognizes yield a *TextParsedResult.
+func ParseResult(r *zxinggo.Result) ParsedResult {
+	for _, p := range parsers {
+		if parsed := p(r); parsed != nil {
+			return parsed
+		}
+	}
+	return &TextParsedResult{Text: r.Text}
+}
+
+// maybeAppend appends value to the builder on its own line if it is non-empty.
+func maybeAppend(sb *strings.Builder, value string) {
+	if value == "" {
+		return
+	}
+	if sb.Len() > 0 {
+		sb.WriteByte('\n')
+	}
+	sb.WriteString(value)
+}
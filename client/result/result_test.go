@@ -0,0 +1,149 @@
+package result
+
+import (
+	"testing"
+	"time"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+)
+
+func TestParseResultText(t *testing.T) {
+	r := zxinggo.NewResult("hello world", nil, nil, zxinggo.FormatQRCode)
+	parsed := ParseResult(r)
+	if parsed.Type() != TypeText {
+		t.Fatalf("type = %s, want TEXT", parsed.Type())
+	}
+	if parsed.DisplayResult() != "hello world" {
+		t.Errorf("display = %q", parsed.DisplayResult())
+	}
+}
+
+func TestParseDriverLicenseV8(t *testing.T) {
+	data := "@\n\x1e\rANSI 636014080002DL00410240ZC02810024" +
+		"DLDAQD1234562\nDCSPUBLIC\nDACJOHN\nDADQUINCY\nDBB08311977\nDBA08312026\n" +
+		"DBD08312021\nDBC1\nDAG123 MAIN STREET\nDAIANYTOWN\nDAJCA\nDAK945010000  \n" +
+		"DCGUSA\nDCFABC123\r" +
+		"ZCZCAGRN\nZCBBRN\r"
+	r := zxinggo.NewResult(data, nil, nil, zxinggo.FormatPDF417)
+	dl, ok := ParseResult(r).(*DriverLicenseParsedResult)
+	if !ok {
+		t.Fatalf("expected driver license result")
+	}
+	if dl.Jurisdiction != "CA" || dl.AAMVAVersion != 8 || dl.JurisdictionVersion != 0 {
+		t.Errorf("header = %s v%d/%d", dl.Jurisdiction, dl.AAMVAVersion, dl.JurisdictionVersion)
+	}
+	if dl.DocumentType != "DL" || dl.DocumentNumber != "D1234562" {
+		t.Errorf("document = %s %s", dl.DocumentType, dl.DocumentNumber)
+	}
+	if dl.FirstName != "JOHN" || dl.MiddleName != "QUINCY" || dl.LastName != "PUBLIC" {
+		t.Errorf("name = %q %q %q", dl.FirstName, dl.MiddleName, dl.LastName)
+	}
+	if want := time.Date(1977, 8, 31, 0, 0, 0, 0, time.UTC); !dl.DateOfBirth.Equal(want) {
+		t.Errorf("DOB = %v, want %v", dl.DateOfBirth, want)
+	}
+	if dl.ExpirationDate.Year() != 2026 {
+		t.Errorf("expiry = %v", dl.ExpirationDate)
+	}
+	if dl.Sex != "M" || dl.PostalCode != "94501" || dl.AddressCity != "ANYTOWN" {
+		t.Errorf("sex/postal/city = %q %q %q", dl.Sex, dl.PostalCode, dl.AddressCity)
+	}
+	if dl.Elements["ZCA"] != "GRN" {
+		t.Errorf("jurisdiction element ZCA = %q", dl.Elements["ZCA"])
+	}
+}
+
+func TestParseDriverLicenseCanadianDates(t *testing.T) {
+	data := "@\n\x1e\rANSI 636012030001DL00310080" +
+		"DLDAQX1234\nDCSDOE\nDCTJANE,ANN\nDBB19800115\nDBC2\nDCGCAN\r"
+	r := zxinggo.NewResult(data, nil, nil, zxinggo.FormatPDF417)
+	dl, ok := ParseResult(r).(*DriverLicenseParsedResult)
+	if !ok {
+		t.Fatalf("expected driver license result")
+	}
+	if dl.Jurisdiction != "ON" {
+		t.Errorf("jurisdiction = %q", dl.Jurisdiction)
+	}
+	if dl.FirstName != "JANE" || dl.MiddleName != "ANN" {
+		t.Errorf("given names = %q %q", dl.FirstName, dl.MiddleName)
+	}
+	if want := time.Date(1980, 1, 15, 0, 0, 0, 0, time.UTC); !dl.DateOfBirth.Equal(want) {
+		t.Errorf("DOB = %v, want %v", dl.DateOfBirth, want)
+	}
+	if dl.Sex != "F" {
+		t.Errorf("sex = %q", dl.Sex)
+	}
+}
+
+func TestParseDriverLicenseRejectsOtherText(t *testing.T) {
+	for _, text := range []string{
+		"@hello",
+		// A signed subfile offset, which must not be sliced from.
+		"@\n\x1e\rANSI 636014080001DL-0010100DLDAQX\r",
+	} {
+		r := zxinggo.NewResult(text, nil, nil, zxinggo.FormatPDF417)
+		if _, ok := ParseResult(r).(*DriverLicenseParsedResult); ok {
+			t.Errorf("%q: unexpected driver license result", text)
+		}
+	}
+}
+
+func TestParseBoardingPassSingleLeg(t *testing.T) {
+	data := "M1DESMARAIS/LUC       EABC123 YULFRAAC 0834 326J001A0025 100"
+	r := zxinggo.NewResult(data, nil, nil, zxinggo.FormatAztec)
+	bp, ok := ParseResult(r).(*BoardingPassParsedResult)
+	if !ok {
+		t.Fatalf("expected boarding pass result")
+	}
+	if bp.PassengerName != "DESMARAIS/LUC" || !bp.ElectronicTicket {
+		t.Errorf("passenger = %q e-ticket=%v", bp.PassengerName, bp.ElectronicTicket)
+	}
+	if len(bp.Legs) != 1 {
+		t.Fatalf("legs = %d, want 1", len(bp.Legs))
+	}
+	leg := bp.Legs[0]
+	if leg.PNR != "ABC123" || leg.From != "YUL" || leg.To != "FRA" || leg.Carrier != "AC" {
+		t.Errorf("leg = %+v", leg)
+	}
+	if leg.FlightNumber != "0834" || leg.FlightDay != 326 || leg.Seat != "001A" || leg.CheckInSequence != "0025" {
+		t.Errorf("leg = %+v", leg)
+	}
+}
+
+func TestParseBoardingPassMultiLegWithSecurity(t *testing.T) {
+	unique := "1WW6225BAC 0014123456002"
+	repeated1 := "0140012345678900AC 0"
+	leg1Var := ">6" + "18" + unique + "14" + repeated1 + "AIRLINE"
+	leg2Var := "00"
+	data := "M2DESMARAIS/LUC       E" +
+		"ABC123 YULFRAAC 0834 326J001A0025 1" + hexSize(leg1Var) + leg1Var +
+		"DEF456 FRAGVALH 3664 327C012C0002 1" + hexSize(leg2Var) + leg2Var +
+		"^1" + "08" + "GIWVC5EH"
+	r := zxinggo.NewResult(data, nil, nil, zxinggo.FormatPDF417)
+	bp, ok := ParseResult(r).(*BoardingPassParsedResult)
+	if !ok {
+		t.Fatalf("expected boarding pass result")
+	}
+	if bp.Version != 6 || bp.CheckInSource != "W" || bp.IssueDate != "6225" || bp.IssuerAirline != "AC" {
+		t.Errorf("unique conditional = %+v", bp)
+	}
+	if len(bp.Legs) != 2 {
+		t.Fatalf("legs = %d, want 2", len(bp.Legs))
+	}
+	if bp.Legs[0].AirlineNumericCode != "014" || bp.Legs[0].DocumentSerialNumber != "0012345678" {
+		t.Errorf("repeated conditional = %+v", bp.Legs[0])
+	}
+	if bp.Legs[0].AirlineData != "AIRLINE" {
+		t.Errorf("airline data = %q", bp.Legs[0].AirlineData)
+	}
+	if bp.Legs[1].From != "FRA" || bp.Legs[1].To != "GVA" || bp.Legs[1].Carrier != "LH" {
+		t.Errorf("second leg = %+v", bp.Legs[1])
+	}
+	if bp.SecurityDataType != "1" || bp.SecurityData != "GIWVC5EH" {
+		t.Errorf("security = %q %q", bp.SecurityDataType, bp.SecurityData)
+	}
+}
+
+func hexSize(s string) string {
+	const digits = "0123456789ABCDEF"
+	return string([]byte{digits[len(s)>>4], digits[len(s)&0xF]})
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"sort"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/internal/font"
+)
+
+// annotationColours are given to results in turn.
+var annotationColours = []color.RGBA{
+	{230, 25, 75, 255},
+	{60, 180, 75, 255},
+	{0, 130, 200, 255},
+	{245, 130, 48, 255},
+	{145, 30, 180, 255},
+}
+
+// maxLabelRunes limits the decoded text shown in a label.
+const maxLabelRunes = 40
+
+// annotate draws each result's outline, result points and a label of its
+// format and text over a copy of the image, turned upright as it was
+// decoded.
+func annotate(source zxinggo.LuminanceSource, results []*zxinggo.Result) *image.RGBA {
+	width, height := source.Width(), source.Height()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i, l := range source.Matrix() {
+		img.SetRGBA(i%width, i/width, color.RGBA{l, l, l, 255})
+	}
+	// Lines and labels grow with the image so they stay legible.
+	scale := max(1, min(width, height)/400)
+	for i, r := range results {
+		if len(r.Points) == 0 {
+			continue
+		}
+		c := annotationColours[i%len(annotationColours)]
+		outline := outlinePoints(r.Points)
+		for j, p := range outline {
+			if len(outline) == 2 && j == 1 {
+				break
+			}
+			drawLine(img, p, outline[(j+1)%len(outline)], scale, c)
+		}
+		for _, p := range r.Points {
+			x, y := int(math.Round(p.X)), int(math.Round(p.Y))
+			fillRect(img, image.Rect(x-2*scale, y-2*scale, x+2*scale+1, y+2*scale+1), c)
+		}
+		drawLabel(img, outline, resultLabel(r), scale, c)
+	}
+	return img
+}
+
+// outlinePoints orders points around their centroid, so that joining them
+// in turn gives a polygon without crossings whatever order the reader
+// reported them in.
+func outlinePoints(points []zxinggo.ResultPoint) []zxinggo.ResultPoint {
+	var cx, cy float64
+	for _, p := range points {
+		cx += p.X
+		cy += p.Y
+	}
+	cx /= float64(len(points))
+	cy /= float64(len(points))
+	outline := append([]zxinggo.ResultPoint(nil), points...)
+	sort.Slice(outline, func(i, j int) bool {
+		return math.Atan2(outline[i].Y-cy, outline[i].X-cx) < math.Atan2(outline[j].Y-cy, outline[j].X-cx)
+	})
+	return outline
+}
+
+// resultLabel returns the label for r, its format and the start of its
+// text.
+func resultLabel(r *zxinggo.Result) string {
+	text := []rune(r.Text)
+	if len(text) > maxLabelRunes {
+		text = append(text[:maxLabelRunes-3], []rune("...")...)
+	}
+	return fmt.Sprintf("[%s] %s", r.Format, string(text))
+}
+
+// drawLabel prints label in white on a box of colour c just above the
+// outline, or below it if there is no room above, kept inside the image.
+func drawLabel(img *image.RGBA, outline []zxinggo.ResultPoint, label string, scale int, c color.RGBA) {
+	minX, minY, maxY := math.Inf(1), math.Inf(1), math.Inf(-1)
+	for _, p := range outline {
+		minX, minY, maxY = math.Min(minX, p.X), math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	pad := scale
+	boxWidth := font.TextWidth(label, scale) + 2*pad
+	boxHeight := font.GlyphHeight*scale + 2*pad
+	bounds := img.Bounds()
+	x := min(max(int(minX), 0), max(bounds.Dx()-boxWidth, 0))
+	y := int(minY) - 3*scale - boxHeight
+	if y < 0 {
+		y = min(int(maxY)+3*scale, max(bounds.Dy()-boxHeight, 0))
+	}
+	fillRect(img, image.Rect(x, y, x+boxWidth, y+boxHeight), c)
+	font.Draw(img, x+pad, y+pad, scale, label, color.White)
+}
+
+// drawLine draws a line from a to b, width pixels wide, in colour c.
+func drawLine(img *image.RGBA, a, b zxinggo.ResultPoint, width int, c color.RGBA) {
+	steps := int(math.Ceil(math.Max(math.Abs(b.X-a.X), math.Abs(b.Y-a.Y))))
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		x := int(math.Round(a.X + t*(b.X-a.X)))
+		y := int(math.Round(a.Y + t*(b.Y-a.Y)))
+		fillRect(img, image.Rect(x-width/2, y-width/2, x-width/2+width, y-width/2+width), c)
+	}
+}
+
+// fillRect fills r, clipped to the image, with colour c.
+func fillRect(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// writeAnnotated saves the annotated image as a PNG at path, reporting
+// failures on stderr.
+func writeAnnotated(path string, source zxinggo.LuminanceSource, results []*zxinggo.Result) {
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: error: %v\n", path, err)
+		return
+	}
+	defer f.Close()
+	if err := png.Encode(f, annotate(source, results)); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: error: %v\n", path, err)
+	}
+}
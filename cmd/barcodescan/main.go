@@ -1,136 +1,319 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"image"
-	_ "image/gif"
-	_ "image/jpeg"
-	_ "image/png"
 	"os"
+	"slices"
+	"strings"
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/binarizer"
+	_ "github.com/ericlevine/zxinggo/imageformat" // read BMP and WebP too
 
 	// Register all format readers.
 	_ "github.com/ericlevine/zxinggo/aztec"
 	_ "github.com/ericlevine/zxinggo/datamatrix"
+	_ "github.com/ericlevine/zxinggo/dotcode"
+	_ "github.com/ericlevine/zxinggo/hanxin"
 	_ "github.com/ericlevine/zxinggo/maxicode"
 	_ "github.com/ericlevine/zxinggo/oned"
 	_ "github.com/ericlevine/zxinggo/pdf417"
+	_ "github.com/ericlevine/zxinggo/postal"
 	_ "github.com/ericlevine/zxinggo/qrcode"
+	_ "github.com/ericlevine/zxinggo/stacked"
 )
 
+// Exit codes.
+const (
+	exitFound    = 0 // every image held the barcodes expected of it
+	exitNotFound = 1 // some image held none, or not as many as -expect
+	exitError    = 2 // a flag or image file could not be used
+)
+
+// scanConfig is what the flags ask of each scan.
+type scanConfig struct {
+	formats     []zxinggo.Format
+	tryHarder   bool
+	pure        bool
+	first       bool
+	heatmap     bool
+	dumpMatrix  bool
+	dumpCodes   bool
+	annotateOut string
+}
+
 func main() {
 	tryHarder := flag.Bool("try-harder", false, "spend more time looking for barcodes")
 	pure := flag.Bool("pure", false, "hint that the image is a clean barcode render with minimal border")
+	formats := flag.Bool("formats", false, "list the supported formats and their features, then exit")
+	jsonOut := flag.Bool("json", false, `print each result as a JSON line {"file": ..., "result": ...}`)
+	quiet := flag.Bool("quiet", false, "print only the decoded text of each barcode, one per line")
+	expect := flag.Int("expect", -1, "exit with status 1 unless exactly this many barcodes are found in each image-file")
+	first := flag.Bool("first", false, "stop scanning each image-file at the first barcode found")
+	heatmap := flag.Bool("heatmap", false, "write a heat map of detector work to <image-file>.heatmap.png")
+	dumpMatrix := flag.Bool("dump-matrix", false, "write the module grid of each 2D symbol located to <image-file>.<format>.<n>.txt, whether or not it decodes")
+	dumpCodewords := flag.Bool("dump-codewords", false, "print the codewords and syndromes of each QR code or Data Matrix symbol whose error correction fails")
+	annotateOut := flag.String("annotate", "", "write a copy of the image with each barcode's outline, format and text drawn on it to this PNG file")
+	only := flag.String("only", "", "comma-separated formats or families (ANY_1D, ANY_RETAIL, ANY_GS1, ANY_2D, ANY_POSTAL) to look for instead of every format")
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: barcodescan [flags] <image-file> [image-file...]\n\n")
-		fmt.Fprintf(os.Stderr, "Detect and decode barcodes in image files (PNG, JPEG, GIF).\n\n")
+		fmt.Fprintf(os.Stderr, "Detect and decode barcodes in image files (PNG, JPEG, GIF, BMP, WebP).\n")
+		fmt.Fprintf(os.Stderr, "Exits with status 0 if barcodes are found in every image-file, 1 if not,\n")
+		fmt.Fprintf(os.Stderr, "and 2 if a flag or image-file cannot be used.\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		flag.PrintDefaults()
 	}
 	flag.Parse()
 
+	if *formats {
+		printCapabilities()
+		return
+	}
+
 	if flag.NArg() == 0 {
 		flag.Usage()
-		os.Exit(1)
+		os.Exit(exitError)
+	}
+	if *annotateOut != "" && flag.NArg() > 1 {
+		fmt.Fprintf(os.Stderr, "barcodescan: -annotate takes a single image-file\n")
+		os.Exit(exitError)
+	}
+
+	config := &scanConfig{
+		formats:     zxinggo.ReadableFormats(),
+		tryHarder:   *tryHarder,
+		pure:        *pure,
+		first:       *first,
+		heatmap:     *heatmap,
+		dumpMatrix:  *dumpMatrix,
+		dumpCodes:   *dumpCodewords,
+		annotateOut: *annotateOut,
+	}
+	if *only != "" {
+		var requested []zxinggo.Format
+		for _, name := range strings.Split(*only, ",") {
+			format, err := zxinggo.ParseFormat(strings.ToUpper(strings.TrimSpace(name)))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "barcodescan: -only: %v\n", err)
+				os.Exit(exitError)
+			}
+			requested = append(requested, format)
+		}
+		// Decode tries every format when given only ones it cannot read.
+		readable := config.formats
+		config.formats = slices.DeleteFunc(zxinggo.ExpandFormats(requested), func(f zxinggo.Format) bool {
+			return !slices.Contains(readable, f)
+		})
 	}
 
-	exitCode := 0
+	exitCode := exitFound
+	fail := func(code int) {
+		exitCode = max(exitCode, code)
+	}
 	for _, path := range flag.Args() {
-		results, err := scanFile(path, *tryHarder, *pure)
+		results, err := scanFile(path, config)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%s: error: %v\n", path, err)
-			exitCode = 1
+			fail(exitError)
 			continue
 		}
-		if len(results) == 0 {
-			fmt.Fprintf(os.Stderr, "%s: no barcodes found\n", path)
-			exitCode = 1
-			continue
+		switch {
+		case *expect >= 0 && len(results) != *expect:
+			if !*quiet {
+				fmt.Fprintf(os.Stderr, "%s: found %d barcodes, expected %d\n", path, len(results), *expect)
+			}
+			fail(exitNotFound)
+		case len(results) == 0:
+			if !*quiet {
+				fmt.Fprintf(os.Stderr, "%s: no barcodes found\n", path)
+			}
+			fail(exitNotFound)
 		}
 		for _, r := range results {
-			if flag.NArg() > 1 {
-				fmt.Printf("%s: ", path)
+			switch {
+			case *jsonOut:
+				line, err := json.Marshal(struct {
+					File   string          `json:"file"`
+					Result *zxinggo.Result `json:"result"`
+				}{path, r})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%s: error: %v\n", path, err)
+					fail(exitError)
+					continue
+				}
+				fmt.Println(string(line))
+			case *quiet:
+				fmt.Println(r.Text)
+			default:
+				if flag.NArg() > 1 {
+					fmt.Printf("%s: ", path)
+				}
+				fmt.Printf("[%s] %s\n", r.Format, r.Text)
 			}
-			fmt.Printf("[%s] %s\n", r.Format, r.Text)
 		}
 	}
 	os.Exit(exitCode)
 }
 
-// allFormats lists every format to attempt.
-var allFormats = []zxinggo.Format{
-	zxinggo.FormatQRCode,
-	zxinggo.FormatPDF417,
-	zxinggo.FormatCode128,
-	zxinggo.FormatCode39,
-	zxinggo.FormatEAN13,
-	zxinggo.FormatEAN8,
-	zxinggo.FormatUPCA,
-	zxinggo.FormatUPCE,
-	zxinggo.FormatITF,
-	zxinggo.FormatCodabar,
-	zxinggo.FormatDataMatrix,
-	zxinggo.FormatAztec,
-	zxinggo.FormatRSS14,
-	zxinggo.FormatRSSExpanded,
-	zxinggo.FormatMaxiCode,
-	zxinggo.FormatCode93,
+// printCapabilities prints a table of the formats and what this build
+// supports for each.
+func printCapabilities() {
+	mark := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "-"
+	}
+	fmt.Printf("%-22s %-5s %-5s %-5s %-5s %-5s %s\n", "FORMAT", "READ", "WRITE", "MULTI", "ECI", "GS1", "STRUCTURED APPEND")
+	for _, c := range zxinggo.Capabilities() {
+		fmt.Printf("%-22s %-5s %-5s %-5s %-5s %-5s %s\n", c.Format, mark(c.Read), mark(c.Write),
+			mark(c.Multi), mark(c.ECI), mark(c.GS1), mark(c.StructuredAppend))
+	}
 }
 
-func scanFile(path string, tryHarder, pure bool) ([]*zxinggo.Result, error) {
+// scanFile decodes the barcodes in the image file at path as config asks.
+func scanFile(path string, config *scanConfig) ([]*zxinggo.Result, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
-	img, _, err := image.Decode(f)
+	// Photos are turned upright according to their EXIF orientation.
+	source, err := zxinggo.ReadImageLuminanceSource(f)
 	if err != nil {
 		return nil, fmt.Errorf("decode image: %w", err)
 	}
 
-	source := zxinggo.NewImageLuminanceSource(img)
 	opts := &zxinggo.DecodeOptions{
-		TryHarder:   tryHarder,
-		PureBarcode: pure,
+		TryHarder:     config.tryHarder,
+		PureBarcode:   config.pure,
+		DumpCodewords: config.dumpCodes,
+	}
+	if config.heatmap {
+		// One heat map collects the work of every attempt below.
+		opts.Heatmap = zxinggo.NewHeatmap(source)
+		defer writeHeatmap(path+".heatmap.png", opts.Heatmap)
 	}
 
-	// Try GlobalHistogram binarizer first (fast, works well for clean images),
-	// then fall back to Hybrid binarizer (local adaptive thresholding, better
-	// for photographs with uneven lighting). This mirrors the Java ZXing
-	// MultiFormatReader retry strategy.
-	bitmaps := []*zxinggo.BinaryBitmap{
-		zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(source)),
-		zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source)),
+	// Try the GlobalHistogram binarizer first (fast, works well for clean
+	// images), then the Hybrid binarizer (local adaptive thresholding,
+	// better for photographs with uneven lighting), as the Java ZXing
+	// MultiFormatReader retries. With -try-harder, climb on to the image
+	// halved, inverted and turned diagonally.
+	ladder := zxinggo.NewLadder(binarizer.NewGlobalHistogram(nil), binarizer.NewHybrid(nil))
+	if config.tryHarder {
+		ladder = zxinggo.TryHarderLadder(binarizer.NewGlobalHistogram(nil), binarizer.NewHybrid(nil))
 	}
 
 	var results []*zxinggo.Result
+	var bitmaps []*zxinggo.BinaryBitmap
 	seen := map[string]bool{}
+	dumped := map[string]bool{}
 
-	for _, bitmap := range bitmaps {
-		for _, format := range allFormats {
-			formatOpts := *opts
+climb:
+	for rung, bitmap := range ladder.Bitmaps(source) {
+		bitmaps = append(bitmaps, bitmap)
+		found := false
+		for _, format := range config.formats {
+			formatOpts := rung.Options(opts)
 			formatOpts.PossibleFormats = []zxinggo.Format{format}
 
-			result, err := tryDecode(bitmap, &formatOpts)
+			result, err := tryDecode(bitmap, formatOpts)
+			var decodeErr *zxinggo.DecodeError
+			if errors.As(err, &decodeErr) {
+				if dump := decodeErr.Dump(); !dumped[dump] {
+					dumped[dump] = true
+					fmt.Fprintf(os.Stderr, "%s: %s", path, dump)
+				}
+			}
 			if err != nil {
 				continue
 			}
+			found = true
 			key := fmt.Sprintf("%s:%s", result.Format, result.Text)
 			if seen[key] {
 				continue
 			}
 			seen[key] = true
 			results = append(results, result)
+			if config.first {
+				break climb
+			}
+		}
+		// The rungs -try-harder adds are costly; climb them only while
+		// nothing has been read.
+		if found && config.tryHarder {
+			break
 		}
 	}
 
+	if config.dumpMatrix {
+		writeMatrices(path, bitmaps, opts)
+	}
+	if config.annotateOut != "" {
+		writeAnnotated(config.annotateOut, source, results)
+	}
 	return results, nil
 }
 
+// writeMatrices saves the module grid of every symbol DetectOnly locates in
+// any of the bitmaps as text, one file per distinct grid, for bug reports.
+// Unset modules are written as dots, so that a grid keeps its width when
+// trailing spaces are trimmed; bitutil.ParseBitMatrix reads them back.
+func writeMatrices(path string, bitmaps []*zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) {
+	seen := map[string]bool{}
+	n := 0
+	for _, bitmap := range bitmaps {
+		detections, err := tryDetect(bitmap, opts)
+		if err != nil {
+			continue
+		}
+		for _, d := range detections {
+			dump := d.Bits.StringWithChars("X ", ". ")
+			if seen[dump] {
+				continue
+			}
+			seen[dump] = true
+			n++
+			out := fmt.Sprintf("%s.%s.%d.txt", path, d.Format, n)
+			if err := os.WriteFile(out, []byte(dump), 0o644); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: error: %v\n", out, err)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "%s: wrote %dx%d %s grid\n", out, d.Bits.Width(), d.Bits.Height(), d.Format)
+		}
+	}
+}
+
+// writeHeatmap saves h as a PNG at path, reporting failures on stderr.
+func writeHeatmap(path string, h *zxinggo.Heatmap) {
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: error: %v\n", path, err)
+		return
+	}
+	defer f.Close()
+	if err := h.WritePNG(f); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: error: %v\n", path, err)
+	}
+}
+
+// tryDetect calls zxinggo.DetectOnly, converting panics to errors as
+// tryDecode does.
+func tryDetect(bitmap *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) (detections []zxinggo.Detection, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			detections = nil
+			err = fmt.Errorf("detector panic: %v", r)
+		}
+	}()
+	return zxinggo.DetectOnly(bitmap, opts)
+}
+
 // tryDecode calls zxinggo.Decode but recovers from panics that decoders may
 // raise on malformed input, converting them to errors.
 func tryDecode(bitmap *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) (result *zxinggo.Result, err error) {
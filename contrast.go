@@ -0,0 +1,185 @@
+package zxinggo
+
+import "time"
+
+// Contrast selects a luminance pre-processing step applied before
+// binarization to images whose histogram spans too narrow a range of
+// luminance, such as faded thermal-printed receipts. Images with enough
+// contrast are binarized as they are.
+type Contrast int
+
+const (
+	// ContrastNone binarizes images as they are.
+	ContrastNone Contrast = iota
+	// ContrastStretch maps the darkest and lightest luminances linearly to
+	// black and white.
+	ContrastStretch
+	// ContrastEqualize stretches contrast like ContrastStretch, then
+	// equalizes the histogram of each tile of a 4 by 4 grid, limiting the
+	// gain so that noise in flat areas is not amplified, and blends between
+	// tiles. It suits unevenly faded images better than ContrastStretch.
+	ContrastEqualize
+)
+
+// minDynamicRange is the spread of luminance, between the darkest and
+// lightest contrastClipFraction of pixels, below which an image is enhanced.
+const minDynamicRange = 96
+
+// contrastClipFraction is the fraction of pixels at each end of the
+// histogram ignored when measuring an image's dynamic range, so that specks
+// of dirt or glare do not hide poor contrast.
+const contrastClipFraction = 0.01
+
+// equalizeTiles is the number of tiles across and down that ContrastEqualize
+// equalizes separately, and equalizeClipLimit is the most pixels of a
+// luminance a tile's histogram may have, as a multiple of the average.
+const (
+	equalizeTiles     = 4
+	equalizeClipLimit = 40
+)
+
+// enhanceContrast returns a copy of image binarized afresh from enhanced
+// luminances if opts asks for it and the image's dynamic range is poor.
+// Otherwise, or if its binarizer cannot be recreated, it returns image.
+func enhanceContrast(image *BinaryBitmap, opts *DecodeOptions) *BinaryBitmap {
+	if opts == nil || opts.Contrast == ContrastNone {
+		return image
+	}
+	defer observeStage(opts, MetricsStageContrast, time.Now())
+	source := image.binarizer.LuminanceSource()
+	width, height := source.Width(), source.Height()
+	lum := source.Matrix()
+	var histogram [256]int
+	for _, l := range lum {
+		histogram[l]++
+	}
+	low, high := histogramRange(&histogram, len(lum))
+	if high-low >= minDynamicRange {
+		return image
+	}
+
+	var enhanced []byte
+	switch opts.Contrast {
+	case ContrastStretch:
+		enhanced = stretchContrast(lum, low, high)
+	case ContrastEqualize:
+		enhanced = equalizeContrast(stretchContrast(lum, low, high), width, height)
+	default:
+		return image
+	}
+	binarizer := NewBinarizerFromSource(image.binarizer, &ImageLuminanceSource{luminances: enhanced, width: width, height: height})
+	if binarizer == nil {
+		return image
+	}
+	return NewBinaryBitmap(binarizer)
+}
+
+// histogramRange returns the luminances below which and above which
+// contrastClipFraction of the total pixels in histogram lie.
+func histogramRange(histogram *[256]int, total int) (low, high int) {
+	clip := int(contrastClipFraction * float64(total))
+	for sum := 0; low < 255; low++ {
+		if sum += histogram[low]; sum > clip {
+			break
+		}
+	}
+	high = 255
+	for sum := 0; high > low; high-- {
+		if sum += histogram[high]; sum > clip {
+			break
+		}
+	}
+	return low, high
+}
+
+// stretchContrast maps luminances linearly so that low becomes black and
+// high white, saturating those beyond them.
+func stretchContrast(lum []byte, low, high int) []byte {
+	var table [256]byte
+	for l := range table {
+		switch {
+		case l <= low:
+			table[l] = 0
+		case l >= high:
+			table[l] = 255
+		default:
+			table[l] = byte((l - low) * 255 / (high - low))
+		}
+	}
+	out := make([]byte, len(lum))
+	for i, l := range lum {
+		out[i] = table[l]
+	}
+	return out
+}
+
+// equalizeContrast applies contrast-limited adaptive histogram equalization:
+// each tile's histogram is clipped and equalized, and each pixel is mapped
+// by blending the mappings of the four tiles whose centres surround it.
+func equalizeContrast(lum []byte, width, height int) []byte {
+	tilesX, tilesY := min(equalizeTiles, width), min(equalizeTiles, height)
+	tileW := (width + tilesX - 1) / tilesX
+	tileH := (height + tilesY - 1) / tilesY
+	tables := make([][256]byte, tilesX*tilesY)
+	for ty := 0; ty < tilesY; ty++ {
+		for tx := 0; tx < tilesX; tx++ {
+			var histogram [256]int
+			count := 0
+			for y := ty * tileH; y < min((ty+1)*tileH, height); y++ {
+				for _, l := range lum[y*width+tx*tileW : y*width+min((tx+1)*tileW, width)] {
+					histogram[l]++
+					count++
+				}
+			}
+			tables[ty*tilesX+tx] = equalizeTable(&histogram, count)
+		}
+	}
+
+	out := make([]byte, len(lum))
+	for y := 0; y < height; y++ {
+		// Tile coordinates of the pixel relative to the tile centres.
+		fy := (float64(y)+0.5)/float64(tileH) - 0.5
+		ty0 := max(0, min(int(fy), tilesY-1))
+		ty1 := min(ty0+1, tilesY-1)
+		wy := max(0, min(fy-float64(ty0), 1))
+		for x := 0; x < width; x++ {
+			fx := (float64(x)+0.5)/float64(tileW) - 0.5
+			tx0 := max(0, min(int(fx), tilesX-1))
+			tx1 := min(tx0+1, tilesX-1)
+			wx := max(0, min(fx-float64(tx0), 1))
+			l := lum[y*width+x]
+			top := (1-wx)*float64(tables[ty0*tilesX+tx0][l]) + wx*float64(tables[ty0*tilesX+tx1][l])
+			bottom := (1-wx)*float64(tables[ty1*tilesX+tx0][l]) + wx*float64(tables[ty1*tilesX+tx1][l])
+			out[y*width+x] = byte((1-wy)*top + wy*bottom + 0.5)
+		}
+	}
+	return out
+}
+
+// equalizeTable returns the equalizing mapping for a tile's histogram of
+// count pixels, after clipping it at equalizeClipLimit times the average and
+// spreading the excess evenly.
+func equalizeTable(histogram *[256]int, count int) [256]byte {
+	var table [256]byte
+	if count == 0 {
+		return table
+	}
+	limit := max(1, equalizeClipLimit*count/256)
+	excess := 0
+	for i, n := range histogram {
+		if n > limit {
+			excess += n - limit
+			histogram[i] = limit
+		}
+	}
+	bonus, remainder := excess/256, excess%256
+	sum := 0
+	for i, n := range histogram {
+		sum += n + bonus
+		if i < remainder {
+			sum++
+		}
+		table[i] = byte(sum * 255 / count)
+	}
+	return table
+}
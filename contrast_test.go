@@ -0,0 +1,67 @@
+package zxinggo_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+)
+
+// faded renders contents as a faded print would look: dark modules barely
+// darker than the paper, which itself fades from left to right.
+func faded(t *testing.T, contents string, format zxinggo.Format) *image.Gray {
+	t.Helper()
+	matrix, err := zxinggo.Encode(contents, format, 240, 120, nil)
+	if err != nil {
+		t.Fatalf("%v: encode error: %v", format, err)
+	}
+	width, height := matrix.Width(), matrix.Height()
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			paper := 200 + 4*x/width
+			if matrix.Get(x, y) {
+				paper -= 12
+			}
+			img.SetGray(x, y, color.Gray{Y: uint8(paper)})
+		}
+	}
+	return img
+}
+
+func TestContrastEnhancement(t *testing.T) {
+	tests := []struct {
+		format   zxinggo.Format
+		contents string
+	}{
+		{zxinggo.FormatQRCode, "FADED RECEIPT"},
+		{zxinggo.FormatCode128, "RECEIPT-0042"},
+	}
+	for _, tt := range tests {
+		source := zxinggo.NewGrayImageLuminanceSource(faded(t, tt.contents, tt.format))
+		for _, contrast := range []zxinggo.Contrast{zxinggo.ContrastNone, zxinggo.ContrastStretch, zxinggo.ContrastEqualize} {
+			for name, bitmap := range map[string]*zxinggo.BinaryBitmap{
+				"global": zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(source)),
+				"hybrid": zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source)),
+			} {
+				opts := &zxinggo.DecodeOptions{Contrast: contrast, PossibleFormats: []zxinggo.Format{tt.format}}
+				result, err := zxinggo.Decode(bitmap, opts)
+				if contrast == zxinggo.ContrastNone {
+					if err == nil {
+						t.Errorf("%v, %s: decoded without enhancement; the test image is too clear", tt.format, name)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("%v, %s, contrast %d: decode error: %v", tt.format, name, contrast, err)
+					continue
+				}
+				if result.Text != tt.contents {
+					t.Errorf("%v, %s, contrast %d: text = %q, want %q", tt.format, name, contrast, result.Text, tt.contents)
+				}
+			}
+		}
+	}
+}
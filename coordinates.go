@@ -0,0 +1,64 @@
+package zxinggo
+
+import "github.com/ericlevine/zxinggo/transform"
+
+// CoordinateMapper is implemented by LuminanceSources derived from another
+// image, as those returned by ImageLuminanceSource's Crop, Scale, Rotate and
+// RotateCounterClockwise are, to map their coordinates back to it. Decode,
+// Results, ReaderResults and DecodeDocument report the points of symbols
+// read from such a source in the coordinates of the original image, so
+// callers that crop or downscale before decoding need not map them back.
+type CoordinateMapper interface {
+	// ToOriginal returns the transform from the source's coordinates to
+	// the original image's, or nil if they are the same. As for
+	// TransformPoints, pixel (x, y) covers x to x+1 and y to y+1.
+	ToOriginal() *transform.PerspectiveTransform
+}
+
+// sourceToOriginal returns the transform from source's coordinates to its
+// original image's, or nil if source is not a CoordinateMapper or is not
+// derived from another image.
+func sourceToOriginal(source LuminanceSource) *transform.PerspectiveTransform {
+	if mapper, ok := source.(CoordinateMapper); ok {
+		return mapper.ToOriginal()
+	}
+	return nil
+}
+
+// deriveToOriginal returns the ToOriginal of a source derived from source,
+// step being the transform from the derived source's coordinates to
+// source's.
+func deriveToOriginal(source LuminanceSource, step *transform.PerspectiveTransform) *transform.PerspectiveTransform {
+	if parent := sourceToOriginal(source); parent != nil {
+		return parent.Times(step)
+	}
+	return step
+}
+
+// mapToOriginal returns result with its points mapped from image to the
+// image its source was derived from: a copy, or result itself if the source
+// is not derived from another image.
+func mapToOriginal(image *BinaryBitmap, result *Result) *Result {
+	xform := sourceToOriginal(image.LuminanceSource())
+	if xform == nil {
+		return result
+	}
+	return transformResult(result, xform)
+}
+
+// transformResult returns a copy of result with xform applied to its
+// points, or result itself if it has none.
+func transformResult(result *Result, xform *transform.PerspectiveTransform) *Result {
+	if len(result.Points) == 0 {
+		return result
+	}
+	points := append([]ResultPoint(nil), result.Points...)
+	TransformPoints(xform, points)
+	mapped := NewResult(result.Text, result.RawBytes, points, result.Format)
+	mapped.NumBits = result.NumBits
+	mapped.Timestamp = result.Timestamp
+	for k, v := range result.Metadata {
+		mapped.PutMetadata(k, v)
+	}
+	return mapped
+}
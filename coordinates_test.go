@@ -0,0 +1,73 @@
+package zxinggo_test
+
+import (
+	"image"
+	"image/draw"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+)
+
+// TestCoordinateMapper decodes a QR code from sources cropped, scaled and
+// turned from a page and checks that the points are reported where the
+// symbol is on the page.
+func TestCoordinateMapper(t *testing.T) {
+	matrix, err := zxinggo.Encode("MAPPED BACK", zxinggo.FormatQRCode, 200, 200, nil)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	page := image.NewGray(image.Rect(0, 0, 640, 480))
+	draw.Draw(page, page.Bounds(), image.White, image.Point{}, draw.Src)
+	symbol := zxinggo.BitMatrixToImage(matrix)
+	draw.Draw(page, symbol.Bounds().Add(image.Pt(350, 220)), symbol, image.Point{}, draw.Src)
+	source := zxinggo.NewGrayImageLuminanceSource(page)
+	opts := &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{zxinggo.FormatQRCode}}
+
+	want, err := zxinggo.Decode(zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source)), opts)
+	if err != nil {
+		t.Fatalf("decode page: %v", err)
+	}
+	derived := []struct {
+		name      string
+		source    *zxinggo.ImageLuminanceSource
+		tolerance float64
+	}{
+		{"crop", source.Crop(300, 180, 300, 280), 0.01},
+		{"scale", source.Scale(320, 240), 2},
+		{"crop and scale", source.Crop(300, 180, 300, 280).Scale(450, 420), 2},
+		// Finder pattern centres are estimated from the edges of their
+		// runs, which turning moves by a pixel.
+		{"rotate counterclockwise", source.RotateCounterClockwise(), 1},
+		{"rotate", source.Rotate(25), 3},
+		{"crop and rotate", source.Crop(300, 180, 300, 280).Rotate(-40), 3},
+	}
+	for _, d := range derived {
+		result, err := zxinggo.Decode(zxinggo.NewBinaryBitmap(binarizer.NewHybrid(d.source)), opts)
+		if err != nil {
+			t.Errorf("%s: %v", d.name, err)
+			continue
+		}
+		if len(result.Points) != len(want.Points) {
+			t.Errorf("%s: %d points, want %d", d.name, len(result.Points), len(want.Points))
+			continue
+		}
+		for i, p := range result.Points {
+			if zxinggo.Distance(p, want.Points[i]) > d.tolerance {
+				t.Errorf("%s: point %d at %v, want %v", d.name, i, p, want.Points[i])
+			}
+		}
+	}
+
+	cropped := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source.Crop(300, 180, 300, 280)))
+	found := 0
+	for result := range zxinggo.Results(cropped, opts) {
+		found++
+		if zxinggo.Distance(result.Points[0], want.Points[0]) > 0.01 {
+			t.Errorf("Results: point 0 at %v, want %v", result.Points[0], want.Points[0])
+		}
+	}
+	if found != 1 {
+		t.Errorf("Results found %d symbols, want 1", found)
+	}
+}
@@ -0,0 +1,110 @@
+package zxinggo
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/ericlevine/zxinggo/transform"
+)
+
+// CropRect is a rectangle in the plane of a decoded symbol, in modules. The
+// origin is the symbol's top-left corner as printed, with X to the right and
+// Y down along its rows, so a label printed under a 25×25 QR Code, 2 modules
+// below it and 8 modules high, is {X: 0, Y: 27, Width: 25, Height: 8}.
+// Negative coordinates lie above or to the left of the symbol.
+type CropRect struct {
+	X, Y          float64
+	Width, Height float64
+}
+
+// CropNearSymbol returns the part of source covered by rect in the plane of
+// the symbol decoded as result, rectified so the symbol's rows run
+// horizontally, for passing printed text beside a symbol to an OCR engine.
+// pixelsPerModule sets the resolution of the crop; if zero, the symbol's
+// module size in the image is used. Pixels outside source are white.
+//
+// It also returns the transform from crop coordinates, in which pixel
+// centres lie at half-integers, to image coordinates as used by result
+// points, for mapping OCR output back onto the image. Only QR Code and Data
+// Matrix results, whose points and MetadataSymbolDimension fix the symbol's
+// geometry, are supported.
+func CropNearSymbol(source LuminanceSource, result *Result, rect CropRect, pixelsPerModule float64) (*image.Gray, *transform.PerspectiveTransform, error) {
+	if rect.Width <= 0 || rect.Height <= 0 || pixelsPerModule < 0 {
+		return nil, nil, fmt.Errorf("%w: invalid crop rectangle or resolution", ErrFormat)
+	}
+	modules, _, _, err := symbolModulePoints(result)
+	if err != nil {
+		return nil, nil, err
+	}
+	points := result.Points[:len(modules)]
+	if len(modules) == 3 {
+		// Complete the parallelogram; perspective is lost, but it is mild
+		// over the small symbols that have no alignment pattern.
+		modules = append(modules[:3:3], ResultPoint{X: modules[0].X + modules[2].X - modules[1].X, Y: modules[0].Y + modules[2].Y - modules[1].Y})
+		points = append(points[:3:3], ResultPoint{X: points[0].X + points[2].X - points[1].X, Y: points[0].Y + points[2].Y - points[1].Y})
+	}
+	if pixelsPerModule == 0 {
+		var inImage, inModules float64
+		for i := range modules {
+			j := (i + 1) % len(modules)
+			inImage += Distance(points[i], points[j])
+			inModules += Distance(modules[i], modules[j])
+		}
+		pixelsPerModule = inImage / inModules
+	}
+
+	width := int(math.Ceil(rect.Width * pixelsPerModule))
+	height := int(math.Ceil(rect.Height * pixelsPerModule))
+	if width < 1 || height < 1 || width > 4*source.Width() || height > 4*source.Height() {
+		return nil, nil, fmt.Errorf("%w: crop of %dx%d pixels is out of range", ErrFormat, width, height)
+	}
+	m, p := modules, points
+	moduleToImage := transform.QuadrilateralToQuadrilateral(
+		m[0].X, m[0].Y, m[1].X, m[1].Y, m[2].X, m[2].Y, m[3].X, m[3].Y,
+		p[0].X, p[0].Y, p[1].X, p[1].Y, p[2].X, p[2].Y, p[3].X, p[3].Y)
+	cropToModule := transform.Translation(rect.X, rect.Y).Times(transform.Scaling(1/pixelsPerModule, 1/pixelsPerModule))
+	xform := moduleToImage.Times(cropToModule)
+
+	lum := source.Matrix()
+	sw, sh := source.Width(), source.Height()
+	out := image.NewGray(image.Rect(0, 0, width, height))
+	coords := make([]float64, 2*width)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			coords[2*x] = float64(x) + 0.5
+			coords[2*x+1] = float64(y) + 0.5
+		}
+		xform.TransformPoints(coords)
+		row := out.Pix[y*out.Stride:]
+		for x := 0; x < width; x++ {
+			row[x] = sampleBilinear(lum, sw, sh, coords[2*x], coords[2*x+1])
+		}
+	}
+	return out, xform, nil
+}
+
+// RectifiedImage returns the symbol decoded as r, cropped from source with
+// the quiet zone DefaultQuietZone gives its format and rectified so that
+// its rows run horizontally and each module is pixelsPerModule pixels
+// square, for archiving beside the decoded data or grading with a
+// third-party verifier. As with CropNearSymbol, of which it is the special
+// case, only QR Code and Data Matrix results are supported.
+func (r *Result) RectifiedImage(source LuminanceSource, pixelsPerModule int) (*image.Gray, error) {
+	if pixelsPerModule <= 0 {
+		return nil, fmt.Errorf("%w: invalid resolution of %d pixels per module", ErrFormat, pixelsPerModule)
+	}
+	_, cols, rows, err := symbolModulePoints(r)
+	if err != nil {
+		return nil, err
+	}
+	zone := DefaultQuietZone(r.Format)
+	rect := CropRect{
+		X:      -float64(zone.Left),
+		Y:      -float64(zone.Top),
+		Width:  float64(zone.Left) + cols + float64(zone.Right),
+		Height: float64(zone.Top) + rows + float64(zone.Bottom),
+	}
+	img, _, err := CropNearSymbol(source, r, rect, float64(pixelsPerModule))
+	return img, err
+}
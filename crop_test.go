@@ -0,0 +1,108 @@
+package zxinggo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCropNearSymbol(t *testing.T) {
+	// An 18×18 Data Matrix at 4 pixels per module, turned a quarter turn
+	// clockwise, with a dark label 4 modules high printed 2 modules below it.
+	const width, height = 200, 200
+	toImage := func(mx, my float64) ResultPoint { return ResultPoint{X: 120 - 4*my, Y: 20 + 4*mx} }
+	lum := make([]byte, width*height)
+	for iy := 0; iy < height; iy++ {
+		for ix := 0; ix < width; ix++ {
+			mx, my := (float64(iy)-20)/4, (120-float64(ix))/4
+			lum[iy*width+ix] = 255
+			if mx >= 0 && mx < 18 && my >= 20 && my < 24 {
+				lum[iy*width+ix] = 0
+			}
+		}
+	}
+	source := &ImageLuminanceSource{luminances: lum, width: width, height: height}
+	r := NewResult("", nil, []ResultPoint{toImage(0.5, 0.5), toImage(0.5, 17.5), toImage(17.5, 17.5), toImage(17.5, 0.5)}, FormatDataMatrix)
+	r.PutMetadata(MetadataSymbolDimension, [2]int{18, 18})
+
+	crop, xform, err := CropNearSymbol(source, r, CropRect{X: 0, Y: 20, Width: 18, Height: 4}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := crop.Bounds(); b.Dx() != 72 || b.Dy() != 16 {
+		t.Fatalf("crop is %dx%d, want 72x16", b.Dx(), b.Dy())
+	}
+	for y := 1; y < 15; y++ {
+		for x := 1; x < 71; x++ {
+			if v := crop.GrayAt(x, y).Y; v > 64 {
+				t.Fatalf("crop pixel (%d, %d) = %d, want dark", x, y, v)
+			}
+		}
+	}
+	want := toImage(0, 20)
+	if x, y := xform.Transform(0, 0); Distance(ResultPoint{X: x, Y: y}, want) > 1e-6 {
+		t.Errorf("crop origin maps to (%v, %v), want %v", x, y, want)
+	}
+
+	crop, _, err = CropNearSymbol(source, r, CropRect{X: 0, Y: 25, Width: 18, Height: 4}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := crop.GrayAt(18, 4).Y; v != 255 {
+		t.Errorf("crop below the label = %d, want white", v)
+	}
+}
+
+func TestCropNearSymbolErrors(t *testing.T) {
+	source := &ImageLuminanceSource{luminances: make([]byte, 100), width: 10, height: 10}
+	r := NewResult("", nil, []ResultPoint{{X: 1, Y: 1}, {X: 9, Y: 1}}, FormatCode128)
+	if _, _, err := CropNearSymbol(source, r, CropRect{Width: 1, Height: 1}, 0); !errors.Is(err, ErrFormat) {
+		t.Errorf("CropNearSymbol of a 1D result = %v, want ErrFormat", err)
+	}
+}
+
+func TestRectifiedImage(t *testing.T) {
+	// A 10×10 Data Matrix-like grid at 5 pixels per module, turned a
+	// quarter turn counterclockwise and shifted, its modules dark where
+	// x+2y is a multiple of 3.
+	const width, height = 120, 120
+	const scale, left, bottom = 5.0, 30.0, 90.0
+	dark := func(mx, my int) bool { return (mx+2*my)%3 == 0 }
+	toImage := func(mx, my float64) ResultPoint { return ResultPoint{X: left + scale*my, Y: bottom - scale*mx} }
+	lum := make([]byte, width*height)
+	for iy := 0; iy < height; iy++ {
+		for ix := 0; ix < width; ix++ {
+			mx, my := (bottom-float64(iy)-0.5)/scale, (float64(ix)+0.5-left)/scale
+			lum[iy*width+ix] = 255
+			if mx >= 0 && mx < 10 && my >= 0 && my < 10 && dark(int(mx), int(my)) {
+				lum[iy*width+ix] = 0
+			}
+		}
+	}
+	source := &ImageLuminanceSource{luminances: lum, width: width, height: height}
+	r := NewResult("", nil, []ResultPoint{toImage(0.5, 0.5), toImage(0.5, 9.5), toImage(9.5, 9.5), toImage(9.5, 0.5)}, FormatDataMatrix)
+	r.PutMetadata(MetadataSymbolDimension, [2]int{10, 10})
+
+	img, err := r.RectifiedImage(source, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A module of quiet zone either side.
+	if b := img.Bounds(); b.Dx() != 36 || b.Dy() != 36 {
+		t.Fatalf("image is %dx%d, want 36x36", b.Dx(), b.Dy())
+	}
+	for my := -1; my <= 10; my++ {
+		for mx := -1; mx <= 10; mx++ {
+			want := byte(255)
+			if mx >= 0 && mx < 10 && my >= 0 && my < 10 && dark(mx, my) {
+				want = 0
+			}
+			if v := img.GrayAt(3*mx+4, 3*my+4).Y; v != want {
+				t.Errorf("module (%d, %d) = %d, want %d", mx, my, v, want)
+			}
+		}
+	}
+
+	if _, err := r.RectifiedImage(source, 0); !errors.Is(err, ErrFormat) {
+		t.Errorf("RectifiedImage at 0 pixels per module = %v, want ErrFormat", err)
+	}
+}
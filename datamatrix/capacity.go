@@ -0,0 +1,49 @@
+package datamatrix
+
+import (
+	"fmt"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/datamatrix/decoder"
+	"github.com/ericlevine/zxinggo/datamatrix/encoder"
+)
+
+// capacityPlanner sizes Data Matrix symbols as Writer encodes them. Data
+// Matrix has a fixed error correction level for each size, so the level
+// must be empty.
+type capacityPlanner struct{}
+
+// Fits reports whether payload encodes in the symbol with the given version
+// number, 1 to 48.
+func (capacityPlanner) Fits(payload string, version int, ecLevel string) (bool, error) {
+	if ecLevel != "" {
+		return false, fmt.Errorf("%w: Data Matrix has no error correction level %q", zxinggo.ErrWriter, ecLevel)
+	}
+	v, err := decoder.GetVersionForNumber(version)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", zxinggo.ErrWriter, err)
+	}
+	codewords, err := encoder.EncodeHighLevel(payload)
+	if err != nil {
+		return false, nil
+	}
+	return len(codewords) <= v.DataCodewords(), nil
+}
+
+// SmallestSymbolFor returns the size Writer would encode payload in.
+func (capacityPlanner) SmallestSymbolFor(payload string, opts *zxinggo.EncodeOptions) (zxinggo.SymbolSize, error) {
+	code, err := encoder.Encode(payload)
+	if err != nil {
+		return zxinggo.SymbolSize{}, fmt.Errorf("%w: %v", zxinggo.ErrWriter, err)
+	}
+	v, err := decoder.GetVersionForDimensions(code.Height(), code.Width())
+	if err != nil {
+		return zxinggo.SymbolSize{}, fmt.Errorf("%w: %v", zxinggo.ErrWriter, err)
+	}
+	return zxinggo.SymbolSize{
+		Format:  zxinggo.FormatDataMatrix,
+		Version: v.VersionNumber(),
+		Width:   code.Width(),
+		Height:  code.Height(),
+	}, nil
+}
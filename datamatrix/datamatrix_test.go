@@ -1,11 +1,17 @@
 package datamatrix
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/binarizer"
 	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/datamatrix/decoder"
+	"github.com/ericlevine/zxinggo/datamatrix/encoder"
+	"github.com/ericlevine/zxinggo/internal/symbolgen"
 )
 
 func TestDataMatrixRoundTrip(t *testing.T) {
@@ -95,3 +101,404 @@ func (s *bitMatrixLuminanceSource) Matrix() []byte {
 	}
 	return result
 }
+
+func TestDecodeMatrix(t *testing.T) {
+	matrix, err := NewWriter().Encode("Hello, World!", zxinggo.FormatDataMatrix, 0, 0, nil)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	// Strip the writer's one-module quiet zone.
+	bits := bitutil.NewBitMatrixWithSize(matrix.Width()-2, matrix.Height()-2)
+	for y := 0; y < bits.Height(); y++ {
+		for x := 0; x < bits.Width(); x++ {
+			if matrix.Get(x+1, y+1) {
+				bits.Set(x, y)
+			}
+		}
+	}
+	result, err := DecodeMatrix(bits, nil)
+	if err != nil {
+		t.Fatalf("DecodeMatrix: %v", err)
+	}
+	if result.Text != "Hello, World!" {
+		t.Errorf("got %q, want %q", result.Text, "Hello, World!")
+	}
+	dim := [2]int{bits.Width(), bits.Height()}
+	if got := result.Metadata[zxinggo.MetadataSymbolDimension]; got != dim {
+		t.Errorf("symbol dimension = %v, want %v", got, dim)
+	}
+}
+
+// packTriples packs C40, Text or X12 values three to a codeword pair.
+func packTriples(values ...int) []byte {
+	var cw []byte
+	for i := 0; i < len(values); i += 3 {
+		v := 1600*values[i] + 40*values[i+1] + values[i+2] + 1
+		cw = append(cw, byte(v/256), byte(v%256))
+	}
+	return cw
+}
+
+// packEdifact packs 6-bit EDIFACT values into codewords, padding the last
+// one with zero bits.
+func packEdifact(values ...int) []byte {
+	var cw []byte
+	bits, n := 0, 0
+	for _, v := range values {
+		bits = bits<<6 | v
+		n += 6
+		for n >= 8 {
+			cw = append(cw, byte(bits>>(n-8)))
+			n -= 8
+		}
+	}
+	if n > 0 {
+		cw = append(cw, byte(bits<<(8-n)))
+	}
+	return cw
+}
+
+func cat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func TestDecodeBitStreamModes(t *testing.T) {
+	tests := []struct {
+		name      string
+		codewords []byte
+		want      string
+	}{
+		{"c40", cat([]byte{230}, packTriples(14, 22, 26, 14, 22, 26), []byte{254}), "AIMAIM"},
+		{"c40 shift 3", cat([]byte{230}, packTriples(14, 2, 2, 2, 0, 39), []byte{254}), "Ab`Z"},
+		{"text shift 3", cat([]byte{239}, packTriples(14, 2, 2, 2, 27, 3), []byte{254}), "aB{ "},
+		{"text shift 3 upper", cat([]byte{239}, packTriples(2, 26, 2, 1, 2, 31), []byte{254}), "ZA\x7f"},
+		{"c40 shift 2 fnc1", cat([]byte{230}, packTriples(1, 27, 4), []byte{254}), "\x1d0"},
+		{"c40 upper shift", cat([]byte{230}, packTriples(1, 30, 14), []byte{254}), "\xc1"},
+		{"c40 trailing ascii", cat([]byte{230}, packTriples(14, 15, 16), []byte{'x' + 1}), "ABCx"},
+		{"x12", cat([]byte{238}, packTriples(14, 4, 0, 1, 2, 3), []byte{254}), "A0\r*> "},
+		{"edifact unlatch after 4", cat([]byte{240}, packEdifact(1, 2, 3, 4, 31), []byte{'x' + 1, 'y' + 1, 'z' + 1}), "ABCDxyz"},
+		{"edifact unlatch after 1", cat([]byte{240}, packEdifact(1, 31), []byte{'x' + 1, 'y' + 1}), "Axy"},
+		{"edifact unlatch after 2", cat([]byte{240}, packEdifact(1, 2, 31), []byte{'x' + 1, 'y' + 1}), "ABxy"},
+		{"edifact unlatch after 3", cat([]byte{240}, packEdifact(1, 2, 3, 31), []byte{'x' + 1}), "ABCx"},
+		{"edifact implicit unlatch", cat([]byte{240}, packEdifact(1, 2, 3, 4), []byte{'1' + 1, '2' + 1}), "ABCD12"},
+		{"edifact symbols", cat([]byte{240}, packEdifact(0, 32, 46, 63, 31), []byte{'x' + 1, 'y' + 1}), "@ .?xy"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dr, err := decoder.DecodeBitStream(tc.codewords)
+			if err != nil {
+				t.Fatalf("DecodeBitStream(%v): %v", tc.codewords, err)
+			}
+			if dr.Text != tc.want {
+				t.Errorf("DecodeBitStream(%v) = %q, want %q", tc.codewords, dr.Text, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecodeBitStreamInvalid(t *testing.T) {
+	for _, codewords := range [][]byte{
+		cat([]byte{230}, packTriples(1, 28, 3)), // reserved shift 2 value
+		cat([]byte{230}, packTriples(0, 32, 3)), // shift 1 out of range
+		cat([]byte{230}, []byte{253, 254}),      // basic set value 40
+		cat([]byte{238}, packTriples(14, 14, 3), []byte{253, 254}),
+	} {
+		if _, err := decoder.DecodeBitStream(codewords); !errors.Is(err, zxinggo.ErrFormat) {
+			t.Errorf("DecodeBitStream(%v) error = %v, want ErrFormat", codewords, err)
+		}
+	}
+}
+
+// TestDecodeAllSizes builds every ECC-200 symbol size from codewords that
+// mix the encodation modes, and reads it back.
+func TestDecodeAllSizes(t *testing.T) {
+	mixed := cat(
+		[]byte{'a' + 1, 142}, // "a12"
+		[]byte{230}, packTriples(14, 22, 26, 2, 1, 3), []byte{254},
+		[]byte{239}, packTriples(14, 2, 2, 4, 5, 6), []byte{254},
+		[]byte{240}, packEdifact(1, 2, 3, 4, 31),
+		[]byte{'!' + 1},
+	)
+	const mixedText = "a12AIMa aB012ABCD!"
+
+	sizes := [][2]int{
+		{10, 10}, {12, 12}, {14, 14}, {16, 16}, {18, 18}, {20, 20}, {22, 22}, {24, 24},
+		{26, 26}, {32, 32}, {36, 36}, {40, 40}, {44, 44}, {48, 48}, {52, 52}, {64, 64},
+		{72, 72}, {80, 80}, {88, 88}, {96, 96}, {104, 104}, {120, 120}, {132, 132}, {144, 144},
+		{18, 8}, {32, 8}, {26, 12}, {36, 12}, {36, 16}, {48, 16},
+		{48, 8}, {64, 8}, {80, 8}, {96, 8}, {120, 8}, {144, 8}, {64, 12}, {88, 12}, {64, 16},
+		{36, 20}, {44, 20}, {64, 20}, {48, 22}, {48, 24}, {64, 24}, {40, 26}, {48, 26}, {64, 26},
+	}
+	for _, size := range sizes {
+		t.Run(fmt.Sprintf("%dx%d", size[0], size[1]), func(t *testing.T) {
+			info, err := encoder.LookupBySize(size[0], size[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Fill the symbol exactly with digit pairs, and, where it fits,
+			// encode the mixed-mode message followed by padding.
+			digits := strings.Repeat("0123456789", info.DataCapacity/5+1)[:2*info.DataCapacity]
+			cases := map[string][]byte{digits: mustHighLevel(t, digits)}
+			if len(mixed) <= info.DataCapacity {
+				cases[mixedText] = mixed
+			}
+			for want, codewords := range cases {
+				bits, err := encoder.EncodeCodewords(codewords, info)
+				if err != nil {
+					t.Fatal(err)
+				}
+				result, err := DecodeMatrix(bits, nil)
+				if err != nil {
+					t.Fatalf("DecodeMatrix: %v", err)
+				}
+				if result.Text != want {
+					t.Errorf("got %q, want %q", result.Text, want)
+				}
+			}
+		})
+	}
+}
+
+func mustHighLevel(t *testing.T, msg string) []byte {
+	t.Helper()
+	codewords, err := encoder.EncodeHighLevel(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return codewords
+}
+
+func TestVersionTables(t *testing.T) {
+	versions := decoder.Versions()
+	if len(versions) != 48 {
+		t.Fatalf("len(Versions()) = %d, want 48", len(versions))
+	}
+	for i, v := range versions {
+		if v.VersionNumber() != i+1 {
+			t.Errorf("Versions()[%d] is version %d", i, v.VersionNumber())
+		}
+		if got, err := decoder.GetVersionForNumber(i + 1); err != nil || got != v {
+			t.Errorf("GetVersionForNumber(%d) = %v, %v", i+1, got, err)
+		}
+		rows, cols := v.SymbolSizeRows(), v.SymbolSizeColumns()
+		if got, err := decoder.GetVersionForDimensions(rows, cols); err != nil || got != v {
+			t.Errorf("GetVersionForDimensions(%d, %d) = %v, %v", rows, cols, got, err)
+		}
+		if v.IsRectangular() != (rows != cols) {
+			t.Errorf("%dx%d: IsRectangular() = %v", rows, cols, v.IsRectangular())
+		}
+		si, err := encoder.LookupBySize(cols, rows)
+		if err != nil {
+			t.Errorf("%dx%d: not in the encoder's table", rows, cols)
+		} else if v.DataCodewords() != si.DataCapacity || v.GetECBlocks().TotalECCodewords() != si.ErrorCodewords {
+			t.Errorf("%dx%d: %d data and %d EC codewords, encoder has %d and %d", rows, cols,
+				v.DataCodewords(), v.GetECBlocks().TotalECCodewords(), si.DataCapacity, si.ErrorCodewords)
+		}
+	}
+	if v, _ := decoder.GetVersionForDimensions(144, 144); v.DataCodewords() != 1558 {
+		t.Errorf("144x144 holds %d data codewords, want 1558", v.DataCodewords())
+	}
+	for _, n := range []int{0, 49} {
+		if _, err := decoder.GetVersionForNumber(n); err == nil {
+			t.Errorf("GetVersionForNumber(%d) succeeded", n)
+		}
+	}
+}
+
+// TestDecodeGeneratedSymbols decodes symbols built module by module, without
+// the encoder, in every version.
+func TestDecodeGeneratedSymbols(t *testing.T) {
+	dec := decoder.NewDecoder()
+	for version := 1; version <= 48; version++ {
+		for seed := int64(0); seed < 3; seed++ {
+			symbol := symbolgen.DataMatrix(seed, version)
+			result, err := dec.Decode(symbol.Bits)
+			if err != nil {
+				t.Errorf("seed %d, version %d: %v", seed, version, err)
+				continue
+			}
+			if result.Text != symbol.Text {
+				t.Errorf("seed %d, version %d: decoded %q, want %q", seed, version, result.Text, symbol.Text)
+			}
+		}
+	}
+}
+
+// TestDMRE encodes messages into DMRE sizes and reads them back through the
+// detector, with the symbol rendered at 6 pixels per module in a quiet zone.
+func TestDMRE(t *testing.T) {
+	tests := []struct {
+		contents string
+		cols     int
+		rows     int
+	}{
+		{strings.Repeat("0123456789", 4)[:36], 48, 8},
+		{strings.Repeat("0123456789", 5)[:48], 64, 8},
+		{strings.Repeat("0123456789", 9)[:88], 36, 20},
+	}
+	for _, tt := range tests {
+		matrix, err := NewWriter().Encode(tt.contents, zxinggo.FormatDataMatrix, 0, 0, &zxinggo.EncodeOptions{DataMatrixShape: zxinggo.DataMatrixShapeRectangle, DataMatrixDMRE: true})
+		if err != nil {
+			t.Fatalf("%q: encode error: %v", tt.contents, err)
+		}
+		if w, h := matrix.Width()-2, matrix.Height()-2; w != tt.cols || h != tt.rows {
+			t.Errorf("%q: encoded as %dx%d, want %dx%d", tt.contents, h, w, tt.rows, tt.cols)
+			continue
+		}
+		const scale, border = 6, 30
+		img := bitutil.NewBitMatrixWithSize(matrix.Width()*scale+2*border, matrix.Height()*scale+2*border)
+		for y := 0; y < img.Height(); y++ {
+			for x := 0; x < img.Width(); x++ {
+				mx, my := (x-border)/scale, (y-border)/scale
+				if x >= border && y >= border && mx < matrix.Width() && my < matrix.Height() && matrix.Get(mx, my) {
+					img.Set(x, y)
+				}
+			}
+		}
+		bitmap := zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(newBitMatrixLuminanceSource(img)))
+		result, err := NewReader().Decode(bitmap, nil)
+		if err != nil {
+			t.Errorf("%q: decode error: %v", tt.contents, err)
+			continue
+		}
+		if result.Text != tt.contents {
+			t.Errorf("got %q, want %q", result.Text, tt.contents)
+		}
+		if dim := result.Metadata[zxinggo.MetadataSymbolDimension]; dim != [2]int{tt.cols, tt.rows} {
+			t.Errorf("%q: symbol dimension %v, want [%d %d]", tt.contents, dim, tt.cols, tt.rows)
+		}
+	}
+
+	// Without the option, the writer keeps to the ISO/IEC 16022 sizes.
+	opts := &zxinggo.EncodeOptions{DataMatrixShape: zxinggo.DataMatrixShapeRectangle}
+	matrix, err := NewWriter().Encode(tests[0].contents, zxinggo.FormatDataMatrix, 0, 0, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, h := matrix.Width()-2, matrix.Height()-2; w != 36 || h != 12 {
+		t.Errorf("rectangular symbol is %dx%d, want 12x36", h, w)
+	}
+}
+
+// dotPeen renders matrix as round dots of the given luminance on a surface
+// of another, 10 pixels per module with a 4 pixel gap between dots.
+func dotPeen(matrix *bitutil.BitMatrix, dot, surface byte) *zxinggo.ImageLuminanceSource {
+	const scale, border, radius = 10, 30, 3.0
+	width, height := matrix.Width()*scale+2*border, matrix.Height()*scale+2*border
+	lum := make([]byte, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			lum[y*width+x] = surface
+			mx, my := (x-border)/scale, (y-border)/scale
+			if x < border || y < border || mx >= matrix.Width() || my >= matrix.Height() || !matrix.Get(mx, my) {
+				continue
+			}
+			dx := float64(x-border-mx*scale) - scale/2 + 0.5
+			dy := float64(y-border-my*scale) - scale/2 + 0.5
+			if dx*dx+dy*dy <= radius*radius {
+				lum[y*width+x] = dot
+			}
+		}
+	}
+	return zxinggo.NewLuminanceSourceFromBytes(lum, width, height, width)
+}
+
+func TestDPM(t *testing.T) {
+	const contents = "SN 0042-7781"
+	matrix, err := encoder.Encode(contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name         string
+		dot, surface byte
+	}{
+		{"dot-peened", 110, 140},
+		{"laser-etched light on dark", 95, 60},
+	}
+	for _, tt := range tests {
+		source := dotPeen(matrix, tt.dot, tt.surface)
+		bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source))
+		if _, err := NewReader().Decode(bitmap, nil); err == nil {
+			t.Errorf("%s: decoded without DataMatrixDPM", tt.name)
+		}
+		result, err := NewReader().Decode(bitmap, &zxinggo.DecodeOptions{DataMatrixDPM: true})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if result.Text != contents {
+			t.Errorf("%s: got %q, want %q", tt.name, result.Text, contents)
+		}
+	}
+}
+
+func TestCloseMatrix(t *testing.T) {
+	m := bitutil.NewBitMatrixWithSize(20, 9)
+	for _, x := range []int{4, 8, 12} {
+		m.SetRegion(x, 3, 2, 3)
+	}
+	closed := closeMatrix(m, 2)
+	for x := 4; x < 14; x++ {
+		if !closed.Get(x, 4) {
+			t.Errorf("closed (%d, 4) is unset; dots 2 pixels apart should merge", x)
+		}
+	}
+	if closed.Get(3, 4) || closed.Get(14, 4) || closed.Get(8, 1) {
+		t.Error("closing grew the marks")
+	}
+}
+
+// renderLowRes renders matrix at scale pixels per module, not necessarily
+// whole, offset by (offsetX, offsetY) pixels in a white margin, each pixel
+// gray in proportion to the area of dark modules it covers.
+func renderLowRes(matrix *bitutil.BitMatrix, scale, offsetX, offsetY float64) *zxinggo.ImageLuminanceSource {
+	const samples = 8
+	width := int(float64(matrix.Width())*scale+2*offsetX) + 4
+	height := int(float64(matrix.Height())*scale+2*offsetY) + 4
+	lum := make([]byte, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			dark := 0
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					mx := (float64(x) + (float64(sx)+0.5)/samples - offsetX) / scale
+					my := (float64(y) + (float64(sy)+0.5)/samples - offsetY) / scale
+					if mx >= 0 && my >= 0 && int(mx) < matrix.Width() && int(my) < matrix.Height() && matrix.Get(int(mx), int(my)) {
+						dark++
+					}
+				}
+			}
+			lum[y*width+x] = byte(255 - 255*dark/(samples*samples))
+		}
+	}
+	return zxinggo.NewLuminanceSourceFromBytes(lum, width, height, width)
+}
+
+// TestLowResolution reads small symbols rendered at 2 to 3 pixels per
+// module, off the pixel grid, where the corners the detector finds are a
+// fraction of a module out until corrected against the clock tracks.
+func TestLowResolution(t *testing.T) {
+	for _, contents := range []string{"AB", "HELLO1", "Hello, World"} {
+		matrix, err := encoder.Encode(contents)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, scale := range []float64{2, 2.2, 2.6, 3} {
+			source := renderLowRes(matrix, scale, 3.5, 2.5)
+			bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source))
+			result, err := NewReader().Decode(bitmap, nil)
+			if err != nil {
+				t.Errorf("%dx%d at %g pixels per module: %v", matrix.Width(), matrix.Height(), scale, err)
+				continue
+			}
+			if result.Text != contents {
+				t.Errorf("%dx%d at %g pixels per module: got %q, want %q", matrix.Width(), matrix.Height(), scale, result.Text, contents)
+			}
+		}
+	}
+}
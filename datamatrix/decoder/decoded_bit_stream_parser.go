@@ -4,15 +4,11 @@ import (
 	"strings"
 
 	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/internal"
 )
 
 // DecoderResult holds the decoded text and raw bytes from a Data Matrix barcode.
-type DecoderResult struct {
-	Text            string
-	RawBytes        []byte
-	ErrorsCorrected int
-	SymbologyModifier int
-}
+type DecoderResult = internal.DecodedPayload
 
 // Data Matrix encoding modes
 const (
@@ -25,16 +21,12 @@ const (
 	modePad            // padding reached — stop
 )
 
-// C40 and Text shift 2 lookup table. Index 0-26 map to printable characters,
-// 27 = FNC1, 28-29 reserved, 30 = Upper Shift.
-var c40TextShift2 = [32]byte{
+// C40 and Text shift 2 lookup table. Index 0-26 map to printable characters
+// and 27 is FNC1; 30, Upper Shift, is handled in code.
+var c40TextShift2 = [28]byte{
 	'!', '"', '#', '$', '%', '&', '\'', '(', ')', '*', '+', ',', '-', '.', '/',
 	':', ';', '<', '=', '>', '?', '@', '[', '\\', ']', '^', '_',
 	0x1D, // 27: FNC1 (GS)
-	0,    // 28: reserved (Structured Append)
-	0,    // 29: reserved (Upper Shift latch — handled separately for C40/Text)
-	0,    // 30: Upper Shift — handled in code
-	0,    // 31: padding placeholder
 }
 
 // DecodeBitStream decodes the data codewords of a Data Matrix symbol into text.
@@ -87,10 +79,7 @@ func DecodeBitStream(bytes []byte) (*DecoderResult, error) {
 		}
 	}
 
-	return &DecoderResult{
-		Text:     result.String(),
-		RawBytes: bytes,
-	}, nil
+	return internal.NewDecodedPayload(bytes, result.String(), nil, ""), nil
 }
 
 // decodeASCII processes codewords in ASCII mode. It processes all codewords
@@ -194,83 +183,49 @@ func decodeC40Text(result *strings.Builder, bytes []byte, pos *int, textMode boo
 				}
 				if cVal == 3 {
 					appendWithShift(result, ' ', upperShift)
-					upperShift = false
-					continue
-				}
-				if cVal <= 13 {
+				} else if cVal <= 13 {
 					appendWithShift(result, byte('0'+cVal-4), upperShift)
-					upperShift = false
-					continue
-				}
-				if textMode {
-					appendWithShift(result, byte('a'+cVal-14), upperShift)
+				} else if cVal <= 39 {
+					if textMode {
+						appendWithShift(result, byte('a'+cVal-14), upperShift)
+					} else {
+						appendWithShift(result, byte('A'+cVal-14), upperShift)
+					}
 				} else {
-					appendWithShift(result, byte('A'+cVal-14), upperShift)
+					return 0, zxinggo.ErrFormat
 				}
 				upperShift = false
 
 			case 1: // Shift 1 set: ASCII 0-31
+				if cVal > 31 {
+					return 0, zxinggo.ErrFormat
+				}
 				appendWithShift(result, byte(cVal), upperShift)
 				upperShift = false
 				shift = 0
 
 			case 2: // Shift 2 set
-				if cVal < 27 {
+				if cVal <= 27 {
 					appendWithShift(result, c40TextShift2[cVal], upperShift)
 					upperShift = false
-				} else if cVal == 27 {
-					// FNC1
-					appendWithShift(result, 0x1D, upperShift)
-					upperShift = false
 				} else if cVal == 30 {
 					// Upper Shift — next character gets +128
 					upperShift = true
+				} else {
+					// 28 and 29 are not valid inside a C40/Text segment.
+					return 0, zxinggo.ErrFormat
 				}
-				// 28, 29, 31 are reserved/ignored
 				shift = 0
 
-			case 3: // Shift 3 set
-				if textMode {
-					// Text mode shift 3: ` A-Z { | } ~ DEL
-					if cVal == 0 {
-						appendWithShift(result, '`', upperShift)
-					} else if cVal <= 26 {
-						appendWithShift(result, byte('A'+cVal-1), upperShift)
-					} else {
-						switch cVal {
-						case 27:
-							appendWithShift(result, '{', upperShift)
-						case 28:
-							appendWithShift(result, '|', upperShift)
-						case 29:
-							appendWithShift(result, '}', upperShift)
-						case 30:
-							appendWithShift(result, '~', upperShift)
-						case 31:
-							appendWithShift(result, 127, upperShift)
-						}
-					}
-				} else {
-					// C40 mode shift 3: ` a-z { | } ~ DEL
-					if cVal == 0 {
-						appendWithShift(result, '`', upperShift)
-					} else if cVal <= 26 {
-						appendWithShift(result, byte('a'+cVal-1), upperShift)
-					} else {
-						switch cVal {
-						case 27:
-							appendWithShift(result, '{', upperShift)
-						case 28:
-							appendWithShift(result, '|', upperShift)
-						case 29:
-							appendWithShift(result, '}', upperShift)
-						case 30:
-							appendWithShift(result, '~', upperShift)
-						case 31:
-							appendWithShift(result, 127, upperShift)
-						}
-					}
+			case 3: // Shift 3 set: ` a-z { | } ~ DEL, with A-Z in Text mode
+				if cVal > 31 {
+					return 0, zxinggo.ErrFormat
+				}
+				ch := byte('`' + cVal)
+				if textMode && cVal >= 1 && cVal <= 26 {
+					ch = byte('A' + cVal - 1)
 				}
+				appendWithShift(result, ch, upperShift)
 				upperShift = false
 				shift = 0
 			}
@@ -326,6 +281,8 @@ func decodeAnsiX12(result *strings.Builder, bytes []byte, pos *int) (int, error)
 				result.WriteByte(byte('0' + cVal - 4))
 			case cVal >= 14 && cVal <= 39:
 				result.WriteByte(byte('A' + cVal - 14))
+			default:
+				return 0, zxinggo.ErrFormat
 			}
 		}
 	}
@@ -333,56 +290,29 @@ func decodeAnsiX12(result *strings.Builder, bytes []byte, pos *int) (int, error)
 }
 
 // decodeEdifact decodes EDIFACT encoded data.
-// EDIFACT packs four 6-bit values into three codewords (24 bits).
+// EDIFACT packs four 6-bit values into three codewords (24 bits). The
+// unlatch value 31 may end the segment part way through a triplet, in which
+// case the rest of its codeword is padding and ASCII resumes at the next
+// one. A segment also ends without an unlatch when no more than two
+// codewords remain; those are ASCII.
 func decodeEdifact(result *strings.Builder, bytes []byte, pos *int) (int, error) {
-	for *pos < len(bytes) {
-		// We need at least 3 bytes to decode a full EDIFACT triplet (4 values).
-		// However, partial decoding at end is also possible.
-
-		// Read up to 3 codewords and unpack 4 x 6-bit EDIFACT values.
-		// But the ZXing Java approach reads one byte at a time and extracts 6-bit
-		// values. Let me follow the Java approach:
-		//
-		// Java loops reading single bytes. For each byte, it takes the bottom 6 bits
-		// as an EDIFACT value. If the value is 31 (0x1F), it's an unlatch.
-		// Four bytes produce 4 values = one "word". But the 4 values are just the
-		// bottom 6 bits of 4 consecutive bytes.
-
-		// Actually, looking at the ZXing Java code more carefully:
-		// The EDIFACT encoder packs 4 x 6-bit values into 3 bytes.
-		// The decoder unpacks 3 bytes into 4 x 6-bit values.
-
-		if *pos+2 > len(bytes) {
-			break
-		}
-
-		b1 := int(bytes[*pos]) & 0xFF
-		*pos++
-		b2 := int(bytes[*pos]) & 0xFF
-		*pos++
-		b3 := int(bytes[*pos]) & 0xFF
-		*pos++
-
-		// 3 bytes = 24 bits = 4 x 6-bit values
-		val1 := (b1 >> 2) & 0x3F
-		val2 := ((b1 & 0x03) << 4) | ((b2 >> 4) & 0x0F)
-		val3 := ((b2 & 0x0F) << 2) | ((b3 >> 6) & 0x03)
-		val4 := b3 & 0x3F
-
-		vals := [4]int{val1, val2, val3, val4}
-		for _, ev := range vals {
-			if ev == 31 {
-				// Unlatch to ASCII
+	for len(bytes)-*pos > 2 {
+		triplet := int(bytes[*pos])<<16 | int(bytes[*pos+1])<<8 | int(bytes[*pos+2])
+		for i := 0; i < 4; i++ {
+			ev := (triplet >> (18 - 6*i)) & 0x3F
+			if ev == 0x1F {
+				// Unlatch to ASCII at the next codeword boundary.
+				*pos += (6*(i+1) + 7) / 8
 				return modeASCII, nil
 			}
-			// EDIFACT values 32-94 map directly to ASCII 32-94
-			// Values 0-31 and 95-63 map to ASCII 64-95 and 96-127
-			ch := ev
-			if (ch & 0x20) == 0 {
-				ch |= 0x40
+			// EDIFACT values 32-63 map directly to ASCII 32-63;
+			// values 0-30 map to ASCII 64-94.
+			if ev&0x20 == 0 {
+				ev |= 0x40
 			}
-			result.WriteByte(byte(ch))
+			result.WriteByte(byte(ev))
 		}
+		*pos += 3
 	}
 	return modeASCII, nil
 }
@@ -9,6 +9,10 @@ import (
 // Decoder decodes Data Matrix ECC-200 barcodes.
 type Decoder struct {
 	rsDecoder *reedsolomon.Decoder
+
+	// DumpCodewords has Decode return a *zxinggo.DecodeError, rather than
+	// zxinggo.ErrChecksum, when error correction fails.
+	DumpCodewords bool
 }
 
 // NewDecoder creates a new Data Matrix Decoder.
@@ -43,6 +47,7 @@ func (d *Decoder) Decode(bits *bitutil.BitMatrix) (*DecoderResult, error) {
 	resultBytes := make([]byte, totalDataBytes)
 	dataBlocksCount := len(dataBlocks)
 	totalErrorsCorrected := 0
+	unusedEC := 1.0
 
 	for j := 0; j < dataBlocksCount; j++ {
 		codewordBytes := dataBlocks[j].Codewords
@@ -50,9 +55,14 @@ func (d *Decoder) Decode(bits *bitutil.BitMatrix) (*DecoderResult, error) {
 
 		corrected, err := d.correctErrors(codewordBytes, numDataCodewords)
 		if err != nil {
+			if d.DumpCodewords {
+				return nil, d.codewordError(rawCodewords, version)
+			}
 			return nil, err
 		}
 		totalErrorsCorrected += corrected
+		capacity := len(codewordBytes) - numDataCodewords
+		unusedEC = min(unusedEC, 1-float64(2*corrected)/float64(capacity))
 
 		// De-interlace data blocks: block j's i-th codeword goes to
 		// position i*dataBlocksCount+j in the result.
@@ -67,10 +77,35 @@ func (d *Decoder) Decode(bits *bitutil.BitMatrix) (*DecoderResult, error) {
 		return nil, err
 	}
 	dr.ErrorsCorrected = totalErrorsCorrected
+	dr.UnusedErrorCorrection = max(unusedEC, 0)
 	dr.SymbologyModifier = 1
 	return dr, nil
 }
 
+// codewordError describes each block of codewords, as read, for a
+// DecodeError.
+func (d *Decoder) codewordError(rawCodewords []byte, version *Version) error {
+	dataBlocks, err := GetDataBlocks(rawCodewords, version)
+	if err != nil {
+		return err
+	}
+	e := &zxinggo.DecodeError{Format: zxinggo.FormatDataMatrix}
+	for _, db := range dataBlocks {
+		ints := make([]int, len(db.Codewords))
+		for i, b := range db.Codewords {
+			ints[i] = int(b)
+		}
+		numEC := len(ints) - db.NumDataCodewords
+		e.Blocks = append(e.Blocks, zxinggo.CodewordBlock{
+			Codewords:     ints,
+			DataCodewords: db.NumDataCodewords,
+			Syndromes:     reedsolomon.Syndromes(reedsolomon.DataMatrixField256, ints, numEC),
+			Correctable:   d.rsDecoder.Correctable(ints, numEC),
+		})
+	}
+	return e
+}
+
 // correctErrors uses Reed-Solomon error correction to fix errors in a block.
 func (d *Decoder) correctErrors(codewordBytes []byte, numDataCodewords int) (int, error) {
 	numCodewords := len(codewordBytes)
@@ -11,10 +11,24 @@ type ECB struct {
 
 // ECBlocks describes the error-correction block layout for a Data Matrix symbol.
 type ECBlocks struct {
-	ECCodewords int // total EC codewords across all blocks
+	ECCodewords int // EC codewords per block
 	Blocks      []ECB
 }
 
+// NumBlocks returns the total number of blocks.
+func (ecb ECBlocks) NumBlocks() int {
+	total := 0
+	for _, b := range ecb.Blocks {
+		total += b.Count
+	}
+	return total
+}
+
+// TotalECCodewords returns the total number of error-correction codewords.
+func (ecb ECBlocks) TotalECCodewords() int {
+	return ecb.ECCodewords * ecb.NumBlocks()
+}
+
 // Version describes a Data Matrix ECC-200 symbol size and its EC block layout.
 type Version struct {
 	versionNumber        int
@@ -47,6 +61,12 @@ func (v *Version) TotalCodewords() int { return v.totalCodewords }
 // GetECBlocks returns the error-correction block layout.
 func (v *Version) GetECBlocks() ECBlocks { return v.ecBlocks }
 
+// DataCodewords returns the number of data codewords the symbol holds.
+func (v *Version) DataCodewords() int { return v.totalCodewords - v.ecBlocks.TotalECCodewords() }
+
+// IsRectangular reports whether the symbol has fewer rows than columns.
+func (v *Version) IsRectangular() bool { return v.symbolSizeRows != v.symbolSizeColumns }
+
 func newVersion(versionNumber, symbolSizeRows, symbolSizeColumns, dataRegionSizeRows, dataRegionSizeColumns, ecCodewordsPerBlock int, blocks ...ECB) Version {
 	total := 0
 	for _, block := range blocks {
@@ -66,6 +86,26 @@ func newVersion(versionNumber, symbolSizeRows, symbolSizeColumns, dataRegionSize
 	}
 }
 
+// Versions returns every symbol size in order of version number: the 24
+// square sizes, then the 6 rectangular ones and the 18 rectangular DMRE
+// extensions of ISO 21471.
+func Versions() []*Version {
+	all := make([]*Version, len(versions))
+	for i := range versions {
+		all[i] = &versions[i]
+	}
+	return all
+}
+
+// GetVersionForNumber returns the Version with the given version number
+// (1-48).
+func GetVersionForNumber(number int) (*Version, error) {
+	if number < 1 || number > len(versions) {
+		return nil, fmt.Errorf("datamatrix/decoder: invalid version number %d", number)
+	}
+	return &versions[number-1], nil
+}
+
 // GetVersionForDimensions returns the Version for a Data Matrix symbol of the
 // given row and column count.
 func GetVersionForDimensions(numRows, numColumns int) (*Version, error) {
@@ -81,7 +121,7 @@ func GetVersionForDimensions(numRows, numColumns int) (*Version, error) {
 // Data from ISO/IEC 16022 Table 7 and ISO 21471:2020 (DMRE) 5.5.1 Table 7.
 //
 // Fields per entry: versionNumber, symbolSizeRows, symbolSizeColumns,
-//   dataRegionSizeRows, dataRegionSizeColumns, totalECCodewords, ECB{count, dataCodewords}...
+//   dataRegionSizeRows, dataRegionSizeColumns, ecCodewordsPerBlock, ECB{count, dataCodewords}...
 var versions = [48]Version{
 	// Square symbols
 	newVersion(1, 10, 10, 8, 8, 5, ECB{1, 3}),
@@ -13,15 +13,13 @@ import (
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/datamatrix/decoder"
 	"github.com/ericlevine/zxinggo/transform"
 )
 
 // DetectorResult holds the result of detecting a Data Matrix barcode: the
 // sampled bit matrix and the four corner points.
-type DetectorResult struct {
-	Bits   *bitutil.BitMatrix
-	Points []zxinggo.ResultPoint
-}
+type DetectorResult = zxinggo.DetectorResult
 
 // initSize is the default initial search size for WhiteRectangleDetector.
 const initSize = 10
@@ -30,18 +28,54 @@ const initSize = 10
 type detector struct {
 	image             *bitutil.BitMatrix
 	rectangleDetector *whiteRectangleDetector
+
+	// relaxed completes the parallelogram when the top-right corner cannot
+	// be located from the clock tracks.
+	relaxed bool
+
+	// heatmap records the symbol found, accepted if it was sampled.
+	heatmap *zxinggo.Heatmap
 }
 
 // Detect locates a Data Matrix barcode in the given binary image and returns
 // the sampled bit matrix along with the four corner points.
 func Detect(image *bitutil.BitMatrix) (*DetectorResult, error) {
+	return DetectWithOptions(image, nil)
+}
+
+// DetectWithOptions is like Detect but records the rows and columns it
+// searches for the white rectangle, and the symbol it finds, in
+// opts.Heatmap. opts may be nil.
+func DetectWithOptions(image *bitutil.BitMatrix, opts *zxinggo.DecodeOptions) (*DetectorResult, error) {
+	var heatmap *zxinggo.Heatmap
+	if opts != nil {
+		heatmap = opts.Heatmap
+	}
 	wrd, err := newWhiteRectangleDetector(image)
 	if err != nil {
 		return nil, err
 	}
+	wrd.heatmap = heatmap
 	d := &detector{
 		image:             image,
 		rectangleDetector: wrd,
+		heatmap:           heatmap,
+	}
+	return d.detect()
+}
+
+// DetectRelaxed is like Detect but, when the clock tracks are too broken to
+// locate the top-right corner, as in dot-peened marks, takes it to complete
+// the parallelogram of the other three.
+func DetectRelaxed(image *bitutil.BitMatrix) (*DetectorResult, error) {
+	wrd, err := newWhiteRectangleDetector(image)
+	if err != nil {
+		return nil, err
+	}
+	d := &detector{
+		image:             image,
+		rectangleDetector: wrd,
+		relaxed:           true,
 	}
 	return d.detect()
 }
@@ -55,7 +89,14 @@ func (d *detector) detect() (*DetectorResult, error) {
 	points := d.detectSolid1(cornerPoints)
 	points = d.detectSolid2(points)
 	points[3] = d.correctTopRight(points)
+	if points[3] == (zxinggo.ResultPoint{}) && d.relaxed {
+		topRight := zxinggo.ResultPoint{X: points[0].X + points[2].X - points[1].X, Y: points[0].Y + points[2].Y - points[1].Y}
+		if d.isValid(topRight) {
+			points[3] = topRight
+		}
+	}
 	if points[3] == (zxinggo.ResultPoint{}) {
+		d.recordCandidate(points[0], points[1], points[2], zxinggo.ResultPoint{X: points[0].X + points[2].X - points[1].X, Y: points[0].Y + points[2].Y - points[1].Y}, false)
 		return nil, zxinggo.ErrNotFound
 	}
 	points = d.shiftToModuleCenter(points)
@@ -74,26 +115,143 @@ func (d *detector) detect() (*DetectorResult, error) {
 		dimensionRight++
 	}
 
-	if 4*dimensionTop < 6*dimensionRight && 4*dimensionRight < 6*dimensionTop {
-		// The matrix is square
-		if dimensionTop > dimensionRight {
-			dimensionRight = dimensionTop
-		} else {
-			dimensionTop = dimensionRight
-		}
+	dimensionTop, dimensionRight = symbolDimensions(dimensionTop, dimensionRight)
+
+	xform := gridTransform(topLeft, bottomLeft, bottomRight, topRight, dimensionTop, dimensionRight)
+	if max(dimensionTop, dimensionRight) <= clockVotingMaxDimension {
+		xform = d.voteClockTracks(xform, dimensionTop, dimensionRight)
+		topLeft, bottomLeft, bottomRight, topRight = moduleCenter(xform, 0, 0),
+			moduleCenter(xform, 0, dimensionRight-1),
+			moduleCenter(xform, dimensionTop-1, dimensionRight-1),
+			moduleCenter(xform, dimensionTop-1, 0)
 	}
 
-	bits, err := sampleGrid(d.image,
-		topLeft, bottomLeft, bottomRight, topRight,
-		dimensionTop, dimensionRight)
+	sampler := &transform.DefaultGridSampler{}
+	bits, err := sampler.SampleGridTransform(d.image, dimensionTop, dimensionRight, xform)
+	d.recordCandidate(topLeft, bottomLeft, bottomRight, topRight, err == nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &DetectorResult{
-		Bits:   bits,
-		Points: []zxinggo.ResultPoint{topLeft, bottomLeft, bottomRight, topRight},
-	}, nil
+	return zxinggo.NewDetectorResult(bits, []zxinggo.ResultPoint{topLeft, bottomLeft, bottomRight, topRight}), nil
+}
+
+// recordCandidate records the symbol with the given corners in d.heatmap,
+// centred on them and sized by its longer diagonal.
+func (d *detector) recordCandidate(topLeft, bottomLeft, bottomRight, topRight zxinggo.ResultPoint, accepted bool) {
+	if d.heatmap == nil {
+		return
+	}
+	x := (topLeft.X + bottomLeft.X + bottomRight.X + topRight.X) / 4
+	y := (topLeft.Y + bottomLeft.Y + bottomRight.Y + topRight.Y) / 4
+	size := max(zxinggo.Distance(topLeft, bottomRight), zxinggo.Distance(bottomLeft, topRight))
+	d.heatmap.Candidate(zxinggo.FormatDataMatrix, x, y, size, accepted)
+}
+
+// clockVotingMaxDimension is the largest symbol, in modules a side, whose
+// grid voteClockTracks corrects. In symbols of 10x10 to 16x16 modules from
+// low resolution images the corners found are often off by a good fraction
+// of a module, enough to sample a row or column of data from its
+// neighbour; larger symbols have transitions enough to place them well.
+const clockVotingMaxDimension = 16
+
+// clockVotingSteps are the corrections, in modules, voteClockTracks tries
+// for each of the shift and the scale of each axis.
+var clockVotingSteps = [...]float64{0, -0.25, 0.25, -0.5, 0.5}
+
+// voteClockTracks samples the finder pattern and clock tracks of a symbol
+// of dimensionX by dimensionY modules through xform, shifted and scaled by
+// up to half a module along each axis, and returns the transform whose
+// samples best match them: the solid L on the left and bottom and the
+// alternating tracks on the top and right. Ties go to the smaller
+// correction, and so to xform itself.
+func (d *detector) voteClockTracks(xform *transform.PerspectiveTransform, dimensionX, dimensionY int) *transform.PerspectiveTransform {
+	best, bestScore := xform, d.clockTrackScore(xform, dimensionX, dimensionY)
+	for _, shiftX := range clockVotingSteps {
+		for _, shiftY := range clockVotingSteps {
+			for _, scaleX := range clockVotingSteps {
+				for _, scaleY := range clockVotingSteps {
+					if shiftX == 0 && shiftY == 0 && scaleX == 0 && scaleY == 0 {
+						continue
+					}
+					// Scale about the middle of the symbol so that each
+					// correction moves opposite edges oppositely.
+					cx, cy := float64(dimensionX)/2, float64(dimensionY)/2
+					kx := 1 + scaleX/float64(dimensionX)
+					ky := 1 + scaleY/float64(dimensionY)
+					adjust := transform.Translation(cx+shiftX, cy+shiftY).
+						Times(transform.Scaling(kx, ky)).
+						Times(transform.Translation(-cx, -cy))
+					candidate := xform.Times(adjust)
+					if score := d.clockTrackScore(candidate, dimensionX, dimensionY); score > bestScore {
+						best, bestScore = candidate, score
+					}
+				}
+			}
+		}
+	}
+	return best
+}
+
+// clockTrackScore returns how many modules of the finder pattern and clock
+// tracks of a symbol of dimensionX by dimensionY modules sample as they
+// should through xform, or -1 if any falls outside the image.
+func (d *detector) clockTrackScore(xform *transform.PerspectiveTransform, dimensionX, dimensionY int) int {
+	score := 0
+	check := func(x, y int, black bool) bool {
+		px, py := xform.Transform(float64(x)+0.5, float64(y)+0.5)
+		ix, iy := int(px), int(py)
+		if px < 0 || py < 0 || ix >= d.image.Width() || iy >= d.image.Height() {
+			return false
+		}
+		if d.image.Get(ix, iy) == black {
+			score++
+		}
+		return true
+	}
+	for x := 0; x < dimensionX; x++ {
+		if !check(x, 0, x%2 == 0) || !check(x, dimensionY-1, true) {
+			return -1
+		}
+	}
+	for y := 1; y < dimensionY-1; y++ {
+		if !check(0, y, true) || !check(dimensionX-1, y, y%2 == 1) {
+			return -1
+		}
+	}
+	return score
+}
+
+// moduleCenter returns the point xform maps the center of module x, y to.
+func moduleCenter(xform *transform.PerspectiveTransform, x, y int) zxinggo.ResultPoint {
+	px, py := xform.Transform(float64(x)+0.5, float64(y)+0.5)
+	return zxinggo.ResultPoint{X: px, Y: py}
+}
+
+// symbolDimensions turns the columns and rows counted along the clock tracks
+// into a symbol size. Counts that are not an ECC 200 size are taken as
+// square if nearly so, and otherwise snapped to the nearest rectangular
+// size, including the DMRE sizes of ISO/IEC 21471, within two modules.
+func symbolDimensions(cols, rows int) (int, int) {
+	if _, err := decoder.GetVersionForDimensions(rows, cols); err == nil {
+		return cols, rows
+	}
+	if 4*cols < 6*rows && 4*rows < 6*cols {
+		// The matrix is square
+		if cols > rows {
+			return cols, cols
+		}
+		return rows, rows
+	}
+	bestCols, bestRows, bestDistance := cols, rows, 5
+	for _, v := range decoder.Versions() {
+		dc := iabs(v.SymbolSizeColumns() - cols)
+		dr := iabs(v.SymbolSizeRows() - rows)
+		if v.IsRectangular() && dc <= 2 && dr <= 2 && dc+dr < bestDistance {
+			bestCols, bestRows, bestDistance = v.SymbolSizeColumns(), v.SymbolSizeRows(), dc+dr
+		}
+	}
+	return bestCols, bestRows
 }
 
 // shiftPoint shifts a point toward another point by 1/(div+1) of the distance.
@@ -306,16 +464,13 @@ func (d *detector) isValid(p zxinggo.ResultPoint) bool {
 	return p.X >= 0 && p.X <= float64(d.image.Width()-1) && p.Y > 0 && p.Y <= float64(d.image.Height()-1)
 }
 
-// sampleGrid samples the image grid to produce the bit matrix.
-func sampleGrid(image *bitutil.BitMatrix,
-	topLeft, bottomLeft, bottomRight, topRight zxinggo.ResultPoint,
-	dimensionX, dimensionY int) (*bitutil.BitMatrix, error) {
+// gridTransform returns the transform from the module grid of a symbol of
+// dimensionX by dimensionY modules to the image, given the centers of its
+// corner modules.
+func gridTransform(topLeft, bottomLeft, bottomRight, topRight zxinggo.ResultPoint,
+	dimensionX, dimensionY int) *transform.PerspectiveTransform {
 
-	sampler := &transform.DefaultGridSampler{}
-
-	return sampler.SampleGrid(image,
-		dimensionX,
-		dimensionY,
+	return transform.QuadrilateralToQuadrilateral(
 		0.5,
 		0.5,
 		float64(dimensionX)-0.5,
@@ -419,6 +574,9 @@ type whiteRectangleDetector struct {
 	rightInit int
 	downInit  int
 	upInit    int
+
+	// heatmap records the pixels containsBlackPoint scans.
+	heatmap *zxinggo.Heatmap
 }
 
 func newWhiteRectangleDetector(image *bitutil.BitMatrix) (*whiteRectangleDetector, error) {
@@ -644,15 +802,19 @@ func (d *whiteRectangleDetector) containsBlackPoint(a, b, fixed int, horizontal
 	if horizontal {
 		for x := a; x <= b; x++ {
 			if d.image.Get(x, fixed) {
+				d.heatmap.ScanRow(fixed, a, x+1)
 				return true
 			}
 		}
+		d.heatmap.ScanRow(fixed, a, b+1)
 	} else {
 		for y := a; y <= b; y++ {
 			if d.image.Get(fixed, y) {
+				d.heatmap.ScanColumn(fixed, a, y+1)
 				return true
 			}
 		}
+		d.heatmap.ScanColumn(fixed, a, b+1)
 	}
 	return false
 }
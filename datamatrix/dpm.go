@@ -0,0 +1,157 @@
+package datamatrix
+
+import (
+	"math"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/datamatrix/detector"
+)
+
+// dpmClosingRadii are the radii, in pixels, of the morphological closings
+// tried on a direct part mark, from none for solid modules to wide enough
+// to join dots spaced a few pixels apart.
+var dpmClosingRadii = []int{0, 1, 2, 3, 5}
+
+// dpmWeight is k in the Wolf–Jolion threshold: how far the threshold moves
+// from the local mean towards the darkest luminance in flat areas.
+const dpmWeight = 0.5
+
+// decodeDPM searches source for a direct part mark, dark marks on a light
+// surface first, then light marks on a dark one.
+func (r *Reader) decodeDPM(source zxinggo.LuminanceSource, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	for _, light := range []bool{false, true} {
+		if zxinggo.DeadlinePassed(opts) {
+			return nil, zxinggo.ErrDeadlineExceeded
+		}
+		marks := dpmBinarize(source, light)
+		for _, radius := range dpmClosingRadii {
+			if zxinggo.DeadlinePassed(opts) {
+				return nil, zxinggo.ErrDeadlineExceeded
+			}
+			closed := marks
+			if radius > 0 {
+				closed = closeMatrix(marks, radius)
+			}
+			det, err := detector.DetectRelaxed(closed)
+			if err != nil {
+				continue
+			}
+			if result, err := decodeBits(newDecoder(opts), det.Bits, det.Points, opts); err == nil {
+				return result, nil
+			}
+		}
+	}
+	return nil, zxinggo.ErrNotFound
+}
+
+// dpmBinarize thresholds source against local contrast with the method of
+// Wolf and Jolion, which, unlike block thresholds with a minimum dynamic
+// range, separates marks that differ from their surface by only a few
+// levels while leaving flat areas clear. Set bits are marks: pixels darker
+// than the threshold, or lighter if light is set.
+func dpmBinarize(source zxinggo.LuminanceSource, light bool) *bitutil.BitMatrix {
+	width, height := source.Width(), source.Height()
+	lum := source.Matrix()
+	if light {
+		inverted := make([]byte, len(lum))
+		for i, l := range lum {
+			inverted[i] = 255 - l
+		}
+		lum = inverted
+	}
+
+	// Integral images of luminance and its square, with a zero first row
+	// and column.
+	stride := width + 1
+	sum := make([]float64, stride*(height+1))
+	sumSq := make([]float64, stride*(height+1))
+	darkest := 255.0
+	for y := 0; y < height; y++ {
+		var rowSum, rowSumSq float64
+		for x := 0; x < width; x++ {
+			l := float64(lum[y*width+x])
+			darkest = min(darkest, l)
+			rowSum += l
+			rowSumSq += l * l
+			sum[(y+1)*stride+x+1] = sum[y*stride+x+1] + rowSum
+			sumSq[(y+1)*stride+x+1] = sumSq[y*stride+x+1] + rowSumSq
+		}
+	}
+
+	radius := max(7, min(width, height)/16)
+	window := func(x, y int) (mean, deviation float64) {
+		x0, y0 := max(0, x-radius), max(0, y-radius)
+		x1, y1 := min(width, x+radius+1), min(height, y+radius+1)
+		n := float64((x1 - x0) * (y1 - y0))
+		s := sum[y1*stride+x1] - sum[y0*stride+x1] - sum[y1*stride+x0] + sum[y0*stride+x0]
+		sq := sumSq[y1*stride+x1] - sumSq[y0*stride+x1] - sumSq[y1*stride+x0] + sumSq[y0*stride+x0]
+		mean = s / n
+		return mean, math.Sqrt(max(0, sq/n-mean*mean))
+	}
+	maxDeviation := 0.0
+	for y := 0; y < height; y += radius {
+		for x := 0; x < width; x += radius {
+			_, d := window(x, y)
+			maxDeviation = max(maxDeviation, d)
+		}
+	}
+
+	marks := bitutil.NewBitMatrixWithSize(width, height)
+	if maxDeviation == 0 {
+		return marks
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			mean, deviation := window(x, y)
+			threshold := mean - dpmWeight*(1-deviation/maxDeviation)*(mean-darkest)
+			if float64(lum[y*width+x]) < threshold {
+				marks.Set(x, y)
+			}
+		}
+	}
+	return marks
+}
+
+// closeMatrix returns the morphological closing of m by a square of side
+// 2*radius+1: set bits are grown by radius, joining marks closer than
+// twice that, then shrunk back.
+func closeMatrix(m *bitutil.BitMatrix, radius int) *bitutil.BitMatrix {
+	return morph(morph(m, radius, true), radius, false)
+}
+
+// morph dilates m by a square of side 2*radius+1 if grow is set, or erodes
+// it, treating pixels outside m as unset. Both are separable, so it runs a
+// sliding window along the rows and then the columns.
+func morph(m *bitutil.BitMatrix, radius int, grow bool) *bitutil.BitMatrix {
+	width, height := m.Width(), m.Height()
+	pass := func(n int, get func(int) bool, set func(int)) {
+		count := 0
+		for i := 0; i < radius && i < n; i++ {
+			if get(i) {
+				count++
+			}
+		}
+		for i := 0; i < n; i++ {
+			if j := i + radius; j < n && get(j) {
+				count++
+			}
+			if j := i - radius - 1; j >= 0 && get(j) {
+				count--
+			}
+			size := min(n-1, i+radius) - max(0, i-radius) + 1
+			if grow && count > 0 || !grow && count == size && size == 2*radius+1 {
+				set(i)
+			}
+		}
+	}
+	rows := bitutil.NewBitMatrixWithSize(width, height)
+	for y := 0; y < height; y++ {
+		pass(width, func(x int) bool { return m.Get(x, y) }, func(x int) { rows.Set(x, y) })
+	}
+	out := bitutil.NewBitMatrixWithSize(width, height)
+	for x := 0; x < width; x++ {
+		pass(height, func(y int) bool { return rows.Get(x, y) }, func(y int) { out.Set(x, y) })
+	}
+	return out
+}
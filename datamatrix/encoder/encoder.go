@@ -23,6 +23,18 @@ func Encode(contents string) (*bitutil.BitMatrix, error) {
 // EncodeWithShape encodes the contents string into a Data Matrix ECC-200
 // symbol with the given shape constraint.
 func EncodeWithShape(contents string, shape SymbolShapeHint) (*bitutil.BitMatrix, error) {
+	return encode(contents, shape, Lookup)
+}
+
+// EncodeWithDMRE is like EncodeWithShape but may also choose the
+// rectangular extension (DMRE) sizes of ISO/IEC 21471, such as 8x48 or
+// 24x64, picking the symbol with the fewest modules. Not every reader
+// supports DMRE.
+func EncodeWithDMRE(contents string, shape SymbolShapeHint) (*bitutil.BitMatrix, error) {
+	return encode(contents, shape, LookupDMRE)
+}
+
+func encode(contents string, shape SymbolShapeHint, lookup func(int, SymbolShapeHint) (*SymbolInfo, error)) (*bitutil.BitMatrix, error) {
 	if len(contents) == 0 {
 		return nil, fmt.Errorf("datamatrix/encoder: empty contents")
 	}
@@ -34,11 +46,22 @@ func EncodeWithShape(contents string, shape SymbolShapeHint) (*bitutil.BitMatrix
 	}
 
 	// Step 2: Look up the appropriate symbol size.
-	symbolInfo, err := Lookup(len(encoded), shape)
+	symbolInfo, err := lookup(len(encoded), shape)
 	if err != nil {
 		return nil, fmt.Errorf("datamatrix/encoder: symbol lookup failed: %w", err)
 	}
 
+	return EncodeCodewords(encoded, symbolInfo)
+}
+
+// EncodeCodewords builds the symbol described by symbolInfo from already
+// high-level encoded data codewords, padding them to the symbol's capacity
+// and adding error correction.
+func EncodeCodewords(encoded []byte, symbolInfo *SymbolInfo) (*bitutil.BitMatrix, error) {
+	if len(encoded) > symbolInfo.DataCapacity {
+		return nil, fmt.Errorf("datamatrix/encoder: %d codewords exceed capacity %d", len(encoded), symbolInfo.DataCapacity)
+	}
+
 	// Step 3: Pad codewords to fill the data capacity.
 	codewords := PadCodewords(encoded, symbolInfo.DataCapacity)
 
@@ -102,9 +125,10 @@ func encodeLowLevel(placement *DefaultPlacement, symbolInfo *SymbolInfo) *bituti
 					matrix.Set(regionOriginX+x, regionOriginY)
 				}
 			}
-			// Right column (alternating, starting with set at the top).
+			// Right column (alternating, starting with unset at the top,
+			// so that the top-right corner module is light).
 			for y := 0; y < drRows+2; y++ {
-				if y%2 == 0 {
+				if y%2 == 1 {
 					matrix.Set(regionOriginX+drCols+1, regionOriginY+y)
 				}
 			}
@@ -79,11 +79,16 @@ func EncodeECC200(codewords []byte, symbolInfo *SymbolInfo) ([]byte, error) {
 		ecBlocks[i] = ec
 	}
 
-	// Interleave EC codewords into result.
+	// Interleave EC codewords into result. In the 144x144 symbol the two
+	// shorter blocks come first in each round, matching the decoder.
+	rotate := 0
+	if symbolInfo.NumRSBlocks2 > 0 {
+		rotate = block1Count
+	}
 	ecStart := symbolInfo.DataCapacity
 	for i := 0; i < ecPerBlock; i++ {
 		for j := 0; j < blockCount; j++ {
-			result[ecStart] = ecBlocks[j][i]
+			result[ecStart] = ecBlocks[(j+rotate)%blockCount][i]
 			ecStart++
 		}
 	}
@@ -119,8 +119,32 @@ var symbols = []SymbolInfo{
 	{true, 49, 28, 48, 16, 14, 22, 49, 28, 0, 0},
 }
 
+// dmreSymbols are the rectangular extension sizes of ISO/IEC 21471 (DMRE),
+// which Lookup leaves out as not every reader supports them.
+var dmreSymbols = []SymbolInfo{
+	{true, 18, 15, 48, 8, 6, 22, 18, 15, 0, 0},
+	{true, 24, 18, 64, 8, 6, 14, 24, 18, 0, 0},
+	{true, 32, 22, 80, 8, 6, 18, 32, 22, 0, 0},
+	{true, 38, 28, 96, 8, 6, 22, 38, 28, 0, 0},
+	{true, 49, 32, 120, 8, 6, 18, 49, 32, 0, 0},
+	{true, 63, 36, 144, 8, 6, 22, 63, 36, 0, 0},
+	{true, 43, 27, 64, 12, 10, 14, 43, 27, 0, 0},
+	{true, 64, 36, 88, 12, 10, 20, 64, 36, 0, 0},
+	{true, 62, 36, 64, 16, 14, 14, 62, 36, 0, 0},
+	{true, 44, 28, 36, 20, 18, 16, 44, 28, 0, 0},
+	{true, 56, 34, 44, 20, 18, 20, 56, 34, 0, 0},
+	{true, 84, 42, 64, 20, 18, 14, 84, 42, 0, 0},
+	{true, 72, 38, 48, 22, 20, 22, 72, 38, 0, 0},
+	{true, 80, 41, 48, 24, 22, 22, 80, 41, 0, 0},
+	{true, 108, 46, 64, 24, 22, 14, 108, 46, 0, 0},
+	{true, 70, 38, 40, 26, 24, 18, 70, 38, 0, 0},
+	{true, 90, 42, 48, 26, 24, 22, 90, 42, 0, 0},
+	{true, 118, 50, 64, 26, 24, 14, 118, 50, 0, 0},
+}
+
 // Lookup finds the smallest symbol that can hold the given number of data codewords.
 // shapeHint can be used to restrict the search to square or rectangular symbols.
+// The DMRE sizes are not considered; see LookupDMRE.
 func Lookup(dataCodewords int, shapeHint SymbolShapeHint) (*SymbolInfo, error) {
 	for i := range symbols {
 		si := &symbols[i]
@@ -137,12 +161,43 @@ func Lookup(dataCodewords int, shapeHint SymbolShapeHint) (*SymbolInfo, error) {
 	return nil, fmt.Errorf("datamatrix/encoder: no symbol found for %d data codewords", dataCodewords)
 }
 
-// LookupBySize returns the SymbolInfo for a specific symbol matrix size.
+// LookupDMRE is like Lookup but also considers the DMRE sizes of ISO/IEC
+// 21471, returning the symbol with the fewest modules that can hold the
+// given number of data codewords.
+func LookupDMRE(dataCodewords int, shapeHint SymbolShapeHint) (*SymbolInfo, error) {
+	var best *SymbolInfo
+	for _, table := range [][]SymbolInfo{symbols, dmreSymbols} {
+		for i := range table {
+			si := &table[i]
+			if shapeHint == ShapeHintForceSquare && si.Rectangular {
+				continue
+			}
+			if shapeHint == ShapeHintForceRectangle && !si.Rectangular {
+				continue
+			}
+			if si.DataCapacity < dataCodewords {
+				continue
+			}
+			if best == nil || si.MatrixWidth*si.MatrixHeight < best.MatrixWidth*best.MatrixHeight {
+				best = si
+			}
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("datamatrix/encoder: no symbol found for %d data codewords", dataCodewords)
+	}
+	return best, nil
+}
+
+// LookupBySize returns the SymbolInfo for a specific symbol matrix size,
+// including the DMRE sizes.
 func LookupBySize(matrixWidth, matrixHeight int) (*SymbolInfo, error) {
-	for i := range symbols {
-		si := &symbols[i]
-		if si.MatrixWidth == matrixWidth && si.MatrixHeight == matrixHeight {
-			return si, nil
+	for _, table := range [][]SymbolInfo{symbols, dmreSymbols} {
+		for i := range table {
+			si := &table[i]
+			if si.MatrixWidth == matrixWidth && si.MatrixHeight == matrixHeight {
+				return si, nil
+			}
 		}
 	}
 	return nil, errors.New("datamatrix/encoder: no symbol found for the given size")
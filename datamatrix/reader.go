@@ -2,32 +2,40 @@
 package datamatrix
 
 import (
-	"fmt"
-
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
 	"github.com/ericlevine/zxinggo/datamatrix/decoder"
 	"github.com/ericlevine/zxinggo/datamatrix/detector"
+	"github.com/ericlevine/zxinggo/transform"
 )
 
-// Reader decodes Data Matrix barcodes from binary images.
+// Reader decodes Data Matrix barcodes from binary images. Decode keeps no
+// state in the Reader, so one may be shared by goroutines.
 type Reader struct {
-	dec *decoder.Decoder
+	// opts are used by Decode and Detect when they are passed nil.
+	opts *zxinggo.DecodeOptions
 }
 
 // NewReader creates a new Data Matrix Reader.
 func NewReader() *Reader {
-	return &Reader{
-		dec: decoder.NewDecoder(),
-	}
+	return &Reader{}
+}
+
+// NewReaderWithOptions creates a Data Matrix reader that uses opts whenever it is
+// given nil options, so it can be configured once and used on its own
+// rather than through zxinggo.Decode.
+func NewReaderWithOptions(opts *zxinggo.DecodeOptions) *Reader {
+	return &Reader{opts: opts}
 }
 
 // Decode locates and decodes a Data Matrix barcode in the given image.
 func (r *Reader) Decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	if opts == nil {
+		opts = r.opts
+	}
 	if opts == nil {
 		opts = &zxinggo.DecodeOptions{}
 	}
-
 	matrix, err := image.BlackMatrix()
 	if err != nil {
 		return nil, err
@@ -38,30 +46,89 @@ func (r *Reader) Decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions
 		if err != nil {
 			return nil, err
 		}
-		dr, err := r.dec.Decode(bits)
-		if err != nil {
-			return nil, err
+		return decodeBits(newDecoder(opts), bits, nil, opts)
+	}
+
+	detResult, err := detector.DetectWithOptions(matrix, opts)
+	if err == nil {
+		var result *zxinggo.Result
+		if result, err = decodeBits(newDecoder(opts), detResult.Bits, detResult.Points, opts); err == nil {
+			return result, nil
 		}
-		result := zxinggo.NewResult(dr.Text, dr.RawBytes, nil, zxinggo.FormatDataMatrix)
-		result.PutMetadata(zxinggo.MetadataSymbologyIdentifier, fmt.Sprintf("]d%d", dr.SymbologyModifier))
-		result.PutMetadata(zxinggo.MetadataErrorsCorrected, dr.ErrorsCorrected)
-		return result, nil
 	}
+	if opts.DataMatrixDPM {
+		return r.decodeDPM(image.LuminanceSource(), opts)
+	}
+	return nil, err
+}
+
+// DecodeMatrix decodes a Data Matrix barcode from its module grid, one bit
+// per module including the finder and timing patterns but no quiet zone, as
+// sampled by an external detector. The grid must be upright, with the solid
+// L-shaped finder pattern along its left and bottom edges. opts may be nil;
+// only MaxErrorsCorrected is used. The result has no points.
+func DecodeMatrix(bits *bitutil.BitMatrix, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	return decodeBits(decoder.NewDecoder(), bits, nil, opts)
+}
 
-	detResult, err := detector.Detect(matrix)
+// newDecoder returns a decoder configured from opts. Each call decodes with
+// a decoder of its own, so that calls with different options on one Reader
+// do not interfere.
+func newDecoder(opts *zxinggo.DecodeOptions) *decoder.Decoder {
+	dec := decoder.NewDecoder()
+	dec.DumpCodewords = opts.DumpCodewords
+	return dec
+}
+
+// decodeBits decodes a sampled grid with dec, rejecting it if it needed
+// more correction than opts, which may be nil, allows.
+func decodeBits(dec *decoder.Decoder, bits *bitutil.BitMatrix, points []zxinggo.ResultPoint, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	dr, err := dec.Decode(bits)
 	if err != nil {
 		return nil, err
 	}
+	result := dr.Result(points, zxinggo.FormatDataMatrix)
+	result.PutMetadata(zxinggo.MetadataSymbolDimension, [2]int{bits.Width(), bits.Height()})
+	if err := zxinggo.CheckErrorBudget(result, opts); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
-	dr, err := r.dec.Decode(detResult.Bits)
+// Detect locates a Data Matrix barcode in the given image without decoding it.
+func (r *Reader) Detect(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) ([]zxinggo.Detection, error) {
+	if opts == nil {
+		opts = r.opts
+	}
+	matrix, err := image.BlackMatrix()
+	if err != nil {
+		return nil, err
+	}
+	detResult, err := detector.DetectWithOptions(matrix, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	result := zxinggo.NewResult(dr.Text, dr.RawBytes, detResult.Points, zxinggo.FormatDataMatrix)
-	result.PutMetadata(zxinggo.MetadataSymbologyIdentifier, fmt.Sprintf("]d%d", dr.SymbologyModifier))
-	result.PutMetadata(zxinggo.MetadataErrorsCorrected, dr.ErrorsCorrected)
-	return result, nil
+	// The detector's points are the centers of the corner modules; extend
+	// them by half a module to the symbol's edges.
+	cols := float64(detResult.Bits.Width())
+	rows := float64(detResult.Bits.Height())
+	topLeft, bottomLeft, bottomRight, topRight := detResult.Points[0], detResult.Points[1], detResult.Points[2], detResult.Points[3]
+	xform := transform.QuadrilateralToQuadrilateral(
+		0.5, 0.5, cols-0.5, 0.5, cols-0.5, rows-0.5, 0.5, rows-0.5,
+		topLeft.X, topLeft.Y, topRight.X, topRight.Y, bottomRight.X, bottomRight.Y, bottomLeft.X, bottomLeft.Y)
+	outline := []float64{0, 0, cols, 0, cols, rows, 0, rows}
+	xform.TransformPoints(outline)
+
+	points := make([]zxinggo.ResultPoint, 4)
+	for i := range points {
+		points[i] = zxinggo.ResultPoint{X: outline[2*i], Y: outline[2*i+1]}
+	}
+	return []zxinggo.Detection{{
+		Format: zxinggo.FormatDataMatrix,
+		Points: points,
+		Bits:   detResult.Bits,
+	}}, nil
 }
 
 // Reset resets internal state.
@@ -128,4 +195,7 @@ func moduleSizePure(leftTopBlack []int, image *bitutil.BitMatrix) (int, error) {
 }
 
 // Compile-time check.
-var _ zxinggo.Reader = (*Reader)(nil)
+var (
+	_ zxinggo.Reader   = (*Reader)(nil)
+	_ zxinggo.Detector = (*Reader)(nil)
+)
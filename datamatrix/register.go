@@ -9,4 +9,5 @@ func init() {
 	zxinggo.RegisterWriter(zxinggo.FormatDataMatrix, func() zxinggo.Writer {
 		return NewWriter()
 	})
+	zxinggo.RegisterCapacityPlanner(zxinggo.FormatDataMatrix, capacityPlanner{})
 }
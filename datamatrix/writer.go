@@ -25,22 +25,34 @@ func (w *Writer) Encode(contents string, format zxinggo.Format, width, height in
 		return nil, fmt.Errorf("can only encode DATA_MATRIX, but got %s", format)
 	}
 
-	encoded, err := encoder.Encode(contents)
+	shape := encoder.ShapeHintForceNone
+	encode := encoder.EncodeWithShape
+	if opts != nil {
+		switch opts.DataMatrixShape {
+		case zxinggo.DataMatrixShapeSquare:
+			shape = encoder.ShapeHintForceSquare
+		case zxinggo.DataMatrixShapeRectangle:
+			shape = encoder.ShapeHintForceRectangle
+		}
+		if opts.DataMatrixDMRE {
+			encode = encoder.EncodeWithDMRE
+		}
+	}
+	encoded, err := encode(contents, shape)
 	if err != nil {
 		return nil, err
 	}
 
-	return renderMatrix(encoded, width, height), nil
+	return renderMatrix(encoded, width, height, zxinggo.EncodeQuietZone(zxinggo.FormatDataMatrix, opts).Left), nil
 }
 
-// renderMatrix scales the encoded Data Matrix symbol to fit the requested
-// width and height, preserving the module aspect ratio.
-func renderMatrix(code *bitutil.BitMatrix, width, height int) *bitutil.BitMatrix {
+// renderMatrix scales the encoded Data Matrix symbol, with a quiet zone of qz
+// modules on each side, to fit the requested width and height, preserving
+// the module aspect ratio.
+func renderMatrix(code *bitutil.BitMatrix, width, height, qz int) *bitutil.BitMatrix {
 	inputWidth := code.Width()
 	inputHeight := code.Height()
 
-	// Add a 1-module quiet zone on each side.
-	qz := 1
 	outputWidth := inputWidth + 2*qz
 	outputHeight := inputHeight + 2*qz
 
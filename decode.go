@@ -1,15 +1,28 @@
 package zxinggo
 
+import "time"
+
 // DecodeOptions configures barcode decoding behavior.
 type DecodeOptions struct {
 	// PureBarcode hints that the image contains only the barcode with minimal
 	// border and no rotation.
 	PureBarcode bool
 
-	// TryHarder enables spending more time looking for barcodes.
+	// PureQuietZone is the width in pixels of the white border added around
+	// a PureBarcode image whose black pixels reach its edges, since most
+	// detectors need a quiet zone around the symbol. Zero adds an eighth of
+	// the image's longer side, and a negative value adds none.
+	PureQuietZone int
+
+	// TryHarder enables spending more time looking for barcodes. Images
+	// that do not read even so can be retried up a Ladder.
 	TryHarder bool
 
-	// PossibleFormats limits which formats to look for.
+	// PossibleFormats limits which formats to look for, tried in the order
+	// given. It may name format families, such as FormatAnyRetail, which
+	// stand for their formats; see ExpandFormats. When it is empty, every
+	// registered format is tried, in an order chosen from statistics of the
+	// image; see Heatmap.FormatOrder.
 	PossibleFormats []Format
 
 	// CharacterSet specifies the character set to use when decoding.
@@ -18,24 +31,150 @@ type DecodeOptions struct {
 	// AllowedLengths restricts the set of valid barcode lengths for 1D formats.
 	AllowedLengths []int
 
+	// Profile selects how strictly symbols are held to their specification.
+	// See Profile for the tolerances it adjusts.
+	Profile Profile
+
 	// AssumeCode39CheckDigit assumes Code 39 includes a check digit.
 	AssumeCode39CheckDigit bool
 
+	// MaxInterCharacterGap is the widest gap allowed between two characters
+	// of a Code 39 symbol, in narrow element widths, or of a Code 93 symbol,
+	// whose characters should abut, in modules. Dot matrix printers leave
+	// wide ones. Zero leaves it to Profile, and a negative value allows any
+	// gap.
+	MaxInterCharacterGap float64
+
+	// AssumeTwoOfFiveCheckDigit assumes Matrix, Industrial and IATA 2 of 5
+	// symbols end in a modulo 10 check digit, which is verified and removed.
+	AssumeTwoOfFiveCheckDigit bool
+
 	// AssumeGS1 assumes data is GS1 formatted.
 	AssumeGS1 bool
 
 	// AllowedEANExtensions restricts the allowed EAN extension lengths.
 	AllowedEANExtensions []int
 
+	// ReportUPCAAsEAN13 reports UPC-A symbols as the EAN-13 symbols they
+	// also are, with the leading zero. Otherwise an EAN-13 symbol starting
+	// with zero is reported as FormatUPCA, without it, whenever FormatUPCA
+	// is among PossibleFormats or PossibleFormats is empty.
+	ReportUPCAAsEAN13 bool
+
+	// OneDCandidateRows, when positive, has 1D readers read on past the
+	// first row that decodes until this many rows have, or the rows they
+	// scan run out, and return the text most of them read, with every text
+	// read and its count in MetadataCandidates. Callers can then check
+	// ambiguous reads against the values they expect, such as a list of
+	// SKUs. Zero returns the first row's text.
+	OneDCandidateRows int
+
 	// AlsoInverted enables checking for barcodes on inverted images.
 	AlsoInverted bool
+
+	// SubPixelRadius, when positive, refines the result points of a successful
+	// decode to sub-pixel accuracy by fitting edges in the luminance image
+	// within this many pixels of each point. See RefinePoint.
+	SubPixelRadius int
+
+	// RegionProposer, if set, is asked for candidate symbol regions, such as
+	// those found by a neural detector. Each region is rectified and searched
+	// before the whole image is.
+	RegionProposer RegionProposer
+
+	// RegionsOnly skips the whole-image search when RegionProposer is set.
+	RegionsOnly bool
+
+	// Contrast selects how images with poor contrast, such as faded receipts,
+	// are enhanced before binarization. See Contrast.
+	Contrast Contrast
+
+	// Heatmap, if set, records where detectors search the image and the
+	// candidate patterns they find. See Heatmap.
+	Heatmap *Heatmap
+
+	// Metrics, if set, is told how long decoding and each of its stages
+	// take and which formats' readers succeed and fail. See MetricsSink.
+	Metrics MetricsSink
+
+	// AztecMaxLayers rejects Aztec symbols with more data layers than this
+	// before sampling them. Zero allows any size.
+	AztecMaxLayers int
+
+	// QRRequireAlignmentFrom rejects QR codes of this version or larger whose
+	// alignment pattern is not found, rather than sampling them with their
+	// bottom-right corner estimated from the finder patterns, which skews
+	// large symbols seen in perspective. Zero accepts any. See
+	// MetadataAlignmentPattern.
+	QRRequireAlignmentFrom int
+
+	// QRIgnoreECI3 has QR code readers guess the character set of byte
+	// segments after an ECI 000003 designator, as if there were none,
+	// rather than read them as the ISO-8859-1 it designates. Many encoders
+	// put it before UTF-8 or Shift_JIS text, which reading it strictly
+	// garbles.
+	QRIgnoreECI3 bool
+
+	// MaxErrorsCorrected limits, for each format it names, how many
+	// codewords error correction may repair, errors and erasures together,
+	// before a symbol is rejected as suspect. High-assurance applications
+	// can then have a heavily damaged symbol scanned again rather than trust
+	// its correction. Formats not named, and those without error correction,
+	// accept any symbol that corrects. See CheckErrorBudget.
+	MaxErrorsCorrected map[Format]int
+
+	// DumpCodewords has QR code and Data Matrix readers whose symbol fails
+	// error correction return a *DecodeError holding its codewords and
+	// syndromes, rather than ErrChecksum, and Decode return it rather than
+	// ErrNotFound when no other symbol is read.
+	DumpCodewords bool
+
+	// DataMatrixDPM tunes Data Matrix reading for direct part marks, such
+	// as dot-peened or laser-etched codes on metal, when the usual search
+	// fails: the image is binarized against local contrast, however low,
+	// dots are merged into solid modules by morphological closing, light
+	// marks on a dark surface are tried as well as dark ones, and a broken
+	// clock track is tolerated. It costs several extra searches per image.
+	DataMatrixDPM bool
+
+	// Normalize post-processes the text of decoded results, such as
+	// removing the GS characters of GS1 data. See TextNormalization.
+	Normalize TextNormalization
+
+	// DisableDetectors turns off optional search stages, bounding the time
+	// a decode takes at the cost of reading fewer difficult symbols. See
+	// DetectorStage.
+	DisableDetectors DetectorStage
+
+	// Deadline, if not zero, bounds the time a decode takes: once it has
+	// passed, decoding returns ErrDeadlineExceeded rather than trying
+	// further formats, retries or search stages. It is checked between
+	// readers, between the rows 1D readers scan, and between the searches
+	// of Aztec's quarters and Data Matrix direct part marks, so a decode
+	// overruns it by at most one such step.
+	Deadline time.Time
+
+	// MaxResults, if positive, stops a search for every symbol in an
+	// image, by Results, ReaderResults, DecodeDocument or any
+	// MultipleBarcodeReader's DecodeMultiple, once it has found this many.
+	MaxResults int
+
+	// StopOnFormats stops such a search as soon as it finds a symbol of
+	// one of these formats or families, the last it returns, such as the
+	// one QR code on a page of 1D symbols. The search still reads every
+	// format of PossibleFormats until then.
+	StopOnFormats []Format
 }
 
-// Reader decodes barcodes from a BinaryBitmap.
+// Reader decodes barcodes from a BinaryBitmap. Every format's reader, and
+// MultiFormatReader, implements it.
 type Reader interface {
-	// Decode attempts to decode a barcode from the image.
+	// Decode attempts to decode a barcode from the image. It does not
+	// combine what it reads with earlier images; readers that can, such as
+	// RSSExpandedReader.DecodeFrame, do so through other methods.
 	Decode(image *BinaryBitmap, opts *DecodeOptions) (*Result, error)
 
-	// Reset resets any internal state.
+	// Reset forgets any state kept between images, such as the rows a
+	// stacked 1D reader has tallied.
 	Reset()
 }
@@ -0,0 +1,88 @@
+package zxinggo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DecodeError reports a symbol that was located and sampled but whose error
+// correction failed, with the codewords as read. It wraps ErrChecksum. QR
+// code and Data Matrix readers return it in place of ErrChecksum when
+// DecodeOptions.DumpCodewords is set, so that a failed read can be examined:
+// a few blocks with many nonzero syndromes suggest damage beyond the error
+// correction capacity, while every block failing suggests the sampling grid
+// was misaligned or the wrong mask or size was assumed.
+type DecodeError struct {
+	Format Format
+	// Blocks are the symbol's error correction blocks, each as read before
+	// any correction.
+	Blocks []CodewordBlock
+}
+
+// CodewordBlock is one error correction block of a DecodeError.
+type CodewordBlock struct {
+	// Codewords are the block's data codewords followed by its error
+	// correction codewords.
+	Codewords []int
+	// DataCodewords is how many of Codewords are data.
+	DataCodewords int
+	// Syndromes are those of Codewords, all zero when the block was read
+	// without error.
+	Syndromes []int
+	// Correctable reports whether error correction repaired the block.
+	Correctable bool
+}
+
+// Error summarizes how many blocks failed to correct.
+func (e *DecodeError) Error() string {
+	failed := 0
+	for _, b := range e.Blocks {
+		if !b.Correctable {
+			failed++
+		}
+	}
+	return fmt.Sprintf("%v: %s symbol with %d of %d blocks uncorrectable", ErrChecksum, e.Format, failed, len(e.Blocks))
+}
+
+// Unwrap returns ErrChecksum.
+func (e *DecodeError) Unwrap() error {
+	return ErrChecksum
+}
+
+// Dump returns the codewords and syndromes of each block in hexadecimal,
+// sixteen to a line after their offset in the block, for reading by eye.
+func (e *DecodeError) Dump() string {
+	var sb strings.Builder
+	sb.WriteString(e.Error())
+	sb.WriteByte('\n')
+	for i, b := range e.Blocks {
+		state := "uncorrectable"
+		if b.Correctable {
+			state = "correctable"
+		}
+		fmt.Fprintf(&sb, "block %d: %d data + %d error correction codewords, %s\n",
+			i+1, b.DataCodewords, len(b.Codewords)-b.DataCodewords, state)
+		dumpHex(&sb, b.Codewords)
+		nonzero := 0
+		for _, s := range b.Syndromes {
+			if s != 0 {
+				nonzero++
+			}
+		}
+		fmt.Fprintf(&sb, "  syndromes, %d of %d nonzero:\n", nonzero, len(b.Syndromes))
+		dumpHex(&sb, b.Syndromes)
+	}
+	return sb.String()
+}
+
+// dumpHex writes values as indented lines of sixteen, each led by the
+// offset of its first value.
+func dumpHex(sb *strings.Builder, values []int) {
+	for start := 0; start < len(values); start += 16 {
+		fmt.Fprintf(sb, "  %04d ", start)
+		for _, v := range values[start:min(start+16, len(values))] {
+			fmt.Fprintf(sb, " %02X", v)
+		}
+		sb.WriteByte('\n')
+	}
+}
@@ -0,0 +1,39 @@
+package zxinggo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeErrorDump(t *testing.T) {
+	err := &DecodeError{Format: FormatDataMatrix, Blocks: []CodewordBlock{
+		{
+			Codewords:     []int{0x42, 0x81, 0x00, 0x17, 0xFF, 0x20, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C},
+			DataCodewords: 3,
+			Syndromes:     []int{0, 0x1F, 0},
+		},
+		{
+			Codewords:     []int{0x01, 0x02},
+			DataCodewords: 1,
+			Syndromes:     []int{0},
+			Correctable:   true,
+		},
+	}}
+	if !errors.Is(err, ErrChecksum) {
+		t.Error("DecodeError does not wrap ErrChecksum")
+	}
+	want := `checksum error: DATA_MATRIX symbol with 1 of 2 blocks uncorrectable
+block 1: 3 data + 15 error correction codewords, uncorrectable
+  0000  42 81 00 17 FF 20 01 02 03 04 05 06 07 08 09 0A
+  0016  0B 0C
+  syndromes, 1 of 3 nonzero:
+  0000  00 1F 00
+block 2: 1 data + 1 error correction codewords, correctable
+  0000  01 02
+  syndromes, 0 of 1 nonzero:
+  0000  00
+`
+	if got := err.Dump(); got != want {
+		t.Errorf("Dump() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package zxinggo
+
+import "github.com/ericlevine/zxinggo/bitutil"
+
+// Detection is a symbol located in an image without decoding its contents.
+type Detection struct {
+	// Format is the symbology the symbol appears to be.
+	Format Format
+
+	// Points outlines the symbol as a quadrilateral in image coordinates.
+	// For QR Code and Data Matrix it starts at the top-left corner of the
+	// symbol as printed and runs clockwise.
+	Points []ResultPoint
+
+	// Bits is the symbol's module grid sampled from the image, one bit per
+	// module, ready for a decoder.
+	Bits *bitutil.BitMatrix
+}
+
+// Detector is implemented by readers that can locate symbols without
+// decoding them.
+type Detector interface {
+	// Detect locates symbols in the image.
+	Detect(image *BinaryBitmap, opts *DecodeOptions) ([]Detection, error)
+}
+
+// DetectOnly locates symbols in the image without decoding them, using every
+// registered reader that implements Detector (QR Code, Data Matrix, Aztec and
+// Han Xin Code, plus DotCode when requested), or those among
+// opts.PossibleFormats. A symbol is reported if its finder structures are
+// found and its module grid can be sampled, whether or not its contents
+// would decode. Without that check one symbol may also be reported as
+// another symbology, so restrict opts.PossibleFormats when the symbology is
+// known.
+func DetectOnly(image *BinaryBitmap, opts *DecodeOptions) (_ []Detection, err error) {
+	defer recoverIndexError(&err)
+	var detections []Detection
+	for _, reader := range buildReaders(opts) {
+		detector, ok := reader.(Detector)
+		if !ok {
+			continue
+		}
+		found, err := detector.Detect(image, opts)
+		if err == nil {
+			detections = append(detections, found...)
+		}
+	}
+	if len(detections) == 0 {
+		return nil, ErrNotFound
+	}
+	return detections, nil
+}
@@ -1,18 +1,15 @@
-package internal
+package zxinggo
 
 import "github.com/ericlevine/zxinggo/bitutil"
 
-// DetectorResult encapsulates the result of detecting a barcode in an image.
+// DetectorResult is what a format's detector found in an image: the symbol
+// sampled into a grid of modules, and the points that located it. Detectors
+// that learn more about the symbol embed it in their own result type.
 type DetectorResult struct {
 	Bits   *bitutil.BitMatrix
 	Points []ResultPoint
 }
 
-// ResultPoint represents a point of interest found by a detector.
-type ResultPoint struct {
-	X, Y float64
-}
-
 // NewDetectorResult creates a new DetectorResult.
 func NewDetectorResult(bits *bitutil.BitMatrix, points []ResultPoint) *DetectorResult {
 	return &DetectorResult{Bits: bits, Points: points}
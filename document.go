@@ -0,0 +1,87 @@
+package zxinggo
+
+import "github.com/ericlevine/zxinggo/transform"
+
+// documentMaxSide is the longest side, in pixels, that DecodeDocument
+// halves a page to before its first search. Letter pages scanned at 300dpi
+// are halved once and at 600dpi twice, to about 150dpi, where the modules
+// of the symbols printed on forms still span a few pixels.
+const documentMaxSide = 2000
+
+// DocumentPreset returns options for scanned pages, as DecodeDocument uses
+// when given none: PDF417, QR codes and Data Matrix only, with TryHarder,
+// as symbols on pages fed through a scanner may be turned any way. Each
+// call returns new options, which the caller may adjust.
+func DocumentPreset() *DecodeOptions {
+	return &DecodeOptions{
+		PossibleFormats: []Format{FormatPDF417, FormatQRCode, FormatDataMatrix},
+		TryHarder:       true,
+	}
+}
+
+// DecodeDocument returns every symbol on a scanned page, binarized with a
+// binarizer from factory, such as binarizer.NewHybrid(nil), whose local
+// thresholds cope with the uneven background of scans. opts may be nil for
+// DocumentPreset.
+//
+// Pages scanned at 300 to 600dpi are large for the detectors and their
+// symbols' modules many pixels wide, so the page is halved until its
+// longest side is at most 2000 pixels and searched as Results does, then
+// searched again at each larger size up to the page itself for symbols too
+// small to read when shrunk. Each symbol is returned once, with its points
+// in the coordinates of page, or of its original image if page is a
+// CoordinateMapper, in the order found. The search stops early as
+// opts.MaxResults and opts.StopOnFormats ask. It returns ErrNotFound if
+// there are none.
+func DecodeDocument(page LuminanceSource, factory BinarizerFactory, opts *DecodeOptions) ([]*Result, error) {
+	if opts == nil {
+		opts = DocumentPreset()
+	}
+	pyramid := []LuminanceSource{page}
+	for level := page; max(level.Width(), level.Height()) > documentMaxSide; {
+		level = halveLuminance(level)
+		pyramid = append(pyramid, level)
+	}
+
+	var results []*Result
+	seen := map[string]bool{}
+	for level := len(pyramid) - 1; level >= 0; level-- {
+		image := NewBinaryBitmap(factory.CreateBinarizer(pyramid[level]))
+		for result := range Results(image, opts) {
+			key := result.Format.String() + ":" + result.Text
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			results = append(results, result)
+			if SearchDone(opts, len(results), result) {
+				return results, nil
+			}
+		}
+	}
+	if len(results) == 0 {
+		return nil, ErrNotFound
+	}
+	return results, nil
+}
+
+// halveLuminance returns source at half its width and height, each pixel
+// the mean of the 2x2 block it covers. An odd last row or column is
+// dropped. Its points map back to source's original image, so that Results
+// reports them there.
+func halveLuminance(source LuminanceSource) *ImageLuminanceSource {
+	width, height := source.Width()/2, source.Height()/2
+	halved := make([]byte, width*height)
+	var upper, lower []byte
+	for y := 0; y < height; y++ {
+		upper = source.Row(2*y, upper)
+		lower = source.Row(2*y+1, lower)
+		for x := 0; x < width; x++ {
+			sum := int(upper[2*x]) + int(upper[2*x+1]) + int(lower[2*x]) + int(lower[2*x+1])
+			halved[y*width+x] = byte((sum + 2) / 4)
+		}
+	}
+	halvedSource := NewLuminanceSourceFromBytes(halved, width, height, width)
+	halvedSource.toOriginal = deriveToOriginal(source, transform.Scaling(2, 2))
+	return halvedSource
+}
@@ -0,0 +1,60 @@
+package zxinggo_test
+
+import (
+	"fmt"
+	"image"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+	"github.com/ericlevine/zxinggo/sheet"
+)
+
+func TestDecodeDocument(t *testing.T) {
+	// A page of large symbols, as scanned at 600dpi, which is halved twice
+	// before it is first searched.
+	var items []sheet.Item
+	for i, format := range []zxinggo.Format{
+		zxinggo.FormatQRCode, zxinggo.FormatDataMatrix, zxinggo.FormatPDF417,
+		zxinggo.FormatDataMatrix, zxinggo.FormatPDF417, zxinggo.FormatQRCode,
+	} {
+		items = append(items, sheet.Item{Contents: fmt.Sprintf("FORM %d", i), Format: format})
+	}
+	s, err := sheet.New(items, sheet.Layout{Columns: 2, CellWidth: 1400, CellHeight: 1300, Margin: 150, Gutter: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if max(s.Width, s.Height) <= 2*2000 {
+		t.Fatalf("page is %dx%d, too small to halve twice", s.Width, s.Height)
+	}
+	source := zxinggo.NewImageLuminanceSource(s.Image())
+	results, err := zxinggo.DecodeDocument(source, binarizer.NewHybrid(nil), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := map[string]*zxinggo.Result{}
+	for _, r := range results {
+		found[r.Text] = r
+	}
+	for i, item := range items {
+		r := found[item.Contents]
+		if r == nil {
+			t.Errorf("%s %q not found", item.Format, item.Contents)
+			continue
+		}
+		if r.Format != item.Format {
+			t.Errorf("%q read as %s, want %s", item.Contents, r.Format, item.Format)
+		}
+		// Points are in page coordinates.
+		bounds := s.Bounds(i).Inset(-10)
+		for _, p := range r.Points {
+			if !image.Pt(int(p.X), int(p.Y)).In(bounds) {
+				t.Errorf("%q: point (%.0f, %.0f) outside %v", item.Contents, p.X, p.Y, s.Bounds(i))
+			}
+		}
+	}
+	if len(results) != len(items) {
+		t.Errorf("%d results, want %d", len(results), len(items))
+	}
+}
@@ -0,0 +1,157 @@
+package decoder
+
+import (
+	"fmt"
+	"strings"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/charset"
+	"github.com/ericlevine/zxinggo/internal"
+)
+
+// Code sets. Decoding starts in Code Set C.
+const (
+	codeSetA = iota
+	codeSetB
+	codeSetC
+)
+
+// Control codewords, with the same meaning in every code set. Binary mode
+// runs until a latch to a code set or the end of the data.
+const (
+	shiftA       = 100
+	shiftB       = 101
+	shiftC       = 102
+	latchA       = 103
+	latchB       = 104
+	latchC       = 105
+	latchBinary  = 106
+	fnc1         = 107
+	lastCodeword = fnc1
+)
+
+// binaryBase is the radix of binary mode codewords. Each group of six holds
+// five bytes; a shorter final group of n+1 codewords holds n bytes.
+const binaryBase = 103
+
+// specials are the multi-character values 96 to 99 of Code Sets A and B.
+var specials = [4]string{
+	"\r\n",
+	"[)>\x1e05\x1d",
+	"[)>\x1e06\x1d",
+	"\x1e\x04",
+}
+
+// DecodeBitStream decodes the unmasked data codewords of a symbol. FNC1 is
+// returned as GS (0x1D) except in the first position, where it marks GS1
+// data and is dropped.
+func DecodeBitStream(codewords []int, characterSet string) (*internal.DecodedPayload, error) {
+	var result strings.Builder
+	var byteSegments [][]byte
+	codeSet := codeSetC
+
+	for i := 0; i < len(codewords); i++ {
+		value := codewords[i]
+		switch {
+		case value > lastCodeword:
+			return nil, fmt.Errorf("%w: invalid DotCode codeword %d", zxinggo.ErrFormat, value)
+		case value >= shiftA && value <= shiftC:
+			i++
+			if i == len(codewords) {
+				return nil, fmt.Errorf("%w: DotCode data ends after shift", zxinggo.ErrFormat)
+			}
+			if err := decodeCharacter(&result, codeSetA+value-shiftA, codewords[i]); err != nil {
+				return nil, err
+			}
+		case value >= latchA && value <= latchC:
+			codeSet = codeSetA + value - latchA
+		case value == latchBinary:
+			end := i + 1
+			for end < len(codewords) && codewords[end] < binaryBase {
+				end++
+			}
+			if end < len(codewords) && codewords[end] > latchC {
+				return nil, fmt.Errorf("%w: invalid DotCode binary codeword %d", zxinggo.ErrFormat, codewords[end])
+			}
+			seg, err := decodeBinary(codewords[i+1 : end])
+			if err != nil {
+				return nil, err
+			}
+			byteSegments = append(byteSegments, seg)
+			result.WriteString(charset.DecodeBytes(seg, charset.GuessEncoding(seg, characterSet)))
+			i = end - 1
+		case value == fnc1:
+			if i > 0 {
+				result.WriteByte(0x1D)
+			}
+		default:
+			if err := decodeCharacter(&result, codeSet, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	rawBytes := make([]byte, len(codewords))
+	for i, cw := range codewords {
+		rawBytes[i] = byte(cw)
+	}
+	return internal.NewDecodedPayload(rawBytes, result.String(), byteSegments, ""), nil
+}
+
+// decodeCharacter decodes a data value, below shiftA, in a code set.
+func decodeCharacter(result *strings.Builder, codeSet, value int) error {
+	switch {
+	case value >= shiftA:
+		return fmt.Errorf("%w: DotCode codeword %d is not a character", zxinggo.ErrFormat, value)
+	case codeSet == codeSetC:
+		fmt.Fprintf(result, "%02d", value)
+	case value >= 96:
+		result.WriteString(specials[value-96])
+	case codeSet == codeSetB:
+		result.WriteByte(byte(' ' + value))
+	case value < 64:
+		result.WriteByte(byte(' ' + value))
+	default:
+		result.WriteByte(byte(value - 64))
+	}
+	return nil
+}
+
+// decodeBinary decodes binary mode codewords, each group most significant
+// first.
+func decodeBinary(codewords []int) ([]byte, error) {
+	var bytes []byte
+	for start := 0; start < len(codewords); start += 6 {
+		end := start + 6
+		if end > len(codewords) {
+			end = len(codewords)
+		}
+		group, err := decodeBinaryGroup(codewords[start:end])
+		if err != nil {
+			return nil, err
+		}
+		bytes = append(bytes, group...)
+	}
+	return bytes, nil
+}
+
+// decodeBinaryGroup decodes n+1 binary mode codewords into n bytes.
+func decodeBinaryGroup(codewords []int) ([]byte, error) {
+	n := len(codewords) - 1
+	if n == 0 {
+		return nil, fmt.Errorf("%w: invalid DotCode binary group", zxinggo.ErrFormat)
+	}
+	value := 0
+	for _, cw := range codewords {
+		value = value*binaryBase + cw
+	}
+	bytes := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		bytes[i] = byte(value)
+		value >>= 8
+	}
+	if value != 0 {
+		return nil, fmt.Errorf("%w: invalid DotCode binary group", zxinggo.ErrFormat)
+	}
+	return bytes, nil
+}
@@ -0,0 +1,280 @@
+// Package decoder implements DotCode decoding: reading codewords from the dot
+// grid, Reed-Solomon error correction over GF(113), and data decoding.
+package decoder
+
+import (
+	"fmt"
+	"sort"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/internal"
+	pdf417decoder "github.com/ericlevine/zxinggo/pdf417/decoder"
+)
+
+// DotCodeGF is the field DotCode error correction works in: the integers
+// modulo 113, generated by 3.
+var DotCodeGF = pdf417decoder.NewModulusGF(113, 3)
+
+const (
+	// maxBlockCodewords is the longest Reed-Solomon block; longer symbols
+	// interleave several blocks.
+	maxBlockCodewords = 112
+
+	// minECCodewords is the number of EC codewords in a symbol with no data;
+	// each pair of data codewords adds one more.
+	minECCodewords = 3
+)
+
+// maskWeights are the increments of the four data masks. Mask k adds
+// i*maskWeights[k] to the i-th data codeword, modulo 113.
+var maskWeights = [4]int{0, 3, 7, 17}
+
+// dotPatterns maps each codeword value to its 9-dot pattern, first dot in bit
+// 8. The patterns are those with five dots, most transitions between dot and
+// gap first and then in numerical order, so that 0 is 101010101.
+var dotPatterns [113]int
+
+// patternValues is the inverse of dotPatterns, -1 for patterns that are not
+// codewords.
+var patternValues [512]int
+
+func init() {
+	var patterns []int
+	for p := 0; p < 512; p++ {
+		if bitCount(p) == 5 {
+			patterns = append(patterns, p)
+		}
+	}
+	sort.SliceStable(patterns, func(i, j int) bool {
+		return transitions(patterns[i]) > transitions(patterns[j])
+	})
+	for i := range patternValues {
+		patternValues[i] = -1
+	}
+	for value := range dotPatterns {
+		dotPatterns[value] = patterns[value]
+		patternValues[patterns[value]] = value
+	}
+}
+
+func bitCount(p int) int {
+	n := 0
+	for ; p != 0; p &= p - 1 {
+		n++
+	}
+	return n
+}
+
+func transitions(p int) int {
+	return bitCount((p ^ p>>1) & 0xFF)
+}
+
+// DotPattern returns the 9-dot pattern of a codeword value, first dot in bit
+// 8.
+func DotPattern(value int) int {
+	return dotPatterns[value]
+}
+
+// NumDataCodewords returns how many data codewords, not counting the mask
+// codeword, a symbol of the given size holds. It is the most that leave
+// room for their EC codewords; every other codeword is used for error
+// correction.
+func NumDataCodewords(width, height int) int {
+	capacity := NumCodewords(width, height)
+	n := 0
+	for (n+1)+minECCodewords+(n+1)/2 <= capacity {
+		n++
+	}
+	return n
+}
+
+// NumCodewords returns how many 9-dot codewords, data and EC, fit in a
+// symbol of the given size after the two mask dots.
+func NumCodewords(width, height int) int {
+	return (width*height/2 - 2) / 9
+}
+
+// DotPositions returns the positions of a symbol's dots in the order they
+// are read. Dots lie where x+y is even. They are read row by row from the
+// top when the height is odd and column by column from the left when it is
+// even, except for the six dots nearest the corners, which come last:
+// top-left, top-right, bottom-right, then bottom-left.
+func DotPositions(width, height int) [][2]int {
+	corners := cornerPositions(width, height)
+	isCorner := func(x, y int) bool {
+		for _, c := range corners {
+			if c[0] == x && c[1] == y {
+				return true
+			}
+		}
+		return false
+	}
+
+	positions := make([][2]int, 0, width*height/2)
+	add := func(x, y int) {
+		if (x+y)%2 == 0 && !isCorner(x, y) {
+			positions = append(positions, [2]int{x, y})
+		}
+	}
+	if height%2 == 1 {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				add(x, y)
+			}
+		}
+	} else {
+		for x := 0; x < width; x++ {
+			for y := 0; y < height; y++ {
+				add(x, y)
+			}
+		}
+	}
+	return append(positions, corners...)
+}
+
+// cornerPositions returns the dot positions nearest each corner. Since the
+// width plus the height is odd, two corners are dot positions themselves
+// and the other two are flanked by a dot along each edge.
+func cornerPositions(width, height int) [][2]int {
+	var positions [][2]int
+	for _, c := range [4][4]int{
+		{0, 0, 1, 1},
+		{width - 1, 0, -1, 1},
+		{width - 1, height - 1, -1, -1},
+		{0, height - 1, 1, -1},
+	} {
+		x, y, dx, dy := c[0], c[1], c[2], c[3]
+		if (x+y)%2 == 0 {
+			positions = append(positions, [2]int{x, y})
+		} else {
+			positions = append(positions, [2]int{x + dx, y}, [2]int{x, y + dy})
+		}
+	}
+	return positions
+}
+
+// Block describes one of the interleaved Reed-Solomon blocks of a symbol:
+// the indexes of its codewords in the symbol's codeword sequence, of which
+// the first NumData are data.
+type Block struct {
+	Indexes []int
+	NumData int
+}
+
+// Blocks splits a codeword sequence of the given length, whose first
+// numData codewords are data, into error correction blocks. Block i takes
+// every codeword whose index is i modulo the number of blocks.
+func Blocks(numData, numCodewords int) []Block {
+	step := (numCodewords + maxBlockCodewords - 1) / maxBlockCodewords
+	blocks := make([]Block, step)
+	for i := range blocks {
+		for j := i; j < numCodewords; j += step {
+			blocks[i].Indexes = append(blocks[i].Indexes, j)
+			if j < numData {
+				blocks[i].NumData++
+			}
+		}
+	}
+	return blocks
+}
+
+// Mask applies (or, with unmask set, removes) the given mask to the data
+// codewords in place.
+func Mask(data []int, mask int, unmask bool) {
+	weight := maskWeights[mask]
+	for i := range data {
+		offset := i * weight % 113
+		if unmask {
+			offset = 113 - offset
+		}
+		data[i] = (data[i] + offset) % 113
+	}
+}
+
+// Decoder decodes upright DotCode dot grids.
+type Decoder struct {
+	ec *pdf417decoder.ErrorCorrection
+}
+
+// NewDecoder creates a new DotCode decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{ec: pdf417decoder.NewErrorCorrectionForField(DotCodeGF)}
+}
+
+// Decode decodes an upright DotCode grid, one bit per position with dots
+// where x+y is even.
+func (d *Decoder) Decode(bits *bitutil.BitMatrix, characterSet string) (*internal.DecodedPayload, error) {
+	width, height := bits.Width(), bits.Height()
+	if (width+height)%2 == 0 {
+		return nil, fmt.Errorf("%w: DotCode width plus height must be odd", zxinggo.ErrFormat)
+	}
+	numCodewords := NumCodewords(width, height)
+	if numCodewords < minECCodewords {
+		return nil, fmt.Errorf("%w: DotCode symbol too small", zxinggo.ErrFormat)
+	}
+
+	// The mask is the first codeword but only takes two dots; the rest take
+	// nine each. Patterns that are not codewords are erasures.
+	positions := DotPositions(width, height)
+	dot := func(i int) int {
+		if bits.Get(positions[i][0], positions[i][1]) {
+			return 1
+		}
+		return 0
+	}
+	codewords := make([]int, 1+numCodewords)
+	codewords[0] = dot(0)<<1 | dot(1)
+	var erasures []int
+	for i := 1; i < len(codewords); i++ {
+		pattern := 0
+		for j := 0; j < 9; j++ {
+			pattern = pattern<<1 | dot(2+9*(i-1)+j)
+		}
+		if value := patternValues[pattern]; value >= 0 {
+			codewords[i] = value
+		} else {
+			erasures = append(erasures, i)
+		}
+	}
+
+	numData := 1 + NumDataCodewords(width, height)
+	errorsCorrected := 0
+	for _, block := range Blocks(numData, len(codewords)) {
+		received := make([]int, len(block.Indexes))
+		var blockErasures []int
+		for i, index := range block.Indexes {
+			received[i] = codewords[index]
+			for _, e := range erasures {
+				if e == index {
+					blockErasures = append(blockErasures, i)
+				}
+			}
+		}
+		if len(blockErasures) > len(received)-block.NumData {
+			return nil, zxinggo.ErrChecksum
+		}
+		corrected, err := d.ec.Decode(received, len(received)-block.NumData, blockErasures)
+		if err != nil {
+			return nil, err
+		}
+		errorsCorrected += corrected
+		for i, index := range block.Indexes {
+			codewords[index] = received[i]
+		}
+	}
+
+	mask := codewords[0]
+	if mask >= len(maskWeights) {
+		return nil, fmt.Errorf("%w: invalid DotCode mask %d", zxinggo.ErrFormat, mask)
+	}
+	data := codewords[1:numData]
+	Mask(data, mask, true)
+
+	result, err := DecodeBitStream(data, characterSet)
+	if err != nil {
+		return nil, err
+	}
+	result.ErrorsCorrected = errorsCorrected
+	return result, nil
+}
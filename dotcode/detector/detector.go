@@ -0,0 +1,364 @@
+// Package detector locates DotCode symbols. DotCode has no finder pattern;
+// a symbol is a checkerboard of separate dots. The detector finds the dots
+// as connected blobs, works out the grid they lie on from the distances and
+// directions between neighbouring dots, and samples the grid.
+package detector
+
+import (
+	"math"
+	"sort"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+)
+
+const (
+	// minDots is the fewest dots taken for a symbol.
+	minDots = 12
+
+	// maxDimension is the largest number of grid positions along either
+	// side of a symbol.
+	maxDimension = 200
+
+	// maxLinkPitches is how far apart, in grid pitches, two dots may be and
+	// still be taken as part of the same symbol. The quiet zone is at least
+	// three pitches wide.
+	maxLinkPitches = 2.9
+
+	// refineIterations is how many times the grid is fitted to the dots.
+	refineIterations = 3
+)
+
+// dot is a blob of dark pixels.
+type dot struct {
+	x, y float64
+	area int
+}
+
+// Detect locates a DotCode symbol and samples its dot grid, one bit per grid
+// position. The grid's orientation is not known: its top-left position is
+// the one nearest the image's top-left, and the decoder must try each
+// rotation. The result's points are the corners of the grid's outline, from
+// its top-left clockwise.
+func Detect(image *bitutil.BitMatrix) (*zxinggo.DetectorResult, error) {
+	dots := filterDots(findDots(image))
+	if len(dots) < minDots {
+		return nil, zxinggo.ErrNotFound
+	}
+
+	pitch, angle, ok := estimateGrid(dots)
+	if !ok {
+		return nil, zxinggo.ErrNotFound
+	}
+	dots = largestCluster(dots, maxLinkPitches*pitch)
+	if len(dots) < minDots {
+		return nil, zxinggo.ErrNotFound
+	}
+
+	// Start from a rotated square grid through the dots and refine it into
+	// the affine transform that best fits them.
+	cos, sin := math.Cos(angle)*pitch, math.Sin(angle)*pitch
+	g := grid{ax: cos, bx: -sin, ay: sin, by: cos}
+	g.cx, g.cy = gridPhase(dots, g)
+	var cols, rows []int
+	for i := 0; i < refineIterations; i++ {
+		cols, rows = g.assign(dots)
+		if !g.fit(dots, cols, rows) {
+			return nil, zxinggo.ErrNotFound
+		}
+	}
+	cols, rows = g.assign(dots)
+
+	minCol, minRow := math.MaxInt32, math.MaxInt32
+	maxCol, maxRow := math.MinInt32, math.MinInt32
+	for i := range dots {
+		minCol, maxCol = min(minCol, cols[i]), max(maxCol, cols[i])
+		minRow, maxRow = min(minRow, rows[i]), max(maxRow, rows[i])
+	}
+	width, height := maxCol-minCol+1, maxRow-minRow+1
+	if width > maxDimension || height > maxDimension {
+		return nil, zxinggo.ErrNotFound
+	}
+	bits := bitutil.NewBitMatrixWithSize(width, height)
+	for i := range dots {
+		bits.Set(cols[i]-minCol, rows[i]-minRow)
+	}
+
+	left, top := float64(minCol)-0.5, float64(minRow)-0.5
+	right, bottom := float64(maxCol)+0.5, float64(maxRow)+0.5
+	points := make([]zxinggo.ResultPoint, 4)
+	for i, c := range [4][2]float64{{left, top}, {right, top}, {right, bottom}, {left, bottom}} {
+		x, y := g.toImage(c[0], c[1])
+		points[i] = zxinggo.ResultPoint{X: x, Y: y}
+	}
+	return zxinggo.NewDetectorResult(bits, points), nil
+}
+
+// findDots labels the 4-connected blobs of dark pixels. Diagonally adjacent
+// dots printed large enough to touch at their corners stay separate.
+func findDots(image *bitutil.BitMatrix) []dot {
+	width, height := image.Width(), image.Height()
+	visited := bitutil.NewBitMatrixWithSize(width, height)
+	var dots []dot
+	var stack [][2]int
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if !image.Get(x, y) || visited.Get(x, y) {
+				continue
+			}
+			var sumX, sumY, area int
+			minX, maxX, minY, maxY := x, x, y, y
+			visited.Set(x, y)
+			stack = append(stack[:0], [2]int{x, y})
+			for len(stack) > 0 {
+				p := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				sumX += p[0]
+				sumY += p[1]
+				area++
+				minX, maxX = min(minX, p[0]), max(maxX, p[0])
+				minY, maxY = min(minY, p[1]), max(maxY, p[1])
+				for _, d := range [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+					nx, ny := p[0]+d[0], p[1]+d[1]
+					if nx >= 0 && ny >= 0 && nx < width && ny < height &&
+						image.Get(nx, ny) && !visited.Get(nx, ny) {
+						visited.Set(nx, ny)
+						stack = append(stack, [2]int{nx, ny})
+					}
+				}
+			}
+			// Keep roughly round blobs that fill much of their bounding box.
+			w, h := maxX-minX+1, maxY-minY+1
+			if w > 2*h || h > 2*w || 2*area < w*h {
+				continue
+			}
+			dots = append(dots, dot{
+				x:    float64(sumX)/float64(area) + 0.5,
+				y:    float64(sumY)/float64(area) + 0.5,
+				area: area,
+			})
+		}
+	}
+	return dots
+}
+
+// filterDots keeps the blobs whose size is close to the median, which is
+// taken to be the size of the symbol's dots.
+func filterDots(dots []dot) []dot {
+	if len(dots) == 0 {
+		return nil
+	}
+	areas := make([]int, len(dots))
+	for i, d := range dots {
+		areas[i] = d.area
+	}
+	sort.Ints(areas)
+	median := areas[len(areas)/2]
+	var kept []dot
+	for _, d := range dots {
+		if 4*d.area >= median && d.area <= 4*median {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// neighbours calls f for each pair of dots at most maxDistance apart.
+func neighbours(dots []dot, maxDistance float64, f func(i, j int, dx, dy, distance float64)) {
+	order := make([]int, len(dots))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return dots[order[a]].x < dots[order[b]].x })
+	for a, i := range order {
+		for _, j := range order[a+1:] {
+			dx := dots[j].x - dots[i].x
+			if dx > maxDistance {
+				break
+			}
+			dy := dots[j].y - dots[i].y
+			if distance := math.Hypot(dx, dy); distance <= maxDistance {
+				f(i, j, dx, dy, distance)
+			}
+		}
+	}
+}
+
+// estimateGrid works out the grid pitch and the angle of its rows from the
+// nearest neighbours of the dots, which mostly lie diagonally adjacent on
+// the checkerboard.
+func estimateGrid(dots []dot) (pitch, angle float64, ok bool) {
+	// Look for neighbours no further apart than several dot diameters.
+	searchRadius := 0.0
+	for _, d := range dots {
+		searchRadius += 6 * math.Sqrt(float64(d.area)) / float64(len(dots))
+	}
+	nearest := make([]float64, len(dots))
+	for i := range nearest {
+		nearest[i] = math.Inf(1)
+	}
+	neighbours(dots, searchRadius, func(i, j int, _, _, distance float64) {
+		nearest[i] = math.Min(nearest[i], distance)
+		nearest[j] = math.Min(nearest[j], distance)
+	})
+	sort.Float64s(nearest)
+	diagonal := nearest[len(nearest)/2]
+	if math.IsInf(diagonal, 1) {
+		return 0, 0, false
+	}
+
+	// Diagonal neighbours are 45 degrees off the rows, so four times their
+	// angle is the same for all of them, up to noise.
+	var sumCos, sumSin, sumDistance float64
+	count := 0
+	neighbours(dots, 1.25*diagonal, func(_, _ int, dx, dy, distance float64) {
+		if distance < 0.75*diagonal {
+			return
+		}
+		theta := 4 * math.Atan2(dy, dx)
+		sumCos += math.Cos(theta)
+		sumSin += math.Sin(theta)
+		sumDistance += distance
+		count++
+	})
+	if count == 0 {
+		return 0, 0, false
+	}
+	angle = (math.Atan2(sumSin, sumCos) - math.Pi) / 4
+	if angle <= -math.Pi/4 {
+		angle += math.Pi / 2
+	}
+	pitch = sumDistance / float64(count) / math.Sqrt2
+	return pitch, angle, true
+}
+
+// largestCluster returns the largest group of dots linked by steps of at
+// most maxDistance.
+func largestCluster(dots []dot, maxDistance float64) []dot {
+	parent := make([]int, len(dots))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	neighbours(dots, maxDistance, func(i, j int, _, _, _ float64) {
+		parent[find(i)] = find(j)
+	})
+
+	sizes := make(map[int]int)
+	best := 0
+	for i := range dots {
+		root := find(i)
+		sizes[root]++
+		if sizes[root] > sizes[best] || (sizes[root] == sizes[best] && root < best) {
+			best = root
+		}
+	}
+	var cluster []dot
+	for i, d := range dots {
+		if find(i) == best {
+			cluster = append(cluster, d)
+		}
+	}
+	return cluster
+}
+
+// grid maps grid positions (col, row) to image coordinates
+// (cx + ax*col + bx*row, cy + ay*col + by*row).
+type grid struct {
+	ax, bx, cx float64
+	ay, by, cy float64
+}
+
+func (g grid) toImage(col, row float64) (float64, float64) {
+	return g.cx + g.ax*col + g.bx*row, g.cy + g.ay*col + g.by*row
+}
+
+func (g grid) toGrid(x, y float64) (float64, float64) {
+	x, y = x-g.cx, y-g.cy
+	det := g.ax*g.by - g.bx*g.ay
+	return (g.by*x - g.bx*y) / det, (g.ax*y - g.ay*x) / det
+}
+
+// assign returns the grid position nearest each dot.
+func (g grid) assign(dots []dot) (cols, rows []int) {
+	cols = make([]int, len(dots))
+	rows = make([]int, len(dots))
+	for i, d := range dots {
+		col, row := g.toGrid(d.x, d.y)
+		cols[i], rows[i] = int(math.Round(col)), int(math.Round(row))
+	}
+	return cols, rows
+}
+
+// fit sets the grid to the least squares fit of the dots to their
+// positions.
+func (g *grid) fit(dots []dot, cols, rows []int) bool {
+	// Solve the normal equations for x and y against (col, row, 1).
+	var m [3][3]float64
+	var vx, vy [3]float64
+	for i, d := range dots {
+		v := [3]float64{float64(cols[i]), float64(rows[i]), 1}
+		for r := 0; r < 3; r++ {
+			for c := 0; c < 3; c++ {
+				m[r][c] += v[r] * v[c]
+			}
+			vx[r] += v[r] * d.x
+			vy[r] += v[r] * d.y
+		}
+	}
+	sx, ok := solve3(m, vx)
+	if !ok {
+		return false
+	}
+	sy, ok := solve3(m, vy)
+	if !ok {
+		return false
+	}
+	g.ax, g.bx, g.cx = sx[0], sx[1], sx[2]
+	g.ay, g.by, g.cy = sy[0], sy[1], sy[2]
+	return g.ax*g.by-g.bx*g.ay != 0
+}
+
+// solve3 solves m*x = v by Cramer's rule.
+func solve3(m [3][3]float64, v [3]float64) ([3]float64, bool) {
+	det := func(a [3][3]float64) float64 {
+		return a[0][0]*(a[1][1]*a[2][2]-a[1][2]*a[2][1]) -
+			a[0][1]*(a[1][0]*a[2][2]-a[1][2]*a[2][0]) +
+			a[0][2]*(a[1][0]*a[2][1]-a[1][1]*a[2][0])
+	}
+	d := det(m)
+	if math.Abs(d) < 1e-9 {
+		return [3]float64{}, false
+	}
+	var x [3]float64
+	for c := 0; c < 3; c++ {
+		a := m
+		for r := 0; r < 3; r++ {
+			a[r][c] = v[r]
+		}
+		x[c] = det(a) / d
+	}
+	return x, true
+}
+
+// gridPhase returns the origin that puts the dots of a grid with no origin
+// nearest to whole grid positions.
+func gridPhase(dots []dot, g grid) (float64, float64) {
+	var colCos, colSin, rowCos, rowSin float64
+	for _, d := range dots {
+		col, row := g.toGrid(d.x, d.y)
+		colCos += math.Cos(2 * math.Pi * col)
+		colSin += math.Sin(2 * math.Pi * col)
+		rowCos += math.Cos(2 * math.Pi * row)
+		rowSin += math.Sin(2 * math.Pi * row)
+	}
+	col := math.Atan2(colSin, colCos) / (2 * math.Pi)
+	row := math.Atan2(rowSin, rowCos) / (2 * math.Pi)
+	return g.toImage(col, row)
+}
@@ -0,0 +1,293 @@
+package dotcode
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/dotcode/decoder"
+)
+
+// Codewords used to build test symbols.
+const (
+	shiftC      = 102
+	latchA      = 103
+	latchB      = 104
+	latchC      = 105
+	latchBinary = 106
+	fnc1        = 107
+)
+
+// textB returns the Code Set B codewords for ASCII text.
+func textB(s string) []int {
+	var codewords []int
+	for _, c := range s {
+		codewords = append(codewords, int(c)-' ')
+	}
+	return codewords
+}
+
+// binaryCodewords returns the binary mode codewords for data.
+func binaryCodewords(data []byte) []int {
+	var codewords []int
+	for start := 0; start < len(data); start += 5 {
+		group := data[start:min(start+5, len(data))]
+		value := 0
+		for _, b := range group {
+			value = value<<8 | int(b)
+		}
+		digits := make([]int, len(group)+1)
+		for i := len(digits) - 1; i >= 0; i-- {
+			digits[i] = value % 103
+			value /= 103
+		}
+		codewords = append(codewords, digits...)
+	}
+	return codewords
+}
+
+// ecCodewords computes the error correction codewords for a block, whose
+// generator polynomial has roots 3^1 to 3^numEC modulo 113.
+func ecCodewords(data []int, numEC int) []int {
+	generator := []int{1}
+	root := 1
+	for i := 0; i < numEC; i++ {
+		root = root * 3 % 113
+		next := make([]int, len(generator)+1)
+		for j, c := range generator {
+			next[j] = (next[j] + c) % 113
+			next[j+1] = (next[j+1] + 113 - c*root%113) % 113
+		}
+		generator = next
+	}
+	remainder := make([]int, numEC)
+	for _, d := range data {
+		k := (d + remainder[0]) % 113
+		for j := 0; j < numEC-1; j++ {
+			remainder[j] = (remainder[j+1] + 113 - generator[j+1]*k%113) % 113
+		}
+		remainder[numEC-1] = (113 - generator[numEC]*k%113) % 113
+	}
+	for j := range remainder {
+		remainder[j] = (113 - remainder[j]) % 113
+	}
+	return remainder
+}
+
+// buildSymbol lays out an upright width x height symbol holding data, padded
+// with Latch C. It uses the decoder's own tables for the mask, blocks, dot
+// patterns and placement, so the tests check that reading undoes this
+// layout rather than that either matches an independent encoder.
+func buildSymbol(t *testing.T, width, height, mask int, data []int) *bitutil.BitMatrix {
+	t.Helper()
+	numData := decoder.NumDataCodewords(width, height)
+	if len(data) > numData {
+		t.Fatalf("%d codewords do not fit in %dx%d", len(data), width, height)
+	}
+	masked := append([]int(nil), data...)
+	for len(masked) < numData {
+		masked = append(masked, latchC)
+	}
+	decoder.Mask(masked, mask, false)
+
+	codewords := make([]int, 1+decoder.NumCodewords(width, height))
+	codewords[0] = mask
+	copy(codewords[1:], masked)
+	for _, block := range decoder.Blocks(1+numData, len(codewords)) {
+		blockData := make([]int, block.NumData)
+		for i := range blockData {
+			blockData[i] = codewords[block.Indexes[i]]
+		}
+		for i, ec := range ecCodewords(blockData, len(block.Indexes)-block.NumData) {
+			codewords[block.Indexes[block.NumData+i]] = ec
+		}
+	}
+
+	dots := []bool{mask&2 != 0, mask&1 != 0}
+	for _, cw := range codewords[1:] {
+		pattern := decoder.DotPattern(cw)
+		for j := 8; j >= 0; j-- {
+			dots = append(dots, pattern>>uint(j)&1 != 0)
+		}
+	}
+	bits := bitutil.NewBitMatrixWithSize(width, height)
+	for i, p := range decoder.DotPositions(width, height) {
+		// Padding dots are all on.
+		if i >= len(dots) || dots[i] {
+			bits.Set(p[0], p[1])
+		}
+	}
+	return bits
+}
+
+// render draws the grid as round dots of the given pitch, turned by angle
+// degrees about the image centre, with a quiet zone of four pitches.
+func render(bits *bitutil.BitMatrix, pitch, angle float64) *zxinggo.BinaryBitmap {
+	w, h := float64(bits.Width()), float64(bits.Height())
+	size := int(math.Hypot(w+8, h+8) * pitch)
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	cos, sin := math.Cos(angle*math.Pi/180), math.Sin(angle*math.Pi/180)
+	centre := float64(size) / 2
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := float64(x)+0.5-centre, float64(y)+0.5-centre
+			u := (cos*dx+sin*dy)/pitch + w/2
+			v := (-sin*dx+cos*dy)/pitch + h/2
+			col, row := int(math.Floor(u)), int(math.Floor(v))
+			c := uint8(255)
+			if col >= 0 && row >= 0 && col < bits.Width() && row < bits.Height() && bits.Get(col, row) &&
+				math.Hypot(u-float64(col)-0.5, v-float64(row)-0.5) < 0.4 {
+				c = 0
+			}
+			img.SetGray(x, y, color.Gray{Y: c})
+		}
+	}
+	return zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(zxinggo.NewGrayImageLuminanceSource(img)))
+}
+
+func TestDecodeMatrixCodeSets(t *testing.T) {
+	var data []int
+	data = append(data, 20, 24, 10, 7) // Code Set C: 20241007
+	data = append(data, latchB)
+	data = append(data, textB("DotCode ")...)
+	data = append(data, shiftC, 42)
+	data = append(data, latchA, 33, 64+9, 96) // A, TAB, CR LF
+	data = append(data, fnc1, latchC, 99)
+	want := "20241007DotCode 42A\t\r\n\x1d99"
+
+	for mask := 0; mask < 4; mask++ {
+		bits := buildSymbol(t, 27, 26, mask, data)
+		result, err := DecodeMatrix(bits, nil)
+		if err != nil {
+			t.Fatalf("mask %d: decode error: %v", mask, err)
+		}
+		if result.Text != want {
+			t.Errorf("mask %d: text = %q, want %q", mask, result.Text, want)
+		}
+		if result.Format != zxinggo.FormatDotCode {
+			t.Errorf("mask %d: format = %v", mask, result.Format)
+		}
+	}
+}
+
+func TestDecodeMatrixBinary(t *testing.T) {
+	payload := []byte{0x00, 0xFF, 0x10, 0x80, 0x7F, 0x01, 0xFE}
+	data := append([]int{latchBinary}, binaryCodewords(payload)...)
+	data = append(data, latchB)
+	data = append(data, textB("ok")...)
+
+	result, err := DecodeMatrix(buildSymbol(t, 22, 21, 1, data), nil)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	segments, _ := result.Metadata[zxinggo.MetadataByteSegments].([][]byte)
+	if len(segments) != 1 || string(segments[0]) != string(payload) {
+		t.Errorf("byte segments = %x, want %x", segments, payload)
+	}
+	if got := result.Text[len(result.Text)-2:]; got != "ok" {
+		t.Errorf("text ends %q, want \"ok\"", got)
+	}
+}
+
+func TestDecodeMatrixRotatedWithErrors(t *testing.T) {
+	data := append([]int{latchB}, textB("Rotated")...)
+	bits := buildSymbol(t, 18, 17, 2, data)
+	// Turned, the grid is 17x18 with its dots where x+y is odd.
+	bits.Rotate90()
+	bits.Flip(2, 5)
+	bits.Flip(7, 8)
+	bits.Flip(10, 7)
+
+	result, err := DecodeMatrix(bits, nil)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if result.Text != "Rotated" {
+		t.Errorf("text = %q, want \"Rotated\"", result.Text)
+	}
+	if n, _ := result.Metadata[zxinggo.MetadataErrorsCorrected].(int); n == 0 {
+		t.Error("no errors corrected")
+	}
+}
+
+func TestDecodeMatrixInterleaved(t *testing.T) {
+	// More than 112 codewords are split into interleaved blocks.
+	if n := decoder.NumCodewords(46, 45); n < 113 {
+		t.Fatalf("only %d codewords", n)
+	}
+	var data []int
+	var want []byte
+	for i := 0; i < 60; i++ {
+		data = append(data, i)
+		want = append(want, byte('0'+i/10), byte('0'+i%10))
+	}
+	bits := buildSymbol(t, 46, 45, 3, data)
+	bits.Flip(0, 0)
+	bits.Flip(45, 1)
+
+	result, err := DecodeMatrix(bits, nil)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if result.Text != string(want) {
+		t.Errorf("text = %q, want %q", result.Text, want)
+	}
+}
+
+func TestDecodeImage(t *testing.T) {
+	data := append([]int{latchB}, textB("ABC-123")...)
+	bits := buildSymbol(t, 18, 17, 0, data)
+	opts := &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{zxinggo.FormatDotCode}}
+	for _, angle := range []float64{0, 7, 30, 45, 90, 160, 250} {
+		result, err := zxinggo.Decode(render(bits, 6, angle), opts)
+		if err != nil {
+			t.Errorf("angle %v: decode error: %v", angle, err)
+			continue
+		}
+		if result.Text != "ABC-123" {
+			t.Errorf("angle %v: text = %q", angle, result.Text)
+		}
+	}
+}
+
+func TestNotRequested(t *testing.T) {
+	data := append([]int{latchB}, textB("ABC-123")...)
+	image := render(buildSymbol(t, 18, 17, 0, data), 6, 0)
+	if _, err := zxinggo.Decode(image, nil); !errors.Is(err, zxinggo.ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+	if _, err := NewReader().Decode(image, nil); err != nil {
+		t.Errorf("direct decode error: %v", err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	data := append([]int{latchB}, textB("outline")...)
+	bits := buildSymbol(t, 18, 17, 0, data)
+	detections, err := NewReader().Detect(render(bits, 5, 0), nil)
+	if err != nil {
+		t.Fatalf("detect error: %v", err)
+	}
+	// The 18x17 grid of pitch 5 is centred in the image.
+	c := float64(int(math.Hypot(26, 25)*5)) / 2
+	want := [4][2]float64{{c - 45, c - 42.5}, {c + 45, c - 42.5}, {c + 45, c + 42.5}, {c - 45, c + 42.5}}
+	for i, p := range detections[0].Points {
+		if math.Abs(p.X-want[i][0]) > 1.5 || math.Abs(p.Y-want[i][1]) > 1.5 {
+			t.Errorf("point %d = (%.1f, %.1f), want %v", i, p.X, p.Y, want[i])
+		}
+	}
+	if w, h := detections[0].Bits.Width(), detections[0].Bits.Height(); w != 18 || h != 17 {
+		t.Errorf("grid = %dx%d, want 18x17", w, h)
+	}
+}
+
+func TestWrongShape(t *testing.T) {
+	if _, err := DecodeMatrix(bitutil.NewBitMatrixWithSize(16, 16), nil); !errors.Is(err, zxinggo.ErrFormat) {
+		t.Errorf("err = %v, want ErrFormat", err)
+	}
+}
@@ -0,0 +1,167 @@
+// Package dotcode provides DotCode (AIM ISS DotCode) reading.
+//
+// Code Sets A, B and C, binary mode and FNC1 are decoded. The detector
+// expects the dots to be printed separately and the symbol to be roughly
+// flat: it fits an affine grid, not a perspective one. The registered
+// reader only runs when zxinggo.FormatDotCode is among the requested
+// formats.
+package dotcode
+
+import (
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/dotcode/decoder"
+	"github.com/ericlevine/zxinggo/dotcode/detector"
+)
+
+// Reader decodes DotCode symbols from binary images.
+type Reader struct {
+	dec *decoder.Decoder
+
+	// opts are used by Decode and Detect when they are passed nil.
+	opts *zxinggo.DecodeOptions
+
+	// disabled makes the registered reader find nothing unless DotCode was
+	// requested.
+	disabled bool
+}
+
+// NewReader creates a new DotCode Reader.
+func NewReader() *Reader {
+	return &Reader{dec: decoder.NewDecoder()}
+}
+
+// NewReaderWithOptions creates a DotCode reader that uses opts whenever it is
+// given nil options, so it can be configured once and used on its own
+// rather than through zxinggo.Decode.
+func NewReaderWithOptions(opts *zxinggo.DecodeOptions) *Reader {
+	return &Reader{dec: decoder.NewDecoder(), opts: opts}
+}
+
+// Decode locates and decodes a DotCode symbol in the given image.
+func (r *Reader) Decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	if opts == nil {
+		opts = r.opts
+	}
+	if r.disabled {
+		return nil, zxinggo.ErrNotFound
+	}
+	characterSet := ""
+	if opts != nil {
+		characterSet = opts.CharacterSet
+	}
+	matrix, err := image.BlackMatrix()
+	if err != nil {
+		return nil, err
+	}
+	detectorResult, err := detector.Detect(matrix)
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.decodeBits(detectorResult.Bits, characterSet, detectorResult.Points)
+	if err != nil {
+		return nil, err
+	}
+	if err := zxinggo.CheckErrorBudget(result, opts); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DecodeMatrix decodes a DotCode symbol from its dot grid, one bit per grid
+// position with no quiet zone, as sampled by an external detector. The grid
+// may be in any of the four rotations. opts may be nil; only CharacterSet and
+// MaxErrorsCorrected are used. The result has no points.
+func DecodeMatrix(bits *bitutil.BitMatrix, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	characterSet := ""
+	if opts != nil {
+		characterSet = opts.CharacterSet
+	}
+	result, err := NewReader().decodeBits(bits, characterSet, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := zxinggo.CheckErrorBudget(result, opts); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// decodeBits tries each rotation of the grid that puts its dots where x+y is
+// even.
+func (r *Reader) decodeBits(bits *bitutil.BitMatrix, characterSet string, points []zxinggo.ResultPoint) (*zxinggo.Result, error) {
+	if (bits.Width()+bits.Height())%2 == 0 {
+		return nil, zxinggo.ErrFormat
+	}
+	bits = bits.Clone()
+	var lastErr error = zxinggo.ErrFormat
+	for rotation := 0; rotation < 4; rotation++ {
+		if rotation > 0 {
+			bits.Rotate90()
+		}
+		if !onEvenPositions(bits) {
+			continue
+		}
+		dr, err := r.dec.Decode(bits, characterSet)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		result := dr.Result(points, zxinggo.FormatDotCode)
+		result.PutMetadata(zxinggo.MetadataSymbolDimension, [2]int{bits.Width(), bits.Height()})
+		return result, nil
+	}
+	return nil, lastErr
+}
+
+// onEvenPositions reports whether most of the grid's dots lie where x+y is
+// even.
+func onEvenPositions(bits *bitutil.BitMatrix) bool {
+	balance := 0
+	for y := 0; y < bits.Height(); y++ {
+		for x := 0; x < bits.Width(); x++ {
+			if bits.Get(x, y) {
+				if (x+y)%2 == 0 {
+					balance++
+				} else {
+					balance--
+				}
+			}
+		}
+	}
+	return balance > 0
+}
+
+// Detect locates a DotCode symbol in the given image without decoding it.
+// The symbol's orientation is not known until it is decoded, so the outline
+// starts at the corner of the dot grid nearest the image's top-left.
+func (r *Reader) Detect(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) ([]zxinggo.Detection, error) {
+	if opts == nil {
+		opts = r.opts
+	}
+	if r.disabled {
+		return nil, zxinggo.ErrNotFound
+	}
+	matrix, err := image.BlackMatrix()
+	if err != nil {
+		return nil, err
+	}
+	detectorResult, err := detector.Detect(matrix)
+	if err != nil {
+		return nil, err
+	}
+	return []zxinggo.Detection{{
+		Format: zxinggo.FormatDotCode,
+		Points: detectorResult.Points,
+		Bits:   detectorResult.Bits,
+	}}, nil
+}
+
+// Reset resets internal state.
+func (r *Reader) Reset() {}
+
+// Compile-time check.
+var (
+	_ zxinggo.Reader   = (*Reader)(nil)
+	_ zxinggo.Detector = (*Reader)(nil)
+)
@@ -0,0 +1,20 @@
+package dotcode
+
+import zxinggo "github.com/ericlevine/zxinggo"
+
+func init() {
+	zxinggo.RegisterReader(zxinggo.FormatDotCode, func(opts *zxinggo.DecodeOptions) zxinggo.Reader {
+		// Dot textures are easily taken for DotCode, so it is only read when
+		// asked for.
+		reader := NewReader()
+		reader.disabled = true
+		if opts != nil {
+			for _, f := range zxinggo.ExpandFormats(opts.PossibleFormats) {
+				if f == zxinggo.FormatDotCode {
+					reader.disabled = false
+				}
+			}
+		}
+		return reader
+	})
+}
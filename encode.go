@@ -7,10 +7,16 @@ type EncodeOptions struct {
 	// ErrorCorrection specifies the error correction level.
 	ErrorCorrection string
 
-	// CharacterSet specifies the character set to use when encoding.
+	// CharacterSet specifies the character set to use when encoding, by
+	// any of its names, such as "windows-1251" or "ISO-8859-6", in any
+	// case. The QR Code, Aztec and PDF417 writers convert contents to it
+	// and mark the symbol with an ECI for it; EncodeBytes takes data
+	// already in it.
 	CharacterSet string
 
-	// Margin specifies the margin (quiet zone) in modules around the barcode.
+	// Margin specifies the margin (quiet zone) in modules around the
+	// barcode, or only left and right of a 1D barcode. If nil, each
+	// format's DefaultQuietZone is used.
 	Margin *int
 
 	// QRVersion forces a specific QR version (1-40).
@@ -19,6 +25,11 @@ type EncodeOptions struct {
 	// QRMaskPattern forces a specific QR mask pattern (0-7).
 	QRMaskPattern int
 
+	// BoostECLevel raises the QR error correction level above
+	// ErrorCorrection to the highest that fits in the same version, using
+	// capacity that would otherwise be padding.
+	BoostECLevel bool
+
 	// QRCompact enables compact QR mode.
 	QRCompact bool
 
@@ -34,9 +45,56 @@ type EncodeOptions struct {
 	// PDF417AutoECI enables automatic ECI selection in PDF417.
 	PDF417AutoECI bool
 
-	// GS1Format encodes in GS1 format.
+	// PDF417ReaderInitialisation starts a PDF417 symbol with the Reader
+	// Initialisation codeword, for symbols that program the reader.
+	PDF417ReaderInitialisation bool
+
+	// PDF417ECIs lists general purpose (900–810899) and user defined
+	// (810900–811799) ECI designators to place at the start of a PDF417
+	// symbol. Readers report them in PDF417ResultMetadata.ECIs.
+	PDF417ECIs []int
+
+	// DataMatrixShape restricts the Data Matrix writer to square or
+	// rectangular symbols.
+	DataMatrixShape DataMatrixShape
+
+	// DataMatrixDMRE lets the Data Matrix writer choose the rectangular
+	// extension sizes of ISO/IEC 21471 (DMRE), such as 8x48 or 24x64,
+	// picking the symbol with the fewest modules. Together with
+	// DataMatrixShapeRectangle it yields the long, low symbols used in
+	// direct part marking. Not every reader supports them.
+	DataMatrixDMRE bool
+
+	// MaxiCodeMode selects the MaxiCode mode, 2 to 6. Modes 2 and 3 carry
+	// the postcode, country code and service class of a structured carrier
+	// message, mode 2 switching to 3 for a postcode that is not numeric.
+	// Mode 5 trades capacity for enhanced error correction, and mode 6
+	// programs readers. Zero means mode 4, standard error correction.
+	MaxiCodeMode int
+
+	// GS1Format encodes in GS1 format. Code 128, QR Code and Aztec take a
+	// GS1 element string, bracketed or raw, validate it and encode it with
+	// FNC1 first and after each variable-length field but the last, Code
+	// 128 as GS1-128.
 	GS1Format bool
 
+	// ApplicationIndicator, if set, marks QR Code content as data of the
+	// AIM application it names, a letter or two digits, with FNC1 in second
+	// position.
+	ApplicationIndicator string
+
+	// StructuredAppend, if set, marks the symbol as one of a message split
+	// across several. Only Aztec supports it.
+	StructuredAppend *StructuredAppend
+
+	// ITFBearerBars frames an ITF symbol with bearer bars, as ITF-14 printed
+	// on corrugated board needs.
+	ITFBearerBars BearerBars
+
+	// ITFWideToNarrowRatio sets the width of wide ITF bars and spaces
+	// relative to narrow ones, from 2 to 3. Zero means 3.
+	ITFWideToNarrowRatio float64
+
 	// ForceCodeSet forces a specific code set (e.g., for Code 128).
 	ForceCodeSet string
 
@@ -44,6 +102,32 @@ type EncodeOptions struct {
 	Code128Compact bool
 }
 
+// BearerBars selects the bearer bars framing a symbol. Bearer bars even out
+// printing plate pressure across the bars and stop a scan line that runs
+// off the top or bottom of the symbol from reading a partial code.
+type BearerBars int
+
+const (
+	// BearerBarsNone draws no bearer bars.
+	BearerBarsNone BearerBars = iota
+	// BearerBarsTopBottom draws bars along the top and bottom of the symbol.
+	BearerBarsTopBottom
+	// BearerBarsBox draws a frame around the symbol and its quiet zones.
+	BearerBarsBox
+)
+
+// DataMatrixShape selects the shape of Data Matrix symbols.
+type DataMatrixShape int
+
+const (
+	// DataMatrixShapeAny allows square and rectangular symbols.
+	DataMatrixShapeAny DataMatrixShape = iota
+	// DataMatrixShapeSquare allows only square symbols.
+	DataMatrixShapeSquare
+	// DataMatrixShapeRectangle allows only rectangular symbols.
+	DataMatrixShapeRectangle
+)
+
 // PDF417DimensionConfig specifies min/max rows/cols for PDF417.
 type PDF417DimensionConfig struct {
 	MinRows, MaxRows int
@@ -55,3 +139,57 @@ type Writer interface {
 	// Encode encodes the given contents into a barcode.
 	Encode(contents string, format Format, width, height int, opts *EncodeOptions) (*bitutil.BitMatrix, error)
 }
+
+// EncodeResult describes a symbol a writer produced, for callers that log
+// or audit what was encoded. Fields a format has no use for are zero,
+// except MaskPattern, which is then -1.
+type EncodeResult struct {
+	Matrix *bitutil.BitMatrix
+	Format Format
+
+	// Dimension is the size of the symbol in modules, as columns and rows,
+	// without its quiet zone.
+	Dimension [2]int
+
+	// Version is the symbol version chosen, such as 1 to 40 for QR Code.
+	Version int
+
+	// ErrorCorrection is the error correction level encoded, which is
+	// higher than the one asked for if BoostECLevel raised it.
+	ErrorCorrection string
+
+	// MaskPattern is the data mask applied.
+	MaskPattern int
+
+	// Segments lists the modes the message was encoded in, in order.
+	Segments []EncodedSegment
+
+	// Utilization is the fraction, from 0 to 1, of the symbol's data
+	// capacity that the message fills before padding.
+	Utilization float64
+}
+
+// EncodedSegment is a mode a message was encoded in and the number of
+// characters, or bytes in byte mode, encoded in it. Mode names follow the
+// symbology's specification, such as "NUMERIC" or "BYTE" for QR Code.
+// Characters is zero for modes that only mark the data, such as "ECI".
+type EncodedSegment struct {
+	Mode       string
+	Characters int
+}
+
+// ResultWriter is implemented by writers that describe the symbols they
+// produce, as the QR Code writer does.
+type ResultWriter interface {
+	// EncodeWithResult encodes the given contents into a barcode and
+	// describes the symbol produced.
+	EncodeWithResult(contents string, format Format, width, height int, opts *EncodeOptions) (*EncodeResult, error)
+}
+
+// BytesWriter is implemented by writers that can encode arbitrary bytes, as
+// the QR Code writer does, rather than the UTF-8 text of a string.
+type BytesWriter interface {
+	// EncodeBytes encodes data, byte for byte, into a barcode. If
+	// opts.CharacterSet is set, it names the character set data is in.
+	EncodeBytes(data []byte, format Format, width, height int, opts *EncodeOptions) (*bitutil.BitMatrix, error)
+}
@@ -0,0 +1,26 @@
+package zxinggo
+
+import "fmt"
+
+// CheckErrorBudget returns an error wrapping ErrChecksum if error correction
+// repaired more of result's codewords, errors and erasures together, than
+// opts.MaxErrorsCorrected allows for its format. opts may be nil. Readers of
+// formats with error correction call it before returning a result, so a
+// symbol over budget is not read, and the search goes on as if its checksum
+// had failed.
+func CheckErrorBudget(result *Result, opts *DecodeOptions) error {
+	if opts == nil {
+		return nil
+	}
+	budget, ok := opts.MaxErrorsCorrected[result.Format]
+	if !ok {
+		return nil
+	}
+	errors, _ := result.Metadata[MetadataErrorsCorrected].(int)
+	erasures, _ := result.Metadata[MetadataErasuresCorrected].(int)
+	if corrected := errors + erasures; corrected > budget {
+		return fmt.Errorf("%w: %s symbol had %d codewords corrected, more than the %d allowed",
+			ErrChecksum, result.Format, corrected, budget)
+	}
+	return nil
+}
@@ -14,4 +14,8 @@ var (
 
 	// ErrWriter is returned when a barcode cannot be encoded.
 	ErrWriter = errors.New("writer error")
+
+	// ErrDeadlineExceeded is returned when decoding gives up, nothing read,
+	// because DecodeOptions.Deadline has passed.
+	ErrDeadlineExceeded = errors.New("decode deadline exceeded")
 )
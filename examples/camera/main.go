@@ -0,0 +1,68 @@
+// Command camera scans barcodes from a live video stream and prints each
+// one as it comes into view and when it leaves. It is a reference for
+// using zxinggo.Scanner in real time.
+//
+// Frames are read as raw 8-bit grayscale from standard input, which keeps
+// the program free of camera bindings: any camera ffmpeg can open can be
+// piped to it. On Linux, with V4L2:
+//
+//	ffmpeg -f v4l2 -video_size 640x480 -i /dev/video0 -f rawvideo -pix_fmt gray - | camera -size 640x480
+//
+// On macOS, with AVFoundation:
+//
+//	ffmpeg -f avfoundation -framerate 30 -video_size 640x480 -i 0 -f rawvideo -pix_fmt gray - | camera -size 640x480
+//
+// On Windows, with DirectShow:
+//
+//	ffmpeg -f dshow -video_size 640x480 -i video="<camera name>" -f rawvideo -pix_fmt gray - | camera -size 640x480
+//
+// To read a camera directly instead, implement zxinggo.FrameSource over its
+// capture API, wrapping each grayscale frame with
+// zxinggo.NewLuminanceSourceFromBytes, and pass it to Scanner.Run.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+
+	// Register the formats to scan for.
+	_ "github.com/ericlevine/zxinggo/aztec"
+	_ "github.com/ericlevine/zxinggo/datamatrix"
+	_ "github.com/ericlevine/zxinggo/oned"
+	_ "github.com/ericlevine/zxinggo/pdf417"
+	_ "github.com/ericlevine/zxinggo/qrcode"
+)
+
+func main() {
+	size := flag.String("size", "640x480", "frame size, as WIDTHxHEIGHT")
+	tryHarder := flag.Bool("try-harder", false, "spend more time on each frame looking for barcodes")
+	flag.Parse()
+
+	var width, height int
+	if _, err := fmt.Sscanf(*size, "%dx%d", &width, &height); err != nil || width <= 0 || height <= 0 {
+		log.Fatalf("invalid -size %q", *size)
+	}
+
+	scanner := zxinggo.NewScanner(binarizer.NewHybrid(nil), &zxinggo.DecodeOptions{TryHarder: *tryHarder})
+	start := time.Now()
+	scanner.OnNewResult = func(r *zxinggo.Result) {
+		fmt.Printf("%8.3fs new  [%s] %s\n", time.Since(start).Seconds(), r.Format, r.Text)
+	}
+	scanner.OnResultLost = func(r *zxinggo.Result) {
+		fmt.Printf("%8.3fs lost [%s] %s\n", time.Since(start).Seconds(), r.Format, r.Text)
+	}
+
+	frames := zxinggo.NewRawFrameSource(bufio.NewReaderSize(os.Stdin, width*height), width, height)
+	err := scanner.Run(frames)
+	scanner.Reset()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
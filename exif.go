@@ -0,0 +1,94 @@
+package zxinggo
+
+import (
+	"encoding/binary"
+	"image"
+
+	"github.com/ericlevine/zxinggo/imaging"
+)
+
+// exifOrientationTag is the TIFF tag giving how an image must be rotated or
+// mirrored to be displayed upright.
+const exifOrientationTag = 0x0112
+
+// jpegOrientation returns the EXIF orientation, 1 to 8, of JPEG data, or 1 if
+// it has none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || length < 2 || i+2+length > len(data) {
+			// Metadata comes before the start of scan.
+			break
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// tiffOrientation returns the orientation in the first IFD of a TIFF header,
+// or 1 if it has none.
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + 12*i
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == exifOrientationTag {
+			if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			break
+		}
+	}
+	return 1
+}
+
+// orient returns the source turned upright according to an EXIF
+// orientation: 2 to 4 mirror or turn it in place, and 5 to 8 transpose it.
+func (s *ImageLuminanceSource) orient(orientation int) *ImageLuminanceSource {
+	var upright image.Image
+	switch orientation {
+	case 2: // mirrored
+		upright = imaging.MirrorHorizontal(s.grayView())
+	case 3: // turned 180 degrees
+		upright = imaging.Rotate180(s.grayView())
+	case 4: // flipped
+		upright = imaging.MirrorVertical(s.grayView())
+	case 5: // transposed
+		upright = imaging.MirrorHorizontal(imaging.Rotate90(s.grayView()))
+	case 6: // needs turning 90 degrees clockwise
+		upright = imaging.Rotate90(s.grayView())
+	case 7: // transposed about the other diagonal
+		upright = imaging.MirrorHorizontal(imaging.Rotate270(s.grayView()))
+	case 8: // needs turning 90 degrees counterclockwise
+		upright = imaging.Rotate270(s.grayView())
+	default:
+		return s
+	}
+	return newGrayView(upright.(*image.Gray))
+}
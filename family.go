@@ -0,0 +1,59 @@
+package zxinggo
+
+import (
+	"maps"
+	"slices"
+)
+
+var upcEANFormats = []Format{FormatEAN13, FormatEAN8, FormatUPCA, FormatUPCE}
+
+// formatFamilies lists the formats each family stands for, in the order
+// they are tried.
+var formatFamilies = map[Format][]Format{
+	FormatAnyOneD: slices.Concat(upcEANFormats, []Format{
+		FormatCode39, FormatCode93, FormatCode128, FormatITF, FormatCodabar,
+		FormatRSS14, FormatRSSExpanded, FormatCode11, FormatTelepen,
+		FormatMatrix2of5, FormatIndustrial2of5, FormatIATA2of5,
+	}),
+	FormatAnyRetail: slices.Concat(upcEANFormats, []Format{FormatRSS14, FormatRSSExpanded}),
+	FormatAnyGS1: slices.Concat(upcEANFormats, []Format{
+		FormatITF, FormatCode128, FormatRSS14, FormatRSSExpanded,
+		FormatDataMatrix, FormatQRCode, FormatDotCode,
+	}),
+	FormatAny2D: {
+		FormatQRCode, FormatDataMatrix, FormatAztec, FormatPDF417, FormatMaxiCode,
+		FormatHanXin, FormatDotCode, FormatCodablockF, FormatCode16K,
+	},
+	FormatAnyPostal: {FormatRM4SCC, FormatKIX, FormatAustraliaPost, FormatIntelligentMail},
+}
+
+// families lists the keys of formatFamilies in increasing order, so that
+// searches of them are deterministic.
+var families = slices.Sorted(maps.Keys(formatFamilies))
+
+// IsFamily reports whether f is a format family, such as FormatAnyOneD,
+// rather than a format.
+func (f Format) IsFamily() bool {
+	_, ok := formatFamilies[f]
+	return ok
+}
+
+// ExpandFormats returns formats with each family replaced by the formats it
+// stands for, keeping the first of any format listed more than once.
+// Formats that are only read when asked for, such as DotCode and the postal
+// formats, count as asked for when a family includes them.
+func ExpandFormats(formats []Format) []Format {
+	expanded := make([]Format, 0, len(formats))
+	for _, f := range formats {
+		members, ok := formatFamilies[f]
+		if !ok {
+			members = []Format{f}
+		}
+		for _, m := range members {
+			if !slices.Contains(expanded, m) {
+				expanded = append(expanded, m)
+			}
+		}
+	}
+	return expanded
+}
@@ -0,0 +1,77 @@
+package zxinggo
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExpandFormats(t *testing.T) {
+	got := ExpandFormats([]Format{FormatQRCode, FormatAnyRetail, FormatEAN8, FormatAnyPostal})
+	want := []Format{
+		FormatQRCode, FormatEAN13, FormatEAN8, FormatUPCA, FormatUPCE, FormatRSS14, FormatRSSExpanded,
+		FormatRM4SCC, FormatKIX, FormatAustraliaPost, FormatIntelligentMail,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ExpandFormats = %v, want %v", got, want)
+	}
+	if got := ExpandFormats(nil); len(got) != 0 {
+		t.Errorf("ExpandFormats(nil) = %v", got)
+	}
+
+	// Every format is in a family, and no family holds another.
+	covered := map[Format]bool{}
+	for family, members := range formatFamilies {
+		for _, f := range members {
+			if f.IsFamily() {
+				t.Errorf("%v holds family %v", family, f)
+			}
+			covered[f] = true
+		}
+	}
+	for f := range formatCount {
+		if !covered[f] {
+			t.Errorf("%v is in no family", f)
+		}
+	}
+}
+
+func TestParseFormatFamily(t *testing.T) {
+	for family := range formatFamilies {
+		got, err := ParseFormat(family.String())
+		if err != nil || got != family {
+			t.Errorf("ParseFormat(%q) = %v, %v", family.String(), got, err)
+		}
+		if text, err := family.MarshalText(); err != nil || string(text) != family.String() {
+			t.Errorf("%v.MarshalText() = %q, %v", family, text, err)
+		}
+	}
+	if FormatQRCode.IsFamily() || !FormatAny2D.IsFamily() {
+		t.Error("IsFamily misclassifies formats")
+	}
+}
+
+func TestGroupFormat(t *testing.T) {
+	savedGroups := readerGroups
+	defer func() { readerGroups = savedGroups }()
+	readerGroups = map[Format]int{
+		FormatQRCode: 0,
+		FormatEAN13:  1, FormatUPCA: 1, FormatRSS14: 1,
+		FormatCode39: 2, FormatAztec: 2,
+	}
+	tests := []struct {
+		group int
+		want  Format
+	}{
+		{0, FormatQRCode},
+		{1, FormatAnyRetail},
+		{2, FormatCode39},
+	}
+	// Repeat, so that an order depending on map iteration shows.
+	for range 20 {
+		for _, tt := range tests {
+			if got := groupFormat(tt.group); got != tt.want {
+				t.Fatalf("groupFormat(%d) = %v, want %v", tt.group, got, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package zxinggo
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"  // register GIF decoding for ReadImageLuminanceSource
+	_ "image/jpeg" // register JPEG decoding for ReadImageLuminanceSource
+	_ "image/png"  // register PNG decoding for ReadImageLuminanceSource
+	"io"
+	"os"
+)
+
+// ReadImageLuminanceSource decodes a PNG, JPEG or GIF image, or one of any
+// other format registered with the image package, and returns its luminance.
+// Importing the imageformat package adds BMP and WebP. JPEG images are
+// turned upright according to their EXIF orientation, as phone cameras store
+// photos sideways and record how they should be displayed.
+func ReadImageLuminanceSource(r io.Reader) (*ImageLuminanceSource, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return NewImageLuminanceSource(img).orient(jpegOrientation(data)), nil
+}
+
+// DecodeFile decodes a barcode from the image file at path, read with
+// ReadImageLuminanceSource and binarized with a binarizer from factory, such
+// as binarizer.NewHybrid(nil). Only the first frame of an animation is
+// read; see DecodeFileFrames.
+func DecodeFile(path string, factory BinarizerFactory, opts *DecodeOptions) (*Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return DecodeReader(f, factory, opts)
+}
+
+// DecodeReader decodes a barcode from the image read from r, as DecodeFile
+// does from a file. Only the first frame of an animation is read; see
+// DecodeFrames.
+func DecodeReader(r io.Reader, factory BinarizerFactory, opts *DecodeOptions) (*Result, error) {
+	source, err := ReadImageLuminanceSource(r)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(NewBinaryBitmap(factory.CreateBinarizer(source)), opts)
+}
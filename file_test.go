@@ -0,0 +1,117 @@
+package zxinggo_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+)
+
+// exifJPEG encodes img as a JPEG whose EXIF data, in the given byte order,
+// records orientation.
+func exifJPEG(t *testing.T, img image.Image, orientation int, order binary.AppendByteOrder) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	tiff := []byte("MM\x00\x2a")
+	if order == binary.LittleEndian {
+		tiff = []byte("II\x2a\x00")
+	}
+	tiff = order.AppendUint32(tiff, 8)
+	tiff = order.AppendUint16(tiff, 1)
+	tiff = order.AppendUint16(tiff, 0x0112)
+	tiff = order.AppendUint16(tiff, 3) // SHORT
+	tiff = order.AppendUint32(tiff, 1)
+	tiff = order.AppendUint16(tiff, uint16(orientation))
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+
+	out := append([]byte{0xFF, 0xD8, 0xFF, 0xE1}, byte((len(segment)+2)>>8), byte(len(segment)+2))
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func TestReadImageLuminanceSourceOrientation(t *testing.T) {
+	// Each orientation's stored image is the upright 64x32 image, with a dark
+	// square in its top left corner, turned or mirrored so that the square is
+	// in the given corner.
+	corners := map[int][2]bool{ // right, bottom
+		1: {false, false}, 2: {true, false}, 3: {true, true}, 4: {false, true},
+		5: {false, false}, 6: {false, true}, 7: {true, true}, 8: {true, false},
+	}
+	for orientation, corner := range corners {
+		w, h := 64, 32
+		if orientation >= 5 {
+			w, h = h, w
+		}
+		img := image.NewGray(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				px, py := x, y
+				if corner[0] {
+					px = w - 1 - x
+				}
+				if corner[1] {
+					py = h - 1 - y
+				}
+				if px >= 16 || py >= 16 {
+					img.SetGray(x, y, color.Gray{Y: 255})
+				}
+			}
+		}
+		for _, order := range []binary.AppendByteOrder{binary.BigEndian, binary.LittleEndian} {
+			source, err := zxinggo.ReadImageLuminanceSource(bytes.NewReader(exifJPEG(t, img, orientation, order)))
+			if err != nil {
+				t.Fatalf("orientation %d: %v", orientation, err)
+			}
+			if source.Width() != 64 || source.Height() != 32 {
+				t.Errorf("orientation %d: size %dx%d, want 64x32", orientation, source.Width(), source.Height())
+				continue
+			}
+			lum := source.Matrix()
+			if lum[8*64+8] > 64 || lum[24*64+56] < 192 {
+				t.Errorf("orientation %d (%v): dark square not at top left", orientation, order)
+			}
+		}
+	}
+}
+
+func TestDecodeFileOrientation(t *testing.T) {
+	// A phone photo of a horizontal barcode stored sideways, to be turned 90
+	// degrees clockwise.
+	matrix, err := zxinggo.Encode("EXIF ROTATED", zxinggo.FormatCode128, 240, 60, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, h := matrix.Width(), matrix.Height()
+	img := image.NewGray(image.Rect(0, 0, h, w))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if !matrix.Get(x, y) {
+				img.SetGray(y, w-1-x, color.Gray{Y: 255})
+			}
+		}
+	}
+	path := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(path, exifJPEG(t, img, 6, binary.BigEndian), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := zxinggo.DecodeFile(path, binarizer.NewHybrid(nil), nil)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if result.Text != "EXIF ROTATED" {
+		t.Errorf("text = %q", result.Text)
+	}
+}
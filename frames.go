@@ -0,0 +1,47 @@
+package zxinggo
+
+import (
+	"fmt"
+	"io"
+)
+
+// FrameSource supplies the frames of a video stream, such as a camera, to
+// Scanner.Run. Next returns the next frame, blocking until it is ready, or
+// io.EOF when the stream has ended. A frame need only stay valid until the
+// following call to Next, so implementations may reuse their buffers.
+//
+// Camera bindings are left to adapters outside this package: a V4L2,
+// AVFoundation or DirectShow capture loop implements FrameSource by
+// wrapping each grayscale frame with NewLuminanceSourceFromBytes, or any
+// camera that ffmpeg can read can be piped to a RawFrameSource.
+type FrameSource interface {
+	Next() (LuminanceSource, error)
+}
+
+// RawFrameSource reads frames of 8-bit grayscale pixels, packed row after
+// row with no header, from a stream such as the output of
+//
+//	ffmpeg -i <camera> -f rawvideo -pix_fmt gray -
+type RawFrameSource struct {
+	r             io.Reader
+	width, height int
+	buf           []byte
+}
+
+// NewRawFrameSource creates a FrameSource reading width by height frames
+// from r. Each frame is read into the same buffer.
+func NewRawFrameSource(r io.Reader, width, height int) *RawFrameSource {
+	if width <= 0 || height <= 0 {
+		panic(fmt.Sprintf("zxinggo: invalid frame size %dx%d", width, height))
+	}
+	return &RawFrameSource{r: r, width: width, height: height, buf: make([]byte, width*height)}
+}
+
+// Next reads the next frame. It returns io.EOF at the end of the stream and
+// io.ErrUnexpectedEOF if the stream ends partway through a frame.
+func (f *RawFrameSource) Next() (LuminanceSource, error) {
+	if _, err := io.ReadFull(f.r, f.buf); err != nil {
+		return nil, err
+	}
+	return NewLuminanceSourceFromBytes(f.buf, f.width, f.height, f.width), nil
+}
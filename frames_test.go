@@ -0,0 +1,28 @@
+package zxinggo
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestRawFrameSource(t *testing.T) {
+	// Two 3x2 frames and half of a third.
+	data := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	frames := NewRawFrameSource(bytes.NewReader(data), 3, 2)
+	for i := 0; i < 2; i++ {
+		source, err := frames.Next()
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if got, want := source.Matrix(), data[i*6:i*6+6]; !bytes.Equal(got, want) {
+			t.Errorf("frame %d = %v, want %v", i, got, want)
+		}
+	}
+	if _, err := frames.Next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("partial frame: err = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if _, err := NewRawFrameSource(bytes.NewReader(nil), 3, 2).Next(); err != io.EOF {
+		t.Errorf("empty stream: err = %v, want %v", err, io.EOF)
+	}
+}
@@ -4,4 +4,7 @@ go 1.24.0
 
 toolchain go1.24.1
 
-require golang.org/x/text v0.34.0 // indirect
+require (
+	golang.org/x/image v0.25.0
+	golang.org/x/text v0.34.0
+)
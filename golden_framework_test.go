@@ -0,0 +1,214 @@
+package zxinggo_test
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+)
+
+// goldenTestDir is the path to the encoder golden vectors, generated from
+// Java ZXing by testdata/golden/GenerateGolden.java.
+const goldenTestDir = "testdata/golden"
+
+// goldenTestCase defines a golden encoder test for one format/directory.
+type goldenTestCase struct {
+	dir         string // subdirectory name under goldenTestDir, e.g. "qrcode"
+	format      zxinggo.Format
+	maxMismatch int // vectors allowed to differ from the reference
+}
+
+// goldenVector is one encoder input and the symbol Java ZXing encoded from
+// it, without a quiet zone.
+type goldenVector struct {
+	path     string
+	format   zxinggo.Format
+	contents string
+	opts     zxinggo.EncodeOptions
+	want     *bitutil.BitMatrix
+}
+
+// loadGoldenVector reads a golden vector file: "key: value" header lines,
+// with contents Go-quoted, then a "matrix:" line followed by the symbol as
+// written by BitMatrix.String. Lines starting with # are comments.
+func loadGoldenVector(path string) (*goldenVector, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	margin := 0
+	v := &goldenVector{path: path, opts: zxinggo.EncodeOptions{Margin: &margin, QRMaskPattern: -1}}
+	haveFormat, haveContents := false, false
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == "matrix:" {
+			var dump strings.Builder
+			for scanner.Scan() {
+				dump.WriteString(scanner.Text())
+				dump.WriteByte('\n')
+			}
+			if v.want, err = bitutil.ParseBitMatrix(dump.String()); err != nil {
+				return nil, err
+			}
+			break
+		}
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			return nil, fmt.Errorf("malformed line %q", line)
+		}
+		switch key {
+		case "format":
+			v.format, err = zxinggo.ParseFormat(value)
+			haveFormat = true
+		case "contents":
+			v.contents, err = strconv.Unquote(value)
+			haveContents = true
+		case "errorCorrection":
+			v.opts.ErrorCorrection = value
+		case "characterSet":
+			v.opts.CharacterSet = value
+		case "qrVersion":
+			v.opts.QRVersion, err = strconv.Atoi(value)
+		case "qrMaskPattern":
+			v.opts.QRMaskPattern, err = strconv.Atoi(value)
+		case "source":
+			// Records the generator and ZXing version.
+		default:
+			return nil, fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !haveFormat || !haveContents || v.want == nil {
+		return nil, fmt.Errorf("missing format, contents or matrix")
+	}
+	return v, nil
+}
+
+// symbolMatrix encodes the vector and returns the symbol without its quiet
+// zone, which writers add whatever the margin asked for: it must be blank
+// and the same width on each side.
+func (v *goldenVector) symbolMatrix() (*bitutil.BitMatrix, error) {
+	got, err := zxinggo.Encode(v.contents, v.format, 0, 0, &v.opts)
+	if err != nil {
+		return nil, err
+	}
+	want := v.want
+	dx, dy := got.Width()-want.Width(), got.Height()-want.Height()
+	if dx < 0 || dy < 0 || dx%2 != 0 || dy%2 != 0 {
+		return got, nil
+	}
+	left, top := dx/2, dy/2
+	symbol := bitutil.NewBitMatrixWithSize(want.Width(), want.Height())
+	for y := 0; y < got.Height(); y++ {
+		for x := 0; x < got.Width(); x++ {
+			if !got.Get(x, y) {
+				continue
+			}
+			if x < left || y < top || x >= left+want.Width() || y >= top+want.Height() {
+				// Something drawn in the quiet zone: compare it all.
+				return got, nil
+			}
+			symbol.Set(x-left, y-top)
+		}
+	}
+	return symbol, nil
+}
+
+// goldenSpecNames returns the names of the vectors specs.txt lists for
+// format, each of which must have been generated.
+func goldenSpecNames(t *testing.T, format zxinggo.Format) []string {
+	t.Helper()
+	f, err := os.Open(filepath.Join(goldenTestDir, "specs.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if fields := strings.Split(line, "\t"); len(fields) > 1 && fields[0] == format.String() {
+			names = append(names, fields[1])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+// runGoldenTest encodes every vector in a golden test directory and checks
+// the symbols match Java ZXing's module for module.
+func runGoldenTest(t *testing.T, tc goldenTestCase) {
+	t.Helper()
+
+	dir := filepath.Join(goldenTestDir, tc.dir)
+	paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := goldenSpecNames(t, tc.format)
+	if len(names) == 0 && len(paths) == 0 {
+		t.Skipf("no golden vectors listed for %s in specs.txt", tc.format)
+	}
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(dir, name+".txt")); err != nil {
+			t.Errorf("specs.txt lists %s but its vector is missing; regenerate the vectors, see %s/README.md", filepath.Join(tc.dir, name), goldenTestDir)
+		}
+	}
+	if t.Failed() {
+		t.FailNow()
+	}
+
+	mismatches := 0
+	for _, path := range paths {
+		v, err := loadGoldenVector(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if v.format != tc.format {
+			t.Fatalf("%s: format %v in %s directory", path, v.format, tc.format)
+		}
+		got, err := v.symbolMatrix()
+		if err != nil {
+			mismatches++
+			t.Logf("  ERROR file=%s: %v", filepath.Base(path), err)
+			continue
+		}
+		if !got.Equals(v.want) {
+			mismatches++
+			t.Logf("  MISMATCH file=%s contents=%q got %dx%d:\n%s\nwant %dx%d:\n%s", filepath.Base(path), v.contents,
+				got.Width(), got.Height(), got, v.want.Width(), v.want.Height(), v.want)
+		}
+	}
+
+	t.Logf("Total: %d/%d match, %d mismatched (max %d)", len(paths)-mismatches, len(paths), mismatches, tc.maxMismatch)
+	if mismatches < tc.maxMismatch {
+		t.Logf("+++ Test too lax by %d vectors", tc.maxMismatch-mismatches)
+	}
+	if mismatches > tc.maxMismatch {
+		t.Errorf("Too many mismatches: got %d, max %d", mismatches, tc.maxMismatch)
+	}
+}
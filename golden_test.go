@@ -0,0 +1,96 @@
+package zxinggo_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+)
+
+func TestGoldenQRCode(t *testing.T) {
+	runGoldenTest(t, goldenTestCase{dir: "qrcode", format: zxinggo.FormatQRCode})
+}
+
+func TestGoldenDataMatrix(t *testing.T) {
+	runGoldenTest(t, goldenTestCase{dir: "datamatrix", format: zxinggo.FormatDataMatrix})
+}
+
+func TestGoldenAztec(t *testing.T) {
+	runGoldenTest(t, goldenTestCase{dir: "aztec", format: zxinggo.FormatAztec})
+}
+
+func TestGoldenCode128(t *testing.T) {
+	runGoldenTest(t, goldenTestCase{dir: "code128", format: zxinggo.FormatCode128})
+}
+
+func TestGoldenCode39(t *testing.T) {
+	runGoldenTest(t, goldenTestCase{dir: "code39", format: zxinggo.FormatCode39})
+}
+
+func TestGoldenCode93(t *testing.T) {
+	runGoldenTest(t, goldenTestCase{dir: "code93", format: zxinggo.FormatCode93})
+}
+
+func TestGoldenCodabar(t *testing.T) {
+	runGoldenTest(t, goldenTestCase{dir: "codabar", format: zxinggo.FormatCodabar})
+}
+
+func TestGoldenEAN13(t *testing.T) {
+	runGoldenTest(t, goldenTestCase{dir: "ean13", format: zxinggo.FormatEAN13})
+}
+
+func TestGoldenEAN8(t *testing.T) {
+	runGoldenTest(t, goldenTestCase{dir: "ean8", format: zxinggo.FormatEAN8})
+}
+
+func TestGoldenUPCA(t *testing.T) {
+	runGoldenTest(t, goldenTestCase{dir: "upca", format: zxinggo.FormatUPCA})
+}
+
+func TestGoldenUPCE(t *testing.T) {
+	runGoldenTest(t, goldenTestCase{dir: "upce", format: zxinggo.FormatUPCE})
+}
+
+func TestGoldenITF(t *testing.T) {
+	runGoldenTest(t, goldenTestCase{dir: "itf", format: zxinggo.FormatITF})
+}
+
+// TestGoldenVectorFile checks the harness itself on a vector written from
+// this library's own encoder, so it holds without reference vectors.
+func TestGoldenVectorFile(t *testing.T) {
+	margin := 0
+	opts := &zxinggo.EncodeOptions{ErrorCorrection: "Q", QRVersion: 2, QRMaskPattern: 5, Margin: &margin}
+	symbol, err := zxinggo.Encode("GOLDEN \"1\"", zxinggo.FormatQRCode, 0, 0, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "vector.txt")
+	file := "# harness test\nformat: QR_CODE\ncontents: " + strconv.Quote("GOLDEN \"1\"") +
+		"\nerrorCorrection: Q\nqrVersion: 2\nqrMaskPattern: 5\nmatrix:\n" + symbol.String()
+	if err := os.WriteFile(path, []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	v, err := loadGoldenVector(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.contents != "GOLDEN \"1\"" || v.opts.QRVersion != 2 || v.opts.QRMaskPattern != 5 {
+		t.Errorf("loaded contents %q, version %d, mask %d", v.contents, v.opts.QRVersion, v.opts.QRMaskPattern)
+	}
+	// The writer's default quiet zone is trimmed before comparing.
+	v.opts.Margin = nil
+	got, err := v.symbolMatrix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equals(v.want) {
+		t.Errorf("symbol =\n%s\nwant\n%s", got, v.want)
+	}
+	// A different mask does not match.
+	v.opts.QRMaskPattern = 4
+	if got, err := v.symbolMatrix(); err != nil || got.Equals(v.want) {
+		t.Errorf("mask 4 matched mask 5 (error %v)", err)
+	}
+}
@@ -0,0 +1,175 @@
+// Package gs1 provides GS1 Application Identifier (AI) handling: parsing and
+// formatting element strings, and converting them to and from GS1 Digital
+// Link URIs.
+package gs1
+
+import "strconv"
+
+// aiSpec describes the data field of an Application Identifier.
+type aiSpec struct {
+	// length is the exact data length for fixed-length AIs, or the maximum
+	// length for variable-length ones.
+	length  int
+	fixed   bool
+	numeric bool
+	// checkDigit marks AIs whose data ends with a GS1 mod-10 check digit
+	// over the preceding digits.
+	checkDigit bool
+	// date marks AIs whose data is a date YYMMDD, then optionally hours,
+	// minutes and seconds, two digits each. minLength is the least data
+	// length of those that are variable-length.
+	date      bool
+	minLength int
+}
+
+func fixedNumeric(n int) aiSpec { return aiSpec{length: n, fixed: true, numeric: true} }
+func varNumeric(n int) aiSpec   { return aiSpec{length: n, numeric: true} }
+func varAlpha(n int) aiSpec     { return aiSpec{length: n} }
+func fixedKey(n int) aiSpec     { return aiSpec{length: n, fixed: true, numeric: true, checkDigit: true} }
+func fixedAlpha(n int) aiSpec   { return aiSpec{length: n, fixed: true} }
+
+// dateTime is the spec of a date and time field of least to most digits.
+func dateTime(least, most int) aiSpec {
+	return aiSpec{length: most, fixed: least == most, numeric: true, date: true, minLength: least}
+}
+
+// aiTable lists the Application Identifiers understood by this package.
+var aiTable = map[string]aiSpec{
+	"00": fixedKey(18), "01": fixedKey(14), "02": fixedKey(14),
+	"10": varAlpha(20), "11": dateTime(6, 6), "12": dateTime(6, 6),
+	"13": dateTime(6, 6), "15": dateTime(6, 6), "16": dateTime(6, 6),
+	"17": dateTime(6, 6), "20": fixedNumeric(2), "21": varAlpha(20),
+	"22": varAlpha(20), "235": varAlpha(28), "240": varAlpha(30),
+	"241": varAlpha(30), "242": varNumeric(6), "243": varAlpha(20),
+	"250": varAlpha(30), "251": varAlpha(30), "253": varAlpha(30),
+	"254": varAlpha(20), "255": varNumeric(25), "30": varNumeric(8),
+	"37": varNumeric(8), "400": varAlpha(30), "401": varAlpha(30),
+	"402": fixedNumeric(17), "403": varAlpha(30), "410": fixedKey(13),
+	"411": fixedKey(13), "412": fixedKey(13), "413": fixedKey(13),
+	"414": fixedKey(13), "415": fixedKey(13), "416": fixedKey(13),
+	"417": fixedKey(13), "420": varAlpha(20), "421": varAlpha(12),
+	"422": fixedNumeric(3), "423": varNumeric(15), "424": fixedNumeric(3),
+	"425": varNumeric(15), "426": fixedNumeric(3), "427": varAlpha(3),
+	"7001": fixedNumeric(13), "7002": varAlpha(30), "7003": dateTime(10, 10),
+	"7004": varNumeric(4), "7040": fixedAlpha(4), "8003": varAlpha(30),
+	"8004": varAlpha(30), "8005": fixedNumeric(6), "8006": fixedNumeric(18),
+	"8007": varAlpha(34), "8008": dateTime(8, 12), "8010": varAlpha(30),
+	"8011": varNumeric(12), "8012": varAlpha(20), "8013": varAlpha(25),
+	"8017": fixedKey(18), "8018": fixedKey(18), "8019": varNumeric(10),
+	"8020": varAlpha(25), "8200": varAlpha(70), "90": varAlpha(30),
+}
+
+func init() {
+	// Measures and amounts: four digit AIs whose last digit is the implied
+	// decimal point position.
+	for p := 310; p <= 369; p++ {
+		if p > 316 && p < 320 {
+			continue
+		}
+		for d := 0; d <= 9; d++ {
+			aiTable[strconv.Itoa(p*10+d)] = fixedNumeric(6)
+		}
+	}
+	for d := 0; d <= 9; d++ {
+		aiTable[strconv.Itoa(3900+d)] = varNumeric(15)
+		aiTable[strconv.Itoa(3910+d)] = varNumeric(18)
+		aiTable[strconv.Itoa(3920+d)] = varNumeric(15)
+		aiTable[strconv.Itoa(3930+d)] = varNumeric(18)
+	}
+	// Company internal information.
+	for ai := 91; ai <= 99; ai++ {
+		aiTable[strconv.Itoa(ai)] = varAlpha(90)
+	}
+}
+
+// lookupAI finds the Application Identifier at the start of s, returning the
+// AI and its specification.
+func lookupAI(s string) (string, aiSpec, bool) {
+	for n := 2; n <= 4 && n <= len(s); n++ {
+		if spec, ok := aiTable[s[:n]]; ok {
+			return s[:n], spec, true
+		}
+	}
+	return "", aiSpec{}, false
+}
+
+// IsKnownAI reports whether ai is an Application Identifier known to this
+// package.
+func IsKnownAI(ai string) bool {
+	_, ok := aiTable[ai]
+	return ok
+}
+
+// predefinedLengthPrefixes are the first two digits of the AIs whose
+// element strings have a length predefined by the GS1 General
+// Specifications, so that every reader knows where they end. Only these go
+// without an FNC1 separator when another element follows; fixed-length AIs
+// outside them, such as (402), (7003) and (8005), still need one.
+var predefinedLengthPrefixes = map[string]bool{
+	"00": true, "01": true, "02": true, "03": true, "04": true,
+	"11": true, "12": true, "13": true, "14": true, "15": true,
+	"16": true, "17": true, "18": true, "19": true, "20": true,
+	"23": true, "31": true, "32": true, "33": true, "34": true,
+	"35": true, "36": true, "41": true,
+}
+
+// IsFixedLength reports whether ai has a fixed-length data field.
+func IsFixedLength(ai string) bool {
+	return aiTable[ai].fixed
+}
+
+// IsPredefinedLength reports whether ai has a fixed-length data field whose
+// length the GS1 General Specifications predefine, and therefore needs no
+// FNC1 separator after it in an element string.
+func IsPredefinedLength(ai string) bool {
+	return len(ai) >= 2 && aiTable[ai].fixed && predefinedLengthPrefixes[ai[:2]]
+}
+
+// daysInMonth is the number of days in each month of a leap year.
+var daysInMonth = [12]int{31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// validDateTime reports whether s, all digits, is a date YYMMDD followed by
+// as many of hours, minutes and seconds as it has room for. A day of 00,
+// meaning the day is not given, is allowed if dayOptional is set.
+func validDateTime(s string, dayOptional bool) bool {
+	if len(s) < 6 || len(s)%2 != 0 {
+		return false
+	}
+	pair := func(i int) int { return int(s[i]-'0')*10 + int(s[i+1]-'0') }
+	year, month, day := pair(0), pair(2), pair(4)
+	if month < 1 || month > 12 {
+		return false
+	}
+	days := daysInMonth[month-1]
+	if month == 2 && year%4 != 0 {
+		// Every year GS1's sliding century window gives, 50 years either
+		// side of now, is a leap year if its last two digits are.
+		days = 28
+	}
+	if day > days || day == 0 && !dayOptional {
+		return false
+	}
+	for i, limit := range []int{24, 60, 60} {
+		if 6+2*i < len(s) && pair(6+2*i) >= limit {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckDigit computes the GS1 mod-10 check digit for the given digits.
+func CheckDigit(digits string) (byte, bool) {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		c := digits[i]
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		v := int(c - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			v *= 3
+		}
+		sum += v
+	}
+	return byte('0' + (10-sum%10)%10), true
+}
@@ -0,0 +1,197 @@
+package gs1
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+)
+
+// DefaultDigitalLinkDomain is the GS1 resolver used when no domain is given.
+const DefaultDigitalLinkDomain = "https://id.gs1.org"
+
+// primaryKeyQualifiers lists, for each AI that may serve as a Digital Link
+// primary key, the key qualifier AIs allowed in the URI path in the order
+// they must appear.
+var primaryKeyQualifiers = map[string][]string{
+	"00":   nil,
+	"01":   {"22", "10", "21"},
+	"253":  nil,
+	"255":  nil,
+	"401":  nil,
+	"402":  nil,
+	"414":  {"254"},
+	"417":  nil,
+	"8003": nil,
+	"8004": nil,
+	"8006": {"22", "10", "21"},
+	"8010": {"8011"},
+	"8013": nil,
+	"8017": {"8019"},
+	"8018": {"8019"},
+}
+
+// primaryKeyOrder is the order in which primary keys are looked for when
+// building a URI from elements that contain more than one candidate.
+var primaryKeyOrder = []string{
+	"01", "8006", "00", "414", "417", "8017", "8018", "253", "255",
+	"401", "402", "8003", "8004", "8010", "8013",
+}
+
+// DigitalLinkURI converts elements to a GS1 Digital Link URI on the given
+// domain (for example "https://example.com"). An empty domain selects
+// DefaultDigitalLinkDomain. The primary key and its qualifiers form the URI
+// path; all other elements become query parameters.
+func DigitalLinkURI(elements []Element, domain string) (string, error) {
+	if domain == "" {
+		domain = DefaultDigitalLinkDomain
+	}
+	byAI := make(map[string]string, len(elements))
+	for _, e := range elements {
+		if err := e.Validate(); err != nil {
+			return "", err
+		}
+		if _, dup := byAI[e.AI]; dup {
+			return "", fmt.Errorf("%w: duplicate AI (%s)", zxinggo.ErrWriter, e.AI)
+		}
+		byAI[e.AI] = e.Value
+	}
+
+	primary := ""
+	for _, ai := range primaryKeyOrder {
+		if _, ok := byAI[ai]; ok {
+			primary = ai
+			break
+		}
+	}
+	if primary == "" {
+		return "", fmt.Errorf("%w: no GS1 Digital Link primary key", zxinggo.ErrWriter)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.TrimRight(domain, "/"))
+	inPath := map[string]bool{primary: true}
+	writePathSegment(&sb, primary, byAI[primary])
+	for _, q := range primaryKeyQualifiers[primary] {
+		if v, ok := byAI[q]; ok {
+			writePathSegment(&sb, q, v)
+			inPath[q] = true
+		}
+	}
+
+	sep := byte('?')
+	for _, e := range elements {
+		if inPath[e.AI] {
+			continue
+		}
+		sb.WriteByte(sep)
+		sb.WriteString(e.AI)
+		sb.WriteByte('=')
+		sb.WriteString(escapeDigitalLink(e.Value))
+		sep = '&'
+	}
+	return sb.String(), nil
+}
+
+func writePathSegment(sb *strings.Builder, ai, value string) {
+	sb.WriteByte('/')
+	sb.WriteString(ai)
+	sb.WriteByte('/')
+	sb.WriteString(escapeDigitalLink(value))
+}
+
+// escapeDigitalLink percent-encodes every character outside the URI
+// unreserved set, as the Digital Link standard requires for AI values.
+func escapeDigitalLink(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+// ParseDigitalLink extracts the elements from a GS1 Digital Link URI. Any
+// path segments before the primary key (a custom resolver prefix) are ignored,
+// as are query parameters that are not AIs. GTINs shorter than 14 digits are
+// zero-padded.
+func ParseDigitalLink(uri string) ([]Element, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", zxinggo.ErrFormat, err)
+	}
+	segments := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+
+	start := -1
+	for i := 0; i+1 < len(segments); i++ {
+		if _, ok := primaryKeyQualifiers[segments[i]]; ok {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("%w: no GS1 Digital Link primary key in path", zxinggo.ErrFormat)
+	}
+	if (len(segments)-start)%2 != 0 {
+		return nil, fmt.Errorf("%w: odd number of GS1 Digital Link path segments", zxinggo.ErrFormat)
+	}
+
+	var elements []Element
+	primary := segments[start]
+	allowed := primaryKeyQualifiers[primary]
+	for i := start; i < len(segments); i += 2 {
+		ai := segments[i]
+		if i > start {
+			idx := indexOf(allowed, ai)
+			if idx < 0 {
+				return nil, fmt.Errorf("%w: AI (%s) is not a qualifier of (%s)", zxinggo.ErrFormat, ai, primary)
+			}
+			// Qualifiers must keep their order but may be skipped.
+			allowed = allowed[idx+1:]
+		}
+		value, err := url.PathUnescape(segments[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", zxinggo.ErrFormat, err)
+		}
+		if ai == "01" && len(value) < 14 {
+			value = strings.Repeat("0", 14-len(value)) + value
+		}
+		elements = append(elements, Element{AI: ai, Value: value})
+	}
+
+	if u.RawQuery != "" {
+		for _, pair := range strings.Split(u.RawQuery, "&") {
+			key, value, _ := strings.Cut(pair, "=")
+			if !IsKnownAI(key) {
+				continue
+			}
+			value, err := url.QueryUnescape(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", zxinggo.ErrFormat, err)
+			}
+			elements = append(elements, Element{AI: key, Value: value})
+		}
+	}
+
+	for _, e := range elements {
+		if err := e.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return elements, nil
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
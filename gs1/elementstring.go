@@ -0,0 +1,177 @@
+package gs1
+
+import (
+	"fmt"
+	"strings"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+)
+
+// GroupSeparator is the ASCII GS character that stands for FNC1 between
+// variable-length fields in a raw element string.
+const GroupSeparator = '\x1d'
+
+// Element is a single Application Identifier and its data.
+type Element struct {
+	AI    string
+	Value string
+}
+
+// ParseElementString parses a GS1 element string into its elements. Both the
+// bracketed human-readable form, e.g. "(01)09506000134352(10)ABC123", and the
+// raw form with GS separators between fields, as produced by
+// readers with AssumeGS1, are accepted. A leading AIM symbology identifier
+// such as "]C1" or "]Q3" is ignored.
+func ParseElementString(s string) ([]Element, error) {
+	if len(s) >= 3 && s[0] == ']' {
+		s = s[3:]
+	}
+	if strings.HasPrefix(s, "(") {
+		return parseBracketed(s)
+	}
+	return parseRaw(s)
+}
+
+func parseBracketed(s string) ([]Element, error) {
+	var elements []Element
+	for len(s) > 0 {
+		if s[0] != '(' {
+			return nil, fmt.Errorf("%w: expected '(' in GS1 element string", zxinggo.ErrFormat)
+		}
+		end := strings.IndexByte(s, ')')
+		if end < 0 {
+			return nil, fmt.Errorf("%w: unterminated AI in GS1 element string", zxinggo.ErrFormat)
+		}
+		ai := s[1:end]
+		s = s[end+1:]
+		next := strings.IndexByte(s, '(')
+		if next < 0 {
+			next = len(s)
+		}
+		e := Element{AI: ai, Value: s[:next]}
+		if err := e.Validate(); err != nil {
+			return nil, err
+		}
+		elements = append(elements, e)
+		s = s[next:]
+	}
+	return elements, nil
+}
+
+func parseRaw(s string) ([]Element, error) {
+	var elements []Element
+	s = strings.TrimLeft(s, string(GroupSeparator))
+	for len(s) > 0 {
+		ai, spec, ok := lookupAI(s)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown GS1 AI at %q", zxinggo.ErrFormat, s)
+		}
+		s = s[len(ai):]
+		var value string
+		if spec.fixed {
+			if len(s) < spec.length {
+				return nil, fmt.Errorf("%w: AI (%s) data too short", zxinggo.ErrFormat, ai)
+			}
+			value = s[:spec.length]
+			s = s[spec.length:]
+		} else {
+			end := strings.IndexByte(s, GroupSeparator)
+			if end < 0 {
+				end = len(s)
+			}
+			value = s[:end]
+			s = s[end:]
+		}
+		e := Element{AI: ai, Value: value}
+		if err := e.Validate(); err != nil {
+			return nil, err
+		}
+		elements = append(elements, e)
+		s = strings.TrimLeft(s, string(GroupSeparator))
+	}
+	return elements, nil
+}
+
+// Validate checks the element's data against the length, character set,
+// date and check digit rules of its AI.
+func (e Element) Validate() error {
+	spec, ok := aiTable[e.AI]
+	if !ok {
+		return fmt.Errorf("%w: unknown GS1 AI (%s)", zxinggo.ErrFormat, e.AI)
+	}
+	if e.Value == "" {
+		return fmt.Errorf("%w: AI (%s) has no data", zxinggo.ErrFormat, e.AI)
+	}
+	if spec.fixed && len(e.Value) != spec.length {
+		return fmt.Errorf("%w: AI (%s) requires %d characters, got %d", zxinggo.ErrFormat, e.AI, spec.length, len(e.Value))
+	}
+	if len(e.Value) > spec.length {
+		return fmt.Errorf("%w: AI (%s) allows at most %d characters, got %d", zxinggo.ErrFormat, e.AI, spec.length, len(e.Value))
+	}
+	if len(e.Value) < spec.minLength {
+		return fmt.Errorf("%w: AI (%s) requires at least %d characters, got %d", zxinggo.ErrFormat, e.AI, spec.minLength, len(e.Value))
+	}
+	for i := 0; i < len(e.Value); i++ {
+		c := e.Value[i]
+		if spec.numeric && (c < '0' || c > '9') {
+			return fmt.Errorf("%w: AI (%s) must be numeric", zxinggo.ErrFormat, e.AI)
+		}
+		if c < 0x21 || c > 0x7E {
+			return fmt.Errorf("%w: AI (%s) contains invalid character %q", zxinggo.ErrFormat, e.AI, c)
+		}
+	}
+	if spec.date && !validDateTime(e.Value, spec.fixed && spec.length == 6) {
+		return fmt.Errorf("%w: AI (%s) is not a valid date %q", zxinggo.ErrFormat, e.AI, e.Value)
+	}
+	if spec.checkDigit {
+		n := len(e.Value)
+		if cd, _ := CheckDigit(e.Value[:n-1]); cd != e.Value[n-1] {
+			return fmt.Errorf("%w: AI (%s) check digit mismatch", zxinggo.ErrChecksum, e.AI)
+		}
+	}
+	return nil
+}
+
+// FormatRaw formats elements as a raw element string, placing a GS separator
+// after each field that is followed by another element, unless its AI is of
+// predefined length. This is the form encoders expect when GS1Format is set.
+func FormatRaw(elements []Element) string {
+	var sb strings.Builder
+	for i, e := range elements {
+		sb.WriteString(e.AI)
+		sb.WriteString(e.Value)
+		if i < len(elements)-1 && !IsPredefinedLength(e.AI) {
+			sb.WriteByte(GroupSeparator)
+		}
+	}
+	return sb.String()
+}
+
+// RawElementString validates a GS1 element string, bracketed or raw, and
+// returns it in raw form, as FormatRaw places the separators. The GS1
+// writers encode what it returns, FNC1 standing for each separator, so that
+// a separator is never missing after a field a reader cannot find the end
+// of, nor wasted after one of predefined length.
+func RawElementString(s string) (string, error) {
+	elements, err := ParseElementString(s)
+	if err != nil {
+		return "", err
+	}
+	if len(elements) == 0 {
+		return "", fmt.Errorf("%w: empty GS1 element string", zxinggo.ErrFormat)
+	}
+	return FormatRaw(elements), nil
+}
+
+// FormatHRI formats elements in the bracketed human-readable interpretation,
+// e.g. "(01)09506000134352(10)ABC123".
+func FormatHRI(elements []Element) string {
+	var sb strings.Builder
+	for _, e := range elements {
+		sb.WriteByte('(')
+		sb.WriteString(e.AI)
+		sb.WriteByte(')')
+		sb.WriteString(e.Value)
+	}
+	return sb.String()
+}
@@ -0,0 +1,102 @@
+package gs1
+
+import (
+	"errors"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+)
+
+func TestParseElementStringForms(t *testing.T) {
+	want := []Element{{"01", "09506000134352"}, {"10", "ABC123"}, {"17", "201225"}, {"3103", "000189"}}
+	inputs := []string{
+		"(01)09506000134352(10)ABC123(17)201225(3103)000189",
+		"0109506000134352" + "10ABC123\x1d" + "17201225" + "3103000189",
+		"]C1" + "0109506000134352" + "10ABC123\x1d" + "17201225" + "3103000189",
+	}
+	for _, in := range inputs {
+		got, err := ParseElementString(in)
+		if err != nil {
+			t.Fatalf("ParseElementString(%q): %v", in, err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("ParseElementString(%q) = %v, want %v", in, got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("element %d = %v, want %v", i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestFormatRawSeparators(t *testing.T) {
+	elements := []Element{{"10", "ABC"}, {"01", "09506000134352"}, {"21", "X1"}, {"17", "201225"}}
+	want := "10ABC\x1d" + "0109506000134352" + "21X1\x1d" + "17201225"
+	if got := FormatRaw(elements); got != want {
+		t.Errorf("FormatRaw = %q, want %q", got, want)
+	}
+	if got := FormatHRI(elements); got != "(10)ABC(01)09506000134352(21)X1(17)201225" {
+		t.Errorf("FormatHRI = %q", got)
+	}
+
+	// Fixed-length AIs whose lengths are not predefined still need FNC1.
+	elements = []Element{{"402", "09506000134352005"}, {"8005", "000123"}, {"7001", "1234567890123"}, {"3103", "000189"}, {"422", "250"}}
+	want = "40209506000134352005\x1d" + "8005000123\x1d" + "70011234567890123\x1d" + "3103000189" + "422250"
+	if got := FormatRaw(elements); got != want {
+		t.Errorf("FormatRaw = %q, want %q", got, want)
+	}
+}
+
+func TestValidateCheckDigit(t *testing.T) {
+	err := Element{"01", "09506000134353"}.Validate()
+	if !errors.Is(err, zxinggo.ErrChecksum) {
+		t.Errorf("expected checksum error, got %v", err)
+	}
+	if err := (Element{"17", "2012AB"}).Validate(); !errors.Is(err, zxinggo.ErrFormat) {
+		t.Errorf("expected format error for non-numeric date, got %v", err)
+	}
+}
+
+func TestDigitalLinkRoundTrip(t *testing.T) {
+	elements := []Element{{"01", "09506000134352"}, {"17", "201225"}, {"21", "A/B%C"}, {"10", "LOT1"}}
+	uri, err := DigitalLinkURI(elements, "https://example.com/")
+	if err != nil {
+		t.Fatalf("DigitalLinkURI: %v", err)
+	}
+	want := "https://example.com/01/09506000134352/10/LOT1/21/A%2FB%25C?17=201225"
+	if uri != want {
+		t.Errorf("DigitalLinkURI = %q, want %q", uri, want)
+	}
+
+	parsed, err := ParseDigitalLink(uri)
+	if err != nil {
+		t.Fatalf("ParseDigitalLink: %v", err)
+	}
+	got := map[string]string{}
+	for _, e := range parsed {
+		got[e.AI] = e.Value
+	}
+	for _, e := range elements {
+		if got[e.AI] != e.Value {
+			t.Errorf("AI (%s) = %q, want %q", e.AI, got[e.AI], e.Value)
+		}
+	}
+}
+
+func TestParseDigitalLinkCustomPrefix(t *testing.T) {
+	parsed, err := ParseDigitalLink("https://brand.example/products/01/9506000134352?3103=000189&utm=x")
+	if err != nil {
+		t.Fatalf("ParseDigitalLink: %v", err)
+	}
+	want := []Element{{"01", "09506000134352"}, {"3103", "000189"}}
+	if len(parsed) != len(want) || parsed[0] != want[0] || parsed[1] != want[1] {
+		t.Errorf("ParseDigitalLink = %v, want %v", parsed, want)
+	}
+}
+
+func TestParseDigitalLinkRejectsBadQualifierOrder(t *testing.T) {
+	if _, err := ParseDigitalLink("https://id.gs1.org/01/09506000134352/21/X/10/Y"); err == nil {
+		t.Error("expected error for out-of-order qualifiers")
+	}
+}
@@ -0,0 +1,141 @@
+package zxinggo_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+	"github.com/ericlevine/zxinggo/gs1"
+)
+
+// gs1Writers are the formats whose writers take GS1 element strings, and
+// the prefix their readers put before GS1 text.
+var gs1Writers = []struct {
+	format zxinggo.Format
+	prefix string
+}{
+	{zxinggo.FormatCode128, "]C1"},
+	{zxinggo.FormatQRCode, ""},
+	{zxinggo.FormatAztec, ""},
+}
+
+// TestGS1RoundTrip encodes element strings with every GS1 writer and checks
+// that FNC1, read as GS, follows each field but the last whose length GS1
+// does not predefine, and nothing else, and that the text read parses back
+// to the elements written.
+func TestGS1RoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		// raw is the element string as read, GS marking each FNC1 after
+		// the first.
+		raw string
+	}{
+		{"fixed then variable", "(01)09506000134352(10)ABC123", "0109506000134352" + "10ABC123"},
+		{"variable then fixed", "(10)ABC123(17)250101", "10ABC123\x1d" + "17250101"},
+		{"variables", "(21)X1(10)LOT(240)PART", "21X1\x1d" + "10LOT\x1d" + "240PART"},
+		{"measures", "(3103)000189(3922)1999(30)12", "3103000189" + "39221999\x1d" + "3012"},
+		{"raw with a wasted separator", "0109506000134352\x1d10ABC", "0109506000134352" + "10ABC"},
+		{"maximum lengths",
+			"(10)" + strings.Repeat("L", 20) + "(21)" + strings.Repeat("S", 20) + "(00)095060001343520000",
+			"10" + strings.Repeat("L", 20) + "\x1d" + "21" + strings.Repeat("S", 20) + "\x1d" + "00095060001343520000"},
+		{"dates",
+			"(11)240229(17)251200(7003)2501312359(8008)25010108(15)991231",
+			"11240229" + "17251200" + "70032501312359\x1d" + "800825010108\x1d" + "15991231"},
+		{"date and time to the second", "(8008)251231235959(10)A", "8008251231235959\x1d" + "10A"},
+		// Fixed in length, but not of a length GS1 predefines.
+		{"price per unit", "(8005)000123(01)09506000134352", "8005000123\x1d" + "0109506000134352"},
+		{"NATO stock number", "(7001)1234567890123(17)251231", "70011234567890123\x1d" + "17251231"},
+	}
+	for _, w := range gs1Writers {
+		for _, tt := range tests {
+			t.Run(w.format.String()+"/"+tt.name, func(t *testing.T) {
+				want, err := gs1.ParseElementString(tt.input)
+				if err != nil {
+					t.Fatalf("ParseElementString(%q): %v", tt.input, err)
+				}
+				result := encodeGS1(t, tt.input, w.format)
+				if result.Text != w.prefix+tt.raw {
+					t.Errorf("text = %q, want %q", result.Text, w.prefix+tt.raw)
+				}
+				got, err := gs1.ParseElementString(result.Text)
+				if err != nil {
+					t.Fatalf("ParseElementString(%q): %v", result.Text, err)
+				}
+				if !slices.Equal(got, want) {
+					t.Errorf("elements read = %v, want %v", got, want)
+				}
+				if raw := gs1.FormatRaw(got); raw != tt.raw {
+					t.Errorf("FormatRaw = %q, want %q", raw, tt.raw)
+				}
+			})
+		}
+	}
+}
+
+// encodeGS1 encodes input with GS1Format in format and decodes it.
+func encodeGS1(t *testing.T, input string, format zxinggo.Format) *zxinggo.Result {
+	t.Helper()
+	matrix, err := zxinggo.Encode(input, format, 0, 0, &zxinggo.EncodeOptions{GS1Format: true})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	// Two pixels a module, and rows tall enough for a 1D reader.
+	img := zxinggo.BitMatrixToImage(matrix)
+	source := zxinggo.NewGrayImageLuminanceSource(img).Scale(2*matrix.Width(), max(2*matrix.Height(), 40))
+	result, err := zxinggo.Decode(zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(source)), &zxinggo.DecodeOptions{
+		PossibleFormats: []zxinggo.Format{format},
+		PureBarcode:     true,
+		AssumeGS1:       true,
+	})
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	return result
+}
+
+// TestGS1Rejected checks that every GS1 writer, and parsing the text
+// read, reject element strings that break the rules for their AIs.
+func TestGS1Rejected(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  error
+	}{
+		{"variable field too long", "(10)" + strings.Repeat("L", 21), zxinggo.ErrFormat},
+		{"fixed field too short", "(01)0950600013435", zxinggo.ErrFormat},
+		{"fixed field too long", "(17)2501011", zxinggo.ErrFormat},
+		{"check digit", "(01)09506000134353", zxinggo.ErrChecksum},
+		{"numeric field", "(30)12A", zxinggo.ErrFormat},
+		{"month 13", "(17)251301", zxinggo.ErrFormat},
+		{"30 February", "(17)240230", zxinggo.ErrFormat},
+		{"29 February of a common year", "(17)250229", zxinggo.ErrFormat},
+		{"day 00 with a time", "(7003)2501001200", zxinggo.ErrFormat},
+		{"hour 24", "(7003)2501012400", zxinggo.ErrFormat},
+		{"minute 60", "(7003)2501011260", zxinggo.ErrFormat},
+		{"date without an hour", "(8008)250101", zxinggo.ErrFormat},
+		{"odd digits of time", "(8008)250101123", zxinggo.ErrFormat},
+		{"unknown AI", "(23)1", zxinggo.ErrFormat},
+	}
+	for _, tt := range tests {
+		for _, w := range gs1Writers {
+			_, err := zxinggo.Encode(tt.input, w.format, 0, 0, &zxinggo.EncodeOptions{GS1Format: true})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("%s: %s writer: err = %v, want %v", tt.name, w.format, err, tt.want)
+			}
+		}
+		if _, err := gs1.ParseElementString(tt.input); !errors.Is(err, tt.want) {
+			t.Errorf("%s: ParseElementString: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// A reader cannot tell where a variable-length field missing its FNC1
+	// ends, but one that runs past its maximum length is caught.
+	missing := "]C1" + "10" + strings.Repeat("L", 20) + "17250101"
+	if _, err := gs1.ParseElementString(missing); !errors.Is(err, zxinggo.ErrFormat) {
+		t.Errorf("missing separator: err = %v, want ErrFormat", err)
+	}
+}
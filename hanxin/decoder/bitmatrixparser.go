@@ -0,0 +1,66 @@
+package decoder
+
+import (
+	"fmt"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+)
+
+// interleaveStride is the spacing with which codewords are spread over the
+// symbol: every 13th codeword is placed consecutively.
+const interleaveStride = 13
+
+// DataMaskFunc reports whether the module in the given 1-based row i and
+// column j is inverted by a data mask.
+type DataMaskFunc func(i, j int) bool
+
+// DataMasks holds the four Han Xin Code data masks, indexed by the mask
+// number in the function information. Mask 0 leaves the data unchanged.
+var DataMasks = [4]DataMaskFunc{
+	func(i, j int) bool { return false },
+	func(i, j int) bool { return (i+j)%2 == 0 },
+	func(i, j int) bool { return ((i+j)%3+j%3)%2 == 0 },
+	func(i, j int) bool { return (i%j+j%i+i%3+j%3)%2 == 0 },
+}
+
+// ReadCodewords reads the codewords from an upright symbol, undoing the data
+// mask and the interleaving. bits is not modified.
+func ReadCodewords(bits *bitutil.BitMatrix, version *Version, dataMask int) ([]byte, error) {
+	dimension := version.Dimension()
+	if bits.Width() != dimension || bits.Height() != dimension {
+		return nil, fmt.Errorf("%w: Han Xin symbol is %dx%d, want %dx%d",
+			zxinggo.ErrFormat, bits.Width(), bits.Height(), dimension, dimension)
+	}
+	functionPattern := version.BuildFunctionPattern()
+	mask := DataMasks[dataMask]
+
+	// Codewords fill the data modules row by row, most significant bit first.
+	interleaved := make([]byte, version.TotalCodewords)
+	bitsRead := 0
+	for y := 0; y < dimension && bitsRead < 8*len(interleaved); y++ {
+		for x := 0; x < dimension && bitsRead < 8*len(interleaved); x++ {
+			if functionPattern.Get(x, y) {
+				continue
+			}
+			if bits.Get(x, y) != mask(y+1, x+1) {
+				interleaved[bitsRead/8] |= 0x80 >> uint(bitsRead%8)
+			}
+			bitsRead++
+		}
+	}
+	if bitsRead != 8*len(interleaved) {
+		return nil, fmt.Errorf("%w: Han Xin symbol holds %d bits, want %d",
+			zxinggo.ErrFormat, bitsRead, 8*len(interleaved))
+	}
+
+	codewords := make([]byte, len(interleaved))
+	pos := 0
+	for start := 0; start < interleaveStride; start++ {
+		for i := start; i < len(codewords); i += interleaveStride {
+			codewords[i] = interleaved[pos]
+			pos++
+		}
+	}
+	return codewords, nil
+}
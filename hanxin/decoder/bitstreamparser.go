@@ -0,0 +1,301 @@
+package decoder
+
+import (
+	"fmt"
+	"strings"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/charset"
+	"github.com/ericlevine/zxinggo/internal"
+)
+
+// Mode indicators, 4 bits each.
+const (
+	modeTerminator = 0x0
+	modeNumeric    = 0x1
+	modeText       = 0x2
+	modeBinary     = 0x3
+	modeRegion1    = 0x4
+	modeRegion2    = 0x5
+	modeDoubleByte = 0x6
+	modeFourByte   = 0x7
+	modeECI        = 0x8
+	modeEnd        = 0xF
+)
+
+// Mode terminators and switches within segments.
+const (
+	// numericEnd to numericEnd+2 end a numeric segment whose last group has
+	// one to three digits.
+	numericEnd = 1021
+
+	textSwitch = 62
+	textEnd    = 63
+
+	regionSwitch = 0xFFE
+	regionEnd    = 0xFFF
+
+	doubleByteEnd = 0x7FFF
+)
+
+// DecodeBitStream decodes the data codewords of a symbol. Characters from
+// the GB 18030 modes, which between them cover all of Unicode, are returned
+// as UTF-8.
+func DecodeBitStream(bytes []byte, ecLevel ErrorCorrectionLevel, characterSet string) (*internal.DecodedPayload, error) {
+	bs := bitutil.NewBitSource(bytes)
+	var result strings.Builder
+	var byteSegments [][]byte
+	var currentCharacterSetECI *charset.ECI
+
+	for bs.Available() >= 4 {
+		mode, _ := bs.ReadBits(4)
+		if mode == modeTerminator || mode == modeEnd {
+			break
+		}
+		var err error
+		switch mode {
+		case modeNumeric:
+			err = decodeNumericSegment(bs, &result)
+		case modeText:
+			err = decodeTextSegment(bs, &result)
+		case modeBinary:
+			var seg []byte
+			seg, err = decodeBinarySegment(bs, &result, currentCharacterSetECI, characterSet)
+			if err == nil {
+				byteSegments = append(byteSegments, seg)
+			}
+		case modeRegion1, modeRegion2:
+			err = decodeRegionSegment(bs, &result, mode == modeRegion2)
+		case modeDoubleByte:
+			err = decodeDoubleByteSegment(bs, &result)
+		case modeFourByte:
+			err = decodeFourByteCharacter(bs, &result)
+		case modeECI:
+			var value int
+			value, err = parseECIValue(bs)
+			if err == nil {
+				currentCharacterSetECI, err = charset.GetECIByValue(value)
+				if err != nil {
+					err = fmt.Errorf("%w: unknown ECI %d", zxinggo.ErrFormat, value)
+				}
+			}
+		default:
+			err = fmt.Errorf("%w: invalid Han Xin mode %d", zxinggo.ErrFormat, mode)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return internal.NewDecodedPayload(bytes, result.String(), byteSegments, ecLevel.String()), nil
+}
+
+func readBits(bs *bitutil.BitSource, n int) (int, error) {
+	if bs.Available() < n {
+		return 0, fmt.Errorf("%w: Han Xin data ends mid-segment", zxinggo.ErrFormat)
+	}
+	return bs.ReadBits(n)
+}
+
+// decodeNumericSegment decodes digits in groups of three, 10 bits per group.
+// The terminator gives the number of digits in the last group.
+func decodeNumericSegment(bs *bitutil.BitSource, result *strings.Builder) error {
+	last := -1
+	for {
+		value, err := readBits(bs, 10)
+		if err != nil {
+			return err
+		}
+		switch {
+		case value < 1000:
+			if last >= 0 {
+				fmt.Fprintf(result, "%03d", last)
+			}
+			last = value
+		case value >= numericEnd:
+			digits := value - numericEnd + 1
+			if last < 0 || (digits == 1 && last > 9) || (digits == 2 && last > 99) {
+				return fmt.Errorf("%w: invalid Han Xin numeric segment", zxinggo.ErrFormat)
+			}
+			fmt.Fprintf(result, "%0*d", digits, last)
+			return nil
+		default:
+			return fmt.Errorf("%w: invalid Han Xin numeric value %d", zxinggo.ErrFormat, value)
+		}
+	}
+}
+
+// decodeTextSegment decodes 6-bit characters. Submode 1 holds digits and
+// letters; submode 2 holds control characters and punctuation.
+func decodeTextSegment(bs *bitutil.BitSource, result *strings.Builder) error {
+	submode1 := true
+	for {
+		value, err := readBits(bs, 6)
+		if err != nil {
+			return err
+		}
+		switch {
+		case value == textEnd:
+			return nil
+		case value == textSwitch:
+			submode1 = !submode1
+		case submode1:
+			switch {
+			case value < 10:
+				result.WriteByte(byte('0' + value))
+			case value < 36:
+				result.WriteByte(byte('A' + value - 10))
+			default:
+				result.WriteByte(byte('a' + value - 36))
+			}
+		default:
+			switch {
+			case value < 28:
+				result.WriteByte(byte(value))
+			case value < 44:
+				result.WriteByte(byte(' ' + value - 28))
+			case value < 51:
+				result.WriteByte(byte(':' + value - 44))
+			case value < 57:
+				result.WriteByte(byte('[' + value - 51))
+			default:
+				result.WriteByte(byte('{' + value - 57))
+			}
+		}
+	}
+}
+
+// decodeBinarySegment decodes a 13-bit byte count followed by the bytes.
+func decodeBinarySegment(bs *bitutil.BitSource, result *strings.Builder,
+	currentECI *charset.ECI, characterSet string) ([]byte, error) {
+	count, err := readBits(bs, 13)
+	if err != nil {
+		return nil, err
+	}
+	if 8*count > bs.Available() {
+		return nil, fmt.Errorf("%w: Han Xin binary segment overruns data", zxinggo.ErrFormat)
+	}
+	readBytes := make([]byte, count)
+	for i := range readBytes {
+		b, _ := bs.ReadBits(8)
+		readBytes[i] = byte(b)
+	}
+
+	var encoding string
+	if currentECI != nil {
+		encoding = currentECI.GoName
+	} else {
+		encoding = charset.GuessEncoding(readBytes, characterSet)
+	}
+	result.WriteString(charset.DecodeBytes(readBytes, encoding))
+	return readBytes, nil
+}
+
+// decodeRegionSegment decodes 12-bit GB 2312 characters from the common
+// Chinese character regions, switching between them as directed.
+func decodeRegionSegment(bs *bitutil.BitSource, result *strings.Builder, region2 bool) error {
+	var buf []byte
+	for {
+		value, err := readBits(bs, 12)
+		if err != nil {
+			return err
+		}
+		switch {
+		case value == regionEnd:
+			result.WriteString(charset.DecodeBytes(buf, "GB18030"))
+			return nil
+		case value == regionSwitch:
+			region2 = !region2
+		case region2:
+			if value >= 32*94 {
+				return fmt.Errorf("%w: invalid Han Xin region 2 value %d", zxinggo.ErrFormat, value)
+			}
+			buf = append(buf, byte(0xD8+value/94), byte(0xA1+value%94))
+		case value < 0xEB0:
+			// Level 1 hanzi.
+			buf = append(buf, byte(0xB0+value/94), byte(0xA1+value%94))
+		case value < 0xEB0+2*94+32:
+			// Symbols and full-width digits, 0xA1A1 to 0xA3C0.
+			value -= 0xEB0
+			buf = append(buf, byte(0xA1+value/94), byte(0xA1+value%94))
+		case value >= 0xFCA && value < 0xFCA+32:
+			// Pinyin.
+			buf = append(buf, 0xA8, byte(0xA1+value-0xFCA))
+		default:
+			return fmt.Errorf("%w: invalid Han Xin region 1 value %d", zxinggo.ErrFormat, value)
+		}
+	}
+}
+
+// decodeDoubleByteSegment decodes 15-bit GB 18030 two-byte characters.
+func decodeDoubleByteSegment(bs *bitutil.BitSource, result *strings.Builder) error {
+	var buf []byte
+	for {
+		value, err := readBits(bs, 15)
+		if err != nil {
+			return err
+		}
+		if value == doubleByteEnd {
+			result.WriteString(charset.DecodeBytes(buf, "GB18030"))
+			return nil
+		}
+		first, second := value/0xBE, value%0xBE
+		if first > 0xFE-0x81 {
+			return fmt.Errorf("%w: invalid Han Xin double-byte value %d", zxinggo.ErrFormat, value)
+		}
+		// Second bytes run from 0x40 to 0xFE, skipping 0x7F.
+		if second < 0x3F {
+			second += 0x40
+		} else {
+			second += 0x41
+		}
+		buf = append(buf, byte(0x81+first), byte(second))
+	}
+}
+
+// decodeFourByteCharacter decodes a single 21-bit GB 18030 four-byte
+// character.
+func decodeFourByteCharacter(bs *bitutil.BitSource, result *strings.Builder) error {
+	value, err := readBits(bs, 21)
+	if err != nil {
+		return err
+	}
+	b4 := value % 10
+	value /= 10
+	b3 := value % 126
+	value /= 126
+	b2 := value % 10
+	b1 := value / 10
+	if b1 > 0xFE-0x81 {
+		return fmt.Errorf("%w: invalid Han Xin four-byte value", zxinggo.ErrFormat)
+	}
+	buf := []byte{byte(0x81 + b1), byte(0x30 + b2), byte(0x81 + b3), byte(0x30 + b4)}
+	result.WriteString(charset.DecodeBytes(buf, "GB18030"))
+	return nil
+}
+
+func parseECIValue(bs *bitutil.BitSource) (int, error) {
+	firstByte, err := readBits(bs, 8)
+	if err != nil {
+		return 0, err
+	}
+	if firstByte&0x80 == 0 {
+		return firstByte, nil
+	}
+	if firstByte&0xC0 == 0x80 {
+		secondByte, err := readBits(bs, 8)
+		if err != nil {
+			return 0, err
+		}
+		return (firstByte&0x3F)<<8 | secondByte, nil
+	}
+	if firstByte&0xE0 == 0xC0 {
+		rest, err := readBits(bs, 16)
+		if err != nil {
+			return 0, err
+		}
+		return (firstByte&0x1F)<<16 | rest, nil
+	}
+	return 0, fmt.Errorf("%w: invalid ECI designator", zxinggo.ErrFormat)
+}
@@ -0,0 +1,81 @@
+package decoder
+
+import (
+	"fmt"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/internal"
+	"github.com/ericlevine/zxinggo/reedsolomon"
+)
+
+// Decoder decodes Han Xin Code symbols.
+type Decoder struct {
+	rsDecoder *reedsolomon.Decoder
+}
+
+// NewDecoder creates a new Han Xin Code Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{
+		rsDecoder: reedsolomon.NewDecoder(reedsolomon.HanXinField256),
+	}
+}
+
+// Decode decodes an upright Han Xin Code module grid with no quiet zone.
+func (d *Decoder) Decode(bits *bitutil.BitMatrix, characterSet string) (*internal.DecodedPayload, error) {
+	fi, err := ReadFunctionInformation(bits)
+	if err != nil {
+		return nil, err
+	}
+	version, err := VersionForNumber(fi.Version)
+	if err != nil {
+		return nil, err
+	}
+	if version.ECBlocksForLevel(fi.ECLevel) == nil {
+		return nil, fmt.Errorf("%w: Han Xin version %d is not supported", zxinggo.ErrFormat, fi.Version)
+	}
+	codewords, err := ReadCodewords(bits, version, fi.DataMask)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each block's data codewords are followed by its error correction
+	// codewords, and the blocks follow one another.
+	var data []byte
+	errorsCorrected := 0
+	offset := 0
+	for _, ecb := range version.ECBlocksForLevel(fi.ECLevel) {
+		for i := 0; i < ecb.Count; i++ {
+			block := codewords[offset : offset+ecb.DataCodewords+ecb.ECCodewords]
+			corrected, err := d.correctErrors(block, ecb.ECCodewords)
+			if err != nil {
+				return nil, err
+			}
+			errorsCorrected += corrected
+			data = append(data, block[:ecb.DataCodewords]...)
+			offset += len(block)
+		}
+	}
+
+	result, err := DecodeBitStream(data, fi.ECLevel, characterSet)
+	if err != nil {
+		return nil, err
+	}
+	result.ErrorsCorrected = errorsCorrected
+	return result, nil
+}
+
+func (d *Decoder) correctErrors(block []byte, numECCodewords int) (int, error) {
+	ints := make([]int, len(block))
+	for i, b := range block {
+		ints[i] = int(b)
+	}
+	corrected, err := d.rsDecoder.Decode(ints, numECCodewords)
+	if err != nil {
+		return 0, zxinggo.ErrChecksum
+	}
+	for i := range block {
+		block[i] = byte(ints[i])
+	}
+	return corrected, nil
+}
@@ -0,0 +1,83 @@
+package decoder
+
+import (
+	"fmt"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/reedsolomon"
+)
+
+const (
+	// functionInfoBits is the number of bits in each copy of the function
+	// information: seven 4-bit codewords followed by six padding bits.
+	functionInfoBits = 34
+
+	functionInfoCodewords   = 7
+	functionInfoECCodewords = 4
+
+	// versionOffset is added to the version number when it is encoded.
+	versionOffset = 20
+)
+
+// FunctionInformation is the version, error correction level and data mask
+// recorded beside the finder patterns.
+type FunctionInformation struct {
+	Version  int
+	ECLevel  ErrorCorrectionLevel
+	DataMask int
+}
+
+// functionInfoPosition returns the module holding bit k of the given copy of
+// the function information. The first copy runs along the inner edges of the
+// top-left and top-right finder patterns; the second is the first rotated
+// 180 degrees, beside the bottom-right and bottom-left finder patterns.
+func functionInfoPosition(k, dimension, copyIndex int) (x, y int) {
+	switch {
+	case k <= 8:
+		x, y = k, 8
+	case k <= 16:
+		x, y = 8, 16-k
+	case k <= 24:
+		x, y = dimension-9, k-17
+	default:
+		x, y = dimension-34+k, 8
+	}
+	if copyIndex == 1 {
+		x, y = dimension-1-x, dimension-1-y
+	}
+	return x, y
+}
+
+// ReadFunctionInformation reads and error-corrects the function information,
+// trying each copy in turn.
+func ReadFunctionInformation(bits *bitutil.BitMatrix) (*FunctionInformation, error) {
+	dimension := bits.Height()
+	rsDecoder := reedsolomon.NewDecoder(reedsolomon.HanXinFunction)
+	err := error(zxinggo.ErrFormat)
+	for copyIndex := 0; copyIndex < 2; copyIndex++ {
+		codewords := make([]int, functionInfoCodewords)
+		for k := 0; k < 4*functionInfoCodewords; k++ {
+			x, y := functionInfoPosition(k, dimension, copyIndex)
+			codewords[k/4] <<= 1
+			if bits.Get(x, y) {
+				codewords[k/4] |= 1
+			}
+		}
+		if _, rsErr := rsDecoder.Decode(codewords, functionInfoECCodewords); rsErr != nil {
+			err = fmt.Errorf("%w: Han Xin function information: %v", zxinggo.ErrChecksum, rsErr)
+			continue
+		}
+		version := (codewords[0]<<4 | codewords[1]) - versionOffset
+		if version < 1 || version > MaxVersion || DimensionForVersion(version) != dimension {
+			err = fmt.Errorf("%w: Han Xin version %d does not match %d modules", zxinggo.ErrFormat, version, dimension)
+			continue
+		}
+		return &FunctionInformation{
+			Version:  version,
+			ECLevel:  ErrorCorrectionLevel(codewords[2] >> 2),
+			DataMask: codewords[2] & 0x03,
+		}, nil
+	}
+	return nil, err
+}
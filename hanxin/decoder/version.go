@@ -0,0 +1,263 @@
+// Package decoder implements Han Xin Code decoding.
+package decoder
+
+import (
+	"fmt"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+)
+
+// MaxVersion is the largest Han Xin Code version, 189x189 modules.
+const MaxVersion = 84
+
+// ErrorCorrectionLevel is one of the four Han Xin Code error correction
+// levels, L1 (about 8% recovery) to L4 (about 30%).
+type ErrorCorrectionLevel int
+
+const (
+	ECLevelL1 ErrorCorrectionLevel = iota
+	ECLevelL2
+	ECLevelL3
+	ECLevelL4
+)
+
+// String returns the level name.
+func (ecl ErrorCorrectionLevel) String() string {
+	return fmt.Sprintf("L%d", int(ecl)+1)
+}
+
+// ECB describes Count error correction blocks of the same shape.
+type ECB struct {
+	Count         int
+	DataCodewords int
+	ECCodewords   int
+}
+
+// Version describes a Han Xin Code version.
+type Version struct {
+	Number         int
+	TotalCodewords int
+	ECBlocksArray  [4][]ECB // L1, L2, L3, L4; empty above version 3
+}
+
+// totalCodewords is the number of codewords each version holds, from
+// Table B.1 of the standard.
+var totalCodewords = [MaxVersion]int{
+	25, 37, 50, 54, 69, 84, 100, 117, 136, 155, 161, 181, 203, 225, 249,
+	273, 299, 325, 353, 381, 411, 422, 453, 485, 518, 552, 587, 623, 660,
+	698, 737, 754, 794, 836, 878, 922, 966, 1011, 1058, 1105, 1126, 1175,
+	1224, 1275, 1327, 1380, 1434, 1489, 1513, 1569, 1628, 1686, 1745, 1805,
+	1867, 1929, 1992, 2021, 2086, 2151, 2218, 2286, 2355, 2425, 2496, 2528,
+	2600, 2673, 2749, 2824, 2900, 2977, 3056, 3135, 3171, 3252, 3334, 3416,
+	3500, 3585, 3671, 3758, 3798, 3886,
+}
+
+// ecBlocks holds the error correction blocks of versions 1 to 3, from Table
+// D.1 of the standard. The blocks of larger versions are not transcribed
+// here, so those versions are located and sampled but not decoded.
+var ecBlocks = [][4][]ECB{
+	{{{1, 21, 4}}, {{1, 17, 8}}, {{1, 13, 12}}, {{1, 9, 16}}},
+	{{{1, 31, 6}}, {{1, 25, 12}}, {{1, 19, 18}}, {{1, 15, 22}}},
+	{{{1, 42, 8}}, {{1, 34, 16}}, {{1, 26, 24}}, {{1, 20, 30}}},
+}
+
+// alignmentK, alignmentR and alignmentM are the values k, r and m of Annex A
+// for versions 4 and up, which have alignment patterns. Counting rows from
+// the top and columns from the right, the alignment patterns meet at m
+// intervals of k modules and then one of r-1 modules to the far edge.
+var (
+	alignmentK = [MaxVersion]int{
+		0, 0, 0, 14, 16, 16, 17, 18, 19, 20, 14, 15, 16, 16, 17, 17, 18,
+		19, 20, 20, 21, 16, 17, 17, 18, 18, 19, 19, 20, 20, 21, 17, 17, 18,
+		18, 19, 19, 19, 20, 20, 17, 17, 18, 18, 18, 19, 19, 19, 17, 17, 18,
+		18, 18, 18, 19, 19, 19, 17, 17, 18, 18, 18, 18, 19, 19, 17, 17, 17,
+		18, 18, 18, 18, 19, 19, 17, 17, 17, 18, 18, 18, 18, 18, 17, 17,
+	}
+	alignmentR = [MaxVersion]int{
+		0, 0, 0, 15, 15, 17, 18, 19, 20, 21, 15, 15, 15, 17, 17, 19, 19,
+		19, 19, 21, 21, 17, 16, 18, 17, 19, 18, 20, 19, 21, 20, 17, 19, 17,
+		19, 17, 19, 21, 19, 21, 18, 20, 17, 19, 21, 18, 20, 22, 17, 19, 15,
+		17, 19, 21, 17, 19, 21, 18, 20, 15, 17, 19, 21, 16, 18, 17, 19, 21,
+		15, 17, 19, 21, 15, 17, 18, 20, 22, 15, 17, 19, 21, 23, 17, 19,
+	}
+	alignmentM = [MaxVersion]int{
+		0, 0, 0, 1, 1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 3,
+		3, 3, 3, 3, 3, 3, 3, 3, 3, 4, 4, 4, 4, 4, 4, 4, 4, 4, 5, 5, 5, 5,
+		5, 5, 5, 5, 6, 6, 6, 6, 6, 6, 6, 6, 6, 7, 7, 7, 7, 7, 7, 7, 7, 8,
+		8, 8, 8, 8, 8, 8, 8, 8, 9, 9, 9, 9, 9, 9, 9, 9, 10, 10,
+	}
+)
+
+// DimensionForVersion returns the number of modules along each side of a
+// symbol of the given version.
+func DimensionForVersion(number int) int {
+	return 21 + 2*number
+}
+
+// Dimension returns the number of modules along each side of the symbol.
+func (v *Version) Dimension() int {
+	return DimensionForVersion(v.Number)
+}
+
+// ECBlocksForLevel returns the error correction blocks for the given level,
+// or nil if they are not known.
+func (v *Version) ECBlocksForLevel(ecLevel ErrorCorrectionLevel) []ECB {
+	return v.ECBlocksArray[ecLevel]
+}
+
+// VersionForNumber returns the version with the given number. Only versions
+// 1 to 3 have error correction blocks.
+func VersionForNumber(number int) (*Version, error) {
+	if number < 1 || number > MaxVersion {
+		return nil, fmt.Errorf("%w: invalid Han Xin version %d", zxinggo.ErrFormat, number)
+	}
+	v := &Version{Number: number, TotalCodewords: totalCodewords[number-1]}
+	if number <= len(ecBlocks) {
+		v.ECBlocksArray = ecBlocks[number-1]
+	}
+	return v, nil
+}
+
+// AlignmentLines returns where the alignment patterns meet, as offsets in
+// modules that are rows counted from the top and columns counted from the
+// right. It returns nil for versions 1 to 3, which have none.
+func (v *Version) AlignmentLines() []int {
+	k, r, m := alignmentK[v.Number-1], alignmentR[v.Number-1], alignmentM[v.Number-1]
+	if m == 0 {
+		return nil
+	}
+	lines := make([]int, 0, m+2)
+	for i := 0; i <= m; i++ {
+		lines = append(lines, i*k)
+	}
+	return append(lines, m*k+r-1)
+}
+
+// BuildFunctionPattern returns a matrix with the finder patterns, their
+// separators, the function information and the alignment patterns set.
+func (v *Version) BuildFunctionPattern() *bitutil.BitMatrix {
+	function, _ := v.buildPatterns()
+	return function
+}
+
+// AlignmentPattern returns a matrix with the dark modules of the alignment
+// patterns set.
+func (v *Version) AlignmentPattern() *bitutil.BitMatrix {
+	_, dark := v.buildPatterns()
+	return dark
+}
+
+// buildPatterns lays out the function modules as Annex A does, and returns
+// them along with the dark modules of the alignment patterns. Each module
+// belongs to the first pattern that reaches it.
+func (v *Version) buildPatterns() (function, dark *bitutil.BitMatrix) {
+	dimension := v.Dimension()
+	function = bitutil.NewBitMatrix(dimension)
+	dark = bitutil.NewBitMatrix(dimension)
+	function.SetRegion(0, 0, 9, 9)
+	function.SetRegion(dimension-9, 0, 9, 9)
+	function.SetRegion(0, dimension-9, 9, 9)
+	function.SetRegion(dimension-9, dimension-9, 9, 9)
+	lines := v.AlignmentLines()
+	if lines == nil {
+		return function, dark
+	}
+
+	plot := func(x, y int, isDark bool) {
+		if x < 0 || y < 0 || x >= dimension || y >= dimension || function.Get(x, y) {
+			return
+		}
+		function.Set(x, y)
+		if isDark {
+			dark.Set(x, y)
+		}
+	}
+	// An assistant pattern is a dark module ringed by light ones.
+	assistant := func(x, y int) {
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				plot(x+dx, y+dy, dx == 0 && dy == 0)
+			}
+		}
+	}
+	// Assistant patterns mark the ends of the lines along the edges, where
+	// no alignment pattern meets the edge.
+	m := len(lines) - 2
+	for i, offset := range lines {
+		if i%2 != m%2 {
+			assistant(0, offset)
+			assistant(dimension-1-offset, dimension-1)
+		}
+		if i%2 == 1 {
+			assistant(dimension-1, offset)
+			assistant(dimension-1-offset, 0)
+		}
+	}
+	// An alignment pattern is a dark line along the top and right of a
+	// block, bordered by a light line inside it, on every other block.
+	for row, top := range lines {
+		for column, right := range lines {
+			if (row+column)%2 != 0 || row == 0 && column == 0 {
+				continue
+			}
+			x, y := dimension-1-right, top
+			width, height := alignmentK[v.Number-1], alignmentK[v.Number-1]
+			if column >= m {
+				width = lines[m+1] - lines[m]
+			}
+			if row >= m {
+				height = lines[m+1] - lines[m]
+			}
+			plot(x, y, true)
+			plot(x-1, y+1, false)
+			for i := 1; i <= width; i++ {
+				plot(x-i, y, true)
+				plot(x-i-1, y+1, false)
+			}
+			for i := 1; i < height; i++ {
+				plot(x, y+i, true)
+				plot(x-1, y+i+1, false)
+			}
+		}
+	}
+	return function, dark
+}
+
+// finderTopLeft is the top-left finder pattern, one row per entry with the
+// leftmost module in bit 6. The top-right pattern is its mirror image, the
+// bottom-left pattern is a copy of the top-right one, and the bottom-right
+// pattern is the top-left one rotated 180 degrees.
+var finderTopLeft = [7]int{0x7F, 0x40, 0x5F, 0x50, 0x57, 0x57, 0x57}
+
+// FinderModule reports whether the module at (x, y), which must lie within
+// one of the 7x7 finder patterns of a symbol of the given dimension, is dark.
+func FinderModule(x, y, dimension int) bool {
+	switch {
+	case x < 7 && y < 7:
+	case y < 7:
+		x = dimension - 1 - x
+	case x < 7:
+		x, y = 6-x, y-(dimension-7)
+	default:
+		x, y = dimension-1-x, dimension-1-y
+	}
+	return finderTopLeft[y]&(0x40>>uint(x)) != 0
+}
+
+// FinderMismatches counts the modules of the four finder patterns of an
+// upright symbol that differ from what they should be.
+func FinderMismatches(bits *bitutil.BitMatrix) int {
+	dimension := bits.Height()
+	mismatches := 0
+	for _, corner := range [][2]int{{0, 0}, {dimension - 7, 0}, {0, dimension - 7}, {dimension - 7, dimension - 7}} {
+		for y := corner[1]; y < corner[1]+7; y++ {
+			for x := corner[0]; x < corner[0]+7; x++ {
+				if bits.Get(x, y) != FinderModule(x, y, dimension) {
+					mismatches++
+				}
+			}
+		}
+	}
+	return mismatches
+}
@@ -0,0 +1,679 @@
+// Package detector implements Han Xin Code detection in binary images.
+//
+// A Han Xin Code symbol has a 7x7 finder pattern in each corner, made of two
+// nested L shapes around a 3x3 block. A line through the block parallel to a
+// side of the symbol crosses a dark, light, dark and light module and then
+// the block, in the ratio 1:1:1:1:3 starting from the corner of the Ls. The
+// patterns at the top-left, top-right and bottom-right corners of the symbol
+// have the corner of their Ls outermost; the bottom-left pattern has it
+// innermost, which fixes the orientation of the symbol.
+package detector
+
+import (
+	"math"
+	"sort"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/hanxin/decoder"
+	"github.com/ericlevine/zxinggo/transform"
+)
+
+const (
+	minSkip    = 3
+	maxModules = 189
+
+	// maxFinderMismatches is the number of modules of a finder pattern that
+	// may differ from what they should be.
+	maxFinderMismatches = 12
+
+	// maxCandidates bounds the number of finder patterns considered when
+	// looking for a set of four.
+	maxCandidates = 12
+
+	// alignmentRadius is how far, in modules, the modules around where two
+	// alignment pattern lines meet are compared with the pattern, and
+	// alignmentSearch is how far from where it is expected the meeting
+	// point is looked for, in steps of alignmentStep modules.
+	alignmentRadius = 3
+	alignmentSearch = 2.0
+	alignmentStep   = 0.25
+)
+
+// FinderPattern is a located finder pattern.
+type FinderPattern struct {
+	X, Y                float64
+	EstimatedModuleSize float64
+	// DirX and DirY are -1 or +1, pointing from the centre of the pattern
+	// towards the corner of its Ls in image coordinates.
+	DirX, DirY int
+	Count      int
+}
+
+func (fp *FinderPattern) aboutEquals(other *FinderPattern) bool {
+	if fp.DirX != other.DirX || fp.DirY != other.DirY {
+		return false
+	}
+	if math.Abs(other.X-fp.X) > fp.EstimatedModuleSize || math.Abs(other.Y-fp.Y) > fp.EstimatedModuleSize {
+		return false
+	}
+	moduleSizeDiff := math.Abs(other.EstimatedModuleSize - fp.EstimatedModuleSize)
+	return moduleSizeDiff <= 1.0 || moduleSizeDiff <= fp.EstimatedModuleSize
+}
+
+func (fp *FinderPattern) combine(other *FinderPattern) {
+	n := float64(fp.Count)
+	fp.X = (n*fp.X + other.X) / (n + 1)
+	fp.Y = (n*fp.Y + other.Y) / (n + 1)
+	fp.EstimatedModuleSize = (n*fp.EstimatedModuleSize + other.EstimatedModuleSize) / (n + 1)
+	fp.Count++
+}
+
+// Detect locates a Han Xin Code symbol and samples its module grid. The
+// result's points are the centres of the 3x3 blocks of the top-left,
+// top-right, bottom-right and bottom-left finder patterns; see ModuleCenters.
+func Detect(image *bitutil.BitMatrix, tryHarder bool) (*zxinggo.DetectorResult, error) {
+	patterns := findFinderPatterns(image, tryHarder)
+	corners, dimension, err := selectFinderPatterns(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	m := ModuleCenters(dimension)
+	p := corners
+	xform := transform.QuadrilateralToQuadrilateral(
+		m[0], m[1], m[2], m[3], m[4], m[5], m[6], m[7],
+		p[0].X, p[0].Y, p[1].X, p[1].Y, p[2].X, p[2].Y, p[3].X, p[3].Y)
+	version, err := decoder.VersionForNumber((dimension - 21) / 2)
+	if err != nil {
+		return nil, zxinggo.ErrNotFound
+	}
+	var bits *bitutil.BitMatrix
+	if version.AlignmentLines() == nil {
+		bits, err = (&transform.DefaultGridSampler{}).SampleGridTransform(image, dimension, dimension, xform)
+	} else {
+		moduleSize := (p[0].EstimatedModuleSize + p[1].EstimatedModuleSize +
+			p[2].EstimatedModuleSize + p[3].EstimatedModuleSize) / 4
+		bits, err = sampleAligned(image, version, xform, moduleSize)
+	}
+	if err != nil {
+		return nil, zxinggo.ErrNotFound
+	}
+	points := make([]zxinggo.ResultPoint, 4)
+	for i, fp := range corners {
+		points[i] = zxinggo.ResultPoint{X: fp.X, Y: fp.Y}
+	}
+	return zxinggo.NewDetectorResult(bits, points), nil
+}
+
+// sampleAligned samples a symbol with alignment patterns. xform, fitted to
+// the finder patterns, maps module coordinates to the image. Each point where
+// alignment pattern lines meet is looked for near where xform puts it, and
+// how far it is found from there measures the distortion that xform misses,
+// as on a curved surface. Every module is sampled moved by the distortion
+// interpolated from the meeting points around it.
+func sampleAligned(image *bitutil.BitMatrix, version *decoder.Version, xform *transform.PerspectiveTransform, moduleSize float64) (*bitutil.BitMatrix, error) {
+	dimension := version.Dimension()
+	lines := version.AlignmentLines()
+	function := version.BuildFunctionPattern()
+	dark := version.AlignmentPattern()
+	centers := xform.BuildAdjusted(0.5, 0.5)
+
+	// shifts holds the distortion, in pixels, where row line i meets
+	// column line j, which are counted from the right.
+	n := len(lines)
+	shifts := make([][][2]float64, n)
+	known := make([][]bool, n)
+	for i := range shifts {
+		shifts[i] = make([][2]float64, n)
+		known[i] = make([]bool, n)
+	}
+	for i, top := range lines {
+		for j, right := range lines {
+			x, y := dimension-1-right, top
+			if inFinderRegion(x, y, dimension) {
+				continue
+			}
+			// Start from the distortion found above and to the right.
+			var guess [2]float64
+			count := 0
+			for _, nb := range [][2]int{{i - 1, j}, {i, j - 1}} {
+				if nb[0] >= 0 && nb[1] >= 0 && known[nb[0]][nb[1]] {
+					guess[0] += shifts[nb[0]][nb[1]][0]
+					guess[1] += shifts[nb[0]][nb[1]][1]
+					count++
+				}
+			}
+			if count > 0 {
+				guess[0] /= float64(count)
+				guess[1] /= float64(count)
+			}
+			if shift, ok := findAlignment(image, function, dark, centers, x, y, guess, moduleSize); ok {
+				shifts[i][j], known[i][j] = shift, true
+			}
+		}
+	}
+	fillShifts(shifts, known)
+
+	bits := bitutil.NewBitMatrix(dimension)
+	for y := 0; y < dimension; y++ {
+		i := lineIndex(lines, y)
+		fy := float64(y-lines[i]) / float64(lines[i+1]-lines[i])
+		for x := 0; x < dimension; x++ {
+			j := lineIndex(lines, dimension-1-x)
+			fx := float64(dimension-1-x-lines[j]) / float64(lines[j+1]-lines[j])
+			px, py := centers.Transform(float64(x), float64(y))
+			for k, w := range [4]float64{(1 - fy) * (1 - fx), (1 - fy) * fx, fy * (1 - fx), fy * fx} {
+				s := shifts[i+k/2][j+k%2]
+				px += w * s[0]
+				py += w * s[1]
+			}
+			ix, iy := int(px), int(py)
+			if px < 0 || py < 0 || ix >= image.Width() || iy >= image.Height() {
+				return nil, zxinggo.ErrNotFound
+			}
+			if image.Get(ix, iy) {
+				bits.Set(x, y)
+			}
+		}
+	}
+	return bits, nil
+}
+
+// findAlignment looks for where the alignment pattern lines meeting at
+// module (x, y) are, trying shifts around guess and comparing the modules
+// nearby whose colour is known, those of the alignment patterns and of the
+// quiet zone, with what they should be. The shifts that match best form a
+// plateau about a module wide; it returns the middle of the one nearest
+// guess, if they match well enough.
+func findAlignment(image, function, dark *bitutil.BitMatrix, centers *transform.PerspectiveTransform,
+	x, y int, guess [2]float64, moduleSize float64) ([2]float64, bool) {
+	dimension := function.Width()
+	var points []float64
+	var want []bool
+	for ty := y - alignmentRadius; ty <= y+alignmentRadius; ty++ {
+		for tx := x - alignmentRadius; tx <= x+alignmentRadius; tx++ {
+			inside := tx >= 0 && ty >= 0 && tx < dimension && ty < dimension
+			if inside && (!function.Get(tx, ty) || inFinderRegion(tx, ty, dimension)) {
+				continue
+			}
+			points = append(points, float64(tx), float64(ty))
+			want = append(want, inside && dark.Get(tx, ty))
+		}
+	}
+	centers.TransformPoints(points)
+
+	steps := int(alignmentSearch / alignmentStep)
+	size := 2*steps + 1
+	mismatches := make([]int, size*size)
+	best := len(want)/4 + 1
+	for sy := -steps; sy <= steps; sy++ {
+		for sx := -steps; sx <= steps; sx++ {
+			dx := guess[0] + float64(sx)*alignmentStep*moduleSize
+			dy := guess[1] + float64(sy)*alignmentStep*moduleSize
+			count := 0
+			for k, isDark := range want {
+				px, py := points[2*k]+dx, points[2*k+1]+dy
+				if px < 0 || py < 0 || int(px) >= image.Width() || int(py) >= image.Height() ||
+					image.Get(int(px), int(py)) != isDark {
+					count++
+				}
+			}
+			mismatches[(sy+steps)*size+sx+steps] = count
+			best = min(best, count)
+		}
+	}
+
+	nearest := -1
+	for i, count := range mismatches {
+		if count == best && (nearest < 0 || stepDistance(i, steps) < stepDistance(nearest, steps)) {
+			nearest = i
+		}
+	}
+	if nearest < 0 {
+		return [2]float64{}, false
+	}
+	var sum [2]float64
+	count := 0
+	plateau := int(1 / alignmentStep)
+	for i, c := range mismatches {
+		if c == best && abs(i%size-nearest%size) <= plateau && abs(i/size-nearest/size) <= plateau {
+			sum[0] += float64(i%size - steps)
+			sum[1] += float64(i/size - steps)
+			count++
+		}
+	}
+	return [2]float64{
+		guess[0] + sum[0]/float64(count)*alignmentStep*moduleSize,
+		guess[1] + sum[1]/float64(count)*alignmentStep*moduleSize,
+	}, true
+}
+
+// stepDistance returns the squared distance from the middle of the search
+// of findAlignment to the shift at index i.
+func stepDistance(i, steps int) int {
+	size := 2*steps + 1
+	dx, dy := i%size-steps, i/size-steps
+	return dx*dx + dy*dy
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// fillShifts gives each meeting point whose distortion is not known the mean
+// of its known neighbours', until all are known.
+func fillShifts(shifts [][][2]float64, known [][]bool) {
+	n := len(shifts)
+	for {
+		var filled [][2]int
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				if known[i][j] {
+					continue
+				}
+				var sum [2]float64
+				count := 0
+				for _, nb := range [][2]int{{i - 1, j}, {i + 1, j}, {i, j - 1}, {i, j + 1}} {
+					if nb[0] >= 0 && nb[1] >= 0 && nb[0] < n && nb[1] < n && known[nb[0]][nb[1]] {
+						sum[0] += shifts[nb[0]][nb[1]][0]
+						sum[1] += shifts[nb[0]][nb[1]][1]
+						count++
+					}
+				}
+				if count > 0 {
+					shifts[i][j] = [2]float64{sum[0] / float64(count), sum[1] / float64(count)}
+					filled = append(filled, [2]int{i, j})
+				}
+			}
+		}
+		if len(filled) == 0 {
+			return
+		}
+		for _, p := range filled {
+			known[p[0]][p[1]] = true
+		}
+	}
+}
+
+// inFinderRegion reports whether module (x, y) is in one of the 9x9 corners
+// that hold a finder pattern, its separator and function information.
+func inFinderRegion(x, y, dimension int) bool {
+	return (x < 9 || x >= dimension-9) && (y < 9 || y >= dimension-9)
+}
+
+// lineIndex returns the index of the last line at or before offset, short
+// of the last line.
+func lineIndex(lines []int, offset int) int {
+	i := 0
+	for i+2 < len(lines) && lines[i+1] <= offset {
+		i++
+	}
+	return i
+}
+
+// ModuleCenters returns the centres of the 3x3 blocks of the top-left,
+// top-right, bottom-right and bottom-left finder patterns in module
+// coordinates, as x, y pairs. The bottom-left pattern faces into the symbol,
+// so its block is in the symbol's corner.
+func ModuleCenters(dimension int) [8]float64 {
+	far := float64(dimension) - 5.5
+	return [8]float64{5.5, 5.5, far, 5.5, far, far, 1.5, float64(dimension) - 1.5}
+}
+
+// findFinderPatterns scans rows for the 1:1:1:1:3 pattern in either
+// direction, confirms each hit with vertical and horizontal cross-checks
+// through the 3x3 block, and merges hits on the same pattern.
+func findFinderPatterns(image *bitutil.BitMatrix, tryHarder bool) []*FinderPattern {
+	height := image.Height()
+	width := image.Width()
+	skip := (3 * height) / (4 * maxModules)
+	if skip < minSkip || tryHarder {
+		skip = minSkip
+	}
+
+	var patterns []*FinderPattern
+	var runs []int
+	for y := skip - 1; y < height; y += skip {
+		// Collect the run lengths of the row, starting with a light run.
+		runs = runs[:0]
+		x := 0
+		for color := false; x < width; color = !color {
+			start := x
+			for x < width && image.Get(x, y) == color {
+				x++
+			}
+			runs = append(runs, x-start)
+		}
+
+		pos := runs[0]
+		for i := 1; i+4 < len(runs); i += 2 {
+			if threeStart, ok := matchPattern(runs[i : i+5]); ok {
+				start := pos
+				for _, r := range runs[i : i+threeStart] {
+					start += r
+				}
+				centerX := start + runs[i+threeStart]/2
+				if fp := crossCheck(image, centerX, y); fp != nil {
+					merged := false
+					for _, p := range patterns {
+						if p.aboutEquals(fp) {
+							p.combine(fp)
+							merged = true
+							break
+						}
+					}
+					if !merged {
+						patterns = append(patterns, fp)
+					}
+				}
+			}
+			pos += runs[i] + runs[i+1]
+		}
+	}
+	return patterns
+}
+
+// matchPattern reports whether five runs, dark first, are in the ratio
+// 1:1:1:1:3 or 3:1:1:1:1, and returns the index of the wide run.
+func matchPattern(runs []int) (int, bool) {
+	total := 0
+	for _, r := range runs {
+		if r == 0 {
+			return 0, false
+		}
+		total += r
+	}
+	if total < 7 {
+		return 0, false
+	}
+	moduleSize := float64(total) / 7
+	for _, threeIndex := range []int{4, 0} {
+		ok := true
+		for i, r := range runs {
+			expected := moduleSize
+			if i == threeIndex {
+				expected = 3 * moduleSize
+			}
+			if math.Abs(float64(r)-expected) >= expected/2 {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return threeIndex, true
+		}
+	}
+	return 0, false
+}
+
+// crossCheck confirms a finder pattern whose 3x3 block contains (x, y). It
+// finds the middle of the block with vertical, horizontal and again vertical
+// lines through it, and since the narrow runs may appear to match on both
+// sides of the block, checks each possible orientation against the whole
+// pattern.
+func crossCheck(image *bitutil.BitMatrix, x, y int) *FinderPattern {
+	v, ok := crossCheckLine(func(p int) bool { return image.Get(x, p) }, y, image.Height())
+	if !ok {
+		return nil
+	}
+	vMid := (v.blockStart + v.blockEnd) / 2
+	h, ok := crossCheckLine(func(p int) bool { return image.Get(p, vMid) }, x, image.Width())
+	if !ok {
+		return nil
+	}
+	hMid := (h.blockStart + h.blockEnd) / 2
+	v, ok = crossCheckLine(func(p int) bool { return image.Get(hMid, p) }, vMid, image.Height())
+	if !ok {
+		return nil
+	}
+	centerX := float64(h.blockStart+h.blockEnd) / 2
+	centerY := float64(v.blockStart+v.blockEnd) / 2
+
+	var best *FinderPattern
+	bestMismatches := maxFinderMismatches + 1
+	for _, dirX := range h.sides() {
+		for _, dirY := range v.sides() {
+			left, right := h.extent(dirX)
+			top, bottom := v.extent(dirY)
+			hSize := float64(right-left) / 7
+			vSize := float64(bottom-top) / 7
+			if hSize > 2*vSize || vSize > 2*hSize {
+				continue
+			}
+			// Module (c, r) of the pattern, counted from its corner, is
+			// 5-c and 5-r modules from the middle of the block. The top-left
+			// pattern is symmetric about its diagonal, so it serves for any
+			// pattern seen from its corner.
+			mismatches := 0
+			for r := 0; r < 7; r++ {
+				for c := 0; c < 7; c++ {
+					px := centerX + float64(dirX*(5-c))*hSize
+					py := centerY + float64(dirY*(5-r))*vSize
+					if image.Get(int(px), int(py)) != decoder.FinderModule(c, r, 0) {
+						mismatches++
+					}
+				}
+			}
+			if mismatches < bestMismatches {
+				bestMismatches = mismatches
+				best = &FinderPattern{
+					X:                   centerX,
+					Y:                   centerY,
+					EstimatedModuleSize: (hSize + vSize) / 2,
+					DirX:                dirX,
+					DirY:                dirY,
+					Count:               1,
+				}
+			}
+		}
+	}
+	return best
+}
+
+// lineCheck is a finder pattern measured along one line through its block.
+type lineCheck struct {
+	blockStart, blockEnd int
+	// outer holds, for the sides before and after the block, the far end of
+	// the narrow runs, or -1 if they do not match.
+	outer [2]int
+}
+
+// sides returns the directions, -1 or +1, in which the narrow runs match.
+func (lc *lineCheck) sides() []int {
+	var sides []int
+	if lc.outer[0] >= 0 {
+		sides = append(sides, -1)
+	}
+	if lc.outer[1] >= 0 {
+		sides = append(sides, 1)
+	}
+	return sides
+}
+
+// extent returns the span of the pattern with its narrow runs on the given
+// side.
+func (lc *lineCheck) extent(side int) (start, end int) {
+	if side < 0 {
+		return lc.outer[0], lc.blockEnd
+	}
+	return lc.blockStart, lc.outer[1]
+}
+
+// crossCheckLine measures a finder pattern along a line, given a dark
+// position p within its 3x3 block, by looking for dark, light, dark and
+// light runs of a third of the block's width on either side of the block.
+func crossCheckLine(get func(int) bool, p, limit int) (*lineCheck, bool) {
+	if !get(p) {
+		return nil, false
+	}
+	lc := &lineCheck{blockStart: p, blockEnd: p + 1, outer: [2]int{-1, -1}}
+	for lc.blockStart > 0 && get(lc.blockStart-1) {
+		lc.blockStart--
+	}
+	for lc.blockEnd < limit && get(lc.blockEnd) {
+		lc.blockEnd++
+	}
+	block := lc.blockEnd - lc.blockStart
+
+	found := false
+	for i, side := range []int{-1, 1} {
+		pos := lc.blockStart - 1
+		if side > 0 {
+			pos = lc.blockEnd
+		}
+		var runs [4]int
+		color := false
+		for k := range runs {
+			for pos >= 0 && pos < limit && get(pos) == color {
+				runs[k]++
+				pos += side
+			}
+			color = !color
+		}
+		total := block
+		for _, r := range runs {
+			total += r
+		}
+		moduleSize := float64(total) / 7
+		if math.Abs(float64(block)-3*moduleSize) >= 1.5*moduleSize {
+			continue
+		}
+		valid := true
+		for _, r := range runs {
+			if r == 0 || math.Abs(float64(r)-moduleSize) >= moduleSize/2 {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			lc.outer[i] = pos
+			if side < 0 {
+				lc.outer[i] = pos + 1
+			}
+			found = true
+		}
+	}
+	return lc, found
+}
+
+// selectFinderPatterns chooses four finder patterns forming a symbol, three
+// facing out of it and one facing in, and returns them as top-left,
+// top-right, bottom-right and bottom-left along with the symbol's dimension.
+func selectFinderPatterns(patterns []*FinderPattern) ([4]*FinderPattern, int, error) {
+	var best [4]*FinderPattern
+	bestDimension := 0
+	if len(patterns) < 4 {
+		return best, 0, zxinggo.ErrNotFound
+	}
+	sort.SliceStable(patterns, func(i, j int) bool {
+		return patterns[i].Count > patterns[j].Count
+	})
+	if len(patterns) > maxCandidates {
+		patterns = patterns[:maxCandidates]
+	}
+
+	bestScore := math.Inf(1)
+	n := len(patterns)
+	for a := 0; a < n; a++ {
+		for b := a + 1; b < n; b++ {
+			for c := b + 1; c < n; c++ {
+				for d := c + 1; d < n; d++ {
+					set := [4]*FinderPattern{patterns[a], patterns[b], patterns[c], patterns[d]}
+					ordered, dimension, score, ok := orderFinderPatterns(set)
+					if ok && score < bestScore {
+						best, bestDimension, bestScore = ordered, dimension, score
+					}
+				}
+			}
+		}
+	}
+	if best[0] == nil {
+		return best, 0, zxinggo.ErrNotFound
+	}
+	return best, bestDimension, nil
+}
+
+// orderFinderPatterns orders a candidate set of finder patterns and works out
+// the dimension of the symbol they would form. The score is lower the better
+// the set fits a square symbol.
+func orderFinderPatterns(set [4]*FinderPattern) (ordered [4]*FinderPattern, dimension int, score float64, ok bool) {
+	var cx, cy, minSize, maxSize float64
+	minSize = math.Inf(1)
+	for _, p := range set {
+		cx += p.X / 4
+		cy += p.Y / 4
+		minSize = math.Min(minSize, p.EstimatedModuleSize)
+		maxSize = math.Max(maxSize, p.EstimatedModuleSize)
+	}
+	if maxSize > 1.5*minSize {
+		return ordered, 0, 0, false
+	}
+
+	var bottomLeft *FinderPattern
+	var outward []*FinderPattern
+	for _, p := range set {
+		dx := float64(p.DirX) * (p.X - cx)
+		dy := float64(p.DirY) * (p.Y - cy)
+		switch {
+		case dx > 0 && dy > 0:
+			outward = append(outward, p)
+		case dx < 0 && dy < 0 && bottomLeft == nil:
+			bottomLeft = p
+		default:
+			return ordered, 0, 0, false
+		}
+	}
+	if bottomLeft == nil {
+		return ordered, 0, 0, false
+	}
+
+	// The top-right pattern is the one furthest from the bottom-left; of the
+	// others, the top-left comes first going clockwise from the bottom-left.
+	sort.Slice(outward, func(i, j int) bool {
+		return distance(bottomLeft, outward[i]) < distance(bottomLeft, outward[j])
+	})
+	topLeft, bottomRight, topRight := outward[0], outward[1], outward[2]
+	cross := (topLeft.X-bottomLeft.X)*(bottomRight.Y-bottomLeft.Y) -
+		(topLeft.Y-bottomLeft.Y)*(bottomRight.X-bottomLeft.X)
+	if cross < 0 {
+		topLeft, bottomRight = bottomRight, topLeft
+	}
+
+	// The top-left, top-right and bottom-right blocks form a right isosceles
+	// triangle whose legs are dimension-11 modules long.
+	top := distance(topLeft, topRight)
+	right := distance(topRight, bottomRight)
+	mean := (top + right) / 2
+	cos := ((topLeft.X-topRight.X)*(bottomRight.X-topRight.X) +
+		(topLeft.Y-topRight.Y)*(bottomRight.Y-topRight.Y)) / (top * right)
+	moduleSize := (topLeft.EstimatedModuleSize + topRight.EstimatedModuleSize +
+		bottomRight.EstimatedModuleSize + bottomLeft.EstimatedModuleSize) / 4
+	version := int(math.Round((mean/moduleSize + 11 - 21) / 2))
+	if version < 1 || version > decoder.MaxVersion {
+		return ordered, 0, 0, false
+	}
+	dimension = decoder.DimensionForVersion(version)
+
+	// The bottom-left block should be where the other three put it.
+	m := ModuleCenters(dimension)
+	legs := float64(dimension - 11)
+	ux, uy := (topRight.X-topLeft.X)/legs, (topRight.Y-topLeft.Y)/legs
+	vx, vy := (bottomRight.X-topRight.X)/legs, (bottomRight.Y-topRight.Y)/legs
+	predictedX := topLeft.X + (m[6]-m[0])*ux + (m[7]-m[1])*vx
+	predictedY := topLeft.Y + (m[6]-m[0])*uy + (m[7]-m[1])*vy
+	offset := math.Hypot(bottomLeft.X-predictedX, bottomLeft.Y-predictedY) / moduleSize
+
+	score = math.Abs(top-right)/mean + math.Abs(cos) + offset/legs
+	if score > 0.5 {
+		return ordered, 0, 0, false
+	}
+	return [4]*FinderPattern{topLeft, topRight, bottomRight, bottomLeft}, dimension, score, true
+}
+
+func distance(a, b *FinderPattern) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
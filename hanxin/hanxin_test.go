@@ -0,0 +1,399 @@
+package hanxin
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/hanxin/decoder"
+	"github.com/ericlevine/zxinggo/reedsolomon"
+)
+
+// bitWriter accumulates a Han Xin data bit stream.
+type bitWriter struct {
+	bits []bool
+}
+
+func (w *bitWriter) put(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.bits = append(w.bits, value&(1<<uint(i)) != 0)
+	}
+}
+
+func (w *bitWriter) numeric(digits string) {
+	w.put(0x1, 4)
+	group := 0
+	for i := 0; i < len(digits); i += 3 {
+		end := min(i+3, len(digits))
+		value := 0
+		for _, c := range digits[i:end] {
+			value = 10*value + int(c-'0')
+		}
+		w.put(value, 10)
+		group = end - i
+	}
+	w.put(1020+group, 10)
+}
+
+func (w *bitWriter) text(s string) {
+	w.put(0x2, 4)
+	submode1 := true
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		value, inSubmode1 := 0, true
+		switch {
+		case c >= '0' && c <= '9':
+			value = int(c - '0')
+		case c >= 'A' && c <= 'Z':
+			value = int(c-'A') + 10
+		case c >= 'a' && c <= 'z':
+			value = int(c-'a') + 36
+		case c < 28:
+			value, inSubmode1 = int(c), false
+		case c >= ' ' && c <= '/':
+			value, inSubmode1 = int(c-' ')+28, false
+		case c >= ':' && c <= '@':
+			value, inSubmode1 = int(c-':')+44, false
+		case c >= '[' && c <= '`':
+			value, inSubmode1 = int(c-'[')+51, false
+		default:
+			value, inSubmode1 = int(c-'{')+57, false
+		}
+		if inSubmode1 != submode1 {
+			w.put(62, 6)
+			submode1 = inSubmode1
+		}
+		w.put(value, 6)
+	}
+	w.put(63, 6)
+}
+
+func (w *bitWriter) binary(data []byte) {
+	w.put(0x3, 4)
+	w.put(len(data), 13)
+	for _, b := range data {
+		w.put(int(b), 8)
+	}
+}
+
+// region1 encodes GB 2312 level 1 hanzi, given as two-byte codes.
+func (w *bitWriter) region1(codes ...int) {
+	w.put(0x4, 4)
+	for _, c := range codes {
+		w.put((c>>8-0xB0)*94+(c&0xFF-0xA1), 12)
+	}
+	w.put(0xFFF, 12)
+}
+
+func (w *bitWriter) fourByte(b1, b2, b3, b4 int) {
+	w.put(0x7, 4)
+	w.put(((b1-0x81)*10+(b2-0x30))*1260+(b3-0x81)*10+(b4-0x30), 21)
+}
+
+func (w *bitWriter) codewords(n int) []byte {
+	data := make([]byte, n)
+	for i, b := range w.bits {
+		if b {
+			data[i/8] |= 0x80 >> uint(i%8)
+		}
+	}
+	return data
+}
+
+// buildSymbol lays out a Han Xin Code symbol from its data codewords. It
+// follows the standard as the decoder reads it, so the tests check the two
+// agree rather than that either matches an independent encoder.
+func buildSymbol(t *testing.T, versionNumber int, ecLevel decoder.ErrorCorrectionLevel, mask int, w *bitWriter) *bitutil.BitMatrix {
+	t.Helper()
+	version, err := decoder.VersionForNumber(versionNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := version.ECBlocksForLevel(ecLevel)
+	numData := 0
+	for _, b := range blocks {
+		numData += b.Count * b.DataCodewords
+	}
+	if len(w.bits) > 8*numData {
+		t.Fatalf("%d bits do not fit in %d codewords", len(w.bits), numData)
+	}
+	data := w.codewords(numData)
+
+	enc := reedsolomon.NewEncoder(reedsolomon.HanXinField256)
+	var stream []byte
+	for _, b := range blocks {
+		for i := 0; i < b.Count; i++ {
+			block := make([]int, b.DataCodewords+b.ECCodewords)
+			for j := range block[:b.DataCodewords] {
+				block[j] = int(data[j])
+			}
+			data = data[b.DataCodewords:]
+			enc.Encode(block, b.ECCodewords)
+			for _, c := range block {
+				stream = append(stream, byte(c))
+			}
+		}
+	}
+	var interleaved []byte
+	for start := 0; start < 13; start++ {
+		for i := start; i < len(stream); i += 13 {
+			interleaved = append(interleaved, stream[i])
+		}
+	}
+	return layoutSymbol(version, ecLevel, mask, interleaved)
+}
+
+// layoutSymbol lays out a symbol from its interleaved codewords.
+func layoutSymbol(version *decoder.Version, ecLevel decoder.ErrorCorrectionLevel, mask int, interleaved []byte) *bitutil.BitMatrix {
+	versionNumber := version.Number
+	dim := version.Dimension()
+	bits := version.AlignmentPattern()
+	function := version.BuildFunctionPattern()
+	for _, corner := range [][2]int{{0, 0}, {dim - 7, 0}, {0, dim - 7}, {dim - 7, dim - 7}} {
+		for y := corner[1]; y < corner[1]+7; y++ {
+			for x := corner[0]; x < corner[0]+7; x++ {
+				if decoder.FinderModule(x, y, dim) {
+					bits.Set(x, y)
+				}
+			}
+		}
+	}
+
+	fi := []int{(versionNumber + 20) >> 4, (versionNumber + 20) & 0x0F, int(ecLevel)<<2 | mask, 0, 0, 0, 0}
+	reedsolomon.NewEncoder(reedsolomon.HanXinFunction).Encode(fi, 4)
+	var fiBits [34]bool
+	for k := 0; k < 28; k++ {
+		fiBits[k] = fi[k/4]&(0x08>>uint(k%4)) != 0
+	}
+	for k := 28; k < 34; k++ {
+		fiBits[k] = k%2 == 1
+	}
+	for k, dark := range fiBits {
+		if !dark {
+			continue
+		}
+		// The second copy is the first rotated 180 degrees.
+		var x, y int
+		switch {
+		case k <= 8:
+			x, y = k, 8
+		case k <= 16:
+			x, y = 8, 16-k
+		case k <= 24:
+			x, y = dim-9, k-17
+		default:
+			x, y = dim-34+k, 8
+		}
+		bits.Set(x, y)
+		bits.Set(dim-1-x, dim-1-y)
+	}
+
+	n := 0
+	for y := 0; y < dim; y++ {
+		for x := 0; x < dim; x++ {
+			if function.Get(x, y) || n == 8*len(interleaved) {
+				continue
+			}
+			dark := interleaved[n/8]&(0x80>>uint(n%8)) != 0
+			if dark != decoder.DataMasks[mask](y+1, x+1) {
+				bits.Set(x, y)
+			}
+			n++
+		}
+	}
+	return bits
+}
+
+// render draws a symbol with a four-module quiet zone at the given scale,
+// with its middle bulging up and to the left by the given number of modules,
+// as if printed on a curved surface.
+func render(bits *bitutil.BitMatrix, scale int, bulge float64) *zxinggo.BinaryBitmap {
+	size := (bits.Width() + 8) * scale
+	dim := float64(bits.Width())
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			u := (float64(x)+0.5)/float64(scale) - 4
+			v := (float64(y)+0.5)/float64(scale) - 4
+			shift := bulge * math.Sin(math.Pi*u/dim) * math.Sin(math.Pi*v/dim)
+			mx, my := int(math.Floor(u+shift)), int(math.Floor(v+shift))
+			c := uint8(255)
+			if mx >= 0 && my >= 0 && mx < bits.Width() && my < bits.Height() && bits.Get(mx, my) {
+				c = 0
+			}
+			img.SetGray(x, y, color.Gray{Y: c})
+		}
+	}
+	return zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(zxinggo.NewGrayImageLuminanceSource(img)))
+}
+
+func TestDecodeMatrixModes(t *testing.T) {
+	w := &bitWriter{}
+	w.numeric("0123456789")
+	w.text("Han Xin, 2D!")
+	w.binary([]byte("\x01bin"))
+	w.region1(0xBABA, 0xD0C5)          // 汉信
+	w.fourByte(0x94, 0x39, 0xFC, 0x36) // U+1F600
+	want := "0123456789Han Xin, 2D!\x01bin汉信\U0001F600"
+
+	for mask := 0; mask < 4; mask++ {
+		bits := buildSymbol(t, 3, decoder.ECLevelL1, mask, w)
+		result, err := DecodeMatrix(bits, nil)
+		if err != nil {
+			t.Fatalf("mask %d: decode error: %v", mask, err)
+		}
+		if result.Text != want {
+			t.Errorf("mask %d: text = %q, want %q", mask, result.Text, want)
+		}
+		if result.Format != zxinggo.FormatHanXin {
+			t.Errorf("mask %d: format = %v", mask, result.Format)
+		}
+	}
+}
+
+func TestDecodeMatrixNumericGroups(t *testing.T) {
+	for _, digits := range []string{"7", "42", "007", "1234", "98765"} {
+		w := &bitWriter{}
+		w.numeric(digits)
+		result, err := DecodeMatrix(buildSymbol(t, 1, decoder.ECLevelL2, 1, w), nil)
+		if err != nil {
+			t.Fatalf("%q: decode error: %v", digits, err)
+		}
+		if result.Text != digits {
+			t.Errorf("text = %q, want %q", result.Text, digits)
+		}
+	}
+}
+
+func TestDecodeMatrixRotatedWithErrors(t *testing.T) {
+	w := &bitWriter{}
+	w.text("Rotated")
+	bits := buildSymbol(t, 2, decoder.ECLevelL4, 2, w)
+	// Damage a few data modules and one copy of the function information.
+	for _, p := range [][2]int{{10, 10}, {12, 14}, {20, 11}, {3, 8}, {8, 2}} {
+		bits.Flip(p[0], p[1])
+	}
+	bits.Rotate90()
+
+	result, err := DecodeMatrix(bits, nil)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if result.Text != "Rotated" {
+		t.Errorf("text = %q, want %q", result.Text, "Rotated")
+	}
+	if ec := result.Metadata[zxinggo.MetadataErrorCorrectionLevel]; ec != "L4" {
+		t.Errorf("error correction level = %v, want L4", ec)
+	}
+	if n, _ := result.Metadata[zxinggo.MetadataErrorsCorrected].(int); n == 0 {
+		t.Error("expected corrected errors to be reported")
+	}
+}
+
+func TestDecodeImage(t *testing.T) {
+	w := &bitWriter{}
+	w.text("Logistics label 42")
+	bits := buildSymbol(t, 3, decoder.ECLevelL2, 3, w)
+
+	for rotation := 0; rotation < 4; rotation++ {
+		result, err := NewReader().Decode(render(bits, 4, 0), nil)
+		if err != nil {
+			t.Fatalf("rotation %d: decode error: %v", rotation, err)
+		}
+		if result.Text != "Logistics label 42" {
+			t.Errorf("rotation %d: text = %q", rotation, result.Text)
+		}
+		if len(result.Points) != 4 {
+			t.Errorf("rotation %d: got %d points, want 4", rotation, len(result.Points))
+		}
+		bits.Rotate90()
+	}
+}
+
+func TestDetect(t *testing.T) {
+	w := &bitWriter{}
+	w.numeric("12345")
+	bits := buildSymbol(t, 1, decoder.ECLevelL1, 0, w)
+
+	detections, err := NewReader().Detect(render(bits, 5, 0), nil)
+	if err != nil {
+		t.Fatalf("detect error: %v", err)
+	}
+	if len(detections) != 1 || !detections[0].Bits.Equals(bits) {
+		t.Fatal("detected grid does not match the symbol")
+	}
+	// The symbol starts after a 4-module quiet zone of 5-pixel modules.
+	want := []zxinggo.ResultPoint{{X: 20, Y: 20}, {X: 135, Y: 20}, {X: 135, Y: 135}, {X: 20, Y: 135}}
+	for i, p := range detections[0].Points {
+		if zxinggo.Distance(p, want[i]) > 2 {
+			t.Errorf("corner %d = %v, want %v", i, p, want[i])
+		}
+	}
+}
+
+// randomSymbol lays out a symbol of the given version filled with random
+// codewords.
+func randomSymbol(t *testing.T, versionNumber int) *bitutil.BitMatrix {
+	t.Helper()
+	version, err := decoder.VersionForNumber(versionNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codewords := make([]byte, version.TotalCodewords)
+	rand.New(rand.NewSource(int64(versionNumber))).Read(codewords)
+	return layoutSymbol(version, decoder.ECLevelL2, 1, codewords)
+}
+
+func TestVersionCapacity(t *testing.T) {
+	// The modules left over from the function patterns hold each version's
+	// codewords, with fewer than eight to spare.
+	for number := 1; number <= decoder.MaxVersion; number++ {
+		version, err := decoder.VersionForNumber(number)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dim := version.Dimension()
+		function := version.BuildFunctionPattern()
+		free := 0
+		for y := 0; y < dim; y++ {
+			for x := 0; x < dim; x++ {
+				if !function.Get(x, y) {
+					free++
+				}
+			}
+		}
+		if free/8 != version.TotalCodewords {
+			t.Errorf("version %d: %d data modules, want %d codewords", number, free, version.TotalCodewords)
+		}
+	}
+}
+
+func TestDetectAlignment(t *testing.T) {
+	for _, number := range []int{4, 8, 15} {
+		bits := randomSymbol(t, number)
+		// The bulge moves the middle of the symbol a module from where the
+		// finder patterns put it; only the alignment patterns show it.
+		detections, err := NewReader().Detect(render(bits, 5, 1), nil)
+		if err != nil {
+			t.Fatalf("version %d: detect error: %v", number, err)
+		}
+		if len(detections) != 1 || !detections[0].Bits.Equals(bits) {
+			t.Errorf("version %d: detected grid does not match the symbol", number)
+		}
+	}
+}
+
+func TestUnsupportedVersion(t *testing.T) {
+	// Versions above 3 are sampled, but their error correction blocks are not
+	// known.
+	_, err := DecodeMatrix(randomSymbol(t, 10), nil)
+	if !errors.Is(err, zxinggo.ErrFormat) {
+		t.Errorf("err = %v, want ErrFormat", err)
+	}
+}
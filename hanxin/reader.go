@@ -0,0 +1,158 @@
+// Package hanxin provides Han Xin Code (Chinese Sensible Code, ISO/IEC 20830)
+// reading.
+//
+// Support is partial: only symbols of versions 1 to 3, up to 27x27 modules,
+// are decoded. Larger versions are located and sampled, following their
+// alignment patterns, and their function information read, but decoding
+// them fails with zxinggo.ErrFormat: how they split their codewords into
+// error correction blocks, Table D.1 of the standard, is not yet
+// transcribed.
+package hanxin
+
+import (
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/hanxin/decoder"
+	"github.com/ericlevine/zxinggo/hanxin/detector"
+	"github.com/ericlevine/zxinggo/transform"
+)
+
+// Reader decodes Han Xin Code symbols from binary images.
+type Reader struct {
+	dec *decoder.Decoder
+
+	// opts are used by Decode and Detect when they are passed nil.
+	opts *zxinggo.DecodeOptions
+}
+
+// NewReader creates a new Han Xin Code Reader.
+func NewReader() *Reader {
+	return &Reader{dec: decoder.NewDecoder()}
+}
+
+// NewReaderWithOptions creates a Han Xin reader that uses opts whenever it is
+// given nil options, so it can be configured once and used on its own
+// rather than through zxinggo.Decode.
+func NewReaderWithOptions(opts *zxinggo.DecodeOptions) *Reader {
+	return &Reader{dec: decoder.NewDecoder(), opts: opts}
+}
+
+// Decode locates and decodes a Han Xin Code symbol in the given image.
+func (r *Reader) Decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	if opts == nil {
+		opts = r.opts
+	}
+	if opts == nil {
+		opts = &zxinggo.DecodeOptions{}
+	}
+	matrix, err := image.BlackMatrix()
+	if err != nil {
+		return nil, err
+	}
+	detectorResult, err := detector.Detect(matrix, opts.TryHarder)
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.decodeBits(detectorResult.Bits, opts.CharacterSet, detectorResult.Points)
+	if err != nil {
+		return nil, err
+	}
+	if err := zxinggo.CheckErrorBudget(result, opts); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DecodeMatrix decodes a Han Xin Code symbol from its module grid, one bit
+// per module with no quiet zone, as sampled by an external detector. The grid
+// may be in any of the four rotations. opts may be nil; only CharacterSet and
+// MaxErrorsCorrected are used. The result has no points.
+func DecodeMatrix(bits *bitutil.BitMatrix, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	if bits.Width() != bits.Height() || bits.Width() < decoder.DimensionForVersion(1) {
+		return nil, zxinggo.ErrFormat
+	}
+	characterSet := ""
+	if opts != nil {
+		characterSet = opts.CharacterSet
+	}
+
+	// Turn the grid so that its finder patterns are where they belong.
+	bits = bits.Clone()
+	best, bestMismatches := 0, decoder.FinderMismatches(bits)
+	for rotation := 1; rotation < 4; rotation++ {
+		bits.Rotate90()
+		if mismatches := decoder.FinderMismatches(bits); mismatches < bestMismatches {
+			best, bestMismatches = rotation, mismatches
+		}
+	}
+	bits.Rotate90()
+	for rotation := 0; rotation < best; rotation++ {
+		bits.Rotate90()
+	}
+	result, err := NewReader().decodeBits(bits, characterSet, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := zxinggo.CheckErrorBudget(result, opts); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *Reader) decodeBits(bits *bitutil.BitMatrix, characterSet string, points []zxinggo.ResultPoint) (*zxinggo.Result, error) {
+	dr, err := r.dec.Decode(bits, characterSet)
+	if err != nil {
+		return nil, err
+	}
+	result := dr.Result(points, zxinggo.FormatHanXin)
+	result.PutMetadata(zxinggo.MetadataSymbolDimension, [2]int{bits.Width(), bits.Height()})
+	return result, nil
+}
+
+// Detect locates a Han Xin Code symbol in the given image without decoding
+// it. The outline starts at the symbol's top-left corner.
+func (r *Reader) Detect(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) ([]zxinggo.Detection, error) {
+	if opts == nil {
+		opts = r.opts
+	}
+	if opts == nil {
+		opts = &zxinggo.DecodeOptions{}
+	}
+	matrix, err := image.BlackMatrix()
+	if err != nil {
+		return nil, err
+	}
+	detectorResult, err := detector.Detect(matrix, opts.TryHarder)
+	if err != nil {
+		return nil, err
+	}
+
+	// Extend the finder patterns out to the corners of the symbol.
+	p := detectorResult.Points
+	dim := float64(detectorResult.Bits.Width())
+	m := detector.ModuleCenters(detectorResult.Bits.Width())
+	xform := transform.QuadrilateralToQuadrilateral(
+		m[0], m[1], m[2], m[3], m[4], m[5], m[6], m[7],
+		p[0].X, p[0].Y, p[1].X, p[1].Y, p[2].X, p[2].Y, p[3].X, p[3].Y)
+	outline := []float64{0, 0, dim, 0, dim, dim, 0, dim}
+	xform.TransformPoints(outline)
+
+	points := make([]zxinggo.ResultPoint, 4)
+	for i := range points {
+		points[i] = zxinggo.ResultPoint{X: outline[2*i], Y: outline[2*i+1]}
+	}
+	return []zxinggo.Detection{{
+		Format: zxinggo.FormatHanXin,
+		Points: points,
+		Bits:   detectorResult.Bits,
+	}}, nil
+}
+
+// Reset resets internal state.
+func (r *Reader) Reset() {}
+
+// Compile-time check.
+var (
+	_ zxinggo.Reader   = (*Reader)(nil)
+	_ zxinggo.Detector = (*Reader)(nil)
+)
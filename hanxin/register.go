@@ -0,0 +1,9 @@
+package hanxin
+
+import zxinggo "github.com/ericlevine/zxinggo"
+
+func init() {
+	zxinggo.RegisterReader(zxinggo.FormatHanXin, func(opts *zxinggo.DecodeOptions) zxinggo.Reader {
+		return NewReader()
+	})
+}
@@ -0,0 +1,175 @@
+package zxinggo
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sync"
+)
+
+// Heatmap records where detectors spent their time in an image and where
+// they found or rejected candidate patterns, to tune camera placement and
+// understand systematic failures. Set DecodeOptions.Heatmap to collect one;
+// the QR Code, Data Matrix, Aztec, PDF417 and MaxiCode detectors and the 1D
+// readers record into it, and every decoded symbol is recorded as an
+// accepted candidate.
+//
+// Coordinates are those of the image passed to Decode. Work on rotated,
+// padded or rectified copies of the image, such as the rotated search of 1D
+// readers with TryHarder, is not recorded.
+// A nil *Heatmap records nothing.
+//
+// Recording is safe for concurrent use, so DecodeOptions holding a Heatmap
+// may be shared by goroutines decoding at once, though their work is then
+// mixed in one map. Read the fields only once every Decode using it has
+// returned.
+type Heatmap struct {
+	source LuminanceSource
+	mu     sync.Mutex
+
+	// Work counts how many times each pixel, in row-major order, was read
+	// by a scan.
+	Work []int
+
+	// Candidates are the patterns detectors considered, in the order found.
+	Candidates []HeatmapCandidate
+
+	// FormatOrder is the order in which formats were tried, when none were
+	// requested and it was chosen from statistics of the image.
+	FormatOrder []Format
+}
+
+// HeatmapCandidate is a pattern a detector considered.
+type HeatmapCandidate struct {
+	Format Format
+
+	// X, Y and Size give the pattern's centre and approximate width in
+	// pixels.
+	X, Y, Size float64
+
+	// Accepted reports whether the pattern passed the detector's checks.
+	Accepted bool
+}
+
+// NewHeatmap creates an empty heat map for the given image.
+func NewHeatmap(source LuminanceSource) *Heatmap {
+	return &Heatmap{
+		source: source,
+		Work:   make([]int, source.Width()*source.Height()),
+	}
+}
+
+// ScanRow records a scan of pixels left to right-1 of row y.
+func (h *Heatmap) ScanRow(y, left, right int) {
+	if h == nil || y < 0 || y >= h.source.Height() {
+		return
+	}
+	width := h.source.Width()
+	left, right = max(left, 0), min(right, width)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for x := left; x < right; x++ {
+		h.Work[y*width+x]++
+	}
+}
+
+// ScanColumn records a scan of pixels top to bottom-1 of column x.
+func (h *Heatmap) ScanColumn(x, top, bottom int) {
+	if h == nil || x < 0 || x >= h.source.Width() {
+		return
+	}
+	width := h.source.Width()
+	top, bottom = max(top, 0), min(bottom, h.source.Height())
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for y := top; y < bottom; y++ {
+		h.Work[y*width+x]++
+	}
+}
+
+// Candidate records a pattern considered by a detector.
+func (h *Heatmap) Candidate(format Format, x, y, size float64, accepted bool) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Candidates = append(h.Candidates, HeatmapCandidate{format, x, y, size, accepted})
+}
+
+// recordFormatOrder records the order in which formats are tried.
+func (h *Heatmap) recordFormatOrder(order []Format) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.FormatOrder = order
+}
+
+// Image renders the heat map over a dimmed copy of the image: pixels scanned
+// more often run from blue to red, and candidates are outlined in green if
+// accepted and magenta if rejected.
+func (h *Heatmap) Image() *image.RGBA {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	width, height := h.source.Width(), h.source.Height()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	maxWork := 0
+	for _, w := range h.Work {
+		maxWork = max(maxWork, w)
+	}
+	luminances := h.source.Matrix()
+	for i, w := range h.Work {
+		gray := float64(luminances[i]) / 3
+		c := color.RGBA{uint8(gray), uint8(gray), uint8(gray), 255}
+		if w > 0 {
+			// A logarithmic scale keeps rarely scanned pixels visible.
+			heat := math.Log1p(float64(w)) / math.Log1p(float64(maxWork))
+			c.R = uint8(gray + (255-gray)*heat)
+			c.B = uint8(gray + (255-gray)*(1-heat))
+		}
+		img.SetRGBA(i%width, i/width, c)
+	}
+
+	for _, c := range h.Candidates {
+		outline := color.RGBA{255, 0, 255, 255}
+		if c.Accepted {
+			outline = color.RGBA{0, 255, 0, 255}
+		}
+		half := max(c.Size/2, 2)
+		left, right := int(math.Round(c.X-half)), int(math.Round(c.X+half))
+		top, bottom := int(math.Round(c.Y-half)), int(math.Round(c.Y+half))
+		for x := left; x <= right; x++ {
+			img.SetRGBA(x, top, outline)
+			img.SetRGBA(x, bottom, outline)
+		}
+		for y := top; y <= bottom; y++ {
+			img.SetRGBA(left, y, outline)
+			img.SetRGBA(right, y, outline)
+		}
+	}
+	return img
+}
+
+// WritePNG writes the heat map's Image as a PNG.
+func (h *Heatmap) WritePNG(w io.Writer) error {
+	return png.Encode(w, h.Image())
+}
+
+// recordResult records a decoded symbol as an accepted candidate spanning
+// its result points.
+func (h *Heatmap) recordResult(result *Result) {
+	if h == nil || len(result.Points) == 0 {
+		return
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range result.Points {
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	h.Candidate(result.Format, (minX+maxX)/2, (minY+maxY)/2, math.Max(maxX-minX, maxY-minY), true)
+}
@@ -0,0 +1,203 @@
+package zxinggo_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"sync"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+)
+
+func TestHeatmap(t *testing.T) {
+	matrix, err := zxinggo.Encode("HEAT MAP", zxinggo.FormatQRCode, 100, 100, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Place the symbol in the right half of a wider image.
+	img := image.NewGray(image.Rect(0, 0, 300, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 300; x++ {
+			if x < 200 || !matrix.Get(x-200, y) {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	source := zxinggo.NewGrayImageLuminanceSource(img)
+	heatmap := zxinggo.NewHeatmap(source)
+	opts := &zxinggo.DecodeOptions{Heatmap: heatmap, PossibleFormats: []zxinggo.Format{zxinggo.FormatQRCode}}
+	result, err := zxinggo.Decode(zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source)), opts)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+
+	scanned := 0
+	for _, w := range heatmap.Work {
+		if w > 0 {
+			scanned++
+		}
+	}
+	if scanned == 0 || scanned == len(heatmap.Work) {
+		t.Errorf("%d of %d pixels scanned", scanned, len(heatmap.Work))
+	}
+	accepted := 0
+	for _, c := range heatmap.Candidates {
+		if c.Accepted && c.X < 200 {
+			t.Errorf("accepted candidate %+v outside the symbol", c)
+		}
+		if c.Accepted {
+			accepted++
+		}
+	}
+	// Three finder patterns, each confirmed on several rows, and the result.
+	if accepted < 4 {
+		t.Errorf("%d accepted candidates, want at least 4", accepted)
+	}
+	if last := heatmap.Candidates[len(heatmap.Candidates)-1]; last.Format != result.Format || !last.Accepted {
+		t.Errorf("last candidate = %+v, want the result", last)
+	}
+
+	var buf bytes.Buffer
+	if err := heatmap.WritePNG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Bounds() != img.Bounds() {
+		t.Errorf("heat map image bounds = %v, want %v", out.Bounds(), img.Bounds())
+	}
+}
+
+func TestNilHeatmap(t *testing.T) {
+	var heatmap *zxinggo.Heatmap
+	heatmap.ScanRow(0, 0, 10)
+	heatmap.ScanColumn(0, 0, 10)
+	heatmap.Candidate(zxinggo.FormatQRCode, 1, 1, 1, true)
+}
+
+func TestHeatmapFormatOrder(t *testing.T) {
+	for _, format := range []zxinggo.Format{zxinggo.FormatCode128, zxinggo.FormatQRCode} {
+		matrix, err := zxinggo.Encode("ORDER 42", format, 300, 150, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		source := zxinggo.NewGrayImageLuminanceSource(zxinggo.BitMatrixToImage(matrix))
+		heatmap := zxinggo.NewHeatmap(source)
+		result, err := zxinggo.Decode(zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source)), &zxinggo.DecodeOptions{Heatmap: heatmap})
+		if err != nil || result.Format != format {
+			t.Fatalf("%v: decoded %v, %v", format, result, err)
+		}
+		if len(heatmap.FormatOrder) == 0 || heatmap.FormatOrder[0] != format {
+			t.Errorf("%v: format order %v, want %v first", format, heatmap.FormatOrder, format)
+		}
+	}
+
+	// Requested formats are tried in the order given, which is not recorded.
+	source := zxinggo.NewLuminanceSourceFromBytes(make([]byte, 100), 10, 10, 10)
+	heatmap := zxinggo.NewHeatmap(source)
+	zxinggo.Decode(zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source)), &zxinggo.DecodeOptions{Heatmap: heatmap, PossibleFormats: []zxinggo.Format{zxinggo.FormatQRCode}})
+	if heatmap.FormatOrder != nil {
+		t.Errorf("format order %v recorded for requested formats", heatmap.FormatOrder)
+	}
+}
+
+// paddedSource renders matrix in the middle of a white border of the given
+// width.
+func paddedSource(matrix interface {
+	Width() int
+	Height() int
+	Get(x, y int) bool
+}, border int) zxinggo.LuminanceSource {
+	img := image.NewGray(image.Rect(0, 0, matrix.Width()+2*border, matrix.Height()+2*border))
+	for y := range img.Bounds().Dy() {
+		for x := range img.Bounds().Dx() {
+			mx, my := x-border, y-border
+			if mx < 0 || my < 0 || mx >= matrix.Width() || my >= matrix.Height() || !matrix.Get(mx, my) {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	return zxinggo.NewGrayImageLuminanceSource(img)
+}
+
+func TestHeatmapDetectors(t *testing.T) {
+	for _, format := range []zxinggo.Format{zxinggo.FormatDataMatrix, zxinggo.FormatAztec, zxinggo.FormatPDF417, zxinggo.FormatMaxiCode} {
+		matrix, err := zxinggo.Encode("HEAT MAP", format, 150, 150, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		source := paddedSource(matrix, 40)
+		heatmap := zxinggo.NewHeatmap(source)
+		opts := &zxinggo.DecodeOptions{Heatmap: heatmap, PossibleFormats: []zxinggo.Format{format}}
+		if _, err := zxinggo.Decode(zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source)), opts); err != nil {
+			t.Fatalf("%v: decode error: %v", format, err)
+		}
+
+		scanned := 0
+		for _, w := range heatmap.Work {
+			if w > 0 {
+				scanned++
+			}
+		}
+		if scanned == 0 {
+			t.Errorf("%v: no pixels scanned", format)
+		}
+		// The detector's candidate and, for symbols with result points, the
+		// result.
+		want := 2
+		if format == zxinggo.FormatMaxiCode {
+			want = 1
+		}
+		accepted := 0
+		for _, c := range heatmap.Candidates {
+			if c.Format == format && c.Accepted {
+				accepted++
+			}
+		}
+		if accepted < want {
+			t.Errorf("%v: %d accepted candidates, want at least %d", format, accepted, want)
+		}
+	}
+}
+
+func TestHeatmapConcurrent(t *testing.T) {
+	matrix, err := zxinggo.Encode("HEAT MAP", zxinggo.FormatQRCode, 100, 100, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := paddedSource(matrix, 0)
+	decode := func(heatmap *zxinggo.Heatmap) {
+		opts := &zxinggo.DecodeOptions{Heatmap: heatmap, PossibleFormats: []zxinggo.Format{zxinggo.FormatQRCode}}
+		if _, err := zxinggo.Decode(zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source)), opts); err != nil {
+			t.Errorf("decode error: %v", err)
+		}
+	}
+	once := zxinggo.NewHeatmap(source)
+	decode(once)
+
+	// Four decodes at once record four times the work of one.
+	shared := zxinggo.NewHeatmap(source)
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			decode(shared)
+		}()
+	}
+	wg.Wait()
+
+	if len(shared.Candidates) != 4*len(once.Candidates) {
+		t.Errorf("%d candidates, want %d", len(shared.Candidates), 4*len(once.Candidates))
+	}
+	for i, w := range shared.Work {
+		if w != 4*once.Work[i] {
+			t.Fatalf("work at pixel %d = %d, want %d", i, w, 4*once.Work[i])
+		}
+	}
+}
@@ -0,0 +1,16 @@
+// Package imageformat registers BMP and WebP decoding with the image
+// package, so that ReadImageLuminanceSource, DecodeFile and the barcodescan
+// tool read them along with PNG, JPEG and GIF. Warehouse cameras commonly
+// save BMP and web pipelines WebP. Import it for its side effect:
+//
+//	import _ "github.com/ericlevine/zxinggo/imageformat"
+//
+// The decoders are the pure Go ones from golang.org/x/image; they live here
+// rather than in zxinggo so that programs reading only the standard formats
+// do not link them.
+package imageformat
+
+import (
+	_ "golang.org/x/image/bmp"  // register BMP decoding
+	_ "golang.org/x/image/webp" // register WebP decoding
+)
@@ -0,0 +1,121 @@
+package imageformat_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+	"github.com/ericlevine/zxinggo/bitutil"
+	_ "github.com/ericlevine/zxinggo/imageformat"
+	_ "github.com/ericlevine/zxinggo/qrcode"
+	"golang.org/x/image/bmp"
+)
+
+func qrMatrix(t *testing.T, text string) *bitutil.BitMatrix {
+	t.Helper()
+	matrix, err := zxinggo.Encode(text, zxinggo.FormatQRCode, 200, 200, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matrix
+}
+
+// bitWriter writes values least significant bit first, as WebP lossless
+// streams are packed.
+type bitWriter struct {
+	buf  []byte
+	nbit int
+}
+
+func (w *bitWriter) write(value uint32, n int) {
+	for i := 0; i < n; i++ {
+		if w.nbit%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		w.buf[len(w.buf)-1] |= byte(value>>i&1) << (w.nbit % 8)
+		w.nbit++
+	}
+}
+
+// losslessWebP encodes matrix as a black on white lossless WebP image. It
+// uses no transforms and simple prefix codes: a 1-bit code for each of the
+// green, red and blue values 0 and 255, and no bits for the alpha and
+// distance codes that take a single value.
+func losslessWebP(matrix *bitutil.BitMatrix) []byte {
+	w := &bitWriter{}
+	w.write(0x2f, 8)
+	w.write(uint32(matrix.Width()-1), 14)
+	w.write(uint32(matrix.Height()-1), 14)
+	w.write(0, 1) // no alpha
+	w.write(0, 3) // version
+	w.write(0, 1) // no transforms
+	w.write(0, 1) // no color cache
+	w.write(0, 1) // no meta prefix codes
+	for range 3 { // green, red, blue: 0 or 255
+		w.write(1, 1) // simple code
+		w.write(1, 1) // two symbols
+		w.write(0, 1) // first symbol in 1 bit
+		w.write(0, 1)
+		w.write(255, 8)
+	}
+	w.write(1, 1) // alpha: 255
+	w.write(0, 1)
+	w.write(1, 1)
+	w.write(255, 8)
+	w.write(1, 1) // distance: 0
+	w.write(0, 1)
+	w.write(0, 1)
+	w.write(0, 1)
+	for y := 0; y < matrix.Height(); y++ {
+		for x := 0; x < matrix.Width(); x++ {
+			white := uint32(1)
+			if matrix.Get(x, y) {
+				white = 0
+			}
+			w.write(white, 1)
+			w.write(white, 1)
+			w.write(white, 1)
+		}
+	}
+
+	data := w.buf
+	if len(data)%2 == 1 {
+		data = append(data, 0)
+	}
+	out := []byte("RIFF")
+	out = binary.LittleEndian.AppendUint32(out, uint32(12+len(data)))
+	out = append(out, "WEBPVP8L"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(w.buf)))
+	return append(out, data...)
+}
+
+func TestDecodeFileBMPAndWebP(t *testing.T) {
+	matrix := qrMatrix(t, "WAREHOUSE 7")
+	var bmpData bytes.Buffer
+	if err := bmp.Encode(&bmpData, zxinggo.BitMatrixToImage(matrix)); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for name, data := range map[string][]byte{
+		"label.bmp":  bmpData.Bytes(),
+		"label.webp": losslessWebP(matrix),
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		result, err := zxinggo.DecodeFile(path, binarizer.NewHybrid(nil), nil)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if result.Text != "WAREHOUSE 7" {
+			t.Errorf("%s: got %q", name, result.Text)
+		}
+	}
+}
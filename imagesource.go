@@ -1,8 +1,13 @@
 package zxinggo
 
 import (
+	"fmt"
 	"image"
 	"image/color"
+	"math"
+
+	"github.com/ericlevine/zxinggo/imaging"
+	"github.com/ericlevine/zxinggo/transform"
 )
 
 // ImageLuminanceSource is a LuminanceSource implementation that wraps a Go
@@ -11,13 +16,27 @@ type ImageLuminanceSource struct {
 	luminances []byte
 	width      int
 	height     int
+	stride     int // distance between rows in luminances; 0 means width
+
+	// toOriginal maps the source's coordinates to those of the image it
+	// was cropped, scaled or turned from; nil if it was not.
+	toOriginal *transform.PerspectiveTransform
 }
 
 // NewImageLuminanceSource creates a LuminanceSource from a Go image.Image.
 // The image is converted to greyscale luminance values upon construction.
 // Uses the same luminance formula as Java ZXing's BufferedImageLuminanceSource:
 // (306*R + 601*G + 117*B + 0x200) >> 10, operating on 8-bit color components.
+// *image.Gray pixels are copied as they are, and the luma plane of an
+// *image.YCbCr, such as a decoded JPEG, is taken without converting to RGB;
+// it can differ from the formula by a level or two.
 func NewImageLuminanceSource(img image.Image) *ImageLuminanceSource {
+	switch img := img.(type) {
+	case *image.Gray:
+		return NewGrayImageLuminanceSource(img)
+	case *image.YCbCr:
+		return newYCbCrLuminanceSource(img)
+	}
 	bounds := img.Bounds()
 	w := bounds.Dx()
 	h := bounds.Dy()
@@ -71,7 +90,7 @@ func NewGrayImageLuminanceSource(img *image.Gray) *ImageLuminanceSource {
 	// Otherwise copy row by row
 	luminances := make([]byte, w*h)
 	for y := 0; y < h; y++ {
-		srcOff := (bounds.Min.Y+y)*img.Stride + bounds.Min.X
+		srcOff := img.PixOffset(bounds.Min.X, bounds.Min.Y+y)
 		copy(luminances[y*w:], img.Pix[srcOff:srcOff+w])
 	}
 	return &ImageLuminanceSource{
@@ -81,6 +100,34 @@ func NewGrayImageLuminanceSource(img *image.Gray) *ImageLuminanceSource {
 	}
 }
 
+// NewLuminanceSourceFromBytes creates a LuminanceSource over lum, an 8-bit
+// grayscale buffer of height rows of width pixels, each row starting stride
+// bytes after the one before, as V4L2 and machine vision cameras deliver
+// frames. The source takes ownership of lum without copying it, so lum must
+// not change while the source or anything decoding from it is in use.
+func NewLuminanceSourceFromBytes(lum []byte, width, height, stride int) *ImageLuminanceSource {
+	if width <= 0 || height <= 0 || stride < width {
+		panic(fmt.Sprintf("zxinggo: invalid luminance buffer %dx%d with stride %d", width, height, stride))
+	}
+	if len(lum) < (height-1)*stride+width {
+		panic(fmt.Sprintf("zxinggo: luminance buffer of %d bytes is too small for %dx%d with stride %d", len(lum), width, height, stride))
+	}
+	return &ImageLuminanceSource{
+		luminances: lum,
+		width:      width,
+		height:     height,
+		stride:     stride,
+	}
+}
+
+// rowOffset returns the offset of row y in luminances.
+func (s *ImageLuminanceSource) rowOffset(y int) int {
+	if s.stride == 0 {
+		return y * s.width
+	}
+	return y * s.stride
+}
+
 // Row returns a row of luminance data.
 func (s *ImageLuminanceSource) Row(y int, row []byte) []byte {
 	if y < 0 || y >= s.height {
@@ -89,15 +136,22 @@ func (s *ImageLuminanceSource) Row(y int, row []byte) []byte {
 	if row == nil || len(row) < s.width {
 		row = make([]byte, s.width)
 	}
-	offset := y * s.width
+	offset := s.rowOffset(y)
 	copy(row, s.luminances[offset:offset+s.width])
 	return row
 }
 
 // Matrix returns the entire luminance matrix.
 func (s *ImageLuminanceSource) Matrix() []byte {
-	result := make([]byte, len(s.luminances))
-	copy(result, s.luminances)
+	result := make([]byte, s.width*s.height)
+	if s.stride == 0 || s.stride == s.width {
+		copy(result, s.luminances)
+		return result
+	}
+	for y := 0; y < s.height; y++ {
+		offset := s.rowOffset(y)
+		copy(result[y*s.width:], s.luminances[offset:offset+s.width])
+	}
 	return result
 }
 
@@ -115,20 +169,104 @@ func (s *ImageLuminanceSource) Height() int {
 // counterclockwise. This is used by 1D readers to try reading barcodes that
 // may be oriented vertically.
 func (s *ImageLuminanceSource) RotateCounterClockwise() *ImageLuminanceSource {
-	newWidth := s.height
-	newHeight := s.width
-	newLum := make([]byte, newWidth*newHeight)
-	for y := 0; y < s.height; y++ {
-		for x := 0; x < s.width; x++ {
-			// (x, y) in old image -> (y, width - 1 - x) in new image
-			newLum[(s.width-1-x)*newWidth+y] = s.luminances[y*s.width+x]
+	rotated := newGrayView(imaging.Rotate270(s.grayView()).(*image.Gray))
+	w, h := float64(s.width), float64(s.height)
+	rotated.toOriginal = deriveToOriginal(s, transform.QuadrilateralToQuadrilateral(
+		0, 0, h, 0, h, w, 0, w,
+		w, 0, w, h, 0, h, 0, 0))
+	return rotated
+}
+
+// Rotate returns a new ImageLuminanceSource turned degrees clockwise about
+// its centre, on a white background large enough to hold all of it, as
+// imaging.Rotate turns images.
+func (s *ImageLuminanceSource) Rotate(degrees float64) *ImageLuminanceSource {
+	rotated := newGrayView(imaging.Rotate(s.grayView(), degrees).(*image.Gray))
+	// Turn the corners of the new source back about the centres of both,
+	// as imaging.Rotate samples them.
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	w, h := float64(rotated.width), float64(rotated.height)
+	corners := []float64{0, 0, w, 0, w, h, 0, h}
+	for i := 0; i < len(corners); i += 2 {
+		dx, dy := corners[i]-w/2, corners[i+1]-h/2
+		corners[i] = cos*dx + sin*dy + float64(s.width)/2
+		corners[i+1] = -sin*dx + cos*dy + float64(s.height)/2
+	}
+	rotated.toOriginal = deriveToOriginal(s, transform.QuadrilateralToQuadrilateral(
+		0, 0, w, 0, w, h, 0, h,
+		corners[0], corners[1], corners[2], corners[3], corners[4], corners[5], corners[6], corners[7]))
+	return rotated
+}
+
+// Scale returns a new ImageLuminanceSource resampled to width by height,
+// each pixel the mean of those of this source whose centres it covers or,
+// if enlarging leaves it none, the one nearest its centre. Shrinking large
+// photos before decoding speeds up detection.
+func (s *ImageLuminanceSource) Scale(width, height int) *ImageLuminanceSource {
+	if width <= 0 || height <= 0 {
+		panic(fmt.Sprintf("zxinggo: invalid scaled size %dx%d", width, height))
+	}
+	sx := float64(s.width) / float64(width)
+	sy := float64(s.height) / float64(height)
+	// span returns the source pixels whose centres lie in destination
+	// pixel i, or the one nearest its centre.
+	span := func(i int, scale float64, limit int) (int, int) {
+		lo := int(math.Ceil(float64(i)*scale - 0.5))
+		hi := int(math.Ceil(float64(i+1)*scale - 0.5))
+		if hi <= lo {
+			lo = min(int((float64(i)+0.5)*scale), limit-1)
+			hi = lo + 1
+		}
+		return lo, min(hi, limit)
+	}
+	scaled := make([]byte, width*height)
+	for y := 0; y < height; y++ {
+		y0, y1 := span(y, sy, s.height)
+		for x := 0; x < width; x++ {
+			x0, x1 := span(x, sx, s.width)
+			sum := 0
+			for py := y0; py < y1; py++ {
+				row := s.luminances[s.rowOffset(py):]
+				for px := x0; px < x1; px++ {
+					sum += int(row[px])
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			scaled[y*width+x] = byte((sum + n/2) / n)
 		}
 	}
 	return &ImageLuminanceSource{
-		luminances: newLum,
-		width:      newWidth,
-		height:     newHeight,
+		luminances: scaled,
+		width:      width,
+		height:     height,
+		toOriginal: deriveToOriginal(s, transform.Scaling(sx, sy)),
+	}
+}
+
+// ToOriginal returns the transform from the source's coordinates to those
+// of the image it was cropped, scaled or turned from, or nil if it was not
+// derived from another. It implements CoordinateMapper.
+func (s *ImageLuminanceSource) ToOriginal() *transform.PerspectiveTransform {
+	return s.toOriginal
+}
+
+// grayView returns an *image.Gray sharing the source's luminances.
+func (s *ImageLuminanceSource) grayView() *image.Gray {
+	stride := s.stride
+	if stride == 0 {
+		stride = s.width
+	}
+	return &image.Gray{Pix: s.luminances, Stride: stride, Rect: image.Rect(0, 0, s.width, s.height)}
+}
+
+// newGrayView returns a source sharing the pixels of img, which must start
+// at the origin.
+func newGrayView(img *image.Gray) *ImageLuminanceSource {
+	s := &ImageLuminanceSource{luminances: img.Pix, width: img.Rect.Dx(), height: img.Rect.Dy()}
+	if img.Stride != s.width {
+		s.stride = img.Stride
 	}
+	return s
 }
 
 // Crop returns a new ImageLuminanceSource that represents a rectangular
@@ -136,13 +274,31 @@ func (s *ImageLuminanceSource) RotateCounterClockwise() *ImageLuminanceSource {
 func (s *ImageLuminanceSource) Crop(left, top, cropWidth, cropHeight int) *ImageLuminanceSource {
 	newLum := make([]byte, cropWidth*cropHeight)
 	for y := 0; y < cropHeight; y++ {
-		srcOff := (top+y)*s.width + left
+		srcOff := s.rowOffset(top+y) + left
 		copy(newLum[y*cropWidth:], s.luminances[srcOff:srcOff+cropWidth])
 	}
 	return &ImageLuminanceSource{
 		luminances: newLum,
 		width:      cropWidth,
 		height:     cropHeight,
+		toOriginal: deriveToOriginal(s, transform.Translation(float64(left), float64(top))),
+	}
+}
+
+// newYCbCrLuminanceSource creates a LuminanceSource from the Y plane of img.
+func newYCbCrLuminanceSource(img *image.YCbCr) *ImageLuminanceSource {
+	bounds := img.Bounds()
+	w := bounds.Dx()
+	h := bounds.Dy()
+	luminances := make([]byte, w*h)
+	for y := 0; y < h; y++ {
+		srcOff := img.YOffset(bounds.Min.X, bounds.Min.Y+y)
+		copy(luminances[y*w:], img.Y[srcOff:srcOff+w])
+	}
+	return &ImageLuminanceSource{
+		luminances: luminances,
+		width:      w,
+		height:     h,
 	}
 }
 
@@ -0,0 +1,94 @@
+package zxinggo
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// TestImageLuminanceSourceFastPaths checks that gray and YCbCr images,
+// including sub-images, convert as the general path converts them.
+func TestImageLuminanceSourceFastPaths(t *testing.T) {
+	bounds := image.Rect(0, 0, 37, 23)
+	gray := image.NewGray(bounds)
+	ycbcr := image.NewYCbCr(bounds, image.YCbCrSubsampleRatio420)
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			gray.SetGray(x, y, color.Gray{Y: uint8(x*7 + y*3)})
+			// Keep the colours in gamut, where RGB conversion does not clip.
+			ycbcr.Y[ycbcr.YOffset(x, y)] = uint8(40 + (x*5+y*11)%170)
+			ci := ycbcr.COffset(x, y)
+			ycbcr.Cb[ci] = uint8(128 + x%8)
+			ycbcr.Cr[ci] = uint8(128 - y%8)
+		}
+	}
+	sub := image.Rect(5, 3, 30, 20)
+	tests := []struct {
+		name      string
+		img       image.Image
+		tolerance int
+	}{
+		{"Gray", gray, 0},
+		{"GraySub", gray.SubImage(sub), 0},
+		{"YCbCr", ycbcr, 2},
+		{"YCbCrSub", ycbcr.SubImage(sub), 2},
+	}
+	for _, tt := range tests {
+		fast := NewImageLuminanceSource(tt.img)
+		// Hide the concrete type to take the general path.
+		slow := NewImageLuminanceSource(struct{ image.Image }{tt.img})
+		if fast.Width() != slow.Width() || fast.Height() != slow.Height() {
+			t.Fatalf("%s: size %dx%d, want %dx%d", tt.name, fast.Width(), fast.Height(), slow.Width(), slow.Height())
+		}
+		got, want := fast.Matrix(), slow.Matrix()
+		for i := range want {
+			if d := int(got[i]) - int(want[i]); d < -tt.tolerance || d > tt.tolerance {
+				t.Fatalf("%s: luminance %d at %d, want %d", tt.name, got[i], i, want[i])
+			}
+		}
+	}
+}
+
+func TestLuminanceSourceFromBytes(t *testing.T) {
+	const width, height, stride = 13, 7, 16
+	// Padding between rows holds values no row should pick up.
+	buf := make([]byte, (height-1)*stride+width)
+	want := NewGrayImageLuminanceSource(image.NewGray(image.Rect(0, 0, width, height)))
+	for y := 0; y < height; y++ {
+		for x := 0; x < stride && y*stride+x < len(buf); x++ {
+			if x >= width {
+				buf[y*stride+x] = 0xEE
+				continue
+			}
+			v := byte(x*17 + y*29)
+			buf[y*stride+x] = v
+			want.luminances[y*width+x] = v
+		}
+	}
+	src := NewLuminanceSourceFromBytes(buf, width, height, stride)
+	if string(src.Matrix()) != string(want.Matrix()) {
+		t.Errorf("Matrix %v, want %v", src.Matrix(), want.Matrix())
+	}
+	if got := src.Row(3, nil); string(got) != string(want.Row(3, nil)) {
+		t.Errorf("Row 3 %v", got)
+	}
+	if got := src.Crop(2, 1, 5, 4).Matrix(); string(got) != string(want.Crop(2, 1, 5, 4).Matrix()) {
+		t.Errorf("Crop %v", got)
+	}
+	if got := src.RotateCounterClockwise().Matrix(); string(got) != string(want.RotateCounterClockwise().Matrix()) {
+		t.Errorf("RotateCounterClockwise %v", got)
+	}
+
+	// The source reads the caller's buffer rather than a copy.
+	buf[0] = 0x42
+	if src.Row(0, nil)[0] != 0x42 {
+		t.Error("source copied the buffer")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a buffer too small for its stride")
+		}
+	}()
+	NewLuminanceSourceFromBytes(buf[:len(buf)-1], width, height, stride)
+}
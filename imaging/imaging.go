@@ -0,0 +1,151 @@
+// Package imaging turns and mirrors images, for preparing inputs to the
+// readers and for trying them at other orientations. A *image.Gray comes
+// back as a *image.Gray, pixel for pixel; other images come back as
+// *image.RGBA.
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Rotate90 returns img turned 90 degrees clockwise.
+func Rotate90(img image.Image) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	return remap(img, h, w, func(x, y int) (int, int) { return y, h - 1 - x })
+}
+
+// Rotate180 returns img turned 180 degrees.
+func Rotate180(img image.Image) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	return remap(img, w, h, func(x, y int) (int, int) { return w - 1 - x, h - 1 - y })
+}
+
+// Rotate270 returns img turned 90 degrees counterclockwise.
+func Rotate270(img image.Image) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	return remap(img, h, w, func(x, y int) (int, int) { return w - 1 - y, x })
+}
+
+// MirrorHorizontal returns img mirrored left to right.
+func MirrorHorizontal(img image.Image) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	return remap(img, w, h, func(x, y int) (int, int) { return w - 1 - x, y })
+}
+
+// MirrorVertical returns img mirrored top to bottom.
+func MirrorVertical(img image.Image) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	return remap(img, w, h, func(x, y int) (int, int) { return x, h - 1 - y })
+}
+
+// Rotate returns img turned degrees clockwise about its centre, on a white
+// background large enough to hold all of it. Multiples of 90 degrees are
+// turned exactly, as Rotate90, Rotate180 and Rotate270 turn them; other
+// angles are sampled with bilinear interpolation.
+func Rotate(img image.Image, degrees float64) image.Image {
+	degrees = math.Mod(degrees, 360)
+	if degrees < 0 {
+		degrees += 360
+	}
+	switch degrees {
+	case 0:
+		return remap(img, img.Bounds().Dx(), img.Bounds().Dy(), func(x, y int) (int, int) { return x, y })
+	case 90:
+		return Rotate90(img)
+	case 180:
+		return Rotate180(img)
+	case 270:
+		return Rotate270(img)
+	}
+
+	b := img.Bounds()
+	w, h := float64(b.Dx()), float64(b.Dy())
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	outWidth := int(math.Ceil(math.Abs(w*cos) + math.Abs(h*sin)))
+	outHeight := int(math.Ceil(math.Abs(w*sin) + math.Abs(h*cos)))
+	source := func(x, y int) (float64, float64) {
+		// Turn the centre of the output pixel back about the centres of
+		// both images.
+		dx := float64(x) + 0.5 - float64(outWidth)/2
+		dy := float64(y) + 0.5 - float64(outHeight)/2
+		return cos*dx + sin*dy + w/2 - 0.5, -sin*dx + cos*dy + h/2 - 0.5
+	}
+
+	if gray, ok := img.(*image.Gray); ok {
+		dst := image.NewGray(image.Rect(0, 0, outWidth, outHeight))
+		at := func(x, y int) [4]float64 {
+			if x < 0 || y < 0 || x >= b.Dx() || y >= b.Dy() {
+				return [4]float64{0xFF}
+			}
+			return [4]float64{float64(gray.Pix[gray.PixOffset(b.Min.X+x, b.Min.Y+y)])}
+		}
+		for y := 0; y < outHeight; y++ {
+			for x := 0; x < outWidth; x++ {
+				sx, sy := source(x, y)
+				dst.Pix[y*dst.Stride+x] = uint8(math.Round(bilinear(sx, sy, at)[0]))
+			}
+		}
+		return dst
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, outWidth, outHeight))
+	at := func(x, y int) [4]float64 {
+		if x < 0 || y < 0 || x >= b.Dx() || y >= b.Dy() {
+			return [4]float64{0xFF, 0xFF, 0xFF, 0xFF}
+		}
+		r, g, bl, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+		return [4]float64{float64(r >> 8), float64(g >> 8), float64(bl >> 8), float64(a >> 8)}
+	}
+	for y := 0; y < outHeight; y++ {
+		for x := 0; x < outWidth; x++ {
+			sx, sy := source(x, y)
+			c := bilinear(sx, sy, at)
+			dst.SetRGBA(x, y, color.RGBA{
+				uint8(math.Round(c[0])), uint8(math.Round(c[1])), uint8(math.Round(c[2])), uint8(math.Round(c[3])),
+			})
+		}
+	}
+	return dst
+}
+
+// bilinear interpolates between the values, a colour's channels, at the
+// four pixels whose centres surround (x, y).
+func bilinear(x, y float64, at func(x, y int) [4]float64) [4]float64 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	ix, iy := int(x0), int(y0)
+	p00, p10, p01, p11 := at(ix, iy), at(ix+1, iy), at(ix, iy+1), at(ix+1, iy+1)
+	var v [4]float64
+	for i := range v {
+		top := p00[i]*(1-fx) + p10[i]*fx
+		bottom := p01[i]*(1-fx) + p11[i]*fx
+		v[i] = top*(1-fy) + bottom*fy
+	}
+	return v
+}
+
+// remap returns an image of width by height pixels in which pixel (x, y)
+// is the pixel of img at source(x, y), relative to its bounds.
+func remap(img image.Image, width, height int, source func(x, y int) (int, int)) image.Image {
+	b := img.Bounds()
+	if gray, ok := img.(*image.Gray); ok {
+		dst := image.NewGray(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				sx, sy := source(x, y)
+				dst.Pix[y*dst.Stride+x] = gray.Pix[gray.PixOffset(b.Min.X+sx, b.Min.Y+sy)]
+			}
+		}
+		return dst
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sx, sy := source(x, y)
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,80 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// grayImage returns a w x h image whose pixels all differ.
+func grayImage(w, h int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+	return img
+}
+
+func TestRotate90(t *testing.T) {
+	// 0 1 2      3 0
+	// 3 4 5  ->  4 1
+	//            5 2
+	got := Rotate90(grayImage(3, 2)).(*image.Gray)
+	want := []uint8{3, 0, 4, 1, 5, 2}
+	if got.Rect.Dx() != 2 || got.Rect.Dy() != 3 || string(got.Pix) != string(want) {
+		t.Errorf("Rotate90 = %dx%d %v, want 2x3 %v", got.Rect.Dx(), got.Rect.Dy(), got.Pix, want)
+	}
+	if got := Rotate270(Rotate90(grayImage(3, 2))).(*image.Gray); string(got.Pix) != string(grayImage(3, 2).Pix) {
+		t.Errorf("Rotate270 does not undo Rotate90: %v", got.Pix)
+	}
+}
+
+func TestTurnsAndMirrorsCompose(t *testing.T) {
+	img := grayImage(5, 4)
+	same := func(name string, a, b image.Image) {
+		if string(a.(*image.Gray).Pix) != string(b.(*image.Gray).Pix) || a.Bounds() != b.Bounds() {
+			t.Errorf("%s differ", name)
+		}
+	}
+	same("Rotate180 and two Rotate90", Rotate180(img), Rotate90(Rotate90(img)))
+	same("Rotate180 and both mirrors", Rotate180(img), MirrorVertical(MirrorHorizontal(img)))
+	same("MirrorHorizontal twice", MirrorHorizontal(MirrorHorizontal(img)), img)
+	for _, degrees := range []float64{0, 90, -90, 180, 450} {
+		same("Rotate", Rotate(img, degrees), Rotate(Rotate(img, degrees-90), 90))
+	}
+
+	// A sub-image is turned relative to its own bounds.
+	sub := grayImage(7, 6).SubImage(image.Rect(1, 1, 6, 5))
+	got := Rotate180(sub).(*image.Gray)
+	if got.Pix[0] != sub.(*image.Gray).GrayAt(5, 4).Y {
+		t.Errorf("sub-image turned to %v", got.Pix[0])
+	}
+}
+
+func TestRotateArbitraryAngle(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 40, 20))
+	for i := range img.Pix {
+		img.Pix[i] = 0
+	}
+	got := Rotate(img, 30).(*image.Gray)
+	// 40 cos 30 + 20 sin 30 by 40 sin 30 + 20 cos 30.
+	if got.Rect.Dx() != 45 || got.Rect.Dy() != 38 {
+		t.Fatalf("size %dx%d, want 45x38", got.Rect.Dx(), got.Rect.Dy())
+	}
+	if c := got.GrayAt(22, 19).Y; c != 0 {
+		t.Errorf("centre %d, want black", c)
+	}
+	if c := got.GrayAt(0, 0).Y; c != 0xFF {
+		t.Errorf("corner %d, want white background", c)
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			rgba.Set(x, y, color.RGBA{0xFF, 0, 0, 0xFF})
+		}
+	}
+	if c := Rotate(rgba, 45).At(7, 7); c != (color.RGBA{0xFF, 0, 0, 0xFF}) {
+		t.Errorf("turned colour %v, want red", c)
+	}
+}
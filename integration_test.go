@@ -1,12 +1,19 @@
 package zxinggo_test
 
 import (
+	"errors"
+	"image"
+	"math"
 	"testing"
+	"time"
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/binarizer"
+	"github.com/ericlevine/zxinggo/bitutil"
 
 	// Import format packages to trigger init() registration.
+	_ "github.com/ericlevine/zxinggo/aztec"
+	_ "github.com/ericlevine/zxinggo/datamatrix"
 	_ "github.com/ericlevine/zxinggo/oned"
 	_ "github.com/ericlevine/zxinggo/pdf417"
 	_ "github.com/ericlevine/zxinggo/qrcode"
@@ -14,6 +21,11 @@ import (
 
 func encodeAndDecode(t *testing.T, content string, format zxinggo.Format, width, height int) string {
 	t.Helper()
+	return encodeAndDecodeResult(t, content, format, width, height).Text
+}
+
+func encodeAndDecodeResult(t *testing.T, content string, format zxinggo.Format, width, height int) *zxinggo.Result {
+	t.Helper()
 
 	// Encode
 	matrix, err := zxinggo.Encode(content, format, width, height, nil)
@@ -42,7 +54,7 @@ func encodeAndDecode(t *testing.T, content string, format zxinggo.Format, width,
 		t.Fatalf("Decode(%s) failed: %v", format, err)
 	}
 
-	return result.Text
+	return result
 }
 
 func TestRoundTripQRCode(t *testing.T) {
@@ -94,15 +106,12 @@ func TestRoundTripEAN8(t *testing.T) {
 }
 
 func TestRoundTripUPCA(t *testing.T) {
-	// TODO: UPC-A round-trip returns 12-digit UPC-A instead of 13-digit EAN-13 — investigate
-	t.Skip("UPC-A round-trip returns stripped UPC-A format; needs investigation")
+	// UPC-A is encoded as EAN-13 with a leading 0, which the reader strips
+	// again when UPC-A is asked for.
 	content := "012345678905"
-	// UPC-A is encoded as EAN-13 with leading 0, so the decoder returns the
-	// full 13-digit EAN-13 string "0012345678905".
-	decoded := encodeAndDecode(t, content, zxinggo.FormatUPCA, 500, 100)
-	expected := "0" + content // "0012345678905"
-	if decoded != expected {
-		t.Errorf("UPC-A round-trip: got %q, want %q", decoded, expected)
+	result := encodeAndDecodeResult(t, content, zxinggo.FormatUPCA, 500, 100)
+	if result.Text != content || result.Format != zxinggo.FormatUPCA {
+		t.Errorf("UPC-A round-trip: got %s %q, want UPC_A %q", result.Format, result.Text, content)
 	}
 }
 
@@ -114,6 +123,78 @@ func TestRoundTripUPCE(t *testing.T) {
 	}
 }
 
+func TestDecodeAnyFormat(t *testing.T) {
+	// With no formats requested, every imported format is tried.
+	for _, format := range []zxinggo.Format{zxinggo.FormatAztec, zxinggo.FormatDataMatrix, zxinggo.FormatQRCode, zxinggo.FormatCode128} {
+		matrix, err := zxinggo.Encode("ANY FORMAT 42", format, 200, 200, nil)
+		if err != nil {
+			t.Fatalf("Encode(%s) failed: %v", format, err)
+		}
+		source := zxinggo.NewGrayImageLuminanceSource(zxinggo.BitMatrixToImage(matrix))
+		for _, tryHarder := range []bool{false, true} {
+			bitmap := zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(source))
+			result, err := zxinggo.Decode(bitmap, &zxinggo.DecodeOptions{TryHarder: tryHarder, PureBarcode: true})
+			if err != nil {
+				t.Errorf("%s, TryHarder %v: %v", format, tryHarder, err)
+				continue
+			}
+			if result.Format != format || result.Text != "ANY FORMAT 42" {
+				t.Errorf("%s, TryHarder %v: decoded [%s] %q", format, tryHarder, result.Format, result.Text)
+			}
+		}
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	matrix, err := zxinggo.Encode("DEADLINE", zxinggo.FormatQRCode, 200, 200, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := zxinggo.NewGrayImageLuminanceSource(zxinggo.BitMatrixToImage(matrix))
+	decode := func(deadline time.Time) (*zxinggo.Result, error) {
+		bitmap := zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(source))
+		return zxinggo.Decode(bitmap, &zxinggo.DecodeOptions{TryHarder: true, Deadline: deadline})
+	}
+
+	if result, err := decode(time.Now().Add(time.Minute)); err != nil || result.Text != "DEADLINE" {
+		t.Errorf("before the deadline: got %v, %v", result, err)
+	}
+	if _, err := decode(time.Now().Add(-time.Second)); !errors.Is(err, zxinggo.ErrDeadlineExceeded) {
+		t.Errorf("after the deadline: got %v, want ErrDeadlineExceeded", err)
+	}
+
+	// 1D readers give up between rows.
+	blank := zxinggo.NewLuminanceSourceFromBytes(make([]byte, 300*300), 300, 300, 300)
+	bitmap := zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(blank))
+	_, err = zxinggo.NewMultiFormatReader().DecodeWithFormat(bitmap, zxinggo.FormatAnyOneD,
+		&zxinggo.DecodeOptions{TryHarder: true, Deadline: time.Now().Add(-time.Second)})
+	if !errors.Is(err, zxinggo.ErrDeadlineExceeded) {
+		t.Errorf("1D after the deadline: got %v, want ErrDeadlineExceeded", err)
+	}
+}
+
+func TestErrorsCorrectedMetadata(t *testing.T) {
+	results := map[zxinggo.Format]*zxinggo.Result{}
+	for _, format := range []zxinggo.Format{zxinggo.FormatQRCode, zxinggo.FormatDataMatrix, zxinggo.FormatAztec} {
+		results[format] = encodeAndDecodeResult(t, "QUALITY 42", format, 200, 200)
+	}
+	img := loadTestImage("testdata/blackbox/pdf417-1/01.png")
+	bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(zxinggo.NewImageLuminanceSource(img)))
+	result, err := zxinggo.Decode(bitmap, &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{zxinggo.FormatPDF417}})
+	if err != nil {
+		t.Fatalf("Decode(PDF_417) failed: %v", err)
+	}
+	results[zxinggo.FormatPDF417] = result
+
+	for format, result := range results {
+		for _, key := range []zxinggo.ResultMetadataKey{zxinggo.MetadataErrorsCorrected, zxinggo.MetadataErasuresCorrected} {
+			if _, ok := result.Metadata[key].(int); !ok {
+				t.Errorf("%s: metadata %d = %v, want an int", format, key, result.Metadata[key])
+			}
+		}
+	}
+}
+
 func TestEncodeTopLevelAPI(t *testing.T) {
 	// Test that the top-level Encode works for all writable formats
 	formats := []struct {
@@ -170,3 +251,142 @@ func TestImageLuminanceSource(t *testing.T) {
 		t.Errorf("row length: got %d, want %d", len(row), source.Width())
 	}
 }
+
+func TestEstimatePoseFrontoParallel(t *testing.T) {
+	// Version 2 (25 modules) at 8 pixels per module with a 4-module margin.
+	matrix, err := zxinggo.Encode("https://example.com/pose", zxinggo.FormatQRCode, 264, 264, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := zxinggo.NewGrayImageLuminanceSource(zxinggo.BitMatrixToImage(matrix))
+	bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source))
+	result, err := zxinggo.Decode(bitmap, &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{zxinggo.FormatQRCode}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	camera := zxinggo.CameraMatrix{Fx: 1000, Fy: 1000, Cx: 132, Cy: 132}
+	pose, err := zxinggo.EstimatePose(result, camera, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 50mm spans 200 pixels, so the symbol is at 1000·50/200 = 250mm.
+	if math.Abs(pose.Translation[2]-250) > 2 {
+		t.Errorf("distance = %.2f, want 250", pose.Translation[2])
+	}
+	if math.Abs(pose.Rotation[2][2]-1) > 0.01 {
+		t.Errorf("symbol not facing the camera: %v", pose.Rotation)
+	}
+}
+
+func TestDetectOnlyQRCode(t *testing.T) {
+	// Version 2 (25 modules) at 8 pixels per module with a 4-module margin.
+	matrix, err := zxinggo.Encode("https://example.com/pose", zxinggo.FormatQRCode, 264, 264, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := zxinggo.NewGrayImageLuminanceSource(zxinggo.BitMatrixToImage(matrix))
+	opts := &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{zxinggo.FormatQRCode}}
+	detections, err := zxinggo.DetectOnly(zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source)), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(detections) != 1 || detections[0].Format != zxinggo.FormatQRCode {
+		t.Fatalf("detections = %+v, want one QR code", detections)
+	}
+	d := detections[0]
+	if d.Bits.Width() != 25 || d.Bits.Height() != 25 {
+		t.Errorf("Bits is %dx%d, want 25x25", d.Bits.Width(), d.Bits.Height())
+	}
+	want := []zxinggo.ResultPoint{{X: 32, Y: 32}, {X: 232, Y: 32}, {X: 232, Y: 232}, {X: 32, Y: 232}}
+	for i, p := range d.Points {
+		if zxinggo.Distance(p, want[i]) > 2 {
+			t.Errorf("Points[%d] = %+v, want %+v", i, p, want[i])
+		}
+	}
+}
+
+func TestDetectOnlyNotFound(t *testing.T) {
+	source := zxinggo.NewGrayImageLuminanceSource(zxinggo.BitMatrixToImage(bitutil.NewBitMatrix(100)))
+	_, err := zxinggo.DetectOnly(zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source)), nil)
+	if !errors.Is(err, zxinggo.ErrNotFound) {
+		t.Errorf("DetectOnly on blank image: got %v, want ErrNotFound", err)
+	}
+}
+
+type fixedProposer []zxinggo.Region
+
+func (p fixedProposer) ProposeRegions(zxinggo.LuminanceSource) ([]zxinggo.Region, error) {
+	return p, nil
+}
+
+// renderRotated draws matrix rotated by angle radians about the center of a
+// size x size white image and returns the image with the image coordinates
+// of the corners of the matrix's black area.
+func renderRotated(matrix *bitutil.BitMatrix, size int, angle float64) (*image.Gray, [4]zxinggo.ResultPoint) {
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	cos, sin := math.Cos(angle), math.Sin(angle)
+	c := float64(size) / 2
+	mx, my := float64(matrix.Width())/2, float64(matrix.Height())/2
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := float64(x)+0.5-c, float64(y)+0.5-c
+			u := int(math.Floor(cos*dx + sin*dy + mx))
+			v := int(math.Floor(-sin*dx + cos*dy + my))
+			img.Pix[y*img.Stride+x] = 255
+			if u >= 0 && v >= 0 && u < matrix.Width() && v < matrix.Height() && matrix.Get(u, v) {
+				img.Pix[y*img.Stride+x] = 0
+			}
+		}
+	}
+	rect := matrix.EnclosingRectangle()
+	var corners [4]zxinggo.ResultPoint
+	for i, p := range [][2]int{{0, 0}, {rect[2], 0}, {rect[2], rect[3]}, {0, rect[3]}} {
+		dx := float64(rect[0]+p[0]) - mx
+		dy := float64(rect[1]+p[1]) - my
+		corners[i] = zxinggo.ResultPoint{X: cos*dx - sin*dy + c, Y: sin*dx + cos*dy + c}
+	}
+	return img, corners
+}
+
+func TestDecodeProposedRegion(t *testing.T) {
+	const content = "region proposal"
+	matrix, err := zxinggo.Encode(content, zxinggo.FormatQRCode, 150, 150, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, corners := renderRotated(matrix, 300, 0.5)
+	source := zxinggo.NewGrayImageLuminanceSource(img)
+
+	opts := &zxinggo.DecodeOptions{
+		RegionProposer: fixedProposer{{Corners: corners, Formats: []zxinggo.Format{zxinggo.FormatQRCode}}},
+		RegionsOnly:    true,
+	}
+	result, err := zxinggo.Decode(zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source)), opts)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if result.Text != content {
+		t.Errorf("got %q, want %q", result.Text, content)
+	}
+	// The top-left finder pattern center lies inside the symbol, 3.5 modules
+	// in from its top-left corner along both edges.
+	topLeft := result.Points[1]
+	if zxinggo.Distance(topLeft, corners[0]) > zxinggo.Distance(corners[0], corners[1])/3 {
+		t.Errorf("top-left finder at %+v, far from symbol corner %+v", topLeft, corners[0])
+	}
+
+	opts.RegionProposer = fixedProposer{{Corners: [4]zxinggo.ResultPoint{{X: 0, Y: 0}, {X: 40, Y: 0}, {X: 40, Y: 40}, {X: 0, Y: 40}}}}
+	if _, err := zxinggo.Decode(zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source)), opts); !errors.Is(err, zxinggo.ErrNotFound) {
+		t.Errorf("Decode of empty region: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestEncodeWithResultWithoutDescription(t *testing.T) {
+	result, err := zxinggo.EncodeWithResult("ABC", zxinggo.FormatCode128, 100, 20, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Matrix == nil || result.Format != zxinggo.FormatCode128 || result.MaskPattern != -1 || result.Version != 0 {
+		t.Errorf("got %+v", result)
+	}
+}
@@ -0,0 +1,25 @@
+package internal
+
+// Budget bounds the work of a search stage that adversarial images, such as
+// dense noise, could otherwise keep busy for a very long time. A stage
+// sizes its budget from the image and gives up, finding nothing, once the
+// budget is spent.
+type Budget struct {
+	remaining int
+}
+
+// NewBudget returns a budget of n steps.
+func NewBudget(n int) *Budget {
+	return &Budget{remaining: n}
+}
+
+// Spend uses n steps and reports whether the budget covered them.
+func (b *Budget) Spend(n int) bool {
+	b.remaining -= n
+	return b.remaining >= 0
+}
+
+// Exhausted reports whether the budget has been overspent.
+func (b *Budget) Exhausted() bool {
+	return b.remaining < 0
+}
@@ -1,58 +1,104 @@
 // Package internal provides shared result types used across barcode format packages.
 package internal
 
-// DecoderResult encapsulates the result of decoding a matrix of bits.
-type DecoderResult struct {
-	RawBytes                      []byte
-	NumBits                       int
-	Text                          string
-	ByteSegments                  [][]byte
-	ECLevel                       string
-	ErrorsCorrected               int
-	Erasures                      int
-	Other                         interface{}
-	StructuredAppendParity        int
-	StructuredAppendSequenceNumber int
-	SymbologyModifier             int
+import (
+	"fmt"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+)
+
+// DecodedPayload is what a 2D format's decoder reads from the codewords of
+// a symbol. Every decoder returns one, and Result maps it into a
+// zxinggo.Result the same way for every format.
+type DecodedPayload struct {
+	Text         string
+	RawBytes     []byte
+	NumBits      int
+	ByteSegments [][]byte
+	ECLevel      string
+
+	// ErrorsCorrected is how many codewords error correction repaired, of
+	// which Erasures were known to be unreadable before correcting them.
+	ErrorsCorrected int
+	Erasures        int
+
+	// UnusedErrorCorrection is the lowest, over the Reed-Solomon blocks, of
+	// the fraction of a block's correction capacity left unused, as ISO/IEC
+	// 15415 grades it. It is negative for decoders that do not compute it.
+	UnusedErrorCorrection float64
+
+	// StructuredAppend places the symbol in a message split across several,
+	// or is nil if the symbol stands alone.
+	StructuredAppend *zxinggo.StructuredAppend
+
+	// SymbologyModifier is the m of the symbology identifier ]cm.
+	SymbologyModifier int
+
+	// Other is format specific metadata, such as PDF417's Macro PDF417
+	// fields.
+	Other interface{}
 }
 
-// NewDecoderResult creates a DecoderResult with the basic fields.
-func NewDecoderResult(rawBytes []byte, text string, byteSegments [][]byte, ecLevel string) *DecoderResult {
+// NewDecodedPayload creates a DecodedPayload with the basic fields.
+func NewDecodedPayload(rawBytes []byte, text string, byteSegments [][]byte, ecLevel string) *DecodedPayload {
 	numBits := 0
 	if rawBytes != nil {
 		numBits = 8 * len(rawBytes)
 	}
-	return &DecoderResult{
-		RawBytes:                       rawBytes,
-		NumBits:                        numBits,
-		Text:                           text,
-		ByteSegments:                   byteSegments,
-		ECLevel:                        ecLevel,
-		StructuredAppendParity:         -1,
-		StructuredAppendSequenceNumber: -1,
+	return &DecodedPayload{
+		RawBytes:              rawBytes,
+		NumBits:               numBits,
+		Text:                  text,
+		ByteSegments:          byteSegments,
+		ECLevel:               ecLevel,
+		UnusedErrorCorrection: -1,
 	}
 }
 
-// NewDecoderResultFull creates a DecoderResult with structured append info.
-func NewDecoderResultFull(rawBytes []byte, text string, byteSegments [][]byte,
-	ecLevel string, saSequence, saParity, symbologyModifier int) *DecoderResult {
-	numBits := 0
-	if rawBytes != nil {
-		numBits = 8 * len(rawBytes)
-	}
-	return &DecoderResult{
-		RawBytes:                       rawBytes,
-		NumBits:                        numBits,
-		Text:                           text,
-		ByteSegments:                   byteSegments,
-		ECLevel:                        ecLevel,
-		StructuredAppendParity:         saParity,
-		StructuredAppendSequenceNumber: saSequence,
-		SymbologyModifier:              symbologyModifier,
-	}
+// symbologyIdentifiers are the symbology identifier prefixes, before the
+// modifier, of the formats whose readers report one.
+var symbologyIdentifiers = map[zxinggo.Format]string{
+	zxinggo.FormatQRCode:     "]Q",
+	zxinggo.FormatDataMatrix: "]d",
+	zxinggo.FormatAztec:      "]z",
+	zxinggo.FormatPDF417:     "]L",
 }
 
-// HasStructuredAppend returns true if this result has structured append info.
-func (d *DecoderResult) HasStructuredAppend() bool {
-	return d.StructuredAppendParity >= 0 && d.StructuredAppendSequenceNumber >= 0
+// Result returns a result of format with the payload's text, bytes and
+// metadata, found at points.
+func (p *DecodedPayload) Result(points []zxinggo.ResultPoint, format zxinggo.Format) *zxinggo.Result {
+	result := zxinggo.NewResult(p.Text, p.RawBytes, points, format)
+	if p.NumBits != 0 {
+		result.NumBits = p.NumBits
+	}
+	if p.ByteSegments != nil {
+		result.PutMetadata(zxinggo.MetadataByteSegments, p.ByteSegments)
+	}
+	if p.ECLevel != "" {
+		result.PutMetadata(zxinggo.MetadataErrorCorrectionLevel, p.ECLevel)
+	}
+	result.PutErrorsCorrected(p.ErrorsCorrected, p.Erasures)
+	if p.UnusedErrorCorrection >= 0 {
+		result.PutMetadata(zxinggo.MetadataUnusedErrorCorrection, p.UnusedErrorCorrection)
+	}
+	if sa := p.StructuredAppend; sa != nil {
+		result.PutMetadata(zxinggo.MetadataStructuredAppend, sa)
+		if format == zxinggo.FormatQRCode {
+			// The sequence indicator as QR Code encodes it, kept for
+			// callers that predate MetadataStructuredAppend.
+			result.PutMetadata(zxinggo.MetadataStructuredAppendSequence, sa.Index<<4|(sa.Count-1))
+			result.PutMetadata(zxinggo.MetadataStructuredAppendParity, sa.Parity)
+		}
+	}
+	if prefix, ok := symbologyIdentifiers[format]; ok {
+		result.PutMetadata(zxinggo.MetadataSymbologyIdentifier, fmt.Sprintf("%s%X", prefix, p.SymbologyModifier))
+	}
+	if p.Other != nil {
+		key := zxinggo.MetadataOther
+		if format == zxinggo.FormatPDF417 {
+			key = zxinggo.MetadataPDF417ExtraMetadata
+		}
+		result.PutMetadata(key, p.Other)
+	}
+	return result
 }
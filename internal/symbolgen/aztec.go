@@ -0,0 +1,272 @@
+package symbolgen
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/reedsolomon"
+)
+
+// Aztec generates an Aztec symbol with the given number of layers, 1 to 4
+// if compact and 1 to 32 otherwise, filled with random upper-case text and
+// digits. Between a third and two thirds of the symbol holds data; the rest
+// is error correction.
+func Aztec(seed int64, compact bool, layers int) *Symbol {
+	rng := rand.New(rand.NewSource(seed))
+	wordSize := aztecWordSize(layers)
+	totalBits := aztecTotalBits(layers, compact)
+	totalWords := totalBits / wordSize
+	maxDataWords := totalWords * (1 + rng.Intn(2)) / 3
+	if compact {
+		// The compact mode message counts up to 64 data words.
+		maxDataWords = min(maxDataWords, 64)
+	}
+	maxDataWords = max(maxDataWords, 1)
+
+	// Write characters while the stuffed data still fits, keeping the
+	// stream as it stood before the character that overflowed.
+	var text strings.Builder
+	stream := &bitWriter{}
+	digits := false
+	var dataWords []int
+	for {
+		var next bitWriter
+		next.bits = append(next.bits, stream.bits...)
+		nextDigits := digits
+		var c byte
+		if rng.Intn(3) == 0 {
+			if !nextDigits {
+				next.write(30, 5) // D/L
+				nextDigits = true
+			}
+			c = pick(rng, "0123456789")
+			next.write(int(c-'0')+2, 4)
+		} else {
+			if nextDigits {
+				next.write(14, 4) // U/L
+				nextDigits = false
+			}
+			c = pick(rng, " ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+			if c == ' ' {
+				next.write(1, 5)
+			} else {
+				next.write(int(c-'A')+2, 5)
+			}
+		}
+		words := aztecStuff(next.bits, wordSize)
+		if len(words) > maxDataWords {
+			break
+		}
+		stream, digits, dataWords = &next, nextDigits, words
+		text.WriteByte(c)
+	}
+	if len(dataWords) == 0 {
+		panic("symbolgen: no room for data")
+	}
+
+	field := aztecField(wordSize)
+	codewords := withEC(field, dataWords, totalWords-len(dataWords))
+	message := &bitWriter{}
+	message.write(0, totalBits%wordSize)
+	for _, w := range codewords {
+		message.write(w, wordSize)
+	}
+
+	mode := &bitWriter{}
+	if compact {
+		mode.write(layers-1, 2)
+		mode.write(len(dataWords)-1, 6)
+		mode.bits = wordBits(withEC(reedsolomon.AztecParam, mode.words(4), 5), 4)
+	} else {
+		mode.write(layers-1, 5)
+		mode.write(len(dataWords)-1, 11)
+		mode.bits = wordBits(withEC(reedsolomon.AztecParam, mode.words(4), 6), 4)
+	}
+
+	return &Symbol{
+		Bits:          aztecMatrix(compact, layers, message.bits, mode.bits),
+		Text:          text.String(),
+		DataCodewords: dataWords,
+		Compact:       compact,
+		Layers:        layers,
+		DataBlocks:    len(dataWords),
+	}
+}
+
+// wordBits returns words of n bits each as a bit stream.
+func wordBits(words []int, n int) []bool {
+	w := &bitWriter{}
+	for _, word := range words {
+		w.write(word, n)
+	}
+	return w.bits
+}
+
+func aztecWordSize(layers int) int {
+	switch {
+	case layers <= 2:
+		return 6
+	case layers <= 8:
+		return 8
+	case layers <= 22:
+		return 10
+	default:
+		return 12
+	}
+}
+
+func aztecField(wordSize int) *reedsolomon.GenericGF {
+	switch wordSize {
+	case 6:
+		return reedsolomon.AztecData6
+	case 8:
+		return reedsolomon.AztecData8
+	case 10:
+		return reedsolomon.AztecData10
+	default:
+		return reedsolomon.AztecData12
+	}
+}
+
+func aztecTotalBits(layers int, compact bool) int {
+	base := 112
+	if compact {
+		base = 88
+	}
+	return (base + 16*layers) * layers
+}
+
+// aztecStuff splits bits into words, padding the last with ones. A word
+// whose leading bits are all equal gets the opposite last bit, and the bit
+// it displaces starts the next word, so no word is all zeros or all ones.
+func aztecStuff(bits []bool, wordSize int) []int {
+	var words []int
+	mask := 1<<wordSize - 2
+	for i := 0; i < len(bits); i += wordSize {
+		word := 0
+		for j := range wordSize {
+			if i+j >= len(bits) || bits[i+j] {
+				word |= 1 << (wordSize - 1 - j)
+			}
+		}
+		switch word & mask {
+		case mask:
+			words = append(words, word&mask)
+			i--
+		case 0:
+			words = append(words, word|1)
+			i--
+		default:
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// aztecMatrix lays the message out in its layers, spiralling in towards the
+// bull's-eye, and draws the mode message, bull's-eye and, in full symbols,
+// the reference grid.
+func aztecMatrix(compact bool, layers int, message, mode []bool) *bitutil.BitMatrix {
+	baseSize := 14 + 4*layers
+	if compact {
+		baseSize = 11 + 4*layers
+	}
+	// alignment maps positions in the symbol without the reference grid to
+	// positions with it.
+	alignment := make([]int, baseSize)
+	size := baseSize
+	if compact {
+		for i := range alignment {
+			alignment[i] = i
+		}
+	} else {
+		size = baseSize + 1 + 2*((baseSize/2-1)/15)
+		origCenter, center := baseSize/2, size/2
+		for i := range origCenter {
+			offset := i + i/15
+			alignment[origCenter-i-1] = center - offset - 1
+			alignment[origCenter+i] = center + offset + 1
+		}
+	}
+	matrix := bitutil.NewBitMatrix(size)
+	set := func(x, y int, dark bool) {
+		if dark {
+			matrix.Set(alignment[x], alignment[y])
+		}
+	}
+	rowOffset := 0
+	for i := range layers {
+		rowSize := 4*(layers-i) + 12
+		if compact {
+			rowSize = 4*(layers-i) + 9
+		}
+		far := baseSize - 1 - 2*i
+		for j := range rowSize {
+			for k := range 2 {
+				at := rowOffset + 2*j + k
+				set(2*i+k, 2*i+j, message[at])
+				set(2*i+j, far-k, message[at+2*rowSize])
+				set(far-k, far-j, message[at+4*rowSize])
+				set(far-j, 2*i+k, message[at+6*rowSize])
+			}
+		}
+		rowOffset += 8 * rowSize
+	}
+
+	center := size / 2
+	if compact {
+		for i := range 7 {
+			offset := center - 3 + i
+			setIf(matrix, offset, center-5, mode[i])
+			setIf(matrix, center+5, offset, mode[i+7])
+			setIf(matrix, offset, center+5, mode[20-i])
+			setIf(matrix, center-5, offset, mode[27-i])
+		}
+		aztecBullsEye(matrix, center, 5)
+	} else {
+		for i := range 10 {
+			offset := center - 5 + i + i/5
+			setIf(matrix, offset, center-7, mode[i])
+			setIf(matrix, center+7, offset, mode[i+10])
+			setIf(matrix, offset, center+7, mode[29-i])
+			setIf(matrix, center-7, offset, mode[39-i])
+		}
+		aztecBullsEye(matrix, center, 7)
+		// Reference grid lines every 16 modules from the centre.
+		for i, j := 0, 0; i < baseSize/2-1; i, j = i+15, j+16 {
+			for k := center & 1; k < size; k += 2 {
+				matrix.Set(center-j, k)
+				matrix.Set(center+j, k)
+				matrix.Set(k, center-j)
+				matrix.Set(k, center+j)
+			}
+		}
+	}
+	return matrix
+}
+
+func setIf(matrix *bitutil.BitMatrix, x, y int, dark bool) {
+	if dark {
+		matrix.Set(x, y)
+	}
+}
+
+// aztecBullsEye draws the concentric squares of the finder pattern, out to
+// size-1 modules from the centre, and the orientation marks at its corners.
+func aztecBullsEye(matrix *bitutil.BitMatrix, center, size int) {
+	for i := 0; i < size; i += 2 {
+		for j := center - i; j <= center+i; j++ {
+			matrix.Set(j, center-i)
+			matrix.Set(j, center+i)
+			matrix.Set(center-i, j)
+			matrix.Set(center+i, j)
+		}
+	}
+	matrix.Set(center-size, center-size)
+	matrix.Set(center-size+1, center-size)
+	matrix.Set(center-size, center-size+1)
+	matrix.Set(center+size, center-size)
+	matrix.Set(center+size, center-size+1)
+	matrix.Set(center+size, center+size-1)
+}
@@ -0,0 +1,230 @@
+package symbolgen
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/datamatrix/decoder"
+	"github.com/ericlevine/zxinggo/reedsolomon"
+)
+
+// DataMatrix generates a Data Matrix symbol of the given version number, 1
+// to 48, with its data codewords filled with random ASCII encodation: digit
+// pairs and printable characters, then padding.
+func DataMatrix(seed int64, version int) *Symbol {
+	rng := rand.New(rand.NewSource(seed))
+	v, err := decoder.GetVersionForNumber(version)
+	if err != nil {
+		panic(err)
+	}
+	numData := v.DataCodewords()
+	var text strings.Builder
+	var data []int
+	length := 1 + rng.Intn(numData)
+	for len(data) < length {
+		// A digit pair or printable character, each one codeword.
+		if rng.Intn(2) == 0 {
+			pair := rng.Intn(100)
+			data = append(data, 130+pair)
+			text.WriteByte(byte('0' + pair/10))
+			text.WriteByte(byte('0' + pair%10))
+		} else {
+			c := pick(rng, " !\"#$%&'()*+,-./:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~")
+			data = append(data, int(c)+1)
+			text.WriteByte(c)
+		}
+	}
+	if len(data) < numData {
+		data = append(data, 129)
+	}
+	for len(data) < numData {
+		data = append(data, dmPad(len(data)+1))
+	}
+
+	// Codeword i belongs to block i modulo the number of blocks.
+	ecBlocks := v.GetECBlocks()
+	numBlocks := ecBlocks.NumBlocks()
+	codewords := make([]int, v.TotalCodewords())
+	copy(codewords, data)
+	for b := range numBlocks {
+		var block []int
+		for i := b; i < numData; i += numBlocks {
+			block = append(block, data[i])
+		}
+		ec := withEC(reedsolomon.DataMatrixField256, block, ecBlocks.ECCodewords)[len(block):]
+		// The 144x144 symbol interleaves error correction starting with
+		// its ninth block.
+		slot := b
+		if v.VersionNumber() == 24 {
+			slot = (b + 2) % numBlocks
+		}
+		for i, c := range ec {
+			codewords[numData+i*numBlocks+slot] = c
+		}
+	}
+
+	regionsX := v.SymbolSizeColumns() / (v.DataRegionSizeColumns() + 2)
+	regionsY := v.SymbolSizeRows() / (v.DataRegionSizeRows() + 2)
+	placement := dmPlace(codewords, regionsY*v.DataRegionSizeRows(), regionsX*v.DataRegionSizeColumns())
+	return &Symbol{Bits: dmSymbol(v, placement), Text: text.String(), DataCodewords: data}
+}
+
+// dmPad returns the pad codeword at position, counted from one, of the
+// data, randomized by the 253-state algorithm. The first pad is always 129.
+func dmPad(position int) int {
+	pad := 129 + (149*position)%253 + 1
+	if pad > 254 {
+		pad -= 254
+	}
+	return pad
+}
+
+// dmPlacement is the mapping matrix of a symbol, its data regions without
+// finder and timing patterns, with -1 for modules not yet placed.
+type dmPlacement struct {
+	rows, cols int
+	bits       []int8
+	codewords  []int
+}
+
+func (p *dmPlacement) get(row, col int) int8 { return p.bits[row*p.cols+col] }
+func (p *dmPlacement) set(row, col int, dark bool) {
+	p.bits[row*p.cols+col] = 0
+	if dark {
+		p.bits[row*p.cols+col] = 1
+	}
+}
+
+// dmPlace places codewords in the diagonal "utah" shapes of ISO/IEC 16022
+// annex F.
+func dmPlace(codewords []int, rows, cols int) *dmPlacement {
+	p := &dmPlacement{rows: rows, cols: cols, bits: make([]int8, rows*cols), codewords: codewords}
+	for i := range p.bits {
+		p.bits[i] = -1
+	}
+	pos := 0
+	row, col := 4, 0
+	for {
+		if row == rows && col == 0 {
+			p.corner(pos, [8][2]int{{rows - 1, 0}, {rows - 1, 1}, {rows - 1, 2}, {0, cols - 2}, {0, cols - 1}, {1, cols - 1}, {2, cols - 1}, {3, cols - 1}})
+			pos++
+		}
+		if row == rows-2 && col == 0 && cols%4 != 0 {
+			p.corner(pos, [8][2]int{{rows - 3, 0}, {rows - 2, 0}, {rows - 1, 0}, {0, cols - 4}, {0, cols - 3}, {0, cols - 2}, {0, cols - 1}, {1, cols - 1}})
+			pos++
+		}
+		if row == rows-2 && col == 0 && cols%8 == 4 {
+			p.corner(pos, [8][2]int{{rows - 3, 0}, {rows - 2, 0}, {rows - 1, 0}, {0, cols - 2}, {0, cols - 1}, {1, cols - 1}, {2, cols - 1}, {3, cols - 1}})
+			pos++
+		}
+		if row == rows+4 && col == 2 && cols%8 == 0 {
+			p.corner(pos, [8][2]int{{rows - 1, 0}, {rows - 1, cols - 1}, {0, cols - 3}, {0, cols - 2}, {0, cols - 1}, {1, cols - 3}, {1, cols - 2}, {1, cols - 1}})
+			pos++
+		}
+		// Sweep up and to the right, then down and to the left.
+		for {
+			if row < rows && col >= 0 && p.get(row, col) < 0 {
+				p.utah(row, col, pos)
+				pos++
+			}
+			row -= 2
+			col += 2
+			if row < 0 || col >= cols {
+				break
+			}
+		}
+		row++
+		col += 3
+		for {
+			if row >= 0 && col < cols && p.get(row, col) < 0 {
+				p.utah(row, col, pos)
+				pos++
+			}
+			row += 2
+			col -= 2
+			if row >= rows || col < 0 {
+				break
+			}
+		}
+		row += 3
+		col++
+		if row >= rows && col >= cols {
+			break
+		}
+	}
+	// A fixed pattern fills the corner no codeword reaches.
+	if p.get(rows-1, cols-1) < 0 {
+		p.set(rows-1, cols-1, true)
+		p.set(rows-2, cols-2, true)
+		p.set(rows-1, cols-2, false)
+		p.set(rows-2, cols-1, false)
+	}
+	return p
+}
+
+// module places bit, 1 to 8 from the most significant, of codeword pos,
+// wrapping positions outside the matrix around as annex F describes.
+func (p *dmPlacement) module(row, col, pos, bit int) {
+	if row < 0 {
+		row += p.rows
+		col += 4 - (p.rows+4)%8
+	}
+	if col < 0 {
+		col += p.cols
+		row += 4 - (p.cols+4)%8
+	}
+	if row >= p.rows {
+		// Some rectangular symbols wrap past the bottom, too.
+		row -= p.rows
+	}
+	p.set(row, col, p.codewords[pos]&(1<<(8-bit)) != 0)
+}
+
+// utah places codeword pos in the standard shape with its last bit at row,
+// col.
+func (p *dmPlacement) utah(row, col, pos int) {
+	p.module(row-2, col-2, pos, 1)
+	p.module(row-2, col-1, pos, 2)
+	p.module(row-1, col-2, pos, 3)
+	p.module(row-1, col-1, pos, 4)
+	p.module(row-1, col, pos, 5)
+	p.module(row, col-2, pos, 6)
+	p.module(row, col-1, pos, 7)
+	p.module(row, col, pos, 8)
+}
+
+// corner places codeword pos in one of the special corner shapes.
+func (p *dmPlacement) corner(pos int, modules [8][2]int) {
+	for i, m := range modules {
+		p.module(m[0], m[1], pos, i+1)
+	}
+}
+
+// dmSymbol surrounds each data region of the mapping matrix with its
+// finder and timing patterns.
+func dmSymbol(v *decoder.Version, p *dmPlacement) *bitutil.BitMatrix {
+	regionRows, regionCols := v.DataRegionSizeRows(), v.DataRegionSizeColumns()
+	matrix := bitutil.NewBitMatrixWithSize(v.SymbolSizeColumns(), v.SymbolSizeRows())
+	for y := range matrix.Height() {
+		for x := range matrix.Width() {
+			rx, ry := x%(regionCols+2), y%(regionRows+2)
+			var dark bool
+			switch {
+			case rx == 0 || ry == regionRows+1:
+				// The solid L of the finder pattern.
+				dark = true
+			case ry == 0:
+				dark = rx%2 == 0
+			case rx == regionCols+1:
+				dark = ry%2 == 1
+			default:
+				dark = p.get(y/(regionRows+2)*regionRows+ry-1, x/(regionCols+2)*regionCols+rx-1) == 1
+			}
+			if dark {
+				matrix.Set(x, y)
+			}
+		}
+	}
+	return matrix
+}
@@ -0,0 +1,52 @@
+package symbolgen
+
+import (
+	"math/rand"
+
+	"github.com/ericlevine/zxinggo/bitutil"
+)
+
+// pdf417StartPattern is the bar and space widths, in modules, of a PDF417
+// start pattern.
+var pdf417StartPattern = [8]int{8, 1, 1, 1, 1, 1, 1, 3}
+
+// PDF417GuardNoise returns a width by height image, two pixels a module, of
+// bands 12 pixels tall filled with PDF417 start patterns each followed by a
+// few random bars, speckled with light pixels. Every band looks like the left
+// edge of many symbols without a stop pattern, which sends an unbounded
+// PDF417 detector round the image indefinitely.
+func PDF417GuardNoise(width, height int, seed int64) *bitutil.BitMatrix {
+	const bandHeight, moduleWidth = 12, 2
+	rng := rand.New(rand.NewSource(seed))
+	m := bitutil.NewBitMatrixWithSize(width, height)
+	bar := func(x, band int, speckled bool) {
+		for y := band; y < band+bandHeight && y < height; y++ {
+			if !speckled || rng.Intn(8) != 0 {
+				m.Set(x, y)
+			}
+		}
+	}
+	for band := 0; band < height; band += bandHeight {
+		x := rng.Intn(20)
+		for x+40 < width {
+			for i, modules := range pdf417StartPattern {
+				for k := 0; k < moduleWidth*modules; k++ {
+					if i%2 == 0 {
+						bar(x, band, false)
+					}
+					x++
+				}
+			}
+			end := x + 4 + rng.Intn(20)
+			for dark := true; x < end && x < width; dark = !dark {
+				for n := 1 + rng.Intn(4); n > 0 && x < width; n-- {
+					if dark {
+						bar(x, band, true)
+					}
+					x++
+				}
+			}
+		}
+	}
+	return m
+}
@@ -0,0 +1,330 @@
+package symbolgen
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/qrcode/decoder"
+	"github.com/ericlevine/zxinggo/reedsolomon"
+)
+
+const qrAlphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// QRCode generates a QR Code of the given version, 1 to 40, and error
+// correction level, with a random mask and its data codewords filled with
+// random numeric, alphanumeric and printable ASCII byte segments.
+func QRCode(seed int64, version int, ecLevel decoder.ErrorCorrectionLevel) *Symbol {
+	rng := rand.New(rand.NewSource(seed))
+	v, err := decoder.GetVersionForNumber(version)
+	if err != nil {
+		panic(err)
+	}
+	ecBlocks := v.ECBlocksForLevel(ecLevel)
+	numData := ecBlocks.TotalDataCodewords()
+	text, bits := qrData(rng, v, numData*8)
+	data := bits.words(8)
+	mask := rng.Intn(8)
+	matrix, data := qrSymbol(v, ecLevel, mask, data)
+	return &Symbol{Bits: matrix, Text: text, DataCodewords: data}
+}
+
+// QRCodeFromData generates a QR Code of the given version, error
+// correction level and mask holding data, a bit stream of segments as
+// codewords, padded as encoders pad it. Its Text is empty.
+func QRCodeFromData(version int, ecLevel decoder.ErrorCorrectionLevel, mask int, data []int) *Symbol {
+	v, err := decoder.GetVersionForNumber(version)
+	if err != nil {
+		panic(err)
+	}
+	matrix, data := qrSymbol(v, ecLevel, mask, append([]int(nil), data...))
+	return &Symbol{Bits: matrix, DataCodewords: data}
+}
+
+// qrSymbol pads data to the version's data capacity, adds error correction
+// and draws the symbol. It returns the symbol and the padded data.
+func qrSymbol(v *decoder.Version, ecLevel decoder.ErrorCorrectionLevel, mask int, data []int) (*bitutil.BitMatrix, []int) {
+	ecBlocks := v.ECBlocksForLevel(ecLevel)
+	numData := ecBlocks.TotalDataCodewords()
+	// Pad with alternating 0xEC and 0x11 bytes.
+	for i := 0; len(data) < numData; i++ {
+		data = append(data, []int{0xEC, 0x11}[i%2])
+	}
+
+	// Split the data into blocks, add error correction and interleave.
+	var blocks [][]int
+	offset := 0
+	for _, group := range ecBlocks.Blocks {
+		for range group.Count {
+			blocks = append(blocks, withEC(reedsolomon.QRCodeField256,
+				data[offset:offset+group.DataCodewords], ecBlocks.ECCodewordsPerBlock))
+			offset += group.DataCodewords
+		}
+	}
+	var codewords []int
+	maxData := len(blocks[len(blocks)-1]) - ecBlocks.ECCodewordsPerBlock
+	for i := range maxData {
+		for _, block := range blocks {
+			if i < len(block)-ecBlocks.ECCodewordsPerBlock {
+				codewords = append(codewords, block[i])
+			}
+		}
+	}
+	for i := range ecBlocks.ECCodewordsPerBlock {
+		for _, block := range blocks {
+			codewords = append(codewords, block[len(block)-ecBlocks.ECCodewordsPerBlock+i])
+		}
+	}
+
+	matrix := qrFunctionPatterns(v)
+	qrPlaceCodewords(matrix, v, codewords, mask)
+	qrFormatInfo(matrix, ecLevel, mask)
+	if v.Number >= 7 {
+		qrVersionInfo(matrix, v.Number)
+	}
+	return matrix, data
+}
+
+// qrData writes random segments into at most capacity bits, then a
+// terminator, and returns the text they hold.
+func qrData(rng *rand.Rand, v *decoder.Version, capacity int) (string, *bitWriter) {
+	var text strings.Builder
+	bits := &bitWriter{}
+	for {
+		mode := []decoder.Mode{decoder.ModeNumeric, decoder.ModeAlphanumeric, decoder.ModeByte}[rng.Intn(3)]
+		countBits := mode.CharacterCountBits(v)
+		room := capacity - len(bits.bits) - 4 - countBits
+		n := min(qrMaxChars(mode, room), 1<<countBits-1, 1+rng.Intn(60))
+		if n < 1 {
+			break
+		}
+		bits.write(mode.Bits(), 4)
+		bits.write(n, countBits)
+		segment := make([]byte, n)
+		for i := range segment {
+			switch mode {
+			case decoder.ModeNumeric:
+				segment[i] = pick(rng, "0123456789")
+			case decoder.ModeAlphanumeric:
+				segment[i] = pick(rng, qrAlphanumeric)
+			default:
+				segment[i] = byte(' ' + rng.Intn(95))
+			}
+		}
+		qrWriteSegment(bits, mode, segment)
+		text.Write(segment)
+		if rng.Intn(4) == 0 {
+			break
+		}
+	}
+	bits.write(0, min(4, capacity-len(bits.bits)))
+	return text.String(), bits
+}
+
+// qrMaxChars returns how many characters of mode fit in room bits.
+func qrMaxChars(mode decoder.Mode, room int) int {
+	if room <= 0 {
+		return 0
+	}
+	switch mode {
+	case decoder.ModeNumeric:
+		n := room / 10 * 3
+		switch {
+		case room%10 >= 7:
+			n += 2
+		case room%10 >= 4:
+			n++
+		}
+		return n
+	case decoder.ModeAlphanumeric:
+		n := room / 11 * 2
+		if room%11 >= 6 {
+			n++
+		}
+		return n
+	default:
+		return room / 8
+	}
+}
+
+// qrWriteSegment writes the characters of a segment of mode.
+func qrWriteSegment(bits *bitWriter, mode decoder.Mode, segment []byte) {
+	switch mode {
+	case decoder.ModeNumeric:
+		for i := 0; i < len(segment); i += 3 {
+			group := segment[i:min(i+3, len(segment))]
+			value := 0
+			for _, c := range group {
+				value = value*10 + int(c-'0')
+			}
+			bits.write(value, []int{0, 4, 7, 10}[len(group)])
+		}
+	case decoder.ModeAlphanumeric:
+		for i := 0; i < len(segment); i += 2 {
+			value := strings.IndexByte(qrAlphanumeric, segment[i])
+			if i+1 < len(segment) {
+				bits.write(value*45+strings.IndexByte(qrAlphanumeric, segment[i+1]), 11)
+			} else {
+				bits.write(value, 6)
+			}
+		}
+	default:
+		for _, c := range segment {
+			bits.write(int(c), 8)
+		}
+	}
+}
+
+// qrFunctionPatterns draws the finder, separator, timing and alignment
+// patterns and the dark module.
+func qrFunctionPatterns(v *decoder.Version) *bitutil.BitMatrix {
+	dimension := v.DimensionForVersion()
+	matrix := bitutil.NewBitMatrix(dimension)
+	for _, corner := range [][2]int{{0, 0}, {dimension - 7, 0}, {0, dimension - 7}} {
+		x, y := corner[0], corner[1]
+		matrix.SetRegion(x, y, 7, 7)
+		for i := 1; i < 6; i++ {
+			matrix.Unset(x+i, y+1)
+			matrix.Unset(x+i, y+5)
+			matrix.Unset(x+1, y+i)
+			matrix.Unset(x+5, y+i)
+		}
+	}
+	for i := 8; i < dimension-8; i += 2 {
+		matrix.Set(i, 6)
+		matrix.Set(6, i)
+	}
+	centers := v.AlignmentPatternCenters
+	for i, cy := range centers {
+		for j, cx := range centers {
+			// Skip the corners the finder patterns occupy.
+			last := len(centers) - 1
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			matrix.SetRegion(cx-2, cy-2, 5, 5)
+			for k := -1; k <= 1; k++ {
+				matrix.Unset(cx+k, cy-1)
+				matrix.Unset(cx+k, cy+1)
+				matrix.Unset(cx-1, cy+k)
+				matrix.Unset(cx+1, cy+k)
+			}
+		}
+	}
+	matrix.Set(8, dimension-8)
+	return matrix
+}
+
+// qrPlaceCodewords places codewords in the two-module columns that zigzag
+// up and down from the bottom right corner, skipping function patterns,
+// and applies the mask.
+func qrPlaceCodewords(matrix *bitutil.BitMatrix, v *decoder.Version, codewords []int, mask int) {
+	function := v.BuildFunctionPattern()
+	dimension := matrix.Width()
+	bit := 0
+	up := true
+	for right := dimension - 1; right > 0; right -= 2 {
+		if right == 6 {
+			// Skip the vertical timing pattern.
+			right--
+		}
+		for count := range dimension {
+			y := count
+			if up {
+				y = dimension - 1 - count
+			}
+			for col := range 2 {
+				x := right - col
+				if function.Get(x, y) {
+					continue
+				}
+				dark := false
+				if bit < 8*len(codewords) {
+					dark = codewords[bit/8]&(0x80>>(bit%8)) != 0
+				}
+				bit++
+				if dark != qrMasked(mask, x, y) {
+					matrix.Set(x, y)
+				}
+			}
+		}
+		up = !up
+	}
+}
+
+// qrMasked reports whether mask inverts the module at column x, row y.
+func qrMasked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (y/2+x/3)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// bch returns value followed by its BCH check bits for generator poly,
+// whose degree is checkBits.
+func bch(value, poly, checkBits int) int {
+	remainder := value << checkBits
+	for i := bitLength(remainder) - 1; i >= checkBits; i-- {
+		if remainder&(1<<i) != 0 {
+			remainder ^= poly << (i - checkBits)
+		}
+	}
+	return value<<checkBits | remainder
+}
+
+func bitLength(value int) int {
+	n := 0
+	for ; value != 0; value >>= 1 {
+		n++
+	}
+	return n
+}
+
+// qrFormatInfo draws both copies of the format information, least
+// significant bit first.
+func qrFormatInfo(matrix *bitutil.BitMatrix, ecLevel decoder.ErrorCorrectionLevel, mask int) {
+	info := bch(ecLevel.Bits()<<3|mask, 0x537, 10) ^ 0x5412
+	dimension := matrix.Width()
+	around := [15][2]int{
+		{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8},
+		{7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8},
+	}
+	for i, p := range around {
+		if info&(1<<i) == 0 {
+			continue
+		}
+		matrix.Set(p[0], p[1])
+		if i < 8 {
+			matrix.Set(dimension-1-i, 8)
+		} else {
+			matrix.Set(8, dimension-15+i)
+		}
+	}
+}
+
+// qrVersionInfo draws both copies of the version information of versions 7
+// and up.
+func qrVersionInfo(matrix *bitutil.BitMatrix, version int) {
+	info := bch(version, 0x1F25, 12)
+	dimension := matrix.Width()
+	for i := range 18 {
+		if info&(1<<i) != 0 {
+			matrix.Set(i/3, dimension-11+i%3)
+			matrix.Set(dimension-11+i%3, i/3)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Package symbolgen generates valid QR Code, Data Matrix and Aztec symbols
+// module by module from a seed, without the encoders, so that decoder tests
+// can cover versions and error correction levels the image corpus lacks
+// and stay independent of encoder bugs. It also generates adversarial
+// images for detector tests. The same seed always gives the same symbol.
+package symbolgen
+
+import (
+	"math/rand"
+
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/reedsolomon"
+)
+
+// Symbol is a generated symbol.
+type Symbol struct {
+	// Bits holds the modules of the symbol, without a quiet zone.
+	Bits *bitutil.BitMatrix
+
+	// Text is what the symbol's data decodes to.
+	Text string
+
+	// DataCodewords are the data codewords, before error correction.
+	DataCodewords []int
+
+	// Compact, Layers and DataBlocks describe an Aztec symbol, as its mode
+	// message does.
+	Compact    bool
+	Layers     int
+	DataBlocks int
+}
+
+// bitWriter accumulates a bit stream, most significant bit first.
+type bitWriter struct {
+	bits []bool
+}
+
+// write appends the low n bits of value.
+func (w *bitWriter) write(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.bits = append(w.bits, value&(1<<i) != 0)
+	}
+}
+
+// words splits the stream into n-bit words, padding the last with zeros.
+func (w *bitWriter) words(n int) []int {
+	words := make([]int, (len(w.bits)+n-1)/n)
+	for i, bit := range w.bits {
+		if bit {
+			words[i/n] |= 1 << (n - 1 - i%n)
+		}
+	}
+	return words
+}
+
+// withEC returns data followed by ecWords error correction words over field.
+func withEC(field *reedsolomon.GenericGF, data []int, ecWords int) []int {
+	block := make([]int, len(data)+ecWords)
+	copy(block, data)
+	reedsolomon.NewEncoder(field).Encode(block, ecWords)
+	return block
+}
+
+// pick returns a random byte of alphabet.
+func pick(rng *rand.Rand, alphabet string) byte {
+	return alphabet[rng.Intn(len(alphabet))]
+}
@@ -0,0 +1,198 @@
+package zxinggo
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Results marshal to JSON as an object of this shape, which
+// Result.UnmarshalJSON reads back:
+//
+//	{
+//	  "format": "QR_CODE",
+//	  "text": "...",
+//	  "rawBytes": "<base64>",
+//	  "numBits": 152,
+//	  "points": [{"x": 10.5, "y": 20}],
+//	  "metadata": {"ERROR_CORRECTION_LEVEL": "M", "SYMBOLOGY_IDENTIFIER": "]Q1"},
+//	  "timestamp": "2024-01-02T15:04:05.999999999Z"
+//	}
+//
+// rawBytes, numBits, points and metadata are omitted when empty. Formats and
+// metadata keys are written by name. Metadata values keep their Go types
+// through a round trip, except for format-specific structures such as
+// PDF417_EXTRA_METADATA and OTHER, which are read back as json.RawMessage.
+// Unknown metadata keys are skipped when reading.
+type resultJSON struct {
+	Format    Format                    `json:"format"`
+	Text      string                    `json:"text"`
+	RawBytes  []byte                    `json:"rawBytes,omitempty"`
+	NumBits   int                       `json:"numBits,omitempty"`
+	Points    []ResultPoint             `json:"points,omitempty"`
+	Metadata  map[ResultMetadataKey]any `json:"metadata,omitempty"`
+	Timestamp time.Time                 `json:"timestamp"`
+}
+
+// MarshalJSON encodes the result in the canonical JSON form.
+func (r *Result) MarshalJSON() ([]byte, error) {
+	return json.Marshal(resultJSON{
+		Format:    r.Format,
+		Text:      r.Text,
+		RawBytes:  r.RawBytes,
+		NumBits:   r.NumBits,
+		Points:    r.Points,
+		Metadata:  r.Metadata,
+		Timestamp: r.Timestamp,
+	})
+}
+
+// UnmarshalJSON decodes a result from the canonical JSON form.
+func (r *Result) UnmarshalJSON(data []byte) error {
+	var in struct {
+		resultJSON
+		Metadata map[string]json.RawMessage `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*r = Result{
+		Text:      in.Text,
+		RawBytes:  in.RawBytes,
+		NumBits:   in.NumBits,
+		Points:    in.Points,
+		Format:    in.Format,
+		Metadata:  make(map[ResultMetadataKey]any, len(in.Metadata)),
+		Timestamp: in.Timestamp,
+	}
+	for name, raw := range in.Metadata {
+		var key ResultMetadataKey
+		if key.UnmarshalText([]byte(name)) != nil {
+			continue
+		}
+		value, err := metadataValue(key, raw)
+		if err != nil {
+			return fmt.Errorf("metadata %s: %w", name, err)
+		}
+		r.Metadata[key] = value
+	}
+	return nil
+}
+
+// metadataValue decodes the JSON value of a metadata key into the Go type
+// readers store under it.
+func metadataValue(key ResultMetadataKey, raw json.RawMessage) (any, error) {
+	switch key {
+	case MetadataOrientation, MetadataErrorsCorrected, MetadataErasuresCorrected, MetadataIssueNumber,
+		MetadataStructuredAppendSequence, MetadataStructuredAppendParity:
+		return decodeAs[int](raw)
+	case MetadataErrorCorrectionLevel, MetadataSuggestedPrice, MetadataPossibleCountry,
+		MetadataUPCEANExtension, MetadataSymbologyIdentifier, MetadataAlignmentPattern,
+		MetadataRawText:
+		return decodeAs[string](raw)
+	case MetadataByteSegments:
+		return decodeAs[[][]byte](raw)
+	case MetadataSymbolDimension:
+		return decodeAs[[2]int](raw)
+	case MetadataStructuredAppend:
+		return decodeAs[*StructuredAppend](raw)
+	case MetadataCandidates:
+		return decodeAs[[]Candidate](raw)
+	case MetadataSymbolValues:
+		return decodeAs[[]int](raw)
+	case MetadataUnusedErrorCorrection:
+		return decodeAs[float64](raw)
+	}
+	return raw, nil
+}
+
+func decodeAs[T any](raw json.RawMessage) (any, error) {
+	var v T
+	err := json.Unmarshal(raw, &v)
+	return v, err
+}
+
+// ParseFormat returns the format or format family with the given name, as
+// returned by Format.String.
+func ParseFormat(name string) (Format, error) {
+	for f := Format(0); f < formatCount; f++ {
+		if f.String() == name {
+			return f, nil
+		}
+	}
+	for f := range formatFamilies {
+		if f.String() == name {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown barcode format %q", name)
+}
+
+// MarshalText returns the format's name.
+func (f Format) MarshalText() ([]byte, error) {
+	if (f < 0 || f >= formatCount) && !f.IsFamily() {
+		return nil, fmt.Errorf("unknown barcode format %d", int(f))
+	}
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText sets the format from its name.
+func (f *Format) UnmarshalText(text []byte) error {
+	format, err := ParseFormat(string(text))
+	if err != nil {
+		return err
+	}
+	*f = format
+	return nil
+}
+
+var metadataKeyNames = [metadataKeyCount]string{
+	MetadataOther:                    "OTHER",
+	MetadataOrientation:              "ORIENTATION",
+	MetadataByteSegments:             "BYTE_SEGMENTS",
+	MetadataErrorCorrectionLevel:     "ERROR_CORRECTION_LEVEL",
+	MetadataErrorsCorrected:          "ERRORS_CORRECTED",
+	MetadataErasuresCorrected:        "ERASURES_CORRECTED",
+	MetadataIssueNumber:              "ISSUE_NUMBER",
+	MetadataSuggestedPrice:           "SUGGESTED_PRICE",
+	MetadataPossibleCountry:          "POSSIBLE_COUNTRY",
+	MetadataUPCEANExtension:          "UPC_EAN_EXTENSION",
+	MetadataPDF417ExtraMetadata:      "PDF417_EXTRA_METADATA",
+	MetadataStructuredAppendSequence: "STRUCTURED_APPEND_SEQUENCE",
+	MetadataStructuredAppendParity:   "STRUCTURED_APPEND_PARITY",
+	MetadataSymbologyIdentifier:      "SYMBOLOGY_IDENTIFIER",
+	MetadataSymbolDimension:          "SYMBOL_DIMENSION",
+	MetadataStructuredAppend:         "STRUCTURED_APPEND",
+	MetadataAlignmentPattern:         "ALIGNMENT_PATTERN",
+	MetadataCandidates:               "CANDIDATES",
+	MetadataUnusedErrorCorrection:    "UNUSED_ERROR_CORRECTION",
+	MetadataRawText:                  "RAW_TEXT",
+	MetadataSymbolValues:             "SYMBOL_VALUES",
+}
+
+// String returns the name of the metadata key.
+func (k ResultMetadataKey) String() string {
+	if k < 0 || int(k) >= len(metadataKeyNames) {
+		return "UNKNOWN"
+	}
+	return metadataKeyNames[k]
+}
+
+// MarshalText returns the key's name.
+func (k ResultMetadataKey) MarshalText() ([]byte, error) {
+	if k < 0 || int(k) >= len(metadataKeyNames) {
+		return nil, fmt.Errorf("unknown metadata key %d", int(k))
+	}
+	return []byte(metadataKeyNames[k]), nil
+}
+
+// UnmarshalText sets the key from its name.
+func (k *ResultMetadataKey) UnmarshalText(text []byte) error {
+	for i, name := range metadataKeyNames {
+		if name == string(text) {
+			*k = ResultMetadataKey(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown metadata key %q", text)
+}
@@ -0,0 +1,88 @@
+package zxinggo
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResultJSONRoundTrip(t *testing.T) {
+	result := NewResult("Hello", []byte{0x40, 0x56}, []ResultPoint{{X: 1.5, Y: 2}, {X: 30, Y: 4.25}}, FormatQRCode)
+	result.Timestamp = time.Date(2024, 1, 2, 15, 4, 5, 6, time.UTC)
+	result.PutMetadata(MetadataErrorCorrectionLevel, "M")
+	result.PutMetadata(MetadataErrorsCorrected, 2)
+	result.PutMetadata(MetadataByteSegments, [][]byte{{0x48, 0x65}})
+	result.PutMetadata(MetadataSymbologyIdentifier, "]Q1")
+	result.PutMetadata(MetadataSymbolDimension, [2]int{21, 21})
+	result.PutMetadata(MetadataStructuredAppend, &StructuredAppend{Index: 1, Count: 3, ID: "A"})
+	result.PutMetadata(MetadataAlignmentPattern, "found")
+	result.PutMetadata(MetadataCandidates, []Candidate{{Format: FormatCode39, Text: "A1", Votes: 3}})
+	result.PutMetadata(MetadataUnusedErrorCorrection, 0.75)
+	result.PutMetadata(MetadataRawText, " (01)\x1d ")
+	result.PutMetadata(MetadataSymbolValues, []int{104, 33, 102, 106})
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"format":"QR_CODE"`, `"rawBytes":"QFY="`, `"points":[{"x":1.5,"y":2}`,
+		`"ERROR_CORRECTION_LEVEL":"M"`, `"STRUCTURED_APPEND":{"index":1,"count":3,"id":"A"}`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("JSON %s does not contain %s", data, want)
+		}
+	}
+
+	var got Result
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Timestamp.Equal(result.Timestamp) {
+		t.Errorf("timestamp = %v, want %v", got.Timestamp, result.Timestamp)
+	}
+	got.Timestamp = result.Timestamp
+	if !reflect.DeepEqual(&got, result) {
+		t.Errorf("round trip = %+v, want %+v", got, *result)
+	}
+}
+
+func TestMetadataKeyNames(t *testing.T) {
+	for k := range metadataKeyCount {
+		if metadataKeyNames[k] == "" {
+			t.Errorf("metadata key %d has no name", int(k))
+		}
+	}
+}
+
+func TestResultJSONUnknownMetadata(t *testing.T) {
+	var got Result
+	data := `{"format":"CODE_128","text":"x","metadata":{"FUTURE_KEY":1,"PDF417_EXTRA_METADATA":{"FileID":"7"}},"timestamp":"2024-01-02T15:04:05Z"}`
+	if err := json.Unmarshal([]byte(data), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Format != FormatCode128 || len(got.Metadata) != 1 {
+		t.Errorf("got %+v", got)
+	}
+	if raw, ok := got.Metadata[MetadataPDF417ExtraMetadata].(json.RawMessage); !ok || string(raw) != `{"FileID":"7"}` {
+		t.Errorf("PDF417 metadata = %#v", got.Metadata[MetadataPDF417ExtraMetadata])
+	}
+
+	if err := json.Unmarshal([]byte(`{"format":"NOT_A_FORMAT"}`), &got); err == nil {
+		t.Error("expected error for an unknown format")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for f := Format(0); f < formatCount; f++ {
+		got, err := ParseFormat(f.String())
+		if err != nil || got != f {
+			t.Errorf("ParseFormat(%q) = %v, %v", f.String(), got, err)
+		}
+	}
+	for k := range metadataKeyNames {
+		if metadataKeyNames[k] == "" {
+			t.Errorf("metadata key %d has no name", k)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package zxinggo
+
+import (
+	"errors"
+	"iter"
+	"slices"
+)
+
+// Rung is one attempt of a Ladder: the image is shrunk by Downscale, turned
+// Rotation degrees clockwise and, if Inverted, has light and dark swapped,
+// then is binarized with Binarizer and decoded.
+type Rung struct {
+	// Binarizer creates the binarizer the prepared image is read with.
+	Binarizer BinarizerFactory
+
+	// Rotation turns the image clockwise about its centre, as
+	// ImageLuminanceSource.Rotate does. TryHarder already reads 1D symbols
+	// turned a quarter, so angles between, such as 45, are the ones worth
+	// a rung.
+	Rotation float64
+
+	// Inverted reads the image with light and dark swapped, for light
+	// symbols on a dark background.
+	Inverted bool
+
+	// Downscale divides the image's width and height, as
+	// ImageLuminanceSource.Scale shrinks it, which merges the noise of
+	// large photos and brings symbols that fill them within the detectors'
+	// reach. Zero and one leave it at full size.
+	Downscale int
+
+	// PureBarcode reads the image as DecodeOptions.PureBarcode does,
+	// whatever the options given say.
+	PureBarcode bool
+}
+
+// Source returns the image the rung binarizes, prepared from source. Its
+// points map back to source's coordinates, or to those of source's original
+// image if source is a CoordinateMapper.
+func (r Rung) Source(source LuminanceSource) LuminanceSource {
+	if r.Downscale > 1 {
+		width, height := max(source.Width()/r.Downscale, 1), max(source.Height()/r.Downscale, 1)
+		source = asImageSource(source).Scale(width, height)
+	}
+	if r.Rotation != 0 {
+		source = asImageSource(source).Rotate(r.Rotation)
+	}
+	if r.Inverted {
+		source = invertLuminance(source)
+	}
+	return source
+}
+
+// Bitmap returns the bitmap the rung decodes, binarized from source as
+// prepared by Source.
+func (r Rung) Bitmap(source LuminanceSource) *BinaryBitmap {
+	return NewBinaryBitmap(r.Binarizer.CreateBinarizer(r.Source(source)))
+}
+
+// Options returns a copy of opts, which may be nil, adjusted for the rung.
+func (r Rung) Options(opts *DecodeOptions) *DecodeOptions {
+	var adjusted DecodeOptions
+	if opts != nil {
+		adjusted = *opts
+	}
+	if r.PureBarcode {
+		adjusted.PureBarcode = true
+	}
+	return &adjusted
+}
+
+// Ladder is a strategy for reading hard images: rungs, each a way of
+// preparing and binarizing the image, climbed in order until one reads a
+// symbol. Cheap rungs that read most images belong at the bottom, so that
+// the costly ones are only tried on images they fail on.
+//
+// NewLadder and TryHarderLadder build the usual ladders. Their rungs may be
+// edited directly, or multiplied by the ways to prepare the image with
+// Downscaled, Rotated and Inverted, and reordered or pruned with Sort and
+// Filter:
+//
+//	ladder := zxinggo.NewLadder(binarizer.NewHybrid(nil)).Rotated(45).Inverted()
+//	result, err := ladder.Decode(source, opts)
+type Ladder struct {
+	Rungs []Rung
+}
+
+// NewLadder returns a ladder with a rung for each of binarizers, in the
+// order given, reading the image as it is.
+func NewLadder(binarizers ...BinarizerFactory) *Ladder {
+	l := &Ladder{}
+	for _, b := range binarizers {
+		l.Rungs = append(l.Rungs, Rung{Binarizer: b})
+	}
+	return l
+}
+
+// TryHarderLadder returns the ladder for images that do not read with
+// TryHarder alone: each of binarizers on the image as it is, then on the
+// image at half size, then each of those inverted, then all of those turned
+// 45 degrees for 1D symbols lying diagonally. Given the GlobalHistogram
+// and Hybrid binarizers, it has 16 rungs, so callers should set
+// DecodeOptions.Deadline.
+func TryHarderLadder(binarizers ...BinarizerFactory) *Ladder {
+	return NewLadder(binarizers...).Downscaled(2).Inverted().Rotated(45)
+}
+
+// multiply appends, for each of n variants, a copy of the rungs adjusted by
+// vary.
+func (l *Ladder) multiply(n int, vary func(r *Rung, i int)) *Ladder {
+	base := l.Rungs
+	for i := 0; i < n; i++ {
+		for _, r := range base {
+			vary(&r, i)
+			l.Rungs = append(l.Rungs, r)
+		}
+	}
+	return l
+}
+
+// Downscaled appends, for each of factors, a copy of the rungs with the
+// image also shrunk by that factor. It returns l.
+func (l *Ladder) Downscaled(factors ...int) *Ladder {
+	return l.multiply(len(factors), func(r *Rung, i int) {
+		r.Downscale = max(r.Downscale, 1) * factors[i]
+	})
+}
+
+// Rotated appends, for each of degrees, a copy of the rungs with the image
+// also turned that many degrees clockwise. It returns l.
+func (l *Ladder) Rotated(degrees ...float64) *Ladder {
+	return l.multiply(len(degrees), func(r *Rung, i int) {
+		r.Rotation += degrees[i]
+	})
+}
+
+// Inverted appends a copy of the rungs with the image inverted. It returns
+// l.
+func (l *Ladder) Inverted() *Ladder {
+	return l.multiply(1, func(r *Rung, _ int) {
+		r.Inverted = !r.Inverted
+	})
+}
+
+// PureFirst puts before the rungs a copy of each reading the image as a
+// pure barcode, which is quick to read when it is one. It returns l.
+func (l *Ladder) PureFirst() *Ladder {
+	pure := make([]Rung, len(l.Rungs), 2*len(l.Rungs))
+	for i, r := range l.Rungs {
+		r.PureBarcode = true
+		pure[i] = r
+	}
+	l.Rungs = append(pure, l.Rungs...)
+	return l
+}
+
+// Sort reorders the rungs by cmp, as slices.SortStableFunc does, keeping
+// rungs cmp finds equal in their order. It returns l.
+func (l *Ladder) Sort(cmp func(a, b Rung) int) *Ladder {
+	slices.SortStableFunc(l.Rungs, cmp)
+	return l
+}
+
+// Filter removes the rungs keep returns false for. It returns l.
+func (l *Ladder) Filter(keep func(Rung) bool) *Ladder {
+	l.Rungs = slices.DeleteFunc(l.Rungs, func(r Rung) bool { return !keep(r) })
+	return l
+}
+
+// Bitmaps returns an iterator over the rungs and the bitmaps they decode,
+// prepared from source as each is reached, for callers that decode them
+// in their own way, such as every format separately.
+func (l *Ladder) Bitmaps(source LuminanceSource) iter.Seq2[Rung, *BinaryBitmap] {
+	return func(yield func(Rung, *BinaryBitmap) bool) {
+		for _, r := range l.Rungs {
+			if !yield(r, r.Bitmap(source)) {
+				return
+			}
+		}
+	}
+}
+
+// Decode climbs the ladder, decoding source as each rung prepares it, and
+// returns the first symbol read. Its points are in the coordinates of
+// source, or of its original image if source is a CoordinateMapper. If no
+// rung reads a symbol, the error is the first DecodeError, or ErrNotFound;
+// once opts.Deadline passes, it is ErrDeadlineExceeded.
+func (l *Ladder) Decode(source LuminanceSource, opts *DecodeOptions) (*Result, error) {
+	var failed *DecodeError
+	for r, bitmap := range l.Bitmaps(source) {
+		if DeadlinePassed(opts) {
+			return nil, ErrDeadlineExceeded
+		}
+		result, err := Decode(bitmap, r.Options(opts))
+		if err == nil {
+			return result, nil
+		}
+		if errors.Is(err, ErrDeadlineExceeded) {
+			return nil, err
+		}
+		keepDecodeError(&failed, err)
+	}
+	if failed != nil {
+		return nil, failed
+	}
+	return nil, ErrNotFound
+}
+
+// asImageSource returns source as an ImageLuminanceSource, copying its
+// luminance if it is another kind, so that it can be scaled and turned.
+func asImageSource(source LuminanceSource) *ImageLuminanceSource {
+	if s, ok := source.(*ImageLuminanceSource); ok {
+		return s
+	}
+	s := NewLuminanceSourceFromBytes(source.Matrix(), source.Width(), source.Height(), source.Width())
+	s.toOriginal = sourceToOriginal(source)
+	return s
+}
+
+// invertLuminance returns a copy of source with light and dark swapped.
+// Its points map back as source's do.
+func invertLuminance(source LuminanceSource) *ImageLuminanceSource {
+	width, height := source.Width(), source.Height()
+	inverted := make([]byte, width*height)
+	var row []byte
+	for y := 0; y < height; y++ {
+		row = source.Row(y, row)
+		for x, lum := range row[:width] {
+			inverted[y*width+x] = 255 - lum
+		}
+	}
+	s := NewLuminanceSourceFromBytes(inverted, width, height, width)
+	s.toOriginal = sourceToOriginal(source)
+	return s
+}
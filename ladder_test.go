@@ -0,0 +1,116 @@
+package zxinggo_test
+
+import (
+	"cmp"
+	"errors"
+	"image"
+	"image/draw"
+	"math"
+	"testing"
+	"time"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+	"github.com/ericlevine/zxinggo/imaging"
+)
+
+// TestLadderRungs checks the order in which the ladder's builders multiply
+// and rearrange its rungs.
+func TestLadderRungs(t *testing.T) {
+	global, hybrid := binarizer.NewGlobalHistogram(nil), binarizer.NewHybrid(nil)
+	ladder := zxinggo.NewLadder(global, hybrid).Downscaled(2).Inverted().PureFirst()
+	want := []zxinggo.Rung{
+		{Binarizer: global, PureBarcode: true},
+		{Binarizer: hybrid, PureBarcode: true},
+		{Binarizer: global, Downscale: 2, PureBarcode: true},
+		{Binarizer: hybrid, Downscale: 2, PureBarcode: true},
+		{Binarizer: global, Inverted: true, PureBarcode: true},
+		{Binarizer: hybrid, Inverted: true, PureBarcode: true},
+		{Binarizer: global, Downscale: 2, Inverted: true, PureBarcode: true},
+		{Binarizer: hybrid, Downscale: 2, Inverted: true, PureBarcode: true},
+		{Binarizer: global},
+		{Binarizer: hybrid},
+		{Binarizer: global, Downscale: 2},
+		{Binarizer: hybrid, Downscale: 2},
+		{Binarizer: global, Inverted: true},
+		{Binarizer: hybrid, Inverted: true},
+		{Binarizer: global, Downscale: 2, Inverted: true},
+		{Binarizer: hybrid, Downscale: 2, Inverted: true},
+	}
+	checkRungs(t, "built", ladder.Rungs, want)
+
+	ladder.Filter(func(r zxinggo.Rung) bool { return !r.PureBarcode && r.Binarizer == zxinggo.BinarizerFactory(hybrid) })
+	ladder.Sort(func(a, b zxinggo.Rung) int { return cmp.Compare(a.Downscale, b.Downscale) })
+	checkRungs(t, "filtered and sorted", ladder.Rungs, []zxinggo.Rung{
+		{Binarizer: hybrid},
+		{Binarizer: hybrid, Inverted: true},
+		{Binarizer: hybrid, Downscale: 2},
+		{Binarizer: hybrid, Downscale: 2, Inverted: true},
+	})
+
+	if n := len(zxinggo.TryHarderLadder(global, hybrid).Rungs); n != 16 {
+		t.Errorf("TryHarderLadder has %d rungs, want 16", n)
+	}
+}
+
+func checkRungs(t *testing.T, name string, got, want []zxinggo.Rung) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: %d rungs, want %d", name, len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("%s: rung %d is %+v, want %+v", name, i, got[i], want[i])
+		}
+	}
+}
+
+// TestLadderDecode reads a light-on-dark Code 128 symbol lying diagonally
+// on a page, which only a rung that inverts and turns the page reads, and
+// checks that its points are reported in the page's coordinates.
+func TestLadderDecode(t *testing.T) {
+	matrix, err := zxinggo.Encode("LADDER", zxinggo.FormatCode128, 500, 40, nil)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	page := image.NewGray(image.Rect(0, 0, 640, 300))
+	draw.Draw(page, page.Bounds(), image.White, image.Point{}, draw.Src)
+	symbol := zxinggo.BitMatrixToImage(matrix)
+	draw.Draw(page, symbol.Bounds().Add(image.Pt(80, 130)), symbol, image.Point{}, draw.Src)
+	turned := imaging.Rotate(page, 45).(*image.Gray)
+	for i, p := range turned.Pix {
+		turned.Pix[i] = 255 - p
+	}
+	source := zxinggo.NewGrayImageLuminanceSource(turned)
+	opts := &zxinggo.DecodeOptions{
+		PossibleFormats: []zxinggo.Format{zxinggo.FormatCode128},
+		TryHarder:       true,
+	}
+
+	hybrid := binarizer.NewHybrid(nil)
+	if _, err := zxinggo.NewLadder(hybrid).Inverted().Decode(source, opts); !errors.Is(err, zxinggo.ErrNotFound) {
+		t.Fatalf("upright ladder: err = %v, want ErrNotFound", err)
+	}
+	result, err := zxinggo.NewLadder(hybrid).Rotated(45).Inverted().Decode(source, opts)
+	if err != nil {
+		t.Fatalf("turned ladder: %v", err)
+	}
+	if result.Text != "LADDER" {
+		t.Errorf("Text = %q, want LADDER", result.Text)
+	}
+	// The points lie at either end of a row across the bars, which runs
+	// diagonally across the page.
+	if len(result.Points) != 2 {
+		t.Fatalf("%d points, want 2", len(result.Points))
+	}
+	dx := math.Abs(result.Points[1].X - result.Points[0].X)
+	dy := math.Abs(result.Points[1].Y - result.Points[0].Y)
+	if dx < 100 || math.Abs(dx-dy) > 5 {
+		t.Errorf("points %v do not run diagonally across the symbol", result.Points)
+	}
+
+	opts.Deadline = time.Now()
+	if _, err := zxinggo.TryHarderLadder(hybrid).Decode(source, opts); !errors.Is(err, zxinggo.ErrDeadlineExceeded) {
+		t.Errorf("past deadline: err = %v, want ErrDeadlineExceeded", err)
+	}
+}
@@ -7,16 +7,12 @@ import (
 	"strings"
 
 	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/internal"
 	"github.com/ericlevine/zxinggo/reedsolomon"
 )
 
 // DecoderResult holds the decoded text and metadata.
-type DecoderResult struct {
-	Text            string
-	RawBytes        []byte
-	ECLevel         string
-	ErrorsCorrected int
-}
+type DecoderResult = internal.DecodedPayload
 
 // interleave mode constants for correctErrors.
 const (
@@ -39,7 +35,7 @@ func Decode(bits *bitutil.BitMatrix) (*DecoderResult, error) {
 
 	var datawords []byte
 	switch mode {
-	case 2, 3, 4:
+	case 2, 3, 4, 6:
 		ec, err := correctErrors(rsDecoder, codewords, 20, 84, 40, modeEven)
 		if err != nil {
 			return nil, err
@@ -75,12 +71,9 @@ func Decode(bits *bitutil.BitMatrix) (*DecoderResult, error) {
 		return nil, err
 	}
 
-	return &DecoderResult{
-		Text:            text,
-		RawBytes:        codewords,
-		ECLevel:         fmt.Sprintf("%d", mode),
-		ErrorsCorrected: errorsCorrected,
-	}, nil
+	result := internal.NewDecodedPayload(codewords, text, nil, fmt.Sprintf("%d", mode))
+	result.ErrorsCorrected = errorsCorrected
+	return result, nil
 }
 
 // correctErrors performs RS error correction on a subset of codewords.
@@ -120,7 +113,7 @@ func correctErrors(rsDecoder *reedsolomon.Decoder, codewordBytes []byte,
 
 // bitnr maps (y, x) coordinates in the 33x30 MaxiCode grid to bit numbers.
 // Values >= 0 are bit positions (bit/6 = codeword index, 5-bit%6 = bit within codeword).
-// Values < 0 are special: -1 = fixed 0, -2 = fixed 0, -3 = fixed 0 (all unused/fixed bits).
+// Values < 0 carry no data: ModuleLight, ModuleDark or ModuleUnused.
 var bitnr = [33][30]int{
 	{121, 120, 127, 126, 133, 132, 139, 138, 145, 144, 151, 150, 157, 156, 163, 162, 169, 168, 175, 174, 181, 180, 187, 186, 193, 192, 199, 198, -2, -2},
 	{123, 122, 129, 128, 135, 134, 141, 140, 147, 146, 153, 152, 159, 158, 165, 164, 171, 170, 177, 176, 183, 182, 189, 188, 195, 194, 201, 200, 816, -3},
@@ -157,6 +150,31 @@ var bitnr = [33][30]int{
 	{737, 736, 743, 742, 749, 748, 755, 754, 761, 760, 767, 766, 773, 772, 779, 778, 785, 784, 791, 790, 797, 796, 803, 802, 809, 808, 815, 814, 863, 862},
 }
 
+// Modules of the 30x33 grid that carry no data, as Module reports them.
+const (
+	// ModuleLight is an orientation module, always light.
+	ModuleLight = -1
+	// ModuleDark is an orientation module, always dark.
+	ModuleDark = -2
+	// ModuleUnused lies under the finder pattern, or past the end of an odd
+	// row, which is a module shorter than the even rows.
+	ModuleUnused = -3
+)
+
+// Module returns the number of the codeword bit the module at (x, y) of the
+// 30x33 grid carries, counting from the most significant of the 6 bits of
+// codeword 0, or ModuleLight, ModuleDark or ModuleUnused.
+func Module(x, y int) int {
+	return bitnr[y][x]
+}
+
+// CharacterSet returns the 64 characters of code set n, 0 for set A to 4 for
+// set E, indexed by codeword value. Shifts, latches and the other codewords
+// that stand for no character are in the range U+FFF0 to U+FFFC.
+func CharacterSet(n int) []rune {
+	return []rune(sets[n])
+}
+
 // readCodewords reads 144 codewords (6 bits each) from a 30x33 MaxiCode BitMatrix.
 func readCodewords(matrix *bitutil.BitMatrix) []byte {
 	result := make([]byte, 144)
@@ -268,7 +286,7 @@ func decodeBitStream(bytes []byte, mode int) (string, error) {
 			result.WriteString(postcode + string(gsChar) + country + string(gsChar) + service + string(gsChar))
 			result.WriteString(msg)
 		}
-	case 4:
+	case 4, 6:
 		result.WriteString(getMessage(bytes, 1, 93))
 	case 5:
 		result.WriteString(getMessage(bytes, 1, 77))
@@ -0,0 +1,308 @@
+// Package encoder implements MaxiCode encoding: character set encoding, the
+// structured carrier message of modes 2 and 3, Reed-Solomon error correction
+// and module placement.
+package encoder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/maxicode/decoder"
+	"github.com/ericlevine/zxinggo/reedsolomon"
+)
+
+// Symbol dimensions in modules.
+const (
+	Width  = 30
+	Height = 33
+)
+
+// Codeword values with the same meaning in sets A and B.
+const (
+	codeNS     = 31 // numeric shift: 9 digits in the next 5 codewords
+	codePad    = 33
+	codeShift  = 59 // shift to set B from set A, or to set A from set B
+	codeShiftC = 60 // followed by 61 and 62 for sets D and E
+	codeLatch  = 63 // latch to set B from set A, or to set A from set B
+)
+
+const (
+	codewordCount = 144
+	primaryData   = 10
+	primaryEC     = 10
+	// nsDigits is how many digits a numeric shift encodes.
+	nsDigits = 9
+	// postcodeLength is the length of a mode 3 postcode.
+	postcodeLength = 6
+)
+
+// structuredPrefix starts messages in the format of ISO/IEC 15434, ahead of
+// a two digit format code. The carrier message of modes 2 and 3 follows the
+// format code when it is present.
+const structuredPrefix = "[)>\x1e01\x1d"
+
+// Bit positions of the carrier message fields in the primary message,
+// counted from 1 for the most significant bit of codeword 0, most
+// significant first, as the decoder reads them.
+var (
+	countryBits         = []int{53, 54, 43, 44, 45, 46, 47, 48, 37, 38}
+	serviceClassBits    = []int{55, 56, 57, 58, 59, 60, 49, 50, 51, 52}
+	postcode2LengthBits = []int{39, 40, 41, 42, 31, 32}
+	postcode2Bits       = []int{33, 34, 35, 36, 25, 26, 27, 28, 29, 30, 19,
+		20, 21, 22, 23, 24, 13, 14, 15, 16, 17, 18, 7, 8, 9, 10, 11, 12, 1, 2}
+	postcode3Bits = [][]int{
+		{39, 40, 41, 42, 31, 32},
+		{33, 34, 35, 36, 25, 26},
+		{27, 28, 29, 30, 19, 20},
+		{21, 22, 23, 24, 13, 14},
+		{15, 16, 17, 18, 7, 8},
+		{9, 10, 11, 12, 1, 2},
+	}
+)
+
+// codeValues maps the characters of each code set to their codeword values.
+var codeValues [5]map[rune]int
+
+func init() {
+	for n := range codeValues {
+		codeValues[n] = map[rune]int{}
+		for value, r := range decoder.CharacterSet(n) {
+			if _, ok := codeValues[n][r]; !ok && r < '\uFFF0' {
+				codeValues[n][r] = value
+			}
+		}
+	}
+}
+
+// Encode encodes contents as a MaxiCode symbol in the given mode, 2 to 6,
+// and returns its 30x33 module grid, odd rows shifted right by half a
+// module, in the layout decoder.Decode reads.
+//
+// Modes 2 and 3 carry a structured carrier message. Contents take the form
+// the decoder gives it: postcode, country code and service class separated
+// by GS characters, then the rest of the message after another GS, with the
+// fields after "[)>" RS "01" GS and a two digit format code if contents
+// start with them. Mode 2 takes a numeric postcode of up to 9 digits and
+// falls back to mode 3 for others; mode 3 takes up to 6 characters of code
+// set A, padded with spaces. Mode 4 is standard error correction, mode 5
+// enhanced error correction and mode 6 reader programming.
+func Encode(contents string, mode int) (*bitutil.BitMatrix, error) {
+	codewords, err := Codewords(contents, mode)
+	if err != nil {
+		return nil, err
+	}
+	return placeModules(codewords), nil
+}
+
+// Codewords returns the 144 codewords, error correction included, of the
+// MaxiCode symbol Encode would make.
+func Codewords(contents string, mode int) ([]byte, error) {
+	var data []byte
+	switch mode {
+	case 2, 3:
+		primary, message, err := carrierMessage(contents, mode)
+		if err != nil {
+			return nil, err
+		}
+		text, err := encodeText(message, 84)
+		if err != nil {
+			return nil, err
+		}
+		data = append(primary, text...)
+	case 4, 6:
+		text, err := encodeText(contents, 93)
+		if err != nil {
+			return nil, err
+		}
+		data = append([]byte{byte(mode)}, text...)
+	case 5:
+		text, err := encodeText(contents, 77)
+		if err != nil {
+			return nil, err
+		}
+		data = append([]byte{byte(mode)}, text...)
+	default:
+		return nil, fmt.Errorf("%w: no MaxiCode mode %d", zxinggo.ErrWriter, mode)
+	}
+
+	secondaryData := len(data) - primaryData
+	codewords := make([]byte, codewordCount)
+	copy(codewords, data[:primaryData])
+	copy(codewords[primaryData+primaryEC:], data[primaryData:])
+	appendEC(codewords, 0, primaryData, primaryEC, 1)
+	secondaryEC := codewordCount - primaryData - primaryEC - secondaryData
+	for i := 0; i < 2; i++ {
+		appendEC(codewords, primaryData+primaryEC+i, secondaryData/2, secondaryEC/2, 2)
+	}
+	return codewords, nil
+}
+
+// appendEC computes the error correction codewords for the dataCount data
+// codewords at start, start+stride and so on, writing them at the same
+// stride after the data.
+func appendEC(codewords []byte, start, dataCount, ecCount, stride int) {
+	block := make([]int, dataCount+ecCount)
+	for i := 0; i < dataCount; i++ {
+		block[i] = int(codewords[start+i*stride])
+	}
+	reedsolomon.NewEncoder(reedsolomon.MaxiCodeField64).Encode(block, ecCount)
+	for i := dataCount; i < len(block); i++ {
+		codewords[start+i*stride] = byte(block[i])
+	}
+}
+
+// carrierMessage splits contents in the form decoded from modes 2 and 3
+// into the 10 codewords of the primary message and the text of the
+// secondary one, choosing mode 3 for a postcode mode 2 cannot hold.
+func carrierMessage(contents string, mode int) ([]byte, string, error) {
+	var header string
+	if strings.HasPrefix(contents, structuredPrefix) && len(contents) >= len(structuredPrefix)+2 {
+		header, contents = contents[:len(structuredPrefix)+2], contents[len(structuredPrefix)+2:]
+	}
+	fields := strings.SplitN(contents, "\x1d", 4)
+	if len(fields) < 4 {
+		return nil, "", fmt.Errorf("%w: MaxiCode mode %d needs postcode, country and service class separated by GS", zxinggo.ErrWriter, mode)
+	}
+	postcode := fields[0]
+	country, err := carrierNumber("country code", fields[1])
+	if err != nil {
+		return nil, "", err
+	}
+	service, err := carrierNumber("service class", fields[2])
+	if err != nil {
+		return nil, "", err
+	}
+	if mode == 2 && (len(postcode) > nsDigits || !isDigits(postcode)) {
+		mode = 3
+	}
+
+	primary := make([]byte, primaryData)
+	primary[0] = byte(mode)
+	switch {
+	case postcode == "":
+		return nil, "", fmt.Errorf("%w: empty MaxiCode postcode", zxinggo.ErrWriter)
+	case mode == 2:
+		n, _ := strconv.Atoi(postcode)
+		setBits(primary, postcode2Bits, n)
+		setBits(primary, postcode2LengthBits, len(postcode))
+	default:
+		if len(postcode) > postcodeLength {
+			return nil, "", fmt.Errorf("%w: MaxiCode postcode %q is longer than %d characters", zxinggo.ErrWriter, postcode, postcodeLength)
+		}
+		postcode += strings.Repeat(" ", postcodeLength-len(postcode))
+		for i, r := range postcode {
+			value, ok := codeValues[0][r]
+			if !ok {
+				return nil, "", fmt.Errorf("%w: MaxiCode postcode %q has %q, which is not in code set A", zxinggo.ErrWriter, postcode, r)
+			}
+			setBits(primary, postcode3Bits[i], value)
+		}
+	}
+	setBits(primary, countryBits, country)
+	setBits(primary, serviceClassBits, service)
+	return primary, header + fields[3], nil
+}
+
+// carrierNumber parses a country code or service class of up to 3 digits.
+func carrierNumber(name, s string) (int, error) {
+	if s == "" || len(s) > 3 || !isDigits(s) {
+		return 0, fmt.Errorf("%w: MaxiCode %s %q is not 1 to 3 digits", zxinggo.ErrWriter, name, s)
+	}
+	n, _ := strconv.Atoi(s)
+	return n, nil
+}
+
+// setBits writes value to the bits at the given 1-based positions, most
+// significant first.
+func setBits(codewords []byte, positions []int, value int) {
+	for i, p := range positions {
+		if value>>(len(positions)-1-i)&1 == 1 {
+			codewords[(p-1)/6] |= 1 << (5 - (p-1)%6)
+		}
+	}
+}
+
+// encodeText encodes text in the code sets, padded to length codewords. It
+// starts in set A, uses a numeric shift for every run of 9 digits, shifts
+// to set A or B for a single character and latches for more, and shifts to
+// sets C to E for each character they hold.
+func encodeText(text string, length int) ([]byte, error) {
+	runes := []rune(text)
+	codewords := make([]byte, 0, length)
+	set := 0
+	for i := 0; i < len(runes); i++ {
+		if i+nsDigits <= len(runes) && isDigits(string(runes[i:i+nsDigits])) {
+			n, _ := strconv.Atoi(string(runes[i : i+nsDigits]))
+			codewords = append(codewords, codeNS,
+				byte(n>>24), byte(n>>18&0x3F), byte(n>>12&0x3F), byte(n>>6&0x3F), byte(n&0x3F))
+			i += nsDigits - 1
+			continue
+		}
+		r := runes[i]
+		if value, ok := codeValues[set][r]; ok {
+			codewords = append(codewords, byte(value))
+			continue
+		}
+		other := 1 - set
+		if value, ok := codeValues[other][r]; ok {
+			if i+1 < len(runes) && !inSet(set, runes[i+1]) && inSet(other, runes[i+1]) {
+				codewords = append(codewords, codeLatch, byte(value))
+				set = other
+			} else {
+				codewords = append(codewords, codeShift, byte(value))
+			}
+			continue
+		}
+		shifted := false
+		for n := 2; n < len(codeValues) && !shifted; n++ {
+			if value, ok := codeValues[n][r]; ok {
+				codewords = append(codewords, byte(codeShiftC+n-2), byte(value))
+				shifted = true
+			}
+		}
+		if !shifted {
+			return nil, fmt.Errorf("%w: MaxiCode cannot encode %q", zxinggo.ErrWriter, r)
+		}
+	}
+	if len(codewords) > length {
+		return nil, fmt.Errorf("%w: message needs %d MaxiCode codewords, more than the %d available", zxinggo.ErrWriter, len(codewords), length)
+	}
+	for len(codewords) < length {
+		codewords = append(codewords, codePad)
+	}
+	return codewords, nil
+}
+
+func inSet(set int, r rune) bool {
+	_, ok := codeValues[set][r]
+	return ok
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// placeModules lays out codewords in the module grid, with the dark
+// orientation modules.
+func placeModules(codewords []byte) *bitutil.BitMatrix {
+	bits := bitutil.NewBitMatrixWithSize(Width, Height)
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			switch bit := decoder.Module(x, y); {
+			case bit == decoder.ModuleDark:
+				bits.Set(x, y)
+			case bit >= 0 && codewords[bit/6]&(1<<(5-bit%6)) != 0:
+				bits.Set(x, y)
+			}
+		}
+	}
+	return bits
+}
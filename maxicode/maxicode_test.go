@@ -1,10 +1,15 @@
 package maxicode
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
 	"github.com/ericlevine/zxinggo/bitutil"
 	"github.com/ericlevine/zxinggo/maxicode/decoder"
+	"github.com/ericlevine/zxinggo/maxicode/encoder"
 	"github.com/ericlevine/zxinggo/reedsolomon"
 )
 
@@ -177,7 +182,7 @@ func TestExtractPureBits(t *testing.T) {
 		}
 	}
 
-	bits, err := extractPureBits(img)
+	bits, err := extractPureBits(img, nil)
 	if err != nil {
 		t.Fatalf("extractPureBits error: %v", err)
 	}
@@ -240,3 +245,113 @@ func buildBitMatrix(codewords []byte) *bitutil.BitMatrix {
 	}
 	return bits
 }
+
+func TestDecodeMatrixRejectsWrongSize(t *testing.T) {
+	if _, err := DecodeMatrix(bitutil.NewBitMatrix(30), nil); !errors.Is(err, zxinggo.ErrFormat) {
+		t.Errorf("DecodeMatrix(30x30): got %v, want ErrFormat", err)
+	}
+}
+
+func TestEncoderRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		mode     int
+		want     string // decoded text when it differs from contents
+		wantMode string
+	}{
+		{"mode 2", "152382802\x1d840\x1d001\x1d1Z00004951\x1dUPSN\x1d06X610", 2, "", "2"},
+		{"mode 2 leading zeros", "0012\x1d036\x1d7\x1dHELLO", 2, "0012\x1d036\x1d007\x1dHELLO", "2"},
+		{"mode 3", "B1050\x1d056\x1d999\x1dParcel 42", 2, "B1050 \x1d056\x1d999\x1dParcel 42", "3"},
+		{"mode 3 forced", "123456\x1d826\x1d001\x1dX", 3, "", "3"},
+		{"mode 2 header", "[)>\x1e01\x1d96152382802\x1d840\x1d001\x1d1Z12345\x1e\x04", 2, "", "2"},
+		{"mode 4", "Hello, World! 0123456789 àé ©", 4, "", "4"},
+		{"mode 5", "ENHANCED error correction", 5, "", "5"},
+		{"mode 6", "PROGRAM", 6, "", "6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bits, err := encoder.Encode(tt.contents, tt.mode)
+			if err != nil {
+				t.Fatalf("encode error: %v", err)
+			}
+			result, err := DecodeMatrix(bits, nil)
+			if err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			want := tt.want
+			if want == "" {
+				want = tt.contents
+			}
+			if result.Text != want {
+				t.Errorf("got %q, want %q", result.Text, want)
+			}
+			if got := result.Metadata[zxinggo.MetadataErrorCorrectionLevel]; got != tt.wantMode {
+				t.Errorf("mode: got %v, want %s", got, tt.wantMode)
+			}
+		})
+	}
+}
+
+func TestEncoderErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		mode     int
+	}{
+		{"no mode 1", "ABC", 1},
+		{"missing fields", "12345\x1d840", 2},
+		{"long postcode", "ABCDEFG\x1d840\x1d001\x1dX", 3},
+		{"postcode outside set A", "ab\x1d840\x1d001\x1dX", 3},
+		{"bad country", "12345\x1d84A\x1d001\x1dX", 2},
+		{"too long", strings.Repeat("a", 94), 4},
+		{"mode 5 too long", strings.Repeat("A", 78), 5},
+		{"unencodable", "\u4e2d", 4},
+	}
+	for _, tt := range tests {
+		if _, err := encoder.Encode(tt.contents, tt.mode); !errors.Is(err, zxinggo.ErrWriter) {
+			t.Errorf("%s: got %v, want ErrWriter", tt.name, err)
+		}
+	}
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	const contents = "152382802\x1d840\x1d001\x1d1Z00004951\x1dUPSN\x1d06X610"
+	for _, size := range []int{0, 200, 333} {
+		matrix, err := zxinggo.Encode(contents, zxinggo.FormatMaxiCode, size, size, &zxinggo.EncodeOptions{MaxiCodeMode: 2})
+		if err != nil {
+			t.Fatalf("%d: encode error: %v", size, err)
+		}
+		source := zxinggo.NewGrayImageLuminanceSource(zxinggo.BitMatrixToImage(matrix))
+		result, err := NewReader().Decode(zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source)), nil)
+		if err != nil {
+			t.Fatalf("%d: decode error: %v", size, err)
+		}
+		if result.Text != contents {
+			t.Errorf("%d: got %q, want %q", size, result.Text, contents)
+		}
+	}
+}
+
+func TestReaderWithOptions(t *testing.T) {
+	matrix, err := zxinggo.Encode("ONE READER", zxinggo.FormatMaxiCode, 0, 0, nil)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	source := zxinggo.NewGrayImageLuminanceSource(zxinggo.BitMatrixToImage(matrix))
+	heatmap := zxinggo.NewHeatmap(source)
+	reader := NewReaderWithOptions(&zxinggo.DecodeOptions{Heatmap: heatmap})
+	if _, err := reader.Decode(zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source)), nil); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(heatmap.Candidates) != 1 || !heatmap.Candidates[0].Accepted {
+		t.Errorf("candidates = %+v, want the symbol", heatmap.Candidates)
+	}
+	// Options passed to Decode replace the reader's.
+	if _, err := reader.Decode(zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source)), &zxinggo.DecodeOptions{}); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(heatmap.Candidates) != 1 {
+		t.Errorf("%d candidates, want 1", len(heatmap.Candidates))
+	}
+}
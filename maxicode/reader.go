@@ -1,10 +1,11 @@
-// Package maxicode provides MaxiCode barcode reading.
+// Package maxicode provides MaxiCode barcode reading and writing.
 package maxicode
 
 import (
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
 	"github.com/ericlevine/zxinggo/maxicode/decoder"
+	"github.com/ericlevine/zxinggo/transform"
 )
 
 const (
@@ -13,36 +14,62 @@ const (
 )
 
 // Reader decodes MaxiCode barcodes from binary images.
-type Reader struct{}
+type Reader struct {
+	// opts are used by Decode when it is passed nil.
+	opts *zxinggo.DecodeOptions
+}
 
 // NewReader creates a new MaxiCode Reader.
 func NewReader() *Reader {
 	return &Reader{}
 }
 
+// NewReaderWithOptions creates a MaxiCode reader that uses opts whenever it
+// is given nil options, so it can be configured once and used on its own
+// rather than through zxinggo.Decode.
+func NewReaderWithOptions(opts *zxinggo.DecodeOptions) *Reader {
+	return &Reader{opts: opts}
+}
+
 // Decode locates and decodes a MaxiCode in the given image.
 // MaxiCode always operates in "pure barcode" mode — it extracts the symbol
 // directly from the image with no detector.
 func (r *Reader) Decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	if opts == nil {
+		opts = r.opts
+	}
 	matrix, err := image.BlackMatrix()
 	if err != nil {
 		return nil, err
 	}
 
-	bits, err := extractPureBits(matrix)
+	var heatmap *zxinggo.Heatmap
+	if opts != nil {
+		heatmap = opts.Heatmap
+	}
+	bits, err := extractPureBits(matrix, heatmap)
 	if err != nil {
 		return nil, err
 	}
 
+	return DecodeMatrix(bits, opts)
+}
+
+// DecodeMatrix decodes a MaxiCode from its 30x33 module grid, one bit per
+// hexagonal module with odd rows shifted right by half a module, as sampled
+// by an external detector. opts may be nil; only MaxErrorsCorrected is used.
+func DecodeMatrix(bits *bitutil.BitMatrix, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	if bits.Width() != matrixWidth || bits.Height() != matrixHeight {
+		return nil, zxinggo.ErrFormat
+	}
 	dr, err := decoder.Decode(bits)
 	if err != nil {
 		return nil, err
 	}
 
-	result := zxinggo.NewResult(dr.Text, dr.RawBytes, nil, zxinggo.FormatMaxiCode)
-	result.PutMetadata(zxinggo.MetadataErrorsCorrected, dr.ErrorsCorrected)
-	if dr.ECLevel != "" {
-		result.PutMetadata(zxinggo.MetadataErrorCorrectionLevel, dr.ECLevel)
+	result := dr.Result(nil, zxinggo.FormatMaxiCode)
+	if err := zxinggo.CheckErrorBudget(result, opts); err != nil {
+		return nil, err
 	}
 	return result, nil
 }
@@ -53,38 +80,30 @@ func (r *Reader) Reset() {}
 // Compile-time check.
 var _ zxinggo.Reader = (*Reader)(nil)
 
-// extractPureBits extracts the 30x33 MaxiCode grid from the image.
-// MaxiCode uses a hexagonal layout where odd rows are shifted by half a module.
-func extractPureBits(image *bitutil.BitMatrix) (*bitutil.BitMatrix, error) {
+// extractPureBits extracts the 30x33 MaxiCode grid from the image, taking
+// the box bounding its dark pixels to be the box bounding the hexagonal
+// lattice of modules. heatmap, which may be nil, records the scan of every
+// row for that box and the box as a candidate, accepted if it is sampled.
+func extractPureBits(image *bitutil.BitMatrix, heatmap *zxinggo.Heatmap) (*bitutil.BitMatrix, error) {
+	for y := 0; y < image.Height(); y++ {
+		heatmap.ScanRow(y, 0, image.Width())
+	}
 	enclosingRect := image.EnclosingRectangle()
 	if enclosingRect == nil {
 		return nil, zxinggo.ErrNotFound
 	}
 
-	left := enclosingRect[0]
-	top := enclosingRect[1]
-	width := enclosingRect[2]
-	height := enclosingRect[3]
+	left := float64(enclosingRect[0])
+	top := float64(enclosingRect[1])
+	width := float64(enclosingRect[2])
+	height := float64(enclosingRect[3])
 
-	bits := bitutil.NewBitMatrixWithSize(matrixWidth, matrixHeight)
-	for y := 0; y < matrixHeight; y++ {
-		iy := top + min((y*height+height/2)/matrixHeight, height-1)
-		for x := 0; x < matrixWidth; x++ {
-			// Odd rows are offset by half a module width (hexagonal layout).
-			ix := left + min(
-				(x*width+width/2+(y&0x01)*width/2)/matrixWidth,
-				width-1)
-			if image.Get(ix, iy) {
-				bits.Set(x, y)
-			}
-		}
+	latticeWidth, latticeHeight := transform.HexagonalLattice.Bounds(matrixWidth, matrixHeight)
+	toImage := transform.Translation(left, top).Times(transform.Scaling(width/latticeWidth, height/latticeHeight))
+	bits, err := transform.SampleLattice(image, transform.HexagonalLattice, matrixWidth, matrixHeight, toImage)
+	heatmap.Candidate(zxinggo.FormatMaxiCode, left+width/2, top+height/2, max(width, height), err == nil)
+	if err != nil {
+		return nil, zxinggo.ErrNotFound
 	}
 	return bits, nil
 }
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
@@ -6,4 +6,7 @@ func init() {
 	zxinggo.RegisterReader(zxinggo.FormatMaxiCode, func(opts *zxinggo.DecodeOptions) zxinggo.Reader {
 		return NewReader()
 	})
+	zxinggo.RegisterWriter(zxinggo.FormatMaxiCode, func() zxinggo.Writer {
+		return NewWriter()
+	})
 }
@@ -0,0 +1,76 @@
+package maxicode
+
+import (
+	"fmt"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/maxicode/encoder"
+	"github.com/ericlevine/zxinggo/render"
+	"github.com/ericlevine/zxinggo/transform"
+)
+
+const (
+	// defaultMode is the mode Writer uses when EncodeOptions.MaxiCodeMode is
+	// zero: standard error correction with no carrier message.
+	defaultMode = 4
+	// minModuleWidth is the narrowest module, in pixels, Writer draws.
+	minModuleWidth = 6
+)
+
+// finderRadii are the radii, in module widths, where the finder pattern
+// changes between light and dark, from the light centre out to the edge of
+// the outermost of its three dark rings.
+var finderRadii = []float64{0.71, 1.43, 2.14, 2.86, 3.57, 4.29}
+
+// Writer encodes MaxiCode barcodes.
+type Writer struct{}
+
+// NewWriter creates a new MaxiCode Writer.
+func NewWriter() *Writer {
+	return &Writer{}
+}
+
+// Encode encodes the given contents into a MaxiCode image of hexagonal
+// modules around the finder pattern, in the mode opts.MaxiCodeMode selects;
+// see encoder.Encode for the contents modes 2 and 3 take. The symbol is
+// drawn as large as fits in width by height, with modules at least 6 pixels
+// wide.
+func (w *Writer) Encode(contents string, format zxinggo.Format, width, height int, opts *zxinggo.EncodeOptions) (*bitutil.BitMatrix, error) {
+	if contents == "" {
+		return nil, fmt.Errorf("found empty contents")
+	}
+	if format != zxinggo.FormatMaxiCode {
+		return nil, fmt.Errorf("can only encode MAXICODE, but got %s", format)
+	}
+	if width < 0 || height < 0 {
+		return nil, fmt.Errorf("requested dimensions are too small: %dx%d", width, height)
+	}
+
+	mode := defaultMode
+	if opts != nil && opts.MaxiCodeMode != 0 {
+		mode = opts.MaxiCodeMode
+	}
+	code, err := encoder.Encode(contents, mode)
+	if err != nil {
+		return nil, err
+	}
+	return renderHexagons(code, width, height, zxinggo.EncodeQuietZone(zxinggo.FormatMaxiCode, opts).Left), nil
+}
+
+// renderHexagons draws the 30x33 module grid code as hexagons and the
+// finder pattern at its centre.
+func renderHexagons(code *bitutil.BitMatrix, width, height, quietZone int) *bitutil.BitMatrix {
+	canvas := render.NewCanvas(transform.HexagonalLattice, matrixWidth, matrixHeight, width, height, quietZone, minModuleWidth)
+	canvas.DrawModules(code)
+	// The finder is centred on the middle of row 16, between the orientation
+	// modules at columns 8 and 20.
+	cx, cy := transform.HexagonalLattice.Center(matrixWidth/2-1, matrixHeight/2)
+	for i := 0; i+1 < len(finderRadii); i += 2 {
+		canvas.FillRing(cx, cy, finderRadii[i], finderRadii[i+1])
+	}
+	return canvas.Matrix
+}
+
+// Compile-time check.
+var _ zxinggo.Writer = (*Writer)(nil)
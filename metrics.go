@@ -0,0 +1,258 @@
+package zxinggo
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MetricsSink receives counts and timings from decoding, so that servers can
+// export them, for example to Prometheus, without timing each call to
+// Decode. Set DecodeOptions.Metrics to one. Its methods are called from
+// every goroutine decoding with the options, so they must be safe for
+// concurrent use, and are called synchronously, so they should be quick.
+// Metrics is an implementation that keeps the counts in memory.
+type MetricsSink interface {
+	// ObserveRead is called after a format's reader has tried an image,
+	// with whether it read a symbol and how long it took. The format is
+	// that of the symbol read or, when none is, that the reader is
+	// registered for; the 1D reader, registered for every 1D format,
+	// reports failures under FormatAnyOneD whichever it was asked for.
+	// Retries of a padded or inverted image are reported as reads of their
+	// own.
+	ObserveRead(format Format, ok bool, d time.Duration)
+
+	// ObserveStage is called after a stage of decoding has run, with how
+	// long it took.
+	ObserveStage(stage MetricsStage, d time.Duration)
+}
+
+// MetricsStage is a stage of decoding whose time a MetricsSink observes.
+type MetricsStage int
+
+const (
+	// MetricsStageDecode is a whole call to Decode or DecodeWithFormat.
+	MetricsStageDecode MetricsStage = iota
+	// MetricsStageContrast is enhancing the contrast of an image; see
+	// DecodeOptions.Contrast.
+	MetricsStageContrast
+	// MetricsStageRegions is proposing regions and reading them; see
+	// DecodeOptions.RegionProposer.
+	MetricsStageRegions
+	// MetricsStagePadded is reading a PureBarcode image again padded with
+	// a quiet zone.
+	MetricsStagePadded
+	// MetricsStageInverted is reading an image again inverted; see
+	// DecodeOptions.AlsoInverted.
+	MetricsStageInverted
+)
+
+var metricsStageNames = [...]string{
+	MetricsStageDecode:   "decode",
+	MetricsStageContrast: "contrast",
+	MetricsStageRegions:  "regions",
+	MetricsStagePadded:   "padded",
+	MetricsStageInverted: "inverted",
+}
+
+func (s MetricsStage) String() string {
+	if s >= 0 && int(s) < len(metricsStageNames) {
+		return metricsStageNames[s]
+	}
+	return fmt.Sprintf("MetricsStage(%d)", int(s))
+}
+
+// observeStage reports the time since start to opts.Metrics, if set. It is
+// meant to be deferred with start as time.Now().
+func observeStage(opts *DecodeOptions, stage MetricsStage, start time.Time) {
+	if opts != nil && opts.Metrics != nil {
+		opts.Metrics.ObserveStage(stage, time.Since(start))
+	}
+}
+
+// readImage decodes image with reader, built from the registration group,
+// reporting the read to opts.Metrics if it is set.
+func readImage(reader Reader, group int, image *BinaryBitmap, opts *DecodeOptions) (*Result, error) {
+	if opts == nil || opts.Metrics == nil {
+		return reader.Decode(image, opts)
+	}
+	start := time.Now()
+	result, err := reader.Decode(image, opts)
+	d := time.Since(start)
+	if err == nil {
+		opts.Metrics.ObserveRead(result.Format, true, d)
+	} else {
+		opts.Metrics.ObserveRead(groupFormat(group), false, d)
+	}
+	return result, err
+}
+
+// groupFormat returns the format registered under group or, if several
+// are, the smallest family that includes them all, the first in order of
+// those of equal size, or failing that the first of them.
+func groupFormat(group int) Format {
+	var formats []Format
+	for f, g := range readerGroups {
+		if g == group {
+			formats = append(formats, f)
+		}
+	}
+	slices.Sort(formats)
+	if len(formats) == 1 {
+		return formats[0]
+	}
+	best, size := formats[0], -1
+	for _, family := range families {
+		members := formatFamilies[family]
+		if size >= 0 && len(members) >= size {
+			continue
+		}
+		if !slices.ContainsFunc(formats, func(f Format) bool { return !slices.Contains(members, f) }) {
+			best, size = family, len(members)
+		}
+	}
+	return best
+}
+
+// metricsBuckets are the upper bounds, in seconds, of the histogram
+// buckets Metrics sorts durations into.
+var metricsBuckets = [...]float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// Metrics is a MetricsSink that counts reads and sorts their durations
+// into histograms in memory, safe for concurrent use. WritePrometheus
+// writes them in the Prometheus text format, to serve from a /metrics
+// handler.
+type Metrics struct {
+	mu     sync.Mutex
+	reads  map[Format]*[2]uint64 // failures, successes
+	read   map[Format]*histogram
+	stages map[MetricsStage]*histogram
+}
+
+// NewMetrics returns an empty Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		reads:  map[Format]*[2]uint64{},
+		read:   map[Format]*histogram{},
+		stages: map[MetricsStage]*histogram{},
+	}
+}
+
+// histogram counts durations into metricsBuckets, the last count being
+// those beyond the largest bound.
+type histogram struct {
+	counts [len(metricsBuckets) + 1]uint64
+	sum    float64
+}
+
+func (h *histogram) observe(seconds float64) {
+	i := sort.SearchFloat64s(metricsBuckets[:], seconds)
+	h.counts[i]++
+	h.sum += seconds
+}
+
+// ObserveRead implements MetricsSink.
+func (m *Metrics) ObserveRead(format Format, ok bool, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := m.reads[format]
+	if counts == nil {
+		counts = new([2]uint64)
+		m.reads[format] = counts
+		m.read[format] = &histogram{}
+	}
+	if ok {
+		counts[1]++
+	} else {
+		counts[0]++
+	}
+	m.read[format].observe(d.Seconds())
+}
+
+// ObserveStage implements MetricsSink.
+func (m *Metrics) ObserveStage(stage MetricsStage, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h := m.stages[stage]
+	if h == nil {
+		h = &histogram{}
+		m.stages[stage] = h
+	}
+	h.observe(d.Seconds())
+}
+
+// Reads returns how many reads of format have succeeded and failed.
+func (m *Metrics) Reads(format Format) (succeeded, failed uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if counts := m.reads[format]; counts != nil {
+		return counts[1], counts[0]
+	}
+	return 0, 0
+}
+
+// WritePrometheus writes the metrics to w in the Prometheus text exposition
+// format: the counter zxinggo_reads_total, labelled by format and result,
+// and the histograms zxinggo_read_duration_seconds, labelled by format, and
+// zxinggo_stage_duration_seconds, labelled by stage.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	formats := make([]Format, 0, len(m.reads))
+	for f := range m.reads {
+		formats = append(formats, f)
+	}
+	slices.Sort(formats)
+	stages := make([]MetricsStage, 0, len(m.stages))
+	for s := range m.stages {
+		stages = append(stages, s)
+	}
+	slices.Sort(stages)
+
+	p := &promWriter{w: w}
+	p.printf("# HELP zxinggo_reads_total Reads of an image by a format's reader.\n")
+	p.printf("# TYPE zxinggo_reads_total counter\n")
+	for _, f := range formats {
+		p.printf("zxinggo_reads_total{format=%q,result=\"success\"} %d\n", f.String(), m.reads[f][1])
+		p.printf("zxinggo_reads_total{format=%q,result=\"failure\"} %d\n", f.String(), m.reads[f][0])
+	}
+	p.printf("# HELP zxinggo_read_duration_seconds Time a format's reader took to read an image.\n")
+	p.printf("# TYPE zxinggo_read_duration_seconds histogram\n")
+	for _, f := range formats {
+		p.histogram("zxinggo_read_duration_seconds", fmt.Sprintf("format=%q", f.String()), m.read[f])
+	}
+	p.printf("# HELP zxinggo_stage_duration_seconds Time a stage of decoding took.\n")
+	p.printf("# TYPE zxinggo_stage_duration_seconds histogram\n")
+	for _, s := range stages {
+		p.histogram("zxinggo_stage_duration_seconds", fmt.Sprintf("stage=%q", s.String()), m.stages[s])
+	}
+	return p.err
+}
+
+// promWriter writes Prometheus text, keeping the first error.
+type promWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (p *promWriter) printf(format string, args ...any) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format, args...)
+	}
+}
+
+// histogram writes h's cumulative buckets, sum and count.
+func (p *promWriter) histogram(name, labels string, h *histogram) {
+	var count uint64
+	for i, bound := range metricsBuckets {
+		count += h.counts[i]
+		p.printf("%s_bucket{%s,le=\"%g\"} %d\n", name, labels, bound, count)
+	}
+	count += h.counts[len(metricsBuckets)]
+	p.printf("%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, count)
+	p.printf("%s_sum{%s} %g\n", name, labels, h.sum)
+	p.printf("%s_count{%s} %d\n", name, labels, count)
+}
@@ -0,0 +1,67 @@
+package zxinggo_test
+
+import (
+	"image"
+	"image/draw"
+	"strings"
+	"sync"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+)
+
+func TestMetrics(t *testing.T) {
+	matrix, err := zxinggo.Encode("METRICS", zxinggo.FormatQRCode, 200, 200, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := zxinggo.BitMatrixToImage(matrix)
+	blank := image.NewGray(code.Bounds())
+	draw.Draw(blank, blank.Bounds(), image.White, image.Point{}, draw.Src)
+
+	metrics := zxinggo.NewMetrics()
+	opts := &zxinggo.DecodeOptions{
+		PossibleFormats: []zxinggo.Format{zxinggo.FormatQRCode, zxinggo.FormatEAN13, zxinggo.FormatCode128},
+		Metrics:         metrics,
+	}
+	const decodes = 8
+	var wg sync.WaitGroup
+	for i := range 2 * decodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			img := image.Image(code)
+			if i%2 == 1 {
+				img = blank
+			}
+			source := zxinggo.NewImageLuminanceSource(img)
+			zxinggo.Decode(zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source)), opts)
+		}()
+	}
+	wg.Wait()
+
+	if ok, failed := metrics.Reads(zxinggo.FormatQRCode); ok != decodes || failed != decodes {
+		t.Errorf("QR_CODE reads: %d succeeded, %d failed, want %d of each", ok, failed, decodes)
+	}
+	// The 1D reader is registered for every 1D format, so its failures are
+	// counted under the family of them.
+	if ok, failed := metrics.Reads(zxinggo.FormatAnyOneD); ok != 0 || failed != decodes {
+		t.Errorf("ANY_1D reads: %d succeeded, %d failed, want 0 and %d", ok, failed, decodes)
+	}
+
+	var out strings.Builder
+	if err := metrics.WritePrometheus(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`zxinggo_reads_total{format="QR_CODE",result="success"} 8`,
+		`zxinggo_read_duration_seconds_count{format="QR_CODE"} 16`,
+		`zxinggo_read_duration_seconds_bucket{format="QR_CODE",le="+Inf"} 16`,
+		`zxinggo_stage_duration_seconds_count{stage="decode"} 16`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %s:\n%s", want, out.String())
+		}
+	}
+}
@@ -2,6 +2,7 @@ package zxinggo
 
 // MultipleBarcodeReader can decode multiple barcodes from a single image.
 type MultipleBarcodeReader interface {
-	// DecodeMultiple attempts to decode all barcodes in the image.
+	// DecodeMultiple attempts to decode all barcodes in the image. It stops
+	// early as opts.MaxResults and opts.StopOnFormats ask; see SearchDone.
 	DecodeMultiple(image *BinaryBitmap, opts *DecodeOptions) ([]*Result, error)
 }
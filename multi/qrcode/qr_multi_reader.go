@@ -2,7 +2,6 @@
 package qrcode
 
 import (
-	"fmt"
 	"sort"
 
 	zxinggo "github.com/ericlevine/zxinggo"
@@ -12,58 +11,55 @@ import (
 
 // QRCodeMultiReader can detect and decode multiple QR codes in an image,
 // and also combines structured append results.
-type QRCodeMultiReader struct {
-	dec *decoder.Decoder
-}
+type QRCodeMultiReader struct{}
 
 // NewQRCodeMultiReader creates a new QRCodeMultiReader.
 func NewQRCodeMultiReader() *QRCodeMultiReader {
-	return &QRCodeMultiReader{dec: decoder.NewDecoder()}
+	return &QRCodeMultiReader{}
 }
 
-// DecodeMultiple detects and decodes all QR codes in the image.
+// DecodeMultiple detects and decodes all QR codes in the image, until
+// opts.MaxResults or opts.StopOnFormats ends the search. Each symbol of a
+// structured append message counts toward MaxResults before they are
+// combined.
 func (r *QRCodeMultiReader) DecodeMultiple(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) ([]*zxinggo.Result, error) {
 	if opts == nil {
 		opts = &zxinggo.DecodeOptions{}
 	}
+	dec := decoder.NewDecoder()
+	dec.SkipFormatCandidates = zxinggo.DetectorDisabled(opts, zxinggo.DetectorQRFormatCandidates)
 
 	matrix, err := image.BlackMatrix()
 	if err != nil {
 		return nil, err
 	}
 
-	detectorResults, err := detector.DetectMulti(matrix, opts.TryHarder)
+	det := detector.NewDetector(matrix)
+	det.SkipAlignmentRetry = zxinggo.DetectorDisabled(opts, zxinggo.DetectorQRAlignmentRetry)
+	det.SkipGridFit = zxinggo.DetectorDisabled(opts, zxinggo.DetectorQRGridFit)
+	det.RequireAlignmentFrom = opts.QRRequireAlignmentFrom
+	detectorResults, err := det.DetectMulti(opts.TryHarder)
 	if err != nil {
 		return nil, err
 	}
 
 	var results []*zxinggo.Result
 	for _, detResult := range detectorResults {
-		dr, err := r.dec.Decode(detResult.Bits, opts.CharacterSet)
+		dr, err := dec.Decode(detResult.Bits, opts.CharacterSet)
 		if err != nil {
 			continue
 		}
 
-		points := make([]zxinggo.ResultPoint, len(detResult.Points))
-		for i, p := range detResult.Points {
-			points[i] = zxinggo.ResultPoint{X: p.X, Y: p.Y}
-		}
-
-		result := zxinggo.NewResult(dr.Text, dr.RawBytes, points, zxinggo.FormatQRCode)
-		if dr.ByteSegments != nil {
-			result.PutMetadata(zxinggo.MetadataByteSegments, dr.ByteSegments)
-		}
-		if dr.ECLevel != "" {
-			result.PutMetadata(zxinggo.MetadataErrorCorrectionLevel, dr.ECLevel)
-		}
-		if dr.HasStructuredAppend() {
-			result.PutMetadata(zxinggo.MetadataStructuredAppendSequence, dr.StructuredAppendSequenceNumber)
-			result.PutMetadata(zxinggo.MetadataStructuredAppendParity, dr.StructuredAppendParity)
+		result := dr.Result(detResult.Points, zxinggo.FormatQRCode)
+		result.PutMetadata(zxinggo.MetadataAlignmentPattern, detResult.Alignment.String())
+		if zxinggo.CheckErrorBudget(result, opts) != nil {
+			continue
 		}
-		result.PutMetadata(zxinggo.MetadataErrorsCorrected, dr.ErrorsCorrected)
-		result.PutMetadata(zxinggo.MetadataSymbologyIdentifier, fmt.Sprintf("]Q%d", dr.SymbologyModifier))
 
 		results = append(results, result)
+		if zxinggo.SearchDone(opts, len(results), result) {
+			break
+		}
 	}
 
 	if len(results) == 0 {
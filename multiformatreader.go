@@ -1,101 +1,320 @@
 package zxinggo
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ericlevine/zxinggo/bitutil"
+)
 
 // MultiFormatReader is a factory/dispatcher that selects appropriate Reader
 // implementations based on format hints and tries them in sequence.
 type MultiFormatReader struct {
 	readers []Reader
+	// groups holds the registration each of readers was built from.
+	groups []int
 }
 
 // NewMultiFormatReader creates a new multi-format reader. If opts specifies
-// PossibleFormats, only those formats are tried. Otherwise all formats are tried.
+// PossibleFormats, only those formats are tried, in that order. Otherwise all
+// formats whose packages are imported are tried, in an order chosen for each
+// image from cheap statistics of it: 1D formats first if it shows bars, and
+// the 2D formats first if it does not. See ReadableFormats and
+// Heatmap.FormatOrder.
 func NewMultiFormatReader() *MultiFormatReader {
 	return &MultiFormatReader{}
 }
 
 // Decode attempts to decode a barcode from the given image using all registered
 // format readers.
-func (r *MultiFormatReader) Decode(image *BinaryBitmap, opts *DecodeOptions) (*Result, error) {
+func (r *MultiFormatReader) Decode(image *BinaryBitmap, opts *DecodeOptions) (result *Result, err error) {
+	defer recoverIndexError(&err)
+	defer observeStage(opts, MetricsStageDecode, time.Now())
+	image = enhanceContrast(image, opts)
+	if opts != nil && opts.RegionProposer != nil {
+		if result, err := decodeRegions(image, opts); err == nil {
+			return result, nil
+		}
+		if opts.RegionsOnly {
+			return nil, ErrNotFound
+		}
+	}
 	if r.readers == nil {
-		r.readers = buildReaders(opts)
+		r.readers, r.groups = buildGroupedReaders(opts)
 	}
-	for _, reader := range r.readers {
-		result, err := reader.Decode(image, opts)
+	readers, groups := r.readers, r.groups
+	if !formatsRequested(opts) {
+		order := prioritizedOrder(image.binarizer.LuminanceSource(), opts)
+		if opts != nil {
+			opts.Heatmap.recordFormatOrder(order)
+		}
+		readers, groups = sortReaders(r.readers, r.groups, order)
+	}
+	var failed *DecodeError
+	for i, reader := range readers {
+		if DeadlinePassed(opts) {
+			return nil, ErrDeadlineExceeded
+		}
+		result, err := readImage(reader, groups[i], image, opts)
 		if err == nil {
+			return refineResult(image, result, opts), nil
+		}
+		keepDecodeError(&failed, err)
+	}
+	// Pad before AlsoInverted flips the black matrix.
+	if padded, pad := padPureImage(image, opts); padded != nil {
+		if result, ok := decodePadded(readers, groups, padded, pad, opts); ok {
 			return result, nil
 		}
 	}
 	if opts != nil && opts.AlsoInverted {
+		// Nothing follows the inverted image's reads to be timed with them.
+		defer observeStage(opts, MetricsStageInverted, time.Now())
 		// Try again with inverted image — flip the cached black matrix in-place
 		matrix, err := image.BlackMatrix()
 		if err == nil {
 			matrix.FlipAll()
-			for _, reader := range r.readers {
-				result, err := reader.Decode(image, opts)
+			for i, reader := range readers {
+				if DeadlinePassed(opts) {
+					return nil, ErrDeadlineExceeded
+				}
+				result, err := readImage(reader, groups[i], image, opts)
 				if err == nil {
-					return result, nil
+					return refineResult(image, result, opts), nil
 				}
+				keepDecodeError(&failed, err)
 			}
 		}
 	}
+	if DeadlinePassed(opts) {
+		return nil, ErrDeadlineExceeded
+	}
+	if failed != nil {
+		return nil, failed
+	}
 	return nil, ErrNotFound
 }
 
-// DecodeWithFormat attempts to decode a barcode of the given format.
-func (r *MultiFormatReader) DecodeWithFormat(image *BinaryBitmap, format Format, opts *DecodeOptions) (*Result, error) {
+// DecodeWithFormat attempts to decode a barcode of the given format, or of
+// any format of the given family.
+func (r *MultiFormatReader) DecodeWithFormat(image *BinaryBitmap, format Format, opts *DecodeOptions) (result *Result, err error) {
+	defer recoverIndexError(&err)
 	if opts == nil {
 		opts = &DecodeOptions{}
 	}
+	defer observeStage(opts, MetricsStageDecode, time.Now())
 	opts.PossibleFormats = []Format{format}
-	readers := buildReaders(opts)
-	for _, reader := range readers {
-		result, err := reader.Decode(image, opts)
+	if !formatsRequested(opts) {
+		return nil, fmt.Errorf("no reader registered for format %s: %w", format, ErrNotFound)
+	}
+	image = enhanceContrast(image, opts)
+	if opts.RegionProposer != nil {
+		if result, err := decodeRegions(image, opts); err == nil {
+			return result, nil
+		}
+		if opts.RegionsOnly {
+			return nil, fmt.Errorf("no barcode of format %s found: %w", format, ErrNotFound)
+		}
+	}
+	readers, groups := buildGroupedReaders(opts)
+	var failed *DecodeError
+	for i, reader := range readers {
+		if DeadlinePassed(opts) {
+			return nil, ErrDeadlineExceeded
+		}
+		result, err := readImage(reader, groups[i], image, opts)
 		if err == nil {
+			return refineResult(image, result, opts), nil
+		}
+		keepDecodeError(&failed, err)
+	}
+	if padded, pad := padPureImage(image, opts); padded != nil {
+		if result, ok := decodePadded(readers, groups, padded, pad, opts); ok {
 			return result, nil
 		}
 	}
+	if DeadlinePassed(opts) {
+		return nil, ErrDeadlineExceeded
+	}
+	if failed != nil {
+		return nil, failed
+	}
 	return nil, fmt.Errorf("no barcode of format %s found: %w", format, ErrNotFound)
 }
 
+// refineResult applies sub-pixel point refinement and text normalization if
+// opts requests them, and records the result in opts.Heatmap.
+func refineResult(image *BinaryBitmap, result *Result, opts *DecodeOptions) *Result {
+	normalizeResult(result, opts)
+	if opts != nil && opts.SubPixelRadius > 0 {
+		RefineResultPoints(image.binarizer.LuminanceSource(), result, opts.SubPixelRadius)
+	}
+	if opts != nil {
+		opts.Heatmap.recordResult(result)
+	}
+	return result
+}
+
+// keepDecodeError sets *failed to err if it is the first *DecodeError a
+// reader has returned, so that it can be reported if nothing is read.
+func keepDecodeError(failed **DecodeError, err error) {
+	if *failed == nil {
+		errors.As(err, failed)
+	}
+}
+
+// recoverIndexError converts an out-of-range BitMatrix or BitArray access,
+// which only panics in builds with the zxinggo_checked tag, into an error.
+// Any other panic is propagated.
+// Callers must return a nil result alongside the error.
+func recoverIndexError(err *error) {
+	if r := recover(); r != nil {
+		indexErr, ok := r.(*bitutil.IndexError)
+		if !ok {
+			panic(r)
+		}
+		*err = indexErr
+	}
+}
+
 // Reset resets all internal readers.
 func (r *MultiFormatReader) Reset() {
 	for _, reader := range r.readers {
 		reader.Reset()
 	}
-	r.readers = nil
+	r.readers, r.groups = nil, nil
 }
 
+// Ensure MultiFormatReader implements Reader at compile time.
+var _ Reader = (*MultiFormatReader)(nil)
+
 // readerFactory is a function that creates a Reader. This is used as an
 // extension point so format-specific packages can register themselves.
 type readerFactory func(opts *DecodeOptions) Reader
 
 var readerFactories = map[Format]readerFactory{}
 
+// readerGroups maps each format to the registration that covers it, so that
+// formats registered together by RegisterReaders share one reader.
+var readerGroups = map[Format]int{}
+
+// registrations counts the calls to RegisterReaders.
+var registrations int
+
 // RegisterReader registers a reader factory for the given format. This should
 // be called from an init() function in format-specific packages.
 func RegisterReader(format Format, factory readerFactory) {
-	readerFactories[format] = factory
+	RegisterReaders([]Format{format}, factory)
 }
 
-// buildReaders creates readers based on the options.
-func buildReaders(opts *DecodeOptions) []Reader {
-	var readers []Reader
+// RegisterReaders registers one reader factory for several formats that the
+// readers it creates each decode all of, as the 1D reader does. When more
+// than one of the formats is to be tried, one reader is built for them.
+func RegisterReaders(formats []Format, factory readerFactory) {
+	registrations++
+	for _, f := range formats {
+		readerFactories[f] = factory
+		readerGroups[f] = registrations
+	}
+}
 
-	if opts != nil && len(opts.PossibleFormats) > 0 {
-		for _, f := range opts.PossibleFormats {
-			if factory, ok := readerFactories[f]; ok {
-				readers = append(readers, factory(opts))
-			}
+// leadingFormats are the formats Java ZXing's MultiFormatReader tries,
+// besides the 1D ones, when it is given none, in its order.
+var leadingFormats = []Format{FormatQRCode, FormatDataMatrix, FormatAztec, FormatPDF417, FormatMaxiCode}
+
+// readOrder returns every format a reader is registered for, in the order
+// they are tried when none are requested. As in Java ZXing, the formats of
+// leadingFormats come after the others, which are mostly quick 1D scans,
+// unless opts asks to try harder, when they come first.
+func readOrder(opts *DecodeOptions) []Format {
+	var leading, others []Format
+	for _, f := range leadingFormats {
+		if _, ok := readerFactories[f]; ok {
+			leading = append(leading, f)
+		}
+	}
+	for _, f := range ReadableFormats() {
+		if !slices.Contains(leadingFormats, f) {
+			others = append(others, f)
 		}
 	}
+	if opts != nil && opts.TryHarder {
+		return append(leading, others...)
+	}
+	return append(others, leading...)
+}
 
-	if len(readers) == 0 {
-		// Try all registered readers
-		for _, factory := range readerFactories {
-			readers = append(readers, factory(opts))
+// requestedFormats returns the formats of opts.PossibleFormats, with
+// families expanded, that a reader is registered for.
+func requestedFormats(opts *DecodeOptions) []Format {
+	var formats []Format
+	if opts != nil {
+		for _, f := range ExpandFormats(opts.PossibleFormats) {
+			if _, ok := readerFactories[f]; ok {
+				formats = append(formats, f)
+			}
 		}
 	}
+	return formats
+}
 
+// formatsRequested reports whether opts requests any format a reader is
+// registered for, so that buildReaders builds readers for only those.
+func formatsRequested(opts *DecodeOptions) bool {
+	return len(requestedFormats(opts)) > 0
+}
+
+// buildReaders creates readers based on the options: one for each requested
+// format, or if none are requested or registered, for every format, in
+// readOrder. Formats registered together get one reader.
+func buildReaders(opts *DecodeOptions) []Reader {
+	readers, _ := buildGroupedReaders(opts)
 	return readers
 }
+
+// buildGroupedReaders is buildReaders, also returning the registration each
+// reader was built from.
+func buildGroupedReaders(opts *DecodeOptions) ([]Reader, []int) {
+	formats := requestedFormats(opts)
+	if len(formats) == 0 {
+		formats = readOrder(opts)
+	}
+
+	var readers []Reader
+	var groups []int
+	built := map[int]bool{}
+	for _, f := range formats {
+		if built[readerGroups[f]] {
+			continue
+		}
+		built[readerGroups[f]] = true
+		readers = append(readers, readerFactories[f](opts))
+		groups = append(groups, readerGroups[f])
+	}
+	return readers, groups
+}
+
+// sortReaders returns readers, built from the registrations groups, and
+// their groups in the order of the first format of order each was
+// registered for.
+func sortReaders(readers []Reader, groups []int, order []Format) ([]Reader, []int) {
+	rank := map[int]int{}
+	for i, f := range order {
+		if _, ok := rank[readerGroups[f]]; !ok {
+			rank[readerGroups[f]] = i
+		}
+	}
+	indices := make([]int, len(readers))
+	for i := range indices {
+		indices[i] = i
+	}
+	slices.SortStableFunc(indices, func(a, b int) int { return rank[groups[a]] - rank[groups[b]] })
+	sorted := make([]Reader, len(readers))
+	sortedGroups := make([]int, len(groups))
+	for i, j := range indices {
+		sorted[i], sortedGroups[i] = readers[j], groups[j]
+	}
+	return sorted, sortedGroups
+}
@@ -35,6 +35,40 @@ func (w *MultiFormatWriter) Encode(contents string, format Format, width, height
 	return writer.Encode(contents, format, width, height, opts)
 }
 
+// EncodeWithResult encodes the given contents into a barcode of the
+// specified format and describes the symbol produced. The result of a
+// writer that does not implement ResultWriter holds only the matrix and
+// format.
+func (w *MultiFormatWriter) EncodeWithResult(contents string, format Format, width, height int, opts *EncodeOptions) (*EncodeResult, error) {
+	factory, ok := writerFactories[format]
+	if !ok {
+		return nil, fmt.Errorf("no writer registered for format %s: %w", format, ErrWriter)
+	}
+	writer := factory()
+	if writer, ok := writer.(ResultWriter); ok {
+		return writer.EncodeWithResult(contents, format, width, height, opts)
+	}
+	matrix, err := writer.Encode(contents, format, width, height, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &EncodeResult{Matrix: matrix, Format: format, MaskPattern: -1}, nil
+}
+
+// EncodeBytes encodes data, byte for byte, into a barcode of the specified
+// format, whose writer must implement BytesWriter.
+func (w *MultiFormatWriter) EncodeBytes(data []byte, format Format, width, height int, opts *EncodeOptions) (*bitutil.BitMatrix, error) {
+	factory, ok := writerFactories[format]
+	if !ok {
+		return nil, fmt.Errorf("no writer registered for format %s: %w", format, ErrWriter)
+	}
+	writer, ok := factory().(BytesWriter)
+	if !ok {
+		return nil, fmt.Errorf("writer for format %s cannot encode bytes: %w", format, ErrWriter)
+	}
+	return writer.EncodeBytes(data, format, width, height, opts)
+}
+
 // Encode is a top-level convenience function that encodes the given contents
 // into a barcode of the specified format.
 func Encode(contents string, format Format, width, height int, opts *EncodeOptions) (*bitutil.BitMatrix, error) {
@@ -42,9 +76,30 @@ func Encode(contents string, format Format, width, height int, opts *EncodeOptio
 	return w.Encode(contents, format, width, height, opts)
 }
 
+// EncodeWithResult is a top-level convenience function that encodes the
+// given contents into a barcode of the specified format and describes the
+// symbol produced, such as the QR Code version, error correction level and
+// mask chosen.
+func EncodeWithResult(contents string, format Format, width, height int, opts *EncodeOptions) (*EncodeResult, error) {
+	return NewMultiFormatWriter().EncodeWithResult(contents, format, width, height, opts)
+}
+
+// EncodeBytes is a top-level convenience function that encodes data, byte
+// for byte, into a barcode of the specified format. Unlike Encode, it does
+// not treat data as text, so binary payloads survive unchanged. Only QR Code
+// supports it.
+func EncodeBytes(data []byte, format Format, width, height int, opts *EncodeOptions) (*bitutil.BitMatrix, error) {
+	return NewMultiFormatWriter().EncodeBytes(data, format, width, height, opts)
+}
+
 // Decode is a top-level convenience function that decodes a barcode from the
-// given BinaryBitmap.
+// given BinaryBitmap. If its source is a CoordinateMapper, the result's
+// points are in the coordinates of the original image.
 func Decode(image *BinaryBitmap, opts *DecodeOptions) (*Result, error) {
 	r := NewMultiFormatReader()
-	return r.Decode(image, opts)
+	result, err := r.Decode(image, opts)
+	if err != nil {
+		return nil, err
+	}
+	return mapToOriginal(image, result), nil
 }
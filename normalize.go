@@ -0,0 +1,87 @@
+package zxinggo
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// TextNormalization selects post-processing of decoded text for consumers
+// that mishandle what symbols carry, such as the GS characters separating
+// the fields of GS1 data. The zero value leaves text as decoded. Only Text
+// is changed; RawBytes and byte segments stay as read.
+type TextNormalization struct {
+	// GS selects what becomes of GS (ASCII 29) characters.
+	GS GSHandling
+
+	// GSPlaceholder replaces each GS character under GSReplace. Empty means
+	// DefaultGSPlaceholder.
+	GSPlaceholder string
+
+	// TrimSpace removes white space from the start and end of text.
+	TrimSpace bool
+
+	// NFC puts text in Unicode Normalization Form C, composing characters
+	// decoded as a letter followed by combining marks.
+	NFC bool
+
+	// KeepRaw records text as decoded in MetadataRawText when normalizing
+	// changes it.
+	KeepRaw bool
+}
+
+// GSHandling selects what TextNormalization does with GS characters.
+type GSHandling int
+
+const (
+	// GSKeep leaves GS characters in text.
+	GSKeep GSHandling = iota
+	// GSStrip removes GS characters.
+	GSStrip
+	// GSReplace replaces each GS character with a visible placeholder.
+	GSReplace
+)
+
+// DefaultGSPlaceholder is the placeholder GSReplace uses when
+// TextNormalization.GSPlaceholder is empty.
+const DefaultGSPlaceholder = "<GS>"
+
+// gs is the ASCII group separator, which GS1 data uses for FNC1.
+const gs = "\x1d"
+
+// Apply returns text normalized as n selects: GS characters are handled,
+// then Unicode normalized, then white space trimmed.
+func (n TextNormalization) Apply(text string) string {
+	switch n.GS {
+	case GSStrip:
+		text = strings.ReplaceAll(text, gs, "")
+	case GSReplace:
+		placeholder := n.GSPlaceholder
+		if placeholder == "" {
+			placeholder = DefaultGSPlaceholder
+		}
+		text = strings.ReplaceAll(text, gs, placeholder)
+	}
+	if n.NFC {
+		text = norm.NFC.String(text)
+	}
+	if n.TrimSpace {
+		text = strings.TrimSpace(text)
+	}
+	return text
+}
+
+// normalizeResult normalizes the text of result as opts asks.
+func normalizeResult(result *Result, opts *DecodeOptions) {
+	if opts == nil || opts.Normalize == (TextNormalization{}) {
+		return
+	}
+	text := opts.Normalize.Apply(result.Text)
+	if text == result.Text {
+		return
+	}
+	if opts.Normalize.KeepRaw {
+		result.PutMetadata(MetadataRawText, result.Text)
+	}
+	result.Text = text
+}
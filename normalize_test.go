@@ -0,0 +1,66 @@
+package zxinggo_test
+
+import (
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+)
+
+func TestTextNormalizationApply(t *testing.T) {
+	tests := []struct {
+		n    zxinggo.TextNormalization
+		text string
+		want string
+	}{
+		{zxinggo.TextNormalization{}, " 10AB\x1d21C ", " 10AB\x1d21C "},
+		{zxinggo.TextNormalization{GS: zxinggo.GSStrip}, "10AB\x1d21C\x1d", "10AB21C"},
+		{zxinggo.TextNormalization{GS: zxinggo.GSReplace}, "10AB\x1d21C", "10AB<GS>21C"},
+		{zxinggo.TextNormalization{GS: zxinggo.GSReplace, GSPlaceholder: "|"}, "10AB\x1d21C", "10AB|21C"},
+		{zxinggo.TextNormalization{TrimSpace: true}, "\t café\r\n", "café"},
+		{zxinggo.TextNormalization{NFC: true}, "café", "café"},
+		// A trailing GS is stripped before white space is trimmed.
+		{zxinggo.TextNormalization{GS: zxinggo.GSStrip, TrimSpace: true}, "ABC \x1d", "ABC"},
+	}
+	for _, tt := range tests {
+		if got := tt.n.Apply(tt.text); got != tt.want {
+			t.Errorf("%+v.Apply(%q) = %q, want %q", tt.n, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeNormalize(t *testing.T) {
+	matrix, err := zxinggo.Encode("(10)ABC(21)123", zxinggo.FormatCode128, 400, 80, &zxinggo.EncodeOptions{GS1Format: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := zxinggo.NewGrayImageLuminanceSource(zxinggo.BitMatrixToImage(matrix))
+	decode := func(n zxinggo.TextNormalization) *zxinggo.Result {
+		t.Helper()
+		opts := &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{zxinggo.FormatCode128}, AssumeGS1: true, Normalize: n}
+		result, err := zxinggo.Decode(zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source)), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	raw := decode(zxinggo.TextNormalization{})
+	if raw.Text != "]C110ABC\x1d21123" {
+		t.Fatalf("decoded %q without normalization", raw.Text)
+	}
+	if _, ok := raw.Metadata[zxinggo.MetadataRawText]; ok {
+		t.Error("raw text recorded without normalization")
+	}
+
+	result := decode(zxinggo.TextNormalization{GS: zxinggo.GSReplace, KeepRaw: true})
+	if result.Text != "]C110ABC<GS>21123" {
+		t.Errorf("normalized text %q, want %q", result.Text, "]C110ABC<GS>21123")
+	}
+	if got := result.Metadata[zxinggo.MetadataRawText]; got != raw.Text {
+		t.Errorf("raw text metadata %q, want %q", got, raw.Text)
+	}
+	if string(result.RawBytes) != string(raw.RawBytes) {
+		t.Error("normalization changed the raw bytes")
+	}
+}
@@ -30,7 +30,7 @@ func (w *CodabarWriter) Encode(contents string, format zxinggo.Format, width, he
 	if err != nil {
 		return nil, err
 	}
-	return RenderOneDCode(code, width, height), nil
+	return RenderOneDCodeQuietZone(code, width, height, zxinggo.EncodeQuietZone(zxinggo.FormatCodabar, opts)), nil
 }
 
 func (w *CodabarWriter) encode(contents string) ([]bool, error) {
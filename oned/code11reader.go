@@ -0,0 +1,210 @@
+package oned
+
+import (
+	"strings"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+)
+
+const code11AlphabetString = "0123456789-"
+
+// code11CharacterEncodings gives each character's three bars and two spaces,
+// first element in the most significant bit, with 1 for a wide element.
+var code11CharacterEncodings = [11]int{
+	0x01, 0x11, 0x09, 0x18, 0x05, 0x14, 0x0C, 0x03, 0x12, 0x10, // 0-9
+	0x04, // -
+}
+
+const code11StartStopEncoding = 0x06
+
+// code11TwoCheckDigitsLength is the shortest symbol, excluding start and stop
+// characters, that carries both the C and K check digits: ten data characters
+// and the two check digits.
+const code11TwoCheckDigitsLength = 12
+
+// Code11Reader decodes Code 11 barcodes. The C check digit, and for symbols
+// with ten or more data characters the K check digit, are verified and
+// removed from the result.
+type Code11Reader struct {
+	counters []int
+}
+
+// NewCode11Reader creates a new Code 11 reader.
+func NewCode11Reader() *Code11Reader {
+	return &Code11Reader{
+		counters: make([]int, 5),
+	}
+}
+
+// DecodeRow decodes a Code 11 barcode from a single row.
+func (r *Code11Reader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	start, err := r.findStartPattern(row)
+	if err != nil {
+		return nil, err
+	}
+	nextStart := row.GetNextSet(start[1])
+	end := row.Size()
+
+	counters := r.counters
+	var result strings.Builder
+	var lastStart int
+	for {
+		if err := RecordPattern(row, nextStart, counters); err != nil {
+			return nil, err
+		}
+		pattern := code11ToPattern(counters)
+		if pattern < 0 {
+			return nil, zxinggo.ErrNotFound
+		}
+		lastStart = nextStart
+		for _, c := range counters {
+			nextStart += c
+		}
+		if pattern == code11StartStopEncoding {
+			break
+		}
+		decodedChar, err := code11PatternToChar(pattern)
+		if err != nil {
+			return nil, err
+		}
+		result.WriteByte(decodedChar)
+		// Skip the narrow intercharacter gap.
+		nextStart = row.GetNextSet(nextStart)
+		if nextStart >= end {
+			return nil, zxinggo.ErrNotFound
+		}
+	}
+
+	// The stop character must be followed by a quiet zone of at least half its
+	// width.
+	lastPatternSize := nextStart - lastStart
+	whiteSpaceAfterEnd := row.GetNextSet(nextStart) - nextStart
+	if nextStart < end && whiteSpaceAfterEnd*2 < lastPatternSize {
+		return nil, zxinggo.ErrNotFound
+	}
+
+	s := result.String()
+	if len(s) < 2 {
+		return nil, zxinggo.ErrNotFound
+	}
+	if len(s) >= code11TwoCheckDigitsLength {
+		if code11CheckDigit(s[:len(s)-2], 10) != s[len(s)-2] ||
+			code11CheckDigit(s[:len(s)-1], 9) != s[len(s)-1] {
+			return nil, zxinggo.ErrChecksum
+		}
+		s = s[:len(s)-2]
+	} else {
+		if code11CheckDigit(s[:len(s)-1], 10) != s[len(s)-1] {
+			return nil, zxinggo.ErrChecksum
+		}
+		s = s[:len(s)-1]
+	}
+
+	left := float64(start[1]+start[0]) / 2.0
+	right := float64(lastStart) + float64(lastPatternSize)/2.0
+	res := zxinggo.NewResult(
+		s, nil,
+		[]zxinggo.ResultPoint{
+			{X: left, Y: float64(rowNumber)},
+			{X: right, Y: float64(rowNumber)},
+		},
+		zxinggo.FormatCode11,
+	)
+	res.PutMetadata(zxinggo.MetadataSymbologyIdentifier, "]H3")
+	return res, nil
+}
+
+func (r *Code11Reader) findStartPattern(row *bitutil.BitArray) ([2]int, error) {
+	width := row.Size()
+	rowOffset := row.GetNextSet(0)
+
+	counters := r.counters
+	for i := range counters {
+		counters[i] = 0
+	}
+	counterPosition := 0
+	patternStart := rowOffset
+	isWhite := false
+	patternLength := len(counters)
+
+	for i := rowOffset; i < width; i++ {
+		if row.Get(i) != isWhite {
+			counters[counterPosition]++
+		} else {
+			if counterPosition == patternLength-1 {
+				if code11ToPattern(counters) == code11StartStopEncoding {
+					whiteStart := patternStart - (i-patternStart)/2
+					if whiteStart < 0 {
+						whiteStart = 0
+					}
+					if row.IsRange(whiteStart, patternStart, false) {
+						return [2]int{patternStart, i}, nil
+					}
+				}
+				patternStart += counters[0] + counters[1]
+				copy(counters, counters[2:counterPosition+1])
+				counters[counterPosition-1] = 0
+				counters[counterPosition] = 0
+				counterPosition--
+			} else {
+				counterPosition++
+			}
+			counters[counterPosition] = 1
+			isWhite = !isWhite
+		}
+	}
+	return [2]int{}, zxinggo.ErrNotFound
+}
+
+// code11ToPattern classifies each element as narrow or wide using a threshold
+// halfway between the narrowest and widest element. Every Code 11 character
+// has at least one wide element, so the two widths must differ clearly.
+func code11ToPattern(counters []int) int {
+	minCounter, maxCounter := counters[0], counters[0]
+	for _, c := range counters[1:] {
+		if c < minCounter {
+			minCounter = c
+		}
+		if c > maxCounter {
+			maxCounter = c
+		}
+	}
+	if 2*maxCounter < 3*minCounter {
+		return -1
+	}
+	threshold := minCounter + maxCounter
+	pattern := 0
+	for _, c := range counters {
+		pattern <<= 1
+		if 2*c > threshold {
+			pattern |= 1
+		}
+	}
+	return pattern
+}
+
+func code11PatternToChar(pattern int) (byte, error) {
+	for i, enc := range code11CharacterEncodings {
+		if enc == pattern {
+			return code11AlphabetString[i], nil
+		}
+	}
+	return 0, zxinggo.ErrNotFound
+}
+
+// code11CheckDigit computes the modulo 11 check digit of s, weighting
+// characters from the right by 1 to maxWeight cyclically: 10 for the C check
+// digit and 9 for the K check digit.
+func code11CheckDigit(s string, maxWeight int) byte {
+	total := 0
+	weight := 1
+	for i := len(s) - 1; i >= 0; i-- {
+		total += weight * strings.IndexByte(code11AlphabetString, s[i])
+		weight++
+		if weight > maxWeight {
+			weight = 1
+		}
+	}
+	return code11AlphabetString[total%11]
+}
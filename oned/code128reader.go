@@ -153,7 +153,7 @@ func (r *Code128Reader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zx
 	convertFNC1 := opts != nil && opts.AssumeGS1
 	symbologyModifier := 0
 
-	startPatternInfo, err := findCode128StartPattern(row)
+	startPatternInfo, err := FindCode128StartPattern(row)
 	if err != nil {
 		return nil, err
 	}
@@ -194,7 +194,7 @@ func (r *Code128Reader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zx
 		isNextShifted = false
 		lastCode = code
 
-		code, err = decodeCode128(row, counters, nextStart)
+		code, err = DecodeCode128Character(row, counters, nextStart)
 		if err != nil {
 			return nil, err
 		}
@@ -383,6 +383,19 @@ func (r *Code128Reader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zx
 		return nil, zxinggo.ErrNotFound
 	}
 
+	// Under ProfileStrict, require the full quiet zone either side, in
+	// modules of the start character's width.
+	if zxinggo.ProfileOf(opts) == zxinggo.ProfileStrict {
+		zone := zxinggo.DefaultQuietZone(zxinggo.FormatCode128)
+		module := float64(startPatternInfo[1]-startPatternInfo[0]) / 11
+		quietStart := startPatternInfo[0] - int(math.Ceil(float64(zone.Left)*module))
+		quietEnd := nextStart + int(math.Ceil(float64(zone.Right)*module))
+		if quietStart < 0 || quietEnd > row.Size() ||
+			!row.IsRange(quietStart, startPatternInfo[0], false) || !row.IsRange(nextStart, quietEnd, false) {
+			return nil, zxinggo.ErrNotFound
+		}
+	}
+
 	// Validate checksum
 	checksumTotal -= multiplier * lastCode
 	if checksumTotal%103 != lastCode {
@@ -420,10 +433,17 @@ func (r *Code128Reader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zx
 		zxinggo.FormatCode128,
 	)
 	res.PutMetadata(zxinggo.MetadataSymbologyIdentifier, fmt.Sprintf("]C%d", symbologyModifier))
+	values := make([]int, len(rawCodes))
+	for i, c := range rawCodes {
+		values[i] = int(c)
+	}
+	res.PutMetadata(zxinggo.MetadataSymbolValues, values)
 	return res, nil
 }
 
-func findCode128StartPattern(row *bitutil.BitArray) ([3]int, error) {
+// FindCode128StartPattern finds a Code 128 start character preceded by quiet
+// zone, returning its start and end and the start character's value.
+func FindCode128StartPattern(row *bitutil.BitArray) ([3]int, error) {
 	width := row.Size()
 	rowOffset := row.GetNextSet(0)
 
@@ -471,7 +491,10 @@ func findCode128StartPattern(row *bitutil.BitArray) ([3]int, error) {
 	return [3]int{}, zxinggo.ErrNotFound
 }
 
-func decodeCode128(row *bitutil.BitArray, counters []int, rowOffset int) (int, error) {
+// DecodeCode128Character reads the six elements at rowOffset into counters
+// and returns the value of the Code 128 character they best match. The
+// stop character matches on its first six elements.
+func DecodeCode128Character(row *bitutil.BitArray, counters []int, rowOffset int) (int, error) {
 	if err := RecordPattern(row, rowOffset, counters); err != nil {
 		return -1, err
 	}
@@ -3,9 +3,11 @@ package oned
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/gs1"
 )
 
 // Escape characters used to specify FNC codes in Code 128 input.
@@ -24,11 +26,19 @@ func NewCode128Writer() *Code128Writer {
 	return &Code128Writer{}
 }
 
-// Encode encodes the given contents into a Code 128 barcode BitMatrix.
+// Encode encodes the given contents into a Code 128 barcode BitMatrix. With
+// opts.GS1Format, contents is a GS1 element string, bracketed or raw, and is
+// encoded as GS1-128.
 func (w *Code128Writer) Encode(contents string, format zxinggo.Format, width, height int, opts *zxinggo.EncodeOptions) (*bitutil.BitMatrix, error) {
 	if format != zxinggo.FormatCode128 {
 		return nil, fmt.Errorf("can only encode CODE_128, but got %s", format)
 	}
+	if opts != nil && opts.GS1Format {
+		var err error
+		if contents, err = gs1Code128Contents(contents); err != nil {
+			return nil, err
+		}
+	}
 
 	forcedCodeSet := -1
 	if opts != nil && opts.ForceCodeSet != "" {
@@ -52,7 +62,20 @@ func (w *Code128Writer) Encode(contents string, format zxinggo.Format, width, he
 	if err != nil {
 		return nil, err
 	}
-	return RenderOneDCode(code, width, height), nil
+	return RenderOneDCodeQuietZone(code, width, height, zxinggo.EncodeQuietZone(zxinggo.FormatCode128, opts)), nil
+}
+
+// gs1Code128Contents validates a GS1 element string and returns the Code 128
+// contents for it: FNC1 first, then each AI and its data, with FNC1 ending
+// each variable-length field but the last. An invalid element string is an
+// ErrWriter that also wraps the reason gs1.RawElementString gives.
+func gs1Code128Contents(elementString string) (string, error) {
+	raw, err := gs1.RawElementString(elementString)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid GS1 element string %q: %w", zxinggo.ErrWriter, elementString, err)
+	}
+	fnc1 := string([]byte{Code128EscapeFNC1})
+	return fnc1 + strings.ReplaceAll(raw, string(gs1.GroupSeparator), fnc1), nil
 }
 
 func checkCode128Contents(contents string, forcedCodeSet int) error {
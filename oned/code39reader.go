@@ -19,6 +19,22 @@ var code39CharacterEncodings = [43]int{
 
 const code39AsteriskEncoding = 0x094
 
+// code39StrictMaxGap is the widest gap between characters, in narrow
+// element widths, that ISO/IEC 16388 allows.
+const code39StrictMaxGap = 5.3
+
+// code39MaxGap returns the widest gap between characters, in narrow element
+// widths, opts allow, or a negative value for any.
+func code39MaxGap(opts *zxinggo.DecodeOptions) float64 {
+	if opts != nil && opts.MaxInterCharacterGap != 0 {
+		return opts.MaxInterCharacterGap
+	}
+	if zxinggo.ProfileOf(opts) == zxinggo.ProfileStrict {
+		return code39StrictMaxGap
+	}
+	return -1
+}
+
 // Code39Reader decodes Code 39 barcodes.
 type Code39Reader struct {
 	usingCheckDigit bool
@@ -47,6 +63,14 @@ func (r *Code39Reader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxi
 	nextStart := row.GetNextSet(start[1])
 	end := row.Size()
 
+	// The gap after each character but the stop character is checked
+	// against the width of its narrow elements, which for the start
+	// character counters hold now.
+	maxGap := code39MaxGap(opts)
+	if maxGap > 0 && float64(nextStart-start[1]) > maxGap*code39NarrowWidth(counters, code39AsteriskEncoding) {
+		return nil, zxinggo.ErrNotFound
+	}
+
 	var decodedChar byte
 	var lastStart int
 	for {
@@ -67,14 +91,18 @@ func (r *Code39Reader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxi
 		for _, c := range counters {
 			nextStart += c
 		}
+		charEnd := nextStart
 		nextStart = row.GetNextSet(nextStart)
 		if decodedChar == '*' {
 			break
 		}
+		if maxGap > 0 && float64(nextStart-charEnd) > maxGap*code39NarrowWidth(counters, pattern) {
+			return nil, zxinggo.ErrNotFound
+		}
 	}
 	// Remove trailing asterisk
-	s := result.String()
-	s = s[:len(s)-1]
+	read := result.String()
+	s := read[:len(read)-1]
 
 	lastPatternSize := 0
 	for _, c := range counters {
@@ -122,6 +150,12 @@ func (r *Code39Reader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxi
 		zxinggo.FormatCode39,
 	)
 	res.PutMetadata(zxinggo.MetadataSymbologyIdentifier, "]A0")
+	// The start and stop character, '*', counts as one past the alphabet.
+	values := []int{len(code39Alphabet)}
+	for i := 0; i < len(read); i++ {
+		values = append(values, strings.IndexByte(code39Alphabet+"*", read[i]))
+	}
+	res.PutMetadata(zxinggo.MetadataSymbolValues, values)
 	return res, nil
 }
 
@@ -203,6 +237,18 @@ func toNarrowWidePattern(counters []int) int {
 	return -1
 }
 
+// code39NarrowWidth returns the mean width of the six narrow elements of
+// the character whose elements are counters and pattern.
+func code39NarrowWidth(counters []int, pattern int) float64 {
+	narrow := 0
+	for i, c := range counters {
+		if pattern&(1<<uint(len(counters)-1-i)) == 0 {
+			narrow += c
+		}
+	}
+	return float64(narrow) / 6
+}
+
 func code39PatternToChar(pattern int) (byte, error) {
 	for i, enc := range code39CharacterEncodings {
 		if enc == pattern {
@@ -25,7 +25,7 @@ func (w *Code39Writer) Encode(contents string, format zxinggo.Format, width, hei
 	if err != nil {
 		return nil, err
 	}
-	return RenderOneDCode(code, width, height), nil
+	return RenderOneDCodeQuietZone(code, width, height, zxinggo.EncodeQuietZone(zxinggo.FormatCode39, opts)), nil
 }
 
 func (w *Code39Writer) encode(contents string) ([]bool, error) {
@@ -21,6 +21,22 @@ var code93CharacterEncodings = [48]int{
 
 var code93AsteriskEncoding = code93CharacterEncodings[47]
 
+// code93PermissiveMaxGap is the widest gap between characters, in modules,
+// ProfilePermissive allows: as wide as Code 39's may be.
+const code93PermissiveMaxGap = code39StrictMaxGap
+
+// code93MaxGap returns the widest gap between characters, in modules, opts
+// allow, zero for none or a negative value for any.
+func code93MaxGap(opts *zxinggo.DecodeOptions) float64 {
+	if opts != nil && opts.MaxInterCharacterGap != 0 {
+		return opts.MaxInterCharacterGap
+	}
+	if zxinggo.ProfileOf(opts) == zxinggo.ProfilePermissive {
+		return code93PermissiveMaxGap
+	}
+	return 0
+}
+
 // Code93Reader decodes Code 93 barcodes.
 type Code93Reader struct {
 	counters []int
@@ -35,7 +51,8 @@ func NewCode93Reader() *Code93Reader {
 
 // DecodeRow decodes a Code 93 barcode from a single row.
 func (r *Code93Reader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
-	start, err := r.findAsteriskPattern(row)
+	maxGap := code93MaxGap(opts)
+	start, err := r.findAsteriskPattern(row, maxGap)
 	if err != nil {
 		return nil, err
 	}
@@ -43,6 +60,9 @@ func (r *Code93Reader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxi
 	end := row.Size()
 
 	counters := r.counters
+	// All but the last space of the start character span 8 of its 9
+	// modules.
+	module := float64(counters[0]+counters[1]+counters[2]+counters[3]+counters[4]) / 8
 	for i := range counters {
 		counters[i] = 0
 	}
@@ -55,6 +75,17 @@ func (r *Code93Reader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxi
 		if err := RecordPattern(row, nextStart, counters); err != nil {
 			return nil, err
 		}
+		width := 0
+		for _, c := range counters {
+			width += c
+		}
+		if maxGap != 0 {
+			gap := code93SplitGap(counters, module)
+			if maxGap > 0 && float64(gap) > maxGap*module {
+				return nil, zxinggo.ErrNotFound
+			}
+			module = float64(width-gap) / 9
+		}
 		pattern := code93ToPattern(counters)
 		if pattern < 0 {
 			return nil, zxinggo.ErrNotFound
@@ -65,16 +96,13 @@ func (r *Code93Reader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxi
 		}
 		result.WriteByte(decodedChar)
 		lastStart = nextStart
-		for _, c := range counters {
-			nextStart += c
-		}
-		nextStart = row.GetNextSet(nextStart)
+		nextStart = row.GetNextSet(nextStart + width)
 		if decodedChar == '*' {
 			break
 		}
 	}
-	s := result.String()
-	s = s[:len(s)-1] // remove trailing asterisk
+	read := result.String()
+	s := read[:len(read)-1] // remove trailing asterisk
 
 	lastPatternSize := 0
 	for _, c := range counters {
@@ -112,10 +140,19 @@ func (r *Code93Reader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxi
 		zxinggo.FormatCode93,
 	)
 	res.PutMetadata(zxinggo.MetadataSymbologyIdentifier, "]G0")
+	// The start character is the stop character, '*', which ends the
+	// alphabet.
+	values := []int{len(code93AlphabetString) - 1}
+	for i := 0; i < len(read); i++ {
+		values = append(values, strings.IndexByte(code93AlphabetString, read[i]))
+	}
+	res.PutMetadata(zxinggo.MetadataSymbolValues, values)
 	return res, nil
 }
 
-func (r *Code93Reader) findAsteriskPattern(row *bitutil.BitArray) ([2]int, error) {
+// findAsteriskPattern finds the start character, allowing a gap of up to
+// maxGap modules after it, or any if maxGap is negative.
+func (r *Code93Reader) findAsteriskPattern(row *bitutil.BitArray, maxGap float64) ([2]int, error) {
 	width := row.Size()
 	rowOffset := row.GetNextSet(0)
 
@@ -136,6 +173,9 @@ func (r *Code93Reader) findAsteriskPattern(row *bitutil.BitArray) ([2]int, error
 				if code93ToPattern(counters) == code93AsteriskEncoding {
 					return [2]int{patternStart, i}, nil
 				}
+				if maxGap != 0 && code93AsteriskWithGap(counters, maxGap) {
+					return [2]int{patternStart, i}, nil
+				}
 				patternStart += counters[0] + counters[1]
 				copy(counters, counters[2:counterPosition+1])
 				counters[counterPosition-1] = 0
@@ -151,6 +191,31 @@ func (r *Code93Reader) findAsteriskPattern(row *bitutil.BitArray) ([2]int, error
 	return [2]int{}, zxinggo.ErrNotFound
 }
 
+// code93AsteriskWithGap reports whether counters are the start character
+// followed by a gap of up to maxGap modules, or any if maxGap is negative.
+func code93AsteriskWithGap(counters []int, maxGap float64) bool {
+	module := float64(counters[0]+counters[1]+counters[2]+counters[3]+counters[4]) / 8
+	split := make([]int, len(counters))
+	copy(split, counters)
+	gap := code93SplitGap(split, module)
+	return (maxGap < 0 || float64(gap) <= maxGap*module) && code93ToPattern(split) == code93AsteriskEncoding
+}
+
+// code93SplitGap separates the gap after a character from the space ending
+// it, the last of counters, given the module width expected of it: the
+// space is cut to what its other elements leave of the character's 9
+// modules, and at least a module. It returns the width of the gap cut off.
+func code93SplitGap(counters []int, module float64) int {
+	others := counters[0] + counters[1] + counters[2] + counters[3] + counters[4]
+	space := max(int(math.Round(9*module))-others, int(math.Round(module)), 1)
+	gap := counters[5] - space
+	if gap <= 0 {
+		return 0
+	}
+	counters[5] = space
+	return gap
+}
+
 func code93ToPattern(counters []int) int {
 	sum := 0
 	for _, c := range counters {
@@ -25,7 +25,7 @@ func (w *Code93Writer) Encode(contents string, format zxinggo.Format, width, hei
 	if err != nil {
 		return nil, err
 	}
-	return RenderOneDCode(code, width, height), nil
+	return RenderOneDCodeQuietZone(code, width, height, zxinggo.EncodeQuietZone(zxinggo.FormatCode93, opts)), nil
 }
 
 func (w *Code93Writer) encode(contents string) ([]bool, error) {
@@ -26,7 +26,7 @@ func (w *EAN13Writer) Encode(contents string, format zxinggo.Format, width, heig
 	if err != nil {
 		return nil, err
 	}
-	return RenderOneDCode(code, width, height), nil
+	return RenderOneDCodeQuietZone(code, width, height, zxinggo.EncodeQuietZone(zxinggo.FormatEAN13, opts)), nil
 }
 
 // EncodeContents encodes EAN-13 contents into a boolean pattern.
@@ -26,7 +26,7 @@ func (w *EAN8Writer) Encode(contents string, format zxinggo.Format, width, heigh
 	if err != nil {
 		return nil, err
 	}
-	return RenderOneDCode(code, width, height), nil
+	return RenderOneDCodeQuietZone(code, width, height, zxinggo.EncodeQuietZone(zxinggo.FormatEAN8, opts)), nil
 }
 
 // EncodeContents encodes EAN-8 contents into a boolean pattern.
@@ -0,0 +1,213 @@
+package oned
+
+import (
+	"sort"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+)
+
+// FourState is the state of a bar in a 4-state postal barcode, which
+// encodes data in bar heights rather than widths. Every bar crosses the
+// central tracker band; an ascender extends it upwards and a descender
+// downwards.
+type FourState int
+
+// The four bar states. Full is Ascender|Descender.
+const (
+	FourStateTracker   FourState = 0
+	FourStateAscender  FourState = 1
+	FourStateDescender FourState = 2
+	FourStateFull      FourState = 3
+)
+
+// String returns the conventional letter for the state: T, A, D or F.
+func (s FourState) String() string {
+	return string("TADF"[s])
+}
+
+// FourStateRow is a row of 4-state bars located in an image.
+type FourStateRow struct {
+	// Bars are the bar states from left to right.
+	Bars []FourState
+
+	// Start and End are the centres of the first and last bars on the
+	// tracker band.
+	Start, End zxinggo.ResultPoint
+}
+
+// Rotated returns the bars as they would read with the image turned 180
+// degrees: in reverse order with ascenders and descenders swapped.
+func (r *FourStateRow) Rotated() []FourState {
+	bars := make([]FourState, len(r.Bars))
+	for i, s := range r.Bars {
+		bars[len(bars)-1-i] = s&FourStateAscender<<1 | s&FourStateDescender>>1
+	}
+	return bars
+}
+
+// ParseFourState parses bar states written as the letters T, A, D and F.
+func ParseFourState(s string) ([]FourState, bool) {
+	bars := make([]FourState, len(s))
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case 'T':
+			bars[i] = FourStateTracker
+		case 'A':
+			bars[i] = FourStateAscender
+		case 'D':
+			bars[i] = FourStateDescender
+		case 'F':
+			bars[i] = FourStateFull
+		default:
+			return nil, false
+		}
+	}
+	return bars, true
+}
+
+// FindFourStateBars locates the longest row of at least minBars evenly
+// spaced bars of similar width in the image and classifies each bar by how
+// far it extends above and below the band they all cross. Unlike the width
+// based readers, which work on single rows, it needs the whole image to see
+// the bar heights.
+func FindFourStateBars(image *bitutil.BitMatrix, minBars int) (*FourStateRow, error) {
+	// Every row through the tracker band crosses all the bars; rows above
+	// or below it miss some. Find the rows with the most bars and take the
+	// middle one.
+	width, height := image.Width(), image.Height()
+	row := bitutil.NewBitArray(width)
+	var bestRuns [][2]int
+	var bestRows []int
+	for y := 0; y < height; y++ {
+		row = image.Row(y, row)
+		runs := longestBarSequence(row)
+		switch {
+		case len(runs) < minBars || len(runs) < len(bestRuns):
+		case len(runs) > len(bestRuns):
+			bestRuns, bestRows = runs, []int{y}
+		default:
+			bestRows = append(bestRows, y)
+		}
+	}
+	if bestRuns == nil {
+		return nil, zxinggo.ErrNotFound
+	}
+	y := bestRows[len(bestRows)/2]
+	runs := longestBarSequence(image.Row(y, row))
+	if len(runs) != len(bestRuns) {
+		return nil, zxinggo.ErrNotFound
+	}
+
+	// Measure each bar up and down its centre from the band.
+	tops := make([]int, len(runs))
+	bottoms := make([]int, len(runs))
+	for i, run := range runs {
+		x := (run[0] + run[1]) / 2
+		top, bottom := y, y
+		for top > 0 && image.Get(x, top-1) {
+			top--
+		}
+		for bottom < height-1 && image.Get(x, bottom+1) {
+			bottom++
+		}
+		tops[i], bottoms[i] = top, bottom
+	}
+	ascenders, ok := splitHeights(tops, true)
+	if !ok {
+		return nil, zxinggo.ErrNotFound
+	}
+	descenders, ok := splitHeights(bottoms, false)
+	if !ok {
+		return nil, zxinggo.ErrNotFound
+	}
+
+	bars := make([]FourState, len(runs))
+	for i := range bars {
+		if ascenders[i] {
+			bars[i] |= FourStateAscender
+		}
+		if descenders[i] {
+			bars[i] |= FourStateDescender
+		}
+	}
+	first, last := runs[0], runs[len(runs)-1]
+	return &FourStateRow{
+		Bars:  bars,
+		Start: zxinggo.ResultPoint{X: float64(first[0]+first[1]) / 2, Y: float64(y)},
+		End:   zxinggo.ResultPoint{X: float64(last[0]+last[1]) / 2, Y: float64(y)},
+	}, nil
+}
+
+// longestBarSequence returns the longest sequence of dark runs in the row
+// that are of similar width and evenly spaced, as [start, end) pairs.
+func longestBarSequence(row *bitutil.BitArray) [][2]int {
+	var runs [][2]int
+	size := row.Size()
+	for x := row.GetNextSet(0); x < size; {
+		end := row.GetNextUnset(x)
+		runs = append(runs, [2]int{x, end})
+		x = row.GetNextSet(end)
+	}
+
+	var best [][2]int
+	for start := 0; start < len(runs); {
+		end := start + 1
+		for end < len(runs) && fitsSequence(runs[start:end], runs[end]) {
+			end++
+		}
+		if end-start > len(best) {
+			best = runs[start:end]
+		}
+		start = end
+	}
+	return best
+}
+
+// fitsSequence reports whether the next run continues a sequence of bars:
+// about as wide as the first bar, and about as far from the last bar as
+// the last two are from each other.
+func fitsSequence(sequence [][2]int, next [2]int) bool {
+	width := next[1] - next[0]
+	firstWidth := sequence[0][1] - sequence[0][0]
+	if 2*width < firstWidth || width > 2*firstWidth {
+		return false
+	}
+	last := sequence[len(sequence)-1]
+	pitch := next[0] - last[0]
+	if len(sequence) == 1 {
+		// The gap between bars is no wider than a few bars.
+		return next[0]-last[1] <= 4*firstWidth
+	}
+	previous := last[0] - sequence[len(sequence)-2][0]
+	return 4*pitch >= 3*previous && 3*pitch <= 4*previous
+}
+
+// splitHeights divides the bars into those whose ends reach beyond the
+// band and those that stop at it, given their ends on one side. The two
+// groups must be clearly apart.
+func splitHeights(ends []int, up bool) ([]bool, bool) {
+	sorted := append([]int(nil), ends...)
+	sort.Ints(sorted)
+	low, high := sorted[0], sorted[len(sorted)-1]
+
+	// Split at the widest gap between consecutive ends.
+	threshold, widest := 0, 0
+	for i := 1; i < len(sorted); i++ {
+		if gap := sorted[i] - sorted[i-1]; gap > widest {
+			threshold, widest = sorted[i], gap
+		}
+	}
+	if widest < 2 || 3*widest < high-low {
+		return nil, false
+	}
+	extends := make([]bool, len(ends))
+	for i, e := range ends {
+		if up {
+			extends[i] = e < threshold
+		} else {
+			extends[i] = e >= threshold
+		}
+	}
+	return extends, true
+}
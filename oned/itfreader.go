@@ -60,11 +60,12 @@ func NewITFReader() *ITFReader {
 
 // DecodeRow decodes an ITF barcode from a single row.
 func (r *ITFReader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
-	startRange, err := r.decodeStart(row)
+	checkQuietZones := zxinggo.ProfileOf(opts) != zxinggo.ProfilePermissive
+	startRange, err := r.decodeStart(row, checkQuietZones)
 	if err != nil {
 		return nil, err
 	}
-	endRange, err := r.decodeEnd(row)
+	endRange, err := r.decodeEnd(row, checkQuietZones)
 	if err != nil {
 		return nil, err
 	}
@@ -146,7 +147,7 @@ func (r *ITFReader) decodeMiddle(row *bitutil.BitArray, payloadStart, payloadEnd
 	return nil
 }
 
-func (r *ITFReader) decodeStart(row *bitutil.BitArray) ([2]int, error) {
+func (r *ITFReader) decodeStart(row *bitutil.BitArray, checkQuietZone bool) ([2]int, error) {
 	endStart, err := skipWhiteSpace(row)
 	if err != nil {
 		return [2]int{}, err
@@ -159,16 +160,17 @@ func (r *ITFReader) decodeStart(row *bitutil.BitArray) ([2]int, error) {
 
 	r.narrowLineWidth = (startRange[1] - startRange[0]) / 4
 
-	err = r.validateQuietZone(row, startRange[0])
-	if err != nil {
-		return [2]int{}, err
+	if checkQuietZone {
+		if err := r.validateQuietZone(row, startRange[0]); err != nil {
+			return [2]int{}, err
+		}
 	}
 
 	return startRange, nil
 }
 
 func (r *ITFReader) validateQuietZone(row *bitutil.BitArray, startPattern int) error {
-	quietZoneSize := r.narrowLineWidth * 10
+	quietZoneSize := r.narrowLineWidth * zxinggo.DefaultQuietZone(zxinggo.FormatITF).Left
 	if quietZoneSize < 1 {
 		quietZoneSize = 1
 	}
@@ -182,7 +184,7 @@ func (r *ITFReader) validateQuietZone(row *bitutil.BitArray, startPattern int) e
 	return nil
 }
 
-func (r *ITFReader) decodeEnd(row *bitutil.BitArray) ([2]int, error) {
+func (r *ITFReader) decodeEnd(row *bitutil.BitArray, checkQuietZone bool) ([2]int, error) {
 	// For end pattern, we scan from the end backwards.
 	row.Reverse()
 	defer row.Reverse()
@@ -201,8 +203,10 @@ func (r *ITFReader) decodeEnd(row *bitutil.BitArray) ([2]int, error) {
 		}
 	}
 
-	if err := r.validateQuietZone(row, endRange[0]); err != nil {
-		return [2]int{}, err
+	if checkQuietZone {
+		if err := r.validateQuietZone(row, endRange[0]); err != nil {
+			return [2]int{}, err
+		}
 	}
 
 	// Now un-reverse the coordinates
@@ -2,11 +2,20 @@ package oned
 
 import (
 	"fmt"
+	"math"
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
 )
 
+const (
+	// itfDefaultRatio is the width of a wide element in narrow ones.
+	itfDefaultRatio = 3.0
+	// itfBearerWidth is the thickness of bearer bars in narrow elements,
+	// about the 4.8 mm GS1 asks of ITF-14 at its usual 1 mm X-dimension.
+	itfBearerWidth = 5
+)
+
 // ITFWriter encodes ITF (Interleaved 2 of 5) barcodes.
 type ITFWriter struct{}
 
@@ -15,7 +24,9 @@ func NewITFWriter() *ITFWriter {
 	return &ITFWriter{}
 }
 
-// Encode encodes the given contents into an ITF barcode BitMatrix.
+// Encode encodes the given contents into an ITF barcode BitMatrix. Wide
+// elements are drawn opts.ITFWideToNarrowRatio times as wide as narrow ones,
+// rounded to whole pixels, and opts.ITFBearerBars frames the symbol.
 func (w *ITFWriter) Encode(contents string, format zxinggo.Format, width, height int, opts *zxinggo.EncodeOptions) (*bitutil.BitMatrix, error) {
 	if format != zxinggo.FormatITF {
 		return nil, fmt.Errorf("can only encode ITF, but got %s", format)
@@ -26,59 +37,112 @@ func (w *ITFWriter) Encode(contents string, format zxinggo.Format, width, height
 	if len(contents)%2 != 0 {
 		return nil, fmt.Errorf("ITF requires an even number of digits, got %d", len(contents))
 	}
-	code, err := w.encode(contents)
-	if err != nil {
-		return nil, err
+	ratio := itfDefaultRatio
+	bearers := zxinggo.BearerBarsNone
+	if opts != nil {
+		if opts.ITFWideToNarrowRatio != 0 {
+			ratio = opts.ITFWideToNarrowRatio
+		}
+		bearers = opts.ITFBearerBars
 	}
-	return RenderOneDCode(code, width, height), nil
+	if ratio < 2 || ratio > 3 {
+		return nil, fmt.Errorf("ITF wide to narrow ratio must be from 2 to 3, got %g", ratio)
+	}
+	if bearers < zxinggo.BearerBarsNone || bearers > zxinggo.BearerBarsBox {
+		return nil, fmt.Errorf("unknown bearer bars %d", bearers)
+	}
+	return renderITF(itfElements(contents), ratio, bearers, zxinggo.EncodeQuietZone(zxinggo.FormatITF, opts), width, height), nil
 }
 
+// encode returns the modules of the symbol with wide elements three modules
+// wide.
 func (w *ITFWriter) encode(contents string) ([]bool, error) {
-	length := len(contents)
-	// Each digit pair encodes to 5+5 = 10 bars/spaces with width 1 or 3.
-	// Start pattern: 1+1+1+1 = 4. End pattern: 3+1+1 = 5 (wide-narrow-narrow).
-	// A digit pair has total width: sum of (narrow/wide) for bars + sum for spaces.
-	// Each pair: 5 narrow/wide bars interleaved with 5 narrow/wide spaces.
-	// Width = sum of widths for both digits.
-	digitPairWidth := 0
-	for _, w := range itfPatterns[0] {
-		digitPairWidth += w
-	}
-	digitPairWidth *= 2 // bars + spaces (worst case for sizing doesn't matter, we compute exactly)
-
-	// Actually compute total width precisely:
-	// Start: 4 (1+1+1+1), End: 5 (3+1+1)
-	// Each pair: for each of 5 positions, bar width from digit1 + space width from digit2
-	totalWidth := 4 + 5 // start + end
-	for i := 0; i < length; i += 2 {
-		d1 := contents[i] - '0'
-		d2 := contents[i+1] - '0'
-		for j := 0; j < 5; j++ {
-			totalWidth += itfPatterns[d1][j] + itfPatterns[d2][j]
+	elements := itfElements(contents)
+	widths := make([]int, len(elements))
+	totalWidth := 0
+	for i, wide := range elements {
+		widths[i] = 1
+		if wide {
+			widths[i] = 3
 		}
+		totalWidth += widths[i]
 	}
-
 	result := make([]bool, totalWidth)
-	pos := 0
+	AppendPattern(result, 0, widths, true)
+	return result, nil
+}
 
+// itfElements returns the bars and spaces of the symbol for contents, an
+// even number of digits, in order from the first bar; true marks a wide one.
+func itfElements(contents string) []bool {
 	// Start pattern: narrow bar, narrow space, narrow bar, narrow space
-	startPattern := []int{1, 1, 1, 1}
-	pos += AppendPattern(result, pos, startPattern, true)
-
-	for i := 0; i < length; i += 2 {
+	elements := []bool{false, false, false, false}
+	for i := 0; i < len(contents); i += 2 {
 		d1 := contents[i] - '0'
 		d2 := contents[i+1] - '0'
-		encoding := make([]int, 10)
+		// The first digit of a pair is in the bars, the second in the spaces.
 		for j := 0; j < 5; j++ {
-			encoding[2*j] = itfPatterns[d1][j]
-			encoding[2*j+1] = itfPatterns[d2][j]
+			elements = append(elements, itfPatterns[d1][j] > 1, itfPatterns[d2][j] > 1)
 		}
-		pos += AppendPattern(result, pos, encoding, true)
 	}
-
 	// End pattern: wide bar, narrow space, narrow bar
-	endPattern := []int{3, 1, 1}
-	AppendPattern(result, pos, endPattern, true)
+	return append(elements, true, false, false)
+}
 
-	return result, nil
+// renderITF draws elements with narrow elements a whole number of pixels
+// wide, as many as fit the width, and wide ones ratio times as wide, with
+// the quiet zones of zone in narrow elements.
+func renderITF(elements []bool, ratio float64, bearers zxinggo.BearerBars, zone zxinggo.QuietZone, width, height int) *bitutil.BitMatrix {
+	narrowCount, wideCount := 0, 0
+	for _, wide := range elements {
+		if wide {
+			wideCount++
+		} else {
+			narrowCount++
+		}
+	}
+	// The full width in narrow elements, with quiet zones and any box.
+	units := float64(narrowCount) + ratio*float64(wideCount) + float64(zone.Left+zone.Right)
+	if bearers == zxinggo.BearerBarsBox {
+		units += 2 * itfBearerWidth
+	}
+	narrow := max(1, int(float64(width)/units))
+	wide := int(math.Round(ratio * float64(narrow)))
+	codeWidth := narrowCount*narrow + wideCount*wide
+	leftMargin, rightMargin := zone.Left*narrow, zone.Right*narrow
+	bearer := 0
+	if bearers != zxinggo.BearerBarsNone {
+		bearer = itfBearerWidth * narrow
+	}
+	fullWidth := codeWidth + leftMargin + rightMargin
+	if bearers == zxinggo.BearerBarsBox {
+		fullWidth += 2 * bearer
+	}
+	width = max(width, fullWidth)
+	height = max(height, 2*bearer+1)
+
+	output := bitutil.NewBitMatrixWithSize(width, height)
+	left := (width - fullWidth) / 2
+	if bearer > 0 {
+		output.SetRegion(left, 0, fullWidth, bearer)
+		output.SetRegion(left, height-bearer, fullWidth, bearer)
+	}
+	if bearers == zxinggo.BearerBarsBox {
+		output.SetRegion(left, 0, bearer, height)
+		output.SetRegion(left+fullWidth-bearer, 0, bearer, height)
+		left += bearer
+	}
+	x := left + leftMargin
+	for i, isWide := range elements {
+		w := narrow
+		if isWide {
+			w = wide
+		}
+		// Even elements are bars.
+		if i%2 == 0 {
+			output.SetRegion(x, bearer, w, height-2*bearer)
+		}
+		x += w
+	}
+	return output
 }
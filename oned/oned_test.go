@@ -1,10 +1,20 @@
 package oned
 
 import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/png"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
 
 	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
 	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/imaging"
 )
 
 // roundTrip1D encodes a barcode, then decodes the resulting BitMatrix row by row.
@@ -104,6 +114,57 @@ func TestCode128RoundTrip(t *testing.T) {
 	}
 }
 
+func TestCode128GS1(t *testing.T) {
+	tests := []struct {
+		elementString string
+		want          string
+		modules       int
+	}{
+		// Start C, FNC1, eight digit pairs, check character and stop.
+		{"(01)09506000134352", "]C1" + "0109506000134352", 11*11 + 13},
+		{"(10)ABC123(17)250101", "]C1" + "10ABC123\x1d17250101", 0},
+		{"0109506000134352\x1d10ABC", "]C1" + "010950600013435210ABC", 0},
+	}
+	reader := NewCode128Reader()
+	for _, tt := range tests {
+		contents, err := gs1Code128Contents(tt.elementString)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.elementString, err)
+		}
+		code, err := encodeCode128Fast(contents, -1)
+		if err != nil {
+			t.Fatalf("%q: encode error: %v", tt.elementString, err)
+		}
+		if tt.modules != 0 && len(code) != tt.modules {
+			t.Errorf("%q: %d modules, want %d", tt.elementString, len(code), tt.modules)
+		}
+		result, err := reader.DecodeRow(0, paddedRow(code, 10), &zxinggo.DecodeOptions{AssumeGS1: true})
+		if err != nil {
+			t.Fatalf("%q: decode error: %v", tt.elementString, err)
+		}
+		if result.Text != tt.want {
+			t.Errorf("%q: decoded %q, want %q", tt.elementString, result.Text, tt.want)
+		}
+	}
+
+	opts := &zxinggo.EncodeOptions{GS1Format: true}
+	errorTests := []struct {
+		elementString string
+		want          error
+	}{
+		{"(01)09506000134353", zxinggo.ErrChecksum},
+		{"(01)0950600013435", zxinggo.ErrFormat},
+		{"(99)", zxinggo.ErrFormat},
+		{"(3)123", zxinggo.ErrFormat},
+	}
+	for _, tt := range errorTests {
+		_, err := NewCode128Writer().Encode(tt.elementString, zxinggo.FormatCode128, 200, 50, opts)
+		if !errors.Is(err, zxinggo.ErrWriter) || !errors.Is(err, tt.want) {
+			t.Errorf("%q: error %v, want ErrWriter and %v", tt.elementString, err, tt.want)
+		}
+	}
+}
+
 // --- EAN-13 ---
 
 func TestEAN13RoundTrip(t *testing.T) {
@@ -209,6 +270,68 @@ func TestUPCARoundTrip(t *testing.T) {
 	}
 }
 
+// TestUPCAFromEAN13 checks that every path reading an EAN-13 symbol with a
+// leading zero reports it as UPC-A in the same cases, and as EAN-13 with
+// ReportUPCAAsEAN13.
+func TestUPCAFromEAN13(t *testing.T) {
+	code, err := NewEAN13Writer().EncodeContents("0012345678905")
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	row := paddedRow(code, 10)
+	upca := &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{zxinggo.FormatUPCA}}
+	both := &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{zxinggo.FormatEAN13, zxinggo.FormatUPCA}}
+	ean13 := &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{zxinggo.FormatEAN13}}
+	retail := &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{zxinggo.FormatAnyRetail}}
+	tests := []struct {
+		name   string
+		reader RowDecoder
+		opts   *zxinggo.DecodeOptions
+		upca   bool
+	}{
+		{"EAN-13 reader", NewEAN13Reader(), nil, false},
+		{"UPC-A reader", NewUPCAReader(), nil, true},
+		{"multi-format, any format", NewMultiFormatOneDReader(nil), nil, true},
+		{"multi-format, UPC-A", NewMultiFormatOneDReader(upca), upca, true},
+		{"multi-format, EAN-13 and UPC-A", NewMultiFormatOneDReader(both), both, true},
+		{"multi-format, retail family", NewMultiFormatOneDReader(retail), retail, true},
+		{"multi-format, EAN-13", NewMultiFormatOneDReader(ean13), ean13, false},
+		{"chosen decoders, any format", NewOneDReader(nil, NewEAN13Reader()), nil, true},
+		{"chosen decoders, EAN-13", NewOneDReader(ean13, NewEAN13Reader()), ean13, false},
+	}
+	for _, tt := range tests {
+		for _, keep := range []bool{false, true} {
+			var opts zxinggo.DecodeOptions
+			if tt.opts != nil {
+				opts = *tt.opts
+			}
+			opts.ReportUPCAAsEAN13 = keep
+			result, err := tt.reader.DecodeRow(0, row, &opts)
+			if err != nil {
+				t.Errorf("%s, ReportUPCAAsEAN13 %v: %v", tt.name, keep, err)
+				continue
+			}
+			want, wantFormat := "0012345678905", zxinggo.FormatEAN13
+			if tt.upca && !keep {
+				want, wantFormat = "012345678905", zxinggo.FormatUPCA
+			}
+			if result.Text != want || result.Format != wantFormat {
+				t.Errorf("%s, ReportUPCAAsEAN13 %v: read %s %q, want %s %q",
+					tt.name, keep, result.Format, result.Text, wantFormat, want)
+			}
+		}
+	}
+
+	// An EAN-13 symbol not starting with zero is not a UPC-A one.
+	code, err = NewEAN13Writer().EncodeContents("5901234123457")
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	if result, err := NewUPCAReader().DecodeRow(0, paddedRow(code, 10), nil); err == nil {
+		t.Errorf("UPC-A reader read %s %q", result.Format, result.Text)
+	}
+}
+
 // --- UPC-E ---
 
 func TestUPCERoundTrip(t *testing.T) {
@@ -318,6 +441,68 @@ func TestITFOddLengthRejected(t *testing.T) {
 	}
 }
 
+func TestITFBearerBarsAndRatio(t *testing.T) {
+	tests := []struct {
+		bearers zxinggo.BearerBars
+		ratio   float64
+	}{
+		{zxinggo.BearerBarsNone, 0},
+		{zxinggo.BearerBarsTopBottom, 2.5},
+		{zxinggo.BearerBarsBox, 2},
+		{zxinggo.BearerBarsBox, 3},
+	}
+	for _, tt := range tests {
+		opts := &zxinggo.EncodeOptions{ITFBearerBars: tt.bearers, ITFWideToNarrowRatio: tt.ratio}
+		matrix, err := NewITFWriter().Encode("15400141288763", zxinggo.FormatITF, 600, 120, opts)
+		if err != nil {
+			t.Fatalf("bearers %d ratio %g: encode error: %v", tt.bearers, tt.ratio, err)
+		}
+		width, height := matrix.Width(), matrix.Height()
+		// The top row is solid across the middle under any bearer bar, and
+		// the side of a box reaches the middle row.
+		if got := matrix.Get(width/2, 0); got != (tt.bearers != zxinggo.BearerBarsNone) {
+			t.Errorf("bearers %d: top bearer drawn %v", tt.bearers, got)
+		}
+		left := 0
+		for !matrix.Get(left, height/2) {
+			left++
+		}
+		dark := left
+		for matrix.Get(dark, height/2) {
+			dark++
+		}
+		light := dark
+		for !matrix.Get(light, height/2) {
+			light++
+		}
+		// A box side is followed by the quiet zone, twice as wide as it; the
+		// first bar of the start pattern by a space as narrow as itself.
+		if boxed := light-dark >= 2*(dark-left); boxed != (tt.bearers == zxinggo.BearerBarsBox) {
+			t.Errorf("bearers %d: middle row starts with %d dark and %d light pixels", tt.bearers, dark-left, light-dark)
+		}
+		row := matrix.Row(height/2, nil)
+		if tt.bearers == zxinggo.BearerBarsBox {
+			// A scanner reads between the sides of the box.
+			row = bitutil.NewBitArray(width)
+			for x := left + 5; x < width-left-5; x++ {
+				if matrix.Get(x, height/2) {
+					row.Set(x)
+				}
+			}
+		}
+		result, err := NewITFReader().DecodeRow(0, row, nil)
+		if err != nil {
+			t.Fatalf("bearers %d ratio %g: decode error: %v", tt.bearers, tt.ratio, err)
+		}
+		if result.Text != "15400141288763" {
+			t.Errorf("bearers %d ratio %g: decoded %q", tt.bearers, tt.ratio, result.Text)
+		}
+	}
+	if _, err := NewITFWriter().Encode("1234", zxinggo.FormatITF, 200, 50, &zxinggo.EncodeOptions{ITFWideToNarrowRatio: 1.5}); err == nil {
+		t.Error("expected error for a wide to narrow ratio of 1.5")
+	}
+}
+
 // --- Codabar ---
 
 func TestCodabarRoundTrip(t *testing.T) {
@@ -391,6 +576,36 @@ func TestMultiFormatOneDReaderCode39(t *testing.T) {
 	}
 }
 
+func TestOneDReaderDecoders(t *testing.T) {
+	code, err := NewITFWriter().encode("123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := bitutil.NewBitArray(len(code) + 20)
+	for i, b := range code {
+		if b {
+			row.Set(10 + i)
+		}
+	}
+
+	if _, err := NewOneDReader(nil, NewCode128Reader()).DecodeRow(0, row, nil); err == nil {
+		t.Error("Code 128 only reader decoded ITF")
+	}
+	// The reader's options apply when DecodeRow is given none.
+	opts := &zxinggo.DecodeOptions{AllowedLengths: []int{8}}
+	reader := NewOneDReader(opts, NewCode128Reader(), NewITFReader())
+	if _, err := reader.DecodeRow(0, row, nil); err == nil {
+		t.Error("decoded a length the reader's options exclude")
+	}
+	result, err := reader.DecodeRow(0, row, &zxinggo.DecodeOptions{})
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if result.Text != "123456" {
+		t.Errorf("got %q, want %q", result.Text, "123456")
+	}
+}
+
 // --- RSS utilities ---
 
 func TestCombins(t *testing.T) {
@@ -479,3 +694,686 @@ func TestRSSIsFinderPattern(t *testing.T) {
 	_ = rssIsFinderPattern([]int{10, 10, 10, 10})
 	_ = rssIsFinderPattern([]int{1, 1, 1, 1})
 }
+
+func TestRSSExpandedRowSearchBudget(t *testing.T) {
+	// Single-pair rows whose finder patterns combine into hundreds of
+	// thousands of valid partial sequences, none passing the checksum.
+	r := NewRSSExpandedReader()
+	for i, v := range []int{0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5} {
+		pair := expandedPair{
+			leftChar:      &rssDataCharacter{value: -1},
+			rightChar:     &rssDataCharacter{value: i, checksumPortion: i},
+			finderPattern: rssFinderPattern{value: v},
+		}
+		r.rows = append(r.rows, expandedRow{pairs: []expandedPair{pair}, rowNumber: i})
+	}
+	if pairs := r.checkRows(false); pairs != nil {
+		t.Fatalf("found %d pairs", len(pairs))
+	}
+	if !r.rowSearch.Exhausted() {
+		t.Error("row search finished within its budget")
+	}
+}
+
+// --- Code 11 ---
+
+// encodeCode11 renders start, contents (which must include any check
+// digits), and stop with a 2:5 narrow-to-wide ratio.
+func encodeCode11(contents string) ([]bool, error) {
+	var code []bool
+	appendChar := func(pattern int) {
+		for i := 4; i >= 0; i-- {
+			width := 2
+			if pattern&(1<<uint(i)) != 0 {
+				width = 5
+			}
+			for j := 0; j < width; j++ {
+				code = append(code, i%2 == 0)
+			}
+		}
+		code = append(code, false, false) // intercharacter gap
+	}
+	appendChar(code11StartStopEncoding)
+	for i := 0; i < len(contents); i++ {
+		appendChar(code11CharacterEncodings[strings.IndexByte(code11AlphabetString, contents[i])])
+	}
+	appendChar(code11StartStopEncoding)
+	return code[:len(code)-2], nil
+}
+
+func TestCode11RoundTrip(t *testing.T) {
+	reader := NewCode11Reader()
+	for _, tc := range []struct{ data, checks string }{
+		{"123-45", "5"},
+		{"0123456789", "03"},
+	} {
+		if c := code11CheckDigit(tc.data, 10); c != tc.checks[0] {
+			t.Errorf("C check digit of %q = %c, want %c", tc.data, c, tc.checks[0])
+		}
+		code, _ := encodeCode11(tc.data + tc.checks)
+		roundTrip1D(t, tc.data, zxinggo.FormatCode11, func(string) ([]bool, error) { return code, nil }, reader)
+	}
+}
+
+func TestCode11BadCheckDigit(t *testing.T) {
+	code, _ := encodeCode11("123-454")
+	row := bitutil.NewBitArray(len(code) + 20)
+	for i, b := range code {
+		if b {
+			row.Set(i + 10)
+		}
+	}
+	if _, err := NewCode11Reader().DecodeRow(0, row, nil); err != zxinggo.ErrChecksum {
+		t.Errorf("got %v, want ErrChecksum", err)
+	}
+}
+
+// --- Telepen ---
+
+// encodeTelepen renders contents with its check character between the start
+// and stop characters, two pixels per module.
+func encodeTelepen(contents string) ([]bool, error) {
+	sum := 0
+	for i := 0; i < len(contents); i++ {
+		sum += int(contents[i])
+	}
+	full := string(telepenStartChar) + contents + string(rune((127-sum%127)%127)) + string(telepenStopChar)
+	var code []bool
+	for i := 0; i < len(full); i++ {
+		pattern := telepenPattern(full[i])
+		for m := telepenCharModules - 1; m >= 0; m-- {
+			bar := pattern&(1<<uint(m)) != 0
+			code = append(code, bar, bar)
+		}
+	}
+	return code, nil
+}
+
+func TestTelepenPatterns(t *testing.T) {
+	// The start character is ten narrow elements then a wide bar and wide
+	// space; the stop character is its mirror image.
+	if got, want := telepenPattern(telepenStartChar), uint16(0xAAB8); got != want {
+		t.Errorf("start pattern = %016b, want %016b", got, want)
+	}
+	if got, want := telepenPattern(telepenStopChar), uint16(0xE2AA); got != want {
+		t.Errorf("stop pattern = %016b, want %016b", got, want)
+	}
+	if len(telepenCharacters) != 128 {
+		t.Errorf("%d distinct patterns, want 128", len(telepenCharacters))
+	}
+}
+
+func TestTelepenRoundTrip(t *testing.T) {
+	reader := NewTelepenReader()
+	for _, contents := range []string{"Telepen", "ABC-123", "z_z"} {
+		roundTrip1D(t, contents, zxinggo.FormatTelepen, encodeTelepen, reader)
+	}
+}
+
+// encodeTwoOfFive builds a straight 2 of 5 symbol with one-module narrow and
+// three-module wide elements.
+func encodeTwoOfFive(variant twoOfFiveVariant) func(string) ([]bool, error) {
+	return func(contents string) ([]bool, error) {
+		var code []bool
+		bar := true
+		put := func(wide ...bool) {
+			for _, w := range wide {
+				n := 1
+				if w {
+					n = 3
+				}
+				for i := 0; i < n; i++ {
+					code = append(code, bar)
+				}
+				bar = !bar
+			}
+		}
+		const W, N = true, false
+		switch variant {
+		case matrix2of5:
+			put(W, N, N, N, N)
+		case industrial2of5:
+			put(W, N, W, N, N, N)
+		case iata2of5:
+			put(N, N, N, N)
+		}
+		for i := 0; i < len(contents); i++ {
+			enc := twoOfFiveEncodings[contents[i]-'0']
+			for k := 4; k >= 0; k-- {
+				wide := enc&(1<<uint(k)) != 0
+				if variant == matrix2of5 {
+					if k == 4 {
+						put(N)
+					}
+					put(wide)
+				} else {
+					put(wide, N)
+				}
+			}
+		}
+		switch variant {
+		case matrix2of5:
+			put(N, W, N, N, N, N)
+		case industrial2of5:
+			put(W, N, N, N, W)
+		case iata2of5:
+			put(W, N, N)
+		}
+		return code, nil
+	}
+}
+
+func TestTwoOfFiveRoundTrip(t *testing.T) {
+	tests := []struct {
+		variant twoOfFiveVariant
+		format  zxinggo.Format
+		reader  RowDecoder
+	}{
+		{matrix2of5, zxinggo.FormatMatrix2of5, NewMatrix2of5Reader(false)},
+		{industrial2of5, zxinggo.FormatIndustrial2of5, NewIndustrial2of5Reader(false)},
+		{iata2of5, zxinggo.FormatIATA2of5, NewIATA2of5Reader(false)},
+	}
+	for _, tt := range tests {
+		for _, contents := range []string{"123", "0123456789", "98765432100"} {
+			roundTrip1D(t, contents, tt.format, encodeTwoOfFive(tt.variant), tt.reader)
+		}
+	}
+}
+
+func TestTwoOfFiveCheckDigit(t *testing.T) {
+	encode := encodeTwoOfFive(industrial2of5)
+	decode := func(contents string) (*zxinggo.Result, error) {
+		code, _ := encode(contents)
+		row := bitutil.NewBitArray(len(code) + 20)
+		for i, b := range code {
+			if b {
+				row.Set(i + 10)
+			}
+		}
+		return NewIndustrial2of5Reader(true).DecodeRow(0, row, nil)
+	}
+
+	// The check digit of 1234567 is 0.
+	result, err := decode("12345670")
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if result.Text != "1234567" {
+		t.Errorf("text = %q, want %q", result.Text, "1234567")
+	}
+	if _, err := decode("12345671"); !errors.Is(err, zxinggo.ErrChecksum) {
+		t.Errorf("bad check digit: err = %v, want ErrChecksum", err)
+	}
+}
+
+// stackedHalves loads a two-row RSS Expanded Stacked symbol and returns two
+// frames, showing only its top and only its bottom half.
+func stackedHalves(t *testing.T) (top, bottom *zxinggo.BinaryBitmap) {
+	t.Helper()
+	f, err := os.Open("../testdata/blackbox/rssexpandedstacked-1/1.png")
+	if err != nil {
+		t.Skipf("test image not found: %v", err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := img.Bounds()
+	half := func(blank image.Rectangle) *zxinggo.BinaryBitmap {
+		m := image.NewRGBA(b)
+		draw.Draw(m, b, img, b.Min, draw.Src)
+		draw.Draw(m, blank, image.NewUniform(color.White), image.Point{}, draw.Src)
+		return zxinggo.NewBinaryBitmap(binarizer.NewHybrid(zxinggo.NewImageLuminanceSource(m)))
+	}
+	middle := b.Min.Y + b.Dy()/2
+	top = half(image.Rect(b.Min.X, middle, b.Max.X, b.Max.Y))
+	bottom = half(image.Rect(b.Min.X, b.Min.Y, b.Max.X, middle))
+	return top, bottom
+}
+
+func TestRSSExpandedDecodeFrame(t *testing.T) {
+	top, bottom := stackedHalves(t)
+	r := NewRSSExpandedReader()
+	if _, err := r.DecodeFrame(top, nil); err == nil {
+		t.Fatal("decoded the top half alone")
+	}
+	result, err := r.DecodeFrame(bottom, nil)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if want := "(01)90012345678908(3103)012233(15)991231"; result.Text != want {
+		t.Errorf("text = %q, want %q", result.Text, want)
+	}
+
+	r.Reset()
+	if _, err := r.DecodeFrame(bottom, nil); err == nil {
+		t.Error("decoded the bottom half after Reset")
+	}
+}
+
+func TestRSSExpandedRowsDoNotLeak(t *testing.T) {
+	// Decode, and the multi-format reader, start afresh for each image.
+	top, bottom := stackedHalves(t)
+	r := NewRSSExpandedReader()
+	r.Decode(top, nil)
+	if _, err := r.Decode(bottom, nil); err == nil {
+		t.Error("RSSExpandedReader combined rows of two images")
+	}
+	opts := &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{zxinggo.FormatRSSExpanded}}
+	m := NewMultiFormatOneDReader(opts)
+	m.Decode(top, opts)
+	if _, err := m.Decode(bottom, opts); err == nil {
+		t.Error("MultiFormatOneDReader combined rows of two images")
+	}
+}
+
+// rss14Halves loads an RSS-14 symbol and returns two frames, showing only its
+// left and only its right pair of characters.
+func rss14Halves(t *testing.T) (left, right *zxinggo.BinaryBitmap) {
+	t.Helper()
+	f, err := os.Open("../testdata/blackbox/rss14-1/1.png")
+	if err != nil {
+		t.Skipf("test image not found: %v", err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := img.Bounds()
+	half := func(blank image.Rectangle) *zxinggo.BinaryBitmap {
+		m := image.NewRGBA(b)
+		draw.Draw(m, b, img, b.Min, draw.Src)
+		draw.Draw(m, blank, image.NewUniform(color.White), image.Point{}, draw.Src)
+		return zxinggo.NewBinaryBitmap(binarizer.NewHybrid(zxinggo.NewImageLuminanceSource(m)))
+	}
+	// Each half keeps the central data characters but loses the other
+	// half's finder pattern.
+	left = half(image.Rect(b.Min.X+b.Dx()*6/10, b.Min.Y, b.Max.X, b.Max.Y))
+	right = half(image.Rect(b.Min.X, b.Min.Y, b.Min.X+b.Dx()*4/10, b.Max.Y))
+	return left, right
+}
+
+func TestRSS14PairsDoNotLeak(t *testing.T) {
+	left, right := rss14Halves(t)
+	r := NewRSS14Reader()
+	if _, err := r.Decode(left, nil); err == nil {
+		t.Fatal("decoded the left half alone")
+	}
+	if _, err := r.Decode(right, nil); err == nil {
+		t.Error("RSS14Reader combined pairs of two images")
+	}
+	opts := &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{zxinggo.FormatRSS14}}
+	m := NewMultiFormatOneDReader(opts)
+	m.Decode(left, opts)
+	if _, err := m.Decode(right, opts); err == nil {
+		t.Error("MultiFormatOneDReader combined pairs of two images")
+	}
+}
+
+func TestRSSPointsTurned180(t *testing.T) {
+	for _, tc := range []struct {
+		path   string
+		reader func() RowDecoder
+	}{
+		{"../testdata/blackbox/rss14-1/1.png", func() RowDecoder { return NewRSS14Reader() }},
+		{"../testdata/blackbox/rssexpanded-1/1.png", func() RowDecoder { return NewRSSExpandedReader() }},
+		{"../testdata/blackbox/rssexpandedstacked-1/1.png", func() RowDecoder { return NewRSSExpandedReader() }},
+	} {
+		f, err := os.Open(tc.path)
+		if err != nil {
+			t.Skipf("test image not found: %v", err)
+		}
+		img, _, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decode := func(img image.Image) *zxinggo.Result {
+			bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(zxinggo.NewImageLuminanceSource(img)))
+			result, err := DecodeOneD(bitmap, tc.reader(), nil)
+			if err != nil {
+				t.Fatalf("%s: decode error: %v", tc.path, err)
+			}
+			return result
+		}
+		upright := decode(img)
+		turned := decode(imaging.Rotate180(img))
+		if turned.Text != upright.Text || turned.Metadata[zxinggo.MetadataOrientation] != 180 {
+			t.Errorf("%s: turned read %q at orientation %v", tc.path, turned.Text, turned.Metadata[zxinggo.MetadataOrientation])
+		}
+		if len(turned.Points) != 4 || len(upright.Points) != 4 {
+			t.Fatalf("%s: %d and %d points", tc.path, len(upright.Points), len(turned.Points))
+		}
+		// Each finder pattern end lies where the same end of the upright
+		// symbol lands when the image is turned.
+		w := float64(img.Bounds().Dx())
+		for i, p := range turned.Points {
+			if want := w - 1 - upright.Points[i].X; p.X < want-3 || p.X > want+3 {
+				t.Errorf("%s: point %d at x %v, want %v", tc.path, i, p.X, want)
+			}
+		}
+	}
+}
+
+// paddedRow builds a row holding code with quiet modules of white space on
+// either side.
+func paddedRow(code []bool, quiet int) *bitutil.BitArray {
+	row := bitutil.NewBitArray(len(code) + 2*quiet)
+	for i, b := range code {
+		if b {
+			row.Set(quiet + i)
+		}
+	}
+	return row
+}
+
+func TestProfileQuietZones(t *testing.T) {
+	code, err := NewEAN13Writer().EncodeContents("5901234123457")
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	tests := []struct {
+		quiet   int
+		profile zxinggo.Profile
+		ok      bool
+	}{
+		{9, zxinggo.ProfileStrict, true},
+		{8, zxinggo.ProfileStrict, false}, // EAN-13 calls for 9 on the left
+		{4, zxinggo.ProfileStrict, false},
+		{4, zxinggo.ProfileDefault, true},
+		{1, zxinggo.ProfileDefault, false},
+		{1, zxinggo.ProfilePermissive, true},
+	}
+	for _, tc := range tests {
+		opts := &zxinggo.DecodeOptions{Profile: tc.profile}
+		result, err := NewEAN13Reader().DecodeRow(0, paddedRow(code, tc.quiet), opts)
+		if tc.ok && (err != nil || result.Text != "5901234123457") {
+			t.Errorf("%v with %d module quiet zones: result %v, error %v", tc.profile, tc.quiet, result, err)
+		}
+		if !tc.ok && err == nil {
+			t.Errorf("%v with %d module quiet zones: decoded", tc.profile, tc.quiet)
+		}
+	}
+}
+
+func TestProfileStrictCode128QuietZone(t *testing.T) {
+	code, err := encodeCode128Fast("STRICT", -1)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	tests := []struct {
+		quiet   int
+		profile zxinggo.Profile
+		ok      bool
+	}{
+		{10, zxinggo.ProfileStrict, true},
+		{8, zxinggo.ProfileStrict, false},
+		{8, zxinggo.ProfileDefault, true},
+	}
+	for _, tc := range tests {
+		opts := &zxinggo.DecodeOptions{Profile: tc.profile}
+		result, err := NewCode128Reader().DecodeRow(0, paddedRow(code, tc.quiet), opts)
+		if tc.ok && (err != nil || result.Text != "STRICT") {
+			t.Errorf("%v with %d module quiet zones: result %v, error %v", tc.profile, tc.quiet, result, err)
+		}
+		if !tc.ok && err == nil {
+			t.Errorf("%v with %d module quiet zones: decoded", tc.profile, tc.quiet)
+		}
+	}
+}
+
+func TestProfileStrictLeavesCheckDigitsOptional(t *testing.T) {
+	strict := &zxinggo.DecodeOptions{Profile: zxinggo.ProfileStrict}
+
+	// Without a check digit, and with a last character that happens to be
+	// HELLO's check character: both read whole.
+	total := 0
+	for _, c := range "HELLO" {
+		total += strings.IndexRune(code39Alphabet, c)
+	}
+	for _, contents := range []string{"HELLO", "HELLO" + string(code39Alphabet[total%43])} {
+		code, err := NewCode39Writer().encode(contents)
+		if err != nil {
+			t.Fatalf("encode error: %v", err)
+		}
+		result, err := NewCode39Reader().DecodeRow(0, paddedRow(code, 10), strict)
+		if err != nil {
+			t.Fatalf("Code 39 %q: decode error: %v", contents, err)
+		}
+		if result.Text != contents {
+			t.Errorf("Code 39: got %q, want %q", result.Text, contents)
+		}
+	}
+
+	// The check digit of 1234567 is 0.
+	for _, contents := range []string{"1234567", "12345670"} {
+		code, _ := encodeTwoOfFive(industrial2of5)(contents)
+		result, err := NewIndustrial2of5Reader(false).DecodeRow(0, paddedRow(code, 10), strict)
+		if err != nil {
+			t.Fatalf("Industrial 2 of 5 %q: decode error: %v", contents, err)
+		}
+		if result.Text != contents {
+			t.Errorf("Industrial 2 of 5: got %q, want %q", result.Text, contents)
+		}
+	}
+}
+
+func TestCandidateRows(t *testing.T) {
+	// A band across the middle of the image, where the scan starts, reads as
+	// a different SKU, as a smudge might make it.
+	good, err := NewCode39Writer().encode("SKU-1001")
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	bad, err := NewCode39Writer().encode("SKU-1007")
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	const height = 100
+	img := image.NewGray(image.Rect(0, 0, 2*(len(good)+20), height))
+	for y := 0; y < height; y++ {
+		code := good
+		if y >= 48 && y <= 52 {
+			code = bad
+		}
+		row := paddedRow(code, 10)
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			c := uint8(255)
+			if row.Get(x / 2) {
+				c = 0
+			}
+			img.SetGray(x, y, color.Gray{Y: c})
+		}
+	}
+	bitmap := zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(zxinggo.NewGrayImageLuminanceSource(img)))
+
+	r := NewCode39Reader()
+	result, err := DecodeOneD(bitmap, r, nil)
+	if err != nil || result.Text != "SKU-1007" {
+		t.Fatalf("first row: result %v, error %v", result, err)
+	}
+	if _, ok := result.Metadata[zxinggo.MetadataCandidates]; ok {
+		t.Error("candidates reported without OneDCandidateRows")
+	}
+
+	result, err = DecodeOneD(bitmap, r, &zxinggo.DecodeOptions{OneDCandidateRows: 10})
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if result.Text != "SKU-1001" {
+		t.Errorf("text = %q, want SKU-1001", result.Text)
+	}
+	want := []zxinggo.Candidate{
+		{Format: zxinggo.FormatCode39, Text: "SKU-1001", Votes: 9},
+		{Format: zxinggo.FormatCode39, Text: "SKU-1007", Votes: 1},
+	}
+	if got := result.Metadata[zxinggo.MetadataCandidates]; !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeMultipleSideBySide(t *testing.T) {
+	// Two Code 128 symbols in one row, 12 modules apart.
+	encode := func(contents string) []bool {
+		margin := 0
+		m, err := NewCode128Writer().Encode(contents, zxinggo.FormatCode128, 0, 1, &zxinggo.EncodeOptions{Margin: &margin})
+		if err != nil {
+			t.Fatalf("encode error: %v", err)
+		}
+		code := make([]bool, m.Width())
+		for x := range code {
+			code[x] = m.Get(x, 0)
+		}
+		return code
+	}
+	first, second := encode("LOT-4711"), encode("SN 000123")
+	code := append(append(first, make([]bool, 12)...), second...)
+	const height = 40
+	row := paddedRow(code, 10)
+	img := image.NewGray(image.Rect(0, 0, 2*row.Size(), height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			c := uint8(255)
+			if row.Get(x / 2) {
+				c = 0
+			}
+			img.SetGray(x, y, color.Gray{Y: c})
+		}
+	}
+	bitmap := zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(zxinggo.NewGrayImageLuminanceSource(img)))
+
+	opts := &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{zxinggo.FormatCode128}}
+	results, err := NewMultiFormatOneDReader(opts).DecodeMultiple(bitmap, opts)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	var texts []string
+	for _, r := range results {
+		texts = append(texts, r.Text)
+	}
+	if want := []string{"LOT-4711", "SN 000123"}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("texts = %q, want %q", texts, want)
+	}
+	if len(results) == 2 && results[0].Points[1].X >= results[1].Points[0].X {
+		t.Errorf("points overlap: %v, %v", results[0].Points, results[1].Points)
+	}
+
+	opts.MaxResults = 1
+	results, err = NewMultiFormatOneDReader(opts).DecodeMultiple(bitmap, opts)
+	if err != nil {
+		t.Fatalf("decode error with MaxResults: %v", err)
+	}
+	if len(results) != 1 || results[0].Text != "LOT-4711" {
+		t.Errorf("MaxResults 1 read %d symbols", len(results))
+	}
+}
+
+// widenGaps returns code, characters of charWidth modules each followed by
+// a gap of oldGap, with the gaps between the first chars characters
+// widened to gap modules.
+func widenGaps(code []bool, charWidth, oldGap, chars, gap int) []bool {
+	var widened []bool
+	for i := 0; i < chars; i++ {
+		start := i * (charWidth + oldGap)
+		widened = append(widened, code[start:start+charWidth]...)
+		if i < chars-1 {
+			widened = append(widened, make([]bool, gap)...)
+		}
+	}
+	return append(widened, code[chars*(charWidth+oldGap)-oldGap:]...)
+}
+
+func TestInterCharacterGap(t *testing.T) {
+	code39, err := NewCode39Writer().encode("GAP39K")
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	code93, err := NewCode93Writer().encode("GAP93")
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	permissive := &zxinggo.DecodeOptions{Profile: zxinggo.ProfilePermissive}
+	strict := &zxinggo.DecodeOptions{Profile: zxinggo.ProfileStrict}
+	tests := []struct {
+		name   string
+		reader interface {
+			DecodeRow(int, *bitutil.BitArray, *zxinggo.DecodeOptions) (*zxinggo.Result, error)
+		}
+		code []bool
+		opts *zxinggo.DecodeOptions
+		want string
+	}{
+		// Code 39 characters are 12 modules and a narrow gap; the start,
+		// six characters and the stop make 8 characters.
+		{"Code 39 gaps of 8", NewCode39Reader(), widenGaps(code39, 12, 1, 8, 8), nil, "GAP39K"},
+		{"Code 39 gaps of 5 strict", NewCode39Reader(), widenGaps(code39, 12, 1, 8, 5), strict, "GAP39K"},
+		{"Code 39 gaps of 6 strict", NewCode39Reader(), widenGaps(code39, 12, 1, 8, 6), strict, ""},
+		{"Code 39 gaps of 3 at most 2", NewCode39Reader(), widenGaps(code39, 12, 1, 8, 3),
+			&zxinggo.DecodeOptions{MaxInterCharacterGap: 2}, ""},
+		// Code 93 characters are 9 modules and abut; the start, five
+		// characters, two check characters and the stop make 9.
+		{"Code 93", NewCode93Reader(), code93, nil, "GAP93"},
+		{"Code 93 permissive", NewCode93Reader(), code93, permissive, "GAP93"},
+		{"Code 93 gaps of 3", NewCode93Reader(), widenGaps(code93, 9, 0, 9, 3), nil, ""},
+		{"Code 93 gaps of 3 permissive", NewCode93Reader(), widenGaps(code93, 9, 0, 9, 3), permissive, "GAP93"},
+		{"Code 93 gaps of 6 permissive", NewCode93Reader(), widenGaps(code93, 9, 0, 9, 6), permissive, ""},
+		{"Code 93 gaps of 6 any", NewCode93Reader(), widenGaps(code93, 9, 0, 9, 6),
+			&zxinggo.DecodeOptions{MaxInterCharacterGap: -1}, "GAP93"},
+	}
+	for _, tt := range tests {
+		result, err := tt.reader.DecodeRow(0, paddedRow(tt.code, 20), tt.opts)
+		switch {
+		case tt.want == "" && err == nil:
+			t.Errorf("%s: read %q, want no read", tt.name, result.Text)
+		case tt.want != "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.want != "" && result.Text != tt.want:
+			t.Errorf("%s: read %q, want %q", tt.name, result.Text, tt.want)
+		}
+	}
+}
+
+// TestSymbolValues checks the symbol characters Code 128, Code 39 and Code 93
+// readers report, from the start character to the stop character.
+func TestSymbolValues(t *testing.T) {
+	contents, err := gs1Code128Contents("(10)AB")
+	if err != nil {
+		t.Fatalf("gs1Code128Contents: %v", err)
+	}
+	code128, err := encodeCode128Fast(contents, -1)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	code39, err := NewCode39Writer().encode("A1")
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	code93, err := NewCode93Writer().encode("A1")
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	tests := []struct {
+		name   string
+		reader RowDecoder
+		code   []bool
+		want   []int
+	}{
+		// Start C, FNC1, 10, Code B, A, B, check character and stop.
+		{"Code 128", NewCode128Reader(), code128, []int{105, 102, 10, 100, 33, 34, 5, 106}},
+		{"Code 39", NewCode39Reader(), code39, []int{43, 10, 1, 43}},
+		// L and 6 are the check characters C and K.
+		{"Code 93", NewCode93Reader(), code93, []int{47, 10, 1, 21, 6, 47}},
+	}
+	for _, tt := range tests {
+		result, err := tt.reader.DecodeRow(0, paddedRow(tt.code, 10), nil)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := result.Metadata[zxinggo.MetadataSymbolValues]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: values %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
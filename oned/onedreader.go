@@ -3,6 +3,7 @@ package oned
 
 import (
 	"math"
+	"slices"
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
@@ -14,9 +15,54 @@ type RowDecoder interface {
 	DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error)
 }
 
+// directionalDecoder is implemented by row decoders that combine what they
+// read of several rows, and so must be told which way each row is read to
+// keep their points in one frame.
+type directionalDecoder interface {
+	setReversed(reversed bool)
+}
+
 // DecodeOneD decodes a 1D barcode from an image by scanning rows from the
-// middle outward. It tries each row forward and reversed.
+// middle outward. It tries each row forward and reversed. It returns the
+// first row's result, or with opts.OneDCandidateRows, the result most rows
+// agree on; see tally.
 func DecodeOneD(image *zxinggo.BinaryBitmap, decoder RowDecoder, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	candidateRows := 0
+	if opts != nil {
+		candidateRows = opts.OneDCandidateRows
+	}
+	var first *zxinggo.Result
+	var votes tally
+	expired := scanRows(image, opts, func(rowNumber int, row *bitutil.BitArray) bool {
+		result, err := decodeRowBothWays(decoder, rowNumber, row, opts)
+		if err != nil {
+			return false
+		}
+		if candidateRows <= 0 {
+			first = result
+			return true
+		}
+		return votes.add(result) >= candidateRows
+	})
+	if first != nil {
+		return first, nil
+	}
+	if result := votes.winner(); result != nil {
+		return result, nil
+	}
+	if expired {
+		return nil, zxinggo.ErrDeadlineExceeded
+	}
+	return nil, zxinggo.ErrNotFound
+}
+
+// scanRows binarizes the rows of image in the order DecodeOneD scans them,
+// from the middle outward every 32nd of the height, or every 256th with
+// opts.TryHarder, and calls visit with each until it returns true. Without
+// TryHarder at most 15 rows are scanned. Each row scanned is recorded in
+// opts.Heatmap. scanRows reports whether it stopped because opts'
+// deadline passed.
+func scanRows(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions, visit func(rowNumber int, row *bitutil.BitArray) bool) bool {
 	width := image.Width()
 	height := image.Height()
 	row := bitutil.NewBitArray(width)
@@ -29,7 +75,6 @@ func DecodeOneD(image *zxinggo.BinaryBitmap, decoder RowDecoder, opts *zxinggo.D
 	if rowStep < 1 {
 		rowStep = 1
 	}
-
 	maxLines := 15
 	if tryHarder {
 		maxLines = height
@@ -48,38 +93,160 @@ func DecodeOneD(image *zxinggo.BinaryBitmap, decoder RowDecoder, opts *zxinggo.D
 		if rowNumber < 0 || rowNumber >= height {
 			break
 		}
+		if zxinggo.DeadlinePassed(opts) {
+			return true
+		}
 
 		var err error
 		row, err = image.BlackRow(rowNumber, row)
 		if err != nil {
 			continue
 		}
+		if opts != nil {
+			opts.Heatmap.ScanRow(rowNumber, 0, width)
+		}
+		if visit(rowNumber, row) {
+			break
+		}
+	}
+	return false
+}
 
-		for attempt := 0; attempt < 2; attempt++ {
-			if attempt == 1 {
-				row.Reverse()
+// decodeRowBothWays decodes row forward, or failing that reversed, in which
+// case the result is marked as turned 180 degrees and every one of its
+// points, such as the four finder pattern ends of RSS-14 and RSS Expanded
+// or those of a UPC/EAN extension, is mirrored back into the coordinates of
+// the row as given. row is left as it was.
+func decodeRowBothWays(decoder RowDecoder, rowNumber int, row *bitutil.BitArray, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	width := row.Size()
+	for attempt := 0; attempt < 2; attempt++ {
+		if attempt == 1 {
+			row.Reverse()
+		}
+		if d, ok := decoder.(directionalDecoder); ok {
+			d.setReversed(attempt == 1)
+		}
+		result, err := decoder.DecodeRow(rowNumber, row, opts)
+		if attempt == 1 {
+			row.Reverse()
+		}
+		if err != nil {
+			continue
+		}
+		if attempt == 1 {
+			result.PutMetadata(zxinggo.MetadataOrientation, 180)
+			for i, p := range result.Points {
+				result.Points[i] = zxinggo.ResultPoint{X: float64(width) - p.X - 1, Y: p.Y}
 			}
-			result, err := decoder.DecodeRow(rowNumber, row, opts)
-			if err != nil {
-				continue
+		}
+		return result, nil
+	}
+	return nil, zxinggo.ErrNotFound
+}
+
+// maxSymbolsPerRow bounds the symbols DecodeOneDMultiple reads from one row.
+const maxSymbolsPerRow = 8
+
+// DecodeOneDMultiple decodes every symbol in the first row of the image, in
+// the order DecodeOneD scans them, that holds any. After each symbol is read
+// its extent is blanked and the row decoded again, so symbols printed side
+// by side, even of the same format, are all returned, left to right as far
+// as they read forward, until opts.MaxResults or opts.StopOnFormats ends the
+// row.
+func DecodeOneDMultiple(image *zxinggo.BinaryBitmap, decoder RowDecoder, opts *zxinggo.DecodeOptions) ([]*zxinggo.Result, error) {
+	var results []*zxinggo.Result
+	expired := scanRows(image, opts, func(rowNumber int, row *bitutil.BitArray) bool {
+		results = decodeRowAll(decoder, rowNumber, row, opts)
+		return len(results) > 0
+	})
+	if len(results) > 0 {
+		return results, nil
+	}
+	if expired {
+		return nil, zxinggo.ErrDeadlineExceeded
+	}
+	return nil, zxinggo.ErrNotFound
+}
+
+// decodeRowAll decodes the symbols in row one at a time, clearing the span
+// between the result points of each before decoding the row again. A result
+// within a span already cleared, as readers that tally rows can return, ends
+// the search.
+func decodeRowAll(decoder RowDecoder, rowNumber int, row *bitutil.BitArray, opts *zxinggo.DecodeOptions) []*zxinggo.Result {
+	var results []*zxinggo.Result
+	var spans [][2]int
+	for len(results) < maxSymbolsPerRow {
+		result, err := decodeRowBothWays(decoder, rowNumber, row, opts)
+		if err != nil || len(result.Points) < 2 {
+			if err == nil {
+				results = append(results, result)
 			}
-			if attempt == 1 {
-				result.PutMetadata(zxinggo.MetadataOrientation, 180)
-				if result.Points != nil && len(result.Points) >= 2 {
-					result.Points[0] = zxinggo.ResultPoint{
-						X: float64(width) - result.Points[0].X - 1,
-						Y: result.Points[0].Y,
-					}
-					result.Points[1] = zxinggo.ResultPoint{
-						X: float64(width) - result.Points[1].X - 1,
-						Y: result.Points[1].Y,
-					}
-				}
+			break
+		}
+		lo, hi := result.Points[0].X, result.Points[0].X
+		for _, p := range result.Points[1:] {
+			lo, hi = math.Min(lo, p.X), math.Max(hi, p.X)
+		}
+		start := max(int(math.Floor(lo)), 0)
+		end := min(int(math.Ceil(hi)), row.Size()-1)
+		if slices.ContainsFunc(spans, func(s [2]int) bool { return start <= s[1] && end >= s[0] }) {
+			break
+		}
+		results = append(results, result)
+		if zxinggo.SearchDone(opts, len(results), result) {
+			break
+		}
+		spans = append(spans, [2]int{start, end})
+		for i := start; i <= end; i++ {
+			if row.Get(i) {
+				row.Flip(i)
 			}
-			return result, nil
 		}
 	}
-	return nil, zxinggo.ErrNotFound
+	return results
+}
+
+// tally counts the rows that read each text, for OneDCandidateRows.
+type tally struct {
+	candidates []zxinggo.Candidate
+	firsts     []*zxinggo.Result // the first result read for each candidate
+	rows       int
+}
+
+// add counts a row's result and returns the number of rows counted.
+func (t *tally) add(result *zxinggo.Result) int {
+	t.rows++
+	for i, c := range t.candidates {
+		if c.Format == result.Format && c.Text == result.Text {
+			t.candidates[i].Votes++
+			return t.rows
+		}
+	}
+	t.candidates = append(t.candidates, zxinggo.Candidate{Format: result.Format, Text: result.Text, Votes: 1})
+	t.firsts = append(t.firsts, result)
+	return t.rows
+}
+
+// winner returns the first result of the candidate with the most votes, the
+// earliest read of those tied, with the candidates in MetadataCandidates,
+// most votes first. It returns nil if no rows were counted.
+func (t *tally) winner() *zxinggo.Result {
+	if t.rows == 0 {
+		return nil
+	}
+	best := 0
+	for i, c := range t.candidates {
+		if c.Votes > t.candidates[best].Votes {
+			best = i
+		}
+	}
+	candidates := slices.Clone(t.candidates)
+	slices.SortStableFunc(candidates, func(a, b zxinggo.Candidate) int {
+		return b.Votes - a.Votes
+	})
+	result := t.firsts[best]
+	result.PutMetadata(zxinggo.MetadataCandidates, candidates)
+	return result
 }
 
 // RecordPattern records the widths of successive runs of black and white
@@ -3,6 +3,7 @@ package oned
 import (
 	"fmt"
 
+	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
 )
 
@@ -14,10 +15,18 @@ type OneDEncoder interface {
 
 const defaultOneDMargin = 10 // quiet zone in modules
 
-// RenderOneDCode renders a 1D barcode pattern as a BitMatrix with quiet zones.
+// RenderOneDCode renders a 1D barcode pattern as a BitMatrix with quiet zones
+// of defaultOneDMargin modules.
 func RenderOneDCode(code []bool, width, height int) *bitutil.BitMatrix {
+	return RenderOneDCodeQuietZone(code, width, height, zxinggo.QuietZone{Left: defaultOneDMargin, Right: defaultOneDMargin})
+}
+
+// RenderOneDCodeQuietZone renders a 1D barcode pattern as a BitMatrix with
+// the left and right quiet zones of zone, in modules. Width beyond what the
+// symbol and its quiet zones need is shared between the two sides.
+func RenderOneDCodeQuietZone(code []bool, width, height int, zone zxinggo.QuietZone) *bitutil.BitMatrix {
 	inputWidth := len(code)
-	fullWidth := inputWidth + 2*defaultOneDMargin
+	fullWidth := inputWidth + zone.Left + zone.Right
 	if width < fullWidth {
 		width = fullWidth
 	}
@@ -32,7 +41,7 @@ func RenderOneDCode(code []bool, width, height int) *bitutil.BitMatrix {
 	if multiple < 1 {
 		multiple = 1
 	}
-	leftPadding := (outputWidth - (inputWidth * multiple)) / 2
+	leftPadding := zone.Left*multiple + (outputWidth-fullWidth*multiple)/2
 
 	output := bitutil.NewBitMatrixWithSize(outputWidth, outputHeight)
 	for inputX := 0; inputX < inputWidth; inputX++ {
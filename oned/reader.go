@@ -8,8 +8,11 @@ import (
 // MultiFormatOneDReader attempts to decode 1D barcodes by trying multiple
 // format-specific readers in sequence.
 type MultiFormatOneDReader struct {
-	readers          []RowDecoder
-	possibleFormats  map[zxinggo.Format]bool
+	readers         []RowDecoder
+	possibleFormats map[zxinggo.Format]bool
+
+	// opts are used by Decode and DecodeRow when they are passed nil.
+	opts *zxinggo.DecodeOptions
 }
 
 // NewMultiFormatOneDReader creates a new multi-format reader configured by opts.
@@ -19,7 +22,7 @@ func NewMultiFormatOneDReader(opts *zxinggo.DecodeOptions) *MultiFormatOneDReade
 
 	if opts != nil && len(opts.PossibleFormats) > 0 {
 		possibleFormats = make(map[zxinggo.Format]bool)
-		for _, f := range opts.PossibleFormats {
+		for _, f := range zxinggo.ExpandFormats(opts.PossibleFormats) {
 			possibleFormats[f] = true
 		}
 		// UPC/EAN readers: match Java's MultiFormatUPCEANReader else-if logic.
@@ -57,6 +60,21 @@ func NewMultiFormatOneDReader(opts *zxinggo.DecodeOptions) *MultiFormatOneDReade
 		if possibleFormats[zxinggo.FormatRSSExpanded] {
 			readers = append(readers, NewRSSExpandedReader())
 		}
+		if possibleFormats[zxinggo.FormatCode11] {
+			readers = append(readers, NewCode11Reader())
+		}
+		if possibleFormats[zxinggo.FormatTelepen] {
+			readers = append(readers, NewTelepenReader())
+		}
+		if possibleFormats[zxinggo.FormatMatrix2of5] {
+			readers = append(readers, NewMatrix2of5Reader(opts.AssumeTwoOfFiveCheckDigit))
+		}
+		if possibleFormats[zxinggo.FormatIndustrial2of5] {
+			readers = append(readers, NewIndustrial2of5Reader(opts.AssumeTwoOfFiveCheckDigit))
+		}
+		if possibleFormats[zxinggo.FormatIATA2of5] {
+			readers = append(readers, NewIATA2of5Reader(opts.AssumeTwoOfFiveCheckDigit))
+		}
 	}
 
 	if len(readers) == 0 {
@@ -75,48 +93,80 @@ func NewMultiFormatOneDReader(opts *zxinggo.DecodeOptions) *MultiFormatOneDReade
 		}
 	}
 
-	return &MultiFormatOneDReader{readers: readers, possibleFormats: possibleFormats}
+	return &MultiFormatOneDReader{readers: readers, possibleFormats: possibleFormats, opts: opts}
+}
+
+// NewOneDReader creates a reader that tries exactly the given row decoders,
+// in order, for pipelines that need a particular set or configuration of
+// them, such as NewCode39ReaderWithCheckDigit(true, false). opts are used
+// whenever Decode is given nil options. As with NewMultiFormatOneDReader, an
+// EAN-13 result starting with zero is reported as UPC-A unless PossibleFormats
+// is set without FormatUPCA or ReportUPCAAsEAN13 is set.
+func NewOneDReader(opts *zxinggo.DecodeOptions, decoders ...RowDecoder) *MultiFormatOneDReader {
+	possibleFormats := make(map[zxinggo.Format]bool)
+	if opts != nil {
+		for _, f := range zxinggo.ExpandFormats(opts.PossibleFormats) {
+			possibleFormats[f] = true
+		}
+	}
+	return &MultiFormatOneDReader{readers: decoders, possibleFormats: possibleFormats, opts: opts}
 }
 
 // DecodeRow tries each reader in sequence until one succeeds.
-// Includes Java-compatible EAN-13 → UPC-A conversion when UPC-A was requested.
+// Includes Java-compatible EAN-13 → UPC-A conversion when UPC-A was
+// requested, or no formats were, unless opts.ReportUPCAAsEAN13 is set.
 func (r *MultiFormatOneDReader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	if opts == nil {
+		opts = r.opts
+	}
 	for _, reader := range r.readers {
 		result, err := reader.DecodeRow(rowNumber, row, opts)
 		if err == nil {
-			return r.maybeConvertEAN13ToUPCA(result), nil
+			return r.maybeConvertEAN13ToUPCA(result, opts), nil
 		}
 	}
 	return nil, zxinggo.ErrNotFound
 }
 
+// setReversed passes the direction of the row to the readers that need it.
+func (r *MultiFormatOneDReader) setReversed(reversed bool) {
+	for _, reader := range r.readers {
+		if d, ok := reader.(directionalDecoder); ok {
+			d.setReversed(reversed)
+		}
+	}
+}
+
 // maybeConvertEAN13ToUPCA converts an EAN-13 result starting with '0' to UPC-A
-// if UPC-A was requested. Matches Java MultiFormatUPCEANReader behavior.
-func (r *MultiFormatOneDReader) maybeConvertEAN13ToUPCA(result *zxinggo.Result) *zxinggo.Result {
-	if result.Format != zxinggo.FormatEAN13 || len(result.Text) == 0 || result.Text[0] != '0' {
+// if UPC-A was requested, or no formats were, as Java's
+// MultiFormatUPCEANReader does, unless opts asks for EAN-13.
+func (r *MultiFormatOneDReader) maybeConvertEAN13ToUPCA(result *zxinggo.Result, opts *zxinggo.DecodeOptions) *zxinggo.Result {
+	if !isUPCA(result) || opts != nil && opts.ReportUPCAAsEAN13 {
 		return result
 	}
-	// Convert if UPC-A was requested, or if no format filter was set (default readers)
-	if r.possibleFormats == nil || r.possibleFormats[zxinggo.FormatUPCA] {
-		upcaResult := zxinggo.NewResult(result.Text[1:], nil, result.Points, zxinggo.FormatUPCA)
-		for k, v := range result.Metadata {
-			upcaResult.PutMetadata(k, v)
-		}
-		return upcaResult
+	if len(r.possibleFormats) == 0 || r.possibleFormats[zxinggo.FormatUPCA] {
+		return upcaFromEAN13(result)
 	}
 	return result
 }
 
 // Decode decodes a 1D barcode from the given image.
 // Like Java's OneDReader.decode(), if TryHarder is set and the initial scan
-// fails, it tries again with the image rotated 90 degrees counterclockwise.
+// fails, it tries again with the image rotated 90 degrees counterclockwise,
+// unless opts disables DetectorOneDRotation.
+// Rows stored by stacked readers are not carried from one image, or
+// rotation, to the next.
 func (r *MultiFormatOneDReader) Decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	if opts == nil {
+		opts = r.opts
+	}
+	r.Reset()
 	result, err := DecodeOneD(image, r, opts)
 	if err == nil {
 		return result, nil
 	}
 	tryHarder := opts != nil && opts.TryHarder
-	if !tryHarder {
+	if !tryHarder || zxinggo.DetectorDisabled(opts, zxinggo.DetectorOneDRotation) || zxinggo.DeadlinePassed(opts) {
 		return nil, err
 	}
 	// Try with rotated image (90 degrees CCW)
@@ -124,10 +174,54 @@ func (r *MultiFormatOneDReader) Decode(image *zxinggo.BinaryBitmap, opts *zxingg
 	if rotated == nil {
 		return nil, err
 	}
-	result, err2 := DecodeOneD(rotated, r, opts)
+	// The heat map is in the coordinates of the unrotated image.
+	rotatedOpts := *opts
+	rotatedOpts.Heatmap = nil
+	r.Reset()
+	result, err2 := DecodeOneD(rotated, r, &rotatedOpts)
 	if err2 != nil {
 		return nil, err
 	}
+	unrotateResult(result, rotated.Height())
+	return result, nil
+}
+
+// DecodeMultiple decodes every symbol in one row of the image, including
+// several of the same format side by side; see DecodeOneDMultiple. With
+// TryHarder it falls back to the image rotated as Decode does.
+func (r *MultiFormatOneDReader) DecodeMultiple(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) ([]*zxinggo.Result, error) {
+	if opts == nil {
+		opts = r.opts
+	}
+	r.Reset()
+	results, err := DecodeOneDMultiple(image, r, opts)
+	if err == nil {
+		return results, nil
+	}
+	tryHarder := opts != nil && opts.TryHarder
+	if !tryHarder || zxinggo.DetectorDisabled(opts, zxinggo.DetectorOneDRotation) || zxinggo.DeadlinePassed(opts) {
+		return nil, err
+	}
+	rotated := image.RotateCounterClockwise()
+	if rotated == nil {
+		return nil, err
+	}
+	rotatedOpts := *opts
+	rotatedOpts.Heatmap = nil
+	r.Reset()
+	results, err2 := DecodeOneDMultiple(rotated, r, &rotatedOpts)
+	if err2 != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		unrotateResult(result, rotated.Height())
+	}
+	return results, nil
+}
+
+// unrotateResult maps a result read from an image rotated 90 degrees
+// counterclockwise, rotatedHeight high, back to the original image.
+func unrotateResult(result *zxinggo.Result, rotatedHeight int) {
 	// Record that we found it rotated 90 degrees CCW / 270 degrees CW
 	orientation := 270
 	if existing, ok := result.Metadata[zxinggo.MetadataOrientation]; ok {
@@ -138,17 +232,26 @@ func (r *MultiFormatOneDReader) Decode(image *zxinggo.BinaryBitmap, opts *zxingg
 	result.PutMetadata(zxinggo.MetadataOrientation, orientation)
 	// Adjust result points: for a CCW rotation, (x,y) in rotated image
 	// maps to (rotatedHeight - 1 - y, x) in the original image
-	if result.Points != nil {
-		rotatedHeight := rotated.Height()
-		for i, p := range result.Points {
-			result.Points[i] = zxinggo.ResultPoint{
-				X: float64(rotatedHeight) - p.Y - 1,
-				Y: p.X,
-			}
+	for i, p := range result.Points {
+		result.Points[i] = zxinggo.ResultPoint{
+			X: float64(rotatedHeight) - p.Y - 1,
+			Y: p.X,
 		}
 	}
-	return result, nil
 }
 
-// Reset is a no-op for 1D readers.
-func (r *MultiFormatOneDReader) Reset() {}
+// Reset resets the readers that keep state between rows.
+func (r *MultiFormatOneDReader) Reset() {
+	for _, reader := range r.readers {
+		if resetter, ok := reader.(interface{ Reset() }); ok {
+			resetter.Reset()
+		}
+	}
+}
+
+// Ensure MultiFormatOneDReader implements zxinggo.Reader and
+// zxinggo.MultipleBarcodeReader at compile time.
+var (
+	_ zxinggo.Reader                = (*MultiFormatOneDReader)(nil)
+	_ zxinggo.MultipleBarcodeReader = (*MultiFormatOneDReader)(nil)
+)
@@ -3,21 +3,28 @@ package oned
 import zxinggo "github.com/ericlevine/zxinggo"
 
 func init() {
-	// Register all 1D readers via the multi-format 1D reader.
-	oneDReaderFactory := func(opts *zxinggo.DecodeOptions) zxinggo.Reader {
+	// Register all 1D readers via the multi-format 1D reader, which one
+	// instance of reads every 1D format requested.
+	zxinggo.RegisterReaders([]zxinggo.Format{
+		zxinggo.FormatCode128,
+		zxinggo.FormatCode39,
+		zxinggo.FormatEAN13,
+		zxinggo.FormatEAN8,
+		zxinggo.FormatUPCA,
+		zxinggo.FormatUPCE,
+		zxinggo.FormatITF,
+		zxinggo.FormatCodabar,
+		zxinggo.FormatRSS14,
+		zxinggo.FormatRSSExpanded,
+		zxinggo.FormatCode93,
+		zxinggo.FormatCode11,
+		zxinggo.FormatTelepen,
+		zxinggo.FormatMatrix2of5,
+		zxinggo.FormatIndustrial2of5,
+		zxinggo.FormatIATA2of5,
+	}, func(opts *zxinggo.DecodeOptions) zxinggo.Reader {
 		return NewMultiFormatOneDReader(opts)
-	}
-	zxinggo.RegisterReader(zxinggo.FormatCode128, oneDReaderFactory)
-	zxinggo.RegisterReader(zxinggo.FormatCode39, oneDReaderFactory)
-	zxinggo.RegisterReader(zxinggo.FormatEAN13, oneDReaderFactory)
-	zxinggo.RegisterReader(zxinggo.FormatEAN8, oneDReaderFactory)
-	zxinggo.RegisterReader(zxinggo.FormatUPCA, oneDReaderFactory)
-	zxinggo.RegisterReader(zxinggo.FormatUPCE, oneDReaderFactory)
-	zxinggo.RegisterReader(zxinggo.FormatITF, oneDReaderFactory)
-	zxinggo.RegisterReader(zxinggo.FormatCodabar, oneDReaderFactory)
-	zxinggo.RegisterReader(zxinggo.FormatRSS14, oneDReaderFactory)
-	zxinggo.RegisterReader(zxinggo.FormatRSSExpanded, oneDReaderFactory)
-	zxinggo.RegisterReader(zxinggo.FormatCode93, oneDReaderFactory)
+	})
 
 	// Register writers
 	zxinggo.RegisterWriter(zxinggo.FormatCode128, func() zxinggo.Writer { return NewCode128Writer() })
@@ -10,6 +10,7 @@ import (
 // RSS14Reader decodes RSS-14 barcodes, including truncated and stacked variants.
 // Ported from Java ZXing RSS14Reader.
 type RSS14Reader struct {
+	rssDirection
 	possibleLeftPairs  []rssPair
 	possibleRightPairs []rssPair
 	// Reusable scratch buffers
@@ -25,6 +26,20 @@ func NewRSS14Reader() *RSS14Reader {
 	return &RSS14Reader{}
 }
 
+// Decode decodes an RSS-14 barcode from the given image, forgetting any
+// pairs tallied from earlier images.
+func (r *RSS14Reader) Decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	r.Reset()
+	return DecodeOneD(image, r, opts)
+}
+
+// Reset forgets the pairs tallied across rows, so that halves of symbols in
+// different images are not combined.
+func (r *RSS14Reader) Reset() {
+	r.possibleLeftPairs = r.possibleLeftPairs[:0]
+	r.possibleRightPairs = r.possibleRightPairs[:0]
+}
+
 var rss14OutsideEvenTotalSubset = []int{1, 10, 34, 70, 126}
 var rss14InsideOddTotalSubset = []int{4, 20, 48, 81}
 var rss14OutsideGsum = []int{0, 161, 961, 2015, 2715}
@@ -45,6 +60,7 @@ var rss14FinderPatterns = [][]int{
 }
 
 func (r *RSS14Reader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	r.width = row.Size()
 	leftPair := r.decodePair(row, false, rowNumber)
 	r.addOrTally(true, leftPair)
 	row.Reverse()
@@ -58,7 +74,9 @@ func (r *RSS14Reader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxin
 			for j := range r.possibleRightPairs {
 				right := &r.possibleRightPairs[j]
 				if right.count > 1 && rss14CheckChecksum(left, right) {
-					return rss14ConstructResult(left, right), nil
+					result := rss14ConstructResult(left, right)
+					r.place(result.Points)
+					return result, nil
 				}
 			}
 		}
@@ -332,14 +350,16 @@ func (r *RSS14Reader) parseFoundFinderPattern(row *bitutil.BitArray, rowNumber i
 		start = row.Size() - 1 - start
 		end = row.Size() - 1 - end
 	}
-	return &rssFinderPattern{
+	pattern := &rssFinderPattern{
 		value:    value,
 		startEnd: [2]int{firstElementStart, startEnd[1]},
 		resultPoints: [2]zxinggo.ResultPoint{
 			{X: float64(start), Y: float64(rowNumber)},
 			{X: float64(end), Y: float64(rowNumber)},
 		},
-	}, nil
+	}
+	r.place(pattern.resultPoints[:])
+	return pattern, nil
 }
 
 func (r *RSS14Reader) adjustOddEvenCounts14(outsideChar bool, numModules int) error {
@@ -450,3 +470,6 @@ func (r *RSS14Reader) adjustOddEvenCounts14(outsideChar bool, numModules int) er
 	}
 	return nil
 }
+
+// Ensure RSS14Reader implements zxinggo.Reader at compile time.
+var _ zxinggo.Reader = (*RSS14Reader)(nil)
@@ -5,15 +5,24 @@ import (
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/internal"
 )
 
 // RSSExpandedReader decodes RSS Expanded barcodes.
 // Ported from Java ZXing RSSExpandedReader.
+//
+// An RSS Expanded Stacked symbol is read a row at a time, so the reader
+// stores each row that does not complete a symbol on its own and tries to
+// combine it with the rows that follow. Stored rows are kept across calls to
+// DecodeRow and DecodeFrame until Reset; Decode starts afresh for each image.
 type RSSExpandedReader struct {
+	rssDirection
 	pairs          []expandedPair
 	rows           []expandedRow
 	startEnd       [2]int
 	startFromEven  bool
+	// rowSearch bounds the combinations of stored rows tried by checkRows.
+	rowSearch *internal.Budget
 	// Reusable scratch buffers
 	decodeFinderCounters  [4]int
 	dataCharacterCounters [8]int
@@ -27,6 +36,29 @@ func NewRSSExpandedReader() *RSSExpandedReader {
 	return &RSSExpandedReader{}
 }
 
+// Decode decodes an RSS Expanded barcode from the given image, forgetting
+// any rows stored from earlier images.
+func (r *RSSExpandedReader) Decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	r.Reset()
+	return r.DecodeFrame(image, opts)
+}
+
+// DecodeFrame decodes an RSS Expanded barcode from the given image, combining
+// its rows with those stored from earlier frames. This reads a stacked symbol
+// whose rows are only seen across several frames, such as video of a symbol
+// too tall or too damaged to read at once. Rows are ordered by their position
+// in the image, so the symbol should stay in roughly the same place. Call
+// Reset before reading a different symbol.
+func (r *RSSExpandedReader) DecodeFrame(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	return DecodeOneD(image, r, opts)
+}
+
+// Reset forgets the stored rows of a stacked symbol.
+func (r *RSSExpandedReader) Reset() {
+	r.pairs = r.pairs[:0]
+	r.rows = r.rows[:0]
+}
+
 var rssExpandedSymbolWidest = []int{7, 5, 4, 3, 1}
 var rssExpandedEvenTotalSubset = []int{4, 20, 52, 104, 204}
 var rssExpandedGsum = []int{0, 348, 1388, 2948, 3988}
@@ -84,9 +116,15 @@ const (
 	rssExpandedDataCharacterModules          = 17.0
 	rssExpandedMaxFinderPatternDistVariance  = 0.1
 	rssExpandedMaxPairs                      = 11
+	// rssExpandedRowSearchStepsPerPair bounds the combinations of stored
+	// rows tried for one stacked symbol to this many for each pair stored.
+	// Up to 25 rows are stored; rows of partial sequences that never pass
+	// the checksum could otherwise make the search exponential.
+	rssExpandedRowSearchStepsPerPair = 256
 )
 
 func (r *RSSExpandedReader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	r.width = row.Size()
 	// Try starting from even=false first, then even=true
 	r.startFromEven = false
 	result, err := r.tryDecodeRow(rowNumber, row)
@@ -102,7 +140,12 @@ func (r *RSSExpandedReader) tryDecodeRow(rowNumber int, row *bitutil.BitArray) (
 	if err != nil {
 		return nil, err
 	}
-	return rssExpandedConstructResult(pairs)
+	result, err := rssExpandedConstructResult(pairs)
+	if err != nil {
+		return nil, err
+	}
+	r.place(result.Points)
+	return result, nil
 }
 
 func (r *RSSExpandedReader) decodeRow2pairs(rowNumber int, row *bitutil.BitArray) ([]expandedPair, error) {
@@ -116,6 +159,9 @@ func (r *RSSExpandedReader) decodeRow2pairs(rowNumber int, row *bitutil.BitArray
 			break
 		}
 		r.pairs = append(r.pairs, *pair)
+		if len(r.pairs) > rssExpandedMaxPairs {
+			return nil, zxinggo.ErrNotFound
+		}
 	}
 
 	if r.checkExpandedChecksum() && isValidSequence(r.pairs, true) {
@@ -144,6 +190,11 @@ func (r *RSSExpandedReader) checkRows(reverse bool) []expandedPair {
 		return nil
 	}
 	r.pairs = r.pairs[:0]
+	storedPairs := 0
+	for _, row := range r.rows {
+		storedPairs += len(row.pairs)
+	}
+	r.rowSearch = internal.NewBudget(rssExpandedRowSearchStepsPerPair * storedPairs)
 	if reverse {
 		reverseExpandedRows(r.rows)
 	}
@@ -162,6 +213,9 @@ func reverseExpandedRows(rows []expandedRow) {
 
 func (r *RSSExpandedReader) checkRowsRecursive(collectedRows []expandedRow, currentRow int) []expandedPair {
 	for i := currentRow; i < len(r.rows); i++ {
+		if !r.rowSearch.Spend(1) {
+			return nil
+		}
 		row := r.rows[i]
 		r.pairs = append(r.pairs, row.pairs...)
 		addSize := len(row.pairs)
@@ -508,7 +562,7 @@ func (r *RSSExpandedReader) parseFoundExpandedFinderPattern(row *bitutil.BitArra
 		}
 	}
 
-	return &rssFinderPattern{
+	pattern := &rssFinderPattern{
 		value:    value,
 		startEnd: [2]int{start, end},
 		resultPoints: [2]zxinggo.ResultPoint{
@@ -516,6 +570,8 @@ func (r *RSSExpandedReader) parseFoundExpandedFinderPattern(row *bitutil.BitArra
 			{X: float64(end), Y: float64(rowNumber)},
 		},
 	}
+	r.place(pattern.resultPoints[:])
+	return pattern
 }
 
 func (r *RSSExpandedReader) decodeExpandedDataCharacter(row *bitutil.BitArray, pattern *rssFinderPattern, isOddPattern, leftChar bool) (*rssDataCharacter, error) {
@@ -712,3 +768,6 @@ func (r *RSSExpandedReader) adjustOddEvenCountsExpanded(numModules int) error {
 	}
 	return nil
 }
+
+// Ensure RSSExpandedReader implements zxinggo.Reader at compile time.
+var _ zxinggo.Reader = (*RSSExpandedReader)(nil)
@@ -16,6 +16,32 @@ const (
 	rssMaxFinderPatternRatio   = 12.5 / 14.0
 )
 
+// rssDirection records which way the current row is being read. The RSS
+// readers combine pairs found in several rows, some of which may only read
+// reversed, so they keep every finder pattern point in the coordinates of
+// the row unreversed and turn them back into those of the row being read
+// when a result is built.
+type rssDirection struct {
+	reversed bool
+	width    int
+}
+
+// setReversed is called by decodeRowBothWays before each attempt at a row.
+func (d *rssDirection) setReversed(reversed bool) {
+	d.reversed = reversed
+}
+
+// place maps points between the coordinates of the row being read and those
+// of the row unreversed. The mapping is its own inverse.
+func (d *rssDirection) place(points []zxinggo.ResultPoint) {
+	if !d.reversed {
+		return
+	}
+	for i, p := range points {
+		points[i].X = float64(d.width) - p.X - 1
+	}
+}
+
 // rssDataCharacter encapsulates a single character value with checksum info.
 type rssDataCharacter struct {
 	value           int
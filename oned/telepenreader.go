@@ -0,0 +1,220 @@
+package oned
+
+import (
+	"math/bits"
+	"strings"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+)
+
+const (
+	telepenStartChar = '_'
+	telepenStopChar  = 'z'
+
+	// telepenCharModules is the width of every Telepen character in modules.
+	telepenCharModules = 16
+
+	// telepenQuietZoneModules is the shortest space, in modules, treated as
+	// the quiet zone after the stop character.
+	telepenQuietZoneModules = 5
+)
+
+// telepenCharacters maps the 16-module pattern of each character, first
+// module in the most significant bit and 1 for a bar module, to the ASCII
+// character.
+var telepenCharacters = buildTelepenCharacters()
+
+func buildTelepenCharacters() map[uint16]byte {
+	m := make(map[uint16]byte, 128)
+	for c := 0; c < 128; c++ {
+		m[telepenPattern(byte(c))] = byte(c)
+	}
+	return m
+}
+
+// telepenPattern returns the module pattern of c. The character is extended
+// to eight bits with an even parity bit and sent least significant bit first.
+// A 1 bit is a narrow bar and narrow space; a pair of adjacent 0 bits is a
+// wide bar and narrow space; and two 0 bits separated by n 1 bits are a wide
+// bar, n-1 narrow space and narrow bar pairs, and a wide space.
+func telepenPattern(c byte) uint16 {
+	if bits.OnesCount8(c)%2 != 0 {
+		c |= 0x80
+	}
+	var pattern uint16
+	appendElement := func(bar bool, width int) {
+		for i := 0; i < width; i++ {
+			pattern <<= 1
+			if bar {
+				pattern |= 1
+			}
+		}
+	}
+	bit := func(i int) byte { return (c >> uint(i)) & 1 }
+	for i := 0; i < 8; {
+		switch {
+		case bit(i) == 1:
+			appendElement(true, 1)
+			appendElement(false, 1)
+			i++
+		case bit(i+1) == 0:
+			appendElement(true, 3)
+			appendElement(false, 1)
+			i += 2
+		default:
+			j := i + 1
+			for bit(j) == 1 {
+				j++
+			}
+			appendElement(true, 3)
+			for k := 0; k < j-i-2; k++ {
+				appendElement(false, 1)
+				appendElement(true, 1)
+			}
+			appendElement(false, 3)
+			i = j + 1
+		}
+	}
+	return pattern
+}
+
+// TelepenReader decodes Telepen barcodes in full ASCII mode. The check
+// character is verified and removed from the result.
+type TelepenReader struct{}
+
+// NewTelepenReader creates a new Telepen reader.
+func NewTelepenReader() *TelepenReader {
+	return &TelepenReader{}
+}
+
+// DecodeRow decodes a Telepen barcode from a single row.
+func (r *TelepenReader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	end := row.Size()
+	start := row.GetNextSet(0)
+	for start < end {
+		result, err := r.decodeFrom(rowNumber, row, start)
+		if err == nil {
+			return result, nil
+		}
+		// Try again from the next bar.
+		start = row.GetNextSet(row.GetNextUnset(start))
+	}
+	return nil, zxinggo.ErrNotFound
+}
+
+// decodeFrom decodes a symbol whose start character begins at start.
+func (r *TelepenReader) decodeFrom(rowNumber int, row *bitutil.BitArray, start int) (*zxinggo.Result, error) {
+	end := row.Size()
+	pos := start
+	// Estimate the module width from the start character, which is 16
+	// modules wide: ten narrow elements and then a wide bar and wide space.
+	counters := make([]int, 12)
+	if err := RecordPattern(row, start, counters); err != nil {
+		return nil, err
+	}
+	startWidth := 0
+	for _, c := range counters {
+		startWidth += c
+	}
+	module := float64(startWidth) / telepenCharModules
+	if module < 1 {
+		return nil, zxinggo.ErrNotFound
+	}
+	whiteStart := start - int(telepenQuietZoneModules*module)
+	if whiteStart < 0 {
+		whiteStart = 0
+	}
+	if !row.IsRange(whiteStart, start, false) {
+		return nil, zxinggo.ErrNotFound
+	}
+
+	var text strings.Builder
+	first := true
+	var lastStart int
+	for {
+		lastStart = pos
+		pattern, modules, width, atQuietZone := 0, 0, 0, false
+		for modules < telepenCharModules {
+			if pos >= end {
+				return nil, zxinggo.ErrNotFound
+			}
+			bar := row.Get(pos)
+			var next int
+			if bar {
+				next = row.GetNextUnset(pos)
+			} else {
+				next = row.GetNextSet(pos)
+			}
+			run := next - pos
+			n := 1
+			switch {
+			case !bar && modules == telepenCharModules-1 && (next >= end || float64(run) >= telepenQuietZoneModules*module):
+				// The stop character's final narrow space runs into the
+				// quiet zone.
+				atQuietZone = true
+				run = int(module + 0.5)
+			case float64(run) >= 2*module:
+				n = 3
+			}
+			if float64(run) > 4.5*module {
+				return nil, zxinggo.ErrNotFound
+			}
+			for i := 0; i < n; i++ {
+				pattern <<= 1
+				if bar {
+					pattern |= 1
+				}
+			}
+			modules += n
+			width += run
+			pos += run
+		}
+		if modules != telepenCharModules {
+			return nil, zxinggo.ErrNotFound
+		}
+		c, ok := telepenCharacters[uint16(pattern)]
+		if !ok {
+			return nil, zxinggo.ErrNotFound
+		}
+		if first {
+			if c != telepenStartChar {
+				return nil, zxinggo.ErrNotFound
+			}
+			first = false
+		} else {
+			text.WriteByte(c)
+		}
+		// Track gradual changes in module width along the row.
+		module = float64(width) / telepenCharModules
+		if atQuietZone {
+			break
+		}
+	}
+
+	s := text.String()
+	if len(s) < 3 || s[len(s)-1] != telepenStopChar {
+		return nil, zxinggo.ErrNotFound
+	}
+	data, check := s[:len(s)-2], s[len(s)-2]
+	sum := 0
+	for i := 0; i < len(data); i++ {
+		sum += int(data[i])
+	}
+	if byte((127-sum%127)%127) != check {
+		return nil, zxinggo.ErrChecksum
+	}
+
+	left := float64(start) + float64(startWidth)/2.0
+	right := float64(lastStart) + float64(pos-lastStart)/2.0
+	res := zxinggo.NewResult(
+		data, nil,
+		[]zxinggo.ResultPoint{
+			{X: left, Y: float64(rowNumber)},
+			{X: right, Y: float64(rowNumber)},
+		},
+		zxinggo.FormatTelepen,
+	)
+	res.PutMetadata(zxinggo.MetadataSymbologyIdentifier, "]B0")
+	return res, nil
+}
@@ -0,0 +1,280 @@
+package oned
+
+import (
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+)
+
+// twoOfFiveEncodings gives the narrow/wide pattern of each digit, first
+// element in the most significant bit, with 1 for a wide element. Exactly two
+// of the five elements are wide.
+var twoOfFiveEncodings = [10]int{
+	0x06, 0x11, 0x09, 0x18, 0x05, 0x14, 0x0C, 0x03, 0x12, 0x0A,
+}
+
+const (
+	// twoOfFiveQuietZone is the shortest space, in narrow widths, that ends a
+	// symbol or may precede one.
+	twoOfFiveQuietZone = 5
+
+	// twoOfFiveMinDigits is the fewest digits, including any check digit,
+	// accepted in a symbol. The start and stop patterns are short and easily
+	// matched by chance, so very short reads are mostly misreads.
+	twoOfFiveMinDigits = 3
+)
+
+type twoOfFiveVariant int
+
+const (
+	// matrix2of5 encodes each digit in three bars and two spaces, with a
+	// narrow space between characters.
+	matrix2of5 twoOfFiveVariant = iota
+	// industrial2of5 encodes each digit in the widths of five bars separated
+	// by narrow spaces, with a three-bar start and stop.
+	industrial2of5
+	// iata2of5 is Industrial 2 of 5 with a two-bar start and stop.
+	iata2of5
+)
+
+// twoOfFiveVariantInfo describes the start and stop patterns of a variant as
+// element counts and, for the stop, the wide/narrow pattern of its bars.
+var twoOfFiveVariantInfo = [...]struct {
+	format      zxinggo.Format
+	startLength int
+	stopLength  int
+	symbology   string
+}{
+	matrix2of5:     {zxinggo.FormatMatrix2of5, 5, 5, ""},
+	industrial2of5: {zxinggo.FormatIndustrial2of5, 6, 5, "]S0"},
+	iata2of5:       {zxinggo.FormatIATA2of5, 4, 3, "]R0"},
+}
+
+// TwoOfFiveReader decodes the straight (non-interleaved) 2 of 5 symbologies:
+// Matrix 2 of 5, Industrial 2 of 5 and IATA 2 of 5.
+type TwoOfFiveReader struct {
+	variant         twoOfFiveVariant
+	usingCheckDigit bool
+}
+
+// NewMatrix2of5Reader creates a Matrix 2 of 5 reader. If usingCheckDigit is
+// set, the last digit is verified as a modulo 10 check digit and removed.
+func NewMatrix2of5Reader(usingCheckDigit bool) *TwoOfFiveReader {
+	return &TwoOfFiveReader{variant: matrix2of5, usingCheckDigit: usingCheckDigit}
+}
+
+// NewIndustrial2of5Reader creates an Industrial 2 of 5 reader. If
+// usingCheckDigit is set, the last digit is verified as a modulo 10 check
+// digit and removed.
+func NewIndustrial2of5Reader(usingCheckDigit bool) *TwoOfFiveReader {
+	return &TwoOfFiveReader{variant: industrial2of5, usingCheckDigit: usingCheckDigit}
+}
+
+// NewIATA2of5Reader creates an IATA 2 of 5 reader. If usingCheckDigit is set,
+// the last digit is verified as a modulo 10 check digit and removed.
+func NewIATA2of5Reader(usingCheckDigit bool) *TwoOfFiveReader {
+	return &TwoOfFiveReader{variant: iata2of5, usingCheckDigit: usingCheckDigit}
+}
+
+// DecodeRow decodes a 2 of 5 barcode from a single row.
+func (r *TwoOfFiveReader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	// Record every run in the row, starting with the first bar.
+	var runs, starts []int
+	for pos := row.GetNextSet(0); pos < row.Size(); {
+		var next int
+		if len(runs)%2 == 0 {
+			next = row.GetNextUnset(pos)
+		} else {
+			next = row.GetNextSet(pos)
+		}
+		starts = append(starts, pos)
+		runs = append(runs, next-pos)
+		pos = next
+	}
+
+	useCheckDigit := r.usingCheckDigit || (opts != nil && opts.AssumeTwoOfFiveCheckDigit)
+	var lastErr error = zxinggo.ErrNotFound
+	for i := 0; i < len(runs); i += 2 {
+		whiteBefore := starts[0]
+		if i > 0 {
+			whiteBefore = runs[i-1]
+		}
+		digits, end, err := r.decodeAt(runs[i:], whiteBefore)
+		if err != nil {
+			continue
+		}
+		if useCheckDigit {
+			if !CheckStandardUPCEANChecksum(digits) {
+				lastErr = zxinggo.ErrChecksum
+				continue
+			}
+			digits = digits[:len(digits)-1]
+		}
+
+		info := twoOfFiveVariantInfo[r.variant]
+		startEnd := starts[i+info.startLength-1] + runs[i+info.startLength-1]
+		stopStart := starts[i+end-info.stopLength]
+		stopEnd := starts[i+end-1] + runs[i+end-1]
+		res := zxinggo.NewResult(
+			digits, nil,
+			[]zxinggo.ResultPoint{
+				{X: float64(starts[i]+startEnd) / 2.0, Y: float64(rowNumber)},
+				{X: float64(stopStart+stopEnd) / 2.0, Y: float64(rowNumber)},
+			},
+			info.format,
+		)
+		if info.symbology != "" {
+			res.PutMetadata(zxinggo.MetadataSymbologyIdentifier, info.symbology)
+		}
+		return res, nil
+	}
+	return nil, lastErr
+}
+
+// decodeAt decodes a symbol whose start pattern begins with runs[0], returning
+// its digits and the number of runs it spans.
+func (r *TwoOfFiveReader) decodeAt(runs []int, whiteBefore int) (string, int, error) {
+	info := twoOfFiveVariantInfo[r.variant]
+	if len(runs) < info.startLength+info.stopLength {
+		return "", 0, zxinggo.ErrNotFound
+	}
+	start := runs[:info.startLength]
+
+	// Estimate the narrow width from the narrow elements of the start pattern
+	// and check that its wide elements are clearly wider.
+	var wide []int
+	switch r.variant {
+	case matrix2of5:
+		wide = []int{0}
+	case industrial2of5:
+		wide = []int{0, 2}
+	}
+	narrowSum, narrowCount, maxNarrow := 0, 0, 0
+	for j, w := range start {
+		if !containsInt(wide, j) {
+			narrowSum += w
+			narrowCount++
+			maxNarrow = max(maxNarrow, w)
+		}
+	}
+	narrow := float64(narrowSum) / float64(narrowCount)
+	for _, j := range wide {
+		if 2*start[j] < 3*maxNarrow {
+			return "", 0, zxinggo.ErrNotFound
+		}
+	}
+	if float64(whiteBefore) < twoOfFiveQuietZone*narrow {
+		return "", 0, zxinggo.ErrNotFound
+	}
+
+	// The symbol ends at the first space wide enough to be a quiet zone.
+	end := len(runs)
+	for j := info.startLength | 1; j < len(runs); j += 2 {
+		if float64(runs[j]) >= twoOfFiveQuietZone*narrow {
+			end = j
+			break
+		}
+	}
+	elements := runs[info.startLength:end]
+	if end == len(runs) && len(elements)%2 == 0 {
+		// The row ended in the trailing quiet zone.
+		elements = elements[:len(elements)-1]
+		end--
+	}
+
+	isNarrow := func(w int) bool { return float64(w) < 1.5*narrow }
+	var digits []byte
+	switch r.variant {
+	case matrix2of5:
+		// Each character, including the stop, is preceded by a narrow gap.
+		if len(elements)%6 != 0 {
+			return "", 0, zxinggo.ErrNotFound
+		}
+		for len(elements) > 6 {
+			if !isNarrow(elements[0]) {
+				return "", 0, zxinggo.ErrNotFound
+			}
+			d, ok := twoOfFiveDigit(elements[1:6])
+			if !ok {
+				return "", 0, zxinggo.ErrNotFound
+			}
+			digits = append(digits, d)
+			elements = elements[6:]
+		}
+		stop := elements[1:]
+		if !isNarrow(elements[0]) || isNarrow(stop[0]) ||
+			!isNarrow(stop[1]) || !isNarrow(stop[2]) || !isNarrow(stop[3]) || !isNarrow(stop[4]) {
+			return "", 0, zxinggo.ErrNotFound
+		}
+	default:
+		// Each digit is five bars, each followed by a narrow space.
+		if len(elements) < info.stopLength || (len(elements)-info.stopLength)%10 != 0 {
+			return "", 0, zxinggo.ErrNotFound
+		}
+		for len(elements) > info.stopLength {
+			var bars [5]int
+			for k := 0; k < 5; k++ {
+				bars[k] = elements[2*k]
+				if !isNarrow(elements[2*k+1]) {
+					return "", 0, zxinggo.ErrNotFound
+				}
+			}
+			d, ok := twoOfFiveDigit(bars[:])
+			if !ok {
+				return "", 0, zxinggo.ErrNotFound
+			}
+			digits = append(digits, d)
+			elements = elements[10:]
+		}
+		// Industrial stops with wide, narrow and wide bars; IATA with a wide
+		// bar and a narrow bar.
+		stop := elements
+		if isNarrow(stop[0]) || !isNarrow(stop[1]) || !isNarrow(stop[2]) ||
+			(r.variant == industrial2of5 && (!isNarrow(stop[3]) || isNarrow(stop[4]))) {
+			return "", 0, zxinggo.ErrNotFound
+		}
+	}
+	if len(digits) < twoOfFiveMinDigits {
+		return "", 0, zxinggo.ErrNotFound
+	}
+	return string(digits), end, nil
+}
+
+// twoOfFiveDigit decodes five element widths, taking the two widest as wide.
+func twoOfFiveDigit(widths []int) (byte, bool) {
+	first, second := -1, -1
+	for i, w := range widths {
+		if first < 0 || w > widths[first] {
+			first, second = i, first
+		} else if second < 0 || w > widths[second] {
+			second = i
+		}
+	}
+	minWide := min(widths[first], widths[second])
+	pattern, maxNarrow := 0, 0
+	for i, w := range widths {
+		pattern <<= 1
+		if i == first || i == second {
+			pattern |= 1
+		} else {
+			maxNarrow = max(maxNarrow, w)
+		}
+	}
+	if 2*minWide < 3*maxNarrow {
+		return 0, false
+	}
+	for d, enc := range twoOfFiveEncodings {
+		if enc == pattern {
+			return byte('0' + d), true
+		}
+	}
+	return 0, false
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
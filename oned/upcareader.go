@@ -22,13 +22,21 @@ func (r *UPCAReader) BarcodeFormat() zxinggo.Format {
 	return zxinggo.FormatUPCA
 }
 
-// DecodeRow decodes a UPC-A barcode from a single row.
+// DecodeRow decodes a UPC-A barcode from a single row. It reads an EAN-13
+// symbol, which must start with zero, and reports it as UPC-A unless
+// opts.ReportUPCAAsEAN13 is set.
 func (r *UPCAReader) DecodeRow(rowNumber int, row *bitutil.BitArray, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
 	result, err := r.ean13.DecodeRow(rowNumber, row, opts)
 	if err != nil {
 		return nil, err
 	}
-	return maybeReturnUPCAResult(result)
+	if !isUPCA(result) {
+		return nil, zxinggo.ErrFormat
+	}
+	if opts != nil && opts.ReportUPCAAsEAN13 {
+		return result, nil
+	}
+	return upcaFromEAN13(result), nil
 }
 
 // DecodeMiddle decodes the middle portion by delegating to EAN-13.
@@ -36,18 +44,19 @@ func (r *UPCAReader) DecodeMiddle(row *bitutil.BitArray, startRange [2]int, resu
 	return r.ean13.DecodeMiddle(row, startRange, result)
 }
 
-func maybeReturnUPCAResult(result *zxinggo.Result) (*zxinggo.Result, error) {
-	text := result.Text
-	if len(text) > 0 && text[0] == '0' {
-		upcaResult := zxinggo.NewResult(
-			text[1:], nil,
-			result.Points,
-			zxinggo.FormatUPCA,
-		)
-		for k, v := range result.Metadata {
-			upcaResult.PutMetadata(k, v)
-		}
-		return upcaResult, nil
+// isUPCA reports whether result is an EAN-13 symbol that is also a UPC-A
+// one, having a leading zero.
+func isUPCA(result *zxinggo.Result) bool {
+	return result.Format == zxinggo.FormatEAN13 && len(result.Text) > 0 && result.Text[0] == '0'
+}
+
+// upcaFromEAN13 returns the UPC-A result of an EAN-13 result with a leading
+// zero: its text without the zero, at the same points, with the same
+// metadata.
+func upcaFromEAN13(result *zxinggo.Result) *zxinggo.Result {
+	upcaResult := zxinggo.NewResult(result.Text[1:], nil, result.Points, zxinggo.FormatUPCA)
+	for k, v := range result.Metadata {
+		upcaResult.PutMetadata(k, v)
 	}
-	return nil, zxinggo.ErrFormat
+	return upcaResult
 }
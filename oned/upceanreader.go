@@ -63,7 +63,8 @@ type UPCEANMiddleDecoder interface {
 
 // DecodeUPCEAN decodes a UPC/EAN barcode from a row using the given middle decoder.
 func DecodeUPCEAN(rowNumber int, row *bitutil.BitArray, decoder UPCEANMiddleDecoder, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
-	startRange, err := findUPCEANStartGuardPattern(row)
+	profile := zxinggo.ProfileOf(opts)
+	startRange, err := findUPCEANStartGuardPattern(row, profile)
 	if err != nil {
 		return nil, err
 	}
@@ -79,11 +80,26 @@ func DecodeUPCEAN(rowNumber int, row *bitutil.BitArray, decoder UPCEANMiddleDeco
 		return nil, err
 	}
 
-	// Quiet zone check after barcode
-	end := endRange[1]
-	quietEnd := end + (end - endRange[0])
-	if quietEnd >= row.Size() || !row.IsRange(end, quietEnd, false) {
-		return nil, zxinggo.ErrNotFound
+	// Quiet zone check after barcode, and under ProfileStrict before it for
+	// formats that call for more than the start guard was checked for.
+	zone := zxinggo.DefaultQuietZone(decoder.BarcodeFormat())
+	if profile != zxinggo.ProfilePermissive {
+		endModules := len(UPCEANStartEndPattern)
+		if decoder.BarcodeFormat() == zxinggo.FormatUPCE {
+			endModules = len(UPCEANEndPattern)
+		}
+		end := endRange[1]
+		quietEnd := end + upceanQuietZone(end-endRange[0], endModules, zone.Right, profile)
+		if quietEnd >= row.Size() || !row.IsRange(end, quietEnd, false) {
+			return nil, zxinggo.ErrNotFound
+		}
+	}
+	if profile == zxinggo.ProfileStrict {
+		start := startRange[0]
+		quietStart := start - upceanQuietZone(startRange[1]-start, len(UPCEANStartEndPattern), zone.Left, profile)
+		if quietStart < 0 || !row.IsRange(quietStart, start, false) {
+			return nil, zxinggo.ErrNotFound
+		}
 	}
 
 	resultString := result.String()
@@ -180,7 +196,23 @@ func GetStandardUPCEANChecksum(s string) int {
 	return (1000 - sum) % 10
 }
 
-func findUPCEANStartGuardPattern(row *bitutil.BitArray) ([2]int, error) {
+// upceanQuietZone returns the width of quiet zone required next to a guard
+// pattern of guardModules modules that is guardWidth wide: as wide as the
+// guard itself, or zoneModules modules, from the format's DefaultQuietZone,
+// under ProfileStrict.
+func upceanQuietZone(guardWidth, guardModules, zoneModules int, profile zxinggo.Profile) int {
+	if profile == zxinggo.ProfileStrict {
+		return (guardWidth*zoneModules + guardModules - 1) / guardModules
+	}
+	return guardWidth
+}
+
+// upceanMinQuietZone is the narrowest quiet zone, in modules, any UPC/EAN
+// format calls for before its start guard, which is all that can be
+// required before the format is known.
+var upceanMinQuietZone = zxinggo.DefaultQuietZone(zxinggo.FormatEAN8).Left
+
+func findUPCEANStartGuardPattern(row *bitutil.BitArray, profile zxinggo.Profile) ([2]int, error) {
 	counters := make([]int, len(UPCEANStartEndPattern))
 	nextStart := 0
 	for {
@@ -193,7 +225,10 @@ func findUPCEANStartGuardPattern(row *bitutil.BitArray) ([2]int, error) {
 		}
 		start := startRange[0]
 		nextStart = startRange[1]
-		quietStart := start - (nextStart - start)
+		if profile == zxinggo.ProfilePermissive {
+			return startRange, nil
+		}
+		quietStart := start - upceanQuietZone(nextStart-start, len(UPCEANStartEndPattern), upceanMinQuietZone, profile)
 		if quietStart >= 0 && row.IsRange(quietStart, start, false) {
 			return startRange, nil
 		}
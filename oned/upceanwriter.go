@@ -20,7 +20,7 @@ func EncodeUPCEAN(contents string, format zxinggo.Format, width, height int, enc
 	if err != nil {
 		return nil, err
 	}
-	return RenderOneDCode(code, width, height), nil
+	return RenderOneDCodeQuietZone(code, width, height, zxinggo.DefaultQuietZone(format)), nil
 }
 
 // CheckUPCEANDigits validates that a string contains only digits.
@@ -26,7 +26,7 @@ func (w *UPCEWriter) Encode(contents string, format zxinggo.Format, width, heigh
 	if err != nil {
 		return nil, err
 	}
-	return RenderOneDCode(code, width, height), nil
+	return RenderOneDCodeQuietZone(code, width, height, zxinggo.EncodeQuietZone(zxinggo.FormatUPCE, opts)), nil
 }
 
 // EncodeContents encodes UPC-E contents into a boolean pattern.
@@ -0,0 +1,73 @@
+package pdf417
+
+import (
+	"fmt"
+	"strconv"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/pdf417/encoder"
+)
+
+const (
+	// maxRows and maxCodewords bound every PDF417 symbol.
+	maxRows      = 90
+	maxCodewords = 929
+)
+
+// capacityPlanner sizes PDF417 symbols by their number of data columns, 1
+// to 30, each up to 90 rows tall. Error correction levels are "0" to "8",
+// and empty for the writer's default of 2.
+type capacityPlanner struct{}
+
+// Fits reports whether payload, a string of bytes, encodes with automatic
+// compaction in a symbol with the given number of data columns.
+func (capacityPlanner) Fits(payload string, version int, ecLevel string) (bool, error) {
+	if version < 1 || version > 30 {
+		return false, fmt.Errorf("%w: no PDF417 symbol with %d data columns", zxinggo.ErrWriter, version)
+	}
+	level := defaultErrorCorrectionLevel
+	if ecLevel != "" {
+		var err error
+		if level, err = strconv.Atoi(ecLevel); err != nil {
+			return false, fmt.Errorf("%w: no PDF417 error correction level %q", zxinggo.ErrWriter, ecLevel)
+		}
+	}
+	ecCodewords, err := encoder.GetErrorCorrectionCodewordCount(level)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", zxinggo.ErrWriter, err)
+	}
+	highLevel, err := encoder.EncodeHighLevelBytes([]byte(payload), encoder.CompactionAuto)
+	if err != nil {
+		return false, nil
+	}
+	// One codeword holds the symbol length.
+	needed := len([]rune(highLevel)) + 1 + ecCodewords
+	return needed <= min(version*maxRows, maxCodewords), nil
+}
+
+// SmallestSymbolFor returns the size Writer would encode payload in. Like
+// Writer, it prefers the columns that give the symbol its preferred aspect
+// ratio, so the symbol need not be the one with the fewest columns.
+func (capacityPlanner) SmallestSymbolFor(payload string, opts *zxinggo.EncodeOptions) (zxinggo.SymbolSize, error) {
+	enc, level, err := newEncoder(opts)
+	if err != nil {
+		return zxinggo.SymbolSize{}, err
+	}
+	if err := enc.GenerateBarcodeLogic(payload, level); err != nil {
+		return zxinggo.SymbolSize{}, fmt.Errorf("%w: %v", zxinggo.ErrWriter, err)
+	}
+	matrix := enc.BarcodeMatrix()
+	// Start and stop patterns and two row indicators surround the data;
+	// compact symbols drop the right indicator and shorten the stop pattern.
+	width := 17*matrix.Columns() + 69
+	if opts != nil && opts.PDF417Compact {
+		width = 17*matrix.Columns() + 35
+	}
+	return zxinggo.SymbolSize{
+		Format:  zxinggo.FormatPDF417,
+		Version: matrix.Columns(),
+		Width:   width,
+		Height:  matrix.Rows(),
+		ECLevel: strconv.Itoa(level),
+	}, nil
+}
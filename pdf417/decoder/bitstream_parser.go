@@ -120,6 +120,7 @@ const (
 	beginMacroPDF417OptionalField    = 923
 	macroPDF417Terminator            = 922
 	modeShiftToByteCompactionMode    = 913
+	readerInitialisation             = 921
 	maxNumericCodewords              = 15
 
 	macroPDF417OptionalFieldFileName     = 0
@@ -168,10 +169,19 @@ type PDF417ResultMetadata struct {
 	Timestamp    int64
 	FileSize     int64
 	Checksum     int
+
+	// ReaderInitialisation is set when the symbol carries codeword 921,
+	// marking it as a message to program the reader rather than data.
+	ReaderInitialisation bool
+
+	// ECIs lists, in order, the general purpose (900–810899) and user
+	// defined (810900–811799) ECI designators in the symbol. Character set
+	// ECIs are applied to the text instead.
+	ECIs []int
 }
 
-// decodeBitStream decodes PDF417 codewords into a DecoderResult.
-func decodeBitStream(codewords []int, ecLevel string) (*internal.DecoderResult, error) {
+// decodeBitStream decodes PDF417 codewords into a DecodedPayload.
+func decodeBitStream(codewords []int, ecLevel string) (*internal.DecodedPayload, error) {
 	result := newECIResult(len(codewords) * 2)
 
 	codeIndex, err := textCompaction(codewords, 1, result)
@@ -207,11 +217,19 @@ func decodeBitStream(codewords []int, ecLevel string) (*internal.DecoderResult,
 			}
 			codeIndex++
 		case eciGeneralPurpose:
-			// Can't do anything with generic ECI; skip its 2 characters
+			if codeIndex+2 > codewords[0] {
+				return nil, zxinggo.ErrFormat
+			}
+			resultMetadata.ECIs = append(resultMetadata.ECIs, 900*(codewords[codeIndex]+1)+codewords[codeIndex+1])
 			codeIndex += 2
 		case eciUserDefined:
-			// Can't do anything with user ECI; skip its 1 character
+			if codeIndex+1 > codewords[0] {
+				return nil, zxinggo.ErrFormat
+			}
+			resultMetadata.ECIs = append(resultMetadata.ECIs, 810900+codewords[codeIndex])
 			codeIndex++
+		case readerInitialisation:
+			resultMetadata.ReaderInitialisation = true
 		case beginMacroPDF417ControlBlock:
 			codeIndex, err = decodeMacroBlock(codewords, codeIndex, resultMetadata)
 			if err != nil {
@@ -230,11 +248,18 @@ func decodeBitStream(codewords []int, ecLevel string) (*internal.DecoderResult,
 			}
 		}
 	}
-	if result.Len() == 0 && resultMetadata.FileID == "" {
+	if result.Len() == 0 && resultMetadata.FileID == "" && !resultMetadata.ReaderInitialisation {
 		return nil, zxinggo.ErrFormat
 	}
-	dr := internal.NewDecoderResult(nil, result.String(), nil, ecLevel)
+	dr := internal.NewDecodedPayload(nil, result.String(), nil, ecLevel)
 	dr.Other = resultMetadata
+	if resultMetadata.FileID != "" {
+		dr.StructuredAppend = &zxinggo.StructuredAppend{
+			Index: resultMetadata.SegmentIndex,
+			Count: resultMetadata.SegmentCount,
+			ID:    resultMetadata.FileID,
+		}
+	}
 	return dr, nil
 }
 
@@ -410,7 +435,8 @@ func textCompaction(codewords []int, codeIndex int, result *eciResult) (int, err
 				index++
 			case byteCompactionModeLatch, byteCompactionModeLatch6,
 				numericCompactionModeLatch, beginMacroPDF417ControlBlock,
-				beginMacroPDF417OptionalField, macroPDF417Terminator:
+				beginMacroPDF417OptionalField, macroPDF417Terminator,
+				eciGeneralPurpose, eciUserDefined, readerInitialisation:
 				codeIndex--
 				end = true
 			case modeShiftToByteCompactionMode:
@@ -649,7 +675,8 @@ func numericCompaction(codewords []int, codeIndex int, result *eciResult) (int,
 			switch code {
 			case textCompactionModeLatch, byteCompactionModeLatch,
 				byteCompactionModeLatch6, beginMacroPDF417ControlBlock,
-				beginMacroPDF417OptionalField, macroPDF417Terminator, eciCharset:
+				beginMacroPDF417OptionalField, macroPDF417Terminator, eciCharset,
+				eciGeneralPurpose, eciUserDefined, readerInitialisation:
 				codeIndex--
 				end = true
 			}
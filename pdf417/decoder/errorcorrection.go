@@ -18,20 +18,27 @@ func NewErrorCorrection() *ErrorCorrection {
 	}
 }
 
+// NewErrorCorrectionForField creates an ErrorCorrection over another prime
+// field. As for PDF417, the generator polynomial's roots are the field's
+// generator raised to the powers 1 to the number of EC codewords.
+func NewErrorCorrectionForField(field *ModulusGF) *ErrorCorrection {
+	return &ErrorCorrection{
+		field: field,
+	}
+}
+
 // Decode corrects errors in the received codewords. numECCodewords is the
 // number of codewords used for error correction, and erasures gives the
 // known positions of errors (may be nil). It returns the number of errors
 // corrected and modifies received in place. Returns an error if correction
 // is not possible.
 func (ec *ErrorCorrection) Decode(received []int, numECCodewords int, erasures []int) (int, error) {
-	poly := NewModulusPoly(ec.field, received)
-	S := make([]int, numECCodewords)
+	S := ec.Syndromes(received, numECCodewords)
 	hasError := false
-	for i := numECCodewords; i > 0; i-- {
-		eval := poly.EvaluateAt(ec.field.Exp(i))
-		S[numECCodewords-i] = eval
+	for _, eval := range S {
 		if eval != 0 {
 			hasError = true
+			break
 		}
 	}
 
@@ -75,6 +82,19 @@ func (ec *ErrorCorrection) Decode(received []int, numECCodewords int, erasures [
 	return len(errorLocations), nil
 }
 
+// Syndromes evaluates the received codewords, data followed by
+// numECCodewords error-correction codewords, at the roots of the generator
+// polynomial, highest root first. All syndromes are zero exactly when
+// received is a valid codeword.
+func (ec *ErrorCorrection) Syndromes(received []int, numECCodewords int) []int {
+	poly := NewModulusPoly(ec.field, received)
+	S := make([]int, numECCodewords)
+	for i := numECCodewords; i > 0; i-- {
+		S[numECCodewords-i] = poly.EvaluateAt(ec.field.Exp(i))
+	}
+	return S
+}
+
 // runEuclideanAlgorithm runs the extended Euclidean algorithm to find the
 // error locator and error evaluator polynomials.
 func (ec *ErrorCorrection) runEuclideanAlgorithm(a, b *ModulusPoly, R int) ([2]*ModulusPoly, error) {
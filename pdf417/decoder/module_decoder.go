@@ -0,0 +1,103 @@
+package decoder
+
+import (
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/internal"
+)
+
+const modulesInStopPattern = 18
+
+// DecodeModules decodes a PDF417 barcode from its module grid, one bit per
+// module and one grid row per symbol row, with no quiet zone. The grid must
+// be upright, with the start pattern on the left. A compact symbol has no
+// right row indicator and ends in a one-module stop bar.
+func DecodeModules(bits *bitutil.BitMatrix) (*internal.DecodedPayload, error) {
+	width, height := bits.Width(), bits.Height()
+	if width%modulesInCodeword != 1 || width < 3*modulesInCodeword+1 ||
+		height < minRowsInBarcode || height > maxRowsInBarcode {
+		return nil, zxinggo.ErrFormat
+	}
+
+	// Vote on the metadata as the scanning decoder does, first from the left
+	// row indicator, which also tells the normal layout from the compact one,
+	// then from the right row indicator if there is one.
+	columnCount := NewBarcodeValue()
+	rowCountUpperPart := NewBarcodeValue()
+	rowCountLowerPart := NewBarcodeValue()
+	ecLevel := NewBarcodeValue()
+	readRowIndicator := func(x int, isLeft bool) {
+		for y := 0; y < height; y++ {
+			value := moduleCodeword(bits, x, y)
+			if value < 0 || value/30*3+y%3 != y {
+				continue
+			}
+			rowNumber := y
+			if !isLeft {
+				rowNumber += 2
+			}
+			rowIndicatorValue := value % 30
+			switch rowNumber % 3 {
+			case 0:
+				rowCountUpperPart.SetValue(rowIndicatorValue*3 + 1)
+			case 1:
+				ecLevel.SetValue(rowIndicatorValue / 3)
+				rowCountLowerPart.SetValue(rowIndicatorValue % 3)
+			case 2:
+				columnCount.SetValue(rowIndicatorValue + 1)
+			}
+		}
+	}
+	readRowIndicator(modulesInCodeword, true)
+	columns := columnCount.Value()
+	if len(columns) == 0 {
+		return nil, zxinggo.ErrFormat
+	}
+	switch codewordsInRow := width / modulesInCodeword; columns[0] {
+	case codewordsInRow - 4:
+		readRowIndicator(width-modulesInStopPattern-modulesInCodeword, false)
+	case codewordsInRow - 2:
+	default:
+		return nil, zxinggo.ErrFormat
+	}
+	columns = columnCount.Value()
+	upperPart, lowerPart, levels := rowCountUpperPart.Value(), rowCountLowerPart.Value(), ecLevel.Value()
+	if len(upperPart) == 0 || len(lowerPart) == 0 || len(levels) == 0 ||
+		upperPart[0]+lowerPart[0] != height {
+		return nil, zxinggo.ErrFormat
+	}
+
+	codewords := make([]int, height*columns[0])
+	var erasures []int
+	for y := 0; y < height; y++ {
+		for column := 0; column < columns[0]; column++ {
+			i := y*columns[0] + column
+			codewords[i] = moduleCodeword(bits, (column+2)*modulesInCodeword, y)
+			if codewords[i] < 0 {
+				codewords[i] = 0
+				erasures = append(erasures, i)
+			}
+		}
+	}
+	return decodeCodewords(codewords, levels[0], erasures)
+}
+
+// moduleCodeword returns the codeword whose 17 modules start at (x, y), or
+// -1 if they are not a symbol of row y's cluster.
+func moduleCodeword(bits *bitutil.BitMatrix, x, y int) int {
+	if x+modulesInCodeword > bits.Width() {
+		return -1
+	}
+	symbol := 0
+	for i := 0; i < modulesInCodeword; i++ {
+		symbol <<= 1
+		if bits.Get(x+i, y) {
+			symbol |= 1
+		}
+	}
+	codeword := getCodeword(symbol)
+	if codeword < 0 || getCodewordBucketNumber(symbol) != y%3*3 {
+		return -1
+	}
+	return codeword
+}
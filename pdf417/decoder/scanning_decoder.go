@@ -21,7 +21,7 @@ var scanErrorCorrection = NewErrorCorrection()
 // minCodewordWidth and maxCodewordWidth provide bounds on codeword widths.
 func Decode(image *bitutil.BitMatrix,
 	imageTopLeft, imageBottomLeft, imageTopRight, imageBottomRight *zxinggo.ResultPoint,
-	minCodewordWidth, maxCodewordWidth int) (*internal.DecoderResult, error) {
+	minCodewordWidth, maxCodewordWidth int) (*internal.DecodedPayload, error) {
 
 	boundingBox, err := NewBoundingBox(image, imageTopLeft, imageBottomLeft, imageTopRight, imageBottomRight)
 	if err != nil {
@@ -254,7 +254,7 @@ func adjustCodewordCount(detectionResult *DetectionResult, barcodeMatrix [][]*Ba
 	return nil
 }
 
-func createDecoderResult(detectionResult *DetectionResult) (*internal.DecoderResult, error) {
+func createDecoderResult(detectionResult *DetectionResult) (*internal.DecodedPayload, error) {
 	barcodeMatrix := createBarcodeMatrix(detectionResult)
 	if err := adjustCodewordCount(detectionResult, barcodeMatrix); err != nil {
 		return nil, err
@@ -285,7 +285,7 @@ func createDecoderResultFromAmbiguousValues(ecLevel int,
 	codewords []int,
 	erasureArray []int,
 	ambiguousIndexes []int,
-	ambiguousIndexValues [][]int) (*internal.DecoderResult, error) {
+	ambiguousIndexValues [][]int) (*internal.DecodedPayload, error) {
 
 	ambiguousIndexCount := make([]int, len(ambiguousIndexes))
 
@@ -501,7 +501,7 @@ func checkCodewordSkew(codewordSize, minCodewordWidth, maxCodewordWidth int) boo
 		codewordSize <= maxCodewordWidth+codewordSkewSize
 }
 
-func decodeCodewords(codewords []int, ecLevel int, erasures []int) (*internal.DecoderResult, error) {
+func decodeCodewords(codewords []int, ecLevel int, erasures []int) (*internal.DecodedPayload, error) {
 	if len(codewords) == 0 {
 		return nil, zxinggo.ErrFormat
 	}
@@ -5,6 +5,7 @@ import (
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/internal"
 )
 
 var (
@@ -21,6 +22,9 @@ const (
 	skippedRowCountMax           = 25
 	rowStep                      = 5
 	barcodeMinHeight             = 10
+	// detectPasses bounds the guard pattern search in each rotation to this
+	// many scans of every pixel of the image. A symbol takes a few.
+	detectPasses = 32
 )
 
 // B S B S B S B S Bar/Space pattern
@@ -36,9 +40,24 @@ var rotations = [4]int{0, 180, 270, 90}
 // degree rotations. If multiple is true, the image is searched for multiple
 // codes; otherwise at most one code will be found and returned.
 func Detect(matrix *bitutil.BitMatrix, multiple bool, tryHarder bool) (*PDF417DetectorResult, error) {
+	return DetectWithOptions(matrix, multiple, nil)
+}
+
+// DetectWithOptions is like Detect but records the rows it searches for
+// guard patterns, and the symbols it finds, in opts.Heatmap. Only the
+// unrotated search is recorded. opts may be nil.
+func DetectWithOptions(matrix *bitutil.BitMatrix, multiple bool, opts *zxinggo.DecodeOptions) (*PDF417DetectorResult, error) {
+	var heatmap *zxinggo.Heatmap
+	if opts != nil {
+		heatmap = opts.Heatmap
+	}
 	for _, rotation := range rotations {
+		// Each rotation has a budget of its own, so that noise spending one
+		// does not keep a symbol from being found in the next.
+		budget := internal.NewBudget(detectPasses * matrix.Width() * matrix.Height())
 		bitMatrix := applyRotation(matrix, rotation)
-		barcodeCoordinates := detect(multiple, bitMatrix)
+		barcodeCoordinates := detect(multiple, bitMatrix, budget, heatmap)
+		heatmap = nil
 		if len(barcodeCoordinates) > 0 {
 			return &PDF417DetectorResult{
 				Bits:     bitMatrix,
@@ -66,14 +85,16 @@ func applyRotation(matrix *bitutil.BitMatrix, rotation int) *bitutil.BitMatrix {
 }
 
 // detect detects PDF417 codes in an image. Only checks 0 degree rotation.
-func detect(multiple bool, bitMatrix *bitutil.BitMatrix) [][]*zxinggo.ResultPoint {
+// It stops searching, and finds nothing, once budget is spent. heatmap, if
+// not nil, records the rows searched and the codes found.
+func detect(multiple bool, bitMatrix *bitutil.BitMatrix, budget *internal.Budget, heatmap *zxinggo.Heatmap) [][]*zxinggo.ResultPoint {
 	var barcodeCoordinates [][]*zxinggo.ResultPoint
 	row := 0
 	column := 0
 	foundBarcodeInRow := false
 
-	for row < bitMatrix.Height() {
-		vertices := findVertices(bitMatrix, row, column)
+	for row < bitMatrix.Height() && !budget.Exhausted() {
+		vertices := findVertices(bitMatrix, row, column, budget, heatmap)
 
 		if vertices[0] == nil && vertices[3] == nil {
 			if !foundBarcodeInRow {
@@ -98,6 +119,7 @@ func detect(multiple bool, bitMatrix *bitutil.BitMatrix) [][]*zxinggo.ResultPoin
 		}
 		foundBarcodeInRow = true
 		barcodeCoordinates = append(barcodeCoordinates, vertices)
+		recordCandidate(heatmap, vertices[:4], true)
 		if !multiple {
 			break
 		}
@@ -113,6 +135,11 @@ func detect(multiple bool, bitMatrix *bitutil.BitMatrix) [][]*zxinggo.ResultPoin
 		}
 	}
 
+	if budget.Exhausted() {
+		// What was found before the budget ran out is as likely to be
+		// noise as the rest of the image.
+		return nil
+	}
 	return barcodeCoordinates
 }
 
@@ -129,7 +156,7 @@ func detect(multiple bool, bitMatrix *bitutil.BitMatrix) [][]*zxinggo.ResultPoin
 //	[5] x, y bottom left codeword area
 //	[6] x, y top right codeword area
 //	[7] x, y bottom right codeword area
-func findVertices(matrix *bitutil.BitMatrix, startRow, startColumn int) []*zxinggo.ResultPoint {
+func findVertices(matrix *bitutil.BitMatrix, startRow, startColumn int, budget *internal.Budget, heatmap *zxinggo.Heatmap) []*zxinggo.ResultPoint {
 	height := matrix.Height()
 	width := matrix.Width()
 
@@ -137,7 +164,7 @@ func findVertices(matrix *bitutil.BitMatrix, startRow, startColumn int) []*zxing
 	minHeight := barcodeMinHeight
 
 	copyToResult(result,
-		findRowsWithPattern(matrix, height, width, startRow, startColumn, minHeight, startPattern[:]),
+		findRowsWithPattern(matrix, height, width, startRow, startColumn, minHeight, startPattern[:], budget, heatmap),
 		indexesStartPattern[:])
 
 	if result[4] != nil {
@@ -151,7 +178,7 @@ func findVertices(matrix *bitutil.BitMatrix, startRow, startColumn int) []*zxing
 	}
 
 	copyToResult(result,
-		findRowsWithPattern(matrix, height, width, startRow, startColumn, minHeight, stopPattern[:]),
+		findRowsWithPattern(matrix, height, width, startRow, startColumn, minHeight, stopPattern[:], budget, heatmap),
 		indexesStopPattern[:])
 
 	return result
@@ -166,20 +193,21 @@ func copyToResult(result, tmpResult []*zxinggo.ResultPoint, destinationIndexes [
 }
 
 // findRowsWithPattern finds the top and bottom rows where a guard pattern
-// occurs, returning a 4-element slice of result points.
+// occurs, returning a 4-element slice of result points. A pattern found in
+// fewer than minHeight rows is recorded in heatmap as a rejected candidate.
 func findRowsWithPattern(matrix *bitutil.BitMatrix,
 	height, width, startRow, startColumn, minHeight int,
-	pattern []int) []*zxinggo.ResultPoint {
+	pattern []int, budget *internal.Budget, heatmap *zxinggo.Heatmap) []*zxinggo.ResultPoint {
 
 	result := make([]*zxinggo.ResultPoint, 4)
 	found := false
 	counters := make([]int, len(pattern))
 
-	for ; startRow < height; startRow += rowStep {
-		loc := findGuardPattern(matrix, startColumn, startRow, width, pattern, counters)
+	for ; startRow < height && !budget.Exhausted(); startRow += rowStep {
+		loc := findGuardPattern(matrix, startColumn, startRow, width, pattern, counters, budget, heatmap)
 		if loc != nil {
 			for startRow > 0 {
-				previousRowLoc := findGuardPattern(matrix, startColumn, startRow-1, width, pattern, counters)
+				previousRowLoc := findGuardPattern(matrix, startColumn, startRow-1, width, pattern, counters, budget, heatmap)
 				if previousRowLoc != nil {
 					startRow--
 					loc = previousRowLoc
@@ -200,7 +228,7 @@ func findRowsWithPattern(matrix *bitutil.BitMatrix,
 		skippedRowCount := 0
 		previousRowLoc := [2]int{int(result[0].X), int(result[1].X)}
 		for ; stopRow < height; stopRow++ {
-			loc := findGuardPattern(matrix, previousRowLoc[0], stopRow, width, pattern, counters)
+			loc := findGuardPattern(matrix, previousRowLoc[0], stopRow, width, pattern, counters, budget, heatmap)
 			// a found pattern is only considered to belong to the same barcode
 			// if the start and end positions don't differ too much. Pattern
 			// drift should be not bigger than two for consecutive rows. With a
@@ -226,6 +254,9 @@ func findRowsWithPattern(matrix *bitutil.BitMatrix,
 	}
 
 	if stopRow-startRow < minHeight {
+		if found {
+			recordCandidate(heatmap, result, false)
+		}
 		for i := range result {
 			result[i] = nil
 		}
@@ -235,11 +266,18 @@ func findRowsWithPattern(matrix *bitutil.BitMatrix,
 }
 
 // findGuardPattern searches a row for a guard pattern and returns the
-// start/end horizontal offset as a two-element slice, or nil if not found.
+// start/end horizontal offset as a two-element slice, or nil if not found
+// or the budget is spent. heatmap records the pixels scanned.
 func findGuardPattern(matrix *bitutil.BitMatrix,
 	column, row, width int,
 	pattern []int,
-	counters []int) []int {
+	counters []int,
+	budget *internal.Budget,
+	heatmap *zxinggo.Heatmap) []int {
+
+	if !budget.Spend(width - column) {
+		return nil
+	}
 
 	for i := range counters {
 		counters[i] = 0
@@ -266,6 +304,7 @@ func findGuardPattern(matrix *bitutil.BitMatrix,
 		} else {
 			if counterPosition == patternLength-1 {
 				if patternMatchVariance(counters, pattern) < maxAvgVariance {
+					heatmap.ScanRow(row, column, x+1)
 					return []int{patternStart, x}
 				}
 				patternStart += counters[0] + counters[1]
@@ -281,6 +320,7 @@ func findGuardPattern(matrix *bitutil.BitMatrix,
 		}
 	}
 
+	heatmap.ScanRow(row, column, width)
 	if counterPosition == patternLength-1 &&
 		patternMatchVariance(counters, pattern) < maxAvgVariance {
 		return []int{patternStart, x - 1}
@@ -289,6 +329,33 @@ func findGuardPattern(matrix *bitutil.BitMatrix,
 	return nil
 }
 
+// recordCandidate records the symbol or guard pattern spanning the given
+// points, some of which may be nil, in heatmap.
+func recordCandidate(heatmap *zxinggo.Heatmap, points []*zxinggo.ResultPoint, accepted bool) {
+	if heatmap == nil {
+		return
+	}
+	var found []zxinggo.ResultPoint
+	for _, p := range points {
+		if p != nil {
+			found = append(found, *p)
+		}
+	}
+	if len(found) == 0 {
+		return
+	}
+	var x, y, size float64
+	for _, p := range found {
+		x += p.X
+		y += p.Y
+		for _, q := range found {
+			size = math.Max(size, zxinggo.Distance(p, q))
+		}
+	}
+	n := float64(len(found))
+	heatmap.Candidate(zxinggo.FormatPDF417, x/n, y/n, size, accepted)
+}
+
 // patternMatchVariance determines how closely a set of observed counts of runs
 // of black/white values matches a given target pattern. This is reported as
 // the ratio of the total variance from the expected pattern proportions across
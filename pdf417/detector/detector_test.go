@@ -0,0 +1,65 @@
+package detector
+
+import (
+	"testing"
+
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/internal"
+	"github.com/ericlevine/zxinggo/internal/symbolgen"
+	"github.com/ericlevine/zxinggo/pdf417/encoder"
+)
+
+// TestDetectBudget checks that guard pattern noise, which the search
+// would otherwise circle forever, spends the detection budget and finds
+// nothing.
+func TestDetectBudget(t *testing.T) {
+	matrix := symbolgen.PDF417GuardNoise(400, 400, 1)
+	budget := internal.NewBudget(detectPasses * matrix.Width() * matrix.Height())
+	if found := detect(true, matrix, budget, nil); found != nil {
+		t.Errorf("found %d symbols in noise", len(found))
+	}
+	if !budget.Exhausted() {
+		t.Error("detection finished within its budget")
+	}
+}
+
+// TestDetectBudgetPerRotation checks that noise spending the budget of the
+// upright search does not keep a symbol turned a quarter turn from being
+// found.
+func TestDetectBudgetPerRotation(t *testing.T) {
+	enc := encoder.NewPDF417Encoder()
+	if err := enc.GenerateBarcodeLogic("rotated past the noise", 2); err != nil {
+		t.Fatal(err)
+	}
+	symbol := enc.BarcodeMatrix().ScaledMatrix(2, 6)
+
+	// Guard noise above and below, upright and upside down so that both of
+	// those searches spend their budgets on it, and the symbol turned a
+	// quarter turn between.
+	noise := symbolgen.PDF417GuardNoise(400, 400, 1)
+	gap := len(symbol[0]) + 40
+	matrix := bitutil.NewBitMatrixWithSize(400, 800+gap)
+	for y := 0; y < noise.Height(); y++ {
+		for x := 0; x < noise.Width(); x++ {
+			if noise.Get(x, y) {
+				matrix.Set(x, y)
+				matrix.Set(399-x, 799+gap-y)
+			}
+		}
+	}
+	for y, row := range symbol {
+		for x, module := range row {
+			if module == 1 {
+				matrix.Set(20+y, 420+x)
+			}
+		}
+	}
+
+	result, err := Detect(matrix, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Points) == 0 || result.Rotation%180 == 0 {
+		t.Errorf("found %d symbols at rotation %d, want the symbol at 90 or 270", len(result.Points), result.Rotation)
+	}
+}
@@ -70,6 +70,16 @@ func NewBarcodeMatrix(height, width int) *BarcodeMatrix {
 	return m
 }
 
+// Rows returns the number of rows in the symbol.
+func (bm *BarcodeMatrix) Rows() int {
+	return bm.height
+}
+
+// Columns returns the number of data columns in the symbol.
+func (bm *BarcodeMatrix) Columns() int {
+	return bm.width / 17
+}
+
 // Set sets a specific location in the matrix.
 func (bm *BarcodeMatrix) Set(x, y int, value byte) {
 	bm.matrix[y].Set(x, value)
@@ -146,9 +146,8 @@ func GenerateErrorCorrection(dataCodewords string, level int) (string, error) {
 	}
 
 	e := make([]int, k)
-	sld := len(dataCodewords)
-	for i := 0; i < sld; i++ {
-		t1 := (int(dataCodewords[i]) + e[k-1]) % 929
+	for _, codeword := range dataCodewords {
+		t1 := (int(codeword) + e[k-1]) % 929
 		for j := k - 1; j >= 1; j-- {
 			t2 := (t1 * ecCoefficients[level][j]) % 929
 			t3 := 929 - t2
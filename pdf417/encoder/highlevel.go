@@ -93,8 +93,9 @@ func init() {
 }
 
 // EncodeHighLevel performs high-level encoding of a PDF417 message using the
-// algorithm described in annex P of ISO/IEC 15438:2001(E).
-// This is a simplified port that does not support ECI or custom charsets.
+// algorithm described in annex P of ISO/IEC 15438:2001(E). The message must
+// be in ISO-8859-1, the default character set; see EncodeHighLevelBytes for
+// others.
 func EncodeHighLevel(msg string, compaction Compaction) (string, error) {
 	if len(msg) == 0 {
 		return "", errors.New("empty message not allowed")
@@ -117,6 +118,33 @@ func EncodeHighLevel(msg string, compaction Compaction) (string, error) {
 		}
 	}
 
+	// The compactions work on bytes: each character's ISO-8859-1 byte.
+	latin1 := make([]byte, 0, len(msg))
+	for _, ch := range msg {
+		latin1 = append(latin1, byte(ch))
+	}
+	return encodeHighLevel(string(latin1), compaction), nil
+}
+
+// EncodeHighLevelBytes is EncodeHighLevel for a message already converted
+// to the character set it is to be read in, which an ECI designator should
+// announce. Text compaction requires ASCII.
+func EncodeHighLevelBytes(data []byte, compaction Compaction) (string, error) {
+	if len(data) == 0 {
+		return "", errors.New("empty message not allowed")
+	}
+	if compaction == CompactionText {
+		for i, b := range data {
+			if b > 127 {
+				return "", fmt.Errorf("non-encodable byte detected: 0x%02X at position #%d", b, i)
+			}
+		}
+	}
+	return encodeHighLevel(string(data), compaction), nil
+}
+
+// encodeHighLevel encodes msg, a string of bytes, with compaction.
+func encodeHighLevel(msg string, compaction Compaction) string {
 	var sb strings.Builder
 	sb.Grow(len(msg))
 
@@ -177,7 +205,7 @@ func EncodeHighLevel(msg string, compaction Compaction) (string, error) {
 		}
 	}
 
-	return sb.String(), nil
+	return sb.String()
 }
 
 // encodeText encodes parts of the message using Text Compaction as described
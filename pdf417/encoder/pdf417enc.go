@@ -11,6 +11,8 @@ import (
 	"fmt"
 	"math"
 	"strings"
+
+	"github.com/ericlevine/zxinggo/charset"
 )
 
 const (
@@ -501,6 +503,9 @@ type PDF417Encoder struct {
 	maxCols       int
 	maxRows       int
 	minRows       int
+	readerInit    bool
+	ecis          []int
+	characterSet  *charset.ECI
 }
 
 // NewPDF417Encoder creates a new PDF417Encoder with default settings.
@@ -533,6 +538,26 @@ func (p *PDF417Encoder) SetCompact(compact bool) {
 	p.compact = compact
 }
 
+// SetReaderInitialisation sets whether the symbol starts with the Reader
+// Initialisation codeword (921), marking it as a message that programs the
+// reader.
+func (p *PDF417Encoder) SetReaderInitialisation(readerInit bool) {
+	p.readerInit = readerInit
+}
+
+// SetECIs sets general purpose (900–810899) and user defined (810900–811799)
+// ECI designators to place at the start of the data.
+func (p *PDF417Encoder) SetECIs(ecis []int) {
+	p.ecis = ecis
+}
+
+// SetCharacterSet sets the character set messages are converted to and
+// announced in by an ECI designator. If nil, the default, messages are
+// encoded in ISO-8859-1 without one.
+func (p *PDF417Encoder) SetCharacterSet(eci *charset.ECI) {
+	p.characterSet = eci
+}
+
 // BarcodeMatrix returns the barcode matrix.
 func (p *PDF417Encoder) BarcodeMatrix() *BarcodeMatrix {
 	return p.barcodeMatrix
@@ -546,10 +571,24 @@ func (p *PDF417Encoder) GenerateBarcodeLogic(msg string, errorCorrectionLevel in
 	if err != nil {
 		return err
 	}
-	highLevel, err := EncodeHighLevel(msg, p.compaction)
+	var highLevel string
+	if p.characterSet != nil {
+		data, err := charset.EncodeString(msg, p.characterSet)
+		if err != nil {
+			return err
+		}
+		highLevel, err = EncodeHighLevelBytes(data, p.compaction)
+		if err != nil {
+			return err
+		}
+	} else if highLevel, err = EncodeHighLevel(msg, p.compaction); err != nil {
+		return err
+	}
+	prefix, err := p.encodePrefix()
 	if err != nil {
 		return err
 	}
+	highLevel = prefix + highLevel
 	sourceCodeWords := len([]rune(highLevel))
 
 	dimension, err := determineDimensions(p.minCols, p.maxCols, p.minRows, p.maxRows,
@@ -591,6 +630,33 @@ func (p *PDF417Encoder) GenerateBarcodeLogic(msg string, errorCorrectionLevel in
 	return nil
 }
 
+// encodePrefix returns the Reader Initialisation, character set ECI and
+// other ECI codewords that precede the encoded message.
+func (p *PDF417Encoder) encodePrefix() (string, error) {
+	var sb strings.Builder
+	if p.readerInit {
+		sb.WriteRune(921)
+	}
+	if p.characterSet != nil {
+		sb.WriteRune(927)
+		sb.WriteRune(rune(p.characterSet.Value))
+	}
+	for _, eci := range p.ecis {
+		switch {
+		case eci >= 900 && eci < 810900:
+			sb.WriteRune(926)
+			sb.WriteRune(rune(eci/900 - 1))
+			sb.WriteRune(rune(eci % 900))
+		case eci >= 810900 && eci < 811800:
+			sb.WriteRune(925)
+			sb.WriteRune(rune(eci - 810900))
+		default:
+			return "", fmt.Errorf("ECI %d is not a general purpose or user defined ECI", eci)
+		}
+	}
+	return sb.String(), nil
+}
+
 // calculateNumberOfRows calculates the necessary number of rows as described
 // in annex Q of ISO/IEC 15438:2001(E).
 func calculateNumberOfRows(m, k, c int) int {
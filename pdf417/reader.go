@@ -1,24 +1,37 @@
 package pdf417
 
 import (
-	"fmt"
 	"math"
 
 	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
 	"github.com/ericlevine/zxinggo/pdf417/decoder"
 	"github.com/ericlevine/zxinggo/pdf417/detector"
 )
 
 // PDF417Reader decodes PDF417 barcodes from binary images.
-type PDF417Reader struct{}
+type PDF417Reader struct {
+	// opts are used by Decode and DecodeMultiple when they are passed nil.
+	opts *zxinggo.DecodeOptions
+}
 
 // NewPDF417Reader creates a new PDF417 reader.
 func NewPDF417Reader() *PDF417Reader {
 	return &PDF417Reader{}
 }
 
+// NewPDF417ReaderWithOptions creates a PDF417 reader that uses opts
+// whenever it is given nil options, so it can be configured once and used
+// on its own rather than through zxinggo.Decode.
+func NewPDF417ReaderWithOptions(opts *zxinggo.DecodeOptions) *PDF417Reader {
+	return &PDF417Reader{opts: opts}
+}
+
 // Decode locates and decodes a PDF417 barcode in the given image.
 func (r *PDF417Reader) Decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	if opts == nil {
+		opts = r.opts
+	}
 	results, err := r.decode(image, opts, false)
 	if err != nil {
 		return nil, err
@@ -29,8 +42,12 @@ func (r *PDF417Reader) Decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeO
 	return results[0], nil
 }
 
-// DecodeMultiple locates and decodes all PDF417 barcodes in the given image.
+// DecodeMultiple locates and decodes all PDF417 barcodes in the given image,
+// until opts.MaxResults or opts.StopOnFormats ends the search.
 func (r *PDF417Reader) DecodeMultiple(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) ([]*zxinggo.Result, error) {
+	if opts == nil {
+		opts = r.opts
+	}
 	return r.decode(image, opts, true)
 }
 
@@ -40,8 +57,7 @@ func (r *PDF417Reader) decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeO
 		return nil, err
 	}
 
-	tryHarder := opts != nil && opts.TryHarder
-	detResult, err := detector.Detect(matrix, multiple, tryHarder)
+	detResult, err := detector.DetectWithOptions(matrix, multiple, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -64,22 +80,23 @@ func (r *PDF417Reader) decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeO
 			continue
 		}
 
-		result := zxinggo.NewResult(
-			dr.Text,
-			dr.RawBytes,
-			[]zxinggo.ResultPoint{},
-			zxinggo.FormatPDF417,
-		)
-
-		result.PutMetadata(zxinggo.MetadataErrorCorrectionLevel, dr.ECLevel)
-		result.PutMetadata(zxinggo.MetadataErrorsCorrected, dr.ErrorsCorrected)
-		result.PutMetadata(zxinggo.MetadataErasuresCorrected, dr.Erasures)
-		if dr.Other != nil {
-			result.PutMetadata(zxinggo.MetadataPDF417ExtraMetadata, dr.Other)
+		// As in Java ZXing, the points are the corners of the start and
+		// stop patterns, then of the codeword area, less any not found.
+		var resultPoints []zxinggo.ResultPoint
+		for _, p := range points {
+			if p != nil {
+				resultPoints = append(resultPoints, *p)
+			}
+		}
+		result := dr.Result(resultPoints, zxinggo.FormatPDF417)
+		if zxinggo.CheckErrorBudget(result, opts) != nil {
+			continue
 		}
-		result.PutMetadata(zxinggo.MetadataSymbologyIdentifier, fmt.Sprintf("]L%d", dr.SymbologyModifier))
 
 		results = append(results, result)
+		if multiple && zxinggo.SearchDone(opts, len(results), result) {
+			break
+		}
 	}
 
 	if len(results) == 0 {
@@ -88,6 +105,23 @@ func (r *PDF417Reader) decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeO
 	return results, nil
 }
 
+// DecodeMatrix decodes a PDF417 barcode from its module grid, one bit per
+// module and one grid row per symbol row, with no quiet zone, as sampled by
+// an external detector. The grid must be upright, with the start pattern on
+// the left. opts may be nil; only MaxErrorsCorrected is used. The result has
+// no points.
+func DecodeMatrix(bits *bitutil.BitMatrix, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	dr, err := decoder.DecodeModules(bits)
+	if err != nil {
+		return nil, err
+	}
+	result := dr.Result(nil, zxinggo.FormatPDF417)
+	if err := zxinggo.CheckErrorBudget(result, opts); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // Reset resets internal state.
 func (r *PDF417Reader) Reset() {}
 
@@ -121,3 +155,6 @@ func getMaxCodewordWidth(points []*zxinggo.ResultPoint) int {
 		max(getMaxWidth(points[1], points[5]), getMaxWidth(points[7], points[3])*modulesInCodeword/modulesInStopPattern),
 	)
 }
+
+// Ensure PDF417Reader implements zxinggo.Reader at compile time.
+var _ zxinggo.Reader = (*PDF417Reader)(nil)
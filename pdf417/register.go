@@ -9,4 +9,5 @@ func init() {
 	zxinggo.RegisterWriter(zxinggo.FormatPDF417, func() zxinggo.Writer {
 		return NewPDF417Writer()
 	})
+	zxinggo.RegisterCapacityPlanner(zxinggo.FormatPDF417, capacityPlanner{})
 }
@@ -6,13 +6,11 @@ import (
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/charset"
 	"github.com/ericlevine/zxinggo/pdf417/encoder"
 )
 
-const (
-	defaultWhiteSpace            = 30
-	defaultErrorCorrectionLevel  = 2
-)
+const defaultErrorCorrectionLevel = 2
 
 // PDF417Writer encodes PDF417 barcodes.
 type PDF417Writer struct{}
@@ -22,41 +20,20 @@ func NewPDF417Writer() *PDF417Writer {
 	return &PDF417Writer{}
 }
 
-// Encode encodes the given contents into a PDF417 barcode BitMatrix.
+// Encode encodes the given contents into a PDF417 barcode BitMatrix. If
+// opts.CharacterSet is set, contents are converted to it and an ECI for it
+// written; otherwise they must be in ISO-8859-1.
 func (w *PDF417Writer) Encode(contents string, format zxinggo.Format, width, height int, opts *zxinggo.EncodeOptions) (*bitutil.BitMatrix, error) {
 	if format != zxinggo.FormatPDF417 {
 		return nil, fmt.Errorf("can only encode PDF_417, but got %s", format)
 	}
 
-	enc := encoder.NewPDF417Encoder()
-	margin := defaultWhiteSpace
-	errorCorrectionLevel := defaultErrorCorrectionLevel
-
-	if opts != nil {
-		if opts.PDF417Compact {
-			enc.SetCompact(true)
-		}
-		if opts.PDF417Compaction > 0 {
-			enc.SetCompaction(encoder.Compaction(opts.PDF417Compaction))
-		}
-		if opts.PDF417Dimensions != nil {
-			enc.SetDimensions(
-				opts.PDF417Dimensions.MaxCols,
-				opts.PDF417Dimensions.MinCols,
-				opts.PDF417Dimensions.MaxRows,
-				opts.PDF417Dimensions.MinRows,
-			)
-		}
-		if opts.Margin != nil {
-			margin = *opts.Margin
-		}
-		if opts.ErrorCorrection != "" {
-			var ecl int
-			if _, err := fmt.Sscanf(opts.ErrorCorrection, "%d", &ecl); err == nil {
-				errorCorrectionLevel = ecl
-			}
-		}
+	enc, errorCorrectionLevel, err := newEncoder(opts)
+	if err != nil {
+		return nil, err
 	}
+	// The quiet zone is in modules, as wide as a module on every side.
+	quietZone := zxinggo.EncodeQuietZone(zxinggo.FormatPDF417, opts).Left
 
 	if err := enc.GenerateBarcodeLogic(contents, errorCorrectionLevel); err != nil {
 		return nil, err
@@ -79,9 +56,49 @@ func (w *PDF417Writer) Encode(contents string, format zxinggo.Format, width, hei
 		if rotated {
 			scaledMatrix = rotateArray(scaledMatrix)
 		}
-		return bitMatrixFromByteArray(scaledMatrix, margin), nil
+		return bitMatrixFromByteArray(scaledMatrix, quietZone*scale), nil
+	}
+	return bitMatrixFromByteArray(originalScale, quietZone), nil
+}
+
+// newEncoder returns an encoder configured by opts, which may be nil, and the
+// error correction level they select.
+func newEncoder(opts *zxinggo.EncodeOptions) (*encoder.PDF417Encoder, int, error) {
+	enc := encoder.NewPDF417Encoder()
+	errorCorrectionLevel := defaultErrorCorrectionLevel
+	if opts == nil {
+		return enc, errorCorrectionLevel, nil
+	}
+	if opts.CharacterSet != "" {
+		eci := charset.GetECIByName(opts.CharacterSet)
+		if eci == nil {
+			return nil, 0, fmt.Errorf("%w: unsupported character set %q", zxinggo.ErrWriter, opts.CharacterSet)
+		}
+		enc.SetCharacterSet(eci)
+	}
+	if opts.PDF417Compact {
+		enc.SetCompact(true)
+	}
+	if opts.PDF417Compaction > 0 {
+		enc.SetCompaction(encoder.Compaction(opts.PDF417Compaction))
+	}
+	if opts.PDF417Dimensions != nil {
+		enc.SetDimensions(
+			opts.PDF417Dimensions.MaxCols,
+			opts.PDF417Dimensions.MinCols,
+			opts.PDF417Dimensions.MaxRows,
+			opts.PDF417Dimensions.MinRows,
+		)
+	}
+	enc.SetReaderInitialisation(opts.PDF417ReaderInitialisation)
+	enc.SetECIs(opts.PDF417ECIs)
+	if opts.ErrorCorrection != "" {
+		var ecl int
+		if _, err := fmt.Sscanf(opts.ErrorCorrection, "%d", &ecl); err == nil {
+			errorCorrectionLevel = ecl
+		}
 	}
-	return bitMatrixFromByteArray(originalScale, margin), nil
+	return enc, errorCorrectionLevel, nil
 }
 
 func bitMatrixFromByteArray(input [][]byte, margin int) *bitutil.BitMatrix {
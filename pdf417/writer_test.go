@@ -1,9 +1,14 @@
 package pdf417
 
 import (
+	"errors"
 	"testing"
 
 	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/internal/symbolgen"
+	"github.com/ericlevine/zxinggo/pdf417/decoder"
 )
 
 func TestPDF417WriterBasic(t *testing.T) {
@@ -52,3 +57,119 @@ func TestPDF417WriterWithOptions(t *testing.T) {
 		t.Fatal("expected non-empty matrix")
 	}
 }
+
+func TestPDF417ReaderInitialisationAndECIRoundTrip(t *testing.T) {
+	opts := &zxinggo.EncodeOptions{
+		PDF417ReaderInitialisation: true,
+		PDF417ECIs:                 []int{1000, 811000},
+	}
+	matrix, err := NewPDF417Writer().Encode("SET PARAM 42", zxinggo.FormatPDF417, 400, 200, opts)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	source := zxinggo.NewGrayImageLuminanceSource(zxinggo.BitMatrixToImage(matrix))
+	bitmap := zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(source))
+	result, err := NewPDF417Reader().Decode(bitmap, &zxinggo.DecodeOptions{PureBarcode: true})
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if result.Text != "SET PARAM 42" {
+		t.Errorf("Text = %q, want %q", result.Text, "SET PARAM 42")
+	}
+	md, ok := result.Metadata[zxinggo.MetadataPDF417ExtraMetadata].(*decoder.PDF417ResultMetadata)
+	if !ok {
+		t.Fatal("no PDF417 metadata")
+	}
+	if !md.ReaderInitialisation {
+		t.Error("ReaderInitialisation not set")
+	}
+	if len(md.ECIs) != 2 || md.ECIs[0] != 1000 || md.ECIs[1] != 811000 {
+		t.Errorf("ECIs = %v, want [1000 811000]", md.ECIs)
+	}
+	if errs := result.Metadata[zxinggo.MetadataErrorsCorrected]; errs != 0 {
+		t.Errorf("ErrorsCorrected = %v, want 0", errs)
+	}
+
+	opts.PDF417ECIs = []int{3}
+	if _, err := NewPDF417Writer().Encode("x", zxinggo.FormatPDF417, 400, 200, opts); err == nil {
+		t.Error("expected error for a character set ECI")
+	}
+}
+
+// TestDecodeGuardNoise checks that guard pattern noise, which spends the
+// detector's budget, reads as no symbol.
+func TestDecodeGuardNoise(t *testing.T) {
+	img := zxinggo.BitMatrixToImage(symbolgen.PDF417GuardNoise(400, 400, 1))
+	bitmap := zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(zxinggo.NewGrayImageLuminanceSource(img)))
+	if _, err := NewPDF417Reader().DecodeMultiple(bitmap, &zxinggo.DecodeOptions{TryHarder: true}); !errors.Is(err, zxinggo.ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
+
+// moduleGrid returns the module grid of contents encoded with opts, one grid
+// row per symbol row.
+func moduleGrid(t *testing.T, contents string, opts *zxinggo.EncodeOptions) *bitutil.BitMatrix {
+	t.Helper()
+	enc, errorCorrectionLevel, err := newEncoder(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.GenerateBarcodeLogic(contents, errorCorrectionLevel); err != nil {
+		t.Fatal(err)
+	}
+	return bitMatrixFromByteArray(enc.BarcodeMatrix().Matrix(), 0)
+}
+
+func TestDecodeMatrix(t *testing.T) {
+	bits := moduleGrid(t, "Hello, World!", nil)
+	result, err := DecodeMatrix(bits, nil)
+	if err != nil {
+		t.Fatalf("DecodeMatrix: %v", err)
+	}
+	if result.Text != "Hello, World!" {
+		t.Errorf("Text = %q, want %q", result.Text, "Hello, World!")
+	}
+
+	// Damage the first data codeword of the second row.
+	bits.Flip(2*17+5, 1)
+	result, err = DecodeMatrix(bits, nil)
+	if err != nil {
+		t.Fatalf("DecodeMatrix (damaged): %v", err)
+	}
+	if errs := result.Metadata[zxinggo.MetadataErrorsCorrected]; errs != 1 {
+		t.Errorf("ErrorsCorrected = %v, want 1", errs)
+	}
+	opts := &zxinggo.DecodeOptions{MaxErrorsCorrected: map[zxinggo.Format]int{zxinggo.FormatPDF417: 0}}
+	if _, err := DecodeMatrix(bits, opts); !errors.Is(err, zxinggo.ErrChecksum) {
+		t.Errorf("DecodeMatrix (damaged, no corrections allowed): got %v, want ErrChecksum", err)
+	}
+}
+
+func TestDecodeMatrixCompact(t *testing.T) {
+	padded := moduleGrid(t, "Hello, World!", &zxinggo.EncodeOptions{PDF417Compact: true})
+
+	// The encoder leaves a compact symbol's rows their full width; cut them
+	// after the one-module stop bar.
+	columns := (padded.Width()-1)/17 - 4
+	bits := bitutil.NewBitMatrixWithSize((columns+2)*17+1, padded.Height())
+	for y := 0; y < bits.Height(); y++ {
+		for x := 0; x < bits.Width(); x++ {
+			if padded.Get(x, y) {
+				bits.Set(x, y)
+			}
+		}
+	}
+	result, err := DecodeMatrix(bits, nil)
+	if err != nil {
+		t.Fatalf("DecodeMatrix: %v", err)
+	}
+	if result.Text != "Hello, World!" {
+		t.Errorf("Text = %q, want %q", result.Text, "Hello, World!")
+	}
+}
+
+func TestDecodeMatrixRejectsWrongSize(t *testing.T) {
+	if _, err := DecodeMatrix(bitutil.NewBitMatrixWithSize(100, 6), nil); !errors.Is(err, zxinggo.ErrFormat) {
+		t.Errorf("DecodeMatrix(100x6): got %v, want ErrFormat", err)
+	}
+}
@@ -0,0 +1,320 @@
+package zxinggo
+
+import (
+	"fmt"
+	"math"
+)
+
+// CameraMatrix holds pinhole camera intrinsics in pixels: focal lengths Fx, Fy
+// and principal point Cx, Cy. Lens distortion is not modelled; undistort the
+// image, or the result points, first if it is significant.
+type CameraMatrix struct {
+	Fx, Fy float64
+	Cx, Cy float64
+}
+
+// Pose is the position and orientation of a symbol relative to the camera.
+// The symbol frame has its origin at the symbol's center, X to the right and
+// Y down along the symbol's rows as printed, and Z pointing away from the
+// viewer. The camera frame has X right, Y down and Z along the optical axis.
+// A point P in the symbol frame is at Rotation·P + Translation in the camera
+// frame.
+type Pose struct {
+	Rotation    [3][3]float64
+	Translation [3]float64 // millimetres
+	// ReprojectionError is the RMS distance, in pixels, between the result
+	// points and the model points projected with this pose.
+	ReprojectionError float64
+}
+
+const (
+	poseMaxIterations = 20
+	poseEpsilon       = 1e-10
+)
+
+// EstimatePose estimates the pose of a decoded QR Code or Data Matrix symbol
+// from its result points, given the camera intrinsics and the printed width
+// of the symbol in millimetres, excluding the quiet zone.
+//
+// QR Codes without an alignment pattern (version 1) provide only three points.
+// Three points fit up to four poses exactly; the one closest to an affine
+// estimate is returned, so the tilt of a symbol seen at a steep angle may come
+// out mirrored about the line of sight. The distance is largely unaffected.
+// Refining the result points first (see DecodeOptions.SubPixelRadius)
+// improves accuracy noticeably.
+func EstimatePose(result *Result, camera CameraMatrix, symbolSizeMM float64) (*Pose, error) {
+	if camera.Fx <= 0 || camera.Fy <= 0 || symbolSizeMM <= 0 {
+		return nil, fmt.Errorf("%w: invalid camera matrix or symbol size", ErrFormat)
+	}
+	model, err := poseModelPoints(result, symbolSizeMM)
+	if err != nil {
+		return nil, err
+	}
+	n := len(model)
+	// Work in normalized image coordinates.
+	image := make([]ResultPoint, n)
+	for i := 0; i < n; i++ {
+		p := result.Points[i]
+		image[i] = ResultPoint{X: (p.X - camera.Cx) / camera.Fx, Y: (p.Y - camera.Cy) / camera.Fy}
+	}
+
+	src, dst := model, image
+	if n == 3 {
+		// Complete the parallelogram for an initial, affine, estimate.
+		src = append(src[:3:3], ResultPoint{X: model[0].X + model[2].X - model[1].X, Y: model[0].Y + model[2].Y - model[1].Y})
+		dst = append(dst[:3:3], ResultPoint{X: image[0].X + image[2].X - image[1].X, Y: image[0].Y + image[2].Y - image[1].Y})
+	}
+	h, ok := homography(src, dst)
+	if !ok {
+		return nil, fmt.Errorf("%w: degenerate result points", ErrFormat)
+	}
+	pose := poseFromHomography(h)
+	refinePose(pose, model, image, camera)
+
+	var sum float64
+	for i, m := range model {
+		x, y, z := pose.apply(m)
+		if z <= 0 {
+			return nil, fmt.Errorf("%w: symbol is behind the camera", ErrFormat)
+		}
+		dx := camera.Fx * (x/z - image[i].X)
+		dy := camera.Fy * (y/z - image[i].Y)
+		sum += dx*dx + dy*dy
+	}
+	pose.ReprojectionError = math.Sqrt(sum / float64(n))
+	return pose, nil
+}
+
+// poseModelPoints returns the positions, in millimetres in the symbol frame,
+// of the result points of r.
+func poseModelPoints(r *Result, symbolSizeMM float64) ([]ResultPoint, error) {
+	modules, cols, rows, err := symbolModulePoints(r)
+	if err != nil {
+		return nil, err
+	}
+	moduleSize := symbolSizeMM / cols
+	model := make([]ResultPoint, len(modules))
+	for i, m := range modules {
+		model[i] = ResultPoint{X: (m.X - cols/2) * moduleSize, Y: (m.Y - rows/2) * moduleSize}
+	}
+	return model, nil
+}
+
+// symbolModulePoints returns the module coordinates of the result points of
+// r, measured from the top-left corner of the symbol, and the symbol's size
+// in modules.
+func symbolModulePoints(r *Result) (_ []ResultPoint, cols, rows float64, _ error) {
+	dim, ok := r.Metadata[MetadataSymbolDimension].([2]int)
+	if !ok || dim[0] <= 0 || dim[1] <= 0 {
+		return nil, 0, 0, fmt.Errorf("%w: result has no symbol dimension", ErrFormat)
+	}
+	cols, rows = float64(dim[0]), float64(dim[1])
+	var modules []ResultPoint
+	switch r.Format {
+	case FormatQRCode:
+		// Finder pattern centers (bottom-left, top-left, top-right), then the
+		// bottom-right alignment pattern if present.
+		modules = []ResultPoint{{X: 3.5, Y: rows - 3.5}, {X: 3.5, Y: 3.5}, {X: cols - 3.5, Y: 3.5}}
+		if len(r.Points) >= 4 {
+			modules = append(modules, ResultPoint{X: cols - 6.5, Y: rows - 6.5})
+		}
+	case FormatDataMatrix:
+		// Centers of the corner modules: top-left, bottom-left, bottom-right,
+		// top-right.
+		modules = []ResultPoint{{X: 0.5, Y: 0.5}, {X: 0.5, Y: rows - 0.5}, {X: cols - 0.5, Y: rows - 0.5}, {X: cols - 0.5, Y: 0.5}}
+	default:
+		return nil, 0, 0, fmt.Errorf("%w: symbol geometry is not known for %v", ErrFormat, r.Format)
+	}
+	if len(r.Points) < len(modules) {
+		return nil, 0, 0, fmt.Errorf("%w: result has too few points", ErrFormat)
+	}
+	return modules, cols, rows, nil
+}
+
+// homography computes the 3x3 matrix, with h[2][2] = 1, mapping the four src
+// points onto dst.
+func homography(src, dst []ResultPoint) ([3][3]float64, bool) {
+	var h [3][3]float64
+	a := make([][]float64, 8)
+	b := make([]float64, 8)
+	for i := 0; i < 4; i++ {
+		x, y, u, v := src[i].X, src[i].Y, dst[i].X, dst[i].Y
+		a[2*i] = []float64{x, y, 1, 0, 0, 0, -u * x, -u * y}
+		a[2*i+1] = []float64{0, 0, 0, x, y, 1, -v * x, -v * y}
+		b[2*i], b[2*i+1] = u, v
+	}
+	sol, ok := solveLinear(a, b)
+	if !ok {
+		return h, false
+	}
+	h = [3][3]float64{{sol[0], sol[1], sol[2]}, {sol[3], sol[4], sol[5]}, {sol[6], sol[7], 1}}
+	return h, true
+}
+
+// poseFromHomography decomposes a homography from the symbol plane to
+// normalized image coordinates into a rotation and translation.
+func poseFromHomography(h [3][3]float64) *Pose {
+	h1 := [3]float64{h[0][0], h[1][0], h[2][0]}
+	h2 := [3]float64{h[0][1], h[1][1], h[2][1]}
+	h3 := [3]float64{h[0][2], h[1][2], h[2][2]}
+	scale := 2 / (norm3(h1) + norm3(h2))
+	if h3[2] < 0 {
+		// The symbol must be in front of the camera.
+		scale = -scale
+	}
+	r1 := normalize3(scale3(h1, scale))
+	r2 := scale3(h2, scale)
+	r2 = normalize3(sub3(r2, scale3(r1, dot3(r1, r2))))
+	r3 := cross3(r1, r2)
+	pose := &Pose{Translation: scale3(h3, scale)}
+	for i := 0; i < 3; i++ {
+		pose.Rotation[i] = [3]float64{r1[i], r2[i], r3[i]}
+	}
+	return pose
+}
+
+// refinePose minimizes the reprojection error of the model points with
+// Gauss-Newton iterations, updating the rotation by a small rotation vector
+// on each step.
+func refinePose(pose *Pose, model, image []ResultPoint, camera CameraMatrix) {
+	for iter := 0; iter < poseMaxIterations; iter++ {
+		var jtj [6][6]float64
+		var jtr [6]float64
+		for i, m := range model {
+			x, y, z := pose.apply(m)
+			if z <= 0 {
+				return
+			}
+			// Derivatives of the camera-frame point with respect to the
+			// rotation vector are −[R·m]×; with respect to the translation
+			// they are the identity.
+			rx, ry, rz := x-pose.Translation[0], y-pose.Translation[1], z-pose.Translation[2]
+			dp := [3][6]float64{
+				{0, rz, -ry, 1, 0, 0},
+				{-rz, 0, rx, 0, 1, 0},
+				{ry, -rx, 0, 0, 0, 1},
+			}
+			ju := [6]float64{}
+			jv := [6]float64{}
+			for k := 0; k < 6; k++ {
+				ju[k] = camera.Fx * (dp[0][k]/z - x*dp[2][k]/(z*z))
+				jv[k] = camera.Fy * (dp[1][k]/z - y*dp[2][k]/(z*z))
+			}
+			ru := camera.Fx * (x/z - image[i].X)
+			rv := camera.Fy * (y/z - image[i].Y)
+			for j := 0; j < 6; j++ {
+				jtr[j] += ju[j]*ru + jv[j]*rv
+				for k := 0; k < 6; k++ {
+					jtj[j][k] += ju[j]*ju[k] + jv[j]*jv[k]
+				}
+			}
+		}
+		a := make([][]float64, 6)
+		b := make([]float64, 6)
+		for j := 0; j < 6; j++ {
+			a[j] = jtj[j][:]
+			// A little damping keeps the exactly determined three-point case
+			// well conditioned.
+			a[j][j] *= 1 + 1e-6
+			b[j] = -jtr[j]
+		}
+		delta, ok := solveLinear(a, b)
+		if !ok {
+			return
+		}
+		pose.Rotation = matMul3(rodrigues([3]float64{delta[0], delta[1], delta[2]}), pose.Rotation)
+		for k := 0; k < 3; k++ {
+			pose.Translation[k] += delta[3+k]
+		}
+		var step float64
+		for _, d := range delta {
+			step += d * d
+		}
+		if step < poseEpsilon {
+			return
+		}
+	}
+}
+
+// apply transforms a point in the symbol plane into the camera frame.
+func (p *Pose) apply(m ResultPoint) (x, y, z float64) {
+	r, t := &p.Rotation, &p.Translation
+	x = r[0][0]*m.X + r[0][1]*m.Y + t[0]
+	y = r[1][0]*m.X + r[1][1]*m.Y + t[1]
+	z = r[2][0]*m.X + r[2][1]*m.Y + t[2]
+	return x, y, z
+}
+
+// rodrigues converts a rotation vector into a rotation matrix.
+func rodrigues(w [3]float64) [3][3]float64 {
+	theta := norm3(w)
+	if theta < 1e-12 {
+		return [3][3]float64{{1, -w[2], w[1]}, {w[2], 1, -w[0]}, {-w[1], w[0], 1}}
+	}
+	k := scale3(w, 1/theta)
+	s, c := math.Sin(theta), 1-math.Cos(theta)
+	return [3][3]float64{
+		{1 - c*(k[1]*k[1]+k[2]*k[2]), -s*k[2] + c*k[0]*k[1], s*k[1] + c*k[0]*k[2]},
+		{s*k[2] + c*k[0]*k[1], 1 - c*(k[0]*k[0]+k[2]*k[2]), -s*k[0] + c*k[1]*k[2]},
+		{-s*k[1] + c*k[0]*k[2], s*k[0] + c*k[1]*k[2], 1 - c*(k[0]*k[0]+k[1]*k[1])},
+	}
+}
+
+// solveLinear solves a·x = b by Gaussian elimination with partial pivoting.
+// a and b are modified.
+func solveLinear(a [][]float64, b []float64) ([]float64, bool) {
+	n := len(b)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+		for row := col + 1; row < n; row++ {
+			f := a[row][col] / a[col][col]
+			for k := col; k < n; k++ {
+				a[row][k] -= f * a[col][k]
+			}
+			b[row] -= f * b[col]
+		}
+	}
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		sum := b[row]
+		for k := row + 1; k < n; k++ {
+			sum -= a[row][k] * x[k]
+		}
+		x[row] = sum / a[row][row]
+	}
+	return x, true
+}
+
+func matMul3(a, b [3][3]float64) [3][3]float64 {
+	var c [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			c[i][j] = a[i][0]*b[0][j] + a[i][1]*b[1][j] + a[i][2]*b[2][j]
+		}
+	}
+	return c
+}
+
+func dot3(a, b [3]float64) float64 { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+
+func norm3(a [3]float64) float64 { return math.Sqrt(dot3(a, a)) }
+
+func scale3(a [3]float64, s float64) [3]float64 { return [3]float64{a[0] * s, a[1] * s, a[2] * s} }
+
+func sub3(a, b [3]float64) [3]float64 { return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]} }
+
+func normalize3(a [3]float64) [3]float64 { return scale3(a, 1/norm3(a)) }
+
+func cross3(a, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
@@ -0,0 +1,93 @@
+package zxinggo
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// projectPoints builds a result whose points are the projections of the
+// model points of format under the given pose.
+func projectPoints(t *testing.T, format Format, dim [2]int, nPoints int, pose *Pose, camera CameraMatrix, size float64) *Result {
+	t.Helper()
+	r := NewResult("", nil, make([]ResultPoint, nPoints), format)
+	r.PutMetadata(MetadataSymbolDimension, dim)
+	model, err := poseModelPoints(r, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range model {
+		x, y, z := pose.apply(m)
+		r.Points[i] = ResultPoint{X: camera.Fx*x/z + camera.Cx, Y: camera.Fy*y/z + camera.Cy}
+	}
+	return r
+}
+
+func TestEstimatePose(t *testing.T) {
+	camera := CameraMatrix{Fx: 800, Fy: 800, Cx: 320, Cy: 240}
+	want := &Pose{
+		Rotation:    rodrigues([3]float64{0.3, -0.2, 0.1}),
+		Translation: [3]float64{10, -5, 300},
+	}
+	tests := []struct {
+		name    string
+		format  Format
+		dim     [2]int
+		nPoints int
+		// Three points do not determine the rotation uniquely.
+		ambiguous bool
+	}{
+		{"QR with alignment pattern", FormatQRCode, [2]int{25, 25}, 4, false},
+		{"QR version 1", FormatQRCode, [2]int{21, 21}, 3, true},
+		{"Data Matrix", FormatDataMatrix, [2]int{18, 18}, 4, false},
+		{"rectangular Data Matrix", FormatDataMatrix, [2]int{32, 8}, 4, false},
+	}
+	for _, tt := range tests {
+		r := projectPoints(t, tt.format, tt.dim, tt.nPoints, want, camera, 40)
+		got, err := EstimatePose(r, camera, 40)
+		if err != nil {
+			t.Errorf("%s: EstimatePose: %v", tt.name, err)
+			continue
+		}
+		tolerance := 1e-3
+		if tt.ambiguous {
+			tolerance = 1
+		}
+		for i := 0; i < 3; i++ {
+			if math.Abs(got.Translation[i]-want.Translation[i]) > tolerance {
+				t.Errorf("%s: Translation = %v, want %v", tt.name, got.Translation, want.Translation)
+				break
+			}
+		}
+		for i := 0; i < 3 && !tt.ambiguous; i++ {
+			for j := 0; j < 3; j++ {
+				if math.Abs(got.Rotation[i][j]-want.Rotation[i][j]) > 1e-4 {
+					t.Errorf("%s: Rotation = %v, want %v", tt.name, got.Rotation, want.Rotation)
+					i, j = 3, 3
+				}
+			}
+		}
+		if got.ReprojectionError > 1e-3 {
+			t.Errorf("%s: ReprojectionError = %v", tt.name, got.ReprojectionError)
+		}
+	}
+}
+
+func TestEstimatePoseErrors(t *testing.T) {
+	camera := CameraMatrix{Fx: 800, Fy: 800, Cx: 320, Cy: 240}
+	r := NewResult("", nil, []ResultPoint{{X: 1, Y: 1}, {X: 2, Y: 2}}, FormatCode128)
+	if _, err := EstimatePose(r, camera, 40); !errors.Is(err, ErrFormat) {
+		t.Errorf("EstimatePose without dimension: got %v, want ErrFormat", err)
+	}
+	r.PutMetadata(MetadataSymbolDimension, [2]int{21, 21})
+	if _, err := EstimatePose(r, camera, 40); !errors.Is(err, ErrFormat) {
+		t.Errorf("EstimatePose for Code 128: got %v, want ErrFormat", err)
+	}
+	r.Format = FormatQRCode
+	if _, err := EstimatePose(r, camera, 40); !errors.Is(err, ErrFormat) {
+		t.Errorf("EstimatePose with two points: got %v, want ErrFormat", err)
+	}
+	if _, err := EstimatePose(r, CameraMatrix{}, 40); !errors.Is(err, ErrFormat) {
+		t.Errorf("EstimatePose with zero focal length: got %v, want ErrFormat", err)
+	}
+}
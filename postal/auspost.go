@@ -0,0 +1,159 @@
+package postal
+
+import (
+	"fmt"
+	"strings"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/oned"
+	"github.com/ericlevine/zxinggo/reedsolomon"
+)
+
+// Australia Post bars are numbered 0 (full), 1 (ascender), 2 (descender)
+// and 3 (tracker).
+var ausPostBarValues = [4]int{
+	oned.FourStateFull:      0,
+	oned.FourStateAscender:  1,
+	oned.FourStateDescender: 2,
+	oned.FourStateTracker:   3,
+}
+
+// ausPostNTable holds the bar pairs of the digits 0-9, each as 4*first+second.
+var ausPostNTable = [10]int{0x0, 0x1, 0x2, 0x4, 0x5, 0x6, 0x8, 0x9, 0xA, 0xC}
+
+// ausPostCAlphabet holds the characters of the C encoding table in the
+// order of ausPostCTable.
+const ausPostCAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz #"
+
+// ausPostCTable holds the bar triples of the C table characters, each as
+// 16*first+4*second+third.
+var ausPostCTable = [64]int{
+	0x2A, 0x30, 0x31, 0x32, 0x34, 0x35, 0x36, 0x38, 0x39, 0x3A,
+	0x00, 0x01, 0x02, 0x04, 0x05, 0x06, 0x08, 0x09, 0x0A, 0x10, 0x11, 0x12, 0x14,
+	0x15, 0x16, 0x18, 0x19, 0x1A, 0x20, 0x21, 0x22, 0x24, 0x25, 0x26, 0x28, 0x29,
+	0x0B, 0x0C, 0x0D, 0x0E, 0x0F, 0x13, 0x17, 0x1B, 0x1C, 0x1D, 0x1E, 0x1F, 0x23,
+	0x27, 0x2B, 0x2C, 0x2D, 0x2E, 0x2F, 0x33, 0x37, 0x3B, 0x3C, 0x3D, 0x3E, 0x3F,
+	0x03, 0x07,
+}
+
+// ausPostLengths maps each format control code to its symbol's bar count.
+var ausPostLengths = map[int]int{
+	11: 37, // Standard Customer Barcode
+	45: 37, // Reply Paid Barcode
+	87: 37, // Routing Barcode
+	92: 37, // Redirection Barcode
+	59: 52, // Customer Barcode 2
+	62: 67, // Customer Barcode 3
+}
+
+// ausPostParityBars is the number of bars holding the four Reed-Solomon
+// parity symbols.
+const ausPostParityBars = 12
+
+// NewAustraliaPostReader creates a reader for Australia Post customer
+// barcodes. The text is the two digit format control code, the eight digit
+// delivery point identifier, and any customer information. Customer
+// information is read with the N table as digits if it can be, and
+// otherwise with the C table.
+func NewAustraliaPostReader() *Reader {
+	return &Reader{format: zxinggo.FormatAustraliaPost, minBars: 37, decode: decodeAustraliaPost}
+}
+
+func decodeAustraliaPost(states []oned.FourState) (string, error) {
+	n := len(states)
+	if n != 37 && n != 52 && n != 67 {
+		return "", fmt.Errorf("%w: not an Australia Post symbol", zxinggo.ErrNotFound)
+	}
+	bars := make([]int, n)
+	for i, s := range states {
+		bars[i] = ausPostBarValues[s]
+	}
+	// Start and stop are both an ascender and a tracker.
+	if bars[0] != 1 || bars[1] != 3 || bars[n-2] != 1 || bars[n-1] != 3 {
+		return "", fmt.Errorf("%w: not an Australia Post symbol", zxinggo.ErrNotFound)
+	}
+
+	// Error correction works on triples of bars, data then parity.
+	inner := bars[2 : n-2]
+	symbols := make([]int, len(inner)/3)
+	for i := range symbols {
+		symbols[i] = inner[3*i]<<4 | inner[3*i+1]<<2 | inner[3*i+2]
+	}
+	if _, err := reedsolomon.NewDecoder(reedsolomon.AustraliaPostField64).Decode(symbols, 4); err != nil {
+		return "", err
+	}
+	for i, s := range symbols {
+		inner[3*i], inner[3*i+1], inner[3*i+2] = s>>4, s>>2&3, s&3
+	}
+	data := inner[:len(inner)-ausPostParityBars]
+
+	fcc, ok := decodeAusPostN(data[:4])
+	if !ok || ausPostLengths[atoi(fcc)] != n {
+		return "", fmt.Errorf("%w: invalid Australia Post format control code", zxinggo.ErrFormat)
+	}
+	dpid, ok := decodeAusPostN(data[4:20])
+	if !ok {
+		return "", fmt.Errorf("%w: invalid Australia Post delivery point identifier", zxinggo.ErrFormat)
+	}
+	customer, err := decodeAusPostCustomer(data[20:])
+	if err != nil {
+		return "", err
+	}
+	return fcc + dpid + customer, nil
+}
+
+// decodeAusPostCustomer decodes the customer information field. Unused bars
+// at its end are filled with trackers, so a final C table character made of
+// trackers alone ('z') cannot be told from filler and is lost.
+func decodeAusPostCustomer(bars []int) (string, error) {
+	end := len(bars)
+	for end > 0 && bars[end-1] == 3 {
+		end--
+	}
+	if end%2 == 0 {
+		if digits, ok := decodeAusPostN(bars[:end]); ok {
+			return digits, nil
+		}
+	}
+
+	// A C table character may end with trackers itself.
+	end = (end + 2) / 3 * 3
+	var text strings.Builder
+	for i := 0; i < end; i += 3 {
+		triple := bars[i]<<4 | bars[i+1]<<2 | bars[i+2]
+		j := 0
+		for j < len(ausPostCTable) && ausPostCTable[j] != triple {
+			j++
+		}
+		if j == len(ausPostCTable) {
+			return "", fmt.Errorf("%w: invalid Australia Post customer information", zxinggo.ErrFormat)
+		}
+		text.WriteByte(ausPostCAlphabet[j])
+	}
+	return text.String(), nil
+}
+
+// decodeAusPostN decodes pairs of bars as digits.
+func decodeAusPostN(bars []int) (string, bool) {
+	var digits strings.Builder
+	for i := 0; i+1 < len(bars); i += 2 {
+		pair := bars[i]<<2 | bars[i+1]
+		digit := 0
+		for digit < len(ausPostNTable) && ausPostNTable[digit] != pair {
+			digit++
+		}
+		if digit == len(ausPostNTable) {
+			return "", false
+		}
+		digits.WriteByte(byte('0' + digit))
+	}
+	return digits.String(), true
+}
+
+func atoi(digits string) int {
+	n := 0
+	for _, d := range digits {
+		n = 10*n + int(d-'0')
+	}
+	return n
+}
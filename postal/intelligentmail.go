@@ -0,0 +1,201 @@
+package postal
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+	"strings"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/oned"
+)
+
+const (
+	imbBars       = 65
+	imbCharacters = 10
+)
+
+// imbBarMap gives, for bit j of character i at index 13*i+j, the bar
+// element it sets: 1 to 65 are the descenders of bars 1 to 65 and 66 to 130
+// their ascenders (USPS-B-3200, Appendix D).
+var imbBarMap = [130]int{
+	67, 6, 78, 16, 86, 95, 34, 40, 45, 113, 117, 121, 62, 87, 18, 104, 41, 76, 57, 119, 115, 72, 97,
+	2, 127, 26, 105, 35, 122, 52, 114, 7, 24, 82, 68, 63, 94, 44, 77, 112, 70, 100, 39, 30, 107,
+	15, 125, 85, 10, 65, 54, 88, 20, 106, 46, 66, 8, 116, 29, 61, 99, 80, 90, 37, 123, 51, 25, 84,
+	129, 56, 4, 109, 96, 28, 36, 47, 11, 71, 33, 102, 21, 9, 17, 49, 124, 79, 64, 91, 42, 69, 53,
+	60, 14, 1, 27, 103, 126, 75, 89, 50, 120, 19, 32, 110, 92, 111, 130, 59, 31, 12, 81, 43, 55,
+	5, 74, 22, 101, 128, 58, 118, 48, 108, 38, 98, 93, 23, 83, 13, 73, 3,
+}
+
+// imbTable5 and imbTable2 map codewords to 13-bit characters with five and
+// two bits set; codewords 0-1286 use the first and 1287-1364 the second.
+var (
+	imbTable5 = buildNOf13Table(5, 1287)
+	imbTable2 = buildNOf13Table(2, 78)
+)
+
+// imbCodewords maps each 13-bit character to its codeword, and -1 for
+// characters that are not codewords.
+var imbCodewords = func() [8192]int {
+	var codewords [8192]int
+	for i := range codewords {
+		codewords[i] = -1
+	}
+	for cw, c := range imbTable5 {
+		codewords[c] = cw
+	}
+	for cw, c := range imbTable2 {
+		codewords[c] = len(imbTable5) + cw
+	}
+	return codewords
+}()
+
+// buildNOf13Table lists the 13-bit values with n bits set, as USPS-B-3200
+// orders them: pairs of a value and its bit reversal from the start, and
+// values that are their own reversal from the end.
+func buildNOf13Table(n, length int) []int {
+	table := make([]int, length)
+	lower, upper := 0, length-1
+	for c := 0; c < 8192; c++ {
+		if bits.OnesCount(uint(c)) != n {
+			continue
+		}
+		reverse := int(bits.Reverse16(uint16(c)) >> 3)
+		switch {
+		case reverse < c:
+		case reverse == c:
+			table[upper] = c
+			upper--
+		default:
+			table[lower] = c
+			table[lower+1] = reverse
+			lower += 2
+		}
+	}
+	return table
+}
+
+// NewIntelligentMailReader creates a reader for USPS Intelligent Mail
+// barcodes. The text is the 20 digit tracking code followed by the routing
+// (ZIP) code of 0, 5, 9 or 11 digits.
+func NewIntelligentMailReader() *Reader {
+	return &Reader{format: zxinggo.FormatIntelligentMail, minBars: imbBars, decode: decodeIntelligentMail}
+}
+
+func decodeIntelligentMail(bars []oned.FourState) (string, error) {
+	if len(bars) != imbBars {
+		return "", fmt.Errorf("%w: not an Intelligent Mail symbol", zxinggo.ErrNotFound)
+	}
+
+	// Gather the characters' bits from the bars.
+	var characters [imbCharacters]int
+	for i, element := range imbBarMap {
+		bar, part := (element-1)%imbBars, oned.FourStateDescender
+		if element > imbBars {
+			part = oned.FourStateAscender
+		}
+		if bars[bar]&part != 0 {
+			characters[i/13] |= 1 << uint(i%13)
+		}
+	}
+
+	// Characters appear inverted for the bits of the frame check sequence
+	// that are set.
+	var codewords [imbCharacters]int
+	fcs := 0
+	for i, c := range characters {
+		switch bits.OnesCount(uint(c)) {
+		case 8, 11:
+			c ^= 0x1FFF
+			fcs |= 1 << uint(i)
+		}
+		codewords[i] = imbCodewords[c]
+		if codewords[i] < 0 {
+			return "", fmt.Errorf("%w: invalid Intelligent Mail character", zxinggo.ErrFormat)
+		}
+	}
+	// The last bit of the sequence is in codeword A, and codeword J is
+	// doubled to check the orientation.
+	if codewords[0] >= 659 {
+		codewords[0] -= 659
+		fcs |= 1 << 10
+	}
+	if codewords[9]%2 != 0 {
+		return "", fmt.Errorf("%w: Intelligent Mail symbol upside down", zxinggo.ErrFormat)
+	}
+	codewords[9] /= 2
+	if codewords[0] >= 659 || codewords[9] >= 636 {
+		return "", fmt.Errorf("%w: invalid Intelligent Mail codeword", zxinggo.ErrFormat)
+	}
+
+	value := big.NewInt(int64(codewords[0]))
+	for _, cw := range codewords[1:9] {
+		value.Mul(value, big.NewInt(1365))
+		value.Add(value, big.NewInt(int64(cw)))
+	}
+	value.Mul(value, big.NewInt(636))
+	value.Add(value, big.NewInt(int64(codewords[9])))
+
+	var data [13]byte
+	if len(value.Bytes()) > len(data) {
+		return "", fmt.Errorf("%w: invalid Intelligent Mail data", zxinggo.ErrFormat)
+	}
+	value.FillBytes(data[:])
+	if data[0] > 0x3F || imbFrameCheckSequence(data) != fcs {
+		return "", zxinggo.ErrChecksum
+	}
+
+	// The value is the routing code, then the tracking code's first digit,
+	// its second digit (0-4) and its last 18 digits.
+	var tracking [20]byte
+	digit := new(big.Int)
+	for i := 19; i >= 0; i-- {
+		base := int64(10)
+		if i == 1 {
+			base = 5
+		}
+		value.DivMod(value, big.NewInt(base), digit)
+		tracking[i] = byte('0' + digit.Int64())
+	}
+	if !value.IsInt64() {
+		return "", fmt.Errorf("%w: invalid Intelligent Mail routing code", zxinggo.ErrFormat)
+	}
+	var text strings.Builder
+	text.Write(tracking[:])
+	switch routing := value.Int64(); {
+	case routing == 0:
+	case routing <= 100000:
+		fmt.Fprintf(&text, "%05d", routing-1)
+	case routing <= 1000100000:
+		fmt.Fprintf(&text, "%09d", routing-100001)
+	case routing <= 101000100000:
+		fmt.Fprintf(&text, "%011d", routing-1000100001)
+	default:
+		return "", fmt.Errorf("%w: invalid Intelligent Mail routing code", zxinggo.ErrFormat)
+	}
+	return text.String(), nil
+}
+
+// imbFrameCheckSequence computes the 11-bit CRC of the 102-bit data, which
+// is right aligned in 13 bytes.
+func imbFrameCheckSequence(data [13]byte) int {
+	const generator = 0x0F35
+	fcs := 0x07FF
+	for i, b := range data {
+		n := 8
+		if i == 0 {
+			// Skip the two unused bits.
+			n = 6
+		}
+		d := int(b) << 3
+		for bit := 8 - n; bit < 8; bit++ {
+			if (fcs^d<<uint(bit))&0x400 != 0 {
+				fcs = fcs<<1 ^ generator
+			} else {
+				fcs <<= 1
+			}
+			fcs &= 0x7FF
+		}
+	}
+	return fcs
+}
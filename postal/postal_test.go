@@ -0,0 +1,261 @@
+package postal
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+	"github.com/ericlevine/zxinggo/oned"
+	"github.com/ericlevine/zxinggo/reedsolomon"
+)
+
+func mustParse(t *testing.T, s string) []oned.FourState {
+	t.Helper()
+	bars, ok := oned.ParseFourState(s)
+	if !ok {
+		t.Fatalf("bad bar string %q", s)
+	}
+	return bars
+}
+
+// render draws bars two pixels wide and two apart. Full bars are 24 pixels
+// high and the tracker band is the middle 8.
+func render(bars []oned.FourState) *zxinggo.BinaryBitmap {
+	width, height := 4*len(bars)+40, 64
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	for i, bar := range bars {
+		top, bottom := 28, 36
+		if bar&oned.FourStateAscender != 0 {
+			top = 20
+		}
+		if bar&oned.FourStateDescender != 0 {
+			bottom = 44
+		}
+		for y := top; y < bottom; y++ {
+			img.SetGray(20+4*i, y, color.Gray{})
+			img.SetGray(21+4*i, y, color.Gray{})
+		}
+	}
+	return zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(zxinggo.NewGrayImageLuminanceSource(img)))
+}
+
+// encodeRM4SCC returns the bars of an RM4SCC or, without start, stop and
+// check character, a KIX symbol.
+func encodeRM4SCC(text string, kix bool) []oned.FourState {
+	var bars []oned.FourState
+	character := func(row, col int) {
+		for bit := 3; bit >= 0; bit-- {
+			var bar oned.FourState
+			if rm4sccHalves[row]>>uint(bit)&1 != 0 {
+				bar |= oned.FourStateAscender
+			}
+			if rm4sccHalves[col]>>uint(bit)&1 != 0 {
+				bar |= oned.FourStateDescender
+			}
+			bars = append(bars, bar)
+		}
+	}
+	if !kix {
+		bars = append(bars, oned.FourStateAscender)
+	}
+	rowSum, colSum := 0, 0
+	for _, c := range text {
+		i := strings.IndexRune(rm4sccAlphabet, c)
+		character(i/6, i%6)
+		rowSum += i/6 + 1
+		colSum += i%6 + 1
+	}
+	if !kix {
+		character((rowSum+5)%6, (colSum+5)%6)
+		bars = append(bars, oned.FourStateFull)
+	}
+	return bars
+}
+
+// encodeAustraliaPost returns the bars of an Australia Post symbol with the
+// given customer information bars, filled out with trackers.
+func encodeAustraliaPost(fcc, dpid string, customer []int) []oned.FourState {
+	bars := append([]int{1, 3}, ausPostN(fcc+dpid)...)
+	bars = append(bars, customer...)
+	for len(bars) < ausPostLengths[atoi(fcc)]-ausPostParityBars-2 {
+		bars = append(bars, 3)
+	}
+	symbols := make([]int, (len(bars)-2)/3+4)
+	for i := 0; i < len(symbols)-4; i++ {
+		symbols[i] = bars[2+3*i]<<4 | bars[3+3*i]<<2 | bars[4+3*i]
+	}
+	reedsolomon.NewEncoder(reedsolomon.AustraliaPostField64).Encode(symbols, 4)
+	for _, s := range symbols[len(symbols)-4:] {
+		bars = append(bars, s>>4, s>>2&3, s&3)
+	}
+	bars = append(bars, 1, 3)
+
+	states := make([]oned.FourState, len(bars))
+	for i, b := range bars {
+		for s, v := range ausPostBarValues {
+			if v == b {
+				states[i] = oned.FourState(s)
+			}
+		}
+	}
+	return states
+}
+
+func ausPostC(text string) []int {
+	var bars []int
+	for _, c := range text {
+		triple := ausPostCTable[strings.IndexRune(ausPostCAlphabet, c)]
+		bars = append(bars, triple>>4, triple>>2&3, triple&3)
+	}
+	return bars
+}
+
+func ausPostN(digits string) []int {
+	var bars []int
+	for _, d := range digits {
+		pair := ausPostNTable[d-'0']
+		bars = append(bars, pair>>2, pair&3)
+	}
+	return bars
+}
+
+func TestIntelligentMailSpecExamples(t *testing.T) {
+	// Examples from USPS-B-3200, Appendix C.
+	tests := []struct{ bars, want string }{
+		{"ATTFATTDTTADTAATTDTDTATTDAFDDFADFDFTFFFFFTATFAAAATDFFTDAADFTFDTDT", "01234567094987654321"},
+		{"DTTAFADDTTFTDTFTFDTDDADADAFADFATDDFTAAAFDTTADFAAATDFDTDFADDDTDFFT", "0123456709498765432101234"},
+		{"ADFTTAFDTTTTFATTADTAAATFTFTATDAAAFDDADATATDTDTTDFDTDATADADTDFFTFA", "01234567094987654321012345678"},
+		{"AADTFFDFTDADTAADAATFDTDDAAADDTDTTDAFADADDDTFFFDDTTTADFAAADFTDAADA", "0123456709498765432101234567891"},
+	}
+	reader := NewIntelligentMailReader()
+	for _, tt := range tests {
+		result, err := reader.DecodeBars(mustParse(t, tt.bars))
+		if err != nil {
+			t.Errorf("%s: decode error: %v", tt.want, err)
+			continue
+		}
+		if result.Text != tt.want {
+			t.Errorf("text = %q, want %q", result.Text, tt.want)
+		}
+	}
+}
+
+func TestIntelligentMailImage(t *testing.T) {
+	bars := mustParse(t, "AADTFFDFTDADTAADAATFDTDDAAADDTDTTDAFADADDDTFFFDDTTTADFAAADFTDAADA")
+	want := "0123456709498765432101234567891"
+	for _, upsideDown := range []bool{false, true} {
+		b := bars
+		if upsideDown {
+			b = (&oned.FourStateRow{Bars: bars}).Rotated()
+		}
+		result, err := NewIntelligentMailReader().Decode(render(b), nil)
+		if err != nil {
+			t.Fatalf("upside down %v: decode error: %v", upsideDown, err)
+		}
+		if result.Text != want {
+			t.Errorf("upside down %v: text = %q, want %q", upsideDown, result.Text, want)
+		}
+		if o := result.Metadata[zxinggo.MetadataOrientation]; o != map[bool]int{false: 0, true: 180}[upsideDown] {
+			t.Errorf("upside down %v: orientation = %v", upsideDown, o)
+		}
+	}
+}
+
+func TestIntelligentMailChecksum(t *testing.T) {
+	bars := mustParse(t, "ATTFATTDTTADTAATTDTDTATTDAFDDFADFDFTFFFFFTATFAAAATDFFTDAADFTFDTDT")
+	bars[10] ^= oned.FourStateAscender
+	if _, err := NewIntelligentMailReader().DecodeBars(bars); err == nil {
+		t.Error("corrupted symbol decoded")
+	}
+}
+
+func TestRM4SCC(t *testing.T) {
+	bars := encodeRM4SCC("SN34RD1A", false)
+	result, err := NewRM4SCCReader().Decode(render(bars), nil)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if result.Text != "SN34RD1A" {
+		t.Errorf("text = %q, want \"SN34RD1A\"", result.Text)
+	}
+
+	rotated, err := NewRM4SCCReader().Decode(render((&oned.FourStateRow{Bars: bars}).Rotated()), nil)
+	if err != nil || rotated.Text != "SN34RD1A" {
+		t.Errorf("upside down: text = %v, err = %v", rotated, err)
+	}
+
+	// Swap two characters' bars: the check character no longer matches.
+	swapped := append([]oned.FourState(nil), bars...)
+	copy(swapped[1:5], bars[5:9])
+	copy(swapped[5:9], bars[1:5])
+	swapped[1], swapped[2] = swapped[2], swapped[1]
+	if _, err := NewRM4SCCReader().DecodeBars(swapped); !errors.Is(err, zxinggo.ErrChecksum) && !errors.Is(err, zxinggo.ErrFormat) {
+		t.Errorf("err = %v, want checksum or format error", err)
+	}
+}
+
+func TestKIX(t *testing.T) {
+	result, err := NewKIXReader().Decode(render(encodeRM4SCC("2500GG30250", true)), nil)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if result.Text != "2500GG30250" {
+		t.Errorf("text = %q, want \"2500GG30250\"", result.Text)
+	}
+}
+
+func TestAustraliaPost(t *testing.T) {
+	tests := []struct {
+		name     string
+		bars     []oned.FourState
+		wantText string
+	}{
+		{"standard", encodeAustraliaPost("11", "39987520", nil), "1139987520"},
+		{"numeric", encodeAustraliaPost("59", "32211324", ausPostN("12345678")), "593221132412345678"},
+		{"short numeric", encodeAustraliaPost("59", "32211324", ausPostN("123")), "5932211324123"},
+		{"characters", encodeAustraliaPost("62", "56439111", ausPostC("ABA 9#a")), "6256439111ABA 9#a"},
+	}
+	for _, tt := range tests {
+		result, err := NewAustraliaPostReader().Decode(render(tt.bars), nil)
+		if err != nil {
+			t.Errorf("%s: decode error: %v", tt.name, err)
+			continue
+		}
+		if result.Text != tt.wantText {
+			t.Errorf("%s: text = %q, want %q", tt.name, result.Text, tt.wantText)
+		}
+	}
+}
+
+func TestAustraliaPostErrorCorrection(t *testing.T) {
+	bars := encodeAustraliaPost("11", "39987520", nil)
+	bars[8] ^= oned.FourStateFull
+	bars[9] ^= oned.FourStateAscender
+	result, err := NewAustraliaPostReader().DecodeBars(bars)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if result.Text != "1139987520" {
+		t.Errorf("text = %q, want \"1139987520\"", result.Text)
+	}
+}
+
+func TestNotRequested(t *testing.T) {
+	image := render(encodeRM4SCC("SN34RD1A", false))
+	if _, err := zxinggo.Decode(image, nil); !errors.Is(err, zxinggo.ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+	opts := &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{zxinggo.FormatRM4SCC}}
+	if result, err := zxinggo.Decode(image, opts); err != nil || result.Format != zxinggo.FormatRM4SCC {
+		t.Errorf("result = %v, err = %v", result, err)
+	}
+}
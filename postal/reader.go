@@ -0,0 +1,73 @@
+// Package postal provides reading of 4-state postal barcodes: Royal Mail
+// 4-State Customer Code (RM4SCC), Dutch KIX Code, Australia Post customer
+// barcodes and USPS Intelligent Mail.
+//
+// These symbologies encode data in bar heights, which are read with
+// oned.FindFourStateBars. The symbol must lie roughly horizontally; it may be
+// upside down. The registered readers only run when their format is among
+// the requested formats, since KIX Code in particular has no check
+// character.
+package postal
+
+import (
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/oned"
+)
+
+// Reader decodes one 4-state postal symbology.
+type Reader struct {
+	format  zxinggo.Format
+	minBars int
+	decode  func(bars []oned.FourState) (string, error)
+
+	// disabled makes the registered reader find nothing unless its format
+	// was requested.
+	disabled bool
+}
+
+// Decode locates and decodes a 4-state barcode in the given image.
+func (r *Reader) Decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	if r.disabled {
+		return nil, zxinggo.ErrNotFound
+	}
+	matrix, err := image.BlackMatrix()
+	if err != nil {
+		return nil, err
+	}
+	row, err := oned.FindFourStateBars(matrix, r.minBars)
+	if err != nil {
+		return nil, err
+	}
+
+	points := []zxinggo.ResultPoint{row.Start, row.End}
+	orientation := 0
+	text, err := r.decode(row.Bars)
+	if err != nil {
+		var rotatedErr error
+		text, rotatedErr = r.decode(row.Rotated())
+		if rotatedErr != nil {
+			return nil, err
+		}
+		points[0], points[1] = points[1], points[0]
+		orientation = 180
+	}
+	result := zxinggo.NewResult(text, nil, points, r.format)
+	result.PutMetadata(zxinggo.MetadataOrientation, orientation)
+	return result, nil
+}
+
+// DecodeBars decodes bar states read by an external reader, from left to
+// right with the symbol upright.
+func (r *Reader) DecodeBars(bars []oned.FourState) (*zxinggo.Result, error) {
+	text, err := r.decode(bars)
+	if err != nil {
+		return nil, err
+	}
+	return zxinggo.NewResult(text, nil, nil, r.format), nil
+}
+
+// Reset resets internal state.
+func (r *Reader) Reset() {}
+
+// Compile-time check.
+var _ zxinggo.Reader = (*Reader)(nil)
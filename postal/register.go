@@ -0,0 +1,26 @@
+package postal
+
+import zxinggo "github.com/ericlevine/zxinggo"
+
+func init() {
+	register(zxinggo.FormatRM4SCC, NewRM4SCCReader)
+	register(zxinggo.FormatKIX, NewKIXReader)
+	register(zxinggo.FormatAustraliaPost, NewAustraliaPostReader)
+	register(zxinggo.FormatIntelligentMail, NewIntelligentMailReader)
+}
+
+// register registers a reader that only runs when its format is requested.
+func register(format zxinggo.Format, newReader func() *Reader) {
+	zxinggo.RegisterReader(format, func(opts *zxinggo.DecodeOptions) zxinggo.Reader {
+		reader := newReader()
+		reader.disabled = true
+		if opts != nil {
+			for _, f := range zxinggo.ExpandFormats(opts.PossibleFormats) {
+				if f == format {
+					reader.disabled = false
+				}
+			}
+		}
+		return reader
+	})
+}
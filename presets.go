@@ -0,0 +1,26 @@
+package zxinggo
+
+// RetailPreset returns options tuned for retail point of sale scanning of
+// product and shipping labels, where each camera frame should be read, or
+// given up on, in well under 50ms:
+//
+//   - Only EAN-13, EAN-8, UPC-A, UPC-E and Code 128 are looked for. UPC-A
+//     symbols, which read as EAN-13 with a leading zero, are reported as
+//     UPC-A.
+//   - Without TryHarder, 1D readers scan at most 15 rows spread over the
+//     central band of the image, as a scanner's aiming line does, and do
+//     not look for bars turned a quarter turn.
+//   - ProfileStrict requires the full UPC/EAN and Code 128 quiet zones of
+//     DefaultQuietZone, rejecting reads that start inside neighbouring
+//     print.
+//   - 2 and 5 digit add-on extensions are read when present and reported in
+//     MetadataUPCEANExtension; symbols without one are still read.
+//
+// BenchmarkRetailPreset measures a frame of each kind. Each call returns new
+// options, which the caller may adjust.
+func RetailPreset() *DecodeOptions {
+	return &DecodeOptions{
+		PossibleFormats: []Format{FormatEAN13, FormatUPCA, FormatEAN8, FormatUPCE, FormatCode128},
+		Profile:         ProfileStrict,
+	}
+}
@@ -0,0 +1,42 @@
+package zxinggo_test
+
+import (
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+)
+
+func TestRetailPreset(t *testing.T) {
+	tests := []struct {
+		path      string
+		format    zxinggo.Format
+		text      string
+		extension string
+	}{
+		{"testdata/blackbox/ean13-1/1.png", zxinggo.FormatEAN13, "8413000065504", ""},
+		{"testdata/blackbox/upca-1/2.png", zxinggo.FormatUPCA, "036602301467", ""},
+		{"testdata/blackbox/upcean-extension-1/1.png", zxinggo.FormatEAN13, "9780735200449", "51299"},
+		{"testdata/blackbox/code128-1/1.png", zxinggo.FormatCode128, "168901", ""},
+	}
+	for _, tc := range tests {
+		bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(zxinggo.NewImageLuminanceSource(loadTestImage(tc.path))))
+		result, err := zxinggo.Decode(bitmap, zxinggo.RetailPreset())
+		if err != nil {
+			t.Errorf("%s: %v", tc.path, err)
+			continue
+		}
+		if result.Format != tc.format || result.Text != tc.text {
+			t.Errorf("%s: got [%s] %q, want [%s] %q", tc.path, result.Format, result.Text, tc.format, tc.text)
+		}
+		if ext, _ := result.Metadata[zxinggo.MetadataUPCEANExtension].(string); ext != tc.extension {
+			t.Errorf("%s: extension %q, want %q", tc.path, ext, tc.extension)
+		}
+	}
+
+	// Other formats are not looked for.
+	bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(zxinggo.NewImageLuminanceSource(loadTestImage("testdata/blackbox/qrcode-2/1.png"))))
+	if result, err := zxinggo.Decode(bitmap, zxinggo.RetailPreset()); err == nil {
+		t.Errorf("read [%s] %q", result.Format, result.Text)
+	}
+}
@@ -0,0 +1,200 @@
+package zxinggo
+
+import (
+	"slices"
+	"sort"
+)
+
+// symbolShape is the kind of symbol an image appears to hold, judged from
+// statistics cheap enough to gather before every decode.
+type symbolShape int
+
+const (
+	// shapeUnknown is a flat image, one too small to judge, or one whose
+	// statistics fit no shape clearly.
+	shapeUnknown symbolShape = iota
+	// shapeLinear is bars: luminance varies far more in one direction than
+	// the other.
+	shapeLinear
+	// shapeStacked is a textured area at least twice as long as it is high,
+	// like a PDF417 symbol.
+	shapeStacked
+	// shapeMatrix is a roughly square area whose luminance varies as much
+	// in both directions.
+	shapeMatrix
+)
+
+// Limits on the image statistics that separate the shapes.
+const (
+	// linearAnisotropy is the largest anisotropy judged to be bars.
+	linearAnisotropy = 0.5
+	// matrixAnisotropy is the smallest anisotropy judged to be a matrix
+	// symbol.
+	matrixAnisotropy = 0.65
+	// stackedAspect is the smallest aspect ratio judged to be a stacked
+	// symbol.
+	stackedAspect = 2.0
+)
+
+// stackedFormats are the formats tried first for a shapeStacked image, ahead
+// of the other 2D formats.
+var stackedFormats = []Format{FormatPDF417}
+
+// imageStats are statistics of an image's luminance taken at a sparse grid
+// of points, each compared with the points a short distance to its right and
+// below. A point varies across or down if the difference is more than a
+// quarter of the image's range of luminance.
+type imageStats struct {
+	// anisotropy is the number of points that vary in the direction fewer
+	// do over the number that vary in the other, counted in the busiest
+	// parts of the image: near 0 for the bars of a 1D symbol, near 1 for
+	// matrix symbols.
+	anisotropy float64
+
+	// upright reports whether more points vary across than down, as they do
+	// for bars that run vertically.
+	upright bool
+
+	// aspect is the longer side of the box bounding the points where
+	// luminance varies over the shorter, ignoring the outermost 5% on each
+	// side.
+	aspect float64
+
+	// textured counts the points where luminance varies.
+	textured int
+}
+
+// measureImage gathers imageStats from source.
+func measureImage(source LuminanceSource) imageStats {
+	width, height := source.Width(), source.Height()
+	// The offset compared must span several pixels so that anti-aliased
+	// edges do not look like texture, but few enough that the ends of bars
+	// are a small part of what is compared.
+	offset := max(2, min(width, height)/64)
+	step := max(1, min(width, height)/128)
+	if width <= 2*offset || height <= 2*offset {
+		return imageStats{}
+	}
+	lum := source.Matrix()
+	lo, hi := 255, 0
+	for y := 0; y < height; y += step {
+		for x := 0; x < width; x += step {
+			l := int(lum[y*width+x])
+			lo, hi = min(lo, l), max(hi, l)
+		}
+	}
+	threshold := (hi - lo) / 4
+	if threshold == 0 {
+		return imageStats{}
+	}
+
+	// Count the points varying across and down in each of a grid of tiles.
+	const tiles = 8
+	var across, down, varying [tiles * tiles]int
+	var xs, ys []int
+	for y := 0; y+offset < height; y += step {
+		for x := 0; x+offset < width; x += step {
+			l := int(lum[y*width+x])
+			dx := abs(int(lum[y*width+x+offset]) - l)
+			dy := abs(int(lum[(y+offset)*width+x]) - l)
+			if max(dx, dy) <= threshold {
+				continue
+			}
+			tile := y*tiles/height*tiles + x*tiles/width
+			if dx > threshold {
+				across[tile]++
+			}
+			if dy > threshold {
+				down[tile]++
+			}
+			varying[tile]++
+			xs = append(xs, x)
+			ys = append(ys, y)
+		}
+	}
+	if len(xs) == 0 {
+		return imageStats{}
+	}
+	// Judge direction from the busiest tiles, which are most likely to be
+	// the symbol rather than text or background around it.
+	busiest := slices.Max(varying[:])
+	var totalAcross, totalDown int
+	for i := range varying {
+		if 2*varying[i] >= busiest {
+			totalAcross += across[i]
+			totalDown += down[i]
+		}
+	}
+
+	stats := imageStats{textured: len(xs)}
+	stats.anisotropy = float64(min(totalAcross, totalDown)) / float64(max(totalAcross, totalDown))
+	stats.upright = totalAcross > totalDown
+	sort.Ints(xs)
+	sort.Ints(ys)
+	trim := len(xs) / 20
+	boxWidth := float64(xs[len(xs)-1-trim]-xs[trim]) + 1
+	boxHeight := float64(ys[len(ys)-1-trim]-ys[trim]) + 1
+	stats.aspect = max(boxWidth, boxHeight) / min(boxWidth, boxHeight)
+	return stats
+}
+
+// shape judges the kind of symbol the statistics describe. Bars only count
+// as shapeLinear if they run vertically or tryHarder is set, as 1D readers
+// only look for them turned otherwise when trying harder.
+func (s imageStats) shape(tryHarder bool) symbolShape {
+	switch {
+	case s.textured == 0:
+		return shapeUnknown
+	case s.anisotropy < linearAnisotropy:
+		if s.upright || tryHarder {
+			return shapeLinear
+		}
+		return shapeUnknown
+	case s.aspect >= stackedAspect:
+		return shapeStacked
+	case s.anisotropy >= matrixAnisotropy:
+		return shapeMatrix
+	default:
+		return shapeUnknown
+	}
+}
+
+// prioritizedOrder returns every format a reader is registered for, in the
+// order to try them on source when none are requested: 1D formats first if
+// it shows bars, 2D formats first otherwise, led by stacked formats for a
+// wide area and matrix formats for a square one. If the image fits none of
+// these clearly, it is readOrder.
+func prioritizedOrder(source LuminanceSource, opts *DecodeOptions) []Format {
+	order := readOrder(opts)
+	var first []Format
+	switch measureImage(source).shape(opts != nil && opts.TryHarder) {
+	case shapeLinear:
+		for _, f := range order {
+			if !slices.Contains(leadingFormats, f) {
+				first = append(first, f)
+			}
+		}
+	case shapeStacked:
+		first = append(slices.Clone(stackedFormats), leadingFormats...)
+	case shapeMatrix:
+		first = leadingFormats
+	default:
+		return order
+	}
+	rank := func(f Format) int {
+		if i := slices.Index(first, f); i >= 0 {
+			return i
+		}
+		return len(first)
+	}
+	// A stable sort keeps the rest in readOrder.
+	slices.SortStableFunc(order, func(a, b Format) int { return rank(a) - rank(b) })
+	return order
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
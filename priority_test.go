@@ -0,0 +1,66 @@
+package zxinggo
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// shapeSource renders modules of 4x4 pixels, dark where dark returns true,
+// on a 240x240 white image with a 20 pixel margin.
+func shapeSource(dark func(mx, my int) bool) LuminanceSource {
+	const size, margin = 240, 20
+	lum := make([]byte, size*size)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			lum[y*size+x] = 255
+			if x >= margin && y >= margin && x < size-margin && y < size-margin && dark((x-margin)/4, (y-margin)/4) {
+				lum[y*size+x] = 0
+			}
+		}
+	}
+	return NewLuminanceSourceFromBytes(lum, size, size, size)
+}
+
+func TestMeasureImageShape(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	modules := make([]bool, 50*50)
+	for i := range modules {
+		modules[i] = rng.Intn(2) == 0
+	}
+	tests := []struct {
+		name      string
+		dark      func(mx, my int) bool
+		tryHarder bool
+		want      symbolShape
+	}{
+		{"flat", func(mx, my int) bool { return false }, false, shapeUnknown},
+		{"bars", func(mx, my int) bool { return modules[mx] }, false, shapeLinear},
+		{"turned bars", func(mx, my int) bool { return modules[my] }, false, shapeUnknown},
+		{"turned bars trying harder", func(mx, my int) bool { return modules[my] }, true, shapeLinear},
+		{"matrix", func(mx, my int) bool { return modules[my*50+mx] }, false, shapeMatrix},
+		{"stacked", func(mx, my int) bool { return my >= 17 && my < 33 && modules[my*50+mx] }, false, shapeStacked},
+	}
+	for _, tt := range tests {
+		if got := measureImage(shapeSource(tt.dark)).shape(tt.tryHarder); got != tt.want {
+			t.Errorf("%s: shape %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPrioritizedOrder(t *testing.T) {
+	saved, savedGroups := readerFactories, readerGroups
+	defer func() { readerFactories, readerGroups = saved, savedGroups }()
+	readerFactories, readerGroups = map[Format]readerFactory{}, map[Format]int{}
+	for _, f := range []Format{FormatQRCode, FormatPDF417, FormatCode128, FormatDataMatrix} {
+		RegisterReader(f, nil)
+	}
+
+	bars := shapeSource(func(mx, my int) bool { return mx%3 == 0 || mx%7 == 0 })
+	if got := prioritizedOrder(bars, nil); got[0] != FormatCode128 {
+		t.Errorf("order for bars = %v, want CODE_128 first", got)
+	}
+	matrix := shapeSource(func(mx, my int) bool { return (mx*7+my*13)%5 < 2 })
+	if got := prioritizedOrder(matrix, nil); got[0] != FormatQRCode || got[len(got)-1] != FormatCode128 {
+		t.Errorf("order for a matrix = %v, want QR_CODE first and CODE_128 last", got)
+	}
+}
@@ -0,0 +1,62 @@
+package zxinggo
+
+// Profile selects, in one switch, how tolerant readers are of symbols that
+// depart from their specification. It adjusts these groups of tolerances:
+//
+//   - Quiet zones. ProfileStrict requires the DefaultQuietZone of UPC/EAN and
+//     Code 128 symbols, the same writers leave, where the default asks for as
+//     many modules as a UPC/EAN guard pattern has and half a character
+//     beside Code 128. ProfilePermissive does not check UPC/EAN or ITF quiet
+//     zones, which helps with tightly cropped images but lets reads start
+//     inside other printing.
+//   - Skew. ProfileStrict only searches for a QR code's alignment pattern
+//     close to where an undistorted symbol would have it; ProfilePermissive
+//     searches twice as far as the default.
+//   - Inter-character gaps. ProfileStrict holds the gaps between Code 39
+//     characters to the 5.3 narrow element widths of ISO/IEC 16388, where
+//     the default allows any. ProfilePermissive reads Code 93 symbols whose
+//     characters, which should abut, stand up to 5.3 modules apart, as dot
+//     matrix printers leave them. See DecodeOptions.MaxInterCharacterGap.
+//   - Dimension plausibility. ProfileStrict rejects a QR code whose measured
+//     size is not a valid symbol size, rather than rounding it to the nearest
+//     one.
+//
+// Formats without a tolerance in a group are unaffected by it. No profile
+// changes what a symbology's options assume: optional check digits, such as
+// Code 39's, are checked only when AssumeCode39CheckDigit or
+// AssumeTwoOfFiveCheckDigit asks for them, and no profile skips a checksum a
+// format mandates.
+type Profile int
+
+const (
+	// ProfileDefault balances reading damaged symbols against misreads.
+	ProfileDefault Profile = iota
+	// ProfileStrict only reads symbols that meet their specification, for
+	// verification and for applications where a misread is costly.
+	ProfileStrict
+	// ProfilePermissive reads symbols the default rejects, at a greater risk
+	// of false positives.
+	ProfilePermissive
+)
+
+// String returns the profile's name.
+func (p Profile) String() string {
+	switch p {
+	case ProfileDefault:
+		return "DEFAULT"
+	case ProfileStrict:
+		return "STRICT"
+	case ProfilePermissive:
+		return "PERMISSIVE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// ProfileOf returns the profile selected by opts, which may be nil.
+func ProfileOf(opts *DecodeOptions) Profile {
+	if opts == nil {
+		return ProfileDefault
+	}
+	return opts.Profile
+}
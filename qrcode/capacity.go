@@ -0,0 +1,46 @@
+package qrcode
+
+import (
+	"fmt"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/qrcode/decoder"
+	"github.com/ericlevine/zxinggo/qrcode/encoder"
+)
+
+// capacityPlanner sizes QR codes as Writer encodes them.
+type capacityPlanner struct{}
+
+// Fits reports whether payload encodes in a symbol of the given version, 1
+// to 40, at the error correction level named L, M, Q or H.
+func (capacityPlanner) Fits(payload string, version int, ecLevel string) (bool, error) {
+	level, err := parseECLevel(ecLevel)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", zxinggo.ErrWriter, err)
+	}
+	if _, err := decoder.GetVersionForNumber(version); err != nil {
+		return false, fmt.Errorf("%w: no QR Code version %d", zxinggo.ErrWriter, version)
+	}
+	_, err = encoder.EncodeWithHints(payload, level, &encoder.Hints{Version: version, MaskPattern: 0})
+	return err == nil, nil
+}
+
+// SmallestSymbolFor returns the version Writer would encode payload in.
+func (capacityPlanner) SmallestSymbolFor(payload string, opts *zxinggo.EncodeOptions) (zxinggo.SymbolSize, error) {
+	contents, ecLevel, hints, err := encoderSettings(payload, opts)
+	if err != nil {
+		return zxinggo.SymbolSize{}, err
+	}
+	code, err := encoder.EncodeWithHints(contents, ecLevel, hints)
+	if err != nil {
+		return zxinggo.SymbolSize{}, err
+	}
+	dimension := code.Version.DimensionForVersion()
+	return zxinggo.SymbolSize{
+		Format:  zxinggo.FormatQRCode,
+		Version: code.Version.Number,
+		Width:   dimension,
+		Height:  dimension,
+		ECLevel: code.ECLevel.String(),
+	}, nil
+}
@@ -28,6 +28,17 @@ func (p *BitMatrixParser) ReadFormatInformation() (*FormatInformation, error) {
 		return p.parsedFormatInfo, nil
 	}
 
+	formatInfoBits1, formatInfoBits2 := p.readFormatInformationBits()
+	p.parsedFormatInfo = DecodeFormatInformation(formatInfoBits1, formatInfoBits2)
+	if p.parsedFormatInfo != nil {
+		return p.parsedFormatInfo, nil
+	}
+	return nil, zxinggo.ErrFormat
+}
+
+// readFormatInformationBits reads the raw 15-bit format information from its
+// two locations.
+func (p *BitMatrixParser) readFormatInformationBits() (int, int) {
 	// Read top-left format info bits
 	formatInfoBits1 := 0
 	for i := 0; i < 6; i++ {
@@ -50,12 +61,7 @@ func (p *BitMatrixParser) ReadFormatInformation() (*FormatInformation, error) {
 	for i := dimension - 8; i < dimension; i++ {
 		formatInfoBits2 = p.copyBit(i, 8, formatInfoBits2)
 	}
-
-	p.parsedFormatInfo = DecodeFormatInformation(formatInfoBits1, formatInfoBits2)
-	if p.parsedFormatInfo != nil {
-		return p.parsedFormatInfo, nil
-	}
-	return nil, zxinggo.ErrFormat
+	return formatInfoBits1, formatInfoBits2
 }
 
 // ReadVersion reads version information from the QR code.
@@ -14,17 +14,24 @@ const alphanumericChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:";
 
 const gb2312Subset = 1
 
-// DecodeBitStream decodes data bytes into a DecoderResult.
-func DecodeBitStream(bytes []byte, version *Version, ecLevel ErrorCorrectionLevel, characterSet string) (*internal.DecoderResult, error) {
+// DecodeBitStream decodes data bytes into a DecodedPayload.
+func DecodeBitStream(bytes []byte, version *Version, ecLevel ErrorCorrectionLevel, characterSet string) (*internal.DecodedPayload, error) {
+	return decodeBitStream(bytes, version, ecLevel, characterSet, false)
+}
+
+// decodeBitStream is DecodeBitStream, decoding byte segments after ECI
+// 000003 as if no ECI were given if ignoreECI3 is set.
+func decodeBitStream(bytes []byte, version *Version, ecLevel ErrorCorrectionLevel, characterSet string, ignoreECI3 bool) (*internal.DecodedPayload, error) {
 	bs := bitutil.NewBitSource(bytes)
 	var result strings.Builder
 	result.Grow(50)
 	var byteSegments [][]byte
-	symbolSequence := -1
-	parityData := -1
+	var structuredAppend *zxinggo.StructuredAppend
 	var symbologyModifier int
 
 	var currentCharacterSetECI *charset.ECI
+	// guessCharset is set while ECI 000003 is ignored.
+	guessCharset := false
 	fc1InEffect := false
 	hasFNC1first := false
 	hasFNC1second := false
@@ -53,14 +60,28 @@ func DecodeBitStream(bytes []byte, version *Version, ecLevel ErrorCorrectionLeve
 		case ModeFNC1SecondPosition:
 			hasFNC1second = true
 			fc1InEffect = true
+			// The application indicator follows, transmitted ahead of the
+			// data: two digits as their value, or a letter as its ASCII
+			// code plus 100.
+			ai, err := bs.ReadBits(8)
+			if err != nil {
+				return nil, zxinggo.ErrFormat
+			}
+			switch {
+			case ai < 100:
+				result.WriteString(fmt.Sprintf("%02d", ai))
+			case 165 <= ai && ai <= 190, 197 <= ai && ai <= 222:
+				result.WriteByte(byte(ai - 100))
+			default:
+				return nil, zxinggo.ErrFormat
+			}
 		case ModeStructuredAppend:
 			if bs.Available() < 16 {
 				return nil, zxinggo.ErrFormat
 			}
 			seq, _ := bs.ReadBits(8)
 			par, _ := bs.ReadBits(8)
-			symbolSequence = seq
-			parityData = par
+			structuredAppend = &zxinggo.StructuredAppend{Index: seq >> 4, Count: seq&0x0F + 1, Parity: par}
 		case ModeECI:
 			value, err := parseECIValue(bs)
 			if err != nil {
@@ -71,6 +92,7 @@ func DecodeBitStream(bytes []byte, version *Version, ecLevel ErrorCorrectionLeve
 				return nil, zxinggo.ErrFormat
 			}
 			currentCharacterSetECI = eci
+			guessCharset = ignoreECI3 && value == 3
 		case ModeHanzi:
 			subsetBits, _ := bs.ReadBits(4)
 			countBits := mode.CharacterCountBits(version)
@@ -96,7 +118,11 @@ func DecodeBitStream(bytes []byte, version *Version, ecLevel ErrorCorrectionLeve
 					return nil, err
 				}
 			case ModeByte:
-				seg, err := decodeByteSegment(bs, &result, count, currentCharacterSetECI, characterSet)
+				segmentECI := currentCharacterSetECI
+				if guessCharset {
+					segmentECI = nil
+				}
+				seg, err := decodeByteSegment(bs, &result, count, segmentECI, characterSet)
 				if err != nil {
 					return nil, err
 				}
@@ -133,9 +159,10 @@ func DecodeBitStream(bytes []byte, version *Version, ecLevel ErrorCorrectionLeve
 		}
 	}
 
-	ecLevelStr := ecLevel.String()
-	return internal.NewDecoderResultFull(bytes, result.String(), byteSegments, ecLevelStr,
-		symbolSequence, parityData, symbologyModifier), nil
+	payload := internal.NewDecodedPayload(bytes, result.String(), byteSegments, ecLevel.String())
+	payload.StructuredAppend = structuredAppend
+	payload.SymbologyModifier = symbologyModifier
+	return payload, nil
 }
 
 func decodeHanziSegment(bs *bitutil.BitSource, result *strings.Builder, count int) error {
@@ -1,6 +1,8 @@
 package decoder
 
 import (
+	"sort"
+
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
 	"github.com/ericlevine/zxinggo/internal"
@@ -10,6 +12,18 @@ import (
 // Decoder decodes QR codes.
 type Decoder struct {
 	rsDecoder *reedsolomon.Decoder
+
+	// SkipFormatCandidates disables the retries of Decode with other
+	// plausible format information values.
+	SkipFormatCandidates bool
+
+	// DumpCodewords has Decode return a *zxinggo.DecodeError, rather than
+	// zxinggo.ErrChecksum, when error correction fails.
+	DumpCodewords bool
+
+	// IgnoreECI3 has byte segments after ECI 000003 decoded as if no ECI
+	// were given; see zxinggo.DecodeOptions.QRIgnoreECI3.
+	IgnoreECI3 bool
 }
 
 // NewDecoder creates a new QR code Decoder.
@@ -19,39 +33,129 @@ func NewDecoder() *Decoder {
 	}
 }
 
-// Decode decodes a BitMatrix into a DecoderResult.
-func (d *Decoder) Decode(bits *bitutil.BitMatrix, characterSet string) (*internal.DecoderResult, error) {
+// Decode decodes a BitMatrix into a DecodedPayload. If neither the normal nor
+// the mirrored reading succeeds, decoding is retried with the other plausible
+// format information values (see decodeWithVersionAndFormatCandidates).
+func (d *Decoder) Decode(bits *bitutil.BitMatrix, characterSet string) (*internal.DecodedPayload, error) {
 	parser, err := NewBitMatrixParser(bits)
 	if err != nil {
 		return nil, err
 	}
-
 	result, err := d.decodeParser(parser, characterSet)
 	if err == nil {
 		return result, nil
 	}
+	tried := []triedFormat{{false, parser.parsedFormatInfo}}
 
-	// Try mirrored reading
+	// Try mirrored reading. It mirrors and unmasks bits in place, so first
+	// keep a pristine copy for the candidate retries.
 	parser.Remask()
+	var original *bitutil.BitMatrix
+	if !d.SkipFormatCandidates {
+		original = bits.Clone()
+	}
 	parser.SetMirror(true)
 
-	if _, verr := parser.ReadVersion(); verr != nil {
-		return nil, err // return original error
+	if _, verr := parser.ReadVersion(); verr == nil {
+		if _, ferr := parser.ReadFormatInformation(); ferr == nil {
+			parser.Mirror()
+			if result, err2 := d.decodeParser(parser, characterSet); err2 == nil {
+				return result, nil
+			}
+			tried = append(tried, triedFormat{true, parser.parsedFormatInfo})
+		}
 	}
-	if _, ferr := parser.ReadFormatInformation(); ferr != nil {
+
+	if d.SkipFormatCandidates {
 		return nil, err
 	}
+	if result, cerr := d.decodeWithVersionAndFormatCandidates(original, characterSet, tried); cerr == nil {
+		return result, nil
+	}
+	return nil, err // return original error
+}
+
+const (
+	// candidateMaxFormatDistance is the largest number of bit errors in the
+	// format information that a retry candidate may assume. The format code
+	// has a minimum distance of 7, so the nearest codeword is only certain up
+	// to 3 errors.
+	candidateMaxFormatDistance = 5
+
+	// candidateRetryBudget bounds the number of extra decode attempts made by
+	// decodeWithVersionAndFormatCandidates.
+	candidateRetryBudget = 8
+)
+
+// triedFormat records a format information value already used for a decode
+// attempt in a given orientation.
+type triedFormat struct {
+	mirror bool
+	format *FormatInformation
+}
 
-	parser.Mirror()
+// decodeWithVersionAndFormatCandidates retries decoding with each format
+// information value (EC level and data mask) within
+// candidateMaxFormatDistance of what was read, in both orientations, nearest
+// first. The version is taken from the symbol's dimension, which is the only
+// version the grid can hold. Attempts already made are skipped, and at most
+// candidateRetryBudget attempts are made.
+func (d *Decoder) decodeWithVersionAndFormatCandidates(bits *bitutil.BitMatrix, characterSet string, tried []triedFormat) (*internal.DecodedPayload, error) {
+	version, err := GetProvisionalVersionForDimension(bits.Height())
+	if err != nil {
+		return nil, err
+	}
 
-	result, err2 := d.decodeParser(parser, characterSet)
-	if err2 != nil {
-		return nil, err // return original error
+	type candidate struct {
+		mirror bool
+		FormatInformationCandidate
 	}
-	return result, nil
+	var candidates []candidate
+	for _, mirror := range []bool{false, true} {
+		parser := &BitMatrixParser{bitMatrix: bits, mirror: mirror}
+		bits1, bits2 := parser.readFormatInformationBits()
+		for _, c := range FormatInformationCandidates(bits1, bits2, candidateMaxFormatDistance) {
+			candidates = append(candidates, candidate{mirror, c})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Distance < candidates[j].Distance
+	})
+
+	attempts := 0
+	for _, c := range candidates {
+		if wasTried(tried, c.mirror, c.FormatInformation) {
+			continue
+		}
+		if attempts == candidateRetryBudget {
+			break
+		}
+		attempts++
+
+		parser := &BitMatrixParser{bitMatrix: bits.Clone()}
+		if c.mirror {
+			parser.Mirror()
+		}
+		parser.parsedVersion = version
+		parser.parsedFormatInfo = c.FormatInformation
+		if result, err := d.decodeParser(parser, characterSet); err == nil {
+			return result, nil
+		}
+	}
+	return nil, zxinggo.ErrChecksum
+}
+
+func wasTried(tried []triedFormat, mirror bool, fi *FormatInformation) bool {
+	for _, t := range tried {
+		if t.mirror == mirror && t.format != nil &&
+			t.format.ECLevel == fi.ECLevel && t.format.DataMask == fi.DataMask {
+			return true
+		}
+	}
+	return false
 }
 
-func (d *Decoder) decodeParser(parser *BitMatrixParser, characterSet string) (*internal.DecoderResult, error) {
+func (d *Decoder) decodeParser(parser *BitMatrixParser, characterSet string) (*internal.DecodedPayload, error) {
 	version, err := parser.ReadVersion()
 	if err != nil {
 		return nil, err
@@ -77,24 +181,66 @@ func (d *Decoder) decodeParser(parser *BitMatrixParser, characterSet string) (*i
 	resultOffset := 0
 
 	errorsCorrected := 0
+	unusedEC := 1.0
 	for _, db := range dataBlocks {
 		corrected, err := d.correctErrors(db.Codewords, db.NumDataCodewords)
 		if err != nil {
+			if d.DumpCodewords {
+				return nil, d.codewordError(codewords, version, ecLevel)
+			}
 			return nil, err
 		}
 		errorsCorrected += corrected
+		capacity := len(db.Codewords) - db.NumDataCodewords - misdecodeProtection(version, ecLevel)
+		unusedEC = min(unusedEC, 1-float64(2*corrected)/float64(capacity))
 		copy(resultBytes[resultOffset:], db.Codewords[:db.NumDataCodewords])
 		resultOffset += db.NumDataCodewords
 	}
 
-	result, err := DecodeBitStream(resultBytes, version, ecLevel, characterSet)
+	result, err := decodeBitStream(resultBytes, version, ecLevel, characterSet, d.IgnoreECI3)
 	if err != nil {
 		return nil, err
 	}
 	result.ErrorsCorrected = errorsCorrected
+	result.UnusedErrorCorrection = max(unusedEC, 0)
 	return result, nil
 }
 
+// misdecodeProtection returns the error correction codewords per block that
+// ISO/IEC 18004 reserves against misdecodes in the smallest symbols, and
+// which are not available for correction.
+func misdecodeProtection(version *Version, ecLevel ErrorCorrectionLevel) int {
+	switch {
+	case version.Number == 1 && ecLevel == ECLevelL:
+		return 3
+	case version.Number == 1 && ecLevel == ECLevelM, version.Number == 2 && ecLevel == ECLevelL:
+		return 2
+	case version.Number == 1, version.Number == 3 && ecLevel == ECLevelL:
+		return 1
+	}
+	return 0
+}
+
+// codewordError describes each block of codewords, as read, for a
+// DecodeError.
+func (d *Decoder) codewordError(codewords []byte, version *Version, ecLevel ErrorCorrectionLevel) *zxinggo.DecodeError {
+	e := &zxinggo.DecodeError{Format: zxinggo.FormatQRCode}
+	for _, db := range GetDataBlocks(codewords, version, ecLevel) {
+		ints := make([]int, len(db.Codewords))
+		for i, b := range db.Codewords {
+			ints[i] = int(b)
+		}
+		numEC := len(ints) - db.NumDataCodewords
+		e.Blocks = append(e.Blocks, zxinggo.CodewordBlock{
+			Codewords:     ints,
+			DataCodewords: db.NumDataCodewords,
+			Syndromes:     reedsolomon.Syndromes(reedsolomon.QRCodeField256, ints, numEC),
+			Correctable:   d.rsDecoder.Correctable(ints, numEC),
+		})
+	}
+	return e
+}
+
 func (d *Decoder) correctErrors(codewordBytes []byte, numDataCodewords int) (int, error) {
 	numCodewords := len(codewordBytes)
 	codewordsInts := make([]int, numCodewords)
@@ -1,6 +1,9 @@
 package decoder
 
-import "math/bits"
+import (
+	"math/bits"
+	"sort"
+)
 
 const formatInfoMaskQR = 0x5412
 
@@ -64,3 +67,34 @@ func doDecodeFormatInformation(maskedFormatInfo1, maskedFormatInfo2 int) *Format
 	}
 	return nil
 }
+
+// FormatInformationCandidate is a possible reading of damaged format
+// information, with the number of bits that had to be corrected to reach it.
+type FormatInformationCandidate struct {
+	*FormatInformation
+	Distance int
+}
+
+// FormatInformationCandidates returns every format information value within
+// maxDistance bits of either of the two masked readings, closest first. It is
+// used to retry decoding when the best match leads to uncorrectable data.
+func FormatInformationCandidates(maskedFormatInfo1, maskedFormatInfo2, maxDistance int) []FormatInformationCandidate {
+	var candidates []FormatInformationCandidate
+	for _, entry := range formatInfoDecodeLookup {
+		best := 32
+		for _, masked := range []int{maskedFormatInfo1, maskedFormatInfo2} {
+			for _, m := range []int{masked, masked ^ formatInfoMaskQR} {
+				if d := bits.OnesCount(uint(m ^ entry[0])); d < best {
+					best = d
+				}
+			}
+		}
+		if best <= maxDistance {
+			candidates = append(candidates, FormatInformationCandidate{newFormatInformation(entry[1]), best})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Distance < candidates[j].Distance
+	})
+	return candidates
+}
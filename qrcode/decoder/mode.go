@@ -72,6 +72,33 @@ func (m Mode) CharacterCountBits(version *Version) int {
 	return characterCountBits[m][offset]
 }
 
+// String returns the mode name, such as "NUMERIC".
+func (m Mode) String() string {
+	switch m {
+	case ModeTerminator:
+		return "TERMINATOR"
+	case ModeNumeric:
+		return "NUMERIC"
+	case ModeAlphanumeric:
+		return "ALPHANUMERIC"
+	case ModeStructuredAppend:
+		return "STRUCTURED_APPEND"
+	case ModeByte:
+		return "BYTE"
+	case ModeFNC1FirstPosition:
+		return "FNC1_FIRST_POSITION"
+	case ModeECI:
+		return "ECI"
+	case ModeKanji:
+		return "KANJI"
+	case ModeFNC1SecondPosition:
+		return "FNC1_SECOND_POSITION"
+	case ModeHanzi:
+		return "HANZI"
+	}
+	return "?"
+}
+
 // Bits returns the 4-bit encoding of this mode.
 func (m Mode) Bits() int {
 	return int(m)
@@ -33,7 +33,17 @@ func (ecb *ECBlocks) TotalECCodewords() int {
 	return ecb.ECCodewordsPerBlock * ecb.NumBlocks()
 }
 
-// Version represents a QR code version (1-40).
+// TotalDataCodewords returns the total number of data codewords.
+func (ecb *ECBlocks) TotalDataCodewords() int {
+	total := 0
+	for _, b := range ecb.Blocks {
+		total += b.Count * b.DataCodewords
+	}
+	return total
+}
+
+// Version represents a QR code version (1-40). Versions are shared and must
+// not be modified.
 type Version struct {
 	Number                  int
 	AlignmentPatternCenters []int
@@ -51,6 +61,12 @@ func (v *Version) ECBlocksForLevel(ecLevel ErrorCorrectionLevel) *ECBlocks {
 	return &v.ECBlocksArray[ecLevel.Ordinal()]
 }
 
+// DataCodewords returns the number of data codewords the version holds at
+// the given error correction level.
+func (v *Version) DataCodewords(ecLevel ErrorCorrectionLevel) int {
+	return v.ECBlocksForLevel(ecLevel).TotalDataCodewords()
+}
+
 // BuildFunctionPattern builds a BitMatrix indicating function pattern modules.
 func (v *Version) BuildFunctionPattern() *bitutil.BitMatrix {
 	dimension := v.DimensionForVersion()
@@ -100,6 +116,15 @@ var versionDecodeInfo = []int{
 	0x2542E, 0x26A64, 0x27541, 0x28C69,
 }
 
+// Versions returns versions 1 to 40 in order.
+func Versions() []*Version {
+	all := make([]*Version, len(versions))
+	for i := range versions {
+		all[i] = &versions[i]
+	}
+	return all
+}
+
 // GetVersionForNumber returns the Version for the given version number (1-40).
 func GetVersionForNumber(number int) (*Version, error) {
 	if number < 1 || number > 40 {
@@ -108,7 +133,10 @@ func GetVersionForNumber(number int) (*Version, error) {
 	return &versions[number-1], nil
 }
 
-// GetProvisionalVersionForDimension returns the Version for a QR code of the given dimension.
+// GetProvisionalVersionForDimension returns the Version for a QR code of the
+// given dimension in modules, which must be 21 to 177 and one more than a
+// multiple of 4. It is provisional when read from an image because symbols of
+// version 7 and up also encode their version, which takes precedence.
 func GetProvisionalVersionForDimension(dimension int) (*Version, error) {
 	if dimension%4 != 1 {
 		return nil, fmt.Errorf("qrcode/decoder: invalid dimension %d", dimension)
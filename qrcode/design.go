@@ -0,0 +1,251 @@
+package qrcode
+
+import (
+	"fmt"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/qrcode/decoder"
+	"github.com/ericlevine/zxinggo/qrcode/encoder"
+)
+
+// ModuleRole is what a module of a QR code is for.
+type ModuleRole byte
+
+const (
+	// ModuleData is a bit of a data or error correction codeword. Changing
+	// it costs error correction.
+	ModuleData ModuleRole = iota
+	// ModuleRemainder is a remainder bit after the last codeword, which
+	// decoders ignore.
+	ModuleRemainder
+	// ModuleFinder is part of a finder pattern or its separator.
+	ModuleFinder
+	// ModuleTiming is part of a timing pattern.
+	ModuleTiming
+	// ModuleAlignment is part of an alignment pattern.
+	ModuleAlignment
+	// ModuleFormat is a bit of the format information, or the dark module
+	// beside it.
+	ModuleFormat
+	// ModuleVersion is a bit of the version information of versions 7 and
+	// up.
+	ModuleVersion
+)
+
+var moduleRoleNames = [...]string{
+	ModuleData:      "data",
+	ModuleRemainder: "remainder",
+	ModuleFinder:    "finder",
+	ModuleTiming:    "timing",
+	ModuleAlignment: "alignment",
+	ModuleFormat:    "format",
+	ModuleVersion:   "version",
+}
+
+func (r ModuleRole) String() string {
+	if int(r) < len(moduleRoleNames) {
+		return moduleRoleNames[r]
+	}
+	return fmt.Sprintf("ModuleRole(%d)", int(r))
+}
+
+// IsFunction reports whether the role is a function pattern or format or
+// version information: modules decoders rely on to find and sample the
+// symbol, which error correction does not cover.
+func (r ModuleRole) IsFunction() bool {
+	return r >= ModuleFinder
+}
+
+// FunctionMask maps the modules of a QR code of a given version, error
+// correction level and mask to what they are for, so that designers of
+// artistic QR codes can tell which modules they may recolor. Function
+// modules must keep the colors of Pattern; data modules may be recolored at
+// the cost of error correction, Codeword telling which codeword each
+// belongs to; remainder modules are free.
+type FunctionMask struct {
+	version     *decoder.Version
+	ecLevel     decoder.ErrorCorrectionLevel
+	maskPattern int
+	pattern     *encoder.ByteMatrix
+	roles       [][]ModuleRole
+	codewords   [][]int
+}
+
+// NewFunctionMask returns the function mask of a QR code of version 1 to
+// 40, at ecLevel, with mask pattern 0 to 7.
+func NewFunctionMask(version int, ecLevel decoder.ErrorCorrectionLevel, maskPattern int) (*FunctionMask, error) {
+	v, err := decoder.GetVersionForNumber(version)
+	if err != nil {
+		return nil, fmt.Errorf("%w: no QR Code version %d", zxinggo.ErrWriter, version)
+	}
+	if maskPattern < 0 || maskPattern >= len(decoder.DataMasks) {
+		return nil, fmt.Errorf("%w: no QR Code mask pattern %d", zxinggo.ErrWriter, maskPattern)
+	}
+	m := &FunctionMask{
+		version:     v,
+		ecLevel:     ecLevel,
+		maskPattern: maskPattern,
+		pattern:     encoder.FunctionPatterns(v, ecLevel, maskPattern),
+	}
+	dimension := v.DimensionForVersion()
+	m.roles = make([][]ModuleRole, dimension)
+	m.codewords = make([][]int, dimension)
+	for y := range m.roles {
+		m.roles[y] = make([]ModuleRole, dimension)
+		m.codewords[y] = make([]int, dimension)
+		for x := range m.roles[y] {
+			m.codewords[y][x] = -1
+			if m.pattern.Get(x, y) != 0xFF {
+				m.roles[y][x] = functionRole(v, x, y)
+			}
+		}
+	}
+	dataBits := 8 * v.TotalCodewords
+	for i, p := range encoder.DataModuleOrder(m.pattern) {
+		x, y := p[0], p[1]
+		if i < dataBits {
+			m.codewords[y][x] = i / 8
+		} else {
+			m.roles[y][x] = ModuleRemainder
+		}
+	}
+	return m, nil
+}
+
+// functionRole returns the role of the function module at x, y in a
+// symbol of version v.
+func functionRole(v *decoder.Version, x, y int) ModuleRole {
+	dimension := v.DimensionForVersion()
+	near := func(c int) bool { return c < 8 }
+	far := func(c int) bool { return c >= dimension-8 }
+	switch {
+	case near(x) && near(y), far(x) && near(y), near(x) && far(y):
+		return ModuleFinder
+	case (x == 8 && y != 6 && (y < 9 || far(y))) || (y == 8 && x != 6 && (x < 9 || far(x))):
+		return ModuleFormat
+	case v.Number >= 7 && ((x >= dimension-11 && y < 6) || (y >= dimension-11 && x < 6)):
+		return ModuleVersion
+	}
+	for _, cy := range v.AlignmentPatternCenters {
+		for _, cx := range v.AlignmentPatternCenters {
+			overlapsFinder := (cx < 8 || cx >= dimension-8) && (cy < 8 || cy >= dimension-8) &&
+				!(cx >= dimension-8 && cy >= dimension-8)
+			if !overlapsFinder && abs(x-cx) <= 2 && abs(y-cy) <= 2 {
+				return ModuleAlignment
+			}
+		}
+	}
+	return ModuleTiming
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Dimension returns the width and height of the symbol in modules.
+func (m *FunctionMask) Dimension() int {
+	return len(m.roles)
+}
+
+// Role returns what the module at x, y is for.
+func (m *FunctionMask) Role(x, y int) ModuleRole {
+	return m.roles[y][x]
+}
+
+// Codeword returns the index, in the interleaved sequence of data and
+// error correction codewords, of the codeword the module at x, y holds a
+// bit of, or -1 if it is not a data module.
+func (m *FunctionMask) Codeword(x, y int) int {
+	return m.codewords[y][x]
+}
+
+// Masked reports whether the mask inverts the module at x, y, if it is a
+// data or remainder module.
+func (m *FunctionMask) Masked(x, y int) bool {
+	return m.Role(x, y) <= ModuleRemainder && decoder.DataMasks[m.maskPattern](y, x)
+}
+
+// Functions returns a matrix with the function modules set.
+func (m *FunctionMask) Functions() *bitutil.BitMatrix {
+	bits := bitutil.NewBitMatrix(m.Dimension())
+	for y, row := range m.roles {
+		for x, role := range row {
+			if role.IsFunction() {
+				bits.Set(x, y)
+			}
+		}
+	}
+	return bits
+}
+
+// Pattern returns a matrix with the dark function modules set, the colors
+// a design must keep.
+func (m *FunctionMask) Pattern() *bitutil.BitMatrix {
+	bits := bitutil.NewBitMatrix(m.Dimension())
+	for y := 0; y < m.Dimension(); y++ {
+		for x := 0; x < m.Dimension(); x++ {
+			if m.pattern.Get(x, y) == 1 {
+				bits.Set(x, y)
+			}
+		}
+	}
+	return bits
+}
+
+// DesignReport is the outcome of validating a design with
+// FunctionMask.Validate.
+type DesignReport struct {
+	// Result is the symbol read from the design, or nil if it did not
+	// decode.
+	Result *zxinggo.Result
+
+	// FunctionErrors are the x, y positions of the function modules whose
+	// color differs from Pattern.
+	FunctionErrors [][2]int
+
+	// ErrorsCorrected is how many codewords error correction repaired.
+	ErrorsCorrected int
+
+	// UnusedErrorCorrection is the lowest fraction, over the error
+	// correction blocks, of the capacity to correct errors left unused: 0
+	// when a further error in the worst block would not decode.
+	UnusedErrorCorrection float64
+}
+
+// Validate decodes design, a module grid of the mask's dimension with no
+// quiet zone, as a proposed artistic rendering of the symbol, and reports
+// how much error correction its changes cost and which function modules it
+// changed. opts may be nil; only CharacterSet is used. The report is
+// returned even if the design does not decode, the error then being why.
+func (m *FunctionMask) Validate(design *bitutil.BitMatrix, opts *zxinggo.DecodeOptions) (*DesignReport, error) {
+	dimension := m.Dimension()
+	if design.Width() != dimension || design.Height() != dimension {
+		return nil, fmt.Errorf("%w: design is %dx%d modules, want %dx%d",
+			zxinggo.ErrFormat, design.Width(), design.Height(), dimension, dimension)
+	}
+	report := &DesignReport{}
+	for y := 0; y < dimension; y++ {
+		for x := 0; x < dimension; x++ {
+			if m.roles[y][x].IsFunction() && design.Get(x, y) != (m.pattern.Get(x, y) == 1) {
+				report.FunctionErrors = append(report.FunctionErrors, [2]int{x, y})
+			}
+		}
+	}
+	// Decoding unmasks the grid in place.
+	result, err := DecodeMatrix(design.Clone(), opts)
+	if err != nil {
+		return report, err
+	}
+	report.Result = result
+	if n, ok := result.Metadata[zxinggo.MetadataErrorsCorrected].(int); ok {
+		report.ErrorsCorrected = n
+	}
+	if unused, ok := result.Metadata[zxinggo.MetadataUnusedErrorCorrection].(float64); ok {
+		report.UnusedErrorCorrection = unused
+	}
+	return report, nil
+}
@@ -7,7 +7,6 @@ import (
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
-	"github.com/ericlevine/zxinggo/internal"
 	"github.com/ericlevine/zxinggo/qrcode/decoder"
 	"github.com/ericlevine/zxinggo/transform"
 )
@@ -70,9 +69,10 @@ func (ap *AlignmentPattern) combineEstimate(i, j, newModuleSize float64) *Alignm
 // --- FinderPatternFinder ---
 
 type finderPatternFinder struct {
-	image              *bitutil.BitMatrix
-	possibleCenters    []*FinderPattern
-	hasSkipped         bool
+	image                *bitutil.BitMatrix
+	heatmap              *zxinggo.Heatmap
+	possibleCenters      []*FinderPattern
+	hasSkipped           bool
 	crossCheckStateCount [5]int
 }
 
@@ -139,6 +139,7 @@ func (f *finderPatternFinder) find(tryHarder bool) (*FinderPatternInfo, error) {
 				}
 			}
 		}
+		f.heatmap.ScanRow(i, 0, maxJ)
 		if foundPatternCross(stateCount) {
 			confirmed := f.handlePossibleCenter(stateCount, i, maxJ)
 			if confirmed {
@@ -388,13 +389,17 @@ func (f *finderPatternFinder) handlePossibleCenter(stateCount [5]int, i, j int)
 	centerJ := centerFromEnd(stateCount, j)
 	centerI := f.crossCheckVertical(i, int(centerJ), stateCount[2], stateCountTotal)
 	if math.IsNaN(centerI) {
+		f.heatmap.Candidate(zxinggo.FormatQRCode, centerJ, float64(i), float64(stateCountTotal), false)
 		return false
 	}
 
+	rowCenterJ := centerJ
 	centerJ = f.crossCheckHorizontal(int(centerJ), int(centerI), stateCount[2], stateCountTotal)
 	if math.IsNaN(centerJ) || !f.crossCheckDiagonal(int(centerI), int(centerJ)) {
+		f.heatmap.Candidate(zxinggo.FormatQRCode, rowCenterJ, centerI, float64(stateCountTotal), false)
 		return false
 	}
+	f.heatmap.Candidate(zxinggo.FormatQRCode, centerJ, centerI, float64(stateCountTotal), true)
 
 	estimatedModuleSize := float64(stateCountTotal) / 7.0
 	found := false
@@ -588,15 +593,35 @@ func distanceFP(a, b *FinderPattern) float64 {
 // --- AlignmentPatternFinder ---
 
 type alignmentPatternFinder struct {
-	image              *bitutil.BitMatrix
-	possibleCenters    []*AlignmentPattern
-	startX, startY     int
-	width, height      int
-	moduleSize         float64
+	image                *bitutil.BitMatrix
+	possibleCenters      []*AlignmentPattern
+	startX, startY       int
+	width, height        int
+	moduleSize           float64
 	crossCheckStateCount [3]int
 }
 
 func (af *alignmentPatternFinder) find() *AlignmentPattern {
+	if confirmed := af.scan(true); confirmed != nil {
+		return confirmed
+	}
+	if len(af.possibleCenters) > 0 {
+		return af.possibleCenters[0]
+	}
+	return nil
+}
+
+// findAll scans the whole area and returns every candidate found, whether
+// confirmed or not.
+func (af *alignmentPatternFinder) findAll() []*AlignmentPattern {
+	af.scan(false)
+	return af.possibleCenters
+}
+
+// scan scans the area from its middle row outwards, collecting candidates in
+// possibleCenters. With stop, it returns the first candidate confirmed by a
+// second sighting.
+func (af *alignmentPatternFinder) scan(stop bool) *AlignmentPattern {
 	startX := af.startX
 	height := af.height
 	maxJ := startX + af.width
@@ -625,7 +650,7 @@ func (af *alignmentPatternFinder) find() *AlignmentPattern {
 					if currentState == 2 {
 						if af.foundPatternCross(stateCount) {
 							confirmed := af.handlePossibleCenter(stateCount, i, j)
-							if confirmed != nil {
+							if confirmed != nil && stop {
 								return confirmed
 							}
 						}
@@ -648,15 +673,11 @@ func (af *alignmentPatternFinder) find() *AlignmentPattern {
 		}
 		if af.foundPatternCross(stateCount) {
 			confirmed := af.handlePossibleCenter(stateCount, i, maxJ)
-			if confirmed != nil {
+			if confirmed != nil && stop {
 				return confirmed
 			}
 		}
 	}
-
-	if len(af.possibleCenters) > 0 {
-		return af.possibleCenters[0]
-	}
 	return nil
 }
 
@@ -743,6 +764,73 @@ func (af *alignmentPatternFinder) handlePossibleCenter(stateCount [3]int, i, j i
 // Detector detects QR codes in binary images.
 type Detector struct {
 	image *bitutil.BitMatrix
+
+	// Heatmap, if set, records the rows searched for finder patterns and the
+	// candidates found.
+	Heatmap *zxinggo.Heatmap
+
+	// Profile sets how far the alignment pattern is searched for and whether
+	// a measured dimension that is not a valid symbol size is rounded to one.
+	Profile zxinggo.Profile
+
+	// SkipTwoPatterns disables the search for a symbol with one finder
+	// pattern missing.
+	SkipTwoPatterns bool
+
+	// SkipAlignmentRetry disables the second search for an alignment
+	// pattern that the first did not find; see retryAlignment.
+	SkipAlignmentRetry bool
+
+	// SkipGridFit disables fitting the symbol's dimension to its finder and
+	// alignment patterns when the timing patterns do not confirm the one
+	// measured; see fitDimension.
+	SkipGridFit bool
+
+	// RequireAlignmentFrom rejects symbols of this version or larger whose
+	// alignment pattern is not found, rather than sampling them with an
+	// estimated bottom-right corner. Zero accepts any.
+	RequireAlignmentFrom int
+}
+
+// Alignment tells how the bottom-right corner of a symbol was located.
+type Alignment int
+
+const (
+	// AlignmentAbsent means the symbol, of version 1, has no alignment
+	// pattern, and its corner was estimated from the finder patterns.
+	AlignmentAbsent Alignment = iota
+	// AlignmentFound means the alignment pattern was found near where the
+	// finder patterns put it.
+	AlignmentFound
+	// AlignmentRetried means the alignment pattern was found by the second,
+	// wider search.
+	AlignmentRetried
+	// AlignmentEstimated means the alignment pattern was not found, and the
+	// corner was estimated from the finder patterns. Symbols seen in
+	// perspective are sampled skewed.
+	AlignmentEstimated
+)
+
+// String returns "absent", "found", "retried" or "estimated".
+func (a Alignment) String() string {
+	switch a {
+	case AlignmentAbsent:
+		return "absent"
+	case AlignmentFound:
+		return "found"
+	case AlignmentRetried:
+		return "retried"
+	case AlignmentEstimated:
+		return "estimated"
+	}
+	return "unknown"
+}
+
+// DetectorResult is a detected QR code, with how its alignment pattern was
+// located.
+type DetectorResult struct {
+	zxinggo.DetectorResult
+	Alignment Alignment
 }
 
 // NewDetector creates a new Detector for the given image.
@@ -751,29 +839,52 @@ func NewDetector(image *bitutil.BitMatrix) *Detector {
 }
 
 // Detect detects a QR code and returns the sampled bit matrix and corner points.
-func (d *Detector) Detect(tryHarder bool) (*internal.DetectorResult, error) {
-	finder := &finderPatternFinder{image: d.image}
+// With tryHarder, a symbol with one finder pattern torn off or obscured is
+// also looked for when only two are found.
+func (d *Detector) Detect(tryHarder bool) (*DetectorResult, error) {
+	finder := &finderPatternFinder{image: d.image, heatmap: d.Heatmap}
 	info, err := finder.find(tryHarder)
 	if err != nil {
+		if tryHarder && !d.SkipTwoPatterns {
+			if result, err := d.detectFromTwoPatterns(finder.possibleCenters); err == nil {
+				return result, nil
+			}
+		}
 		return nil, err
 	}
 	return d.processFinderPatternInfo(info)
 }
 
-func (d *Detector) processFinderPatternInfo(info *FinderPatternInfo) (*internal.DetectorResult, error) {
-	topLeft := info.TopLeft
-	topRight := info.TopRight
-	bottomLeft := info.BottomLeft
-
-	moduleSize := d.calculateModuleSize(topLeft, topRight, bottomLeft)
+func (d *Detector) processFinderPatternInfo(info *FinderPatternInfo) (*DetectorResult, error) {
+	moduleSize := d.calculateModuleSize(info.TopLeft, info.TopRight, info.BottomLeft)
 	if moduleSize < 1.0 {
 		return nil, zxinggo.ErrNotFound
 	}
+	return d.sampleSymbol(info, moduleSize)
+}
+
+// sampleSymbol samples the symbol located by info, whose modules are about
+// moduleSize pixels wide.
+func (d *Detector) sampleSymbol(info *FinderPatternInfo, moduleSize float64) (*DetectorResult, error) {
+	topLeft := info.TopLeft
+	topRight := info.TopRight
+	bottomLeft := info.BottomLeft
 
 	dimension, err := computeDimension(topLeft, topRight, bottomLeft, moduleSize)
 	if err != nil {
 		return nil, err
 	}
+	if d.Profile == zxinggo.ProfileStrict && measuredDimension(topLeft, topRight, bottomLeft, moduleSize) != dimension {
+		return nil, zxinggo.ErrNotFound
+	}
+	if !d.SkipGridFit && d.Profile != zxinggo.ProfileStrict {
+		if fitted := d.fitDimension(info, dimension); fitted != dimension {
+			// The measured module size was wrong; take it from the finder
+			// pattern spacing instead.
+			dimension = fitted
+			moduleSize = (distanceFP(topLeft, topRight) + distanceFP(topLeft, bottomLeft)) / 2 / float64(dimension-7)
+		}
+	}
 
 	provisionalVersion, err := decoder.GetProvisionalVersionForDimension(dimension)
 	if err != nil {
@@ -781,6 +892,7 @@ func (d *Detector) processFinderPatternInfo(info *FinderPatternInfo) (*internal.
 	}
 
 	var alignmentPattern *AlignmentPattern
+	alignment := AlignmentAbsent
 	if len(provisionalVersion.AlignmentPatternCenters) > 0 {
 		bottomRightX := topRight.X - topLeft.X + bottomLeft.X
 		bottomRightY := topRight.Y - topLeft.Y + bottomLeft.Y
@@ -790,13 +902,41 @@ func (d *Detector) processFinderPatternInfo(info *FinderPatternInfo) (*internal.
 		estAlignmentX := int(topLeft.X + correctionToTopLeft*(bottomRightX-topLeft.X))
 		estAlignmentY := int(topLeft.Y + correctionToTopLeft*(bottomRightY-topLeft.Y))
 
-		for i := 4; i <= 16; i <<= 1 {
+		maxAllowance := 16
+		switch d.Profile {
+		case zxinggo.ProfileStrict:
+			maxAllowance = 4
+		case zxinggo.ProfilePermissive:
+			maxAllowance = 32
+		}
+		alignment = AlignmentEstimated
+		for i := 4; i <= maxAllowance; i <<= 1 {
 			ap := d.findAlignmentInRegion(moduleSize, estAlignmentX, estAlignmentY, float64(i))
 			if ap != nil {
-				alignmentPattern = ap
+				alignmentPattern, alignment = ap, AlignmentFound
 				break
 			}
 		}
+		// The first search takes the first pattern it confirms, which in
+		// larger versions may be a neighbour of the one sought, or failing
+		// that an unconfirmed candidate.
+		if !d.SkipAlignmentRetry && d.Profile != zxinggo.ProfileStrict {
+			score := 0.0
+			if alignmentPattern != nil {
+				score = d.alignmentScore(info, alignmentPattern, provisionalVersion)
+			}
+			if score < minAlignmentScore {
+				if ap := d.retryAlignment(info, moduleSize, estAlignmentX, estAlignmentY, maxAllowance, provisionalVersion); ap != nil {
+					alignmentPattern, alignment = ap, AlignmentRetried
+				} else if alignmentPattern != nil && d.alignmentScore(info, nil, provisionalVersion) > score {
+					// The pattern found fits worse than the estimated corner.
+					alignmentPattern, alignment = nil, AlignmentEstimated
+				}
+			}
+		}
+		if alignmentPattern == nil && d.RequireAlignmentFrom > 0 && provisionalVersion.Number >= d.RequireAlignmentFrom {
+			return nil, zxinggo.ErrNotFound
+		}
 	}
 
 	xform := createTransform(topLeft, topRight, bottomLeft, alignmentPattern, dimension)
@@ -806,29 +946,203 @@ func (d *Detector) processFinderPatternInfo(info *FinderPatternInfo) (*internal.
 		return nil, err
 	}
 
-	var points []internal.ResultPoint
+	var points []zxinggo.ResultPoint
 	if alignmentPattern != nil {
-		points = []internal.ResultPoint{
+		points = []zxinggo.ResultPoint{
 			{X: bottomLeft.X, Y: bottomLeft.Y},
 			{X: topLeft.X, Y: topLeft.Y},
 			{X: topRight.X, Y: topRight.Y},
 			{X: alignmentPattern.X, Y: alignmentPattern.Y},
 		}
 	} else {
-		points = []internal.ResultPoint{
+		points = []zxinggo.ResultPoint{
 			{X: bottomLeft.X, Y: bottomLeft.Y},
 			{X: topLeft.X, Y: topLeft.Y},
 			{X: topRight.X, Y: topRight.Y},
 		}
 	}
 
-	return internal.NewDetectorResult(bits, points), nil
+	return &DetectorResult{*zxinggo.NewDetectorResult(bits, points), alignment}, nil
+}
+
+// minAlignmentScore is the fraction of the modules of a symbol's alignment
+// patterns that must sample as they should for an alignment pattern to be
+// taken as the one at the bottom-right corner.
+const minAlignmentScore = 0.8
+
+// retryAlignment searches again for the alignment pattern nearest the
+// bottom-right corner, when the first search, in squares of up to
+// maxAllowance modules around estX, estY, found none or a wrong one.
+// Perspective moves the pattern from where the parallelogram of the finder
+// patterns puts it, and shrinks or enlarges its modules beyond what the
+// first search tolerates. So the second covers twice the area, expects
+// modules of the size extrapolated from the finder patterns to the corner,
+// and of all the patterns it finds keeps the one through which the symbol
+// samples with its alignment patterns most nearly right.
+func (d *Detector) retryAlignment(info *FinderPatternInfo, moduleSize float64, estX, estY, maxAllowance int, version *decoder.Version) *AlignmentPattern {
+	cornerModuleSize := info.TopRight.EstimatedModuleSize + info.BottomLeft.EstimatedModuleSize - info.TopLeft.EstimatedModuleSize
+	cornerModuleSize = min(max(cornerModuleSize, moduleSize/2), moduleSize*2)
+	finder := d.alignmentFinder(cornerModuleSize, estX, estY, float64(2*maxAllowance)*moduleSize/cornerModuleSize)
+	if finder == nil {
+		return nil
+	}
+	var best *AlignmentPattern
+	bestScore := minAlignmentScore
+	for _, ap := range finder.findAll() {
+		if score := d.alignmentScore(info, ap, version); score >= bestScore {
+			best, bestScore = ap, score
+		}
+	}
+	return best
+}
+
+// alignmentScore samples the symbol with ap as the alignment pattern nearest
+// its bottom-right corner, or with the corner estimated if ap is nil, and
+// returns the fraction of the modules of its alignment patterns that are
+// dark or light as they should be.
+func (d *Detector) alignmentScore(info *FinderPatternInfo, ap *AlignmentPattern, version *decoder.Version) float64 {
+	dimension := version.DimensionForVersion()
+	xform := createTransform(info.TopLeft, info.TopRight, info.BottomLeft, ap, dimension)
+	bits, err := (&transform.DefaultGridSampler{}).SampleGridTransform(d.image, dimension, dimension, xform)
+	if err != nil {
+		return 0
+	}
+	matches, total := alignmentMatches(bits, version)
+	return float64(matches) / float64(total)
+}
+
+// fitDimension returns the symbol's dimension. The dimension measured from
+// the finder pattern spacing and the width of their rings is wrong when
+// printing or blur thickens or thins dark modules by more than it can round
+// off, so unless the timing patterns sample as they should at dimension,
+// each dimension within two versions of it is tried, and the one through
+// which the finder and alignment patterns alone sample most nearly right is
+// returned, if they sample well enough. The timing patterns, which may be what is damaged, are not
+// counted.
+func (d *Detector) fitDimension(info *FinderPatternInfo, dimension int) int {
+	if d.timingScoreAt(info, dimension) >= minTimingScore {
+		return dimension
+	}
+	best, bestScore := dimension, -1.0
+	for _, delta := range []int{0, -4, 4, -8, 8} {
+		version, err := decoder.GetProvisionalVersionForDimension(dimension + delta)
+		if err != nil {
+			continue
+		}
+		if score := d.gridScore(info, version); score > bestScore {
+			best, bestScore = dimension+delta, score
+		}
+	}
+	if bestScore < minGridScore {
+		// Nothing fits well, as in symbols seen at an angle, which the
+		// estimated corner skews; the measured dimension is likelier.
+		return dimension
+	}
+	return best
+}
+
+// minGridScore is the fraction of a symbol's finder and alignment pattern
+// modules that must sample as they should for a fitted dimension to be used
+// in place of the one measured.
+const minGridScore = 0.9
+
+// timingScoreAt is timingScore for a symbol of the given dimension located
+// by its finder patterns alone, looking up only the timing pattern modules
+// rather than sampling the whole symbol.
+func (d *Detector) timingScoreAt(info *FinderPatternInfo, dimension int) float64 {
+	if dimension < 21 {
+		return 0
+	}
+	xform := createTransform(info.TopLeft, info.TopRight, info.BottomLeft, nil, dimension)
+	n := dimension - 16
+	points := make([]float64, 0, 4*n)
+	for i := 8; i < dimension-8; i++ {
+		points = append(points, float64(i)+0.5, 6.5, 6.5, float64(i)+0.5)
+	}
+	xform.TransformPoints(points)
+	matches := 0
+	for k := 0; k < len(points); k += 2 {
+		x, y := int(points[k]), int(points[k+1])
+		if x < 0 || y < 0 || x >= d.image.Width() || y >= d.image.Height() {
+			continue
+		}
+		// Both patterns are dark at even positions.
+		if d.image.Get(x, y) == ((k/4)%2 == 0) {
+			matches++
+		}
+	}
+	return float64(matches) / float64(2*n)
+}
+
+// gridScore samples the symbol as being of the given version, with its
+// bottom-right corner estimated, and returns the fraction of the modules of
+// its finder patterns, their separators and its alignment patterns that are
+// dark or light as they should be.
+func (d *Detector) gridScore(info *FinderPatternInfo, version *decoder.Version) float64 {
+	dimension := version.DimensionForVersion()
+	xform := createTransform(info.TopLeft, info.TopRight, info.BottomLeft, nil, dimension)
+	bits, err := (&transform.DefaultGridSampler{}).SampleGridTransform(d.image, dimension, dimension, xform)
+	if err != nil {
+		return 0
+	}
+	matches, total := 0, 0
+	for _, c := range [][2]int{{3, 3}, {dimension - 4, 3}, {3, dimension - 4}} {
+		m, t := ringMatches(bits, c[0], c[1], 4, finderRings)
+		matches, total = matches+m, total+t
+	}
+	m, t := alignmentMatches(bits, version)
+	return float64(matches+m) / float64(total+t)
+}
+
+// finderRings and alignmentRings have bit r set if the ring r modules from
+// the centre of a finder or alignment pattern is dark; the outer ring of a
+// finder pattern's is its separator.
+const (
+	finderRings    = 1<<0 | 1<<1 | 1<<3
+	alignmentRings = 1<<0 | 1<<2
+)
+
+// ringMatches counts the modules of bits in the square of the given radius
+// around cx, cy that are dark or light as rings says, and the modules in the
+// square that lie in bits.
+func ringMatches(bits *bitutil.BitMatrix, cx, cy, radius int, rings uint) (matches, total int) {
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || y < 0 || x >= bits.Width() || y >= bits.Height() {
+				continue
+			}
+			dark := rings>>uint(max(intAbs(dx), intAbs(dy)))&1 != 0
+			if bits.Get(x, y) == dark {
+				matches++
+			}
+			total++
+		}
+	}
+	return matches, total
+}
+
+// alignmentMatches counts the modules of the alignment patterns of a symbol
+// of the given version sampled into bits that are dark or light as they
+// should be, and the modules of its alignment patterns.
+func alignmentMatches(bits *bitutil.BitMatrix, version *decoder.Version) (matches, total int) {
+	centers := version.AlignmentPatternCenters
+	last := len(centers) - 1
+	for i, cy := range centers {
+		for j, cx := range centers {
+			// Skip the positions taken by finder patterns.
+			if (i == 0 && (j == 0 || j == last)) || (i == last && j == 0) {
+				continue
+			}
+			m, t := ringMatches(bits, cx, cy, 2, alignmentRings)
+			matches, total = matches+m, total+t
+		}
+	}
+	return matches, total
 }
 
 func computeDimension(topLeft, topRight, bottomLeft *FinderPattern, moduleSize float64) (int, error) {
-	tltrCentersDimension := mathRound(distanceFP(topLeft, topRight) / moduleSize)
-	tlblCentersDimension := mathRound(distanceFP(topLeft, bottomLeft) / moduleSize)
-	dimension := (tltrCentersDimension+tlblCentersDimension)/2 + 7
+	dimension := measuredDimension(topLeft, topRight, bottomLeft, moduleSize)
 	switch dimension & 0x03 {
 	case 0:
 		dimension++
@@ -840,6 +1154,14 @@ func computeDimension(topLeft, topRight, bottomLeft *FinderPattern, moduleSize f
 	return dimension, nil
 }
 
+// measuredDimension estimates the symbol's size in modules from the distances
+// between its finder patterns, before rounding to a valid size.
+func measuredDimension(topLeft, topRight, bottomLeft *FinderPattern, moduleSize float64) int {
+	tltrCentersDimension := mathRound(distanceFP(topLeft, topRight) / moduleSize)
+	tlblCentersDimension := mathRound(distanceFP(topLeft, bottomLeft) / moduleSize)
+	return (tltrCentersDimension+tlblCentersDimension)/2 + 7
+}
+
 // mathRound matches Java's MathUtils.round: (int)(d + 0.5) for positive values.
 func mathRound(d float64) int {
 	if d < 0 {
@@ -976,6 +1298,17 @@ func createTransform(topLeft, topRight, bottomLeft *FinderPattern, alignmentPatt
 }
 
 func (d *Detector) findAlignmentInRegion(overallEstModuleSize float64, estAlignmentX, estAlignmentY int, allowanceFactor float64) *AlignmentPattern {
+	finder := d.alignmentFinder(overallEstModuleSize, estAlignmentX, estAlignmentY, allowanceFactor)
+	if finder == nil {
+		return nil
+	}
+	return finder.find()
+}
+
+// alignmentFinder returns a finder for alignment patterns within
+// allowanceFactor modules of estAlignmentX, estAlignmentY, or nil if the
+// area inside the image is too small to hold one.
+func (d *Detector) alignmentFinder(overallEstModuleSize float64, estAlignmentX, estAlignmentY int, allowanceFactor float64) *alignmentPatternFinder {
 	allowance := int(allowanceFactor * overallEstModuleSize)
 	alignmentAreaLeftX := max(0, estAlignmentX-allowance)
 	alignmentAreaRightX := min(d.image.Width()-1, estAlignmentX+allowance)
@@ -988,7 +1321,7 @@ func (d *Detector) findAlignmentInRegion(overallEstModuleSize float64, estAlignm
 		return nil
 	}
 
-	finder := &alignmentPatternFinder{
+	return &alignmentPatternFinder{
 		image:      d.image,
 		startX:     alignmentAreaLeftX,
 		startY:     alignmentAreaTopY,
@@ -996,7 +1329,6 @@ func (d *Detector) findAlignmentInRegion(overallEstModuleSize float64, estAlignm
 		height:     alignmentAreaBottomY - alignmentAreaTopY,
 		moduleSize: overallEstModuleSize,
 	}
-	return finder.find()
 }
 
 func intAbs(x int) int {
@@ -6,7 +6,6 @@ import (
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
-	"github.com/ericlevine/zxinggo/internal"
 )
 
 const (
@@ -17,7 +16,14 @@ const (
 )
 
 // DetectMulti detects multiple QR codes in the given image.
-func DetectMulti(image *bitutil.BitMatrix, tryHarder bool) ([]*internal.DetectorResult, error) {
+func DetectMulti(image *bitutil.BitMatrix, tryHarder bool) ([]*DetectorResult, error) {
+	return NewDetector(image).DetectMulti(tryHarder)
+}
+
+// DetectMulti detects multiple QR codes in the detector's image, locating
+// each as Detect does.
+func (d *Detector) DetectMulti(tryHarder bool) ([]*DetectorResult, error) {
+	image := d.image
 	finder := &finderPatternFinder{image: image}
 
 	// Run the multi-finder pattern scan
@@ -26,10 +32,9 @@ func DetectMulti(image *bitutil.BitMatrix, tryHarder bool) ([]*internal.Detector
 		return nil, err
 	}
 
-	det := &Detector{image: image}
-	var results []*internal.DetectorResult
+	var results []*DetectorResult
 	for _, info := range infos {
-		result, err := det.processFinderPatternInfo(info)
+		result, err := d.processFinderPatternInfo(info)
 		if err == nil {
 			results = append(results, result)
 		}
@@ -41,6 +46,26 @@ func DetectMulti(image *bitutil.BitMatrix, tryHarder bool) ([]*internal.Detector
 }
 
 func findMulti(f *finderPatternFinder, tryHarder bool) ([]*FinderPatternInfo, error) {
+	scanMulti(f, tryHarder)
+	patternGroups, err := selectMultipleBestPatterns(f.possibleCenters)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*FinderPatternInfo
+	for _, group := range patternGroups {
+		info := orderFinderPatterns(group[:])
+		result = append(result, info)
+	}
+	if len(result) == 0 {
+		return nil, zxinggo.ErrNotFound
+	}
+	return result, nil
+}
+
+// scanMulti scans the whole image for finder patterns, collecting them in
+// f.possibleCenters, without stopping once three are confirmed.
+func scanMulti(f *finderPatternFinder, tryHarder bool) {
 	image := f.image
 	maxI := image.Height()
 	maxJ := image.Width()
@@ -83,21 +108,6 @@ func findMulti(f *finderPatternFinder, tryHarder bool) ([]*FinderPatternInfo, er
 			f.handlePossibleCenter(stateCount, i, maxJ)
 		}
 	}
-
-	patternGroups, err := selectMultipleBestPatterns(f.possibleCenters)
-	if err != nil {
-		return nil, err
-	}
-
-	var result []*FinderPatternInfo
-	for _, group := range patternGroups {
-		info := orderFinderPatterns(group[:])
-		result = append(result, info)
-	}
-	if len(result) == 0 {
-		return nil, zxinggo.ErrNotFound
-	}
-	return result, nil
 }
 
 func selectMultipleBestPatterns(possibleCenters []*FinderPattern) ([][3]*FinderPattern, error) {
@@ -129,45 +139,21 @@ func selectMultipleBestPatterns(possibleCenters []*FinderPattern) ([][3]*FinderP
 		for i2 := i1 + 1; i2 < size-1; i2++ {
 			p2 := filtered[i2]
 
-			vModSize12A := math.Abs(p1.EstimatedModuleSize - p2.EstimatedModuleSize)
-			vModSize12 := vModSize12A / math.Min(p1.EstimatedModuleSize, p2.EstimatedModuleSize)
-			if vModSize12A > diffModSizeCutoff && vModSize12 >= diffModSizeCutoffPercent {
+			if moduleSizesDiffer(p1, p2) {
 				break
 			}
 
 			for i3 := i2 + 1; i3 < size; i3++ {
 				p3 := filtered[i3]
 
-				vModSize23A := math.Abs(p2.EstimatedModuleSize - p3.EstimatedModuleSize)
-				vModSize23 := vModSize23A / math.Min(p2.EstimatedModuleSize, p3.EstimatedModuleSize)
-				if vModSize23A > diffModSizeCutoff && vModSize23 >= diffModSizeCutoffPercent {
+				if moduleSizesDiffer(p2, p3) {
 					break
 				}
 
 				test := [3]*FinderPattern{p1, p2, p3}
-				// Order using the same ordering as single QR detection
-				ordered := orderFinderPatterns(test[:])
-
-				dA := distanceFP(ordered.TopLeft, ordered.BottomLeft)
-				dC := distanceFP(ordered.TopRight, ordered.BottomLeft)
-				dB := distanceFP(ordered.TopLeft, ordered.TopRight)
-
-				estimatedModuleCount := (dA + dB) / (p1.EstimatedModuleSize * 2.0)
-				if estimatedModuleCount > maxModuleCountPerEdge || estimatedModuleCount < minModuleCountPerEdge {
-					continue
-				}
-
-				vABBC := math.Abs((dA - dB) / math.Min(dA, dB))
-				if vABBC >= 0.1 {
-					continue
-				}
-
-				dCpy := math.Sqrt(dA*dA + dB*dB)
-				vPyC := math.Abs((dC - dCpy) / math.Min(dC, dCpy))
-				if vPyC >= 0.1 {
+				if _, rejection := tripleGeometry(test); rejection != TripleAccepted {
 					continue
 				}
-
 				results = append(results, test)
 			}
 		}
@@ -178,3 +164,39 @@ func selectMultipleBestPatterns(possibleCenters []*FinderPattern) ([][3]*FinderP
 	}
 	return results, nil
 }
+
+// moduleSizesDiffer reports whether finder patterns a and b have module
+// sizes too different for them to belong to one symbol.
+func moduleSizesDiffer(a, b *FinderPattern) bool {
+	diff := math.Abs(a.EstimatedModuleSize - b.EstimatedModuleSize)
+	return diff > diffModSizeCutoff && diff/math.Min(a.EstimatedModuleSize, b.EstimatedModuleSize) >= diffModSizeCutoffPercent
+}
+
+// tripleGeometry orders three finder patterns, the first with the largest
+// module size, as the corners of a symbol, and checks that they lie at the
+// corners of a plausibly sized square, seen at most slightly skewed.
+func tripleGeometry(test [3]*FinderPattern) (*FinderPatternInfo, TripleRejection) {
+	// Order using the same ordering as single QR detection
+	ordered := orderFinderPatterns(test[:])
+
+	dA := distanceFP(ordered.TopLeft, ordered.BottomLeft)
+	dC := distanceFP(ordered.TopRight, ordered.BottomLeft)
+	dB := distanceFP(ordered.TopLeft, ordered.TopRight)
+
+	estimatedModuleCount := (dA + dB) / (test[0].EstimatedModuleSize * 2.0)
+	if estimatedModuleCount > maxModuleCountPerEdge || estimatedModuleCount < minModuleCountPerEdge {
+		return ordered, TripleSizeOutOfRange
+	}
+
+	vABBC := math.Abs((dA - dB) / math.Min(dA, dB))
+	if vABBC >= 0.1 {
+		return ordered, TripleNotIsosceles
+	}
+
+	dCpy := math.Sqrt(dA*dA + dB*dB)
+	vPyC := math.Abs((dC - dCpy) / math.Min(dC, dCpy))
+	if vPyC >= 0.1 {
+		return ordered, TripleNotRightAngle
+	}
+	return ordered, TripleAccepted
+}
@@ -0,0 +1,115 @@
+package detector
+
+import "sort"
+
+// TripleRejection tells why three finder patterns were not read as the
+// corners of a QR code.
+type TripleRejection int
+
+const (
+	// TripleAccepted means the patterns passed every check.
+	TripleAccepted TripleRejection = iota
+	// TripleModuleSizeMismatch means the patterns' module sizes differ by
+	// more than 5% and half a pixel.
+	TripleModuleSizeMismatch
+	// TripleSizeOutOfRange means the patterns are too close together or
+	// too far apart, for their module size, for a symbol of 9 to 180
+	// modules a side.
+	TripleSizeOutOfRange
+	// TripleNotIsosceles means the sides from the top-left pattern to the
+	// other two differ in length by 10% or more.
+	TripleNotIsosceles
+	// TripleNotRightAngle means the patterns do not lie at a right angle,
+	// the diagonal differing by 10% or more from what the sides imply.
+	TripleNotRightAngle
+	// TripleOverBudget means the patterns were plausible but not sampled,
+	// as the budget of triples to try was spent.
+	TripleOverBudget
+	// TripleSharesPattern means the patterns were plausible but one of
+	// them belongs to a symbol already read.
+	TripleSharesPattern
+	// TripleSampleFailed means no symbol could be sampled between the
+	// patterns: its dimension, alignment pattern or grid did not fit.
+	TripleSampleFailed
+	// TripleDecodeFailed means a symbol was sampled but did not decode.
+	TripleDecodeFailed
+)
+
+var tripleRejectionNames = [...]string{
+	TripleAccepted:           "accepted",
+	TripleModuleSizeMismatch: "module size mismatch",
+	TripleSizeOutOfRange:     "size out of range",
+	TripleNotIsosceles:       "not isosceles",
+	TripleNotRightAngle:      "not a right angle",
+	TripleOverBudget:         "over budget",
+	TripleSharesPattern:      "shares a pattern",
+	TripleSampleFailed:       "sample failed",
+	TripleDecodeFailed:       "decode failed",
+}
+
+// String returns a short description of the rejection, such as "not
+// isosceles".
+func (r TripleRejection) String() string {
+	if r >= 0 && int(r) < len(tripleRejectionNames) {
+		return tripleRejectionNames[r]
+	}
+	return "unknown"
+}
+
+// FinderTriple is three finder patterns, ordered as the corners of a
+// symbol, and the outcome of the geometric checks DetectMulti applies to
+// them.
+type FinderTriple struct {
+	FinderPatternInfo
+	Rejection TripleRejection
+}
+
+// FinderCandidates scans the whole image for finder patterns, as
+// DetectMulti does, and returns those confirmed on at least two rows, the
+// largest modules first. Images with many symbols, or decorations that look
+// like finder patterns, yield many.
+func (d *Detector) FinderCandidates(tryHarder bool) []*FinderPattern {
+	finder := &finderPatternFinder{image: d.image, heatmap: d.Heatmap}
+	scanMulti(finder, tryHarder)
+	var candidates []*FinderPattern
+	for _, fp := range finder.possibleCenters {
+		if fp.Count >= centerQuorum {
+			candidates = append(candidates, fp)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[j].EstimatedModuleSize < candidates[i].EstimatedModuleSize
+	})
+	return candidates
+}
+
+// Triples returns every combination of three of candidates, ordered as the
+// corners of a symbol and checked as DetectMulti checks them. Those that
+// pass have Rejection TripleAccepted. There are n(n-1)(n-2)/6 of them for n
+// candidates.
+func Triples(candidates []*FinderPattern) []FinderTriple {
+	sorted := append([]*FinderPattern(nil), candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[j].EstimatedModuleSize < sorted[i].EstimatedModuleSize
+	})
+	var triples []FinderTriple
+	for i1 := 0; i1 < len(sorted)-2; i1++ {
+		for i2 := i1 + 1; i2 < len(sorted)-1; i2++ {
+			for i3 := i2 + 1; i3 < len(sorted); i3++ {
+				test := [3]*FinderPattern{sorted[i1], sorted[i2], sorted[i3]}
+				info, rejection := tripleGeometry(test)
+				if moduleSizesDiffer(test[0], test[1]) || moduleSizesDiffer(test[1], test[2]) {
+					rejection = TripleModuleSizeMismatch
+				}
+				triples = append(triples, FinderTriple{FinderPatternInfo: *info, Rejection: rejection})
+			}
+		}
+	}
+	return triples
+}
+
+// SampleTriple samples the symbol whose finder patterns are info, as Detect
+// does once it has found them.
+func (d *Detector) SampleTriple(info *FinderPatternInfo) (*DetectorResult, error) {
+	return d.processFinderPatternInfo(info)
+}
@@ -0,0 +1,114 @@
+package detector
+
+import (
+	"math"
+	"sort"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+)
+
+// maxTwoPatternCandidates is the most confirmed finder patterns paired up
+// when looking for a symbol with one finder pattern missing.
+const maxTwoPatternCandidates = 6
+
+// minTimingScore is the fraction of timing pattern modules that must
+// alternate as they should for a symbol with an inferred finder pattern to
+// be accepted, or for a symbol's measured dimension to be trusted.
+const minTimingScore = 0.8
+
+// detectFromTwoPatterns looks for a symbol of which only two finder patterns
+// were found, such as a torn label with one corner missing. Each pair of
+// found patterns could be two ends of a side or the ends of the diagonal, so
+// the missing pattern is placed at each position that completes a square,
+// and the placement whose sampled timing patterns alternate best is kept.
+// The placements assume the symbol is seen nearly square on.
+func (d *Detector) detectFromTwoPatterns(possibleCenters []*FinderPattern) (*DetectorResult, error) {
+	var confirmed []*FinderPattern
+	for _, p := range possibleCenters {
+		if p.Count >= centerQuorum {
+			confirmed = append(confirmed, p)
+		}
+	}
+	sort.SliceStable(confirmed, func(i, j int) bool {
+		return confirmed[i].Count > confirmed[j].Count
+	})
+	if len(confirmed) > maxTwoPatternCandidates {
+		confirmed = confirmed[:maxTwoPatternCandidates]
+	}
+
+	var best *DetectorResult
+	bestScore := minTimingScore
+	for i, p := range confirmed {
+		for _, q := range confirmed[i+1:] {
+			small, large := p.EstimatedModuleSize, q.EstimatedModuleSize
+			if small > large {
+				small, large = large, small
+			}
+			if large > small*1.4 {
+				continue
+			}
+			moduleSize := (small + large) / 2
+			for _, third := range thirdPatternCandidates(p, q, moduleSize) {
+				info := orderFinderPatterns([]*FinderPattern{p, q, third})
+				result, err := d.sampleSymbol(info, moduleSize)
+				if err != nil {
+					continue
+				}
+				if score := timingScore(result); score > bestScore {
+					best, bestScore = result, score
+				}
+			}
+		}
+	}
+	if best == nil {
+		return nil, zxinggo.ErrNotFound
+	}
+	return best, nil
+}
+
+// thirdPatternCandidates returns where the third finder pattern of a symbol
+// with modules moduleSize wide could be, given two of them: beside either
+// end if they share a side, or across the middle if they span the diagonal.
+func thirdPatternCandidates(p, q *FinderPattern, moduleSize float64) []*FinderPattern {
+	dx, dy := q.X-p.X, q.Y-p.Y
+	modules := math.Sqrt(dx*dx+dy*dy) / moduleSize
+	pattern := func(x, y float64) *FinderPattern {
+		return &FinderPattern{X: x, Y: y, EstimatedModuleSize: moduleSize}
+	}
+	var candidates []*FinderPattern
+	// Finder pattern centres are 14 to 170 modules apart along a side.
+	if modules >= 14 && modules <= 170 {
+		candidates = append(candidates,
+			pattern(p.X-dy, p.Y+dx), pattern(p.X+dy, p.Y-dx),
+			pattern(q.X-dy, q.Y+dx), pattern(q.X+dy, q.Y-dx))
+	}
+	if modules >= 14*math.Sqrt2 && modules <= 170*math.Sqrt2 {
+		mx, my := (p.X+q.X)/2, (p.Y+q.Y)/2
+		candidates = append(candidates,
+			pattern(mx-dy/2, my+dx/2), pattern(mx+dy/2, my-dx/2))
+	}
+	return candidates
+}
+
+// timingScore returns the fraction of the modules of a sampled symbol's
+// timing patterns, along row and column 6 between the finder patterns, that
+// alternate between dark and light as they should.
+func timingScore(result *DetectorResult) float64 {
+	bits := result.Bits
+	dimension := bits.Width()
+	matches, total := 0, 0
+	for i := 8; i < dimension-8; i++ {
+		dark := i%2 == 0
+		if bits.Get(i, 6) == dark {
+			matches++
+		}
+		if bits.Get(6, i) == dark {
+			matches++
+		}
+		total += 2
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(matches) / float64(total)
+}
@@ -8,6 +8,7 @@ import (
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/charset"
 	"github.com/ericlevine/zxinggo/qrcode/decoder"
 	"github.com/ericlevine/zxinggo/reedsolomon"
 )
@@ -60,6 +61,27 @@ type QRCode struct {
 	Version     *decoder.Version
 	MaskPattern int
 	Matrix      *ByteMatrix
+
+	// Segments lists the mode indicators written, in order.
+	Segments []Segment
+
+	// DataBits is the length of the encoded message in bits, before the
+	// terminator and padding.
+	DataBits int
+}
+
+// Segment is a mode indicator in an encoded message and the number of
+// characters it covers, or of bytes in byte mode. It is zero for ECI and
+// FNC1 indicators.
+type Segment struct {
+	Mode       decoder.Mode
+	Characters int
+}
+
+// Utilization returns the fraction, from 0 to 1, of the symbol's data
+// capacity that the message fills before padding.
+func (qr *QRCode) Utilization() float64 {
+	return float64(qr.DataBits) / float64(8*qr.Version.DataCodewords(qr.ECLevel))
 }
 
 // alphanumericTable maps ASCII values to alphanumeric codes.
@@ -84,9 +106,14 @@ func GetAlphanumericCode(code int) int {
 
 // ChooseMode determines the best encoding mode for the content.
 func ChooseMode(content string) decoder.Mode {
+	return chooseMode([]byte(content))
+}
+
+// chooseMode determines the best encoding mode for data.
+func chooseMode(data []byte) decoder.Mode {
 	hasNumeric := false
 	hasAlphanumeric := false
-	for _, c := range content {
+	for _, c := range data {
 		if c >= '0' && c <= '9' {
 			hasNumeric = true
 		} else if GetAlphanumericCode(int(c)) != -1 {
@@ -104,17 +131,130 @@ func ChooseMode(content string) decoder.Mode {
 	return decoder.ModeByte
 }
 
+// Hints holds optional parameters for EncodeWithHints.
+type Hints struct {
+	// Version forces a specific version (1-40); 0 picks the smallest that fits.
+	Version int
+
+	// MaskPattern forces a specific mask pattern (0-7); -1 picks the best.
+	MaskPattern int
+
+	// GS1Format marks the content as a GS1 element string by emitting the
+	// FNC1 in first position mode indicator.
+	GS1Format bool
+
+	// ApplicationIndicator, if set, marks the content as data of the AIM
+	// application it names, a letter or two digits, by emitting the FNC1 in
+	// second position mode indicator. It excludes GS1Format.
+	ApplicationIndicator string
+
+	// BoostECLevel raises the error correction level to the highest that
+	// still fits in the chosen version.
+	BoostECLevel bool
+
+	// CharacterSet, if set, names the character set of the content, such as
+	// "UTF-8" or "ISO-8859-1", and an ECI designator for it is written
+	// before content encoded in byte mode, so that readers interpret the
+	// bytes correctly. Empty writes no ECI.
+	CharacterSet string
+}
+
+// applicationIndicatorValue returns the byte that follows the FNC1 in
+// second position mode indicator: the value of two digits, or the ASCII
+// code of a letter plus 100.
+func applicationIndicatorValue(ai string) (int, error) {
+	switch {
+	case len(ai) == 2 && '0' <= ai[0] && ai[0] <= '9' && '0' <= ai[1] && ai[1] <= '9':
+		return int(ai[0]-'0')*10 + int(ai[1]-'0'), nil
+	case len(ai) == 1 && ('A' <= ai[0] && ai[0] <= 'Z' || 'a' <= ai[0] && ai[0] <= 'z'):
+		return int(ai[0]) + 100, nil
+	}
+	return 0, fmt.Errorf("%w: invalid application indicator %q", zxinggo.ErrWriter, ai)
+}
+
 // Encode encodes content into a QRCode.
 func Encode(content string, ecLevel decoder.ErrorCorrectionLevel, qrVersion int, maskPattern int) (*QRCode, error) {
-	mode := ChooseMode(content)
+	return EncodeWithHints(content, ecLevel, &Hints{Version: qrVersion, MaskPattern: maskPattern})
+}
+
+// EncodeWithHints encodes content into a QRCode using the given hints. If
+// hints.CharacterSet is set, content is converted from UTF-8 to that
+// character set, failing if it cannot represent every character, and an ECI
+// for it is written. Otherwise the UTF-8 bytes of content are written as
+// they are with no ECI, which readers that guess the character set read
+// back correctly unless the text is also valid in another one, such as
+// Shift_JIS; set CharacterSet to "UTF-8" to be sure. Binary data should be
+// encoded with EncodeBytesWithHints.
+func EncodeWithHints(content string, ecLevel decoder.ErrorCorrectionLevel, hints *Hints) (*QRCode, error) {
+	data := []byte(content)
+	if hints != nil && hints.CharacterSet != "" {
+		eci, err := characterSetECI(hints.CharacterSet)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = charset.EncodeString(content, eci); err != nil {
+			return nil, fmt.Errorf("%w: %v", zxinggo.ErrWriter, err)
+		}
+	}
+	return EncodeBytesWithHints(data, ecLevel, hints)
+}
+
+// characterSetECI returns the ECI for the character set called name.
+func characterSetECI(name string) (*charset.ECI, error) {
+	eci := charset.GetECIByName(name)
+	if eci == nil {
+		return nil, fmt.Errorf("%w: unsupported character set %q", zxinggo.ErrWriter, name)
+	}
+	return eci, nil
+}
+
+// EncodeBytesWithHints encodes data into a QRCode using the given hints,
+// byte for byte, so that any binary payload survives. If
+// hints.CharacterSet is set, an ECI designator for it tells readers what
+// character set data is in; data is not converted. Without one, readers
+// guess at a character set for the text they return, but report the bytes
+// unchanged in their byte segments.
+func EncodeBytesWithHints(data []byte, ecLevel decoder.ErrorCorrectionLevel, hints *Hints) (*QRCode, error) {
+	if hints == nil {
+		hints = &Hints{MaskPattern: -1}
+	}
+	qrVersion := hints.Version
+	maskPattern := hints.MaskPattern
+	mode := chooseMode(data)
 
 	// Build header bits
+	var segments []Segment
 	headerBits := bitutil.NewBitArray(0)
+	if hints.CharacterSet != "" && mode == decoder.ModeByte {
+		eci, err := characterSetECI(hints.CharacterSet)
+		if err != nil {
+			return nil, err
+		}
+		appendECI(eci.Value, headerBits)
+		segments = append(segments, Segment{Mode: decoder.ModeECI})
+	}
+	if hints.GS1Format && hints.ApplicationIndicator != "" {
+		return nil, fmt.Errorf("%w: GS1 format and an application indicator are exclusive", zxinggo.ErrWriter)
+	}
+	if hints.GS1Format {
+		headerBits.AppendBits(uint32(decoder.ModeFNC1FirstPosition.Bits()), 4)
+		segments = append(segments, Segment{Mode: decoder.ModeFNC1FirstPosition})
+	}
+	if hints.ApplicationIndicator != "" {
+		value, err := applicationIndicatorValue(hints.ApplicationIndicator)
+		if err != nil {
+			return nil, err
+		}
+		headerBits.AppendBits(uint32(decoder.ModeFNC1SecondPosition.Bits()), 4)
+		headerBits.AppendBits(uint32(value), 8)
+		segments = append(segments, Segment{Mode: decoder.ModeFNC1SecondPosition})
+	}
 	headerBits.AppendBits(uint32(mode.Bits()), 4)
+	segments = append(segments, Segment{Mode: mode, Characters: len(data)})
 
 	// Build data bits
 	dataBits := bitutil.NewBitArray(0)
-	if err := appendBytes(content, mode, dataBits); err != nil {
+	if err := appendBytes(data, mode, dataBits); err != nil {
 		return nil, err
 	}
 
@@ -134,12 +274,21 @@ func Encode(content string, ecLevel decoder.ErrorCorrectionLevel, qrVersion int,
 	}
 
 	// Complete header with character count
-	numLetters := len(content)
+	numLetters := len(data)
 	countBits := mode.CharacterCountBits(version)
+	if hints.BoostECLevel {
+		numInputBits := headerBits.Size() + countBits + dataBits.Size()
+		for level := ecLevel + 1; level <= decoder.ECLevelH; level++ {
+			if willFit(numInputBits, version, level) {
+				ecLevel = level
+			}
+		}
+	}
 	headerBits.AppendBits(uint32(numLetters), countBits)
 
 	// Combine header and data
 	headerBits.AppendBitArray(dataBits)
+	messageBits := headerBits.Size()
 
 	// Calculate total data bytes
 	ecBlocks := version.ECBlocksForLevel(ecLevel)
@@ -163,6 +312,8 @@ func Encode(content string, ecLevel decoder.ErrorCorrectionLevel, qrVersion int,
 		ECLevel:     ecLevel,
 		Version:     version,
 		MaskPattern: -1,
+		Segments:    segments,
+		DataBits:    messageBits,
 	}
 
 	dimension := version.DimensionForVersion()
@@ -185,15 +336,21 @@ func chooseVersion(mode decoder.Mode, headerBits *bitutil.BitArray, dataBits *bi
 	for versionNum := 1; versionNum <= 40; versionNum++ {
 		version, _ := decoder.GetVersionForNumber(versionNum)
 		totalBits := headerBits.Size() + mode.CharacterCountBits(version) + dataBits.Size()
-		ecBlocks := version.ECBlocksForLevel(ecLevel)
-		numDataBytes := version.TotalCodewords - ecBlocks.TotalECCodewords()
-		if totalBits <= numDataBytes*8 {
+		if willFit(totalBits, version, ecLevel) {
 			return version, nil
 		}
 	}
 	return nil, fmt.Errorf("%w: data too large", zxinggo.ErrWriter)
 }
 
+// willFit reports whether numInputBits bits fit in the data codewords of
+// version at ecLevel.
+func willFit(numInputBits int, version *decoder.Version, ecLevel decoder.ErrorCorrectionLevel) bool {
+	ecBlocks := version.ECBlocksForLevel(ecLevel)
+	numDataBytes := version.TotalCodewords - ecBlocks.TotalECCodewords()
+	return numInputBits <= numDataBytes*8
+}
+
 func terminateBits(numDataBytes int, bits *bitutil.BitArray) error {
 	capacity := numDataBytes * 8
 	if bits.Size() > capacity {
@@ -225,7 +382,20 @@ func terminateBits(numDataBytes int, bits *bitutil.BitArray) error {
 	return nil
 }
 
-func appendBytes(content string, mode decoder.Mode, bits *bitutil.BitArray) error {
+// appendECI appends an ECI designator for value.
+func appendECI(value int, bits *bitutil.BitArray) {
+	bits.AppendBits(uint32(decoder.ModeECI.Bits()), 4)
+	switch {
+	case value < 1<<7:
+		bits.AppendBits(uint32(value), 8)
+	case value < 1<<14:
+		bits.AppendBits(uint32(0b10<<14|value), 16)
+	default:
+		bits.AppendBits(uint32(0b110<<21|value), 24)
+	}
+}
+
+func appendBytes(content []byte, mode decoder.Mode, bits *bitutil.BitArray) error {
 	switch mode {
 	case decoder.ModeNumeric:
 		return appendNumericBytes(content, bits)
@@ -238,7 +408,7 @@ func appendBytes(content string, mode decoder.Mode, bits *bitutil.BitArray) erro
 	}
 }
 
-func appendNumericBytes(content string, bits *bitutil.BitArray) error {
+func appendNumericBytes(content []byte, bits *bitutil.BitArray) error {
 	length := len(content)
 	i := 0
 	for i < length {
@@ -260,7 +430,7 @@ func appendNumericBytes(content string, bits *bitutil.BitArray) error {
 	return nil
 }
 
-func appendAlphanumericBytes(content string, bits *bitutil.BitArray) error {
+func appendAlphanumericBytes(content []byte, bits *bitutil.BitArray) error {
 	length := len(content)
 	i := 0
 	for i < length {
@@ -283,7 +453,7 @@ func appendAlphanumericBytes(content string, bits *bitutil.BitArray) error {
 	return nil
 }
 
-func append8BitBytes(content string, bits *bitutil.BitArray) error {
+func append8BitBytes(content []byte, bits *bitutil.BitArray) error {
 	for i := 0; i < len(content); i++ {
 		bits.AppendBits(uint32(content[i]), 8)
 	}
@@ -686,7 +856,23 @@ func maybeEmbedVersionInfo(version *decoder.Version, matrix *ByteMatrix) {
 }
 
 func embedDataBits(dataBits *bitutil.BitArray, maskPattern int, matrix *ByteMatrix) {
-	bitIndex := 0
+	for bitIndex, p := range DataModuleOrder(matrix) {
+		x, y := p[0], p[1]
+		bit := bitIndex < dataBits.Size() && dataBits.Get(bitIndex)
+		// Apply mask
+		if decoder.DataMasks[maskPattern](y, x) {
+			bit = !bit
+		}
+		matrix.SetBool(x, y, bit)
+	}
+}
+
+// DataModuleOrder returns the x, y positions of the empty (0xFF) modules of
+// matrix in the order codeword bits are placed in them: in pairs of
+// columns from the right, alternately upward and downward, skipping the
+// vertical timing pattern.
+func DataModuleOrder(matrix *ByteMatrix) [][2]int {
+	var order [][2]int
 	dimension := matrix.Height
 
 	for j := dimension - 1; j > 0; j -= 2 {
@@ -702,24 +888,26 @@ func embedDataBits(dataBits *bitutil.BitArray, maskPattern int, matrix *ByteMatr
 			for col := 0; col < 2; col++ {
 				x := j - col
 				if matrix.Get(x, i) == 0xFF { // empty cell
-					var bit bool
-					if bitIndex < dataBits.Size() {
-						bit = dataBits.Get(bitIndex)
-						bitIndex++
-					}
-					// Apply mask
-					if decoder.DataMasks[maskPattern](i, x) {
-						bit = !bit
-					}
-					if bit {
-						matrix.Set(x, i, 1)
-					} else {
-						matrix.Set(x, i, 0)
-					}
+					order = append(order, [2]int{x, i})
 				}
 			}
 		}
 	}
+	return order
+}
+
+// FunctionPatterns returns a matrix of the given version with its finder,
+// separator, timing and alignment patterns, and its format information
+// for ecLevel and maskPattern and version information, set as Encode sets
+// them. The data modules are left empty, 0xFF.
+func FunctionPatterns(version *decoder.Version, ecLevel decoder.ErrorCorrectionLevel, maskPattern int) *ByteMatrix {
+	dimension := version.DimensionForVersion()
+	matrix := NewByteMatrix(dimension, dimension)
+	matrix.Clear(0xFF)
+	embedBasicPatterns(version, matrix)
+	embedTypeInfo(ecLevel, maskPattern, matrix)
+	maybeEmbedVersionInfo(version, matrix)
+	return matrix
 }
 
 func calculateBCHCode(value, poly int) int {
@@ -1,11 +1,21 @@
 package qrcode
 
 import (
+	"errors"
+	"image"
+	"image/color"
+	"slices"
+	"sync"
 	"testing"
 
 	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/internal/symbolgen"
 	"github.com/ericlevine/zxinggo/qrcode/decoder"
+	"github.com/ericlevine/zxinggo/qrcode/detector"
 	"github.com/ericlevine/zxinggo/qrcode/encoder"
+	"github.com/ericlevine/zxinggo/transform"
 )
 
 func TestRoundTripNumeric(t *testing.T) {
@@ -82,6 +92,81 @@ func TestWriterEmptyContents(t *testing.T) {
 	}
 }
 
+func TestEncodeBytes(t *testing.T) {
+	// Every byte value, which is not valid UTF-8 or text in any character
+	// set, comes back unchanged in the byte segments.
+	data := make([]byte, 256)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	code, err := encoder.EncodeBytesWithHints(data, decoder.ECLevelL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := decoder.NewDecoder().Decode(code.ToBitMatrix(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.ByteSegments) != 1 || string(result.ByteSegments[0]) != string(data) {
+		t.Errorf("byte segments %x, want %x", result.ByteSegments, data)
+	}
+
+	// Through the writer, the symbol decodes from an image.
+	matrix, err := zxinggo.EncodeBytes(data[:64], zxinggo.FormatQRCode, 300, 300, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(zxinggo.NewGrayImageLuminanceSource(zxinggo.BitMatrixToImage(matrix))))
+	decoded, err := NewReader().Decode(bitmap, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if segments, _ := decoded.Metadata[zxinggo.MetadataByteSegments].([][]byte); len(segments) != 1 || string(segments[0]) != string(data[:64]) {
+		t.Errorf("decoded byte segments %x, want %x", segments, data[:64])
+	}
+}
+
+func TestEncodeCharacterSet(t *testing.T) {
+	tests := []struct {
+		content, characterSet string
+		raw                   string
+	}{
+		{"café 日本", "UTF-8", "café 日本"},
+		{"café", "ISO-8859-1", "caf\xe9"},
+		{"日本", "Shift_JIS", "\x93\xfa\x96{"},
+	}
+	for _, tt := range tests {
+		code, err := encoder.EncodeWithHints(tt.content, decoder.ECLevelL, &encoder.Hints{MaskPattern: -1, CharacterSet: tt.characterSet})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.characterSet, err)
+		}
+		result, err := decoder.NewDecoder().Decode(code.ToBitMatrix(), "")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.characterSet, err)
+		}
+		if result.Text != tt.content {
+			t.Errorf("%s: decoded %q, want %q", tt.characterSet, result.Text, tt.content)
+		}
+		if string(result.ByteSegments[0]) != tt.raw {
+			t.Errorf("%s: bytes %q, want %q", tt.characterSet, result.ByteSegments[0], tt.raw)
+		}
+		// Modifier 2 marks ECI, in the ]Q2 symbology identifier.
+		if result.SymbologyModifier != 2 {
+			t.Errorf("%s: symbology modifier %d, want 2 for an ECI", tt.characterSet, result.SymbologyModifier)
+		}
+	}
+
+	if _, err := encoder.EncodeWithHints("日本", decoder.ECLevelL, &encoder.Hints{CharacterSet: "ISO-8859-1"}); !errors.Is(err, zxinggo.ErrWriter) {
+		t.Errorf("unencodable content: error %v, want ErrWriter", err)
+	}
+	if _, err := encoder.EncodeWithHints("A", decoder.ECLevelL, &encoder.Hints{CharacterSet: "EBCDIC"}); !errors.Is(err, zxinggo.ErrWriter) {
+		t.Errorf("unknown character set: error %v, want ErrWriter", err)
+	}
+	if _, err := zxinggo.EncodeBytes([]byte("A"), zxinggo.FormatAztec, 100, 100, nil); !errors.Is(err, zxinggo.ErrWriter) {
+		t.Errorf("EncodeBytes for Aztec: error %v, want ErrWriter", err)
+	}
+}
+
 func testRoundTrip(t *testing.T, content string, ecLevel decoder.ErrorCorrectionLevel) {
 	t.Helper()
 
@@ -107,3 +192,885 @@ func testRoundTrip(t *testing.T, content string, ecLevel decoder.ErrorCorrection
 		t.Errorf("round-trip mismatch: got %q, want %q", result.Text, content)
 	}
 }
+
+// matrixDump is a module grid as written by barcodescan -dump-matrix, with
+// two modules damaged and trailing spaces lost.
+const matrixDump = `X X X X X X X               X X X X X X X
+X           X   X X X       X           X
+X   X X X   X         X     X   X X X   X
+X   X X X   X       X   X   X   X X X   X
+X   X X X   X   X   X X X   X   X X X   X
+X           X       X X     X           X
+X X X X X X X   X   X   X   X X X X X X X
+                  X
+X   X   X   X           X       X     X
+X   X X X       X     X   X   X
+  X X X   X X     X X X   X X X   X X X
+    X X X     X     X X X X   X X   X X
+X X X X   X X         X   X X X         X
+                X           X X   X X X X
+X X X X X X X     X X   X       X   X   X
+X           X       X       X X   X   X
+X   X X X   X   X X X   X   X X     X X X
+X   X X X   X     X X X   X       X X X
+X   X X X   X   X X   X   X X X X X     X
+X           X     X   X X X   X     X X X
+X X X X X X X   X X X X   X X X X   X   X
+`
+
+func TestDecodeMatrixDump(t *testing.T) {
+	bits, err := bitutil.ParseBitMatrix(matrixDump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := DecodeMatrix(bits, nil)
+	if err != nil {
+		t.Fatalf("DecodeMatrix failed: %v", err)
+	}
+	if result.Text != "BUG 1234" {
+		t.Errorf("got %q, want %q", result.Text, "BUG 1234")
+	}
+	if got := result.Metadata[zxinggo.MetadataErrorsCorrected]; got != 2 {
+		t.Errorf("errors corrected = %v, want 2", got)
+	}
+}
+
+func TestErrorBudget(t *testing.T) {
+	bits, err := bitutil.ParseBitMatrix(matrixDump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The dump needs 2 codewords corrected.
+	for _, tc := range []struct {
+		budget map[zxinggo.Format]int
+		ok     bool
+	}{
+		{nil, true},
+		{map[zxinggo.Format]int{zxinggo.FormatQRCode: 2}, true},
+		{map[zxinggo.Format]int{zxinggo.FormatQRCode: 1}, false},
+		{map[zxinggo.Format]int{zxinggo.FormatDataMatrix: 0}, true},
+	} {
+		opts := &zxinggo.DecodeOptions{MaxErrorsCorrected: tc.budget}
+		result, err := NewReader().Decode(renderKeystone(bits, 4, 0), opts)
+		if tc.ok && (err != nil || result.Text != "BUG 1234") {
+			t.Errorf("budget %v: got %v, %v; want BUG 1234", tc.budget, result, err)
+		}
+		if !tc.ok && !errors.Is(err, zxinggo.ErrChecksum) {
+			t.Errorf("budget %v: got %v, %v; want ErrChecksum", tc.budget, result, err)
+		}
+	}
+}
+
+func TestDumpCodewords(t *testing.T) {
+	code, err := encoder.Encode("DUMP ME", decoder.ECLevelL, 0, 0)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	bits := code.ToBitMatrix()
+	// Invert the bottom-right corner, where the first codewords are placed.
+	size := bits.Width()
+	for y := size - 6; y < size; y++ {
+		for x := size - 6; x < size; x++ {
+			bits.Flip(x, y)
+		}
+	}
+
+	if _, err := decoder.NewDecoder().Decode(bits.Clone(), ""); !errors.Is(err, zxinggo.ErrChecksum) {
+		t.Fatalf("Decode = %v, want ErrChecksum", err)
+	}
+	dec := decoder.NewDecoder()
+	dec.DumpCodewords = true
+	_, err = dec.Decode(bits, "")
+	var decodeErr *zxinggo.DecodeError
+	if !errors.As(err, &decodeErr) || !errors.Is(err, zxinggo.ErrChecksum) {
+		t.Fatalf("Decode = %v, want a DecodeError", err)
+	}
+	if decodeErr.Format != zxinggo.FormatQRCode || len(decodeErr.Blocks) != 1 {
+		t.Fatalf("got %s with %d blocks, want QR_CODE with 1", decodeErr.Format, len(decodeErr.Blocks))
+	}
+	block := decodeErr.Blocks[0]
+	if len(block.Codewords) != 26 || block.DataCodewords != 19 || len(block.Syndromes) != 7 || block.Correctable {
+		t.Errorf("block has %d codewords, %d data, %d syndromes, correctable %v; want 26, 19, 7, false",
+			len(block.Codewords), block.DataCodewords, len(block.Syndromes), block.Correctable)
+	}
+}
+
+func TestReaderSharedByGoroutines(t *testing.T) {
+	code, err := encoder.Encode("DUMP ME", decoder.ECLevelL, 0, 0)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	bits := code.ToBitMatrix()
+	size := bits.Width()
+	for y := size - 6; y < size; y++ {
+		for x := size - 6; x < size; x++ {
+			bits.Flip(x, y)
+		}
+	}
+
+	// Options passed to one call must not leak into another running at
+	// the same time on the same Reader.
+	reader := NewReader()
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func(dump bool) {
+			defer wg.Done()
+			_, err := reader.Decode(renderKeystone(bits, 4, 0), &zxinggo.DecodeOptions{DumpCodewords: dump})
+			var decodeErr *zxinggo.DecodeError
+			if errors.As(err, &decodeErr) != dump {
+				t.Errorf("DumpCodewords %v: Decode = %v", dump, err)
+			}
+		}(i%2 == 0)
+	}
+	wg.Wait()
+}
+
+func TestRoundTripGS1(t *testing.T) {
+	content := "0109506000134352" + "10ABC123\x1d" + "17201225"
+	code, err := encoder.EncodeWithHints(content, decoder.ECLevelM, &encoder.Hints{MaskPattern: -1, GS1Format: true})
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	result, err := decoder.NewDecoder().Decode(code.ToBitMatrix(), "")
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if result.Text != content {
+		t.Errorf("round-trip mismatch: got %q, want %q", result.Text, content)
+	}
+	if result.SymbologyModifier != 3 {
+		t.Errorf("symbology modifier = %d, want 3 (FNC1 first position)", result.SymbologyModifier)
+	}
+}
+
+func TestRoundTripApplicationIndicator(t *testing.T) {
+	for _, tc := range []struct{ ai, content, want string }{
+		{"37", "ABC%123", "37ABC\x1d123"},
+		{"a", "1234", "a1234"},
+		{"Z", "hello", "Zhello"},
+	} {
+		hints := &encoder.Hints{MaskPattern: -1, ApplicationIndicator: tc.ai}
+		code, err := encoder.EncodeWithHints(tc.content, decoder.ECLevelM, hints)
+		if err != nil {
+			t.Fatalf("Encode(%q): %v", tc.ai, err)
+		}
+		result, err := decoder.NewDecoder().Decode(code.ToBitMatrix(), "")
+		if err != nil {
+			t.Fatalf("Decode(%q): %v", tc.ai, err)
+		}
+		if result.Text != tc.want {
+			t.Errorf("application indicator %q: got %q, want %q", tc.ai, result.Text, tc.want)
+		}
+		if result.SymbologyModifier != 5 {
+			t.Errorf("symbology modifier = %d, want 5 (FNC1 second position)", result.SymbologyModifier)
+		}
+	}
+
+	for _, ai := range []string{"1", "123", "%"} {
+		if _, err := encoder.EncodeWithHints("1234", decoder.ECLevelM, &encoder.Hints{MaskPattern: -1, ApplicationIndicator: ai}); err == nil {
+			t.Errorf("application indicator %q: expected error", ai)
+		}
+	}
+}
+
+func TestBoostECLevel(t *testing.T) {
+	// 74 bits fit version 1 at level Q but not H.
+	content := "HELLO WORLD"
+	for _, boost := range []bool{false, true} {
+		code, err := encoder.EncodeWithHints(content, decoder.ECLevelL, &encoder.Hints{MaskPattern: -1, BoostECLevel: boost})
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		want := map[bool]decoder.ErrorCorrectionLevel{false: decoder.ECLevelL, true: decoder.ECLevelQ}[boost]
+		if code.ECLevel != want || code.Version.Number != 1 {
+			t.Errorf("boost %v: version %d, level %v, want version 1, level %v", boost, code.Version.Number, code.ECLevel, want)
+		}
+		result, err := decoder.NewDecoder().Decode(code.ToBitMatrix(), "")
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		if result.Text != content || result.ECLevel != want.String() {
+			t.Errorf("boost %v: decoded %q at level %s", boost, result.Text, result.ECLevel)
+		}
+	}
+}
+
+func TestEncodeWithResult(t *testing.T) {
+	margin := 0
+	opts := &zxinggo.EncodeOptions{BoostECLevel: true, QRMaskPattern: 5, Margin: &margin}
+	result, err := NewWriter().EncodeWithResult("HELLO WORLD", zxinggo.FormatQRCode, 0, 0, opts)
+	if err != nil {
+		t.Fatalf("EncodeWithResult failed: %v", err)
+	}
+	// 4 mode bits, 9 count bits and 61 data bits in the 13 data codewords
+	// of version 1 at level Q.
+	if result.Version != 1 || result.ErrorCorrection != "Q" || result.MaskPattern != 5 ||
+		result.Dimension != [2]int{21, 21} || result.Utilization != 74.0/104 {
+		t.Errorf("got %+v", result)
+	}
+	if want := []zxinggo.EncodedSegment{{Mode: "ALPHANUMERIC", Characters: 11}}; !slices.Equal(result.Segments, want) {
+		t.Errorf("segments %v, want %v", result.Segments, want)
+	}
+	if result.Matrix.Width() != 21 {
+		t.Errorf("matrix width %d, want 21", result.Matrix.Width())
+	}
+
+	result, err = zxinggo.EncodeWithResult("(01)09521234543213", zxinggo.FormatQRCode, 0, 0,
+		&zxinggo.EncodeOptions{GS1Format: true, QRMaskPattern: -1})
+	if err != nil {
+		t.Fatalf("EncodeWithResult failed: %v", err)
+	}
+	if want := []zxinggo.EncodedSegment{{Mode: "FNC1_FIRST_POSITION"}, {Mode: "NUMERIC", Characters: 16}}; !slices.Equal(result.Segments, want) {
+		t.Errorf("GS1 segments %v, want %v", result.Segments, want)
+	}
+	if result.MaskPattern < 0 || result.MaskPattern > 7 {
+		t.Errorf("GS1 mask %d", result.MaskPattern)
+	}
+}
+
+func TestDecodeRetriesFormatCandidates(t *testing.T) {
+	const content = "FORMAT CANDIDATES"
+	code, err := encoder.Encode(content, decoder.ECLevelM, 0, 0)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	bits := code.ToBitMatrix()
+
+	// Masked format information for EC level M with data masks 0 and 1; they
+	// differ in 7 bits. Moving 4 of those bits towards mask 1 makes mask 1 the
+	// nearest (3 bits away) while the true mask 0 is 4 bits away.
+	const right, wrong = 0x5412, 0x5125
+	corrupted := right
+	flipped := 0
+	for bit := 0; bit < 15 && flipped < 4; bit++ {
+		if (right^wrong)&(1<<bit) != 0 {
+			corrupted ^= 1 << bit
+			flipped++
+		}
+	}
+	writeFormatInformation(bits, corrupted)
+
+	skipping := decoder.NewDecoder()
+	skipping.SkipFormatCandidates = true
+	if _, err := skipping.Decode(bits.Clone(), ""); err == nil {
+		t.Error("decoded without format candidates")
+	}
+	result, err := decoder.NewDecoder().Decode(bits, "")
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if result.Text != content {
+		t.Errorf("got %q, want %q", result.Text, content)
+	}
+}
+
+// writeFormatInformation overwrites both copies of the 15 format information
+// bits, in the order BitMatrixParser reads them.
+func writeFormatInformation(bits *bitutil.BitMatrix, value int) {
+	dimension := bits.Height()
+	var first, second [][2]int
+	for i := 0; i < 6; i++ {
+		first = append(first, [2]int{i, 8})
+	}
+	first = append(first, [2]int{7, 8}, [2]int{8, 8}, [2]int{8, 7})
+	for j := 5; j >= 0; j-- {
+		first = append(first, [2]int{8, j})
+	}
+	for j := dimension - 1; j >= dimension-7; j-- {
+		second = append(second, [2]int{8, j})
+	}
+	for i := dimension - 8; i < dimension; i++ {
+		second = append(second, [2]int{i, 8})
+	}
+	for _, positions := range [][][2]int{first, second} {
+		for n, p := range positions {
+			if value&(1<<(14-n)) != 0 {
+				bits.Set(p[0], p[1])
+			} else {
+				bits.Unset(p[0], p[1])
+			}
+		}
+	}
+}
+
+func TestDecodeMatrix(t *testing.T) {
+	const content = "DECODE MATRIX"
+	code, err := encoder.Encode(content, decoder.ECLevelQ, 0, -1)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	result, err := DecodeMatrix(code.ToBitMatrix(), nil)
+	if err != nil {
+		t.Fatalf("DecodeMatrix failed: %v", err)
+	}
+	if result.Text != content {
+		t.Errorf("got %q, want %q", result.Text, content)
+	}
+	if result.Metadata[zxinggo.MetadataErrorCorrectionLevel] != "Q" {
+		t.Errorf("EC level = %v, want Q", result.Metadata[zxinggo.MetadataErrorCorrectionLevel])
+	}
+}
+
+// renderKeystone draws a symbol with a four-module quiet zone at the given
+// scale, its top edge narrowed by inset pixels at each end.
+func renderKeystone(bits *bitutil.BitMatrix, scale, inset float64) *zxinggo.BinaryBitmap {
+	n := float64(bits.Width())
+	size := int((n + 8) * scale)
+	lo, hi := 4*scale, (n+4)*scale
+	xf := transform.QuadrilateralToQuadrilateral(
+		lo+inset, lo, hi-inset, lo, hi, hi, lo, hi,
+		0, 0, n, 0, n, n, 0, n)
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	pt := make([]float64, 2)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			pt[0], pt[1] = float64(x)+0.5, float64(y)+0.5
+			xf.TransformPoints(pt)
+			c := uint8(255)
+			if pt[0] >= 0 && pt[1] >= 0 && pt[0] < n && pt[1] < n && bits.Get(int(pt[0]), int(pt[1])) {
+				c = 0
+			}
+			img.SetGray(x, y, color.Gray{Y: c})
+		}
+	}
+	return zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(zxinggo.NewGrayImageLuminanceSource(img)))
+}
+
+func TestProfileSkewTolerance(t *testing.T) {
+	code, err := encoder.Encode("https://example.com/profiles", decoder.ECLevelM, 4, -1)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	bits := code.ToBitMatrix()
+	tests := []struct {
+		inset   float64
+		profile zxinggo.Profile
+		ok      bool
+	}{
+		{0, zxinggo.ProfileStrict, true},
+		{0, zxinggo.ProfileDefault, true},
+		{0, zxinggo.ProfilePermissive, true},
+		{24, zxinggo.ProfileStrict, false},
+		{24, zxinggo.ProfileDefault, true},
+		{24, zxinggo.ProfilePermissive, true},
+	}
+	for _, tc := range tests {
+		opts := &zxinggo.DecodeOptions{Profile: tc.profile}
+		result, err := NewReader().Decode(renderKeystone(bits, 6, tc.inset), opts)
+		if tc.ok && (err != nil || result.Text != "https://example.com/profiles") {
+			t.Errorf("%v with inset %v: result %v, error %v", tc.profile, tc.inset, result, err)
+		}
+		if !tc.ok && err == nil {
+			t.Errorf("%v with inset %v: decoded", tc.profile, tc.inset)
+		}
+	}
+}
+
+func TestDecodeWithFinderPatternMissing(t *testing.T) {
+	const content = "TORN LABEL 1234567890"
+	corners := map[string][2]int{"top left": {0, 0}, "top right": {1, 0}, "bottom left": {0, 1}}
+	for name, corner := range corners {
+		code, err := encoder.Encode(content, decoder.ECLevelH, 5, -1)
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		bits := code.ToBitMatrix()
+		// Tear off the finder pattern and its separator.
+		n := bits.Width()
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				bits.Unset(corner[0]*(n-8)+x, corner[1]*(n-8)+y)
+			}
+		}
+		image := renderKeystone(bits, 4, 0)
+		if _, err := NewReader().Decode(image, &zxinggo.DecodeOptions{}); err == nil {
+			t.Errorf("%s missing: decoded without TryHarder", name)
+		}
+		image = renderKeystone(bits, 4, 0)
+		result, err := NewReader().Decode(image, &zxinggo.DecodeOptions{TryHarder: true})
+		if err != nil {
+			t.Errorf("%s missing: decode error: %v", name, err)
+			continue
+		}
+		if result.Text != content {
+			t.Errorf("%s missing: text = %q, want %q", name, result.Text, content)
+		}
+		image = renderKeystone(bits, 4, 0)
+		opts := &zxinggo.DecodeOptions{TryHarder: true, DisableDetectors: zxinggo.DetectorQRTwoPatterns}
+		if _, err := NewReader().Decode(image, opts); err == nil {
+			t.Errorf("%s missing: decoded with two pattern search disabled", name)
+		}
+	}
+}
+
+func TestAlignmentRetry(t *testing.T) {
+	// In a version 10 symbol seen with its top edge 10 modules narrower, the
+	// first search finds a neighbour of the bottom-right alignment pattern.
+	symbol := symbolgen.QRCode(1, 10, decoder.ECLevelM)
+	result, err := NewReader().Decode(renderKeystone(symbol.Bits, 4, 20), nil)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if result.Text != symbol.Text {
+		t.Errorf("text = %q, want %q", result.Text, symbol.Text)
+	}
+	if got := result.Metadata[zxinggo.MetadataAlignmentPattern]; got != "retried" {
+		t.Errorf("alignment pattern %v, want retried", got)
+	}
+	opts := &zxinggo.DecodeOptions{DisableDetectors: zxinggo.DetectorQRAlignmentRetry}
+	if _, err := NewReader().Decode(renderKeystone(symbol.Bits, 4, 20), opts); err == nil {
+		t.Error("decoded with alignment retry disabled")
+	}
+
+	result, err = NewReader().Decode(renderKeystone(symbol.Bits, 4, 0), nil)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if got := result.Metadata[zxinggo.MetadataAlignmentPattern]; got != "found" {
+		t.Errorf("square on: alignment pattern %v, want found", got)
+	}
+}
+
+func TestRequireAlignment(t *testing.T) {
+	symbol := symbolgen.QRCode(1, 7, decoder.ECLevelH)
+	bits := symbol.Bits.Clone()
+	// Erase the bottom-right alignment pattern.
+	n := bits.Width()
+	for y := n - 9; y < n-4; y++ {
+		for x := n - 9; x < n-4; x++ {
+			bits.Unset(x, y)
+		}
+	}
+	result, err := NewReader().Decode(renderKeystone(bits, 4, 0), nil)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if got := result.Metadata[zxinggo.MetadataAlignmentPattern]; got != "estimated" {
+		t.Errorf("alignment pattern %v, want estimated", got)
+	}
+	// Without the retry, a stray candidate is taken for the pattern.
+	opts := &zxinggo.DecodeOptions{DisableDetectors: zxinggo.DetectorQRAlignmentRetry}
+	if _, err := NewReader().Decode(renderKeystone(bits, 4, 0), opts); err == nil {
+		t.Error("decoded with alignment retry disabled")
+	}
+	for _, tc := range []struct {
+		from int
+		ok   bool
+	}{{0, true}, {7, false}, {8, true}} {
+		_, err := NewReader().Decode(renderKeystone(bits, 4, 0), &zxinggo.DecodeOptions{QRRequireAlignmentFrom: tc.from})
+		if (err == nil) != tc.ok {
+			t.Errorf("QRRequireAlignmentFrom %d: error %v", tc.from, err)
+		}
+	}
+}
+
+// renderBold draws a symbol with a four-module quiet zone at the given
+// scale, each dark module spreading bold pixels into its neighbours, as ink
+// does on absorbent paper.
+func renderBold(bits *bitutil.BitMatrix, scale, bold int) *zxinggo.BinaryBitmap {
+	n := bits.Width()
+	size := (n + 8) * scale
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if !bits.Get(x, y) {
+				continue
+			}
+			for py := (y+4)*scale - bold; py < (y+5)*scale+bold; py++ {
+				for px := (x+4)*scale - bold; px < (x+5)*scale+bold; px++ {
+					img.SetGray(px, py, color.Gray{})
+				}
+			}
+		}
+	}
+	return zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(zxinggo.NewGrayImageLuminanceSource(img)))
+}
+
+func TestGridFit(t *testing.T) {
+	// Printed bold, the finder pattern rings measure too wide and the
+	// dimension measured is a version too small.
+	symbol := symbolgen.QRCode(1, 10, decoder.ECLevelH)
+	bits := symbol.Bits.Clone()
+	// Erase the timing patterns, so they confirm no dimension.
+	n := bits.Width()
+	for i := 8; i < n-8; i++ {
+		bits.Unset(i, 6)
+		bits.Unset(6, i)
+	}
+	for name, bits := range map[string]*bitutil.BitMatrix{"intact": symbol.Bits, "timing erased": bits} {
+		result, err := NewReader().Decode(renderBold(bits, 5, 1), nil)
+		if err != nil {
+			t.Errorf("%s: decode error: %v", name, err)
+			continue
+		}
+		if result.Text != symbol.Text {
+			t.Errorf("%s: text = %q, want %q", name, result.Text, symbol.Text)
+		}
+		opts := &zxinggo.DecodeOptions{DisableDetectors: zxinggo.DetectorQRGridFit}
+		if _, err := NewReader().Decode(renderBold(bits, 5, 1), opts); err == nil {
+			t.Errorf("%s: decoded with grid fit disabled", name)
+		}
+	}
+	// A symbol whose timing patterns are erased but whose dimension measures
+	// right keeps it.
+	result, err := NewReader().Decode(renderKeystone(bits, 5, 0), nil)
+	if err != nil || result.Text != symbol.Text {
+		t.Errorf("timing erased, not bold: result %v, error %v", result, err)
+	}
+}
+
+func TestReaderWithOptions(t *testing.T) {
+	const content = "TORN LABEL 1234567890"
+	code, err := encoder.Encode(content, decoder.ECLevelH, 5, -1)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	bits := code.ToBitMatrix()
+	// Only TryHarder finds the symbol without its top left finder pattern.
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			bits.Unset(x, y)
+		}
+	}
+	reader := NewReaderWithOptions(&zxinggo.DecodeOptions{TryHarder: true})
+	result, err := reader.Decode(renderKeystone(bits, 4, 0), nil)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if result.Text != content {
+		t.Errorf("text = %q, want %q", result.Text, content)
+	}
+	// Options passed to Decode replace the reader's.
+	if _, err := reader.Decode(renderKeystone(bits, 4, 0), &zxinggo.DecodeOptions{}); err == nil {
+		t.Error("decoded without TryHarder")
+	}
+}
+
+func TestVersionTables(t *testing.T) {
+	versions := decoder.Versions()
+	if len(versions) != 40 {
+		t.Fatalf("len(Versions()) = %d, want 40", len(versions))
+	}
+	for i, v := range versions {
+		if v.Number != i+1 {
+			t.Errorf("Versions()[%d] is version %d", i, v.Number)
+		}
+		dimension := v.DimensionForVersion()
+		if got, err := decoder.GetProvisionalVersionForDimension(dimension); err != nil || got != v {
+			t.Errorf("GetProvisionalVersionForDimension(%d) = %v, %v", dimension, got, err)
+		}
+		for _, ecLevel := range []decoder.ErrorCorrectionLevel{decoder.ECLevelL, decoder.ECLevelM, decoder.ECLevelQ, decoder.ECLevelH} {
+			ecBlocks := v.ECBlocksForLevel(ecLevel)
+			if got := v.DataCodewords(ecLevel) + ecBlocks.TotalECCodewords(); got != v.TotalCodewords {
+				t.Errorf("version %d, %v: %d codewords, want %d", v.Number, ecLevel, got, v.TotalCodewords)
+			}
+		}
+	}
+	tests := []struct {
+		version int
+		ecLevel decoder.ErrorCorrectionLevel
+		want    int
+	}{
+		{1, decoder.ECLevelL, 19},
+		{1, decoder.ECLevelH, 9},
+		{40, decoder.ECLevelL, 2956},
+		{40, decoder.ECLevelH, 1276},
+	}
+	for _, tc := range tests {
+		v, _ := decoder.GetVersionForNumber(tc.version)
+		if got := v.DataCodewords(tc.ecLevel); got != tc.want {
+			t.Errorf("version %d, %v: %d data codewords, want %d", tc.version, tc.ecLevel, got, tc.want)
+		}
+	}
+	if _, err := decoder.GetProvisionalVersionForDimension(23); err == nil {
+		t.Error("GetProvisionalVersionForDimension(23) succeeded")
+	}
+}
+
+// TestDecodeGeneratedSymbols decodes symbols built module by module, without
+// the encoder, across every version and error correction level.
+func TestDecodeGeneratedSymbols(t *testing.T) {
+	levels := []decoder.ErrorCorrectionLevel{decoder.ECLevelL, decoder.ECLevelM, decoder.ECLevelQ, decoder.ECLevelH}
+	dec := decoder.NewDecoder()
+	for version := 1; version <= 40; version++ {
+		for i, level := range levels {
+			seed := int64(version*4 + i)
+			symbol := symbolgen.QRCode(seed, version, level)
+			result, err := dec.Decode(symbol.Bits, "")
+			if err != nil {
+				t.Errorf("seed %d, version %d-%v: %v", seed, version, level, err)
+				continue
+			}
+			if result.Text != symbol.Text {
+				t.Errorf("seed %d, version %d-%v: decoded %q, want %q", seed, version, level, result.Text, symbol.Text)
+			}
+		}
+	}
+}
+
+// TestIgnoreECI3 reads UTF-8 text after ECI 000003, as many encoders write
+// it, as the ISO-8859-1 the ECI designates, and with QRIgnoreECI3 as the
+// UTF-8 it is.
+func TestIgnoreECI3(t *testing.T) {
+	const text = "Grüße"
+	bits := bitutil.NewBitArray(0)
+	bits.AppendBits(uint32(decoder.ModeECI), 4)
+	bits.AppendBits(3, 8)
+	bits.AppendBits(uint32(decoder.ModeByte), 4)
+	bits.AppendBits(uint32(len(text)), 8)
+	for i := 0; i < len(text); i++ {
+		bits.AppendBits(uint32(text[i]), 8)
+	}
+	bits.AppendBits(0, 4)
+	// Pad to whole bytes, which ToBytes reads.
+	for bits.Size()%8 != 0 {
+		bits.AppendBit(false)
+	}
+	raw := make([]byte, bits.SizeInBytes())
+	bits.ToBytes(0, raw, 0, len(raw))
+	data := make([]int, len(raw))
+	for i, b := range raw {
+		data[i] = int(b)
+	}
+	symbol := symbolgen.QRCodeFromData(1, decoder.ECLevelL, 0, data)
+
+	var latin1 []rune
+	for i := 0; i < len(text); i++ {
+		latin1 = append(latin1, rune(text[i]))
+	}
+	tests := []struct {
+		opts *zxinggo.DecodeOptions
+		want string
+	}{
+		{nil, string(latin1)},
+		{&zxinggo.DecodeOptions{QRIgnoreECI3: true}, text},
+	}
+	for _, tt := range tests {
+		ignore := tt.opts != nil
+		result, err := DecodeMatrix(symbol.Bits.Clone(), tt.opts)
+		if err != nil {
+			t.Fatalf("QRIgnoreECI3 %v: DecodeMatrix: %v", ignore, err)
+		}
+		if result.Text != tt.want {
+			t.Errorf("QRIgnoreECI3 %v: DecodeMatrix read %q, want %q", ignore, result.Text, tt.want)
+		}
+		// The symbol still has an ECI, whatever it is taken to mean.
+		if id := result.Metadata[zxinggo.MetadataSymbologyIdentifier]; id != "]Q2" {
+			t.Errorf("QRIgnoreECI3 %v: symbology identifier %v, want ]Q2", ignore, id)
+		}
+		result, err = NewReader().Decode(renderBold(symbol.Bits, 4, 0), tt.opts)
+		if err != nil {
+			t.Fatalf("QRIgnoreECI3 %v: Decode: %v", ignore, err)
+		}
+		if result.Text != tt.want {
+			t.Errorf("QRIgnoreECI3 %v: Decode read %q, want %q", ignore, result.Text, tt.want)
+		}
+	}
+}
+
+func TestDecodeTriples(t *testing.T) {
+	contents := []string{"LEFT SYMBOL", "RIGHT SYMBOL"}
+	const scale, gap = 4, 12
+	var symbols []*bitutil.BitMatrix
+	for _, content := range contents {
+		code, err := encoder.Encode(content, decoder.ECLevelM, 2, -1)
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		symbols = append(symbols, code.ToBitMatrix())
+	}
+	n := symbols[0].Width()
+	img := image.NewGray(image.Rect(0, 0, (2*n+gap+8)*scale, (n+8)*scale))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	for i, bits := range symbols {
+		left := 4 + i*(n+gap)
+		for y := 0; y < n; y++ {
+			for x := 0; x < n; x++ {
+				if !bits.Get(x, y) {
+					continue
+				}
+				for py := (y + 4) * scale; py < (y+5)*scale; py++ {
+					for px := (x + left) * scale; px < (x+left+1)*scale; px++ {
+						img.SetGray(px, py, color.Gray{})
+					}
+				}
+			}
+		}
+	}
+	newImage := func() *zxinggo.BinaryBitmap {
+		return zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(zxinggo.NewGrayImageLuminanceSource(img)))
+	}
+
+	search, err := NewReader().DecodeTriples(newImage(), nil, 0)
+	if err != nil {
+		t.Fatalf("DecodeTriples: %v", err)
+	}
+	if len(search.Candidates) != 6 {
+		t.Fatalf("got %d candidates, want 6", len(search.Candidates))
+	}
+	if len(search.Triples) != 20 {
+		t.Fatalf("got %d triples, want 20", len(search.Triples))
+	}
+	var texts []string
+	for _, result := range search.Results() {
+		texts = append(texts, result.Text)
+	}
+	slices.Sort(texts)
+	if !slices.Equal(texts, contents) {
+		t.Errorf("read %q, want %q", texts, contents)
+	}
+	for _, triple := range search.Triples {
+		if (triple.Rejection == detector.TripleAccepted) != (triple.Result != nil) {
+			t.Errorf("triple %v has result %v", triple.Rejection, triple.Result)
+		}
+	}
+
+	search, err = NewReader().DecodeTriples(newImage(), nil, 1)
+	if err != nil {
+		t.Fatalf("DecodeTriples: %v", err)
+	}
+	if got := len(search.Results()); got != 1 {
+		t.Errorf("budget 1: read %d symbols, want 1", got)
+	}
+	if !slices.ContainsFunc(search.Triples, func(t TripleReport) bool {
+		return t.Rejection == detector.TripleOverBudget
+	}) {
+		t.Error("budget 1: no triple rejected as over budget")
+	}
+}
+
+func TestFunctionMask(t *testing.T) {
+	const content = "ARTISTIC QR"
+	code, err := encoder.Encode(content, decoder.ECLevelH, 7, 3)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	mask, err := NewFunctionMask(code.Version.Number, code.ECLevel, code.MaskPattern)
+	if err != nil {
+		t.Fatalf("NewFunctionMask: %v", err)
+	}
+	n := mask.Dimension()
+	counts := map[ModuleRole]int{}
+	pattern := mask.Pattern()
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			role := mask.Role(x, y)
+			counts[role]++
+			if role.IsFunction() && pattern.Get(x, y) != (code.Matrix.Get(x, y) == 1) {
+				t.Errorf("%v module at %d,%d differs from the encoded symbol", role, x, y)
+			}
+			if (mask.Codeword(x, y) >= 0) != (role == ModuleData) {
+				t.Errorf("%v module at %d,%d is in codeword %d", role, x, y, mask.Codeword(x, y))
+			}
+		}
+	}
+	want := map[ModuleRole]int{
+		ModuleData:      8 * code.Version.TotalCodewords,
+		ModuleRemainder: 0,
+		ModuleFinder:    3 * 64,
+		ModuleTiming:    2*(n-16) - 2*5,
+		ModuleAlignment: 6 * 25,
+		ModuleFormat:    31,
+		ModuleVersion:   36,
+	}
+	for role, count := range want {
+		if counts[role] != count {
+			t.Errorf("%d %v modules, want %d", counts[role], role, count)
+		}
+	}
+	if _, err := NewFunctionMask(41, decoder.ECLevelL, 0); err == nil {
+		t.Error("NewFunctionMask accepted version 41")
+	}
+
+	// Recolor the codewords of a logo in the middle and check it still
+	// decodes, using some of the error correction.
+	design := code.ToBitMatrix()
+	report, err := mask.Validate(design, nil)
+	if err != nil || report.Result.Text != content || report.ErrorsCorrected != 0 {
+		t.Fatalf("plain design: report %+v, error %v", report, err)
+	}
+	logo := map[int]bool{}
+	for y := n/2 - 3; y < n/2+3; y++ {
+		for x := n/2 - 3; x < n/2+3; x++ {
+			if mask.Role(x, y) == ModuleData {
+				design.Unset(x, y)
+				logo[mask.Codeword(x, y)] = true
+			}
+		}
+	}
+	report, err = mask.Validate(design, nil)
+	if err != nil {
+		t.Fatalf("logo design did not decode: %v", err)
+	}
+	if report.Result.Text != content || report.ErrorsCorrected == 0 || report.ErrorsCorrected > len(logo) {
+		t.Errorf("logo design: text %q, %d errors corrected in %d codewords covered",
+			report.Result.Text, report.ErrorsCorrected, len(logo))
+	}
+	if len(report.FunctionErrors) != 0 {
+		t.Errorf("logo design: function errors %v", report.FunctionErrors)
+	}
+
+	// Recoloring a finder pattern is reported even though a sampled grid
+	// still decodes.
+	design.Flip(3, 3)
+	report, err = mask.Validate(design, nil)
+	if err != nil {
+		t.Fatalf("finder design did not decode: %v", err)
+	}
+	if !slices.Equal(report.FunctionErrors, [][2]int{{3, 3}}) {
+		t.Errorf("finder design: function errors %v, want [[3 3]]", report.FunctionErrors)
+	}
+}
+
+func TestStructuredAppend(t *testing.T) {
+	// Symbol 2 of 3, parity 0x5A, then "hi" in byte mode.
+	bits := bitutil.NewBitArray(0)
+	bits.AppendBits(uint32(decoder.ModeStructuredAppend), 4)
+	bits.AppendBits(0x12, 8)
+	bits.AppendBits(0x5A, 8)
+	bits.AppendBits(uint32(decoder.ModeByte), 4)
+	bits.AppendBits(2, 8)
+	bits.AppendBits('h', 8)
+	bits.AppendBits('i', 8)
+	bits.AppendBits(0, 4)
+	for bits.Size()%8 != 0 {
+		bits.AppendBit(false)
+	}
+	data := make([]byte, bits.SizeInBytes())
+	bits.ToBytes(0, data, 0, len(data))
+
+	version, err := decoder.GetVersionForNumber(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := decoder.DecodeBitStream(data, version, decoder.ECLevelL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := payload.Result(nil, zxinggo.FormatQRCode)
+	if result.Text != "hi" {
+		t.Errorf("Text = %q, want %q", result.Text, "hi")
+	}
+	want := &zxinggo.StructuredAppend{Index: 1, Count: 3, Parity: 0x5A}
+	if sa, _ := result.Metadata[zxinggo.MetadataStructuredAppend].(*zxinggo.StructuredAppend); sa == nil || *sa != *want {
+		t.Errorf("StructuredAppend = %+v, want %+v", sa, want)
+	}
+	if seq := result.Metadata[zxinggo.MetadataStructuredAppendSequence]; seq != 0x12 {
+		t.Errorf("StructuredAppendSequence = %v, want %d", seq, 0x12)
+	}
+	if parity := result.Metadata[zxinggo.MetadataStructuredAppendParity]; parity != 0x5A {
+		t.Errorf("StructuredAppendParity = %v, want %d", parity, 0x5A)
+	}
+	if id := result.Metadata[zxinggo.MetadataSymbologyIdentifier]; id != "]Q1" {
+		t.Errorf("SymbologyIdentifier = %v, want ]Q1", id)
+	}
+	if _, ok := result.Metadata[zxinggo.MetadataUnusedErrorCorrection]; ok {
+		t.Error("UnusedErrorCorrection set without error correction")
+	}
+}
@@ -2,32 +2,43 @@
 package qrcode
 
 import (
-	"fmt"
 	"math"
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
 	"github.com/ericlevine/zxinggo/qrcode/decoder"
 	"github.com/ericlevine/zxinggo/qrcode/detector"
+	"github.com/ericlevine/zxinggo/transform"
 )
 
-// Reader decodes QR codes from binary images.
+// Reader decodes QR codes from binary images. Decode keeps no state in the
+// Reader, so one may be shared by goroutines.
 type Reader struct {
-	dec *decoder.Decoder
+	// opts are used by Decode and Detect when they are passed nil.
+	opts *zxinggo.DecodeOptions
 }
 
 // NewReader creates a new QR code Reader.
 func NewReader() *Reader {
-	return &Reader{
-		dec: decoder.NewDecoder(),
-	}
+	return &Reader{}
+}
+
+// NewReaderWithOptions creates a QR code reader that uses opts whenever it is
+// given nil options, so it can be configured once and used on its own
+// rather than through zxinggo.Decode.
+func NewReaderWithOptions(opts *zxinggo.DecodeOptions) *Reader {
+	return &Reader{opts: opts}
 }
 
 // Decode locates and decodes a QR code in the given image.
 func (r *Reader) Decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	if opts == nil {
+		opts = r.opts
+	}
 	if opts == nil {
 		opts = &zxinggo.DecodeOptions{}
 	}
+	dec := newDecoder(opts)
 
 	matrix, err := image.BlackMatrix()
 	if err != nil {
@@ -39,59 +50,134 @@ func (r *Reader) Decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions
 		if err != nil {
 			return nil, err
 		}
-		dr, err := r.dec.Decode(bits, opts.CharacterSet)
+		result, err := decodeBits(dec, bits, opts.CharacterSet, nil)
 		if err != nil {
 			return nil, err
 		}
-
-		result := zxinggo.NewResult(dr.Text, dr.RawBytes, nil, zxinggo.FormatQRCode)
-		populateMetadata(result, dr.ByteSegments, dr.ECLevel,
-			dr.HasStructuredAppend(), dr.StructuredAppendSequenceNumber,
-			dr.StructuredAppendParity, dr.ErrorsCorrected, dr.SymbologyModifier)
+		if err := zxinggo.CheckErrorBudget(result, opts); err != nil {
+			return nil, err
+		}
 		return result, nil
 	}
 
-	det := detector.NewDetector(matrix)
-	detectorResult, err := det.Detect(opts.TryHarder)
+	detectorResult, err := newDetector(matrix, opts).Detect(opts.TryHarder)
 	if err != nil {
 		return nil, err
 	}
-	dr, err := r.dec.Decode(detectorResult.Bits, opts.CharacterSet)
+	result, err := decodeBits(dec, detectorResult.Bits, opts.CharacterSet, detectorResult.Points)
 	if err != nil {
 		return nil, err
 	}
-
-	points := make([]zxinggo.ResultPoint, len(detectorResult.Points))
-	for i, p := range detectorResult.Points {
-		points[i] = zxinggo.ResultPoint{X: p.X, Y: p.Y}
+	if err := zxinggo.CheckErrorBudget(result, opts); err != nil {
+		return nil, err
 	}
+	result.PutMetadata(zxinggo.MetadataAlignmentPattern, detectorResult.Alignment.String())
+	return result, nil
+}
 
-	result := zxinggo.NewResult(dr.Text, dr.RawBytes, points, zxinggo.FormatQRCode)
-	populateMetadata(result, dr.ByteSegments, dr.ECLevel,
-		dr.HasStructuredAppend(), dr.StructuredAppendSequenceNumber,
-		dr.StructuredAppendParity, dr.ErrorsCorrected, dr.SymbologyModifier)
+// newDecoder returns a decoder configured from opts. Each call decodes with
+// a decoder of its own, so that calls with different options on one Reader
+// do not interfere.
+func newDecoder(opts *zxinggo.DecodeOptions) *decoder.Decoder {
+	dec := decoder.NewDecoder()
+	dec.SkipFormatCandidates = zxinggo.DetectorDisabled(opts, zxinggo.DetectorQRFormatCandidates)
+	dec.DumpCodewords = opts.DumpCodewords
+	dec.IgnoreECI3 = opts.QRIgnoreECI3
+	return dec
+}
+
+// newDetector returns a detector for matrix configured from opts.
+func newDetector(matrix *bitutil.BitMatrix, opts *zxinggo.DecodeOptions) *detector.Detector {
+	det := detector.NewDetector(matrix)
+	det.Heatmap = opts.Heatmap
+	det.Profile = opts.Profile
+	det.SkipTwoPatterns = zxinggo.DetectorDisabled(opts, zxinggo.DetectorQRTwoPatterns)
+	det.SkipAlignmentRetry = zxinggo.DetectorDisabled(opts, zxinggo.DetectorQRAlignmentRetry)
+	det.SkipGridFit = zxinggo.DetectorDisabled(opts, zxinggo.DetectorQRGridFit)
+	det.RequireAlignmentFrom = opts.QRRequireAlignmentFrom
+	return det
+}
+
+// DecodeMatrix decodes a QR code from its module grid, one bit per module
+// with no quiet zone, as sampled by an external detector. The grid must be
+// upright, or upright and mirrored. opts may be nil; only CharacterSet,
+// QRIgnoreECI3 and MaxErrorsCorrected are used. The result has no points.
+func DecodeMatrix(bits *bitutil.BitMatrix, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	dec := decoder.NewDecoder()
+	characterSet := ""
+	if opts != nil {
+		characterSet = opts.CharacterSet
+		dec.IgnoreECI3 = opts.QRIgnoreECI3
+	}
+	result, err := decodeBits(dec, bits, characterSet, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := zxinggo.CheckErrorBudget(result, opts); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
-// Reset resets internal state.
-func (r *Reader) Reset() {
-	// nothing to reset
+// decodeBits decodes a sampled grid with dec.
+func decodeBits(dec *decoder.Decoder, bits *bitutil.BitMatrix, characterSet string, points []zxinggo.ResultPoint) (*zxinggo.Result, error) {
+	dr, err := dec.Decode(bits, characterSet)
+	if err != nil {
+		return nil, err
+	}
+	result := dr.Result(points, zxinggo.FormatQRCode)
+	result.PutMetadata(zxinggo.MetadataSymbolDimension, [2]int{bits.Width(), bits.Height()})
+	return result, nil
 }
 
-func populateMetadata(result *zxinggo.Result, byteSegments [][]byte, ecLevel string,
-	hasStructuredAppend bool, saSequence, saParity, errorsCorrected, symbologyModifier int) {
-	if byteSegments != nil {
-		result.PutMetadata(zxinggo.MetadataByteSegments, byteSegments)
+// Detect locates a QR code in the given image without decoding it.
+func (r *Reader) Detect(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) ([]zxinggo.Detection, error) {
+	if opts == nil {
+		opts = r.opts
 	}
-	if ecLevel != "" {
-		result.PutMetadata(zxinggo.MetadataErrorCorrectionLevel, ecLevel)
+	if opts == nil {
+		opts = &zxinggo.DecodeOptions{}
 	}
-	if hasStructuredAppend {
-		result.PutMetadata(zxinggo.MetadataStructuredAppendSequence, saSequence)
-		result.PutMetadata(zxinggo.MetadataStructuredAppendParity, saParity)
+	matrix, err := image.BlackMatrix()
+	if err != nil {
+		return nil, err
 	}
-	result.PutMetadata(zxinggo.MetadataErrorsCorrected, errorsCorrected)
-	result.PutMetadata(zxinggo.MetadataSymbologyIdentifier, fmt.Sprintf("]Q%d", symbologyModifier))
+	detectorResult, err := newDetector(matrix, opts).Detect(opts.TryHarder)
+	if err != nil {
+		return nil, err
+	}
+
+	// Map the module grid onto the image through the finder patterns and,
+	// if found, the alignment pattern, as the detector did when sampling.
+	p := detectorResult.Points
+	dim := float64(detectorResult.Bits.Width())
+	bottomLeft, topLeft, topRight := p[0], p[1], p[2]
+	bottomRight := zxinggo.ResultPoint{X: topRight.X - topLeft.X + bottomLeft.X, Y: topRight.Y - topLeft.Y + bottomLeft.Y}
+	sourceBottomRight := dim - 3.5
+	if len(p) > 3 {
+		bottomRight = p[3]
+		sourceBottomRight = dim - 6.5
+	}
+	xform := transform.QuadrilateralToQuadrilateral(
+		3.5, 3.5, dim-3.5, 3.5, sourceBottomRight, sourceBottomRight, 3.5, dim-3.5,
+		topLeft.X, topLeft.Y, topRight.X, topRight.Y, bottomRight.X, bottomRight.Y, bottomLeft.X, bottomLeft.Y)
+	outline := []float64{0, 0, dim, 0, dim, dim, 0, dim}
+	xform.TransformPoints(outline)
+
+	points := make([]zxinggo.ResultPoint, 4)
+	for i := range points {
+		points[i] = zxinggo.ResultPoint{X: outline[2*i], Y: outline[2*i+1]}
+	}
+	return []zxinggo.Detection{{
+		Format: zxinggo.FormatQRCode,
+		Points: points,
+		Bits:   detectorResult.Bits,
+	}}, nil
+}
+
+// Reset resets internal state.
+func (r *Reader) Reset() {
+	// nothing to reset
 }
 
 // extractPureBits extracts a QR code from a "pure" image — one that contains
@@ -187,3 +273,8 @@ func moduleSizePure(leftTopBlack []int, image *bitutil.BitMatrix) (float64, erro
 	}
 	return float64(x-leftTopBlack[0]) / 7.0, nil
 }
+
+var (
+	_ zxinggo.Reader   = (*Reader)(nil)
+	_ zxinggo.Detector = (*Reader)(nil)
+)
@@ -9,4 +9,5 @@ func init() {
 	zxinggo.RegisterWriter(zxinggo.FormatQRCode, func() zxinggo.Writer {
 		return NewWriter()
 	})
+	zxinggo.RegisterCapacityPlanner(zxinggo.FormatQRCode, capacityPlanner{})
 }
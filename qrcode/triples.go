@@ -0,0 +1,117 @@
+package qrcode
+
+import (
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/qrcode/decoder"
+	"github.com/ericlevine/zxinggo/qrcode/detector"
+)
+
+// TripleReport is what became of one combination of three finder patterns
+// tried by DecodeTriples.
+type TripleReport struct {
+	detector.FinderTriple
+
+	// Err is why the symbol between the patterns could not be sampled or
+	// decoded, when Rejection is TripleSampleFailed or TripleDecodeFailed.
+	Err error
+
+	// Result is the symbol read, when Rejection is TripleAccepted.
+	Result *zxinggo.Result
+}
+
+// TripleSearch is the outcome of DecodeTriples.
+type TripleSearch struct {
+	// Candidates are the finder patterns confirmed in the image, the
+	// largest modules first.
+	Candidates []*detector.FinderPattern
+
+	// Triples are every combination of three candidates, in the order
+	// they were tried.
+	Triples []TripleReport
+}
+
+// Results returns the symbols read, in the order they were read.
+func (s *TripleSearch) Results() []*zxinggo.Result {
+	var results []*zxinggo.Result
+	for _, t := range s.Triples {
+		if t.Result != nil {
+			results = append(results, t.Result)
+		}
+	}
+	return results
+}
+
+// DecodeTriples finds every finder pattern in image and tries each
+// combination of three that could be the corners of a QR code, as reading
+// several symbols does, reporting why each of the others was rejected. It
+// is meant for images with many symbols, or decorations that look like
+// finder patterns, where Decode and DecodeMultiple find fewer symbols than
+// expected. At most budget plausible triples are sampled and decoded, the
+// rest being rejected as TripleOverBudget; zero or less means no limit.
+// Triples sharing a pattern with a symbol already read are skipped.
+//
+// The error is that of binarizing image; finding no symbol is not an error,
+// the search simply having no results.
+func (r *Reader) DecodeTriples(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions, budget int) (*TripleSearch, error) {
+	if opts == nil {
+		opts = r.opts
+	}
+	if opts == nil {
+		opts = &zxinggo.DecodeOptions{}
+	}
+	dec := newDecoder(opts)
+
+	matrix, err := image.BlackMatrix()
+	if err != nil {
+		return nil, err
+	}
+	det := newDetector(matrix, opts)
+	search := &TripleSearch{Candidates: det.FinderCandidates(opts.TryHarder)}
+	used := map[*detector.FinderPattern]bool{}
+	tried := 0
+	for _, triple := range detector.Triples(search.Candidates) {
+		report := TripleReport{FinderTriple: triple}
+		if triple.Rejection == detector.TripleAccepted {
+			r.tryTriple(det, dec, &report, used, &tried, budget, opts)
+		}
+		search.Triples = append(search.Triples, report)
+	}
+	return search, nil
+}
+
+// tryTriple samples the symbol between the accepted patterns of report and
+// decodes it with dec, recording the outcome in report.
+func (r *Reader) tryTriple(det *detector.Detector, dec *decoder.Decoder, report *TripleReport, used map[*detector.FinderPattern]bool, tried *int, budget int, opts *zxinggo.DecodeOptions) {
+	info := &report.FinderPatternInfo
+	patterns := []*detector.FinderPattern{info.BottomLeft, info.TopLeft, info.TopRight}
+	for _, fp := range patterns {
+		if used[fp] {
+			report.Rejection = detector.TripleSharesPattern
+			return
+		}
+	}
+	if budget > 0 && *tried >= budget {
+		report.Rejection = detector.TripleOverBudget
+		return
+	}
+	*tried++
+
+	detectorResult, err := det.SampleTriple(info)
+	if err != nil {
+		report.Rejection, report.Err = detector.TripleSampleFailed, err
+		return
+	}
+	result, err := decodeBits(dec, detectorResult.Bits, opts.CharacterSet, detectorResult.Points)
+	if err == nil {
+		err = zxinggo.CheckErrorBudget(result, opts)
+	}
+	if err != nil {
+		report.Rejection, report.Err = detector.TripleDecodeFailed, err
+		return
+	}
+	result.PutMetadata(zxinggo.MetadataAlignmentPattern, detectorResult.Alignment.String())
+	report.Result = result
+	for _, fp := range patterns {
+		used[fp] = true
+	}
+}
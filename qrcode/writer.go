@@ -5,12 +5,11 @@ import (
 
 	zxinggo "github.com/ericlevine/zxinggo"
 	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/gs1"
 	"github.com/ericlevine/zxinggo/qrcode/decoder"
 	"github.com/ericlevine/zxinggo/qrcode/encoder"
 )
 
-const defaultQuietZoneSize = 4
-
 // Writer encodes QR codes.
 type Writer struct{}
 
@@ -19,52 +18,156 @@ func NewWriter() *Writer {
 	return &Writer{}
 }
 
-// Encode encodes the given contents into a QR code BitMatrix.
+// Encode encodes the given contents into a QR code BitMatrix. If
+// opts.CharacterSet is set, contents are converted to it and an ECI
+// written; see encoder.EncodeWithHints.
 func (w *Writer) Encode(contents string, format zxinggo.Format, width, height int, opts *zxinggo.EncodeOptions) (*bitutil.BitMatrix, error) {
+	code, err := encodeCode(contents, format, width, height, opts)
+	if err != nil {
+		return nil, err
+	}
+	return encoder.RenderResult(code, width, height, quietZone(opts)), nil
+}
+
+// EncodeWithResult is Encode, also reporting the version, error correction
+// level, mask and modes chosen.
+func (w *Writer) EncodeWithResult(contents string, format zxinggo.Format, width, height int, opts *zxinggo.EncodeOptions) (*zxinggo.EncodeResult, error) {
+	code, err := encodeCode(contents, format, width, height, opts)
+	if err != nil {
+		return nil, err
+	}
+	segments := make([]zxinggo.EncodedSegment, len(code.Segments))
+	for i, s := range code.Segments {
+		segments[i] = zxinggo.EncodedSegment{Mode: s.Mode.String(), Characters: s.Characters}
+	}
+	dimension := code.Version.DimensionForVersion()
+	return &zxinggo.EncodeResult{
+		Matrix:          encoder.RenderResult(code, width, height, quietZone(opts)),
+		Format:          zxinggo.FormatQRCode,
+		Dimension:       [2]int{dimension, dimension},
+		Version:         code.Version.Number,
+		ErrorCorrection: code.ECLevel.String(),
+		MaskPattern:     code.MaskPattern,
+		Segments:        segments,
+		Utilization:     code.Utilization(),
+	}, nil
+}
+
+// encodeCode encodes contents as Encode does, without rendering them.
+func encodeCode(contents string, format zxinggo.Format, width, height int, opts *zxinggo.EncodeOptions) (*encoder.QRCode, error) {
 	if contents == "" {
 		return nil, fmt.Errorf("found empty contents")
 	}
+	if err := checkRequest(format, width, height); err != nil {
+		return nil, err
+	}
+
+	contents, ecLevel, hints, err := encoderSettings(contents, opts)
+	if err != nil {
+		return nil, err
+	}
+	return encoder.EncodeWithHints(contents, ecLevel, hints)
+}
+
+// EncodeBytes encodes data into a QR code BitMatrix byte for byte. If
+// opts.CharacterSet is set, it names the character set data is in, and an
+// ECI for it is written; see encoder.EncodeBytesWithHints.
+func (w *Writer) EncodeBytes(data []byte, format zxinggo.Format, width, height int, opts *zxinggo.EncodeOptions) (*bitutil.BitMatrix, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("found empty contents")
+	}
+	if err := checkRequest(format, width, height); err != nil {
+		return nil, err
+	}
+
+	ecLevel, hints, err := encoderHints(opts)
+	if err != nil {
+		return nil, err
+	}
+	code, err := encoder.EncodeBytesWithHints(data, ecLevel, hints)
+	if err != nil {
+		return nil, err
+	}
+	return encoder.RenderResult(code, width, height, quietZone(opts)), nil
+}
+
+// Ensure Writer implements BytesWriter and ResultWriter at compile time.
+var (
+	_ zxinggo.BytesWriter  = (*Writer)(nil)
+	_ zxinggo.ResultWriter = (*Writer)(nil)
+)
+
+// checkRequest checks the format and dimensions asked of Writer.
+func checkRequest(format zxinggo.Format, width, height int) error {
 	if format != zxinggo.FormatQRCode {
-		return nil, fmt.Errorf("can only encode QR_CODE, but got %s", format)
+		return fmt.Errorf("can only encode QR_CODE, but got %s", format)
 	}
 	if width < 0 || height < 0 {
-		return nil, fmt.Errorf("requested dimensions are too small: %dx%d", width, height)
-	}
-
-	ecLevel := decoder.ECLevelL
-	quietZone := defaultQuietZoneSize
-	qrVersion := 0
-	maskPattern := -1
-
-	if opts != nil {
-		if opts.ErrorCorrection != "" {
-			switch opts.ErrorCorrection {
-			case "L":
-				ecLevel = decoder.ECLevelL
-			case "M":
-				ecLevel = decoder.ECLevelM
-			case "Q":
-				ecLevel = decoder.ECLevelQ
-			case "H":
-				ecLevel = decoder.ECLevelH
-			default:
-				return nil, fmt.Errorf("unknown error correction level: %s", opts.ErrorCorrection)
-			}
-		}
-		if opts.Margin != nil {
-			quietZone = *opts.Margin
-		}
-		if opts.QRVersion > 0 {
-			qrVersion = opts.QRVersion
-		}
-		if opts.QRMaskPattern >= 0 && opts.QRMaskPattern <= 7 {
-			maskPattern = opts.QRMaskPattern
+		return fmt.Errorf("requested dimensions are too small: %dx%d", width, height)
+	}
+	return nil
+}
+
+// quietZone returns the quiet zone in modules selected by opts, which may be
+// nil.
+func quietZone(opts *zxinggo.EncodeOptions) int {
+	return zxinggo.EncodeQuietZone(zxinggo.FormatQRCode, opts).Left
+}
+
+// encoderSettings returns the contents to encode, the error correction level
+// and the encoder hints selected by opts, which may be nil.
+func encoderSettings(contents string, opts *zxinggo.EncodeOptions) (string, decoder.ErrorCorrectionLevel, *encoder.Hints, error) {
+	ecLevel, hints, err := encoderHints(opts)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if opts != nil && opts.GS1Format {
+		// Accept the bracketed human-readable form as a convenience, and
+		// validate either form.
+		if contents, err = gs1.RawElementString(contents); err != nil {
+			return "", 0, nil, err
 		}
 	}
+	return contents, ecLevel, hints, nil
+}
 
-	code, err := encoder.Encode(contents, ecLevel, qrVersion, maskPattern)
+// encoderHints returns the error correction level and the encoder hints
+// selected by opts, which may be nil.
+func encoderHints(opts *zxinggo.EncodeOptions) (decoder.ErrorCorrectionLevel, *encoder.Hints, error) {
+	hints := &encoder.Hints{MaskPattern: -1}
+	if opts == nil {
+		return decoder.ECLevelL, hints, nil
+	}
+	ecLevel, err := parseECLevel(opts.ErrorCorrection)
 	if err != nil {
-		return nil, err
+		return 0, nil, err
+	}
+	if opts.QRVersion > 0 {
+		hints.Version = opts.QRVersion
+	}
+	if opts.QRMaskPattern >= 0 && opts.QRMaskPattern <= 7 {
+		hints.MaskPattern = opts.QRMaskPattern
+	}
+	hints.BoostECLevel = opts.BoostECLevel
+	hints.ApplicationIndicator = opts.ApplicationIndicator
+	hints.GS1Format = opts.GS1Format
+	hints.CharacterSet = opts.CharacterSet
+	return ecLevel, hints, nil
+}
+
+// parseECLevel returns the error correction level named by s, or L if s is
+// empty.
+func parseECLevel(s string) (decoder.ErrorCorrectionLevel, error) {
+	switch s {
+	case "", "L":
+		return decoder.ECLevelL, nil
+	case "M":
+		return decoder.ECLevelM, nil
+	case "Q":
+		return decoder.ECLevelQ, nil
+	case "H":
+		return decoder.ECLevelH, nil
+	default:
+		return 0, fmt.Errorf("unknown error correction level: %s", s)
 	}
-	return encoder.RenderResult(code, width, height, quietZone), nil
 }
@@ -0,0 +1,168 @@
+package zxinggo
+
+import (
+	"slices"
+	"time"
+
+	"github.com/ericlevine/zxinggo/bitutil"
+)
+
+// QuietZone is the blank margin, in modules, a symbol needs on each side for
+// readers to tell where it starts and ends. 1D symbols need none above and
+// below.
+type QuietZone struct {
+	Left, Right, Top, Bottom int
+}
+
+// quietZones are the quiet zones writers leave around symbols and
+// ProfileStrict readers require of them.
+var quietZones = map[Format]QuietZone{
+	FormatQRCode:     {4, 4, 4, 4},
+	FormatDataMatrix: {1, 1, 1, 1},
+	FormatAztec:      {1, 1, 1, 1},
+	FormatMaxiCode:   {1, 1, 1, 1},
+	FormatPDF417:     {2, 2, 2, 2},
+	FormatEAN13:      {Left: 9, Right: 7},
+	FormatUPCA:       {Left: 9, Right: 7},
+	FormatUPCE:       {Left: 9, Right: 7},
+	FormatEAN8:       {Left: 7, Right: 7},
+	FormatCode128:    {Left: 10, Right: 10},
+	FormatCode39:     {Left: 10, Right: 10},
+	FormatCode93:     {Left: 10, Right: 10},
+	FormatCodabar:    {Left: 10, Right: 10},
+	FormatITF:        {Left: 10, Right: 10},
+}
+
+// DefaultQuietZone returns the quiet zone writers leave around symbols of
+// format, and readers under ProfileStrict require: 4 modules around QR
+// codes, 2 around PDF417, 1 around Data Matrix, Aztec and MaxiCode symbols,
+// 9 left and 7 right of EAN-13, UPC-A and UPC-E, 7 either side of EAN-8 and
+// 10 either side of other 1D symbols. It is zero for formats without a
+// writer.
+func DefaultQuietZone(format Format) QuietZone {
+	return quietZones[format]
+}
+
+// EncodeQuietZone returns the quiet zone to leave around a symbol of format
+// encoded with opts, which may be nil: opts.Margin on each side if set,
+// only left and right of 1D symbols, or else DefaultQuietZone.
+func EncodeQuietZone(format Format, opts *EncodeOptions) QuietZone {
+	zone := DefaultQuietZone(format)
+	if opts == nil || opts.Margin == nil {
+		return zone
+	}
+	m := *opts.Margin
+	if slices.Contains(formatFamilies[FormatAnyOneD], format) {
+		return QuietZone{Left: m, Right: m}
+	}
+	return QuietZone{m, m, m, m}
+}
+
+// pureMarginFraction is the default white border added around a pure image
+// that lacks a quiet zone, as a fraction of its longer side.
+const pureMarginFraction = 0.125
+
+// maxPureGrayFraction is the largest fraction of mid-grey pixels in an image
+// treated as a rendered symbol rather than a photograph, which is never
+// padded.
+const maxPureGrayFraction = 0.125
+
+// padPureImage returns a copy of image with a white border added, and the
+// border's width, if opts marks it as a pure barcode, black pixels reach its
+// edges and it is nearly black and white, as rendered images cropped tightly
+// to the symbol are. The copy is binarized afresh, so that pixels on the
+// original edges are thresholded like any other. Otherwise, or if opts
+// disables DetectorPurePadding, it returns nil without allocating the copy.
+// MultiFormatReader calls it only once the image itself has failed to decode.
+func padPureImage(image *BinaryBitmap, opts *DecodeOptions) (*BinaryBitmap, int) {
+	if opts == nil || !opts.PureBarcode || opts.PureQuietZone < 0 || DetectorDisabled(opts, DetectorPurePadding) {
+		return nil, 0
+	}
+	matrix, err := image.BlackMatrix()
+	if err != nil || !touchesEdge(matrix) {
+		return nil, 0
+	}
+	source := image.binarizer.LuminanceSource()
+	lum := source.Matrix()
+	if !isTwoTone(lum) {
+		return nil, 0
+	}
+	width, height := source.Width(), source.Height()
+	pad := opts.PureQuietZone
+	if pad == 0 {
+		pad = int(pureMarginFraction*float64(max(width, height))) + 1
+	}
+
+	paddedWidth := width + 2*pad
+	luminances := make([]byte, paddedWidth*(height+2*pad))
+	for i := range luminances {
+		luminances[i] = 255
+	}
+	for y := 0; y < height; y++ {
+		copy(luminances[(y+pad)*paddedWidth+pad:], lum[y*width:(y+1)*width])
+	}
+	binarizer := NewBinarizerFromSource(image.binarizer, &ImageLuminanceSource{luminances: luminances, width: paddedWidth, height: height + 2*pad})
+	if binarizer == nil {
+		return nil, 0
+	}
+	return NewBinaryBitmap(binarizer), pad
+}
+
+// touchesEdge reports whether any pixel on the edges of matrix is black.
+func touchesEdge(matrix *bitutil.BitMatrix) bool {
+	width, height := matrix.Width(), matrix.Height()
+	for x := 0; x < width; x++ {
+		if matrix.Get(x, 0) || matrix.Get(x, height-1) {
+			return true
+		}
+	}
+	for y := 0; y < height; y++ {
+		if matrix.Get(0, y) || matrix.Get(width-1, y) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTwoTone reports whether few enough luminances are mid-grey for the image
+// to be a rendering rather than a photograph.
+func isTwoTone(luminances []byte) bool {
+	gray := 0
+	for _, l := range luminances {
+		if l >= 64 && l < 192 {
+			gray++
+		}
+	}
+	return float64(gray) <= maxPureGrayFraction*float64(len(luminances))
+}
+
+// decodePadded decodes an image padded by padPureImage with the first of
+// readers, built from the registrations groups, that can, and returns the
+// result in the coordinates of the original image. Work on the padded image
+// is not recorded in opts.Heatmap.
+func decodePadded(readers []Reader, groups []int, padded *BinaryBitmap, pad int, opts *DecodeOptions) (*Result, bool) {
+	defer observeStage(opts, MetricsStagePadded, time.Now())
+	paddedOpts := *opts
+	paddedOpts.Heatmap = nil
+	for i, reader := range readers {
+		if DeadlinePassed(opts) {
+			break
+		}
+		if result, err := readImage(reader, groups[i], padded, &paddedOpts); err == nil {
+			result = unpadResult(refineResult(padded, result, &paddedOpts), pad)
+			opts.Heatmap.recordResult(result)
+			return result, true
+		}
+	}
+	return nil, false
+}
+
+// unpadResult moves the points of a result decoded from an image padded by
+// padPureImage back into the coordinates of the original image.
+func unpadResult(result *Result, pad int) *Result {
+	for i := range result.Points {
+		result.Points[i].X -= float64(pad)
+		result.Points[i].Y -= float64(pad)
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+package zxinggo_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+)
+
+// tightCrop renders contents and crops the image to the symbol's bars or
+// modules, leaving no quiet zone.
+func tightCrop(t *testing.T, contents string, format zxinggo.Format) *image.Gray {
+	t.Helper()
+	matrix, err := zxinggo.Encode(contents, format, 200, 60, nil)
+	if err != nil {
+		t.Fatalf("%v: encode error: %v", format, err)
+	}
+	r := matrix.EnclosingRectangle()
+	img := image.NewGray(image.Rect(0, 0, r[2], r[3]))
+	for y := 0; y < r[3]; y++ {
+		for x := 0; x < r[2]; x++ {
+			if !matrix.Get(r[0]+x, r[1]+y) {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestPureImageWithoutQuietZone(t *testing.T) {
+	tests := []struct {
+		format   zxinggo.Format
+		contents string
+	}{
+		{zxinggo.FormatQRCode, "TIGHT CROP"},
+		{zxinggo.FormatAztec, "tight crop"},
+		{zxinggo.FormatCode128, "TIGHT-128"},
+		{zxinggo.FormatEAN13, "5901234123457"},
+	}
+	for _, tt := range tests {
+		img := tightCrop(t, tt.contents, tt.format)
+		source := zxinggo.NewGrayImageLuminanceSource(img)
+		for _, quietZone := range []int{0, 4} {
+			opts := &zxinggo.DecodeOptions{PureBarcode: true, PureQuietZone: quietZone, PossibleFormats: []zxinggo.Format{tt.format}}
+			bitmap := zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(source))
+			result, err := zxinggo.Decode(bitmap, opts)
+			if err != nil {
+				t.Errorf("%v, quiet zone %d: decode error: %v", tt.format, quietZone, err)
+				continue
+			}
+			if result.Text != tt.contents {
+				t.Errorf("%v, quiet zone %d: text = %q, want %q", tt.format, quietZone, result.Text, tt.contents)
+			}
+			for _, p := range result.Points {
+				if p.X < -1 || p.Y < -1 || p.X > float64(img.Rect.Dx()) || p.Y > float64(img.Rect.Dy()) {
+					t.Errorf("%v, quiet zone %d: point %v outside the image", tt.format, quietZone, p)
+				}
+			}
+		}
+	}
+}
+
+func TestPureQuietZoneDisabled(t *testing.T) {
+	source := zxinggo.NewGrayImageLuminanceSource(tightCrop(t, "TIGHT-128", zxinggo.FormatCode128))
+	formats := []zxinggo.Format{zxinggo.FormatCode128}
+	for _, opts := range []*zxinggo.DecodeOptions{
+		{PureBarcode: true, PureQuietZone: -1, PossibleFormats: formats},
+		{PureBarcode: true, DisableDetectors: zxinggo.DetectorPurePadding, PossibleFormats: formats},
+	} {
+		if result, err := zxinggo.Decode(zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(source)), opts); err == nil {
+			t.Errorf("decoded %q without a quiet zone", result.Text)
+		}
+	}
+}
+
+func TestEncodeQuietZone(t *testing.T) {
+	three := 3
+	tests := []struct {
+		format   zxinggo.Format
+		contents string
+		opts     *zxinggo.EncodeOptions
+		want     zxinggo.QuietZone
+	}{
+		{zxinggo.FormatQRCode, "QUIET", nil, zxinggo.QuietZone{Left: 4, Right: 4, Top: 4, Bottom: 4}},
+		{zxinggo.FormatDataMatrix, "QUIET", nil, zxinggo.QuietZone{Left: 1, Right: 1, Top: 1, Bottom: 1}},
+		{zxinggo.FormatPDF417, "QUIET", nil, zxinggo.QuietZone{Left: 2, Right: 2, Top: 2, Bottom: 2}},
+		{zxinggo.FormatEAN13, "5901234123457", nil, zxinggo.QuietZone{Left: 9, Right: 7}},
+		{zxinggo.FormatEAN8, "96385074", nil, zxinggo.QuietZone{Left: 7, Right: 7}},
+		{zxinggo.FormatCode128, "QUIET", nil, zxinggo.QuietZone{Left: 10, Right: 10}},
+		{zxinggo.FormatEAN13, "5901234123457", &zxinggo.EncodeOptions{Margin: &three}, zxinggo.QuietZone{Left: 3, Right: 3}},
+		{zxinggo.FormatQRCode, "QUIET", &zxinggo.EncodeOptions{Margin: &three}, zxinggo.QuietZone{Left: 3, Right: 3, Top: 3, Bottom: 3}},
+	}
+	for _, tc := range tests {
+		if tc.opts == nil {
+			if got := zxinggo.DefaultQuietZone(tc.format); got != tc.want {
+				t.Errorf("DefaultQuietZone(%v) = %+v, want %+v", tc.format, got, tc.want)
+			}
+		}
+		// At the smallest size, modules are a pixel wide.
+		matrix, err := zxinggo.Encode(tc.contents, tc.format, 0, 0, tc.opts)
+		if err != nil {
+			t.Fatalf("%v: encode error: %v", tc.format, err)
+		}
+		r := matrix.EnclosingRectangle()
+		got := zxinggo.QuietZone{
+			Left:   r[0],
+			Right:  matrix.Width() - r[0] - r[2],
+			Top:    r[1],
+			Bottom: matrix.Height() - r[1] - r[3],
+		}
+		if got != tc.want {
+			t.Errorf("%v with %+v: quiet zone %+v, want %+v", tc.format, tc.opts, got, tc.want)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package reedsolomon
 
-import "errors"
+import (
+	"errors"
+	"slices"
+)
 
 // ErrReedSolomon indicates a Reed-Solomon decoding failure.
 var ErrReedSolomon = errors.New("reedsolomon: decoding error")
@@ -18,14 +21,12 @@ func NewDecoder(field *GenericGF) *Decoder {
 // Decode corrects errors in received in-place and returns the number of
 // errors corrected. twoS is the number of error-correction codewords.
 func (d *Decoder) Decode(received []int, twoS int) (int, error) {
-	poly := newGenericGFPoly(d.field, received)
-	syndromeCoefficients := make([]int, twoS)
+	syndromeCoefficients := Syndromes(d.field, received, twoS)
 	noError := true
-	for i := 0; i < twoS; i++ {
-		eval := poly.EvaluateAt(d.field.Exp(i + d.field.GeneratorBase()))
-		syndromeCoefficients[twoS-1-i] = eval
-		if eval != 0 {
+	for _, s := range syndromeCoefficients {
+		if s != 0 {
 			noError = false
+			break
 		}
 	}
 	if noError {
@@ -54,6 +55,26 @@ func (d *Decoder) Decode(received []int, twoS int) (int, error) {
 	return len(errorLocations), nil
 }
 
+// Correctable reports whether received, data followed by twoS
+// error-correction codewords, can be corrected. received is not changed.
+func (d *Decoder) Correctable(received []int, twoS int) bool {
+	_, err := d.Decode(slices.Clone(received), twoS)
+	return err == nil
+}
+
+// Syndromes evaluates the codewords in received, data followed by twoS
+// error-correction codewords, at the roots of the field's generator
+// polynomial. The highest root's syndrome comes first. All syndromes are zero
+// exactly when received is a valid codeword.
+func Syndromes(field *GenericGF, received []int, twoS int) []int {
+	poly := newGenericGFPoly(field, received)
+	syndromes := make([]int, twoS)
+	for i := 0; i < twoS; i++ {
+		syndromes[twoS-1-i] = poly.EvaluateAt(field.Exp(i + field.GeneratorBase()))
+	}
+	return syndromes
+}
+
 func (d *Decoder) runEuclideanAlgorithm(a, b *GenericGFPoly, R int) ([2]*GenericGFPoly, error) {
 	if a.Degree() < b.Degree() {
 		a, b = b, a
@@ -24,6 +24,9 @@ var (
 	AztecData6        = NewGenericGF(0x0043, 64, 1)
 	AztecParam        = NewGenericGF(0x0013, 16, 1)
 	MaxiCodeField64   = AztecData6
+	AustraliaPostField64 = AztecData6
+	HanXinField256    = NewGenericGF(0x0163, 256, 1) // x^8 + x^6 + x^5 + x + 1
+	HanXinFunction    = AztecParam
 )
 
 // NewGenericGF creates a GF(size) using the given primitive polynomial.
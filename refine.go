@@ -0,0 +1,95 @@
+package zxinggo
+
+import "math"
+
+const (
+	// refineMaxIterations bounds the number of refinement steps per point.
+	refineMaxIterations = 10
+
+	// refineEpsilon is the step size, in pixels, below which refinement is
+	// considered converged.
+	refineEpsilon = 0.01
+)
+
+// RefinePoint refines p to sub-pixel accuracy by fitting the luminance edges
+// within radius pixels of it. It finds the point q minimizing the sum over
+// the window of (g·(x−q))², where g is the luminance gradient at pixel x,
+// which for a corner is the intersection of its edges. Working on the
+// greyscale image rather than the binarized one recovers the position
+// information carried by anti-aliased edge pixels.
+//
+// The window should be large enough to include the edges around the point,
+// typically about one module. p is returned unchanged if the window holds
+// too little edge structure to constrain both coordinates, or if the fit
+// wanders outside the window.
+func RefinePoint(source LuminanceSource, p ResultPoint, radius int) ResultPoint {
+	return refinePoint(source.Matrix(), source.Width(), source.Height(), p, radius)
+}
+
+// RefineResultPoints refines every point of r in place using RefinePoint.
+func RefineResultPoints(source LuminanceSource, r *Result, radius int) {
+	if len(r.Points) == 0 || radius <= 0 {
+		return
+	}
+	lum := source.Matrix()
+	for i, p := range r.Points {
+		r.Points[i] = refinePoint(lum, source.Width(), source.Height(), p, radius)
+	}
+}
+
+func refinePoint(lum []byte, width, height int, p ResultPoint, radius int) ResultPoint {
+	if radius <= 0 {
+		return p
+	}
+	// Gaussian weights favour gradients near the current estimate.
+	sigma := float64(radius) / 2
+	q := p
+	for iter := 0; iter < refineMaxIterations; iter++ {
+		cx := int(math.Round(q.X))
+		cy := int(math.Round(q.Y))
+		var gxx, gxy, gyy, bx, by float64
+		for y := cy - radius; y <= cy+radius; y++ {
+			if y < 1 || y >= height-1 {
+				continue
+			}
+			for x := cx - radius; x <= cx+radius; x++ {
+				if x < 1 || x >= width-1 {
+					continue
+				}
+				gx := (float64(lum[y*width+x+1]) - float64(lum[y*width+x-1])) / 2
+				gy := (float64(lum[(y+1)*width+x]) - float64(lum[(y-1)*width+x])) / 2
+				dx := float64(x) - q.X
+				dy := float64(y) - q.Y
+				w := math.Exp(-(dx*dx + dy*dy) / (2 * sigma * sigma))
+				a := w * gx * gx
+				b := w * gx * gy
+				c := w * gy * gy
+				gxx += a
+				gxy += b
+				gyy += c
+				bx += a*float64(x) + b*float64(y)
+				by += b*float64(x) + c*float64(y)
+			}
+		}
+		det := gxx*gyy - gxy*gxy
+		// Require both eigenvalues to be significant: edges in one direction
+		// only (as at the end of a 1D bar) leave the position unconstrained.
+		trace := gxx + gyy
+		if trace == 0 || det < 1e-3*trace*trace {
+			return p
+		}
+		next := ResultPoint{
+			X: (gyy*bx - gxy*by) / det,
+			Y: (gxx*by - gxy*bx) / det,
+		}
+		step := Distance(next, q)
+		q = next
+		if Distance(q, p) > float64(radius) {
+			return p
+		}
+		if step < refineEpsilon {
+			break
+		}
+	}
+	return q
+}
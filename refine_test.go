@@ -0,0 +1,52 @@
+package zxinggo
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+// renderSquare draws a black square with sub-pixel corners on white, using
+// 8x8 supersampling so edge pixels carry partial coverage.
+func renderSquare(width, height int, left, top, right, bottom float64) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	const ss = 8
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			covered := 0
+			for sy := 0; sy < ss; sy++ {
+				for sx := 0; sx < ss; sx++ {
+					px := float64(x) + (float64(sx)+0.5)/ss
+					py := float64(y) + (float64(sy)+0.5)/ss
+					if px >= left && px < right && py >= top && py < bottom {
+						covered++
+					}
+				}
+			}
+			img.Pix[y*img.Stride+x] = byte(255 - 255*covered/(ss*ss))
+		}
+	}
+	return img
+}
+
+func TestRefinePointCorner(t *testing.T) {
+	// Pixel (x, y) covers [x, x+1); its centre is at x+0.5. Shift by half a
+	// pixel so that the expected corner is in pixel-centre coordinates.
+	img := renderSquare(60, 60, 20.8, 15.3, 45.8, 40.3)
+	source := NewGrayImageLuminanceSource(img)
+	want := ResultPoint{X: 20.3, Y: 14.8}
+	got := RefinePoint(source, ResultPoint{X: 21, Y: 15}, 4)
+	if math.Abs(got.X-want.X) > 0.15 || math.Abs(got.Y-want.Y) > 0.15 {
+		t.Errorf("RefinePoint = %+v, want %+v", got, want)
+	}
+}
+
+func TestRefinePointUnconstrained(t *testing.T) {
+	// A vertical edge only constrains x; the point must be left alone.
+	img := renderSquare(60, 60, 30.5, 0, 60, 60)
+	source := NewGrayImageLuminanceSource(img)
+	p := ResultPoint{X: 30, Y: 30}
+	if got := RefinePoint(source, p, 4); got != p {
+		t.Errorf("RefinePoint = %+v, want unchanged %+v", got, p)
+	}
+}
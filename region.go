@@ -0,0 +1,121 @@
+package zxinggo
+
+import (
+	"math"
+	"time"
+
+	"github.com/ericlevine/zxinggo/transform"
+)
+
+// Region is a candidate symbol location proposed by an external detector.
+type Region struct {
+	// Corners are the corners of the symbol, excluding its quiet zone, in
+	// image coordinates and in order around the symbol. They need not start
+	// at the symbol's top-left corner.
+	Corners [4]ResultPoint
+
+	// Formats, if non-empty, restricts the formats tried in this region.
+	// Otherwise DecodeOptions.PossibleFormats applies.
+	Formats []Format
+}
+
+// RegionProposer proposes regions of an image that may contain symbols.
+// Implementations typically wrap a neural network detector.
+type RegionProposer interface {
+	// ProposeRegions returns candidate regions, most likely first.
+	ProposeRegions(source LuminanceSource) ([]Region, error)
+}
+
+// regionMargin is the quiet zone added around a rectified region, as a
+// fraction of its longer side.
+const regionMargin = 0.125
+
+// decodeRegions decodes the regions proposed by opts.RegionProposer, returning
+// the first result with its points mapped back into image coordinates.
+func decodeRegions(image *BinaryBitmap, opts *DecodeOptions) (*Result, error) {
+	defer observeStage(opts, MetricsStageRegions, time.Now())
+	source := image.binarizer.LuminanceSource()
+	regions, err := opts.RegionProposer.ProposeRegions(source)
+	if err != nil {
+		return nil, err
+	}
+	for _, region := range regions {
+		bitmap, xform := rectifyRegion(image, source, region)
+		if bitmap == nil {
+			continue
+		}
+		regionOpts := *opts
+		regionOpts.RegionProposer = nil
+		regionOpts.Heatmap = nil
+		if len(region.Formats) > 0 {
+			regionOpts.PossibleFormats = region.Formats
+		}
+		readers, groups := buildGroupedReaders(&regionOpts)
+		for i, reader := range readers {
+			result, err := readImage(reader, groups[i], bitmap, &regionOpts)
+			if err != nil {
+				continue
+			}
+			TransformPoints(xform, result.Points)
+			return refineResult(image, result, opts), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// rectifyRegion resamples region into an upright image with a margin around
+// it, binarized like image. It returns the bitmap and the transform from its
+// coordinates to those of source, or nil if the region is degenerate or the
+// binarizer cannot be recreated.
+func rectifyRegion(image *BinaryBitmap, source LuminanceSource, region Region) (*BinaryBitmap, *transform.PerspectiveTransform) {
+	c := region.Corners
+	w := math.Max(Distance(c[0], c[1]), Distance(c[3], c[2]))
+	h := math.Max(Distance(c[1], c[2]), Distance(c[0], c[3]))
+	if w < 1 || h < 1 || w > float64(4*source.Width()) || h > float64(4*source.Height()) {
+		return nil, nil
+	}
+	margin := math.Ceil(regionMargin * math.Max(w, h))
+	width := int(math.Ceil(w + 2*margin))
+	height := int(math.Ceil(h + 2*margin))
+	xform := transform.QuadrilateralToQuadrilateral(
+		margin, margin, margin+w, margin, margin+w, margin+h, margin, margin+h,
+		c[0].X+0.5, c[0].Y+0.5, c[1].X+0.5, c[1].Y+0.5, c[2].X+0.5, c[2].Y+0.5, c[3].X+0.5, c[3].Y+0.5)
+
+	lum := source.Matrix()
+	sw, sh := source.Width(), source.Height()
+	out := make([]byte, width*height)
+	coords := make([]float64, 2*width)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			coords[2*x] = float64(x) + 0.5
+			coords[2*x+1] = float64(y) + 0.5
+		}
+		xform.TransformPoints(coords)
+		for x := 0; x < width; x++ {
+			out[y*width+x] = sampleBilinear(lum, sw, sh, coords[2*x]-0.5, coords[2*x+1]-0.5)
+		}
+	}
+	binarizer := NewBinarizerFromSource(image.binarizer, &ImageLuminanceSource{luminances: out, width: width, height: height})
+	if binarizer == nil {
+		return nil, nil
+	}
+	return NewBinaryBitmap(binarizer), xform
+}
+
+// sampleBilinear interpolates the luminance at (x, y), treating pixels
+// outside the image as white.
+func sampleBilinear(lum []byte, width, height int, x, y float64) byte {
+	x0 := int(math.Floor(x))
+	y0 := int(math.Floor(y))
+	fx := x - float64(x0)
+	fy := y - float64(y0)
+	at := func(px, py int) float64 {
+		if px < 0 || py < 0 || px >= width || py >= height {
+			return 255
+		}
+		return float64(lum[py*width+px])
+	}
+	top := at(x0, y0)*(1-fx) + at(x0+1, y0)*fx
+	bottom := at(x0, y0+1)*(1-fx) + at(x0+1, y0+1)*fx
+	return byte(math.Round(top*(1-fy) + bottom*fy))
+}
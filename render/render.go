@@ -0,0 +1,95 @@
+// Package render draws the module grids of symbols into pixel matrices,
+// placing and shaping modules by a transform.Lattice so that writers need
+// no pixel arithmetic of their own for square or hexagonal modules.
+package render
+
+import (
+	"math"
+
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/transform"
+)
+
+// Canvas is a pixel matrix with a symbol's lattice placed on it: lattice
+// coordinates, in module widths, are scaled by Module and offset to Left
+// and Top.
+type Canvas struct {
+	Matrix  *bitutil.BitMatrix
+	Lattice transform.Lattice
+
+	// Left and Top are the pixel position of the top left corner of the
+	// box bounding the symbol.
+	Left, Top float64
+
+	// Module is the width of a module in pixels.
+	Module float64
+}
+
+// NewCanvas returns a blank canvas of width by height pixels holding a grid
+// of dimensionX columns and dimensionY rows laid out on lattice, centred,
+// with quietZone modules of space around it. Modules are the largest whole
+// number of pixels wide that fits, but no fewer than minModule; the canvas
+// grows if the symbol does not fit at that size.
+func NewCanvas(lattice transform.Lattice, dimensionX, dimensionY, width, height, quietZone, minModule int) *Canvas {
+	symbolWidth, symbolHeight := lattice.Bounds(dimensionX, dimensionY)
+	outputWidth := symbolWidth + float64(2*quietZone)
+	outputHeight := symbolHeight + float64(2*quietZone)
+
+	module := math.Floor(math.Min(float64(width)/outputWidth, float64(height)/outputHeight))
+	module = math.Max(module, float64(minModule))
+	width = max(width, int(math.Ceil(module*outputWidth)))
+	height = max(height, int(math.Ceil(module*outputHeight)))
+	return &Canvas{
+		Matrix:  bitutil.NewBitMatrixWithSize(width, height),
+		Lattice: lattice,
+		Left:    (float64(width) - module*symbolWidth) / 2,
+		Top:     (float64(height) - module*symbolHeight) / 2,
+		Module:  module,
+	}
+}
+
+// Transform returns the transform from lattice coordinates to the pixels of
+// the canvas.
+func (c *Canvas) Transform() *transform.PerspectiveTransform {
+	return transform.Translation(c.Left, c.Top).Times(transform.Scaling(c.Module, c.Module))
+}
+
+// DrawModules fills the module at each position set in code.
+func (c *Canvas) DrawModules(code *bitutil.BitMatrix) {
+	for y := 0; y < code.Height(); y++ {
+		for x := 0; x < code.Width(); x++ {
+			if code.Get(x, y) {
+				cx, cy := c.Lattice.Center(x, y)
+				c.fill(cx, cy, c.Lattice.Extent, c.Lattice.Contains)
+			}
+		}
+	}
+}
+
+// FillRing fills the pixels whose centres lie between radii inner and
+// outer, in module widths, of the point (x, y) in lattice coordinates. An
+// inner radius of zero fills a disc.
+func (c *Canvas) FillRing(x, y, inner, outer float64) {
+	extent := func() (float64, float64) { return outer, outer }
+	c.fill(x, y, extent, func(dx, dy float64) bool {
+		d := math.Hypot(dx, dy)
+		return d >= inner && d <= outer
+	})
+}
+
+// fill sets the pixels, within extent of the point (x, y) in lattice
+// coordinates, whose centres are at an offset from it that contains
+// accepts.
+func (c *Canvas) fill(x, y float64, extent func() (float64, float64), contains func(dx, dy float64) bool) {
+	ex, ey := extent()
+	cx, cy := c.Left+x*c.Module, c.Top+y*c.Module
+	x0, x1 := max(int(cx-ex*c.Module), 0), min(int(cx+ex*c.Module), c.Matrix.Width()-1)
+	y0, y1 := max(int(cy-ey*c.Module), 0), min(int(cy+ey*c.Module), c.Matrix.Height()-1)
+	for py := y0; py <= y1; py++ {
+		for px := x0; px <= x1; px++ {
+			if contains((float64(px)+0.5-cx)/c.Module, (float64(py)+0.5-cy)/c.Module) {
+				c.Matrix.Set(px, py)
+			}
+		}
+	}
+}
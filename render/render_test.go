@@ -0,0 +1,45 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/transform"
+)
+
+func TestDrawAndSample(t *testing.T) {
+	code := bitutil.NewBitMatrixWithSize(13, 11)
+	for y := 0; y < code.Height(); y++ {
+		for x := 0; x < code.Width(); x++ {
+			if (x*7+y*3)%5 < 2 {
+				code.Set(x, y)
+			}
+		}
+	}
+	for _, lattice := range []transform.Lattice{transform.SquareLattice, transform.HexagonalLattice} {
+		for _, size := range []int{0, 50, 97} {
+			canvas := NewCanvas(lattice, code.Width(), code.Height(), size, size, 2, 3)
+			canvas.DrawModules(code)
+			if canvas.Matrix.Width() < size || canvas.Matrix.Height() < size {
+				t.Fatalf("%d: canvas is %dx%d", size, canvas.Matrix.Width(), canvas.Matrix.Height())
+			}
+			bits, err := transform.SampleLattice(canvas.Matrix, lattice, code.Width(), code.Height(), canvas.Transform())
+			if err != nil {
+				t.Fatalf("%d: sample error: %v", size, err)
+			}
+			if !bits.Equals(code) {
+				t.Errorf("%d: sampled grid differs from the grid drawn", size)
+			}
+		}
+	}
+}
+
+func TestNewCanvasFits(t *testing.T) {
+	canvas := NewCanvas(transform.SquareLattice, 10, 10, 100, 60, 1, 1)
+	if canvas.Module != 5 || canvas.Matrix.Width() != 100 || canvas.Matrix.Height() != 60 {
+		t.Errorf("module %v in %dx%d, want 5 in 100x60", canvas.Module, canvas.Matrix.Width(), canvas.Matrix.Height())
+	}
+	if canvas.Left != 25 || canvas.Top != 5 {
+		t.Errorf("symbol at (%v, %v), want (25, 5)", canvas.Left, canvas.Top)
+	}
+}
@@ -0,0 +1,181 @@
+package zxinggo
+
+import (
+	"iter"
+	"slices"
+
+	"github.com/ericlevine/zxinggo/transform"
+)
+
+const (
+	// minDimensionToRecur is the smallest width or height, in pixels, of an
+	// area beside a symbol that ReaderResults searches for more.
+	minDimensionToRecur = 100
+	// maxResultsDepth is how many times ReaderResults searches the areas
+	// beside the symbols found in an area.
+	maxResultsDepth = 4
+)
+
+// Results returns an iterator over the symbols of every registered format
+// in image, yielding each as it is found. See ReaderResults.
+func Results(image *BinaryBitmap, opts *DecodeOptions) iter.Seq[*Result] {
+	return ReaderResults(NewMultiFormatReader(), image, opts)
+}
+
+// ReaderResults returns an iterator over the symbols reader finds in image,
+// as multi.GenericMultipleBarcodeReader decodes them: after a symbol is
+// found, the areas left, above, right and below it are searched in turn,
+// and so on. If reader is a MultipleBarcodeReader, such as the 1D reader,
+// every symbol it returns is yielded. Symbols are yielded as they are
+// found, once for each text, and the search stops when the loop over them
+// does, or as opts.MaxResults and opts.StopOnFormats ask, so a caller after
+// the first few symbols of a large sheet need not wait for the rest. If
+// image's source is a CoordinateMapper, the points
+// are in the coordinates of the original image.
+func ReaderResults(reader Reader, image *BinaryBitmap, opts *DecodeOptions) iter.Seq[*Result] {
+	return func(yield func(*Result) bool) {
+		search := &resultSearch{reader: reader, opts: opts, yield: yield, seen: map[string]bool{}}
+		search.toOriginal = sourceToOriginal(image.LuminanceSource())
+		search.area(image, 0, 0, 0)
+	}
+}
+
+// resultSearch is the state of a ReaderResults iteration.
+type resultSearch struct {
+	reader  Reader
+	opts    *DecodeOptions
+	yield   func(*Result) bool
+	seen    map[string]bool
+	found   int
+	stopped bool
+
+	// toOriginal maps the image being iterated over to its original, if
+	// it was derived from one.
+	toOriginal *transform.PerspectiveTransform
+}
+
+// area searches image, which is offset by (xOffset, yOffset) from the
+// image being iterated over, and the areas beside the symbols in it.
+func (s *resultSearch) area(image *BinaryBitmap, xOffset, yOffset, depth int) {
+	if depth > maxResultsDepth || s.stopped {
+		return
+	}
+	found, err := s.decode(image)
+	if err != nil {
+		return
+	}
+
+	var points []ResultPoint
+	for _, result := range found {
+		points = append(points, result.Points...)
+		if s.seen[result.Text] {
+			continue
+		}
+		s.seen[result.Text] = true
+		result = translateResult(result, xOffset, yOffset)
+		if s.toOriginal != nil {
+			result = transformResult(result, s.toOriginal)
+		}
+		s.found++
+		if !s.yield(result) || SearchDone(s.opts, s.found, result) {
+			s.stopped = true
+			return
+		}
+	}
+	if len(points) == 0 {
+		return
+	}
+
+	width := image.Width()
+	height := image.Height()
+	minX := float64(width)
+	minY := float64(height)
+	maxX := 0.0
+	maxY := 0.0
+	for _, p := range points {
+		minX = min(minX, p.X)
+		minY = min(minY, p.Y)
+		maxX = max(maxX, p.X)
+		maxY = max(maxY, p.Y)
+	}
+
+	// Left of the symbols
+	if minX > minDimensionToRecur {
+		if cropped := image.Crop(0, 0, int(minX), height); cropped != nil {
+			s.area(cropped, xOffset, yOffset, depth+1)
+		}
+	}
+	// Above
+	if minY > minDimensionToRecur {
+		if cropped := image.Crop(0, 0, width, int(minY)); cropped != nil {
+			s.area(cropped, xOffset, yOffset, depth+1)
+		}
+	}
+	// Right and below start past the symbols' last column and row, as a
+	// sliver of a PDF417 symbol's bars is taken for another symbol.
+	right, below := int(maxX)+1, int(maxY)+1
+	// Right
+	if maxX < float64(width-minDimensionToRecur) {
+		if cropped := image.Crop(right, 0, width-right, height); cropped != nil {
+			s.area(cropped, xOffset+right, yOffset, depth+1)
+		}
+	}
+	// Below
+	if maxY < float64(height-minDimensionToRecur) {
+		if cropped := image.Crop(0, below, width, height-below); cropped != nil {
+			s.area(cropped, xOffset, yOffset+below, depth+1)
+		}
+	}
+}
+
+// SearchDone reports whether a search for every symbol in an image that
+// has found n symbols, the last being last, should stop, as opts.MaxResults
+// and opts.StopOnFormats ask. MultipleBarcodeReaders check it after each
+// symbol they read.
+func SearchDone(opts *DecodeOptions, n int, last *Result) bool {
+	if opts == nil {
+		return false
+	}
+	if opts.MaxResults > 0 && n >= opts.MaxResults {
+		return true
+	}
+	return len(opts.StopOnFormats) > 0 && slices.Contains(ExpandFormats(opts.StopOnFormats), last.Format)
+}
+
+// decode decodes image with the reader, reading every symbol it can return.
+func (s *resultSearch) decode(image *BinaryBitmap) ([]*Result, error) {
+	if multiple, ok := s.reader.(MultipleBarcodeReader); ok {
+		opts := s.opts
+		if opts != nil && opts.MaxResults > 0 {
+			// Ask only for the symbols still wanted.
+			remaining := *opts
+			remaining.MaxResults -= s.found
+			opts = &remaining
+		}
+		return multiple.DecodeMultiple(image, opts)
+	}
+	result, err := s.reader.Decode(image, s.opts)
+	if err != nil {
+		return nil, err
+	}
+	return []*Result{result}, nil
+}
+
+// translateResult returns a copy of result with its points offset by
+// (xOffset, yOffset), or result itself if there is no offset to apply.
+func translateResult(result *Result, xOffset, yOffset int) *Result {
+	if len(result.Points) == 0 || xOffset == 0 && yOffset == 0 {
+		return result
+	}
+	points := make([]ResultPoint, len(result.Points))
+	for i, p := range result.Points {
+		points[i] = ResultPoint{X: p.X + float64(xOffset), Y: p.Y + float64(yOffset)}
+	}
+	translated := NewResult(result.Text, result.RawBytes, points, result.Format)
+	translated.NumBits = result.NumBits
+	translated.Timestamp = result.Timestamp
+	for k, v := range result.Metadata {
+		translated.PutMetadata(k, v)
+	}
+	return translated
+}
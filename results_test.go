@@ -0,0 +1,130 @@
+package zxinggo_test
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+	multiqr "github.com/ericlevine/zxinggo/multi/qrcode"
+	"github.com/ericlevine/zxinggo/pdf417"
+	"github.com/ericlevine/zxinggo/sheet"
+)
+
+func TestResults(t *testing.T) {
+	var items []sheet.Item
+	for i := 0; i < 6; i++ {
+		contents := fmt.Sprintf("ASSET-%04d", i)
+		items = append(items, sheet.Item{Contents: contents, Format: zxinggo.FormatQRCode})
+	}
+	s, err := sheet.New(items, sheet.Layout{Columns: 3, CellWidth: 120, CellHeight: 120, Margin: 20, Gutter: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(zxinggo.NewImageLuminanceSource(s.Image())))
+	opts := &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{zxinggo.FormatQRCode}}
+
+	var texts []string
+	for result := range zxinggo.Results(bitmap, opts) {
+		texts = append(texts, result.Text)
+	}
+	sort.Strings(texts)
+	if len(texts) != len(items) || texts[0] != "ASSET-0000" || texts[5] != "ASSET-0005" {
+		t.Errorf("Results found %q", texts)
+	}
+
+	n := 0
+	for range zxinggo.Results(bitmap, opts) {
+		n++
+		if n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("stopped after %d results, want 2", n)
+	}
+}
+
+// TestResultsStopEarly checks that MaxResults and StopOnFormats end a search
+// for every symbol without the caller breaking out of it.
+func TestResultsStopEarly(t *testing.T) {
+	var items []sheet.Item
+	for i := 0; i < 5; i++ {
+		items = append(items, sheet.Item{Contents: fmt.Sprintf("ITEM-%d", i), Format: zxinggo.FormatCode128})
+	}
+	items = append(items, sheet.Item{Contents: "THE-QR", Format: zxinggo.FormatQRCode})
+	s, err := sheet.New(items, sheet.Layout{Columns: 2, CellWidth: 200, CellHeight: 120, Margin: 20, Gutter: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(zxinggo.NewImageLuminanceSource(s.Image())))
+	formats := []zxinggo.Format{zxinggo.FormatCode128, zxinggo.FormatQRCode}
+
+	all := collect(bitmap, &zxinggo.DecodeOptions{PossibleFormats: formats})
+	if len(all) != len(items) {
+		t.Fatalf("found %d symbols, want %d", len(all), len(items))
+	}
+
+	if got := collect(bitmap, &zxinggo.DecodeOptions{PossibleFormats: formats, MaxResults: 2}); len(got) != 2 {
+		t.Errorf("MaxResults 2 found %d symbols", len(got))
+	}
+
+	got := collect(bitmap, &zxinggo.DecodeOptions{
+		PossibleFormats: formats,
+		StopOnFormats:   []zxinggo.Format{zxinggo.FormatQRCode},
+	})
+	// The full search finds a Code 128 symbol after the QR code.
+	if len(got) == 0 || len(got) == len(all) || got[len(got)-1].Text != "THE-QR" {
+		t.Fatalf("StopOnFormats found %v, want THE-QR last", got)
+	}
+	for _, r := range got[:len(got)-1] {
+		if r.Format == zxinggo.FormatQRCode {
+			t.Errorf("StopOnFormats went on past %q", r.Text)
+		}
+	}
+}
+
+// TestDecodeMultipleStopEarly checks that the readers that find several
+// symbols at once stop as MaxResults and StopOnFormats ask.
+func TestDecodeMultipleStopEarly(t *testing.T) {
+	readers := []struct {
+		format zxinggo.Format
+		reader zxinggo.MultipleBarcodeReader
+	}{
+		{zxinggo.FormatQRCode, multiqr.NewQRCodeMultiReader()},
+		{zxinggo.FormatPDF417, pdf417.NewPDF417Reader()},
+	}
+	for _, r := range readers {
+		var items []sheet.Item
+		for i := 0; i < 4; i++ {
+			items = append(items, sheet.Item{Contents: fmt.Sprintf("PART-%d", i), Format: r.format})
+		}
+		s, err := sheet.New(items, sheet.Layout{Columns: 2, CellWidth: 240, CellHeight: 160, Margin: 20, Gutter: 30})
+		if err != nil {
+			t.Fatal(err)
+		}
+		bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(zxinggo.NewImageLuminanceSource(s.Image())))
+
+		all, err := r.reader.DecodeMultiple(bitmap, &zxinggo.DecodeOptions{})
+		if err != nil || len(all) < 2 {
+			t.Fatalf("%v: found %d symbols, err %v", r.format, len(all), err)
+		}
+		limited, err := r.reader.DecodeMultiple(bitmap, &zxinggo.DecodeOptions{MaxResults: 1})
+		if err != nil || len(limited) != 1 {
+			t.Errorf("%v: MaxResults 1 found %d symbols, err %v", r.format, len(limited), err)
+		}
+		stopped, err := r.reader.DecodeMultiple(bitmap, &zxinggo.DecodeOptions{StopOnFormats: []zxinggo.Format{r.format}})
+		if err != nil || len(stopped) != 1 {
+			t.Errorf("%v: StopOnFormats found %d symbols, err %v", r.format, len(stopped), err)
+		}
+	}
+}
+
+func collect(bitmap *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) []*zxinggo.Result {
+	var results []*zxinggo.Result
+	for result := range zxinggo.Results(bitmap, opts) {
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,150 @@
+package zxinggo
+
+import (
+	"io"
+	"slices"
+	"time"
+)
+
+// Scanner decodes a stream of frames, such as video from a camera, and
+// turns the per-frame results into scan events. A symbol, identified by its
+// format and text, is reported to OnNewResult when it comes into view and
+// to OnResultLost once it has been out of view for a while, rather than in
+// every frame it appears in. Symbols lost together are reported in the order
+// they came into view.
+type Scanner struct {
+	// Options are used to decode every frame.
+	Options *DecodeOptions
+
+	// LostFrames and LostAfter set how long a symbol must be missing before
+	// it is lost: for more than LostFrames consecutive frames and for at
+	// least LostAfter since it was last seen. Until then, seeing it again
+	// does not report a new result.
+	LostFrames int
+	LostAfter  time.Duration
+
+	// OnNewResult, if set, is called with the first result of a symbol that
+	// is not already in view.
+	OnNewResult func(*Result)
+
+	// OnResultLost, if set, is called with the last result of a symbol that
+	// has been lost.
+	OnResultLost func(*Result)
+
+	factory BinarizerFactory
+	reader  Reader
+	now     func() time.Time
+	frame   int
+	inView  []*scanEntry // in the order they came into view
+}
+
+// scanKey identifies a symbol across frames.
+type scanKey struct {
+	format Format
+	text   string
+}
+
+// scanEntry is a symbol in view.
+type scanEntry struct {
+	key       scanKey
+	result    *Result
+	lastFrame int
+	lastSeen  time.Time
+}
+
+// NewScanner creates a scanner that binarizes frames with binarizers from
+// factory, such as binarizer.NewHybrid(nil). A symbol is lost after being
+// missing for 5 frames and half a second.
+func NewScanner(factory BinarizerFactory, opts *DecodeOptions) *Scanner {
+	return &Scanner{
+		Options:    opts,
+		LostFrames: 5,
+		LostAfter:  500 * time.Millisecond,
+		factory:    factory,
+		reader:     NewMultiFormatReader(),
+		now:        time.Now,
+	}
+}
+
+// ScanFrame decodes one frame, reports the events it causes and returns the
+// frame's result.
+func (s *Scanner) ScanFrame(source LuminanceSource) (*Result, error) {
+	s.frame++
+	now := s.now()
+	result, err := s.reader.Decode(NewBinaryBitmap(s.frameBinarizer(source)), s.Options)
+	if err == nil {
+		key := scanKey{result.Format, result.Text}
+		i := slices.IndexFunc(s.inView, func(e *scanEntry) bool { return e.key == key })
+		var entry *scanEntry
+		if i >= 0 {
+			entry = s.inView[i]
+		} else {
+			entry = &scanEntry{key: key}
+			s.inView = append(s.inView, entry)
+			if s.OnNewResult != nil {
+				s.OnNewResult(result)
+			}
+		}
+		entry.result, entry.lastFrame, entry.lastSeen = result, s.frame, now
+	}
+
+	var lost []*scanEntry
+	s.inView = slices.DeleteFunc(s.inView, func(e *scanEntry) bool {
+		if s.frame-e.lastFrame > s.LostFrames && now.Sub(e.lastSeen) >= s.LostAfter {
+			lost = append(lost, e)
+			return true
+		}
+		return false
+	})
+	s.reportLost(lost)
+	return result, err
+}
+
+// Run scans every frame from frames, reporting events as ScanFrame does,
+// until Next fails. It returns nil when frames ends with io.EOF and Next's
+// error otherwise. Frames in which nothing decodes are not errors. Symbols
+// still in view at the end are not reported lost until Reset is called.
+func (s *Scanner) Run(frames FrameSource) error {
+	for {
+		source, err := frames.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		s.ScanFrame(source)
+	}
+}
+
+// frameBinarizer returns the binarizer for a frame, from
+// CreateFrameBinarizer if the factory carries state between frames.
+func (s *Scanner) frameBinarizer(source LuminanceSource) Binarizer {
+	if f, ok := s.factory.(FrameBinarizerFactory); ok {
+		return f.CreateFrameBinarizer(source)
+	}
+	return s.factory.CreateBinarizer(source)
+}
+
+// Reset reports every symbol in view as lost and forgets them. If the
+// binarizer factory is a FrameBinarizerFactory, such as a smoothed
+// GlobalHistogram, it is reset to forget state carried between frames.
+func (s *Scanner) Reset() {
+	lost := s.inView
+	s.inView = nil
+	s.reportLost(lost)
+	s.reader.Reset()
+	if f, ok := s.factory.(FrameBinarizerFactory); ok {
+		f.Reset()
+	}
+}
+
+// reportLost calls OnResultLost with the last result of each entry.
+func (s *Scanner) reportLost(lost []*scanEntry) {
+	if s.OnResultLost == nil {
+		return
+	}
+	for _, entry := range lost {
+		s.OnResultLost(entry.result)
+	}
+}
@@ -0,0 +1,147 @@
+package zxinggo
+
+import (
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ericlevine/zxinggo/bitutil"
+)
+
+// frameSource is a frame whose contents are the text a scriptedReader
+// decodes from it, or nothing if empty.
+type frameSource struct{ text string }
+
+func (f frameSource) Row(y int, row []byte) []byte { return row }
+func (f frameSource) Matrix() []byte               { return nil }
+func (f frameSource) Width() int                   { return 1 }
+func (f frameSource) Height() int                  { return 1 }
+
+type frameBinarizer struct{ source LuminanceSource }
+
+func (b frameBinarizer) BlackRow(y int, row *bitutil.BitArray) (*bitutil.BitArray, error) {
+	return row, nil
+}
+func (b frameBinarizer) BlackMatrix() (*bitutil.BitMatrix, error) { return nil, ErrNotFound }
+func (b frameBinarizer) LuminanceSource() LuminanceSource         { return b.source }
+func (b frameBinarizer) Width() int                               { return 1 }
+func (b frameBinarizer) Height() int                              { return 1 }
+func (b frameBinarizer) CreateBinarizer(source LuminanceSource) Binarizer {
+	return frameBinarizer{source}
+}
+
+type scriptedReader struct{}
+
+func (scriptedReader) Decode(image *BinaryBitmap, opts *DecodeOptions) (*Result, error) {
+	text := image.binarizer.LuminanceSource().(frameSource).text
+	if text == "" {
+		return nil, ErrNotFound
+	}
+	return NewResult(text, nil, nil, FormatQRCode), nil
+}
+
+func (scriptedReader) Reset() {}
+
+func TestScannerEvents(t *testing.T) {
+	clock := time.Unix(0, 0)
+	s := NewScanner(frameBinarizer{}, nil)
+	s.reader = scriptedReader{}
+	s.now = func() time.Time { return clock }
+	s.LostFrames = 2
+	s.LostAfter = 100 * time.Millisecond
+
+	var events []string
+	s.OnNewResult = func(r *Result) { events = append(events, "new "+r.Text) }
+	s.OnResultLost = func(r *Result) { events = append(events, "lost "+r.Text) }
+
+	// Frames 33ms apart: A is held in view with a two-frame dropout, then B
+	// replaces it.
+	for _, text := range []string{"A", "A", "", "", "A", "A", "B", "B", "B", "B", "B"} {
+		s.ScanFrame(frameSource{text})
+		clock = clock.Add(33 * time.Millisecond)
+	}
+	s.Reset()
+
+	want := []string{"new A", "new B", "lost A", "lost B"}
+	if len(events) != len(want) {
+		t.Fatalf("events = %q, want %q", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("events = %q, want %q", events, want)
+			break
+		}
+	}
+}
+
+func TestScannerLostOrder(t *testing.T) {
+	s := NewScanner(frameBinarizer{}, nil)
+	s.reader = scriptedReader{}
+	s.now = func() time.Time { return time.Unix(0, 0) }
+	var lost []string
+	s.OnResultLost = func(r *Result) { lost = append(lost, r.Text) }
+
+	texts := []string{"E", "B", "D", "A", "C", "F", "H", "G"}
+	for _, text := range texts {
+		s.ScanFrame(frameSource{text})
+	}
+	s.Reset()
+	if !slices.Equal(lost, texts) {
+		t.Errorf("lost %q, want %q", lost, texts)
+	}
+}
+
+func TestScannerFrameLimit(t *testing.T) {
+	// With no time limit, a symbol is lost after LostFrames missing frames
+	// however quickly they come.
+	clock := time.Unix(0, 0)
+	s := NewScanner(frameBinarizer{}, nil)
+	s.reader = scriptedReader{}
+	s.now = func() time.Time { return clock }
+	s.LostFrames = 1
+	s.LostAfter = 0
+
+	count := 0
+	s.OnNewResult = func(*Result) { count++ }
+	for _, text := range []string{"A", "", "A", "", "", "A"} {
+		if r, _ := s.ScanFrame(frameSource{text}); text != "" && (r == nil || r.Text != text) {
+			t.Fatalf("frame result = %v, want %q", r, text)
+		}
+	}
+	if count != 2 {
+		t.Errorf("%d new results, want 2", count)
+	}
+}
+
+// scriptedFrames is a FrameSource of frameSources, ending with err.
+type scriptedFrames struct {
+	texts []string
+	err   error
+}
+
+func (f *scriptedFrames) Next() (LuminanceSource, error) {
+	if len(f.texts) == 0 {
+		return nil, f.err
+	}
+	text := f.texts[0]
+	f.texts = f.texts[1:]
+	return frameSource{text}, nil
+}
+
+func TestScannerRun(t *testing.T) {
+	s := NewScanner(frameBinarizer{}, nil)
+	s.reader = scriptedReader{}
+	var seen []string
+	s.OnNewResult = func(r *Result) { seen = append(seen, r.Text) }
+
+	if err := s.Run(&scriptedFrames{[]string{"A", "", "B"}, io.EOF}); err != nil {
+		t.Fatalf("Run = %v at end of stream, want nil", err)
+	}
+	if len(seen) != 2 || seen[0] != "A" || seen[1] != "B" {
+		t.Errorf("new results = %q, want [A B]", seen)
+	}
+	if err := s.Run(&scriptedFrames{nil, io.ErrClosedPipe}); err != io.ErrClosedPipe {
+		t.Errorf("Run = %v, want %v", err, io.ErrClosedPipe)
+	}
+}
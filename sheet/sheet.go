@@ -0,0 +1,212 @@
+// Package sheet lays out several barcodes on one page in a grid, with a
+// label under each, for printed test sheets, batches of asset tags and
+// multi-symbol fixtures for DecodeMultiple. The writers for the formats
+// used must be registered, by importing their packages.
+package sheet
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/internal/font"
+)
+
+// Item is a barcode to place on a sheet.
+type Item struct {
+	Contents string
+	Format   zxinggo.Format
+
+	// Label is printed under the barcode; empty for none. Characters
+	// outside printable ASCII print as '?' in Image.
+	Label string
+
+	// Options are passed to the writer and may be nil.
+	Options *zxinggo.EncodeOptions
+}
+
+// Layout describes the page grid.
+type Layout struct {
+	// Columns is the number of barcodes in each row; zero means one.
+	Columns int
+
+	// Rows limits the number of rows; zero means as many as the items need.
+	Rows int
+
+	// CellWidth and CellHeight are the size in pixels asked of each writer.
+	// Every cell grows to the largest barcode the writers return.
+	CellWidth, CellHeight int
+
+	// Margin is the blank border around the page and Gutter the space
+	// between cells, in pixels.
+	Margin, Gutter int
+
+	// LabelScale is the size of a label's font pixels in page pixels; zero
+	// means 2.
+	LabelScale int
+}
+
+// Sheet is a page of barcodes laid out in a grid.
+type Sheet struct {
+	// Width and Height are the size of the page in pixels.
+	Width, Height int
+
+	items       []Item
+	matrices    []*bitutil.BitMatrix
+	bounds      []image.Rectangle
+	cells       []image.Rectangle // the area of each cell above its label
+	labelScale  int
+	labelHeight int
+	cellWidth   int
+}
+
+// New encodes items and lays them out in reading order.
+func New(items []Item, layout Layout) (*Sheet, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: sheet has no items", zxinggo.ErrWriter)
+	}
+	columns := max(layout.Columns, 1)
+	rows := (len(items) + columns - 1) / columns
+	if layout.Rows > 0 && rows > layout.Rows {
+		return nil, fmt.Errorf("%w: %d items do not fit %d rows of %d", zxinggo.ErrWriter, len(items), layout.Rows, columns)
+	}
+	s := &Sheet{
+		items:      items,
+		matrices:   make([]*bitutil.BitMatrix, len(items)),
+		bounds:     make([]image.Rectangle, len(items)),
+		cells:      make([]image.Rectangle, len(items)),
+		labelScale: layout.LabelScale,
+		cellWidth:  layout.CellWidth,
+	}
+	if s.labelScale <= 0 {
+		s.labelScale = 2
+	}
+	cellHeight := layout.CellHeight
+	for i, item := range items {
+		matrix, err := zxinggo.Encode(item.Contents, item.Format, layout.CellWidth, layout.CellHeight, item.Options)
+		if err != nil {
+			return nil, fmt.Errorf("sheet item %d: %w", i, err)
+		}
+		s.matrices[i] = matrix
+		s.cellWidth = max(s.cellWidth, matrix.Width())
+		cellHeight = max(cellHeight, matrix.Height())
+		if item.Label != "" {
+			// Two font pixels separate the label from the barcode.
+			s.labelHeight = (font.GlyphHeight + 2) * s.labelScale
+		}
+	}
+
+	pitchX := s.cellWidth + layout.Gutter
+	pitchY := cellHeight + s.labelHeight + layout.Gutter
+	s.Width = 2*layout.Margin + columns*pitchX - layout.Gutter
+	s.Height = 2*layout.Margin + rows*pitchY - layout.Gutter
+	for i, matrix := range s.matrices {
+		cell := image.Rect(0, 0, s.cellWidth, cellHeight).Add(image.Pt(
+			layout.Margin+i%columns*pitchX, layout.Margin+i/columns*pitchY))
+		s.cells[i] = cell
+		// Centre each barcode in its cell.
+		x := cell.Min.X + (s.cellWidth-matrix.Width())/2
+		y := cell.Min.Y + (cellHeight-matrix.Height())/2
+		s.bounds[i] = image.Rect(x, y, x+matrix.Width(), y+matrix.Height())
+	}
+	return s, nil
+}
+
+// Bounds returns where the barcode of item i lies on the page, including
+// the quiet zone the writer drew around it.
+func (s *Sheet) Bounds(i int) image.Rectangle {
+	return s.bounds[i]
+}
+
+// Image renders the page, black on white.
+func (s *Sheet) Image() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, s.Width, s.Height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	for i, matrix := range s.matrices {
+		origin := s.bounds[i].Min
+		for y := 0; y < matrix.Height(); y++ {
+			for x := 0; x < matrix.Width(); x++ {
+				if matrix.Get(x, y) {
+					img.SetGray(origin.X+x, origin.Y+y, color.Gray{})
+				}
+			}
+		}
+		s.drawLabel(img, i)
+	}
+	return img
+}
+
+// drawLabel prints the label of item i under its barcode.
+func (s *Sheet) drawLabel(img *image.Gray, i int) {
+	label := s.label(i)
+	if label == "" {
+		return
+	}
+	scale := s.labelScale
+	x := s.labelCentre(i) - font.TextWidth(label, scale)/2
+	y := s.labelBaseline(i) - font.GlyphHeight*scale
+	font.Draw(img, x, y, scale, label, color.Gray{})
+}
+
+// label returns the label of item i, shortened to fit its cell.
+func (s *Sheet) label(i int) string {
+	label := []rune(s.items[i].Label)
+	fit := (s.cellWidth/s.labelScale + 1) / (font.GlyphWidth + 1)
+	if len(label) > fit {
+		label = label[:fit]
+	}
+	return string(label)
+}
+
+// labelCentre returns the x coordinate item i's label is centred on.
+func (s *Sheet) labelCentre(i int) int {
+	c := s.cells[i]
+	return (c.Min.X + c.Max.X) / 2
+}
+
+// labelBaseline returns the y coordinate of the bottom of item i's label.
+func (s *Sheet) labelBaseline(i int) int {
+	return s.cells[i].Max.Y + s.labelHeight
+}
+
+// WriteSVG writes the page as an SVG document, one pixel to a user unit,
+// with the barcodes as paths and the labels as text.
+func (s *Sheet) WriteSVG(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		s.Width, s.Height, s.Width, s.Height)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="#fff"/>`+"\n", s.Width, s.Height)
+	for i, matrix := range s.matrices {
+		origin := s.bounds[i].Min
+		bw.WriteString(`<path fill="#000" d="`)
+		for y := 0; y < matrix.Height(); y++ {
+			for x := 0; x < matrix.Width(); {
+				if !matrix.Get(x, y) {
+					x++
+					continue
+				}
+				start := x
+				for x < matrix.Width() && matrix.Get(x, y) {
+					x++
+				}
+				fmt.Fprintf(bw, "M%d %dh%dv1h%dz", origin.X+start, origin.Y+y, x-start, start-x)
+			}
+		}
+		bw.WriteString("\"/>\n")
+		if label := s.label(i); label != "" {
+			fmt.Fprintf(bw, `<text x="%d" y="%d" font-family="monospace" font-size="%d" text-anchor="middle">`,
+				s.labelCentre(i), s.labelBaseline(i), font.GlyphHeight*s.labelScale*4/3)
+			xml.EscapeText(bw, []byte(label))
+			bw.WriteString("</text>\n")
+		}
+	}
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
@@ -0,0 +1,104 @@
+package sheet
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"sort"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+	"github.com/ericlevine/zxinggo/multi"
+	_ "github.com/ericlevine/zxinggo/oned"
+	"github.com/ericlevine/zxinggo/qrcode"
+)
+
+func TestSheetDecodesEveryItem(t *testing.T) {
+	var items []Item
+	for i := 0; i < 6; i++ {
+		contents := fmt.Sprintf("ASSET-%04d", i)
+		items = append(items, Item{Contents: contents, Format: zxinggo.FormatQRCode, Label: contents})
+	}
+	s, err := New(items, Layout{Columns: 3, CellWidth: 120, CellHeight: 120, Margin: 20, Gutter: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Width != 2*20+3*120+2*10 {
+		t.Errorf("width %d", s.Width)
+	}
+	img := s.Image()
+
+	// Each code decodes from its bounds.
+	for i, item := range items {
+		crop := img.SubImage(s.Bounds(i)).(*image.Gray)
+		bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(zxinggo.NewImageLuminanceSource(crop)))
+		result, err := zxinggo.Decode(bitmap, nil)
+		if err != nil {
+			t.Fatalf("item %d: %v", i, err)
+		}
+		if result.Text != item.Contents {
+			t.Errorf("item %d: decoded %q", i, result.Text)
+		}
+	}
+
+	// The page serves as a fixture for DecodeMultiple.
+	bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(zxinggo.NewImageLuminanceSource(img)))
+	results, err := multi.NewGenericMultipleBarcodeReader(qrcode.NewReader()).DecodeMultiple(bitmap, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, r := range results {
+		texts = append(texts, r.Text)
+	}
+	sort.Strings(texts)
+	if len(texts) != len(items) || texts[0] != "ASSET-0000" || texts[5] != "ASSET-0005" {
+		t.Errorf("DecodeMultiple found %q", texts)
+	}
+}
+
+func TestSheetLabelsAndSVG(t *testing.T) {
+	items := []Item{
+		{Contents: "12345678", Format: zxinggo.FormatCode128, Label: "Bin <A&B>"},
+		{Contents: "87654321", Format: zxinggo.FormatCode128},
+	}
+	s, err := New(items, Layout{Columns: 1, CellWidth: 200, CellHeight: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := s.Image()
+	// The label is printed under the first code, in the band below its cell.
+	dark := 0
+	for y := s.Bounds(0).Max.Y; y < s.Bounds(1).Min.Y; y++ {
+		for x := 0; x < s.Width; x++ {
+			if img.GrayAt(x, y).Y == 0 {
+				dark++
+			}
+		}
+	}
+	if dark == 0 {
+		t.Error("no label printed under the first code")
+	}
+
+	var buf bytes.Buffer
+	if err := s.WriteSVG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Width int      `xml:"width,attr"`
+		Paths []string `xml:"path"`
+		Texts []string `xml:"text"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid SVG: %v", err)
+	}
+	if doc.Width != s.Width || len(doc.Paths) != 2 || len(doc.Texts) != 1 || doc.Texts[0] != "Bin <A&B>" {
+		t.Errorf("SVG width %d, %d paths, texts %q", doc.Width, len(doc.Paths), doc.Texts)
+	}
+
+	if _, err := New(items, Layout{Columns: 1, Rows: 1}); err == nil {
+		t.Error("expected an error for items that do not fit the rows")
+	}
+}
@@ -0,0 +1,149 @@
+package stacked
+
+import (
+	"fmt"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/oned"
+)
+
+const (
+	codablockStop       = 106
+	codablockMaxColumns = 62
+	// codablockRowOffset is added to the row number in the row indicators of
+	// the rows after the first.
+	codablockRowOffset = 42
+)
+
+// CodablockFReader decodes Codablock-F symbols. Each of the 2 to 44 rows is
+// a Code 128 row of start character A, a row indicator, the data characters
+// and the row's check character. The first row's indicator is the number of
+// rows less two, and those of the other rows their row number plus 42. Every
+// row starts in code set A, and the last row ends with two check characters
+// over the text.
+type CodablockFReader struct {
+	// disabled makes the registered reader find nothing unless Codablock-F
+	// was requested.
+	disabled bool
+}
+
+// NewCodablockFReader creates a new Codablock-F reader.
+func NewCodablockFReader() *CodablockFReader {
+	return &CodablockFReader{}
+}
+
+// Decode locates and decodes a Codablock-F symbol in the given image.
+func (r *CodablockFReader) Decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	if r.disabled {
+		return nil, zxinggo.ErrNotFound
+	}
+	return decodeStacked(image, readCodablockFRow, decodeCodablockF, zxinggo.FormatCodablockF)
+}
+
+// Reset resets internal state.
+func (r *CodablockFReader) Reset() {}
+
+func readCodablockFRow(row *bitutil.BitArray) (*symbolRow, error) {
+	start, err := oned.FindCode128StartPattern(row)
+	if err != nil {
+		return nil, err
+	}
+	if start[2] != valueStartA {
+		return nil, zxinggo.ErrNotFound
+	}
+
+	counters := make([]int, 6)
+	next := start[1]
+	var values []int
+	for {
+		value, err := oned.DecodeCode128Character(row, counters, next)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range counters {
+			next += c
+		}
+		if value == codablockStop {
+			break
+		}
+		if value >= valueStartA || len(values) > codablockMaxColumns+2 {
+			return nil, zxinggo.ErrNotFound
+		}
+		values = append(values, value)
+	}
+	if len(values) < 3 {
+		return nil, zxinggo.ErrNotFound
+	}
+
+	check := values[len(values)-1]
+	values = values[:len(values)-1]
+	sum := valueStartA
+	for i, v := range values {
+		sum += (i + 1) * v
+	}
+	if sum%103 != check {
+		return nil, zxinggo.ErrChecksum
+	}
+
+	index := 0
+	if values[0] > codablockRowOffset {
+		index = values[0] - codablockRowOffset
+	}
+	return &symbolRow{
+		index:  index,
+		values: values,
+		left:   float64(start[0]),
+		right:  float64(row.GetNextUnset(next)),
+	}, nil
+}
+
+func decodeCodablockF(rows map[int][]int) (string, int, string, error) {
+	first, ok := rows[0]
+	if !ok {
+		return "", 0, "", zxinggo.ErrNotFound
+	}
+	numRows := first[0] + 2
+	var d code128Decoder
+	var checks []int
+	for i := 0; i < numRows; i++ {
+		values, ok := rows[i]
+		if !ok {
+			return "", 0, "", zxinggo.ErrNotFound
+		}
+		characters := values[1:]
+		if i == numRows-1 {
+			if len(characters) < 2 {
+				return "", 0, "", fmt.Errorf("%w: Codablock-F symbol check characters missing", zxinggo.ErrFormat)
+			}
+			checks = characters[len(characters)-2:]
+			characters = characters[:len(characters)-2]
+		}
+		d.set, d.shifted, d.fnc4 = 'A', 0, false
+		for _, v := range characters {
+			if err := d.decode(v); err != nil {
+				return "", 0, "", err
+			}
+		}
+	}
+
+	k1, k2 := codablockChecks(d.text)
+	if checks[0] != k1 || checks[1] != k2 {
+		return "", 0, "", zxinggo.ErrChecksum
+	}
+	return d.String(), numRows, "", nil
+}
+
+// codablockChecks computes the symbol check characters K1 and K2, weighted
+// sums modulo 86 of the text's bytes.
+func codablockChecks(text []byte) (int, int) {
+	k1, k2 := 0, 0
+	for i, b := range text {
+		k1 += (i + 1) * int(b)
+		k2 += i * int(b)
+	}
+	return k1 % 86, k2 % 86
+}
+
+// Compile-time check.
+var _ zxinggo.Reader = (*CodablockFReader)(nil)
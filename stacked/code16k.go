@@ -0,0 +1,218 @@
+package stacked
+
+import (
+	"fmt"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+	"github.com/ericlevine/zxinggo/oned"
+)
+
+const (
+	code16KColumns = 5
+	code16KMaxRows = 16
+	code16KModes   = 7
+	// A row is 70 modules: start, separator bar, characters and stop.
+	code16KRowModules = 7 + 1 + 11*code16KColumns + 7
+	// code16KRowElements counts the bars and spaces of a row.
+	code16KRowElements = 4 + 1 + 6*code16KColumns + 4
+
+	code16KPad         = 103
+	code16KShiftTwo    = 104
+	code16KShiftC      = 105
+	code16KMaxVariance = 0.25
+	code16KMaxElement  = 0.7
+)
+
+// code16KStartStop are the start and stop patterns. A start pattern begins
+// with a bar and a stop pattern with a space.
+var code16KStartStop = [8][]int{
+	{3, 2, 1, 1}, {2, 2, 2, 1}, {2, 1, 2, 2}, {1, 4, 1, 1},
+	{1, 1, 3, 2}, {1, 2, 3, 1}, {1, 1, 1, 4}, {3, 1, 1, 2},
+}
+
+// code16KStops gives the stop pattern of each row; row r starts with
+// pattern r mod 8.
+var code16KStops = [code16KMaxRows]int{0, 1, 2, 3, 4, 5, 6, 7, 4, 5, 6, 7, 0, 1, 2, 3}
+
+// Code16KReader decodes Code 16K symbols of 2 to 16 rows. Each row has a
+// start pattern and a separator bar, five Code 128 symbol characters drawn
+// space first, and a stop pattern; the start and stop patterns identify the
+// row. The first character gives the number of rows and the starting mode,
+// and the last two are check characters over all the others.
+type Code16KReader struct {
+	// disabled makes the registered reader find nothing unless Code 16K was
+	// requested.
+	disabled bool
+}
+
+// NewCode16KReader creates a new Code 16K reader.
+func NewCode16KReader() *Code16KReader {
+	return &Code16KReader{}
+}
+
+// Decode locates and decodes a Code 16K symbol in the given image.
+func (r *Code16KReader) Decode(image *zxinggo.BinaryBitmap, opts *zxinggo.DecodeOptions) (*zxinggo.Result, error) {
+	if r.disabled {
+		return nil, zxinggo.ErrNotFound
+	}
+	return decodeStacked(image, readCode16KRow, decodeCode16K, zxinggo.FormatCode16K)
+}
+
+// Reset resets internal state.
+func (r *Code16KReader) Reset() {}
+
+func readCode16KRow(row *bitutil.BitArray) (*symbolRow, error) {
+	// Record the runs from the first bar, so that even runs are bars.
+	var starts, widths []int
+	size := row.Size()
+	for x := row.GetNextSet(0); x < size; {
+		end := row.GetNextUnset(x)
+		if len(starts)%2 == 1 {
+			end = row.GetNextSet(x)
+		}
+		starts = append(starts, x)
+		widths = append(widths, end-x)
+		x = end
+	}
+
+	for i := 0; i+code16KRowElements <= len(widths); i += 2 {
+		if r, ok := matchCode16KRow(widths[i : i+code16KRowElements]); ok {
+			last := i + code16KRowElements - 1
+			r.left = float64(starts[i])
+			r.right = float64(starts[last] + widths[last])
+			return r, nil
+		}
+	}
+	return nil, zxinggo.ErrNotFound
+}
+
+// matchCode16KRow reads a row from the widths of its elements.
+func matchCode16KRow(widths []int) (*symbolRow, bool) {
+	start := matchPattern(widths[:4], code16KStartStop[:])
+	if start < 0 {
+		return nil, false
+	}
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+	unit := float64(total) / code16KRowModules
+	if separator := float64(widths[4]); separator < unit/2 || separator > 1.5*unit {
+		return nil, false
+	}
+	stop := matchPattern(widths[len(widths)-4:], code16KStartStop[:])
+	if stop < 0 {
+		return nil, false
+	}
+	index := -1
+	for r := start; r < code16KMaxRows; r += 8 {
+		if code16KStops[r] == stop {
+			index = r
+		}
+	}
+	if index < 0 {
+		return nil, false
+	}
+
+	values := make([]int, code16KColumns)
+	for i := range values {
+		values[i] = matchPattern(widths[5+6*i:11+6*i], oned.Code128Patterns[:code16KShiftC+1])
+		if values[i] < 0 {
+			return nil, false
+		}
+	}
+	return &symbolRow{index: index, values: values}, true
+}
+
+// matchPattern returns the index of the pattern the counters match best, or
+// -1 if none matches.
+func matchPattern(counters []int, patterns [][]int) int {
+	best, bestVariance := -1, code16KMaxVariance
+	for i, pattern := range patterns {
+		if variance := oned.PatternMatchVariance(counters, pattern, code16KMaxElement); variance < bestVariance {
+			best, bestVariance = i, variance
+		}
+	}
+	return best
+}
+
+func decodeCode16K(rows map[int][]int) (string, int, string, error) {
+	first, ok := rows[0]
+	if !ok {
+		return "", 0, "", zxinggo.ErrNotFound
+	}
+	numRows := first[0]/code16KModes + 2
+	if numRows > code16KMaxRows {
+		return "", 0, "", fmt.Errorf("%w: too many Code 16K rows", zxinggo.ErrFormat)
+	}
+	var values []int
+	for i := 0; i < numRows; i++ {
+		row, ok := rows[i]
+		if !ok {
+			return "", 0, "", zxinggo.ErrNotFound
+		}
+		values = append(values, row...)
+	}
+	n := len(values)
+	c1, c2 := code16KChecks(values[:n-2])
+	if values[n-2] != c1 || values[n-1] != c2 {
+		return "", 0, "", zxinggo.ErrChecksum
+	}
+
+	// Modes 0 to 2 start in code sets A to C, 3 and 4 in B and C with an
+	// implied FNC1, and 5 and 6 in code set C with the first one or two
+	// characters shifted to code set B.
+	var d code128Decoder
+	switch mode := first[0] % code16KModes; mode {
+	case 0, 1, 2:
+		d.set = "ABC"[mode]
+	case 3, 4:
+		d.set = "BC"[mode-3]
+		d.gs1 = true
+	default:
+		d.set = 'C'
+		d.shift('B', mode-4)
+	}
+	for _, v := range values[1 : n-2] {
+		current := d.set
+		if d.shifted > 0 {
+			current = d.shiftSet
+		}
+		switch {
+		case v == code16KPad:
+			continue
+		case current != 'C' && v == code16KShiftTwo:
+			d.shift(d.otherSet(), 2)
+			continue
+		case current != 'C' && v == code16KShiftC:
+			d.shift('C', 1)
+			continue
+		}
+		if err := d.decode(v); err != nil {
+			return "", 0, "", err
+		}
+	}
+
+	identifier := "]K0"
+	if d.gs1 {
+		identifier = "]K1"
+	}
+	return d.String(), numRows, identifier, nil
+}
+
+// code16KChecks computes the two check characters, weighted sums modulo 107
+// of the other characters; the second includes the first.
+func code16KChecks(values []int) (int, int) {
+	c1, c2 := 0, 0
+	for i, v := range values {
+		c1 += (i + 2) * v
+		c2 += (i + 1) * v
+	}
+	c1 %= 107
+	c2 = (c2 + (len(values)+1)*c1) % 107
+	return c1, c2
+}
+
+// Compile-time check.
+var _ zxinggo.Reader = (*Code16KReader)(nil)
@@ -0,0 +1,234 @@
+// Package stacked provides reading of stacked linear symbologies whose rows
+// are built from Code 128 symbol characters: Codablock-F and Code 16K.
+//
+// Each symbol row is read from single pixel rows with the Code 128 pattern
+// matching in package oned, and the rows are put in order by their row
+// indicators. The registered readers only run when their format is among
+// the requested formats, since every pixel row of the image is scanned and
+// a Codablock-F row also reads as a Code 128 symbol.
+package stacked
+
+import (
+	"slices"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/bitutil"
+)
+
+// symbolRow is one row of a stacked symbol read from a pixel row.
+type symbolRow struct {
+	// index is the row's position in the symbol, from 0.
+	index int
+	// values are the row's characters between its start and check
+	// characters.
+	values []int
+	// left and right are the ends of the row's bars, and y the pixel row.
+	left, right float64
+	y           int
+}
+
+// rowReader reads a symbol row from a pixel row.
+type rowReader func(row *bitutil.BitArray) (*symbolRow, error)
+
+// assembler decodes the text of a symbol from the values of its rows by
+// index, returning the number of rows it took and the symbology identifier.
+type assembler func(rows map[int][]int) (text string, numRows int, identifier string, err error)
+
+// decodeStacked reads every pixel row of the image, forwards and reversed,
+// and decodes a symbol from the rows found in either orientation.
+func decodeStacked(image *zxinggo.BinaryBitmap, readRow rowReader, assemble assembler, format zxinggo.Format) (*zxinggo.Result, error) {
+	width, height := image.Width(), image.Height()
+	row := bitutil.NewBitArray(width)
+	var readings [2]map[int][]*symbolRow
+	for i := range readings {
+		readings[i] = make(map[int][]*symbolRow)
+	}
+	for y := 0; y < height; y++ {
+		var err error
+		row, err = image.BlackRow(y, row)
+		if err != nil {
+			continue
+		}
+		for orientation := 0; orientation < 2; orientation++ {
+			if orientation == 1 {
+				row.Reverse()
+			}
+			r, err := readRow(row)
+			if err != nil {
+				continue
+			}
+			r.y = y
+			if orientation == 1 {
+				r.left, r.right = float64(width)-r.left, float64(width)-r.right
+			}
+			readings[orientation][r.index] = append(readings[orientation][r.index], r)
+		}
+	}
+
+	err := zxinggo.ErrNotFound
+	for orientation, found := range readings {
+		if len(found) == 0 {
+			continue
+		}
+		rows := make(map[int]*symbolRow, len(found))
+		values := make(map[int][]int, len(found))
+		for index, rs := range found {
+			rows[index] = mostCommon(rs)
+			values[index] = rows[index].values
+		}
+		text, numRows, identifier, assembleErr := assemble(values)
+		if assembleErr != nil {
+			err = assembleErr
+			continue
+		}
+		first, last := rows[0], rows[numRows-1]
+		points := []zxinggo.ResultPoint{
+			{X: first.left, Y: float64(first.y)},
+			{X: first.right, Y: float64(first.y)},
+			{X: last.right, Y: float64(last.y)},
+			{X: last.left, Y: float64(last.y)},
+		}
+		result := zxinggo.NewResult(text, nil, points, format)
+		result.PutMetadata(zxinggo.MetadataOrientation, 180*orientation)
+		if identifier != "" {
+			result.PutMetadata(zxinggo.MetadataSymbologyIdentifier, identifier)
+		}
+		return result, nil
+	}
+	return nil, err
+}
+
+// mostCommon returns the reading of a row whose values were read most
+// often, the first of those read in case of a tie.
+func mostCommon(readings []*symbolRow) *symbolRow {
+	best, bestCount := readings[0], 0
+	for _, r := range readings {
+		count := 0
+		for _, other := range readings {
+			if slices.Equal(r.values, other.values) {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = r, count
+		}
+	}
+	return best
+}
+
+// Code 128 function characters shared by both symbologies.
+const (
+	valueFNC3   = 96
+	valueFNC2   = 97
+	valueShift  = 98
+	valueCodeC  = 99
+	valueCodeB  = 100
+	valueCodeA  = 101
+	valueFNC1   = 102
+	valueFNC4A  = 101
+	valueFNC4B  = 100
+	valueStartA = 103
+)
+
+// code128Decoder interprets symbol character values in Code 128 code sets
+// A, B and C.
+type code128Decoder struct {
+	// set is the current code set: 'A', 'B' or 'C'.
+	set byte
+	// shifted characters are read in shiftSet instead.
+	shifted  int
+	shiftSet byte
+	// fnc4 adds 128 to the next data character.
+	fnc4 bool
+	// gs1 is set by an FNC1 in the first position.
+	gs1  bool
+	text []byte
+}
+
+// shift reads the next n characters in the given code set.
+func (d *code128Decoder) shift(set byte, n int) {
+	d.shiftSet, d.shifted = set, n
+}
+
+// otherSet returns code set B in code set A and A otherwise.
+func (d *code128Decoder) otherSet() byte {
+	if d.set == 'A' {
+		return 'B'
+	}
+	return 'A'
+}
+
+// decode interprets one character value.
+func (d *code128Decoder) decode(value int) error {
+	set := d.set
+	if d.shifted > 0 {
+		set = d.shiftSet
+		d.shifted--
+	}
+	if value >= valueStartA {
+		return zxinggo.ErrFormat
+	}
+
+	if set == 'C' {
+		switch value {
+		case valueCodeB:
+			d.set = 'B'
+		case valueCodeA:
+			d.set = 'A'
+		case valueFNC1:
+			d.fnc1()
+		default:
+			d.text = append(d.text, byte('0'+value/10), byte('0'+value%10))
+		}
+		return nil
+	}
+
+	switch {
+	case set == 'A' && value < 64:
+		d.data(' ' + value)
+	case set == 'A' && value < 96:
+		d.data(value - 64)
+	case set == 'B' && value < 96:
+		d.data(' ' + value)
+	case value == valueFNC2, value == valueFNC3:
+	case value == valueShift:
+		d.shift(d.otherSet(), 1)
+	case value == valueCodeC:
+		d.set = 'C'
+	case value == valueFNC1:
+		d.fnc1()
+	case set == 'A' && value == valueFNC4A, set == 'B' && value == valueFNC4B:
+		d.fnc4 = true
+	default:
+		// Code A in code set B, or Code B in code set A.
+		d.set = d.otherSet()
+	}
+	return nil
+}
+
+func (d *code128Decoder) data(c int) {
+	if d.fnc4 {
+		c += 128
+		d.fnc4 = false
+	}
+	d.text = append(d.text, byte(c))
+}
+
+// fnc1 marks GS1 data in the first position and separates fields with GS
+// elsewhere.
+func (d *code128Decoder) fnc1() {
+	if len(d.text) == 0 {
+		d.gs1 = true
+	} else {
+		d.text = append(d.text, 0x1D)
+	}
+}
+
+// String returns the text read, as ISO-8859-1.
+func (d *code128Decoder) String() string {
+	runes := make([]rune, len(d.text))
+	for i, b := range d.text {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
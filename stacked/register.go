@@ -0,0 +1,25 @@
+package stacked
+
+import zxinggo "github.com/ericlevine/zxinggo"
+
+func init() {
+	zxinggo.RegisterReader(zxinggo.FormatCodablockF, func(opts *zxinggo.DecodeOptions) zxinggo.Reader {
+		return &CodablockFReader{disabled: !requested(opts, zxinggo.FormatCodablockF)}
+	})
+	zxinggo.RegisterReader(zxinggo.FormatCode16K, func(opts *zxinggo.DecodeOptions) zxinggo.Reader {
+		return &Code16KReader{disabled: !requested(opts, zxinggo.FormatCode16K)}
+	})
+}
+
+// requested reports whether format is among the requested formats.
+func requested(opts *zxinggo.DecodeOptions, format zxinggo.Format) bool {
+	if opts == nil {
+		return false
+	}
+	for _, f := range zxinggo.ExpandFormats(opts.PossibleFormats) {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
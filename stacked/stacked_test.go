@@ -0,0 +1,220 @@
+package stacked
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+	"github.com/ericlevine/zxinggo/oned"
+)
+
+// render draws rows of element widths, each starting with a bar, two pixels
+// per module and ten pixels high, with two pixel separator bars between and
+// around them. Upside down, the image is turned 180 degrees.
+func render(rows [][]int, upsideDown bool) *zxinggo.BinaryBitmap {
+	rowWidth := 0
+	for _, row := range rows {
+		w := 0
+		for _, e := range row {
+			w += 2 * e
+		}
+		rowWidth = max(rowWidth, w)
+	}
+	width, height := rowWidth+48, 12*len(rows)+22
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	set := func(x, y int) {
+		if upsideDown {
+			x, y = width-1-x, height-1-y
+		}
+		img.SetGray(x, y, color.Gray{})
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	for i := 0; i <= len(rows); i++ {
+		for y := 10 + 12*i; y < 12+12*i; y++ {
+			for x := 24; x < 24+rowWidth; x++ {
+				set(x, y)
+			}
+		}
+	}
+	for i, row := range rows {
+		x := 24
+		for j, w := range row {
+			for k := 0; k < 2*w; k++ {
+				for y := 12 + 12*i; y < 22+12*i; y++ {
+					if j%2 == 0 {
+						set(x+k, y)
+					}
+				}
+			}
+			x += 2 * w
+		}
+	}
+	return zxinggo.NewBinaryBitmap(binarizer.NewGlobalHistogram(zxinggo.NewGrayImageLuminanceSource(img)))
+}
+
+// textA returns the code set A values of text.
+func textA(text string) []int {
+	var values []int
+	for _, c := range text {
+		values = append(values, int(c)-' ')
+	}
+	return values
+}
+
+// codablockF returns the element widths of a Codablock-F symbol with the
+// given row data and text. It and code16K use the reader's own tables and
+// check characters, so the tests check that reading undoes this layout
+// rather than that either matches an independent encoder.
+func codablockF(text string, data [][]int) [][]int {
+	k1, k2 := codablockChecks([]byte(text))
+	var rows [][]int
+	for i, d := range data {
+		indicator := len(data) - 2
+		if i > 0 {
+			indicator = i + codablockRowOffset
+		}
+		values := append([]int{indicator}, d...)
+		if i == len(data)-1 {
+			values = append(values, k1, k2)
+		}
+		check := valueStartA
+		for j, v := range values {
+			check += (j + 1) * v
+		}
+		values = append(values, check%103)
+
+		row := append([]int(nil), oned.Code128Patterns[valueStartA]...)
+		for _, v := range values {
+			row = append(row, oned.Code128Patterns[v]...)
+		}
+		rows = append(rows, append(row, oned.Code128Patterns[codablockStop]...))
+	}
+	return rows
+}
+
+// code16K returns the element widths of a Code 16K symbol with the given
+// mode and values, padded to fill its last row.
+func code16K(mode int, data []int) [][]int {
+	numRows := max(2, (len(data)+3+code16KColumns-1)/code16KColumns)
+	values := append([]int{code16KModes*(numRows-2) + mode}, data...)
+	for len(values) < code16KColumns*numRows-2 {
+		values = append(values, code16KPad)
+	}
+	c1, c2 := code16KChecks(values)
+	values = append(values, c1, c2)
+
+	var rows [][]int
+	for r := 0; r < numRows; r++ {
+		row := append([]int(nil), code16KStartStop[r%8]...)
+		row = append(row, 1)
+		for _, v := range values[code16KColumns*r : code16KColumns*(r+1)] {
+			row = append(row, oned.Code128Patterns[v][:6]...)
+		}
+		rows = append(rows, append(row, code16KStartStop[code16KStops[r]]...))
+	}
+	return rows
+}
+
+func decode(t *testing.T, image *zxinggo.BinaryBitmap, format zxinggo.Format) *zxinggo.Result {
+	t.Helper()
+	opts := &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{format}}
+	result, err := zxinggo.Decode(image, opts)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if result.Format != format {
+		t.Errorf("format = %v, want %v", result.Format, format)
+	}
+	return result
+}
+
+func TestCodablockF(t *testing.T) {
+	text := "STACKED CODE 128 ROWS."
+	rows := codablockF(text, [][]int{textA(text[:8]), textA(text[8:16]), textA(text[16:])})
+	result := decode(t, render(rows, false), zxinggo.FormatCodablockF)
+	if result.Text != text {
+		t.Errorf("text = %q, want %q", result.Text, text)
+	}
+	if len(result.Points) != 4 {
+		t.Errorf("%d points, want 4", len(result.Points))
+	}
+}
+
+func TestCodablockFCodeSets(t *testing.T) {
+	// Each row starts in code set A.
+	text := "123456ab\x1dXY"
+	rows := codablockF(text, [][]int{
+		{valueCodeC, 12, 34, 56, valueCodeB, 'a' - ' ', 'b' - ' '},
+		append([]int{valueFNC1}, textA("XY")...),
+	})
+	for _, upsideDown := range []bool{false, true} {
+		result := decode(t, render(rows, upsideDown), zxinggo.FormatCodablockF)
+		if result.Text != text {
+			t.Errorf("upside down %v: text = %q, want %q", upsideDown, result.Text, text)
+		}
+		if o := result.Metadata[zxinggo.MetadataOrientation]; o != map[bool]int{false: 0, true: 180}[upsideDown] {
+			t.Errorf("upside down %v: orientation = %v", upsideDown, o)
+		}
+	}
+}
+
+func TestCodablockFChecksum(t *testing.T) {
+	rows := codablockF("ABCD", [][]int{textA("ABCD"), textA("ABCE")})
+	if _, err := NewCodablockFReader().Decode(render(rows, false), nil); !errors.Is(err, zxinggo.ErrChecksum) {
+		t.Errorf("err = %v, want ErrChecksum", err)
+	}
+}
+
+func TestCode16K(t *testing.T) {
+	tests := []struct {
+		name string
+		mode int
+		data []int
+		want string
+	}{
+		{"code set B", 1, textA("Hello16"), "Hello16"},
+		{"code set C", 2, []int{12, 34, 56, valueCodeB, 'a' - ' ', code16KShiftC, 78, 'z' - ' '}, "123456a78z"},
+		{"shifted B", 5, []int{'#' - ' ', 20, 24}, "#2024"},
+		{"double shift", 0, []int{'A' - ' ', code16KShiftTwo, 'b' - ' ', 'c' - ' ', 'D' - ' '}, "AbcD"},
+	}
+	for _, tt := range tests {
+		result := decode(t, render(code16K(tt.mode, tt.data), false), zxinggo.FormatCode16K)
+		if result.Text != tt.want {
+			t.Errorf("%s: text = %q, want %q", tt.name, result.Text, tt.want)
+		}
+		if id := result.Metadata[zxinggo.MetadataSymbologyIdentifier]; id != "]K0" {
+			t.Errorf("%s: symbology identifier = %v", tt.name, id)
+		}
+	}
+}
+
+func TestCode16KGS1UpsideDown(t *testing.T) {
+	data := []int{1, 12, 34, 56, 78, 90, 12, 31, valueFNC1, 10, valueCodeB, 'A' - ' ', 'B' - ' '}
+	result := decode(t, render(code16K(4, data), true), zxinggo.FormatCode16K)
+	if want := "0112345678901231\x1d10AB"; result.Text != want {
+		t.Errorf("text = %q, want %q", result.Text, want)
+	}
+	if id := result.Metadata[zxinggo.MetadataSymbologyIdentifier]; id != "]K1" {
+		t.Errorf("symbology identifier = %v, want ]K1", id)
+	}
+	if o := result.Metadata[zxinggo.MetadataOrientation]; o != 180 {
+		t.Errorf("orientation = %v, want 180", o)
+	}
+}
+
+func TestNotRequested(t *testing.T) {
+	image := render(code16K(1, textA("Hello16")), false)
+	if _, err := zxinggo.Decode(image, nil); !errors.Is(err, zxinggo.ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+	if _, err := NewCode16KReader().Decode(image, nil); err != nil {
+		t.Errorf("direct decode error: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package zxinggo
+
+import "time"
+
+// DetectorStage is a set of optional search stages that readers try when
+// their usual search fails. Each can multiply the time a failed decode
+// takes, so DecodeOptions.DisableDetectors turns them off for callers that
+// need a bound on latency more than they need difficult symbols read:
+//
+//   - DetectorQRTwoPatterns: with TryHarder, a QR code with only two finder
+//     patterns found is searched for with the third placed at each position
+//     that completes a square. Disabled, a QR code needs all three.
+//   - DetectorQRFormatCandidates: when a QR code's data does not correct, it
+//     is decoded again with up to 8 other plausible error correction level
+//     and mask combinations. Disabled, only the format information as read,
+//     normally and mirrored, is tried.
+//   - DetectorAztecModeResample: when an Aztec mode message read along the
+//     lines around the bull's eye does not correct, the ring is sampled
+//     again through the bull's eye's perspective transform. Disabled, tilted
+//     Aztec symbols are read less often.
+//   - DetectorWhiteRectangle: the Aztec detector looks for the bull's eye
+//     inside the white rectangle around the image centre. Disabled, it walks
+//     out from the centre of the image to the first dark pixels instead,
+//     which only finds a bull's eye at the centre of the image.
+//   - DetectorAztecQuarters: with TryHarder, an Aztec symbol not found from
+//     the centre of the image is looked for from the centre of each quarter
+//     of it. Disabled, only symbols around the centre of the image are read.
+//   - DetectorOneDRotation: with TryHarder, 1D formats that are not found
+//     are looked for again in the image turned a quarter turn. Disabled,
+//     only bars that run roughly vertically are read.
+//   - DetectorPurePadding: a PureBarcode image whose symbol touches its edges
+//     is padded, binarized again and decoded a second time. Disabled, such
+//     images are decoded as they are.
+//   - DetectorQRAlignmentRetry: when a QR code's alignment pattern is not
+//     found near where the finder patterns put it, or the one found does not
+//     fit the symbol, a wider area is searched and each pattern in it tried.
+//     Disabled, large QR codes seen in perspective are read less often.
+//   - DetectorQRGridFit: when a QR code's timing patterns do not sample as
+//     they should at the dimension measured from its finder patterns, the
+//     dimensions within two versions of it are tried and the one its finder
+//     and alignment patterns fit best is used. Disabled, QR codes printed
+//     with modules too bold or too thin are read less often.
+//
+// Formats not named are unaffected.
+type DetectorStage uint
+
+const (
+	DetectorQRTwoPatterns DetectorStage = 1 << iota
+	DetectorQRFormatCandidates
+	DetectorAztecModeResample
+	DetectorWhiteRectangle
+	DetectorOneDRotation
+	DetectorPurePadding
+	DetectorQRAlignmentRetry
+	DetectorQRGridFit
+	DetectorAztecQuarters
+)
+
+// DetectorDisabled reports whether opts, which may be nil, disables stage.
+func DetectorDisabled(opts *DecodeOptions, stage DetectorStage) bool {
+	return opts != nil && opts.DisableDetectors&stage != 0
+}
+
+// DeadlinePassed reports whether opts, which may be nil, sets a Deadline
+// that has passed. Readers check it between the steps of long searches and
+// return ErrDeadlineExceeded once it has.
+func DeadlinePassed(opts *DecodeOptions) bool {
+	return opts != nil && !opts.Deadline.IsZero() && time.Now().After(opts.Deadline)
+}
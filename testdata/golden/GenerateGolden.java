@@ -0,0 +1,241 @@
+// GenerateGolden writes encoder golden vectors for zxinggo from Java ZXing.
+// See README.md for the file format.
+//
+//	javac -cp core-3.5.3.jar GenerateGolden.java
+//	java -cp core-3.5.3.jar:. GenerateGolden specs.txt .
+
+import com.google.zxing.BarcodeFormat;
+import com.google.zxing.EncodeHintType;
+import com.google.zxing.common.BitMatrix;
+import com.google.zxing.datamatrix.DataMatrixWriter;
+import com.google.zxing.oned.CodaBarWriter;
+import com.google.zxing.oned.Code128Writer;
+import com.google.zxing.oned.Code39Writer;
+import com.google.zxing.oned.Code93Writer;
+import com.google.zxing.oned.EAN13Writer;
+import com.google.zxing.oned.EAN8Writer;
+import com.google.zxing.oned.ITFWriter;
+import com.google.zxing.oned.OneDimensionalCodeWriter;
+import com.google.zxing.oned.UPCEWriter;
+import com.google.zxing.qrcode.decoder.ErrorCorrectionLevel;
+import com.google.zxing.qrcode.encoder.ByteMatrix;
+import com.google.zxing.qrcode.encoder.QRCode;
+
+import java.io.IOException;
+import java.io.PrintWriter;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.nio.file.Paths;
+import java.util.EnumMap;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+
+public final class GenerateGolden {
+
+  private static final String SOURCE = "GenerateGolden.java, zxing " + zxingVersion();
+
+  public static void main(String[] args) throws Exception {
+    if (args.length != 2) {
+      System.err.println("usage: GenerateGolden specs.txt outdir");
+      System.exit(2);
+    }
+    Path out = Paths.get(args[1]);
+    List<String> lines = Files.readAllLines(Paths.get(args[0]), StandardCharsets.UTF_8);
+    int count = 0;
+    for (int n = 0; n < lines.size(); n++) {
+      String line = lines.get(n);
+      if (line.isEmpty() || line.startsWith("#")) {
+        continue;
+      }
+      String[] fields = line.split("\t");
+      if (fields.length < 3) {
+        throw new IllegalArgumentException("line " + (n + 1) + ": want format, name and contents");
+      }
+      String format = fields[0];
+      String contents = unquote(fields[2]);
+      Map<String, String> options = new LinkedHashMap<>();
+      for (int i = 3; i < fields.length; i++) {
+        int eq = fields[i].indexOf('=');
+        if (eq < 0) {
+          throw new IllegalArgumentException("line " + (n + 1) + ": malformed option " + fields[i]);
+        }
+        options.put(fields[i].substring(0, eq), fields[i].substring(eq + 1));
+      }
+      boolean[][] symbol = encode(format, contents, options);
+      Path file = out.resolve(directory(format)).resolve(fields[1] + ".txt");
+      Files.createDirectories(file.getParent());
+      write(file, format, contents, options, symbol);
+      count++;
+    }
+    System.out.println("wrote " + count + " vectors");
+  }
+
+  private static boolean[][] encode(String format, String contents, Map<String, String> options)
+      throws Exception {
+    switch (format) {
+      case "QR_CODE":
+        return encodeQRCode(contents, options);
+      case "DATA_MATRIX":
+        return bits(new DataMatrixWriter().encode(contents, BarcodeFormat.DATA_MATRIX, 0, 0));
+      case "AZTEC":
+        // zxinggo's defaults: 33% error correction, layers chosen to fit.
+        return bits(com.google.zxing.aztec.encoder.Encoder.encode(contents, 33, 0).getMatrix());
+      case "UPC_A":
+        // ZXing's UPCAWriter is EAN13Writer with a leading zero.
+        return row(new EAN13Writer(), "0" + contents);
+      default:
+        return row(oneDWriter(format), contents);
+    }
+  }
+
+  private static boolean[][] encodeQRCode(String contents, Map<String, String> options)
+      throws Exception {
+    Map<EncodeHintType, Object> hints = new EnumMap<>(EncodeHintType.class);
+    ErrorCorrectionLevel level = ErrorCorrectionLevel.L;
+    for (Map.Entry<String, String> option : options.entrySet()) {
+      switch (option.getKey()) {
+        case "errorCorrection":
+          level = ErrorCorrectionLevel.valueOf(option.getValue());
+          break;
+        case "characterSet":
+          hints.put(EncodeHintType.CHARACTER_SET, option.getValue());
+          break;
+        case "qrVersion":
+          hints.put(EncodeHintType.QR_VERSION, option.getValue());
+          break;
+        case "qrMaskPattern":
+          hints.put(EncodeHintType.QR_MASK_PATTERN, option.getValue());
+          break;
+        default:
+          throw new IllegalArgumentException("unknown QR_CODE option " + option.getKey());
+      }
+    }
+    QRCode code = com.google.zxing.qrcode.encoder.Encoder.encode(contents, level, hints);
+    ByteMatrix matrix = code.getMatrix();
+    boolean[][] symbol = new boolean[matrix.getHeight()][matrix.getWidth()];
+    for (int y = 0; y < matrix.getHeight(); y++) {
+      for (int x = 0; x < matrix.getWidth(); x++) {
+        symbol[y][x] = matrix.get(x, y) == 1;
+      }
+    }
+    return symbol;
+  }
+
+  private static OneDimensionalCodeWriter oneDWriter(String format) {
+    switch (format) {
+      case "CODE_128":
+        return new Code128Writer();
+      case "CODE_39":
+        return new Code39Writer();
+      case "CODE_93":
+        return new Code93Writer();
+      case "CODABAR":
+        return new CodaBarWriter();
+      case "EAN_13":
+        return new EAN13Writer();
+      case "EAN_8":
+        return new EAN8Writer();
+      case "UPC_E":
+        return new UPCEWriter();
+      case "ITF":
+        return new ITFWriter();
+      default:
+        throw new IllegalArgumentException("unsupported format " + format);
+    }
+  }
+
+  private static String directory(String format) {
+    return format.replace("_", "").toLowerCase();
+  }
+
+  private static boolean[][] row(OneDimensionalCodeWriter writer, String contents) {
+    return new boolean[][] {writer.encode(contents)};
+  }
+
+  private static boolean[][] bits(BitMatrix matrix) {
+    boolean[][] symbol = new boolean[matrix.getHeight()][matrix.getWidth()];
+    for (int y = 0; y < matrix.getHeight(); y++) {
+      for (int x = 0; x < matrix.getWidth(); x++) {
+        symbol[y][x] = matrix.get(x, y);
+      }
+    }
+    return symbol;
+  }
+
+  private static void write(Path file, String format, String contents,
+      Map<String, String> options, boolean[][] symbol) throws IOException {
+    try (PrintWriter w = new PrintWriter(Files.newBufferedWriter(file, StandardCharsets.UTF_8))) {
+      w.print("# generated by GenerateGolden.java; do not edit\n");
+      w.print("source: " + SOURCE + "\n");
+      w.print("format: " + format + "\n");
+      w.print("contents: " + quote(contents) + "\n");
+      for (Map.Entry<String, String> option : options.entrySet()) {
+        w.print(option.getKey() + ": " + option.getValue() + "\n");
+      }
+      w.print("matrix:\n");
+      for (boolean[] row : symbol) {
+        StringBuilder line = new StringBuilder(row.length * 2);
+        for (boolean dark : row) {
+          line.append(dark ? "X " : "  ");
+        }
+        w.print(line + "\n");
+      }
+    }
+  }
+
+  // quote quotes s so Go's strconv.Unquote reads it back.
+  private static String quote(String s) {
+    StringBuilder b = new StringBuilder("\"");
+    s.codePoints().forEach(c -> {
+      if (c == '"' || c == '\\') {
+        b.append('\\').appendCodePoint(c);
+      } else if (c >= 0x20 && c < 0x7F) {
+        b.appendCodePoint(c);
+      } else if (c <= 0xFFFF) {
+        b.append(String.format("\\u%04x", c));
+      } else {
+        b.append(String.format("\\U%08x", c));
+      }
+    });
+    return b.append('"').toString();
+  }
+
+  // unquote reads a spec's contents: a double-quoted string with \", \\,
+  // \n, \t and \\uXXXX escapes.
+  private static String unquote(String s) {
+    if (s.length() < 2 || s.charAt(0) != '"' || s.charAt(s.length() - 1) != '"') {
+      throw new IllegalArgumentException("contents not quoted: " + s);
+    }
+    StringBuilder b = new StringBuilder();
+    for (int i = 1; i < s.length() - 1; i++) {
+      char c = s.charAt(i);
+      if (c != '\\') {
+        b.append(c);
+        continue;
+      }
+      char e = s.charAt(++i);
+      switch (e) {
+        case 'n':
+          b.append('\n');
+          break;
+        case 't':
+          b.append('\t');
+          break;
+        case 'u':
+          b.append((char) Integer.parseInt(s.substring(i + 1, i + 5), 16));
+          i += 4;
+          break;
+        default:
+          b.append(e);
+      }
+    }
+    return b.toString();
+  }
+
+  private static String zxingVersion() {
+    String version = BarcodeFormat.class.getPackage().getImplementationVersion();
+    return version != null ? version : "unknown";
+  }
+}
@@ -44,14 +44,14 @@ func (s *DefaultGridSampler) SampleGridTransform(image *bitutil.BitMatrix, dimen
 		return nil, ErrNotFound
 	}
 	bits := bitutil.NewBitMatrixWithSize(dimensionX, dimensionY)
+	centers := transform.BuildAdjusted(0.5, 0.5)
 	points := make([]float64, 2*dimensionX)
 	for y := 0; y < dimensionY; y++ {
-		iValue := float64(y) + 0.5
 		for x := 0; x < len(points); x += 2 {
-			points[x] = float64(x/2) + 0.5
-			points[x+1] = iValue
+			points[x] = float64(x / 2)
+			points[x+1] = float64(y)
 		}
-		transform.TransformPoints(points)
+		centers.TransformPoints(points)
 		if err := CheckAndNudgePoints(image, points); err != nil {
 			return nil, err
 		}
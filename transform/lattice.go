@@ -0,0 +1,120 @@
+package transform
+
+import (
+	"math"
+
+	"github.com/ericlevine/zxinggo/bitutil"
+)
+
+// Lattice is the geometry of a symbol's modules: where the module at each
+// grid position lies and what shape it has. Coordinates are in module
+// widths from the top left corner of the box bounding the symbol, so a
+// PerspectiveTransform or a scale and offset places them in an image.
+type Lattice interface {
+	// Center returns the centre of the module in column x of row y.
+	Center(x, y int) (float64, float64)
+
+	// Bounds returns the width and height of the box bounding a grid of
+	// dimensionX columns and dimensionY rows.
+	Bounds(dimensionX, dimensionY int) (float64, float64)
+
+	// Extent returns half the width and half the height of a module.
+	Extent() (float64, float64)
+
+	// Contains reports whether the point (dx, dy) from a module's centre
+	// lies within the module.
+	Contains(dx, dy float64) bool
+}
+
+// SquareLattice lays modules out as unit squares in rows and columns, as
+// most matrix symbols do.
+var SquareLattice Lattice = squareLattice{}
+
+// HexagonalLattice lays modules out as hexagons one module wide with their
+// points up and down, in rows that overlap by a quarter of a hexagon's
+// height, with odd rows shifted right by half a module. MaxiCode uses it.
+var HexagonalLattice Lattice = hexagonalLattice{}
+
+type squareLattice struct{}
+
+func (squareLattice) Center(x, y int) (float64, float64) {
+	return float64(x) + 0.5, float64(y) + 0.5
+}
+
+func (squareLattice) Bounds(dimensionX, dimensionY int) (float64, float64) {
+	return float64(dimensionX), float64(dimensionY)
+}
+
+func (squareLattice) Extent() (float64, float64) {
+	return 0.5, 0.5
+}
+
+func (squareLattice) Contains(dx, dy float64) bool {
+	return math.Abs(dx) <= 0.5 && math.Abs(dy) <= 0.5
+}
+
+// hexRowPitch is the distance between the centres of adjacent rows of
+// hexagons, and hexCircumradius the distance from a hexagon's centre to its
+// points, both in module widths.
+var (
+	hexRowPitch     = math.Sqrt(3) / 2
+	hexCircumradius = 1 / math.Sqrt(3)
+)
+
+type hexagonalLattice struct{}
+
+func (hexagonalLattice) Center(x, y int) (float64, float64) {
+	return float64(x) + 0.5 + float64(y&1)/2, hexCircumradius + float64(y)*hexRowPitch
+}
+
+func (hexagonalLattice) Bounds(dimensionX, dimensionY int) (float64, float64) {
+	if dimensionY == 0 {
+		return float64(dimensionX), 0
+	}
+	return float64(dimensionX), float64(dimensionY-1)*hexRowPitch + 2*hexCircumradius
+}
+
+func (hexagonalLattice) Extent() (float64, float64) {
+	return 0.5, hexCircumradius
+}
+
+func (hexagonalLattice) Contains(dx, dy float64) bool {
+	dx, dy = math.Abs(dx), math.Abs(dy)
+	// Within the vertical sides and the four sloping ones.
+	return dx <= 0.5 && dx/2+dy*hexRowPitch <= 0.5
+}
+
+// SampleLattice samples the modules of a grid of dimensionX columns and
+// dimensionY rows laid out on lattice, reading the pixel of image that
+// transform maps each module's centre to. transform maps lattice
+// coordinates to image coordinates; for a symbol seen straight on, it is a
+// translation times a scaling.
+func SampleLattice(image *bitutil.BitMatrix, lattice Lattice, dimensionX, dimensionY int,
+	transform *PerspectiveTransform,
+) (*bitutil.BitMatrix, error) {
+	if dimensionX <= 0 || dimensionY <= 0 {
+		return nil, ErrNotFound
+	}
+	bits := bitutil.NewBitMatrixWithSize(dimensionX, dimensionY)
+	points := make([]float64, 2*dimensionX)
+	for y := 0; y < dimensionY; y++ {
+		for x := 0; x < dimensionX; x++ {
+			points[2*x], points[2*x+1] = lattice.Center(x, y)
+		}
+		transform.TransformPoints(points)
+		if err := CheckAndNudgePoints(image, points); err != nil {
+			return nil, err
+		}
+		for x := 0; x < len(points); x += 2 {
+			ix := int(points[x])
+			iy := int(points[x+1])
+			if ix < 0 || ix >= image.Width() || iy < 0 || iy >= image.Height() {
+				return nil, ErrNotFound
+			}
+			if image.Get(ix, iy) {
+				bits.Set(x/2, y)
+			}
+		}
+	}
+	return bits, nil
+}
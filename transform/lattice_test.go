@@ -0,0 +1,37 @@
+package transform
+
+import "testing"
+
+func TestHexagonalLattice(t *testing.T) {
+	lattice := HexagonalLattice
+	x0, y0 := lattice.Center(0, 0)
+	x1, y1 := lattice.Center(0, 1)
+	if !near(x1-x0, 0.5) || !near(y1-y0, hexRowPitch) {
+		t.Errorf("row 1 is offset (%v, %v) from row 0, want (0.5, %v)", x1-x0, y1-y0, hexRowPitch)
+	}
+	if w, h := lattice.Bounds(30, 33); !near(w, 30) || !near(h, 32*hexRowPitch+2*hexCircumradius) {
+		t.Errorf("bounds = %v x %v", w, h)
+	}
+
+	// A point is in exactly one of two neighbouring modules, except on the
+	// side they share.
+	for _, p := range [][2]float64{{0.4, 0.3}, {0.1, 0.55}, {0.3, 0.5}} {
+		inFirst := lattice.Contains(p[0], p[1])
+		inSecond := lattice.Contains(p[0]-0.5, p[1]-hexRowPitch)
+		if inFirst == inSecond {
+			t.Errorf("point %v: in row 0 %v, in row 1 %v", p, inFirst, inSecond)
+		}
+	}
+	if lattice.Contains(0, hexCircumradius+0.01) || !lattice.Contains(0, hexCircumradius-0.01) {
+		t.Error("hexagon does not reach its points")
+	}
+}
+
+func TestSquareLattice(t *testing.T) {
+	if x, y := SquareLattice.Center(2, 3); x != 2.5 || y != 3.5 {
+		t.Errorf("Center(2, 3) = (%v, %v)", x, y)
+	}
+	if SquareLattice.Contains(0.51, 0) || !SquareLattice.Contains(0.49, -0.49) {
+		t.Error("square module has the wrong shape")
+	}
+}
@@ -1,6 +1,23 @@
 // Package transform provides geometric transformation utilities for barcode detection.
+//
+// A PerspectiveTransform maps points of one plane to another, such as a
+// symbol's module grid to the image it was found in. Transforms compose, so
+// a point found in a cropped and scaled copy of an image can be mapped back
+// to the original:
+//
+//	toOriginal := transform.Translation(left, top).Times(transform.Scaling(1/scale, 1/scale))
+//	x, y := toOriginal.Transform(px, py)
 package transform
 
+import (
+	"errors"
+	"math"
+)
+
+// ErrSingular is returned when inverting a transform that collapses the
+// plane onto a line or a point.
+var ErrSingular = errors.New("transform: singular transform")
+
 // PerspectiveTransform implements a perspective transform in two dimensions.
 type PerspectiveTransform struct {
 	a11, a12, a13 float64
@@ -8,6 +25,22 @@ type PerspectiveTransform struct {
 	a31, a32, a33 float64
 }
 
+// Identity returns the transform that leaves every point where it is.
+func Identity() *PerspectiveTransform {
+	return &PerspectiveTransform{a11: 1, a22: 1, a33: 1}
+}
+
+// Translation returns the transform that moves points by (dx, dy).
+func Translation(dx, dy float64) *PerspectiveTransform {
+	return &PerspectiveTransform{a11: 1, a22: 1, a31: dx, a32: dy, a33: 1}
+}
+
+// Scaling returns the transform that scales points about the origin by sx
+// horizontally and sy vertically.
+func Scaling(sx, sy float64) *PerspectiveTransform {
+	return &PerspectiveTransform{a11: sx, a22: sy, a33: 1}
+}
+
 // QuadrilateralToQuadrilateral computes the transform from one quadrilateral to another.
 func QuadrilateralToQuadrilateral(
 	x0, y0, x1, y1, x2, y2, x3, y3 float64,
@@ -18,6 +51,13 @@ func QuadrilateralToQuadrilateral(
 	return sToQ.Times(qToS)
 }
 
+// Transform returns the image of the point (x, y).
+func (pt *PerspectiveTransform) Transform(x, y float64) (float64, float64) {
+	denominator := pt.a13*x + pt.a23*y + pt.a33
+	return (pt.a11*x + pt.a21*y + pt.a31) / denominator,
+		(pt.a12*x + pt.a22*y + pt.a32) / denominator
+}
+
 // TransformPoints transforms pairs of (x, y) coordinates in-place.
 // points must have even length: [x0, y0, x1, y1, ...].
 func (pt *PerspectiveTransform) TransformPoints(points []float64) {
@@ -88,7 +128,39 @@ func (pt *PerspectiveTransform) BuildAdjoint() *PerspectiveTransform {
 	}
 }
 
-// Times returns this * other.
+// Inverse returns the transform that undoes pt, mapping each point of its
+// image back to where it came from.
+func (pt *PerspectiveTransform) Inverse() (*PerspectiveTransform, error) {
+	det := pt.a11*(pt.a22*pt.a33-pt.a23*pt.a32) -
+		pt.a21*(pt.a12*pt.a33-pt.a13*pt.a32) +
+		pt.a31*(pt.a12*pt.a23-pt.a13*pt.a22)
+	scale := math.Abs(pt.a11) + math.Abs(pt.a12) + math.Abs(pt.a13) +
+		math.Abs(pt.a21) + math.Abs(pt.a22) + math.Abs(pt.a23) +
+		math.Abs(pt.a31) + math.Abs(pt.a32) + math.Abs(pt.a33)
+	if math.IsNaN(det) || math.Abs(det) <= 1e-12*scale*scale*scale {
+		return nil, ErrSingular
+	}
+	// The adjoint is the inverse up to a scale factor, which a projective
+	// transform ignores; dividing it out keeps the coefficients well scaled.
+	inv := pt.BuildAdjoint()
+	for _, a := range []*float64{
+		&inv.a11, &inv.a12, &inv.a13,
+		&inv.a21, &inv.a22, &inv.a23,
+		&inv.a31, &inv.a32, &inv.a33,
+	} {
+		*a /= det
+	}
+	return inv, nil
+}
+
+// BuildAdjusted returns pt applied after translating points by (dx, dy), so
+// that the grid point (x, y) maps where pt maps (x+dx, y+dy). Grid samplers
+// use it to sample the centres of modules at integer coordinates.
+func (pt *PerspectiveTransform) BuildAdjusted(dx, dy float64) *PerspectiveTransform {
+	return pt.Times(Translation(dx, dy))
+}
+
+// Times returns this * other: the transform that applies other and then pt.
 func (pt *PerspectiveTransform) Times(other *PerspectiveTransform) *PerspectiveTransform {
 	return &PerspectiveTransform{
 		a11: pt.a11*other.a11 + pt.a21*other.a12 + pt.a31*other.a13,
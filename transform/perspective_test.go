@@ -0,0 +1,87 @@
+package transform
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func checkPoint(t *testing.T, pt *PerspectiveTransform, x, y, wantX, wantY float64) {
+	t.Helper()
+	if gotX, gotY := pt.Transform(x, y); !near(gotX, wantX) || !near(gotY, wantY) {
+		t.Errorf("(%v, %v) -> (%v, %v), want (%v, %v)", x, y, gotX, gotY, wantX, wantY)
+	}
+}
+
+func TestQuadrilateralToQuadrilateral(t *testing.T) {
+	from := []float64{0, 0, 10, 0, 10, 10, 0, 10}
+	to := []float64{2, 3, 17, 1, 20, 16, 4, 12}
+	pt := QuadrilateralToQuadrilateral(
+		from[0], from[1], from[2], from[3], from[4], from[5], from[6], from[7],
+		to[0], to[1], to[2], to[3], to[4], to[5], to[6], to[7])
+	for i := 0; i < len(from); i += 2 {
+		checkPoint(t, pt, from[i], from[i+1], to[i], to[i+1])
+	}
+
+	points := append([]float64(nil), from...)
+	pt.TransformPoints(points)
+	xs := []float64{from[0], from[2], from[4], from[6]}
+	ys := []float64{from[1], from[3], from[5], from[7]}
+	pt.TransformPointsSeparate(xs, ys)
+	for i := 0; i < len(to); i += 2 {
+		if !near(points[i], to[i]) || !near(points[i+1], to[i+1]) {
+			t.Errorf("TransformPoints: point %d = (%v, %v), want (%v, %v)", i/2, points[i], points[i+1], to[i], to[i+1])
+		}
+		if !near(xs[i/2], to[i]) || !near(ys[i/2], to[i+1]) {
+			t.Errorf("TransformPointsSeparate: point %d = (%v, %v), want (%v, %v)", i/2, xs[i/2], ys[i/2], to[i], to[i+1])
+		}
+	}
+}
+
+func TestInverse(t *testing.T) {
+	pt := QuadrilateralToQuadrilateral(
+		0, 0, 1, 0, 1, 1, 0, 1,
+		100, 50, 400, 80, 380, 420, 90, 300)
+	inv, err := pt.Inverse()
+	if err != nil {
+		t.Fatalf("Inverse: %v", err)
+	}
+	for _, p := range [][2]float64{{0, 0}, {0.5, 0.25}, {1, 1}, {0.1, 0.9}} {
+		x, y := pt.Transform(p[0], p[1])
+		checkPoint(t, inv, x, y, p[0], p[1])
+	}
+
+	if _, err := Scaling(2, 0).Inverse(); !errors.Is(err, ErrSingular) {
+		t.Errorf("Inverse of a singular transform: error %v, want ErrSingular", err)
+	}
+}
+
+func TestComposition(t *testing.T) {
+	checkPoint(t, Identity(), 3, 4, 3, 4)
+	checkPoint(t, Translation(2, -1), 3, 4, 5, 3)
+	checkPoint(t, Scaling(2, 3), 3, 4, 6, 12)
+
+	// Times applies its argument first.
+	checkPoint(t, Translation(2, -1).Times(Scaling(2, 3)), 3, 4, 8, 11)
+	checkPoint(t, Scaling(2, 3).Times(Translation(2, -1)), 3, 4, 10, 9)
+
+	pt := SquareToQuadrilateral(10, 10, 50, 12, 48, 60, 8, 55)
+	x, y := pt.Transform(0.75, 0.25)
+	checkPoint(t, pt.BuildAdjusted(0.5, 0.5), 0.25, -0.25, x, y)
+}
+
+func TestMapCropBackToOriginal(t *testing.T) {
+	// A point found in a copy of the image cropped at (120, 80) and halved
+	// in size maps back to the original.
+	toOriginal := Translation(120, 80).Times(Scaling(2, 2))
+	checkPoint(t, toOriginal, 15, 30, 150, 140)
+	toCrop, err := toOriginal.Inverse()
+	if err != nil {
+		t.Fatalf("Inverse: %v", err)
+	}
+	checkPoint(t, toCrop, 150, 140, 15, 30)
+}
@@ -0,0 +1,369 @@
+package zxinggo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ericlevine/zxinggo/transform"
+)
+
+// Grade is a print quality grade, from GradeF (fail) to GradeA (best).
+type Grade int
+
+const (
+	GradeF Grade = iota
+	GradeD
+	GradeC
+	GradeB
+	GradeA
+)
+
+// String returns the grade's letter.
+func (g Grade) String() string {
+	if g < GradeF || g > GradeA {
+		return "?"
+	}
+	return string("FDCBA"[g])
+}
+
+// VerificationParameter is one measured print quality parameter and its
+// grade.
+type VerificationParameter struct {
+	Name  string
+	Value float64
+	Grade Grade
+}
+
+// Verification is the print quality of a decoded symbol: each measured
+// parameter and the overall grade, the lowest of theirs.
+type Verification struct {
+	Grade      Grade
+	Parameters []VerificationParameter
+}
+
+// Parameter returns the parameter with the given name, or false if it was
+// not measured.
+func (v *Verification) Parameter(name string) (VerificationParameter, bool) {
+	for _, p := range v.Parameters {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return VerificationParameter{}, false
+}
+
+// Names of the parameters Verify measures.
+const (
+	ParamSymbolContrast        = "Symbol contrast"
+	ParamModulation            = "Modulation"
+	ParamAxialNonuniformity    = "Axial non-uniformity"
+	ParamUnusedErrorCorrection = "Unused error correction"
+	ParamDecode                = "Decode"
+	ParamMinimumReflectance    = "Minimum reflectance"
+	ParamMinimumEdgeContrast   = "Minimum edge contrast"
+	ParamDefects               = "Defects"
+	ParamDecodability          = "Decodability"
+)
+
+// Verify grades the print quality of the symbol decoded as result from
+// source, after the parameters of ISO/IEC 15415 for QR Code and Data Matrix
+// and ISO/IEC 15416 for linear symbols, turning a decode into a basic
+// verification.
+//
+// It is not a calibrated verifier: reflectance is taken to be luminance
+// over 255, with no reference aperture or illumination, and each parameter
+// is measured in the simplest way the standards allow. Matrix symbols are
+// sampled once at each module centre, and modulation is graded on the worst
+// module rather than through the error correction budget, which is
+// stricter. Linear symbols are graded from a single scan along the result
+// points rather than the average of ten. Use it to compare prints and catch
+// poor ones, not to certify them.
+func Verify(source LuminanceSource, result *Result) (*Verification, error) {
+	switch result.Format {
+	case FormatQRCode, FormatDataMatrix:
+		return verifyMatrix(source, result)
+	case FormatCode128, FormatCode39, FormatCode93, FormatCode11, FormatCodabar, FormatEAN13, FormatEAN8,
+		FormatUPCA, FormatUPCE, FormatITF, FormatRSS14, FormatRSSExpanded, FormatTelepen,
+		FormatMatrix2of5, FormatIndustrial2of5, FormatIATA2of5:
+		return verifyLinear(source, result)
+	}
+	return nil, fmt.Errorf("%w: print quality is not graded for %v", ErrFormat, result.Format)
+}
+
+// verifyMatrix grades a QR Code or Data Matrix symbol after ISO/IEC 15415.
+func verifyMatrix(source LuminanceSource, result *Result) (*Verification, error) {
+	modules, cols, rows, err := symbolModulePoints(result)
+	if err != nil {
+		return nil, err
+	}
+	points := result.Points[:len(modules)]
+	if len(modules) == 3 {
+		modules = append(modules[:3:3], ResultPoint{X: modules[0].X + modules[2].X - modules[1].X, Y: modules[0].Y + modules[2].Y - modules[1].Y})
+		points = append(points[:3:3], ResultPoint{X: points[0].X + points[2].X - points[1].X, Y: points[0].Y + points[2].Y - points[1].Y})
+	}
+	m, p := modules, points
+	xform := transform.QuadrilateralToQuadrilateral(
+		m[0].X, m[0].Y, m[1].X, m[1].Y, m[2].X, m[2].Y, m[3].X, m[3].Y,
+		p[0].X, p[0].Y, p[1].X, p[1].Y, p[2].X, p[2].Y, p[3].X, p[3].Y)
+
+	lum := source.Matrix()
+	width, height := source.Width(), source.Height()
+	reflectance := func(mx, my float64) float64 {
+		c := []float64{mx, my}
+		xform.TransformPoints(c)
+		return float64(sampleBilinear(lum, width, height, c[0], c[1])) / 255
+	}
+
+	// The symbol's modules, and a ring one module wide in the quiet zone
+	// for the light extreme.
+	nc, nr := int(cols), int(rows)
+	symbol := make([]float64, 0, nc*nr)
+	rmin, rmax := 1.0, 0.0
+	for y := -1; y <= nr; y++ {
+		for x := -1; x <= nc; x++ {
+			r := reflectance(float64(x)+0.5, float64(y)+0.5)
+			rmin, rmax = min(rmin, r), max(rmax, r)
+			if x >= 0 && y >= 0 && x < nc && y < nr {
+				symbol = append(symbol, r)
+			}
+		}
+	}
+	sc := rmax - rmin
+	v := &Verification{}
+	v.add(ParamSymbolContrast, sc, gradeAtLeast(sc, 0.70, 0.55, 0.40, 0.20))
+
+	modulation := 0.0
+	if sc > 0 {
+		gt := (rmax + rmin) / 2
+		modulation = 1
+		for _, r := range symbol {
+			modulation = min(modulation, 2*math.Abs(r-gt)/sc)
+		}
+	}
+	v.add(ParamModulation, modulation, gradeAtLeast(modulation, 0.50, 0.40, 0.30, 0.20))
+
+	// Average module spacing along rows and columns, through the middle of
+	// the symbol.
+	c := []float64{0, rows / 2, cols, rows / 2, cols / 2, 0, cols / 2, rows}
+	xform.TransformPoints(c)
+	xAvg := math.Hypot(c[2]-c[0], c[3]-c[1]) / cols
+	yAvg := math.Hypot(c[6]-c[4], c[7]-c[5]) / rows
+	an := math.Abs(xAvg-yAvg) / ((xAvg + yAvg) / 2)
+	v.add(ParamAxialNonuniformity, an, gradeAtMost(an, 0.06, 0.08, 0.10, 0.12))
+
+	if uec, ok := result.Metadata[MetadataUnusedErrorCorrection].(float64); ok {
+		v.add(ParamUnusedErrorCorrection, uec, gradeAtLeast(uec, 0.62, 0.50, 0.37, 0.25))
+	}
+	v.add(ParamDecode, 1, GradeA)
+	return v, nil
+}
+
+// verifyLinear grades a linear symbol after ISO/IEC 15416, from a scan
+// along its result points extended past them into the quiet zones.
+func verifyLinear(source LuminanceSource, result *Result) (*Verification, error) {
+	if len(result.Points) < 2 {
+		return nil, fmt.Errorf("%w: result has too few points", ErrFormat)
+	}
+	start, end := result.Points[0], result.Points[len(result.Points)-1]
+	length := Distance(start, end)
+	if length < 1 {
+		return nil, fmt.Errorf("%w: result points coincide", ErrFormat)
+	}
+	dx, dy := (end.X-start.X)/length, (end.Y-start.Y)/length
+	margin := 0.2 * length
+	n := int(length + 2*margin)
+	lum := source.Matrix()
+	width, height := source.Width(), source.Height()
+	profile := make([]float64, 0, n)
+	for i := 0; i < n; i++ {
+		t := float64(i) - margin
+		x, y := start.X+t*dx, start.Y+t*dy
+		if x < 0 || y < 0 || x > float64(width-1) || y > float64(height-1) {
+			// Keep to the image rather than count its surround as quiet zone.
+			if len(profile) > 0 {
+				break
+			}
+			continue
+		}
+		profile = append(profile, float64(sampleBilinear(lum, width, height, x, y))/255)
+	}
+
+	rmin, rmax := 1.0, 0.0
+	for _, r := range profile {
+		rmin, rmax = min(rmin, r), max(rmax, r)
+	}
+	sc := rmax - rmin
+	v := &Verification{}
+	reflectanceGrade := GradeF
+	if rmin <= 0.5*rmax {
+		reflectanceGrade = GradeA
+	}
+	v.add(ParamMinimumReflectance, rmin, reflectanceGrade)
+	v.add(ParamSymbolContrast, sc, gradeAtLeast(sc, 0.70, 0.55, 0.40, 0.20))
+
+	// Split the profile into elements at the global threshold, each with
+	// its extent and reflectance extremes.
+	type element struct {
+		dark     bool
+		from, to int
+		lo, hi   float64
+	}
+	gt := (rmax + rmin) / 2
+	var elements []element
+	for i, r := range profile {
+		dark := r < gt
+		if len(elements) == 0 || elements[len(elements)-1].dark != dark {
+			elements = append(elements, element{dark: dark, from: i, lo: r, hi: r})
+		}
+		e := &elements[len(elements)-1]
+		e.to = i + 1
+		e.lo, e.hi = min(e.lo, r), max(e.hi, r)
+	}
+	if sc == 0 || len(elements) < 3 {
+		return nil, fmt.Errorf("%w: no bars found along the result points", ErrFormat)
+	}
+
+	// Edge contrast between neighbouring elements, and reflectance
+	// non-uniformity within each element between the quiet zones.
+	ecMin, ernMax := 1.0, 0.0
+	for i := 1; i < len(elements); i++ {
+		a, b := elements[i-1], elements[i]
+		if a.dark {
+			a, b = b, a
+		}
+		ecMin = min(ecMin, a.hi-b.lo)
+		if i < len(elements)-1 {
+			ernMax = max(ernMax, nonuniformity(profile[elements[i].from:elements[i].to], elements[i].dark))
+		}
+	}
+	edgeGrade := GradeF
+	if ecMin >= 0.15 {
+		edgeGrade = GradeA
+	}
+	v.add(ParamMinimumEdgeContrast, ecMin, edgeGrade)
+	modulation := ecMin / sc
+	v.add(ParamModulation, modulation, gradeAtLeast(modulation, 0.70, 0.60, 0.50, 0.40))
+	defects := ernMax / sc
+	v.add(ParamDefects, defects, gradeAtMost(defects, 0.15, 0.20, 0.25, 0.30))
+
+	if multiWidth(result.Format) {
+		d := decodability(profile, gt, elements[0].to, elements[len(elements)-1].from)
+		v.add(ParamDecodability, d, gradeAtLeast(d, 0.62, 0.50, 0.37, 0.25))
+	}
+	v.add(ParamDecode, 1, GradeA)
+	return v, nil
+}
+
+// nonuniformity returns the element reflectance non-uniformity of the
+// element with the given profile: the depth of its deepest valley below
+// its lightest point for a space, or of its highest peak above its darkest
+// point for a bar. The ramps at its edges are not defects.
+func nonuniformity(profile []float64, dark bool) float64 {
+	lo, hi := profile[0], profile[0]
+	for _, r := range profile {
+		lo, hi = min(lo, r), max(hi, r)
+	}
+	ern := 0.0
+	for i := 1; i+1 < len(profile); i++ {
+		a, r, b := profile[i-1], profile[i], profile[i+1]
+		if dark && r >= a && r > b {
+			ern = max(ern, r-lo)
+		} else if !dark && r <= a && r < b {
+			ern = max(ern, hi-r)
+		}
+	}
+	return ern
+}
+
+// multiWidth reports whether the format's elements take more than two
+// widths, so that decodability is measured against a module grid rather
+// than a narrow/wide ratio.
+func multiWidth(format Format) bool {
+	switch format {
+	case FormatCode128, FormatCode93, FormatEAN13, FormatEAN8, FormatUPCA, FormatUPCE,
+		FormatRSS14, FormatRSSExpanded:
+		return true
+	}
+	return false
+}
+
+// decodability measures how far the element widths of the profile between
+// the first and last edges, at from and to, stray from whole multiples of
+// the module width, as 1 less twice the worst error in modules, so 1 is a
+// perfect print and 0 an element midway between two widths.
+func decodability(profile []float64, gt float64, from, to int) float64 {
+	// Edges at sub-pixel positions where the profile crosses gt.
+	var edges []float64
+	for i := from; i <= to && i < len(profile); i++ {
+		a, b := profile[i-1], profile[i]
+		if (a < gt) != (b < gt) {
+			edges = append(edges, float64(i-1)+(a-gt)/(a-b))
+		}
+	}
+	if len(edges) < 3 {
+		return 0
+	}
+	widths := make([]float64, len(edges)-1)
+	for i := range widths {
+		widths[i] = edges[i+1] - edges[i]
+	}
+	// Estimate the module width from the narrowest elements, then refine it
+	// from the total width and module count.
+	sorted := append([]float64(nil), widths...)
+	sort.Float64s(sorted)
+	x := sorted[len(sorted)/4]
+	total := 0.0
+	for _, w := range widths {
+		total += w
+	}
+	for range 2 {
+		modules := 0.0
+		for _, w := range widths {
+			modules += max(1, math.Round(w/x))
+		}
+		x = total / modules
+	}
+	worst := 0.0
+	for _, w := range widths {
+		worst = max(worst, math.Abs(w/x-max(1, math.Round(w/x))))
+	}
+	return max(0, 1-2*worst)
+}
+
+func (v *Verification) add(name string, value float64, grade Grade) {
+	v.Parameters = append(v.Parameters, VerificationParameter{Name: name, Value: value, Grade: grade})
+	if len(v.Parameters) == 1 || grade < v.Grade {
+		v.Grade = grade
+	}
+}
+
+// gradeAtLeast grades value against the lowest values for grades A to D.
+func gradeAtLeast(value, a, b, c, d float64) Grade {
+	switch {
+	case value >= a:
+		return GradeA
+	case value >= b:
+		return GradeB
+	case value >= c:
+		return GradeC
+	case value >= d:
+		return GradeD
+	}
+	return GradeF
+}
+
+// gradeAtMost grades value against the highest values for grades A to D.
+func gradeAtMost(value, a, b, c, d float64) Grade {
+	switch {
+	case value <= a:
+		return GradeA
+	case value <= b:
+		return GradeB
+	case value <= c:
+		return GradeC
+	case value <= d:
+		return GradeD
+	}
+	return GradeF
+}
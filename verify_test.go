@@ -0,0 +1,109 @@
+package zxinggo_test
+
+import (
+	"errors"
+	"image"
+	"testing"
+
+	zxinggo "github.com/ericlevine/zxinggo"
+	"github.com/ericlevine/zxinggo/binarizer"
+)
+
+// verifyRender encodes contents, maps black and white to the luminances
+// dark and light, decodes the image and grades it.
+func verifyRender(t *testing.T, contents string, format zxinggo.Format, width, height int, dark, light byte) *zxinggo.Verification {
+	t.Helper()
+	matrix, err := zxinggo.Encode(contents, format, width, height, nil)
+	if err != nil {
+		t.Fatalf("%v: encode error: %v", format, err)
+	}
+	// Render with a border, as not every writer adds a quiet zone.
+	const border = 20
+	img := image.NewGray(image.Rect(0, 0, matrix.Width()+2*border, matrix.Height()+2*border))
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			mx, my := x-border, y-border
+			if mx >= 0 && my >= 0 && mx < matrix.Width() && my < matrix.Height() && matrix.Get(mx, my) {
+				img.Pix[y*img.Stride+x] = dark
+			} else {
+				img.Pix[y*img.Stride+x] = light
+			}
+		}
+	}
+	source := zxinggo.NewGrayImageLuminanceSource(img)
+	bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source))
+	result, err := zxinggo.Decode(bitmap, &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{format}})
+	if err != nil {
+		t.Fatalf("%v: decode error: %v", format, err)
+	}
+	v, err := zxinggo.Verify(source, result)
+	if err != nil {
+		t.Fatalf("%v: verify error: %v", format, err)
+	}
+	return v
+}
+
+func TestVerifyCleanPrints(t *testing.T) {
+	tests := []struct {
+		format        zxinggo.Format
+		contents      string
+		width, height int
+	}{
+		{zxinggo.FormatQRCode, "https://example.com/verify", 200, 200},
+		{zxinggo.FormatCode128, "VERIFY-15416", 400, 80},
+		{zxinggo.FormatEAN13, "5901234123457", 380, 80},
+	}
+	for _, tt := range tests {
+		v := verifyRender(t, tt.contents, tt.format, tt.width, tt.height, 0, 255)
+		if v.Grade != zxinggo.GradeA {
+			t.Errorf("%v: grade %v, want A; parameters %+v", tt.format, v.Grade, v.Parameters)
+		}
+		if _, ok := v.Parameter(zxinggo.ParamSymbolContrast); !ok {
+			t.Errorf("%v: symbol contrast not measured", tt.format)
+		}
+	}
+}
+
+func TestVerifyDataMatrix(t *testing.T) {
+	source := zxinggo.NewImageLuminanceSource(loadTestImage("testdata/blackbox/datamatrix-1/0123456789.png"))
+	bitmap := zxinggo.NewBinaryBitmap(binarizer.NewHybrid(source))
+	result, err := zxinggo.Decode(bitmap, &zxinggo.DecodeOptions{PossibleFormats: []zxinggo.Format{zxinggo.FormatDataMatrix}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := zxinggo.Verify(source, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Grade != zxinggo.GradeA {
+		t.Errorf("grade %v, want A; parameters %+v", v.Grade, v.Parameters)
+	}
+	if _, ok := v.Parameter(zxinggo.ParamAxialNonuniformity); !ok {
+		t.Error("axial non-uniformity not measured")
+	}
+}
+
+func TestVerifyLowContrast(t *testing.T) {
+	v := verifyRender(t, "LOW CONTRAST", zxinggo.FormatQRCode, 200, 200, 60, 200)
+	sc, _ := v.Parameter(zxinggo.ParamSymbolContrast)
+	if sc.Grade != zxinggo.GradeC || v.Grade != zxinggo.GradeC {
+		t.Errorf("symbol contrast %.2f graded %v, overall %v, want C", sc.Value, sc.Grade, v.Grade)
+	}
+	uec, ok := v.Parameter(zxinggo.ParamUnusedErrorCorrection)
+	if !ok || uec.Value != 1 {
+		t.Errorf("unused error correction = %+v, want 1 for an undamaged symbol", uec)
+	}
+
+	v = verifyRender(t, "LOW-CONTRAST", zxinggo.FormatCode128, 400, 80, 60, 200)
+	if v.Grade != zxinggo.GradeC {
+		t.Errorf("Code 128 grade %v, want C; parameters %+v", v.Grade, v.Parameters)
+	}
+}
+
+func TestVerifyUnsupportedFormat(t *testing.T) {
+	source := zxinggo.NewLuminanceSourceFromBytes(make([]byte, 100), 10, 10, 10)
+	r := zxinggo.NewResult("", nil, nil, zxinggo.FormatMaxiCode)
+	if _, err := zxinggo.Verify(source, r); !errors.Is(err, zxinggo.ErrFormat) {
+		t.Errorf("Verify of MaxiCode = %v, want ErrFormat", err)
+	}
+}